@@ -0,0 +1,165 @@
+package cwalk
+
+import "time"
+
+const (
+	// defaultTuneInterval is how often the tuner samples the walk.
+	defaultTuneInterval = 100 * time.Millisecond
+
+	// ioBoundLatency is the average syscall latency above which the walk is
+	// considered IO-bound, so that more workers overlap more requests.
+	// Cached metadata on local disks answers in a few microseconds; network
+	// and parallel filesystems take hundreds.
+	ioBoundLatency = 100 * time.Microsecond
+)
+
+// SetAutoWorkers enables automatic tuning of the worker count. The walk
+// starts with the number of workers passed to NewWalker and periodically
+// adds workers, up to maxWorkers, while branches queue up and syscalls are
+// slow enough that more workers overlap more IO. Idle workers are retired,
+// down to one, once the queues run dry. A growth step that raised syscall
+// latency without raising throughput is undone and becomes the new maximum,
+// since the filesystem is saturated at that point.
+//
+// maxWorkers <= 0 disables tuning, which is the default.
+func (c *Walker) SetAutoWorkers(maxWorkers int) {
+	c.maxWorkers = maxWorkers
+}
+
+// tuneSample describes the walk during one tuning interval.
+type tuneSample struct {
+	workers int           // Running workers
+	idle    int           // Workers parked for lack of work
+	queued  int           // Branches waiting in worker queues
+	ops     int64         // Syscalls completed during the interval
+	latency time.Duration // Average syscall latency during the interval
+}
+
+// autoTuner decides the target worker count from successive samples.
+type autoTuner struct {
+	min, max int
+	prev     tuneSample
+	grew     bool // Whether the previous decision added workers
+}
+
+// next returns the target worker count for the sample.
+func (t *autoTuner) next(s tuneSample) int {
+	target := s.workers
+	switch {
+	case t.grew && t.prev.ops > 0 && s.ops*10 < t.prev.ops*11 && s.latency*2 > t.prev.latency*3:
+		// Less than 10% more throughput for 50% more latency
+		target = t.prev.workers
+		t.max = t.prev.workers
+	case s.idle == 0 && s.queued >= s.workers && s.latency >= ioBoundLatency:
+		target = s.workers + (s.workers+3)/4
+	case s.idle > 0 && s.queued == 0:
+		target = s.workers - (s.idle+1)/2
+	}
+
+	if target > t.max {
+		target = t.max
+	}
+	if target < t.min {
+		target = t.min
+	}
+
+	t.grew = target > s.workers
+	t.prev = s
+	return target
+}
+
+// autoTune samples the walk every tuneInterval and adjusts the worker count
+// until stop is closed or no work is left.
+func (c *Walker) autoTune(stop <-chan struct{}) {
+	tuner := &autoTuner{min: 1, max: c.maxWorkers}
+	if tuner.max < c.numWorkers {
+		tuner.max = c.numWorkers
+	}
+
+	ticker := time.NewTicker(c.tuneInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		s := tuneSample{ops: c.ioOps.Swap(0)}
+		if nanos := c.ioNanos.Swap(0); s.ops > 0 {
+			s.latency = time.Duration(nanos / s.ops)
+		}
+		for _, worker := range c.workerList() {
+			s.queued += worker.queueLen()
+		}
+
+		c.schedMu.Lock()
+		if c.finished() {
+			c.schedMu.Unlock()
+			return
+		}
+		s.workers, s.idle = c.active, c.idle
+		c.target = tuner.next(s)
+		for c.active < c.target {
+			c.addWorker()
+		}
+		shrink := c.active > c.target
+		c.schedMu.Unlock()
+
+		// Wake parked workers so the surplus can retire
+		if shrink {
+			c.schedCond.Broadcast()
+		}
+	}
+}
+
+// addWorker starts an additional worker. The caller must hold schedMu and
+// the walk must not be finished, so that the wait group is still in use.
+func (c *Walker) addWorker() {
+	c.workerMu.Lock()
+	worker := &walkWorker{id: c.nextWorkerID, walker: c, order: c.order}
+	c.nextWorkerID++
+	workers := make([]*walkWorker, len(c.workers), len(c.workers)+1)
+	copy(workers, c.workers)
+	c.workers = append(workers, worker)
+	c.workerMu.Unlock()
+
+	c.active++
+	c.wg.Add(1)
+	go c.startWorker(worker)
+}
+
+// retire reports whether the worker should exit because the tuner lowered
+// the target worker count. Only workers with an empty queue retire, and
+// queues are only pushed to by their owner or, for roots, under schedMu,
+// so no queued branch is stranded.
+func (c *Walker) retire(worker *walkWorker) bool {
+	if c.maxWorkers <= 0 || worker.queueLen() > 0 {
+		return false
+	}
+
+	c.schedMu.Lock()
+	defer c.schedMu.Unlock()
+	if c.active <= c.target || worker.queueLen() > 0 {
+		return false
+	}
+	c.active--
+
+	c.workerMu.Lock()
+	workers := make([]*walkWorker, 0, len(c.workers)-1)
+	for _, w := range c.workers {
+		if w != worker {
+			workers = append(workers, w)
+		}
+	}
+	c.workers = workers
+	c.workerMu.Unlock()
+	return true
+}
+
+// retireDue reports whether more workers are running than the tuner wants.
+// The caller must hold schedMu.
+func (c *Walker) retireDue() bool {
+	return c.maxWorkers > 0 && c.active > c.target
+}
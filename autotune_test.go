@@ -0,0 +1,116 @@
+package cwalk
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestAutoTunerNext verifies the worker count decisions for typical samples.
+func TestAutoTunerNext(t *testing.T) {
+	slow := 2 * ioBoundLatency
+	fast := ioBoundLatency / 10
+
+	tests := []struct {
+		name   string
+		sample tuneSample
+		want   int
+	}{
+		{"grow when IO-bound with backlog", tuneSample{workers: 4, queued: 10, ops: 1000, latency: slow}, 5},
+		{"grow by a quarter", tuneSample{workers: 16, queued: 100, ops: 1000, latency: slow}, 20},
+		{"no growth when CPU-bound", tuneSample{workers: 4, queued: 10, ops: 1000, latency: fast}, 4},
+		{"no growth without backlog", tuneSample{workers: 4, queued: 2, ops: 1000, latency: slow}, 4},
+		{"shrink idle workers", tuneSample{workers: 8, idle: 4, ops: 10, latency: slow}, 6},
+		{"keep idle workers with queued work", tuneSample{workers: 8, idle: 4, queued: 3, ops: 10}, 8},
+		{"never below one", tuneSample{workers: 1, idle: 1}, 1},
+		{"never above max", tuneSample{workers: 30, queued: 100, ops: 1000, latency: slow}, 32},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tuner := &autoTuner{min: 1, max: 32}
+			if got := tuner.next(tt.sample); got != tt.want {
+				t.Errorf("next(%+v) = %d, want %d", tt.sample, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestAutoTunerUndoesSaturatedGrowth verifies that growth which raised
+// latency without raising throughput is reverted and caps further growth.
+func TestAutoTunerUndoesSaturatedGrowth(t *testing.T) {
+	tuner := &autoTuner{min: 1, max: 64}
+	slow := 2 * ioBoundLatency
+
+	if got := tuner.next(tuneSample{workers: 8, queued: 50, ops: 1000, latency: slow}); got != 10 {
+		t.Fatalf("first step = %d, want 10", got)
+	}
+	if got := tuner.next(tuneSample{workers: 10, queued: 50, ops: 1020, latency: 2 * slow}); got != 8 {
+		t.Fatalf("saturated step = %d, want 8", got)
+	}
+	if got := tuner.next(tuneSample{workers: 8, queued: 50, ops: 1000, latency: slow}); got != 8 {
+		t.Errorf("step after saturation = %d, want 8 (capped)", got)
+	}
+}
+
+// TestWalkAutoWorkersAddAndRetire grows and shrinks the worker pool while a
+// walk is running and verifies that every entry is still visited once.
+func TestWalkAutoWorkersAddAndRetire(t *testing.T) {
+	tmpDir := t.TempDir()
+	const numDirs, filesPerDir = 50, 5
+	for i := 0; i < numDirs; i++ {
+		dir := filepath.Join(tmpDir, fmt.Sprintf("d%02d", i))
+		if err := os.Mkdir(dir, 0755); err != nil {
+			t.Fatalf("failed to create dir: %v", err)
+		}
+		for j := 0; j < filesPerDir; j++ {
+			if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("f%d", j)), nil, 0600); err != nil {
+				t.Fatalf("failed to create file: %v", err)
+			}
+		}
+	}
+
+	for run := 0; run < 10; run++ {
+		var walker *Walker
+		var files, dirs int64
+		callbacks := Callbacks{
+			OnFileOrSymlink: func(relPath string, entry os.DirEntry) {
+				atomic.AddInt64(&files, 1)
+			},
+			OnDirectory: func(relPath string, entry os.DirEntry) {
+				n := atomic.AddInt64(&dirs, 1)
+
+				// Act as the tuner: grow early in the walk, shrink later
+				walker.schedMu.Lock()
+				switch n {
+				case 5:
+					walker.target = 8
+					for walker.active < walker.target {
+						walker.addWorker()
+					}
+				case 30:
+					walker.target = 1
+				}
+				walker.schedMu.Unlock()
+				walker.schedCond.Broadcast()
+			},
+		}
+
+		walker = NewWalker(tmpDir, 2, callbacks)
+		walker.SetAutoWorkers(8)
+		walker.tuneInterval = time.Millisecond
+		if err := walker.Run(); err != nil {
+			t.Fatalf("Walk failed: %v", err)
+		}
+
+		if files != numDirs*filesPerDir || dirs != numDirs {
+			t.Fatalf("run %d: visited %d files and %d dirs, want %d and %d",
+				run, files, dirs, numDirs*filesPerDir, numDirs)
+		}
+		if walker.pending != 0 {
+			t.Fatalf("run %d: %d branches still pending after Run", run, walker.pending)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package cwalk
+
+import (
+	"fmt"
+	"os"
+)
+
+// Backend selects how the walker fetches per-entry metadata.
+type Backend int
+
+const (
+	// BackendLstat reuses metadata from the directory read where possible
+	// and calls lstat(2) otherwise. This is the default.
+	BackendLstat Backend = iota
+	// BackendStatx calls statx(2) for every entry; see SetStatx.
+	BackendStatx
+	// BackendIOUring is experimental. It submits the statx calls for all
+	// entries of a directory as one batch through io_uring, so many
+	// metadata requests are in flight at once per worker. This mostly helps
+	// on high-latency filesystems such as NFS or Lustre.
+	//
+	// Directory reads stay synchronous, since mainline kernels have no
+	// io_uring getdents operation. If io_uring is unavailable (non-Linux,
+	// old kernel, or disabled via kernel.io_uring_disabled or seccomp),
+	// the walker logs a warning and falls back to BackendStatx.
+	BackendIOUring
+)
+
+// ioringEntries is the submission queue size of each worker's io_uring.
+// Larger directories are submitted in chunks of this size.
+const ioringEntries = 256
+
+// String returns the backend name as accepted by ParseBackend.
+func (b Backend) String() string {
+	switch b {
+	case BackendLstat:
+		return "lstat"
+	case BackendStatx:
+		return "statx"
+	case BackendIOUring:
+		return "iouring"
+	default:
+		return fmt.Sprintf("Backend(%d)", int(b))
+	}
+}
+
+// ParseBackend parses a backend name: lstat, statx or iouring.
+func ParseBackend(s string) (Backend, error) {
+	for _, b := range []Backend{BackendLstat, BackendStatx, BackendIOUring} {
+		if s == b.String() {
+			return b, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown backend: %s", s)
+}
+
+// SetBackend selects the metadata backend. The statx and io_uring backends
+// request the fields set with SetStatx, or all fields if no mask was set.
+// Calling SetStatx with a non-zero mask implies at least BackendStatx.
+func (c *Walker) SetBackend(b Backend) {
+	c.backend = b
+}
+
+// statxFields returns the statx field mask to request.
+func (c *Walker) statxFields() StatxMask {
+	if c.statxMask == 0 {
+		return StatxAll
+	}
+	return c.statxMask
+}
+
+// statResult holds the outcome of one metadata request.
+type statResult struct {
+	info os.FileInfo
+	err  error
+}
+
+// ioRing returns the worker's io_uring, creating it on first use. It
+// returns nil if the io_uring backend is not selected or could not be set
+// up, in which case the caller uses synchronous calls instead.
+func (w *walkWorker) ioRing() *ioURing {
+	if w.walker.backend != BackendIOUring || w.walker.fsys != nil || w.ringErr != nil {
+		return nil
+	}
+	if w.ring == nil {
+		w.ring, w.ringErr = newIOURing(ioringEntries)
+		if w.ringErr != nil {
+			err := w.ringErr
+			w.walker.ringFallback.Do(func() {
+				w.walker.log().Warn("io_uring unavailable, falling back to statx", "err", err)
+			})
+			return nil
+		}
+	}
+	return w.ring
+}
+
+// statBatch fetches metadata for all paths through the worker's io_uring.
+// It returns nil if io_uring is not in use; if the ring fails, it is
+// disabled for the rest of the walk and nil is returned as well.
+func (w *walkWorker) statBatch(paths []string) []statResult {
+	ring := w.ioRing()
+	if ring == nil {
+		return nil
+	}
+
+	start := w.walker.acquireIO(len(paths))
+	results, err := ring.statxBatch(paths, w.walker.statxFields())
+	w.walker.releaseIO(start)
+	if err != nil {
+		w.walker.log().Warn("io_uring failed, falling back to statx", "err", err)
+		w.ringErr = err
+		w.closeRing()
+		return nil
+	}
+	return results
+}
+
+// closeRing releases the worker's io_uring, if any.
+func (w *walkWorker) closeRing() {
+	if w.ring != nil {
+		w.ring.close()
+		w.ring = nil
+	}
+}
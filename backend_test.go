@@ -0,0 +1,105 @@
+package cwalk
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// TestParseBackend verifies round-tripping of backend names.
+func TestParseBackend(t *testing.T) {
+	for _, b := range []Backend{BackendLstat, BackendStatx, BackendIOUring} {
+		got, err := ParseBackend(b.String())
+		if err != nil || got != b {
+			t.Errorf("ParseBackend(%q) = %v, %v; want %v", b.String(), got, err, b)
+		}
+	}
+
+	if _, err := ParseBackend("aio"); err == nil {
+		t.Error("expected error for unknown backend")
+	}
+}
+
+// TestWalkBackendsAgree verifies that every backend reports the same
+// metadata. The directory is larger than the io_uring submission queue so
+// batches are split into several chunks. On systems without io_uring the
+// walker falls back to statx, so the test passes either way.
+func TestWalkBackendsAgree(t *testing.T) {
+	tmpDir := t.TempDir()
+	big := filepath.Join(tmpDir, "big")
+	if err := os.Mkdir(big, 0755); err != nil {
+		t.Fatalf("failed to create dir: %v", err)
+	}
+	for i := 0; i < ioringEntries+10; i++ {
+		data := make([]byte, i%17)
+		if err := os.WriteFile(filepath.Join(big, fmt.Sprintf("f%04d", i)), data, 0644); err != nil {
+			t.Fatalf("failed to create file: %v", err)
+		}
+	}
+	if err := os.Symlink("big/f0001", filepath.Join(tmpDir, "link")); err != nil {
+		t.Fatalf("failed to create symlink: %v", err)
+	}
+
+	walk := func(b Backend) map[string]string {
+		var mu sync.Mutex
+		seen := map[string]string{}
+		callbacks := Callbacks{
+			OnLstat: func(isDir bool, relPath string, fileInfo os.FileInfo, err error) {
+				if err != nil {
+					t.Errorf("%v: OnLstat got error for %q: %v", b, relPath, err)
+					return
+				}
+				mu.Lock()
+				defer mu.Unlock()
+				seen[relPath] = fmt.Sprintf("%d %v %d", fileInfo.Size(), fileInfo.Mode(), fileInfo.ModTime().UnixNano())
+			},
+		}
+
+		walker := NewWalker(tmpDir, 2, callbacks)
+		walker.SetBackend(b)
+		if err := walker.Run(); err != nil {
+			t.Fatalf("%v: Walk failed: %v", b, err)
+		}
+		return seen
+	}
+
+	want := walk(BackendLstat)
+	if len(want) != ioringEntries+13 {
+		t.Fatalf("lstat walk saw %d entries, want %d", len(want), ioringEntries+13)
+	}
+	for _, b := range []Backend{BackendStatx, BackendIOUring} {
+		got := walk(b)
+		if len(got) != len(want) {
+			t.Errorf("%v: saw %d entries, want %d", b, len(got), len(want))
+		}
+		for path, meta := range want {
+			if got[path] != meta {
+				t.Errorf("%v: %s = %q, want %q", b, path, got[path], meta)
+			}
+		}
+	}
+}
+
+// TestIOURingMissingPath verifies that per-entry errors are reported
+// like lstat reports them.
+func TestIOURingMissingPath(t *testing.T) {
+	ring, err := newIOURing(ioringEntries)
+	if err != nil {
+		t.Skipf("io_uring unavailable: %v", err)
+	}
+	defer ring.close()
+
+	tmpDir := t.TempDir()
+	results, err := ring.statxBatch([]string{filepath.Join(tmpDir, "missing"), tmpDir}, StatxAll)
+	if err != nil {
+		t.Fatalf("statxBatch failed: %v", err)
+	}
+	if !os.IsNotExist(results[0].err) {
+		t.Errorf("expected not-exist error, got %v", results[0].err)
+	}
+	if results[1].err != nil || !results[1].info.IsDir() {
+		t.Errorf("expected directory info, got %v, %v", results[1].info, results[1].err)
+	}
+}
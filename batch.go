@@ -0,0 +1,130 @@
+package cwalk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+)
+
+// SetReadDirBatch makes the walker read directories in batches of at most
+// n entries and process each batch before reading the next, instead of
+// reading each directory completely first. Memory then stays bounded by
+// the batch size even for directories with tens of millions of entries.
+// Batches are reported through Callbacks.OnReadDirBatch, and OnReadDir is
+// not called.
+//
+// Entries come in the order the file system lists them rather than sorted
+// by name; with SetInodeOrder, each batch is sorted by inode number. n <= 0
+// reads whole directories, which is the default.
+func (c *Walker) SetReadDirBatch(n int) {
+	c.readDirBatch = n
+}
+
+// dirFile is an open directory read in batches. *os.File and the
+// directories of most fs.FS implementations satisfy it.
+type dirFile interface {
+	ReadDir(n int) ([]fs.DirEntry, error)
+	Close() error
+}
+
+// openDir opens the directory at path for reading in batches.
+func (c *Walker) openDir(path string) (dirFile, error) {
+	if c.fsys == nil {
+		if c.inodeOrder {
+			return openInodeDir(path)
+		}
+		return os.Open(path)
+	}
+
+	f, err := c.fsys.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	if dir, ok := f.(fs.ReadDirFile); ok {
+		return dir, nil
+	}
+	f.Close()
+
+	// Read the whole directory of file systems without batch reads
+	entries, err := fs.ReadDir(c.fsys, path)
+	if err != nil {
+		return nil, err
+	}
+	return &entryList{entries: entries}, nil
+}
+
+// entryList serves entries read in one go as batches.
+type entryList struct {
+	entries []fs.DirEntry
+}
+
+func (l *entryList) ReadDir(n int) ([]fs.DirEntry, error) {
+	if n <= 0 || n > len(l.entries) {
+		n = len(l.entries)
+	}
+	if n == 0 {
+		return nil, io.EOF
+	}
+	batch := l.entries[:n]
+	l.entries = l.entries[n:]
+	return batch, nil
+}
+
+func (l *entryList) Close() error { return nil }
+
+// processBatches reads the directory of branch in batches and processes
+// each batch before reading the next (see SetReadDirBatch).
+func (w *walkWorker) processBatches(branch *walkBranch, absPath, relPath string) error {
+	c := w.walker
+	onBatch := c.callbacks.OnReadDirBatch
+
+	c.acquireFD()
+	defer c.releaseFD()
+	start := c.acquireIO(1)
+	dir, err := c.openDir(absPath)
+	c.releaseIO(start)
+	if err != nil {
+		if onBatch != nil {
+			onBatch(relPath, nil, err)
+		}
+		return fmt.Errorf("readdir failed for '%s': %w", absPath, err)
+	}
+	defer dir.Close()
+
+	total := 0
+	for {
+		start := c.acquireIO(1)
+		entries, err := dir.ReadDir(c.readDirBatch)
+		c.releaseIO(start)
+		c.chargeReadDir(entries)
+		if errors.Is(err, io.EOF) {
+			err = nil
+			if len(entries) == 0 {
+				break
+			}
+		}
+		if onBatch != nil {
+			onBatch(relPath, entries, err)
+		}
+		if err != nil {
+			return fmt.Errorf("readdir failed for '%s': %w", absPath, err)
+		}
+
+		total += len(entries)
+		if err := w.processEntries(branch, absPath, relPath, entries); err != nil {
+			return err
+		}
+	}
+
+	if onBatch != nil {
+		onBatch(relPath, nil, nil)
+	}
+	if log := c.log(); log.Enabled(context.Background(), slog.LevelDebug) {
+		log.Debug("read directory", "path", absPath, "entries", total)
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package cwalk
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"testing/fstest"
+)
+
+func TestReadDirBatch(t *testing.T) {
+	tmpDir := t.TempDir()
+	for i := 0; i < 25; i++ {
+		if err := os.WriteFile(filepath.Join(tmpDir, strconv.Itoa(i)), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(tmpDir, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "sub", "file"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, inodeOrder := range []bool{false, true} {
+		var mu sync.Mutex
+		batches := map[string][]int{}
+		done := map[string]int{}
+		seen := 0
+		walker := NewWalker(tmpDir, 2, Callbacks{
+			OnReadDir: func(relPath string, entries []os.DirEntry, err error) {
+				t.Errorf("OnReadDir called for %q in batch mode", relPath)
+			},
+			OnReadDirBatch: func(relPath string, entries []os.DirEntry, err error) {
+				mu.Lock()
+				defer mu.Unlock()
+				if err != nil {
+					t.Errorf("OnReadDirBatch got error for %q: %v", relPath, err)
+				}
+				if len(entries) == 0 {
+					done[relPath]++
+					return
+				}
+				batches[relPath] = append(batches[relPath], len(entries))
+			},
+			OnLstat: func(isDir bool, relPath string, info os.FileInfo, err error) {
+				mu.Lock()
+				seen++
+				mu.Unlock()
+			},
+		})
+		walker.SetReadDirBatch(10)
+		walker.SetInodeOrder(inodeOrder)
+		if err := walker.Run(); err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+
+		if got := batches[""]; len(got) != 3 || got[0] != 10 || got[1] != 10 || got[2] != 6 {
+			t.Errorf("inodeOrder=%v: root batches = %v, want [10 10 6]", inodeOrder, got)
+		}
+		if got := batches["sub"]; len(got) != 1 || got[0] != 1 {
+			t.Errorf("inodeOrder=%v: sub batches = %v, want [1]", inodeOrder, got)
+		}
+		if done[""] != 1 || done["sub"] != 1 {
+			t.Errorf("inodeOrder=%v: final calls = %v, want one per directory", inodeOrder, done)
+		}
+		if seen != 28 {
+			t.Errorf("inodeOrder=%v: got %d lstat calls, want 28", inodeOrder, seen)
+		}
+	}
+}
+
+func TestReadDirBatchFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a":     {},
+		"b":     {},
+		"c":     {},
+		"d/e":   {},
+		"d/f/g": {},
+	}
+
+	var mu sync.Mutex
+	total := 0
+	walker := NewWalkerFS(fsys, ".", 2, Callbacks{
+		OnReadDirBatch: func(relPath string, entries []os.DirEntry, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			if len(entries) > 2 {
+				t.Errorf("batch of %d entries for %q, want at most 2", len(entries), relPath)
+			}
+			total += len(entries)
+		},
+	})
+	walker.SetReadDirBatch(2)
+	if err := walker.Run(); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if total != 7 {
+		t.Errorf("got %d entries in batches, want 7", total)
+	}
+}
@@ -0,0 +1,17 @@
+package cwalk
+
+import (
+	"os"
+	"time"
+)
+
+// BirthTime returns the creation time of an entry from the os.FileInfo
+// passed to OnLstat. It reports false if the time is unknown.
+//
+// macOS, FreeBSD, NetBSD and Windows report the birth time with lstat. On
+// Linux it is only available through statx, so the walk must use SetStatx
+// with StatxBtime or a statx-based backend, and the filesystem must record
+// it.
+func BirthTime(fi os.FileInfo) (time.Time, bool) {
+	return birthTime(fi)
+}
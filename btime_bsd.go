@@ -0,0 +1,23 @@
+//go:build darwin || freebsd || netbsd
+
+package cwalk
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+// birthTime returns st_birthtime. Filesystems without birth times report
+// zero or -1 seconds.
+func birthTime(fi os.FileInfo) (time.Time, bool) {
+	st, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok {
+		return time.Time{}, false
+	}
+	sec, nsec := st.Birthtimespec.Unix()
+	if sec < 0 || (sec == 0 && nsec == 0) {
+		return time.Time{}, false
+	}
+	return time.Unix(sec, nsec), true
+}
@@ -0,0 +1,17 @@
+//go:build linux
+
+package cwalk
+
+import (
+	"os"
+	"time"
+)
+
+// birthTime returns the birth time reported by statx. lstat(2) does not
+// report it on Linux.
+func birthTime(fi os.FileInfo) (time.Time, bool) {
+	if sfi, ok := fi.(*statxFileInfo); ok && !sfi.btime.IsZero() {
+		return sfi.btime, true
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,13 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !windows
+
+package cwalk
+
+import (
+	"os"
+	"time"
+)
+
+// birthTime is not supported on this platform.
+func birthTime(fi os.FileInfo) (time.Time, bool) {
+	return time.Time{}, false
+}
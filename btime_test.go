@@ -0,0 +1,41 @@
+package cwalk
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// TestBirthTime verifies that the birth time of a new file is reported
+// with statx, and only when requested on Linux.
+func TestBirthTime(t *testing.T) {
+	start := time.Now().Add(-time.Second) // Allow for coarse filesystem clocks
+	path := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(path, []byte("data"), 0644); err != nil {
+		t.Fatalf("failed to create file: %v", err)
+	}
+
+	info, err := statx(path, StatxAll)
+	if err != nil {
+		t.Fatalf("statx failed: %v", err)
+	}
+	btime, ok := BirthTime(info)
+	if !ok {
+		t.Skip("filesystem does not report birth times")
+	}
+	if btime.Before(start) || btime.After(time.Now()) {
+		t.Errorf("birth time %v not within the test run (started %v)", btime, start)
+	}
+
+	if runtime.GOOS == "linux" {
+		info, err = statx(path, StatxMtime)
+		if err != nil {
+			t.Fatalf("statx failed: %v", err)
+		}
+		if _, ok := BirthTime(info); ok {
+			t.Error("birth time reported although not requested")
+		}
+	}
+}
@@ -0,0 +1,18 @@
+//go:build windows
+
+package cwalk
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+// birthTime returns the creation time from the file attribute data.
+func birthTime(fi os.FileInfo) (time.Time, bool) {
+	d, ok := fi.Sys().(*syscall.Win32FileAttributeData)
+	if !ok || d.CreationTime.Nanoseconds() == 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(0, d.CreationTime.Nanoseconds()), true
+}
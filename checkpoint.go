@@ -0,0 +1,114 @@
+package cwalk
+
+import (
+	"strings"
+	"time"
+)
+
+// SetCheckpoint makes Run call fn every interval with the paths of the
+// directories queued but not read yet, relative to the root. All workers
+// are paused between directories while fn runs, so the entries reported
+// through the callbacks so far and the queued directories form a
+// consistent state: a walk started with SetResume on these directories
+// reports exactly the entries not reported yet. An error returned by fn is
+// logged and the walk continues.
+//
+// interval <= 0 or a nil fn disables checkpoints, which is the default.
+func (c *Walker) SetCheckpoint(interval time.Duration, fn func(pending []string) error) {
+	c.checkpointEvery, c.checkpointFn = interval, fn
+}
+
+// SetResume makes Run read the given directories, relative to the root,
+// instead of starting at the root, to continue a walk from the directories
+// passed to a SetCheckpoint function. The directories themselves are not
+// reported through OnLstat again, except for the root ("").
+func (c *Walker) SetResume(pending []string) {
+	c.resume = pending
+}
+
+// resumeBranch returns the branch of a directory queued by an interrupted
+// walk.
+func resumeBranch(relPath string) *walkBranch {
+	branch := &walkBranch{}
+	if relPath == "" {
+		return branch
+	}
+	for _, name := range strings.Split(relPath, "/") {
+		branch = &walkBranch{parent: branch, basename: name}
+	}
+	branch.resumed = true
+	return branch
+}
+
+// checkpoints calls the checkpoint function every checkpointEvery until
+// stop is closed.
+func (c *Walker) checkpoints(stop <-chan struct{}) {
+	ticker := time.NewTicker(c.checkpointEvery)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			c.checkpoint()
+		}
+	}
+}
+
+// checkpoint pauses the workers once they are between directories, passes
+// the queued directories to the checkpoint function and resumes the walk.
+func (c *Walker) checkpoint() {
+	c.schedMu.Lock()
+	c.paused = true
+	for c.busy > 0 {
+		c.schedCond.Wait()
+	}
+	c.schedMu.Unlock()
+
+	var pending []string
+	for _, worker := range c.workerList() {
+		worker.mu.Lock()
+		for _, branch := range worker.queue {
+			pending = append(pending, branch.relPath())
+		}
+		worker.mu.Unlock()
+	}
+	if err := c.checkpointFn(pending); err != nil {
+		c.log().Error("writing checkpoint failed", "err", err)
+	}
+
+	c.schedMu.Lock()
+	c.paused = false
+	c.schedMu.Unlock()
+	c.schedCond.Broadcast()
+}
+
+// enterBranch waits while a checkpoint is being taken and then counts the
+// worker as busy until leaveBranch. Without checkpoints it does nothing.
+func (c *Walker) enterBranch() {
+	if c.checkpointFn == nil {
+		return
+	}
+	c.schedMu.Lock()
+	for c.paused {
+		c.schedCond.Wait()
+	}
+	c.busy++
+	c.schedMu.Unlock()
+}
+
+// leaveBranch counts the worker as no longer busy and wakes a pending
+// checkpoint once no worker is.
+func (c *Walker) leaveBranch() {
+	if c.checkpointFn == nil {
+		return
+	}
+	c.schedMu.Lock()
+	c.busy--
+	wake := c.paused && c.busy == 0
+	c.schedMu.Unlock()
+	if wake {
+		c.schedCond.Broadcast()
+	}
+}
@@ -0,0 +1,91 @@
+package cwalk
+
+import (
+	"os"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestCheckpointResume interrupts a walk at its first checkpoint and checks
+// that resuming from the checkpointed directories reports exactly the
+// entries not reported before it.
+func TestCheckpointResume(t *testing.T) {
+	tmpDir := setupLargeTestDir(t, 100, 200)
+
+	walk := func(resume []string, checkpoint func(seen map[string]bool, pending []string)) map[string]bool {
+		var mu sync.Mutex
+		seen := map[string]bool{}
+		walker := NewWalker(tmpDir, 4, Callbacks{
+			OnLstat: func(isDir bool, relPath string, info os.FileInfo, err error) {
+				mu.Lock()
+				seen[relPath] = true
+				mu.Unlock()
+			},
+			OnReadDir: func(relPath string, entries []os.DirEntry, err error) {
+				time.Sleep(200 * time.Microsecond)
+			},
+		})
+		if resume != nil {
+			walker.SetResume(resume)
+		}
+		if checkpoint != nil {
+			walker.SetCheckpoint(time.Millisecond, func(pending []string) error {
+				mu.Lock()
+				defer mu.Unlock()
+				checkpoint(seen, pending)
+				return nil
+			})
+		}
+		if err := walker.Run(); err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		return seen
+	}
+
+	want := walk(nil, nil)
+
+	var before map[string]bool
+	var pending []string
+	walk(nil, func(seen map[string]bool, queued []string) {
+		if before == nil {
+			before = map[string]bool{}
+			for relPath := range seen {
+				before[relPath] = true
+			}
+			pending = append([]string{}, queued...)
+		}
+	})
+	if before == nil {
+		t.Fatal("no checkpoint was taken")
+	}
+
+	after := walk(pending, nil)
+	for relPath := range after {
+		if before[relPath] {
+			t.Errorf("%q reported before and after the checkpoint", relPath)
+		}
+		before[relPath] = true
+	}
+	var missing []string
+	for relPath := range want {
+		if !before[relPath] {
+			missing = append(missing, relPath)
+		}
+	}
+	sort.Strings(missing)
+	if len(missing) > 0 || len(before) != len(want) {
+		t.Errorf("resumed walk reported %d entries, want %d; missing %v", len(before), len(want), missing)
+	}
+}
+
+func TestResumeBranch(t *testing.T) {
+	if branch := resumeBranch(""); !branch.isRoot() || branch.resumed {
+		t.Errorf("resumeBranch(\"\") = %+v, want a root to lstat", branch)
+	}
+	branch := resumeBranch("a/b/c")
+	if got := branch.relPath(); got != "a/b/c" || !branch.resumed {
+		t.Errorf("resumeBranch(\"a/b/c\") has path %q, resumed %v", got, branch.resumed)
+	}
+}
@@ -0,0 +1,396 @@
+package cmd
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/otuschhoff/cwalk/pkg/output"
+	"github.com/otuschhoff/cwalk/pkg/stat"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// Archive options
+	archiveFilters     filterOptions
+	archiveOr          []string
+	archiveNot         []string
+	archiveOutput      string
+	archiveCompression string
+	archiveWorkers     string
+)
+
+// archiveCmd walks the given paths and writes the matching entries to a
+// tar archive.
+var archiveCmd = &cobra.Command{
+	Use:   "archive --output <file> <paths...>",
+	Short: "Write entries matching the filters to a tar archive, optionally compressed",
+	Long: `Archive walks the given paths and streams the entries that match the
+filter flags into a tar archive, such as everything older than two years
+owned by a given user.
+
+Entries are stored under their full path without the leading slash, like
+tar does, with their permissions, owners and modification times. Matching
+directories are stored without their contents, which are only included if
+they match as well; symlinks are stored as symlinks, and further links to
+a file already stored become hard links.
+
+The archive is compressed according to the extension of --output: .tar.gz
+and .tgz with gzip, .tar.zst and .tzst with zstd, which requires the zstd
+command. --compression overrides the choice, and is needed with "--output -",
+which writes the archive to standard output.
+
+Examples:
+  cwalk archive --mtime-older 2y --username alice --output alice-old.tar.zst /home/alice
+  cwalk archive --name-glob '*.log' --output logs.tar.gz /var/log/app
+  cwalk archive --type file --output - --compression gzip /srv/data | ssh backup 'cat > data.tgz'`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runArchive,
+}
+
+// init registers the archive command and its flags.
+func init() {
+	archiveFilters.register(archiveCmd.Flags())
+	archiveCmd.Flags().StringArrayVar(&archiveOr, "or", nil,
+		"Alternative group of filter flags; entries matching the other filter flags or any group are archived (repeatable)")
+	archiveCmd.Flags().StringArrayVar(&archiveNot, "not", nil,
+		"Group of filter flags; entries matching any group are left out (repeatable)")
+	archiveCmd.Flags().StringVarP(&archiveOutput, "output", "o", "",
+		"Archive file to write, or - for standard output (required)")
+	archiveCmd.Flags().StringVar(&archiveCompression, "compression", "auto",
+		"Compression: auto (from the --output extension), none, gzip, zstd")
+	archiveCmd.Flags().StringVar(&archiveWorkers, "workers", "4",
+		"Number of parallel workers for walking, or \"auto\" to tune based on syscall latency and queue depth")
+	archiveCmd.MarkFlagRequired("output")
+
+	rootCmd.AddCommand(archiveCmd)
+}
+
+// runArchive walks the paths given as arguments and writes the matching
+// entries to the archive.
+func runArchive(cmd *cobra.Command, args []string) error {
+	compression, err := archiveCompressionFor(archiveOutput, archiveCompression)
+	if err != nil {
+		return err
+	}
+
+	filters, filterSets, err := buildFilters(&archiveFilters, archiveOr, archiveNot)
+	if err != nil {
+		return err
+	}
+	results, _, err := walkFiltered(args, filters, filterSets, archiveWorkers)
+	if err != nil {
+		return err
+	}
+	infos := archiveEntries(results, archiveOutput)
+
+	counts, err := createArchive(archiveOutput, compression, infos, os.Stderr)
+	if err != nil {
+		return err
+	}
+
+	// Standard output may hold the archive itself
+	fmt.Fprintf(os.Stderr, "Archived %s (%s)\n", counts.describe(), output.FormatBytes(counts.bytes))
+	if counts.skipped > 0 {
+		fmt.Fprintf(os.Stderr, "Skipped %d devices, sockets and pipes\n", counts.skipped)
+	}
+	if counts.failed > 0 {
+		return fmt.Errorf("%d entries could not be archived", counts.failed)
+	}
+	return nil
+}
+
+// createArchive writes the entries to the archive file at path, or to
+// standard output for "-", compressed with compression. Entries that
+// cannot be read are reported on errs and counted as failed.
+func createArchive(path, compression string, infos []stat.FileInfo, errs io.Writer) (*archiveCounts, error) {
+	var out io.WriteCloser = os.Stdout
+	if path != "-" {
+		f, err := os.Create(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create archive: %w", err)
+		}
+		defer f.Close()
+		out = f
+	}
+	cw, err := compressWriter(out, compression)
+	if err != nil {
+		return nil, err
+	}
+
+	counts, err := writeArchive(cw, infos, errs)
+	if closeErr := cw.Close(); err == nil {
+		err = closeErr
+	}
+	if out != os.Stdout {
+		if closeErr := out.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to write archive: %w", err)
+	}
+	return counts, nil
+}
+
+// archiveCompressionFor resolves the compression to use for the output
+// file name. "auto" picks it from the extension; standard output has no
+// extension to go by and is not compressed.
+func archiveCompressionFor(outputPath, compression string) (string, error) {
+	switch compression {
+	case "none", "gzip", "zstd":
+		return compression, nil
+	case "auto":
+	default:
+		return "", fmt.Errorf("invalid --compression: %s (valid: auto, none, gzip, zstd)", compression)
+	}
+
+	name := strings.ToLower(outputPath)
+	switch {
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		return "gzip", nil
+	case strings.HasSuffix(name, ".tar.zst"), strings.HasSuffix(name, ".tzst"):
+		return "zstd", nil
+	}
+	return "none", nil
+}
+
+// compressWriter wraps w to compress what is written to it. Closing the
+// returned writer flushes the compressed stream but does not close w.
+func compressWriter(w io.Writer, compression string) (io.WriteCloser, error) {
+	switch compression {
+	case "gzip":
+		return gzip.NewWriter(w), nil
+	case "zstd":
+		return newZstdWriter(w)
+	}
+	return nopWriteCloser{w}, nil
+}
+
+// nopWriteCloser is a writer whose Close does nothing.
+type nopWriteCloser struct {
+	io.Writer
+}
+
+// Close implements io.Closer.
+func (nopWriteCloser) Close() error { return nil }
+
+// zstdWriter compresses with the zstd command, which writes the compressed
+// stream to the underlying writer.
+type zstdWriter struct {
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+}
+
+// newZstdWriter starts the zstd command writing to w.
+func newZstdWriter(w io.Writer) (*zstdWriter, error) {
+	if _, err := exec.LookPath("zstd"); err != nil {
+		return nil, fmt.Errorf("zstd compression requires the zstd command: %w", err)
+	}
+	cmd := exec.Command("zstd", "-q", "-c", "-T0")
+	cmd.Stdout = w
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	return &zstdWriter{cmd: cmd, stdin: stdin}, nil
+}
+
+// Write implements io.Writer.
+func (z *zstdWriter) Write(p []byte) (int, error) {
+	return z.stdin.Write(p)
+}
+
+// Close ends the input of the zstd command and waits for it to finish.
+func (z *zstdWriter) Close() error {
+	err := z.stdin.Close()
+	if waitErr := z.cmd.Wait(); waitErr != nil {
+		return fmt.Errorf("zstd: %w", waitErr)
+	}
+	return err
+}
+
+// archiveEntries returns the entries of results to archive, sorted by
+// path. Entries inside archives and the output file itself are left out.
+func archiveEntries(results *stat.Results, outputPath string) []stat.FileInfo {
+	outPath, _ := filepath.Abs(outputPath)
+	var infos []stat.FileInfo
+	for _, fi := range results.AllFileInfos {
+		if fi.Archive != "" {
+			continue
+		}
+		if path, err := filepath.Abs(fi.FullPath()); err == nil && path == outPath {
+			continue
+		}
+		infos = append(infos, fi)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].FullPath() < infos[j].FullPath() })
+	return infos
+}
+
+// archiveCounts counts the entries written to an archive.
+type archiveCounts struct {
+	files    int64 // Regular files with their contents
+	links    int64 // Hard links to files stored earlier
+	symlinks int64 // Symbolic links
+	dirs     int64 // Directories
+	bytes    int64 // File contents
+	skipped  int64 // Devices, sockets and pipes
+	failed   int64 // Entries that could not be read
+}
+
+// describe summarizes the counts, such as "3 files, 0 hard links, 1
+// symlinks and 2 directories".
+func (c *archiveCounts) describe() string {
+	return fmt.Sprintf("%d files, %d hard links, %d symlinks and %d directories", c.files, c.links, c.symlinks, c.dirs)
+}
+
+// writeArchive writes the entries to w as a tar stream. Entries that
+// vanished or cannot be read are reported on errs and left out; only
+// errors writing the archive are returned. Devices, sockets and pipes are
+// left out as well.
+func writeArchive(w io.Writer, infos []stat.FileInfo, errs io.Writer) (*archiveCounts, error) {
+	counts := &archiveCounts{}
+	tw := tar.NewWriter(w)
+	stored := make(map[stat.FileID]string)
+
+	for i := range infos {
+		path := infos[i].FullPath()
+		name := archiveName(path)
+		if name == "" {
+			continue
+		}
+
+		info, err := os.Lstat(path)
+		if err != nil {
+			counts.failed++
+			fmt.Fprintf(errs, "%v\n", err)
+			continue
+		}
+		mode := info.Mode()
+		if !mode.IsRegular() && !mode.IsDir() && mode&os.ModeSymlink == 0 {
+			counts.skipped++
+			continue
+		}
+
+		var target string
+		if mode&os.ModeSymlink != 0 {
+			if target, err = os.Readlink(path); err != nil {
+				counts.failed++
+				fmt.Fprintf(errs, "%v\n", err)
+				continue
+			}
+		}
+		hdr, err := tar.FileInfoHeader(info, target)
+		if err != nil {
+			counts.failed++
+			fmt.Fprintf(errs, "%s: %v\n", path, err)
+			continue
+		}
+		hdr.Name = name
+		if mode.IsDir() {
+			hdr.Name += "/"
+		}
+
+		// Store further links to a file as hard links to the first one
+		id := infos[i].ID
+		if mode.IsRegular() && infos[i].Links > 1 && id != (stat.FileID{}) {
+			if first, ok := stored[id]; ok {
+				hdr.Typeflag = tar.TypeLink
+				hdr.Linkname = first
+				hdr.Size = 0
+				if err := tw.WriteHeader(hdr); err != nil {
+					return counts, err
+				}
+				counts.links++
+				continue
+			}
+			stored[id] = name
+		}
+
+		switch {
+		case mode.IsDir():
+			if err := tw.WriteHeader(hdr); err != nil {
+				return counts, err
+			}
+			counts.dirs++
+		case mode&os.ModeSymlink != 0:
+			if err := tw.WriteHeader(hdr); err != nil {
+				return counts, err
+			}
+			counts.symlinks++
+		default:
+			n, err := writeArchiveFile(tw, hdr, path, errs)
+			if err != nil {
+				return counts, err
+			}
+			if n < 0 {
+				counts.failed++
+				delete(stored, id)
+				continue
+			}
+			counts.files++
+			counts.bytes += n
+		}
+	}
+	return counts, tw.Close()
+}
+
+// writeArchiveFile writes a regular file with header hdr. If the file
+// cannot be opened it is reported on errs and left out, and -1 is
+// returned. A file that shrank since hdr was taken is padded with zeros
+// and one that grew is cut off, since the header is written before the
+// contents.
+func writeArchiveFile(tw *tar.Writer, hdr *tar.Header, path string, errs io.Writer) (int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		fmt.Fprintf(errs, "%v\n", err)
+		return -1, nil
+	}
+	defer f.Close()
+
+	if err := tw.WriteHeader(hdr); err != nil {
+		return 0, err
+	}
+	n, err := io.CopyN(tw, f, hdr.Size)
+	if err == io.EOF {
+		fmt.Fprintf(errs, "%s: file shrank while being archived\n", path)
+		_, err = io.CopyN(tw, zeroReader{}, hdr.Size-n)
+	}
+	return hdr.Size, err
+}
+
+// zeroReader reads zeros.
+type zeroReader struct{}
+
+// Read implements io.Reader.
+func (zeroReader) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
+
+// archiveName returns the name of the entry at path in an archive: the
+// cleaned path with slashes, without a volume name, leading slashes or
+// leading ".." elements, like tar stores paths that would otherwise be
+// extracted outside the current directory.
+func archiveName(path string) string {
+	path = filepath.Clean(path)
+	path = filepath.ToSlash(strings.TrimPrefix(path, filepath.VolumeName(path)))
+	for {
+		path = strings.TrimLeft(path, "/")
+		if path != ".." && !strings.HasPrefix(path, "../") {
+			return path
+		}
+		path = path[len(".."):]
+	}
+}
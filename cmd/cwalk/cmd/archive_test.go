@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/otuschhoff/cwalk/pkg/stat"
+)
+
+func TestArchiveCompressionFor(t *testing.T) {
+	tests := []struct {
+		output, compression, want string
+	}{
+		{"old.tar.zst", "auto", "zstd"},
+		{"old.TZST", "auto", "zstd"},
+		{"logs.tar.gz", "auto", "gzip"},
+		{"logs.tgz", "auto", "gzip"},
+		{"plain.tar", "auto", "none"},
+		{"-", "auto", "none"},
+		{"-", "gzip", "gzip"},
+		{"old.tar.zst", "none", "none"},
+	}
+	for _, tt := range tests {
+		if got, err := archiveCompressionFor(tt.output, tt.compression); err != nil || got != tt.want {
+			t.Errorf("archiveCompressionFor(%q, %q) = %q, %v, want %q", tt.output, tt.compression, got, err, tt.want)
+		}
+	}
+	if _, err := archiveCompressionFor("a.tar", "xz"); err == nil {
+		t.Error("unknown compression should fail")
+	}
+}
+
+func TestArchiveName(t *testing.T) {
+	tests := map[string]string{
+		"/srv/data/a.txt": "srv/data/a.txt",
+		"data/./b":        "data/b",
+		"../../etc/x":     "etc/x",
+		"/../x":           "x",
+		"..data/y":        "..data/y",
+		"/":               "",
+	}
+	for path, want := range tests {
+		if got := archiveName(filepath.FromSlash(path)); got != want {
+			t.Errorf("archiveName(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestWriteArchive(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks need privileges")
+	}
+	root := t.TempDir()
+	if err := os.Mkdir(filepath.Join(root, "sub"), 0o750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "sub", "a.txt"), []byte("hello"), 0o640); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Link(filepath.Join(root, "sub", "a.txt"), filepath.Join(root, "hard.txt")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("sub/a.txt", filepath.Join(root, "link")); err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(root, "out.tar")
+	if err := os.WriteFile(out, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := stat.NewStatsWalker([]string{root}, 2, &stat.Filters{}).Walk()
+	if err != nil {
+		t.Fatalf("walk failed: %v", err)
+	}
+	infos := archiveEntries(results, out)
+	if len(infos) != 5 {
+		t.Fatalf("got %d entries, want 5 without the output file", len(infos))
+	}
+
+	var buf, errs bytes.Buffer
+	counts, err := writeArchive(&buf, infos, &errs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if counts.files != 1 || counts.links != 1 || counts.symlinks != 1 || counts.dirs != 2 || counts.bytes != 5 || counts.failed != 0 {
+		t.Errorf("got %s, %d bytes, %d failed: %s", counts.describe(), counts.bytes, counts.failed, errs.String())
+	}
+
+	prefix := archiveName(root) + "/"
+	got := make(map[string]*tar.Header)
+	tr := tar.NewReader(&buf)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		got[hdr.Name] = hdr
+		if hdr.Name == prefix+"hard.txt" {
+			data, _ := io.ReadAll(tr)
+			if string(data) != "hello" {
+				t.Errorf("hard.txt = %q", data)
+			}
+		}
+	}
+	if hdr := got[prefix+"hard.txt"]; hdr == nil || hdr.Typeflag != tar.TypeReg || hdr.Mode&0o777 != 0o640 {
+		t.Errorf("hard.txt header = %+v", hdr)
+	}
+	if hdr := got[prefix+"sub/a.txt"]; hdr == nil || hdr.Typeflag != tar.TypeLink || hdr.Linkname != prefix+"hard.txt" {
+		t.Errorf("sub/a.txt header = %+v", hdr)
+	}
+	if hdr := got[prefix+"link"]; hdr == nil || hdr.Typeflag != tar.TypeSymlink || hdr.Linkname != "sub/a.txt" {
+		t.Errorf("link header = %+v", hdr)
+	}
+	if hdr := got[prefix+"sub/"]; hdr == nil || hdr.Typeflag != tar.TypeDir {
+		t.Errorf("sub/ header = %+v", hdr)
+	}
+}
@@ -0,0 +1,325 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/otuschhoff/cwalk/pkg/output"
+	"github.com/otuschhoff/cwalk/pkg/stat"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// Clean options
+	cleanFilters   filterOptions
+	cleanOr        []string
+	cleanNot       []string
+	cleanOlderThan string
+	cleanDelete    bool
+	cleanDirs      bool
+	cleanLog       string
+	cleanWorkers   string
+)
+
+// cleanCmd walks the given paths and removes the entries matching the
+// filters, or only reports what it would remove.
+var cleanCmd = &cobra.Command{
+	Use:   "clean <paths...>",
+	Short: "Remove entries matching the filters (dry run unless --delete is given)",
+	Long: `Clean walks the given paths and removes the files, symlinks and other
+entries that match the filter flags, such as scratch data past its retention
+period.
+
+Without --delete nothing is removed: clean reports how many entries it would
+remove and how much space that would free. Directories are only removed with
+--dirs, and only once they are empty; clean never removes a directory with
+its contents. The scanned paths themselves and entries inside archives are
+never removed, and at least one filter flag or --older-than is required.
+
+--older-than is a retention period: entries modified within it are kept
+whatever the other filters say. --log appends a line per entry to a
+deletion log, so removals can be audited later.
+
+Examples:
+  cwalk clean --older-than 90d /scratch
+  cwalk clean --older-than 90d --delete --log /var/log/cwalk-clean.log /scratch
+  cwalk clean --name-glob '*.tmp' --dirs --delete /srv/build`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runClean,
+}
+
+// init registers the clean command and its flags.
+func init() {
+	cleanFilters.register(cleanCmd.Flags())
+	cleanCmd.Flags().StringArrayVar(&cleanOr, "or", nil,
+		"Alternative group of filter flags; entries matching the other filter flags or any group are removed (repeatable)")
+	cleanCmd.Flags().StringArrayVar(&cleanNot, "not", nil,
+		"Group of filter flags; entries matching any group are kept (repeatable)")
+	cleanCmd.Flags().StringVar(&cleanOlderThan, "older-than", "",
+		"Retention period: keep entries modified within it (e.g., 90d, 1y)")
+	cleanCmd.Flags().BoolVar(&cleanDelete, "delete", false,
+		"Remove the entries; without it clean only reports what it would remove")
+	cleanCmd.Flags().BoolVar(&cleanDirs, "dirs", false,
+		"Also remove matching directories that are empty once their matching entries are removed")
+	cleanCmd.Flags().StringVar(&cleanLog, "log", "",
+		"Append a line per entry (time, status, size, path) to this deletion log")
+	cleanCmd.Flags().StringVar(&cleanWorkers, "workers", "4",
+		"Number of parallel workers for walking and removing, or \"auto\" to tune the walk based on syscall latency and queue depth")
+
+	rootCmd.AddCommand(cleanCmd)
+}
+
+// runClean walks the paths given as arguments and removes, or reports,
+// the matching entries.
+func runClean(cmd *cobra.Command, args []string) error {
+	filters, filterSets, err := buildCleanFilters(&cleanFilters, cleanOr, cleanNot, cleanOlderThan)
+	if err != nil {
+		return err
+	}
+
+	results, workers, err := walkFiltered(args, filters, filterSets, cleanWorkers)
+	if err != nil {
+		return err
+	}
+
+	var log io.Writer
+	if cleanLog != "" {
+		f, err := os.OpenFile(cleanLog, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return fmt.Errorf("invalid --log: %w", err)
+		}
+		defer f.Close()
+		log = f
+	}
+
+	plan := newCleanPlan(results, cleanDirs)
+	if !cleanDelete {
+		plan.log(log, "planned")
+		fmt.Printf("Would remove %s, freeing %s\n", plan.describe(), output.FormatBytes(plan.bytes))
+		fmt.Println("Nothing was removed; run again with --delete to remove them.")
+		return nil
+	}
+
+	done := plan.execute(workers, log, os.Stderr)
+	fmt.Printf("Removed %s, freeing %s\n", done.describe(), output.FormatBytes(done.bytes))
+	if done.kept > 0 {
+		fmt.Printf("Kept %d directories that were not empty\n", done.kept)
+	}
+	if done.failed > 0 {
+		return fmt.Errorf("%d entries could not be removed", done.failed)
+	}
+	return nil
+}
+
+// cleanItem is an entry to remove.
+type cleanItem struct {
+	fi    stat.FileInfo
+	path  string // Full path
+	frees int64  // Space freed by removing it
+}
+
+// buildCleanFilters builds the filters of the entries clean removes from
+// the filter flags, the --or and --not groups and the retention period,
+// refusing filters that would select every entry.
+func buildCleanFilters(root *filterOptions, orGroups, notGroups []string, olderThan string) (*stat.Filters, []*stat.Filters, error) {
+	filters, sets, err := buildFilters(root, orGroups, notGroups)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Removing everything below the roots is never what was meant
+	if olderThan == "" && selectsEverything(filters) {
+		return nil, nil, fmt.Errorf("clean requires --older-than or at least one filter flag")
+	}
+	if olderThan != "" {
+		retention, err := parseDuration(olderThan)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid --older-than: %w", err)
+		}
+		filters = &stat.Filters{AllOf: []*stat.Filters{filters, {MtimeOlderThan: &retention}}}
+	}
+	return filters, sets, nil
+}
+
+// cleanPlan holds the entries clean removes: files, symlinks and other
+// entries in path order, then directories from the deepest up, so that
+// directories are emptied before they are removed.
+type cleanPlan struct {
+	items []cleanItem
+
+	files    int64 // Regular files
+	symlinks int64 // Symbolic links
+	others   int64 // Other non-directory entries
+	dirs     int64 // Directories
+	bytes    int64 // Space freed, see newCleanPlan
+	kept     int64 // Directories kept because they were not empty
+	failed   int64 // Entries that could not be removed
+}
+
+// newCleanPlan builds the plan for the matched entries of results. Roots
+// and entries inside archives are left out, and directories unless dirs is
+// set. Only the size of non-directory entries counts towards the space
+// freed, and a hard-linked file only counts once, and only if all of its
+// links are removed.
+func newCleanPlan(results *stat.Results, dirs bool) *cleanPlan {
+	plan := &cleanPlan{}
+	links := make(map[stat.FileID]uint64)
+	var dirItems []cleanItem
+	for _, fi := range results.AllFileInfos {
+		if fi.Path == "" || fi.Archive != "" {
+			continue
+		}
+		item := cleanItem{fi: fi, path: fi.FullPath()}
+		if fi.IsDir {
+			if dirs {
+				dirItems = append(dirItems, item)
+			}
+			continue
+		}
+		if fi.Links > 1 && fi.ID != (stat.FileID{}) {
+			links[fi.ID]++
+		}
+		plan.items = append(plan.items, item)
+	}
+
+	sort.Slice(plan.items, func(i, j int) bool { return plan.items[i].path < plan.items[j].path })
+	sort.Slice(dirItems, func(i, j int) bool {
+		di, dj := dirItems[i].fi.Depth(), dirItems[j].fi.Depth()
+		if di != dj {
+			return di > dj
+		}
+		return dirItems[i].path < dirItems[j].path
+	})
+
+	counted := make(map[stat.FileID]bool)
+	for i := range plan.items {
+		item := &plan.items[i]
+		fi := &item.fi
+		if n, ok := links[fi.ID]; ok && fi.Links > 1 {
+			if n < fi.Links || counted[fi.ID] {
+				continue
+			}
+			counted[fi.ID] = true
+		}
+		item.frees = fi.Size
+	}
+
+	plan.items = append(plan.items, dirItems...)
+	for _, item := range plan.items {
+		plan.count(&item)
+	}
+	return plan
+}
+
+// count adds item to the totals of the plan.
+func (p *cleanPlan) count(item *cleanItem) {
+	switch {
+	case item.fi.IsDir:
+		p.dirs++
+	case item.fi.IsSymlink:
+		p.symlinks++
+	case item.fi.Mode.IsRegular():
+		p.files++
+	default:
+		p.others++
+	}
+	p.bytes += item.frees
+}
+
+// describe summarizes the counts of the plan, such as "3 files, 0
+// symlinks, 0 other entries and 1 directories".
+func (p *cleanPlan) describe() string {
+	return fmt.Sprintf("%d files, %d symlinks, %d other entries and %d directories", p.files, p.symlinks, p.others, p.dirs)
+}
+
+// log writes a line per entry of the plan to w, if not nil.
+func (p *cleanPlan) log(w io.Writer, status string) {
+	if w == nil {
+		return
+	}
+	for _, item := range p.items {
+		writeCleanLog(w, status, &item)
+	}
+}
+
+// execute removes the entries of the plan and returns what was removed.
+// Non-directory entries are removed by jobs workers at a time, then
+// directories one by one; directories that are not empty are kept.
+// Failures are reported on errs.
+func (p *cleanPlan) execute(jobs int, log, errs io.Writer) *cleanPlan {
+	done := &cleanPlan{}
+	var mu sync.Mutex
+	record := func(item *cleanItem, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case err == nil:
+			done.count(item)
+			if log != nil {
+				writeCleanLog(log, "removed", item)
+			}
+		case item.fi.IsDir && dirNotEmpty(item.path):
+			done.kept++
+			if log != nil {
+				writeCleanLog(log, "kept", item)
+			}
+		default:
+			done.failed++
+			fmt.Fprintf(errs, "%v\n", err)
+			if log != nil {
+				writeCleanLog(log, "failed", item)
+			}
+		}
+	}
+
+	first := slices.IndexFunc(p.items, func(item cleanItem) bool { return item.fi.IsDir })
+	if first < 0 {
+		first = len(p.items)
+	}
+
+	queue := make(chan *cleanItem)
+	var wg sync.WaitGroup
+	for i := 0; i < jobs; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for item := range queue {
+				record(item, os.Remove(item.path))
+			}
+		}()
+	}
+	for i := range p.items[:first] {
+		queue <- &p.items[i]
+	}
+	close(queue)
+	wg.Wait()
+
+	for i := range p.items[first:] {
+		item := &p.items[first+i]
+		record(item, os.Remove(item.path))
+	}
+	return done
+}
+
+// writeCleanLog writes a deletion log line: the time, the status, the size
+// and the path, separated by tabs.
+func writeCleanLog(w io.Writer, status string, item *cleanItem) {
+	path := strings.NewReplacer("\t", `\t`, "\n", `\n`).Replace(item.path)
+	fmt.Fprintf(w, "%s\t%s\t%d\t%s\n", time.Now().Format(time.RFC3339), status, item.fi.Size, path)
+}
+
+// dirNotEmpty reports whether the directory at path has entries.
+func dirNotEmpty(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	names, _ := f.Readdirnames(1)
+	return len(names) > 0
+}
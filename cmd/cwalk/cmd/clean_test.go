@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/otuschhoff/cwalk/pkg/stat"
+)
+
+func TestNewCleanPlan(t *testing.T) {
+	shared := stat.FileID{Dev: 1, Ino: 10}
+	partial := stat.FileID{Dev: 1, Ino: 20}
+	results := &stat.Results{AllFileInfos: []stat.FileInfo{
+		{Root: "/r", Path: "", IsDir: true, Mode: os.ModeDir | 0755},
+		{Root: "/r", Path: "a", IsDir: true, Mode: os.ModeDir | 0755},
+		{Root: "/r", Path: "a/b", IsDir: true, Mode: os.ModeDir | 0755},
+		{Root: "/r", Path: "a/b/f", Size: 100, Mode: 0644, Links: 1},
+		{Root: "/r", Path: "a/link", Size: 5, Mode: os.ModeSymlink | 0777, IsSymlink: true, Links: 1},
+		{Root: "/r", Path: "h1", Size: 1000, Mode: 0644, Links: 2, ID: shared},
+		{Root: "/r", Path: "h2", Size: 1000, Mode: 0644, Links: 2, ID: shared},
+		{Root: "/r", Path: "p1", Size: 7000, Mode: 0644, Links: 2, ID: partial},
+		{Root: "/r", Path: "t.tar/x", Size: 50, Mode: 0644, Archive: "t.tar"},
+	}}
+
+	plan := newCleanPlan(results, true)
+	var paths []string
+	for _, item := range plan.items {
+		paths = append(paths, item.path)
+	}
+	want := []string{"/r/a/b/f", "/r/a/link", "/r/h1", "/r/h2", "/r/p1", "/r/a/b", "/r/a"}
+	if !slices.Equal(paths, want) {
+		t.Errorf("paths = %q, want %q", paths, want)
+	}
+	// p1 keeps a link outside the plan, so only f, link and one of h1/h2 free space
+	if plan.files != 4 || plan.symlinks != 1 || plan.dirs != 2 || plan.bytes != 1105 {
+		t.Errorf("got %s freeing %d", plan.describe(), plan.bytes)
+	}
+
+	if plan := newCleanPlan(results, false); plan.dirs != 0 || len(plan.items) != 5 {
+		t.Errorf("without dirs got %s", plan.describe())
+	}
+}
+
+func TestCleanPlanExecute(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{"old/empty", "old/full"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, name := range []string{"old/a.tmp", "old/full/keep.txt", "old/full/b.tmp"} {
+		if err := os.WriteFile(filepath.Join(root, name), []byte("data"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	glob, err := stat.CompileGlob("*.tmp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	filters := &stat.Filters{AnyOf: []*stat.Filters{{NameGlob: glob}, {Types: map[string]bool{"dir": true}}}}
+	results, err := stat.NewStatsWalker([]string{root}, 2, filters).Walk()
+	if err != nil {
+		t.Fatalf("walk failed: %v", err)
+	}
+
+	plan := newCleanPlan(results, true)
+	var log, errs bytes.Buffer
+	done := plan.execute(2, &log, &errs)
+	if done.files != 2 || done.dirs != 1 || done.kept != 2 || done.failed != 0 || done.bytes != 8 {
+		t.Errorf("got %s freeing %d, kept %d, failed %d (%s)", done.describe(), done.bytes, done.kept, done.failed, errs.String())
+	}
+
+	for name, exists := range map[string]bool{
+		"old/a.tmp": false, "old/empty": false, "old/full/b.tmp": false,
+		"old/full/keep.txt": true, "old/full": true, "old": true,
+	} {
+		if _, err := os.Lstat(filepath.Join(root, name)); (err == nil) != exists {
+			t.Errorf("%s exists = %v, want %v", name, err == nil, exists)
+		}
+	}
+
+	lines := strings.Split(strings.TrimSpace(log.String()), "\n")
+	if len(lines) != 5 {
+		t.Fatalf("got %d log lines, want 5:\n%s", len(lines), log.String())
+	}
+	if fields := strings.Split(lines[0], "\t"); len(fields) != 4 || fields[1] != "removed" || fields[2] != "4" {
+		t.Errorf("log line = %q", lines[0])
+	}
+	if !strings.Contains(log.String(), "kept\t") {
+		t.Errorf("log does not record kept directories:\n%s", log.String())
+	}
+}
+
+func TestBuildCleanFilters(t *testing.T) {
+	// Only the --or group selects entries
+	filters, _, err := buildCleanFilters(&filterOptions{}, []string{`--name '\.tmp$'`}, nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]bool{"a.tmp": true, "important.db": false, "keep.txt": false} {
+		if got := filters.Matches(&stat.FileInfo{Path: name, Mode: 0o644}); got != want {
+			t.Errorf("Matches(%s) = %v, want %v", name, got, want)
+		}
+	}
+
+	for _, tt := range []struct {
+		name     string
+		root     filterOptions
+		or, not  []string
+		rejected bool
+	}{
+		{"no filters", filterOptions{}, nil, nil, true},
+		{"only --not", filterOptions{}, nil, []string{"--type dir"}, true},
+		{"flag without criterion", filterOptions{selinuxTypes: ","}, nil, nil, true},
+		{"empty --or group", filterOptions{}, []string{"--name ''"}, nil, true},
+		{"filter flag", filterOptions{nameGlob: "*.tmp"}, nil, nil, false},
+		{"--or with --not", filterOptions{}, []string{"--name x"}, []string{"--type dir"}, false},
+	} {
+		_, _, err := buildCleanFilters(&tt.root, tt.or, tt.not, "")
+		if (err != nil) != tt.rejected {
+			t.Errorf("%s: error %v, want rejected %v", tt.name, err, tt.rejected)
+		}
+	}
+	if _, _, err := buildCleanFilters(&filterOptions{}, nil, nil, "90d"); err != nil {
+		t.Errorf("--older-than alone: %v", err)
+	}
+}
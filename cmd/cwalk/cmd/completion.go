@@ -0,0 +1,219 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/otuschhoff/cwalk/pkg/output"
+	"github.com/spf13/cobra"
+)
+
+// completionCmd writes the shell completion script of cwalk. It replaces
+// the default completion command of Cobra to document the installation.
+var completionCmd = &cobra.Command{
+	Use:   "completion bash|zsh|fish|powershell",
+	Short: "Generate the shell completion script",
+	Long: `Completion writes a script to standard output that completes the
+commands, flags, flag values and paths of cwalk in the given shell. Enum
+flags such as --output-format, --output-mode and --type complete their
+values, comma-separated lists one element at a time, --profile and --preset
+the names in the config file, and path arguments the file system.
+
+Load the completions in the current shell:
+  source <(cwalk completion bash)
+  source <(cwalk completion zsh)
+  cwalk completion fish | source
+  cwalk completion powershell | Out-String | Invoke-Expression
+
+Install them for every session:
+  cwalk completion bash > /etc/bash_completion.d/cwalk
+  cwalk completion zsh > "${fpath[1]}/_cwalk"
+  cwalk completion fish > ~/.config/fish/completions/cwalk.fish`,
+	Args:                  cobra.ExactArgs(1),
+	ValidArgs:             []string{"bash", "zsh", "fish", "powershell"},
+	DisableFlagsInUseLine: true,
+	RunE:                  runCompletion,
+}
+
+// init registers the completion command.
+func init() {
+	rootCmd.AddCommand(completionCmd)
+}
+
+// runCompletion writes the completion script of the shell given as
+// argument.
+func runCompletion(cmd *cobra.Command, args []string) error {
+	switch args[0] {
+	case "bash":
+		return rootCmd.GenBashCompletionV2(os.Stdout, true)
+	case "zsh":
+		return rootCmd.GenZshCompletion(os.Stdout)
+	case "fish":
+		return rootCmd.GenFishCompletion(os.Stdout, true)
+	case "powershell":
+		return rootCmd.GenPowerShellCompletionWithDesc(os.Stdout)
+	default:
+		return fmt.Errorf("unsupported shell %q (available: bash, zsh, fish, powershell)", args[0])
+	}
+}
+
+// completeFunc is the signature of Cobra completion functions.
+type completeFunc = func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective)
+
+// registerCompletions registers the completions of flag values and
+// arguments. It runs once all commands have registered their flags.
+func registerCompletions() {
+	modes := append(slices.Clone(output.Modes), "all")
+	types := []string{"file", "dir", "symlink", "other"}
+
+	// Flags of the root command
+	flags := map[string]completeFunc{
+		"output-format":   completeValues(false, outputFormats...),
+		"output-mode":     completeValues(true, modes...),
+		"output":          completeOutput,
+		"color":           completeValues(false, "auto\tonly on a terminal", "always", "never"),
+		"table-style":     completeValues(false, "dark\tlight text on dark backgrounds", "light\tdark text on light backgrounds", "plain\tASCII borders without colors", "borderless\tno borders or colors"),
+		"wrap":            completeValues(false, "soft\twrap at word boundaries", "hard\twrap at the width", "truncate"),
+		"units":           completeValues(false, "binary\tKiB, MiB, ...", "si\tkB, MB, ... powers of 1000", "raw\texact byte counts"),
+		"counts":          completeValues(true, "plain\t1234567", "grouped\t1,234,567", "compact\t1.2M"),
+		"group-by":        completeValues(true, "mtime", "btime", "uid", "user", "gid", "group", "year", "ext", "type"),
+		"sort":            completeValues(false, "size", "inodes", "files", "dirs", "avg-file-size", "files-per-dir", "symlink-pct", "name", "year", "mtime", "path", "owner"),
+		"columns":         completeValues(true, "mode", "octal", "links", "uid", "gid", "owner", "group", "size", "mtime", "type", "depth", "path"),
+		"csv-delimiter":   completeValues(false, "comma", "semicolon", "tab", "pipe"),
+		"csv-sizes":       completeValues(false, "human\te.g. 1.5 GB", "raw\tbyte counts", "both\ta byte count column after each size column"),
+		"statx":           completeValues(true, "mode", "size", "mtime", "owner", "ino", "btime", "all"),
+		"backend":         completeValues(false, "lstat", "statx", "iouring\texperimental", "cephfs\tCephFS recursive statistics"),
+		"traversal":       completeValues(false, "dfs\tdepth first", "bfs\tbreadth first", "largest\tlargest directories first"),
+		"workers":         completeValues(false, "auto\ttune the count during the walk"),
+		"history":         completeDirs,
+		"template":        completeFiles("tmpl", "tpl", "gotmpl"),
+		"quota-file":      completeFiles("csv", "yaml", "yml"),
+		"baseline":        completeFiles("json"),
+		"project-markers": completeValues(true, ".project", "CODEOWNERS", ".git", ".hg", ".svn"),
+		"notify-format":   completeValues(false, "json\tJSON document of the output modes", "text\tplain summary", "slack\tSlack-compatible message"),
+		"notify-on":       completeValues(true, "done\tevery completed scan", "quota\ta quota is exceeded", "growth\tsize grew more than --notify-growth"),
+		"log-level":       completeValues(false, "debug\tevery directory read", "info", "warn", "error\tpaths that could not be read"),
+		"log-format":      completeValues(false, "text\tkey=value pairs", "json\tone object per line"),
+	}
+	for name, fn := range flags {
+		_ = rootCmd.RegisterFlagCompletionFunc(name, fn)
+	}
+
+	// Flags shared by several commands
+	var walk func(cmd *cobra.Command)
+	walk = func(cmd *cobra.Command) {
+		if cmd.Flags().Lookup("type") != nil {
+			_ = cmd.RegisterFlagCompletionFunc("type", completeValues(true, types...))
+		}
+		if cmd != rootCmd && cmd.Flags().Lookup("workers") != nil {
+			_ = cmd.RegisterFlagCompletionFunc("workers", flags["workers"])
+		}
+		for _, sub := range cmd.Commands() {
+			walk(sub)
+		}
+	}
+	walk(rootCmd)
+	_ = rootCmd.RegisterFlagCompletionFunc("profile", completeConfigNames(func(cfg *config) []string { return mapKeys(cfg.Profiles) }))
+	_ = rootCmd.RegisterFlagCompletionFunc("preset", completeConfigNames(func(cfg *config) []string { return mapKeys(cfg.Presets) }))
+	_ = archiveCmd.RegisterFlagCompletionFunc("compression", completeValues(false, "auto\tfrom the --output extension", "none", "gzip", "zstd"))
+
+	// Arguments
+	rootCmd.ValidArgsFunction = completePaths
+	historyCompactCmd.ValidArgsFunction = completeDirs
+	homesCmd.ValidArgsFunction = completeDirs
+	policyApplyCmd.ValidArgsFunction = func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 0 {
+			return completeFiles("yaml", "yml")(cmd, args, toComplete)
+		}
+		return completePaths(cmd, args, toComplete)
+	}
+	presetDeleteCmd.ValidArgsFunction = func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return completeConfigNames(func(cfg *config) []string { return mapKeys(cfg.Presets) })(cmd, args, toComplete)
+	}
+	for _, cmd := range []*cobra.Command{cleanCmd, fixPermsCmd, copyCmd, archiveCmd, auditCmd} {
+		cmd.ValidArgsFunction = completePaths
+	}
+}
+
+// completeValues completes the given values, each optionally followed by
+// a tab and a description. For lists, the element after the last comma is
+// completed.
+func completeValues(list bool, values ...string) completeFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		prefix := ""
+		if i := strings.LastIndex(toComplete, ","); list && i >= 0 {
+			prefix = toComplete[:i+1]
+		}
+		completions := make([]string, 0, len(values))
+		for _, value := range values {
+			completions = append(completions, prefix+value)
+		}
+		directive := cobra.ShellCompDirectiveNoFileComp
+		if list {
+			directive |= cobra.ShellCompDirectiveNoSpace
+		}
+		return completions, directive
+	}
+}
+
+// completeOutput completes the FORMAT:PATH values of --output: the format
+// first, then the path.
+func completeOutput(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if strings.Contains(toComplete, ":") {
+		return nil, cobra.ShellCompDirectiveDefault
+	}
+	var completions []string
+	for _, format := range outputFormats {
+		completions = append(completions, format+":")
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveNoSpace
+}
+
+// completeConfigNames completes the names returned by names from the
+// config file.
+func completeConfigNames(names func(cfg *config) []string) completeFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		path, err := configPath()
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		cfg, err := loadConfig(path)
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return names(cfg), cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// completeFiles completes the names of files with the given extensions and
+// of directories.
+func completeFiles(extensions ...string) completeFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return extensions, cobra.ShellCompDirectiveFilterFileExt
+	}
+}
+
+// completeDirs completes directory names.
+func completeDirs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return nil, cobra.ShellCompDirectiveFilterDirs
+}
+
+// completePaths completes the paths to walk with file and directory names.
+func completePaths(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return nil, cobra.ShellCompDirectiveDefault
+}
+
+// mapKeys returns the keys of m, sorted.
+func mapKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestCompleteValues(t *testing.T) {
+	got, directive := completeValues(true, "file", "dir")(nil, nil, "symlink,d")
+	if !slices.Equal(got, []string{"symlink,file", "symlink,dir"}) {
+		t.Errorf("list completions = %v, want the values after the last comma", got)
+	}
+	if directive&cobra.ShellCompDirectiveNoSpace == 0 || directive&cobra.ShellCompDirectiveNoFileComp == 0 {
+		t.Errorf("list directive = %v, want no space and no file completion", directive)
+	}
+
+	got, directive = completeValues(false, "json", "csv")(nil, nil, "j,")
+	if !slices.Equal(got, []string{"json", "csv"}) || directive != cobra.ShellCompDirectiveNoFileComp {
+		t.Errorf("single value completions = %v, %v", got, directive)
+	}
+}
+
+func TestCompleteOutput(t *testing.T) {
+	got, _ := completeOutput(nil, nil, "js")
+	if !slices.Contains(got, "json:") {
+		t.Errorf("completions = %v, want formats followed by a colon", got)
+	}
+	if got, directive := completeOutput(nil, nil, "json:st"); got != nil || directive != cobra.ShellCompDirectiveDefault {
+		t.Errorf("path completions = %v, %v; want file completion", got, directive)
+	}
+}
+
+func TestRegisterCompletions(t *testing.T) {
+	registerCompletions()
+
+	var out bytes.Buffer
+	rootCmd.SetOut(&out)
+	defer rootCmd.SetOut(nil)
+	rootCmd.SetArgs([]string{cobra.ShellCompRequestCmd, "clean", "--type", ""})
+	defer rootCmd.SetArgs(nil)
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(out.String(), "\n"); !slices.Contains(lines, "file") || !slices.Contains(lines, "symlink") {
+		t.Errorf("clean --type completions = %q, want the inode types", out.String())
+	}
+}
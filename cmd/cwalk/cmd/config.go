@@ -0,0 +1,214 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	// Config file options
+	configFile  string
+	profileName string
+	presetName  string
+)
+
+// defaultConfigFile is the config file read without --config, relative to
+// the home directory.
+const defaultConfigFile = ".cwalk.yaml"
+
+// config is the content of a config file: default values of flags keyed by
+// their long names, named profiles of further flag values, and named
+// presets of filter flag values.
+//
+//	workers: 16
+//	table-style: light
+//	not: ["--name \\.git$"]
+//	profiles:
+//	  stale:
+//	    type: file
+//	    mtime-older: 90d
+//	presets:
+//	  stale-logs:
+//	    type: file
+//	    name: \.log$
+//	    mtime-older: 90d
+type config struct {
+	Flags    map[string]any            `yaml:",inline"`
+	Profiles map[string]map[string]any `yaml:"profiles"`
+	Presets  map[string]map[string]any `yaml:"presets"`
+}
+
+// init registers the config file flags, which apply to every command.
+func init() {
+	rootCmd.PersistentFlags().StringVar(&configFile, "config", "",
+		"Config file with default flag values and profiles (default: ~/"+defaultConfigFile+")")
+	rootCmd.PersistentFlags().StringVar(&profileName, "profile", "",
+		"Apply the flag values of a profile from the config file")
+	rootCmd.PersistentFlags().StringVar(&presetName, "preset", "",
+		"Apply the filters of a preset saved with \"cwalk preset save\"")
+
+	rootCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		if err := applyConfig(cmd); err != nil {
+			return err
+		}
+		return setupLogging()
+	}
+}
+
+// configPath returns the path of the config file: the one given with
+// --config, or else the default one in the home directory.
+func configPath() (string, error) {
+	if configFile != "" {
+		return configFile, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("no config file: %w", err)
+	}
+	return filepath.Join(home, defaultConfigFile), nil
+}
+
+// applyConfig sets the flags of cmd not given on the command line from the
+// config file: to the filters of the preset selected with --preset, the
+// values of the profile selected with --profile, or else to the defaults
+// of the file, in this order. A missing default config file is not an
+// error.
+func applyConfig(cmd *cobra.Command) error {
+	needed := profileName != "" || presetName != ""
+	path, err := configPath()
+	if err != nil {
+		if needed {
+			return err
+		}
+		return nil
+	}
+
+	cfg, err := loadConfig(path)
+	if errors.Is(err, os.ErrNotExist) && configFile == "" && !needed {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var layers []map[string]any
+	if presetName != "" {
+		if cmd.Flags().Lookup("type") == nil {
+			return fmt.Errorf("--preset only applies to commands with filter flags")
+		}
+		preset, ok := cfg.Presets[presetName]
+		if !ok {
+			return fmt.Errorf("unknown preset %q in %s", presetName, path)
+		}
+		layers = append(layers, preset)
+	}
+	if profileName != "" {
+		profile, ok := cfg.Profiles[profileName]
+		if !ok {
+			return fmt.Errorf("unknown profile %q in %s", profileName, path)
+		}
+		layers = append(layers, profile)
+	}
+	layers = append(layers, cfg.Flags)
+
+	set := map[string]bool{}
+	for _, values := range layers {
+		if err := applyFlagValues(cmd.Flags(), values, set); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// loadConfig reads and parses a config file, checking that every key names
+// a flag of some command.
+func loadConfig(path string) (*config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	var cfg config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+
+	names := flagNames(rootCmd)
+	check := func(values map[string]any, where string) error {
+		for name := range values {
+			if !slices.Contains(names, name) {
+				return fmt.Errorf("invalid config file %s: unknown flag %q%s", path, name, where)
+			}
+			if name == "config" || name == "profile" || name == "preset" {
+				return fmt.Errorf("invalid config file %s: %q cannot be set in a config file", path, name)
+			}
+		}
+		return nil
+	}
+	if err := check(cfg.Flags, ""); err != nil {
+		return nil, err
+	}
+	for name, profile := range cfg.Profiles {
+		if err := check(profile, " in profile "+name); err != nil {
+			return nil, err
+		}
+	}
+	filterNames := filterFlagNames()
+	for name, preset := range cfg.Presets {
+		for flag := range preset {
+			if !slices.Contains(filterNames, flag) {
+				return nil, fmt.Errorf("invalid config file %s: %q in preset %s is not a filter flag", path, flag, name)
+			}
+		}
+	}
+	return &cfg, nil
+}
+
+// applyFlagValues sets the flags named in values that are neither given on
+// the command line nor in set, and adds them to set. Values for flags of
+// other commands are ignored. Lists set repeatable flags once per element.
+func applyFlagValues(flags *pflag.FlagSet, values map[string]any, set map[string]bool) error {
+	for name, value := range values {
+		flag := flags.Lookup(name)
+		if flag == nil || flag.Changed || set[name] {
+			continue
+		}
+		set[name] = true
+
+		elems, ok := value.([]any)
+		if !ok {
+			elems = []any{value}
+		}
+		if len(elems) > 1 && !strings.HasSuffix(flag.Value.Type(), "Slice") && !strings.HasSuffix(flag.Value.Type(), "Array") {
+			return fmt.Errorf("flag %q takes a single value", name)
+		}
+		for _, elem := range elems {
+			if err := flag.Value.Set(fmt.Sprint(elem)); err != nil {
+				return fmt.Errorf("invalid value %v for flag %q: %w", elem, name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// flagNames returns the long names of the flags of cmd and its
+// subcommands.
+func flagNames(cmd *cobra.Command) []string {
+	var names []string
+	add := func(flag *pflag.Flag) {
+		names = append(names, flag.Name)
+	}
+	cmd.Flags().VisitAll(add)
+	cmd.PersistentFlags().VisitAll(add)
+	for _, sub := range cmd.Commands() {
+		names = append(names, flagNames(sub)...)
+	}
+	return names
+}
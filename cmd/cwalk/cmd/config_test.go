@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+func TestLoadConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cwalk.yaml")
+	data := `workers: 16
+table-style: light
+not: ["--name \\.git$", "--type symlink"]
+profiles:
+  stale:
+    type: file
+    mtime-older: 90d
+`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := loadConfig(path)
+	if err != nil {
+		t.Fatalf("loadConfig: %v", err)
+	}
+	if len(cfg.Flags) != 3 || cfg.Flags["workers"] != 16 {
+		t.Errorf("flags = %v, want workers, table-style and not", cfg.Flags)
+	}
+	if profile := cfg.Profiles["stale"]; len(profile) != 2 || profile["mtime-older"] != "90d" {
+		t.Errorf("profile stale = %v", profile)
+	}
+
+	for _, data := range []string{"wokers: 16\n", "profiles:\n  p:\n    bogus: 1\n", "profile: stale\n", "workers: [\n"} {
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := loadConfig(path); err == nil {
+			t.Errorf("loadConfig should fail for %q", data)
+		}
+	}
+}
+
+func TestApplyFlagValues(t *testing.T) {
+	var workers int
+	var style string
+	var not []string
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.IntVar(&workers, "workers", 4, "")
+	flags.StringVar(&style, "table-style", "dark", "")
+	flags.StringSliceVar(&not, "not", []string{"default"}, "")
+	if err := flags.Parse([]string{"--workers", "2"}); err != nil {
+		t.Fatal(err)
+	}
+
+	set := map[string]bool{}
+	profile := map[string]any{"table-style": "plain"}
+	defaults := map[string]any{"workers": 16, "table-style": "light", "not": []any{"a", "b"}, "archives": true}
+	for _, values := range []map[string]any{profile, defaults} {
+		if err := applyFlagValues(flags, values, set); err != nil {
+			t.Fatalf("applyFlagValues: %v", err)
+		}
+	}
+	if workers != 2 {
+		t.Errorf("workers = %d, command line value 2 should win", workers)
+	}
+	if style != "plain" {
+		t.Errorf("table-style = %q, profile value should win over defaults", style)
+	}
+	if !slices.Equal(not, []string{"a", "b"}) {
+		t.Errorf("not = %v, want the list of the config file", not)
+	}
+
+	if err := applyFlagValues(flags, map[string]any{"table-style": []any{"a", "b"}}, map[string]bool{}); err == nil {
+		t.Error("a list for a single value flag should fail")
+	}
+}
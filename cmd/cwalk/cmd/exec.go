@@ -0,0 +1,231 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/otuschhoff/cwalk/pkg/stat"
+)
+
+// Limits of a single --exec-batch command line. Linux allows at least
+// 128 KiB of arguments to any command, and most tools cope badly with more
+// than a few thousand operands.
+const (
+	execBatchPaths = 4096
+	execBatchBytes = 128 << 10
+)
+
+// execPlaceholders are replaced in --exec and --exec-batch arguments:
+// the path, its base name, its parent directory, and the path and base
+// name without extension, like fd.
+var execPlaceholders = []string{"{}", "{/}", "{//}", "{.}", "{/.}"}
+
+// execSpec is the command template of --exec or --exec-batch.
+type execSpec struct {
+	args  []string // Program and arguments, with placeholders
+	batch bool     // Run once per batch of paths instead of once per path
+	arg   int      // Index of the argument with placeholders (batch only)
+}
+
+// parseExec parses a command template such as "rm {} ;" or "tar -rf
+// out.tar {} +". The template is split like a shell command line, and a
+// trailing ";" (--exec) or "+" (--exec-batch) is optional. Without a
+// placeholder the path is appended as the last argument. In batch mode
+// only one argument may hold placeholders; it is repeated for every path.
+func parseExec(s string, batch bool) (*execSpec, error) {
+	args, err := splitArgs(s)
+	if err != nil {
+		return nil, err
+	}
+	if n := len(args); n > 0 {
+		last := args[n-1]
+		if !batch && (last == ";" || last == `\;`) || batch && last == "+" {
+			args = args[:n-1]
+		}
+	}
+	if len(args) == 0 {
+		return nil, fmt.Errorf("empty command")
+	}
+
+	spec := &execSpec{args: args, batch: batch, arg: -1}
+	for i, arg := range args {
+		if !hasPlaceholder(arg) {
+			continue
+		}
+		if i == 0 {
+			return nil, fmt.Errorf("the command itself cannot be a placeholder")
+		}
+		if batch && spec.arg >= 0 {
+			return nil, fmt.Errorf("only one argument may hold placeholders in batch mode")
+		}
+		spec.arg = i
+	}
+	if spec.arg < 0 {
+		spec.args = append(spec.args, "{}")
+		spec.arg = len(spec.args) - 1
+	}
+	return spec, nil
+}
+
+// hasPlaceholder reports whether arg contains one of execPlaceholders.
+func hasPlaceholder(arg string) bool {
+	for _, p := range execPlaceholders {
+		if strings.Contains(arg, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// expandPlaceholders replaces the placeholders in arg for path.
+func expandPlaceholders(arg, path string) string {
+	base := filepath.Base(path)
+	return strings.NewReplacer(
+		"{}", path,
+		"{/}", base,
+		"{//}", filepath.Dir(path),
+		"{.}", strings.TrimSuffix(path, filepath.Ext(path)),
+		"{/.}", strings.TrimSuffix(base, filepath.Ext(base)),
+	).Replace(arg)
+}
+
+// commands returns the command lines to run for paths: one per path, or
+// one per batch of paths that fits execBatchPaths and execBatchBytes.
+func (s *execSpec) commands(paths []string) [][]string {
+	var cmds [][]string
+	if !s.batch {
+		for _, p := range paths {
+			cmd := make([]string, len(s.args))
+			for i, arg := range s.args {
+				cmd[i] = expandPlaceholders(arg, p)
+			}
+			cmds = append(cmds, cmd)
+		}
+		return cmds
+	}
+
+	var batch []string
+	size := 0
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		cmd := append([]string{}, s.args[:s.arg]...)
+		cmd = append(cmd, batch...)
+		cmds = append(cmds, append(cmd, s.args[s.arg+1:]...))
+		batch, size = nil, 0
+	}
+	for _, p := range paths {
+		arg := expandPlaceholders(s.args[s.arg], p)
+		if len(batch) == execBatchPaths || len(batch) > 0 && size+len(arg)+1 > execBatchBytes {
+			flush()
+		}
+		batch = append(batch, arg)
+		size += len(arg) + 1
+	}
+	flush()
+	return cmds
+}
+
+// execRunner runs commands on a bounded pool of workers. The output of
+// each command is collected and written in one piece, so output of
+// parallel commands does not interleave.
+type execRunner struct {
+	jobs   int       // Commands run at the same time
+	dryRun bool      // Print the commands instead of running them
+	stdout io.Writer // Receives command output and dry-run command lines
+	stderr io.Writer // Receives command errors
+
+	mu sync.Mutex // Serializes writes to stdout and stderr
+}
+
+// run runs cmds and returns an error if any of them failed. In dry-run
+// mode the command lines are printed in order, quoted for a shell.
+func (r *execRunner) run(cmds [][]string) error {
+	if r.dryRun {
+		for _, cmd := range cmds {
+			quoted := make([]string, len(cmd))
+			for i, arg := range cmd {
+				quoted[i] = shellQuote(arg)
+			}
+			fmt.Fprintln(r.stdout, strings.Join(quoted, " "))
+		}
+		return nil
+	}
+
+	var failed atomic.Int64
+	queue := make(chan []string)
+	var wg sync.WaitGroup
+	for i := 0; i < max(r.jobs, 1); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for cmd := range queue {
+				if !r.runOne(cmd) {
+					failed.Add(1)
+				}
+			}
+		}()
+	}
+	for _, cmd := range cmds {
+		queue <- cmd
+	}
+	close(queue)
+	wg.Wait()
+
+	if n := failed.Load(); n > 0 {
+		return fmt.Errorf("%d of %d commands failed", n, len(cmds))
+	}
+	return nil
+}
+
+// runOne runs a single command and writes its output, reporting whether
+// it succeeded.
+func (r *execRunner) runOne(cmd []string) bool {
+	var stdout, stderr bytes.Buffer
+	c := exec.Command(cmd[0], cmd[1:]...)
+	c.Stdout, c.Stderr = &stdout, &stderr
+	err := c.Run()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stdout.Write(stdout.Bytes())
+	r.stderr.Write(stderr.Bytes())
+	if err != nil {
+		fmt.Fprintf(r.stderr, "%s: %v\n", cmd[0], err)
+		return false
+	}
+	return true
+}
+
+// execPaths returns the full paths of the entries to run commands on,
+// sorted. Entries inside archives have no path of their own and are
+// skipped.
+func execPaths(results *stat.Results) []string {
+	paths := make([]string, 0, len(results.AllFileInfos))
+	for _, fi := range results.AllFileInfos {
+		if fi.Archive == "" {
+			paths = append(paths, fi.FullPath())
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// shellQuote quotes s for a POSIX shell unless it only holds characters
+// that need no quoting.
+func shellQuote(s string) string {
+	if s != "" && strings.IndexFunc(s, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./=:,+@%", r))
+	}) < 0 {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
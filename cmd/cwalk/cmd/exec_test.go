@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"bytes"
+	"os/exec"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/otuschhoff/cwalk/pkg/stat"
+)
+
+func TestParseExec(t *testing.T) {
+	tests := []struct {
+		input   string
+		batch   bool
+		want    []string
+		wantErr bool
+	}{
+		{input: "rm {} ;", want: []string{"rm", "{}"}},
+		{input: `rm {} \;`, want: []string{"rm", "{}"}},
+		{input: "chmod g+w", want: []string{"chmod", "g+w", "{}"}},
+		{input: "mv {} {.}.bak", want: []string{"mv", "{}", "{.}.bak"}},
+		{input: "tar -rf out.tar {} +", batch: true, want: []string{"tar", "-rf", "out.tar", "{}"}},
+		{input: "cp -t /backup", batch: true, want: []string{"cp", "-t", "/backup", "{}"}},
+		{input: "cp {} {/}", batch: true, wantErr: true},
+		{input: "{} --help", wantErr: true},
+		{input: ";", wantErr: true},
+		{input: "echo 'open", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			spec, err := parseExec(tt.input, tt.batch)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error mismatch: got %v, want error %v", err, tt.wantErr)
+			}
+			if err == nil && !slices.Equal(spec.args, tt.want) {
+				t.Errorf("got %q, want %q", spec.args, tt.want)
+			}
+		})
+	}
+}
+
+func TestExecCommands(t *testing.T) {
+	paths := []string{"/data/a.txt", "/data/sub/b.tar.gz"}
+
+	spec, err := parseExec("mv {} {//}/{/.}.old ;", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{
+		{"mv", "/data/a.txt", "/data/a.old"},
+		{"mv", "/data/sub/b.tar.gz", "/data/sub/b.tar.old"},
+	}
+	if got := spec.commands(paths); !slices.EqualFunc(got, want, slices.Equal) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	spec, err = parseExec("tar -rf out.tar {} --remove-files +", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = [][]string{{"tar", "-rf", "out.tar", "/data/a.txt", "/data/sub/b.tar.gz", "--remove-files"}}
+	if got := spec.commands(paths); !slices.EqualFunc(got, want, slices.Equal) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestExecBatchLimits(t *testing.T) {
+	spec, err := parseExec("rm", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	paths := make([]string, execBatchPaths+1)
+	for i := range paths {
+		paths[i] = "f"
+	}
+	cmds := spec.commands(paths)
+	if len(cmds) != 2 || len(cmds[0]) != execBatchPaths+1 || len(cmds[1]) != 2 {
+		t.Errorf("got %d batches for %d paths", len(cmds), len(paths))
+	}
+
+	long := strings.Repeat("x", execBatchBytes/3)
+	cmds = spec.commands([]string{long, long, long, long})
+	if len(cmds) != 2 {
+		t.Errorf("got %d batches of long paths, want 2", len(cmds))
+	}
+}
+
+func TestExecRunner(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("no sh")
+	}
+
+	var stdout, stderr bytes.Buffer
+	r := &execRunner{jobs: 2, stdout: &stdout, stderr: &stderr}
+	err := r.run([][]string{
+		{"sh", "-c", "echo one"},
+		{"sh", "-c", "echo two; exit 3"},
+		{"sh", "-c", "echo three"},
+	})
+	if err == nil || err.Error() != "1 of 3 commands failed" {
+		t.Errorf("error = %v", err)
+	}
+	for _, line := range []string{"one\n", "two\n", "three\n"} {
+		if !strings.Contains(stdout.String(), line) {
+			t.Errorf("stdout %q missing %q", stdout.String(), line)
+		}
+	}
+	if !strings.Contains(stderr.String(), "exit status 3") {
+		t.Errorf("stderr = %q", stderr.String())
+	}
+
+	stdout.Reset()
+	r = &execRunner{jobs: 2, dryRun: true, stdout: &stdout, stderr: &stderr}
+	if err := r.run([][]string{{"rm", "/data/it's here", "/data/plain.txt"}}); err != nil {
+		t.Fatal(err)
+	}
+	if want := `rm '/data/it'\''s here' /data/plain.txt` + "\n"; stdout.String() != want {
+		t.Errorf("dry run = %q, want %q", stdout.String(), want)
+	}
+}
+
+func TestExecPaths(t *testing.T) {
+	results := &stat.Results{AllFileInfos: []stat.FileInfo{
+		{Root: "/data", Path: "b"},
+		{Root: "/data", Path: "a.tar"},
+		{Root: "/data", Path: "a.tar/inner", Archive: "a.tar"},
+		{Root: "/data", Path: ""},
+	}}
+	want := []string{"/data", "/data/a.tar", "/data/b"}
+	if got := execPaths(results); !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
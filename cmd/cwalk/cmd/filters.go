@@ -0,0 +1,416 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+
+	cwalk "github.com/otuschhoff/cwalk"
+	"github.com/otuschhoff/cwalk/pkg/sftp"
+	"github.com/otuschhoff/cwalk/pkg/stat"
+	"github.com/spf13/pflag"
+)
+
+// filterOptions holds the values of the filter flags. The root command
+// registers them once, and each --or and --not group registers them again
+// on a flag set of its own.
+type filterOptions struct {
+	types        string
+	mtimeOlder   string
+	mtimeYounger string
+	btimeOlder   string
+	btimeYounger string
+	sizeMin      string
+	sizeMax      string
+	empty        bool
+	nameRegex    string
+	nameGlob     string
+	pathRegex    string
+	usernames    string
+	uids         string
+	groupnames   string
+	gids         string
+	permsHas     string
+	permsNot     string
+	expr         string
+	xattrs       string
+	selinuxTypes string
+}
+
+// register defines the filter flags on flags.
+func (o *filterOptions) register(flags *pflag.FlagSet) {
+	flags.StringVar(&o.types, "type", "",
+		"Filter by inode type: file, dir, symlink, other (comma-separated)")
+	flags.StringVar(&o.mtimeOlder, "mtime-older", "",
+		"Filter files modified older than (e.g., 7d, 2w, 30m, 1y)")
+	flags.StringVar(&o.mtimeYounger, "mtime-younger", "",
+		"Filter files modified younger than (e.g., 1d, 24h)")
+	flags.StringVar(&o.btimeOlder, "btime-older", "",
+		"Filter files created older than (e.g., 7d, 1y); excludes files without a known creation time")
+	flags.StringVar(&o.btimeYounger, "btime-younger", "",
+		"Filter files created younger than (e.g., 1d, 24h); excludes files without a known creation time")
+	flags.StringVar(&o.sizeMin, "size-min", "",
+		"Minimum file size (e.g., 1K, 100M, 1G)")
+	flags.StringVar(&o.sizeMax, "size-max", "",
+		"Maximum file size (e.g., 1K, 100M, 1G)")
+	flags.BoolVar(&o.empty, "empty", false,
+		"Filter zero-byte files and directories without entries")
+	flags.StringVar(&o.nameRegex, "name", "",
+		"Filter by filename regex pattern")
+	flags.StringVar(&o.pathRegex, "path", "",
+		"Filter by regex pattern on the path relative to the scanned root (e.g., '(^|/)cache/')")
+	flags.StringVar(&o.nameGlob, "name-glob", "",
+		"Filter by shell-style filename pattern (e.g., '*.log', 'data_??.{csv,tsv}'); patterns with a / match the relative path, with ** for any depth (e.g., '**/cache/**')")
+	flags.StringVar(&o.usernames, "username", "",
+		"Filter by username (comma-separated)")
+	flags.StringVar(&o.uids, "uid", "",
+		"Filter by UID (comma-separated)")
+	flags.StringVar(&o.groupnames, "groupname", "",
+		"Filter by group name (comma-separated)")
+	flags.StringVar(&o.gids, "gid", "",
+		"Filter by GID (comma-separated)")
+	flags.StringVar(&o.permsHas, "perms-has", "",
+		"Filter by required permission bits, symbolic or octal (e.g., u+r,g+x or u+s or 4000)")
+	flags.StringVar(&o.permsNot, "perms-not", "",
+		"Filter by forbidden permission bits, symbolic or octal (e.g., o+w or 1000)")
+	flags.StringVar(&o.expr, "filter", "",
+		"Filter by expression, e.g. \"size > 1M && (mtime < now-30d || name =~ '\\.log$')\"")
+	flags.StringVar(&o.xattrs, "xattr", "",
+		"Filter by extended attribute name or name=value (e.g., user.backup=exclude), comma-separated; implies --xattrs")
+	flags.StringVar(&o.selinuxTypes, "selinux-type", "",
+		"Filter by SELinux type (e.g., httpd_sys_content_t), comma-separated; implies --selinux")
+}
+
+// build parses the flag values into filters. All criteria must match.
+func (o *filterOptions) build() (*stat.Filters, error) {
+	filters := &stat.Filters{}
+
+	if o.types != "" {
+		filters.Types = parseInodeTypes(o.types)
+	}
+
+	if o.mtimeOlder != "" {
+		older, err := parseDuration(o.mtimeOlder)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --mtime-older: %w", err)
+		}
+		filters.MtimeOlderThan = &older
+	}
+
+	if o.mtimeYounger != "" {
+		younger, err := parseDuration(o.mtimeYounger)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --mtime-younger: %w", err)
+		}
+		filters.MtimeYoungerThan = &younger
+	}
+
+	if o.btimeOlder != "" {
+		older, err := parseDuration(o.btimeOlder)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --btime-older: %w", err)
+		}
+		filters.BtimeOlderThan = &older
+	}
+
+	if o.btimeYounger != "" {
+		younger, err := parseDuration(o.btimeYounger)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --btime-younger: %w", err)
+		}
+		filters.BtimeYoungerThan = &younger
+	}
+
+	if o.sizeMin != "" {
+		sizeMin, err := parseSize(o.sizeMin)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --size-min: %w", err)
+		}
+		filters.SizeMin = &sizeMin
+	}
+
+	if o.sizeMax != "" {
+		sizeMax, err := parseSize(o.sizeMax)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --size-max: %w", err)
+		}
+		filters.SizeMax = &sizeMax
+	}
+
+	filters.Empty = o.empty
+
+	if o.nameRegex != "" {
+		re, err := regexp.Compile(o.nameRegex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --name regex: %w", err)
+		}
+		filters.NameRegex = re
+	}
+
+	if o.pathRegex != "" {
+		re, err := regexp.Compile(o.pathRegex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --path regex: %w", err)
+		}
+		filters.PathRegex = re
+	}
+
+	if o.nameGlob != "" {
+		g, err := stat.CompileGlob(o.nameGlob)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --name-glob: %w", err)
+		}
+		filters.NameGlob = g
+	}
+
+	if o.usernames != "" {
+		filters.Usernames = parseStringList(o.usernames)
+	}
+
+	if o.uids != "" {
+		uids, err := parseUintList(o.uids)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --uid: %w", err)
+		}
+		filters.UIDs = uids
+	}
+
+	if o.groupnames != "" {
+		filters.Groupnames = parseStringList(o.groupnames)
+	}
+
+	if o.gids != "" {
+		gids, err := parseUintList(o.gids)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --gid: %w", err)
+		}
+		filters.GIDs = gids
+	}
+
+	if o.permsHas != "" {
+		perms, err := parsePerms(o.permsHas)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --perms-has: %w", err)
+		}
+		filters.PermsHas = perms
+	}
+
+	if o.permsNot != "" {
+		perms, err := parsePerms(o.permsNot)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --perms-not: %w", err)
+		}
+		filters.PermsNot = perms
+	}
+
+	if o.expr != "" {
+		expr, err := stat.ParseFilterExpr(o.expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --filter: %w", err)
+		}
+		filters.Expr = expr
+	}
+
+	for _, spec := range parseStringList(o.xattrs) {
+		m, err := stat.ParseXattrMatch(spec)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --xattr: %w", err)
+		}
+		filters.Xattrs = append(filters.Xattrs, m)
+	}
+
+	if o.selinuxTypes != "" {
+		filters.SELinuxTypes = parseStringList(o.selinuxTypes)
+	}
+
+	return filters, nil
+}
+
+// buildFilters combines the filters of the root flags with the --or and
+// --not groups: an entry must match the root flags or any --or group, and
+// no --not group. Without root flags, only the --or groups select entries.
+// It also returns every filter set, for checks that apply to all of them.
+func buildFilters(root *filterOptions, orGroups, notGroups []string) (*stat.Filters, []*stat.Filters, error) {
+	filters, err := root.build()
+	if err != nil {
+		return nil, nil, err
+	}
+	sets := []*stat.Filters{filters}
+
+	if len(orGroups) > 0 {
+		// Filters without any flag match everything, which would void the groups
+		var anyOf []*stat.Filters
+		if *root != (filterOptions{}) {
+			anyOf = append(anyOf, filters)
+		}
+		for _, group := range orGroups {
+			g, err := parseFilterGroup(group)
+			if err != nil {
+				return nil, nil, fmt.Errorf("invalid --or %q: %w", group, err)
+			}
+			anyOf = append(anyOf, g)
+			sets = append(sets, g)
+		}
+		filters = &stat.Filters{AnyOf: anyOf}
+	}
+
+	if len(notGroups) > 0 {
+		var excluded []*stat.Filters
+		for _, group := range notGroups {
+			g, err := parseFilterGroup(group)
+			if err != nil {
+				return nil, nil, fmt.Errorf("invalid --not %q: %w", group, err)
+			}
+			excluded = append(excluded, g)
+		}
+		sets = append(sets, excluded...)
+		filters = &stat.Filters{AllOf: []*stat.Filters{filters}, Not: &stat.Filters{AnyOf: excluded}}
+	}
+
+	return filters, sets, nil
+}
+
+// selectsEverything reports whether filters built by buildFilters select
+// every entry but those of the --not groups, because neither filter flags
+// with a criterion nor --or groups were given. Commands that change or
+// remove entries refuse such filters.
+func selectsEverything(filters *stat.Filters) bool {
+	// --not groups wrap the selecting filters
+	if filters.Not != nil && len(filters.AllOf) == 1 {
+		filters = filters.AllOf[0]
+	}
+	return filters.IsEmpty()
+}
+
+// filterNeeds tells which optional metadata a walk must collect for its
+// filters.
+type filterNeeds struct {
+	xattrs  bool // Extended attributes
+	selinux bool // SELinux security contexts
+	btime   bool // Birth times
+	empty   bool // Whether directories have entries
+}
+
+// needsOf returns the metadata required by any of the filter sets.
+func needsOf(sets []*stat.Filters) filterNeeds {
+	var n filterNeeds
+	for _, f := range sets {
+		n.xattrs = n.xattrs || len(f.Xattrs) > 0
+		n.selinux = n.selinux || len(f.SELinuxTypes) > 0
+		n.btime = n.btime || f.BtimeOlderThan != nil || f.BtimeYoungerThan != nil
+		n.empty = n.empty || f.Empty
+	}
+	return n
+}
+
+// walkFiltered walks paths with filters, collecting the metadata that the
+// filter sets need, for commands that act on the matched entries. It also
+// returns the number of workers given by workersFlag.
+func walkFiltered(paths []string, filters *stat.Filters, sets []*stat.Filters, workersFlag string) (*stat.Results, int, error) {
+	if slices.ContainsFunc(paths, sftp.IsURL) {
+		return nil, 0, fmt.Errorf("sftp:// roots are not supported")
+	}
+	workers, maxWorkers, err := parseWorkers(workersFlag)
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid --workers: %w", err)
+	}
+
+	needs := needsOf(sets)
+	walker := stat.NewStatsWalker(paths, workers, filters)
+	walker.SetAutoWorkers(maxWorkers)
+	walker.SetEmptyCheck(needs.empty)
+	walker.SetXattrs(needs.xattrs)
+	walker.SetSELinux(needs.selinux)
+	if needs.btime {
+		walker.SetStatx(cwalk.StatxAll | cwalk.StatxBtime)
+	}
+	results, err := walker.Walk()
+	if err != nil {
+		return nil, 0, err
+	}
+	return results, max(workers, 1), nil
+}
+
+// parseFilterGroup parses a group of filter flags given as one argument,
+// such as "--username alice --type file".
+func parseFilterGroup(s string) (*stat.Filters, error) {
+	args, err := splitArgs(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) == 0 {
+		return nil, fmt.Errorf("empty filter group")
+	}
+
+	var opts filterOptions
+	flags := pflag.NewFlagSet("filter group", pflag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	opts.register(flags)
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+	if flags.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected argument %q", flags.Arg(0))
+	}
+	filters, err := opts.build()
+	if err != nil {
+		return nil, err
+	}
+	if filters.IsEmpty() {
+		return nil, fmt.Errorf("no filter criterion in group")
+	}
+	return filters, nil
+}
+
+// splitArgs splits s into arguments at unquoted whitespace, like a shell.
+// Single and double quotes group words and are removed.
+func splitArgs(s string) ([]string, error) {
+	var args []string
+	var cur strings.Builder
+	inArg := false
+	var quote rune
+	for _, r := range s {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				cur.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inArg {
+				args = append(args, cur.String())
+				cur.Reset()
+				inArg = false
+			}
+		default:
+			cur.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote", quote)
+	}
+	if inArg {
+		args = append(args, cur.String())
+	}
+	return args, nil
+}
+
+// parseExcludePaths compiles the --exclude-path patterns.
+func parseExcludePaths(patterns []string) ([]*stat.Glob, error) {
+	var globs []*stat.Glob
+	for _, pattern := range patterns {
+		g, err := stat.CompileGlob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --exclude-path %q: %w", pattern, err)
+		}
+		globs = append(globs, g)
+	}
+	return globs, nil
+}
@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/otuschhoff/cwalk/pkg/stat"
+)
+
+func TestSplitArgs(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    []string
+		wantErr bool
+	}{
+		{input: "--username alice", want: []string{"--username", "alice"}},
+		{input: "  --type  file\t--uid=0 ", want: []string{"--type", "file", "--uid=0"}},
+		{input: `--name '^a b$' --filter "size > 1M"`, want: []string{"--name", "^a b$", "--filter", "size > 1M"}},
+		{input: `--name ''`, want: []string{"--name", ""}},
+		{input: "", want: nil},
+		{input: "--name 'open", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := splitArgs(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error mismatch: got %v, want error %v", err, tt.wantErr)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildFilters(t *testing.T) {
+	root := &filterOptions{uids: "1000"}
+	filters, sets, err := buildFilters(root, []string{"--gid 2000"}, []string{"--type dir"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sets) != 3 {
+		t.Errorf("got %d filter sets, want 3", len(sets))
+	}
+
+	tests := []struct {
+		name string
+		fi   stat.FileInfo
+		want bool
+	}{
+		{"root flags", stat.FileInfo{Path: "a", UID: 1000, GID: 1}, true},
+		{"or group", stat.FileInfo{Path: "b", UID: 1, GID: 2000}, true},
+		{"neither", stat.FileInfo{Path: "c", UID: 1, GID: 1}, false},
+		{"not group", stat.FileInfo{Path: "d", UID: 1000, IsDir: true}, false},
+	}
+	for _, tt := range tests {
+		if got := filters.Matches(&tt.fi); got != tt.want {
+			t.Errorf("%s: Matches = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	// Without root flags, only the groups select entries
+	filters, _, err = buildFilters(&filterOptions{}, []string{"--name x"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]bool{"x": true, "y": false, "important.db": false} {
+		if got := filters.Matches(&stat.FileInfo{Path: name, Mode: 0o644}); got != want {
+			t.Errorf("--or '--name x': Matches(%s) = %v, want %v", name, got, want)
+		}
+	}
+
+	for _, group := range []string{"", "--bogus", "--uid x", "--type file extra", "--or '--uid 1'", "--name ''"} {
+		if _, _, err := buildFilters(&filterOptions{}, []string{group}, nil); err == nil {
+			t.Errorf("--or %q: expected error", group)
+		}
+	}
+}
+
+func TestParseExcludePaths(t *testing.T) {
+	globs, err := parseExcludePaths([]string{".snapshot", "home/*/cache"})
+	if err != nil {
+		t.Fatalf("parseExcludePaths failed: %v", err)
+	}
+	if len(globs) != 2 || !globs[0].MatchString(".snapshot") || !globs[1].MatchString("home/bob/cache") {
+		t.Errorf("parseExcludePaths = %v", globs)
+	}
+	if _, err := parseExcludePaths([]string{"[a-"}); err == nil {
+		t.Error("expected error for an invalid pattern")
+	}
+}
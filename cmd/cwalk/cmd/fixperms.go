@@ -0,0 +1,460 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/otuschhoff/cwalk/pkg/stat"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// Fix-perms options
+	fixFilters filterOptions
+	fixOr      []string
+	fixNot     []string
+	fixOwner   string
+	fixGroup   string
+	fixMode    string
+	fixDryRun  bool
+	fixAll     bool
+	fixWorkers string
+)
+
+// fixPermsCmd walks the given paths and applies an ownership and
+// permission template to the matching entries.
+var fixPermsCmd = &cobra.Command{
+	Use:   "fix-perms <paths...>",
+	Short: "Apply an owner, group and mode template to entries matching the filters",
+	Long: `Fix-perms walks the given paths and brings the entries that match the
+filter flags in line with an ownership and permission template: --owner and
+--group set the owner and group by name or number, and --mode changes the
+permission bits like chmod, symbolically (g+rw,o-rwx, u=rwX) or in octal.
+Only entries that differ from the template are changed, and the number of
+changes is reported.
+
+Symlinks are changed with lchown and their modes are left alone. With
+--dry-run nothing is changed and each planned change is printed. At least
+one filter flag is required; --all applies the template to every entry,
+including the scanned paths themselves.
+
+Examples:
+  cwalk fix-perms --all --group proj --mode g+rwX,o-rwx /srv/projects/proj
+  cwalk fix-perms --type dir --mode g+s --dry-run /srv/projects
+  cwalk fix-perms --uid 1001 --owner alice /home/alice`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runFixPerms,
+}
+
+// init registers the fix-perms command and its flags.
+func init() {
+	fixFilters.register(fixPermsCmd.Flags())
+	fixPermsCmd.Flags().StringArrayVar(&fixOr, "or", nil,
+		"Alternative group of filter flags; entries matching the other filter flags or any group are changed (repeatable)")
+	fixPermsCmd.Flags().StringArrayVar(&fixNot, "not", nil,
+		"Group of filter flags; entries matching any group are left alone (repeatable)")
+	fixPermsCmd.Flags().StringVar(&fixOwner, "owner", "",
+		"Owner to set, by user name or UID")
+	fixPermsCmd.Flags().StringVar(&fixGroup, "group", "",
+		"Group to set, by group name or GID")
+	fixPermsCmd.Flags().StringVar(&fixMode, "mode", "",
+		"Permission changes like chmod, symbolic (e.g., g+rwX,o-rwx or u=rw,go=r) or octal (e.g., 0640)")
+	fixPermsCmd.Flags().BoolVar(&fixDryRun, "dry-run", false,
+		"Print the changes instead of applying them")
+	fixPermsCmd.Flags().BoolVar(&fixAll, "all", false,
+		"Apply the template to every entry, including the scanned paths, when no filter flag is given")
+	fixPermsCmd.Flags().StringVar(&fixWorkers, "workers", "4",
+		"Number of parallel workers for walking and changing, or \"auto\" to tune the walk based on syscall latency and queue depth")
+
+	rootCmd.AddCommand(fixPermsCmd)
+}
+
+// runFixPerms walks the paths given as arguments and applies the template
+// to the matching entries.
+func runFixPerms(cmd *cobra.Command, args []string) error {
+	tmpl, err := parsePermTemplate(fixOwner, fixGroup, fixMode)
+	if err != nil {
+		return err
+	}
+
+	filters, filterSets, err := buildFixFilters(&fixFilters, fixOr, fixNot, fixAll)
+	if err != nil {
+		return err
+	}
+	results, workers, err := walkFiltered(args, filters, filterSets, fixWorkers)
+	if err != nil {
+		return err
+	}
+
+	changes := tmpl.plan(results.AllFileInfos)
+	if fixDryRun {
+		var counts permCounts
+		for _, c := range changes {
+			fmt.Println(c.describe())
+			counts.add(&c)
+		}
+		counts.unchanged = int64(len(results.AllFileInfos) - len(changes))
+		fmt.Printf("Would change %s\n", counts.describe())
+		return nil
+	}
+
+	counts := applyPermChanges(changes, workers, os.Stderr)
+	counts.unchanged = int64(len(results.AllFileInfos) - len(changes))
+	fmt.Printf("Changed %s\n", counts.describe())
+	if counts.failed > 0 {
+		return fmt.Errorf("%d entries could not be changed", counts.failed)
+	}
+	return nil
+}
+
+// buildFixFilters builds the filters of the entries fix-perms changes from
+// the filter flags and the --or and --not groups, refusing filters that
+// would select every entry unless all is set.
+func buildFixFilters(root *filterOptions, orGroups, notGroups []string, all bool) (*stat.Filters, []*stat.Filters, error) {
+	filters, sets, err := buildFilters(root, orGroups, notGroups)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Changing everything below the roots must be asked for explicitly
+	if !all && selectsEverything(filters) {
+		return nil, nil, fmt.Errorf("fix-perms requires at least one filter flag, or --all to change every entry")
+	}
+	return filters, sets, nil
+}
+
+// permTemplate is the ownership and permission template of fix-perms.
+type permTemplate struct {
+	uid  int        // Owner to set, or -1
+	gid  int        // Group to set, or -1
+	mode []modeTerm // Permission changes, applied in order
+}
+
+// parsePermTemplate parses the --owner, --group and --mode values.
+func parsePermTemplate(owner, group, mode string) (*permTemplate, error) {
+	if owner == "" && group == "" && mode == "" {
+		return nil, fmt.Errorf("fix-perms requires --owner, --group or --mode")
+	}
+	tmpl := &permTemplate{uid: -1, gid: -1}
+	var err error
+	if owner != "" {
+		if tmpl.uid, err = lookupID(owner, func(name string) (string, error) {
+			u, err := user.Lookup(name)
+			if err != nil {
+				return "", err
+			}
+			return u.Uid, nil
+		}); err != nil {
+			return nil, fmt.Errorf("invalid --owner: %w", err)
+		}
+	}
+	if group != "" {
+		if tmpl.gid, err = lookupID(group, func(name string) (string, error) {
+			g, err := user.LookupGroup(name)
+			if err != nil {
+				return "", err
+			}
+			return g.Gid, nil
+		}); err != nil {
+			return nil, fmt.Errorf("invalid --group: %w", err)
+		}
+	}
+	if mode != "" {
+		if tmpl.mode, err = parseModeTerms(mode); err != nil {
+			return nil, fmt.Errorf("invalid --mode: %w", err)
+		}
+	}
+	return tmpl, nil
+}
+
+// lookupID returns the numeric ID s, or the ID of the name s as returned
+// by lookup.
+func lookupID(s string, lookup func(string) (string, error)) (int, error) {
+	if id, err := strconv.ParseUint(s, 10, 32); err == nil {
+		return int(id), nil
+	}
+	id, err := lookup(s)
+	if err != nil {
+		return 0, err
+	}
+	n, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("%s has no numeric ID: %s", s, id)
+	}
+	return int(n), nil
+}
+
+// modeTerm is one clause of a chmod-style mode, such as "g+rwX", or an
+// absolute octal mode.
+type modeTerm struct {
+	who   uint32 // Bits the clause applies to (0o7777 for octal modes)
+	op    byte   // '+', '-' or '='
+	perm  uint32 // Bits to add, remove or set, before masking with who
+	condX bool   // X: execute only for directories and executable entries
+}
+
+// parseModeTerms parses a mode like chmod does: an octal mode, or
+// comma-separated clauses of the form [ugoa]*[+-=][rwxXst]*. Clauses
+// without a who apply to all, regardless of the umask. Unlike chmod, which
+// ignores them, s and t for a who without the bit, such as o+s or g+t,
+// are rejected.
+func parseModeTerms(s string) ([]modeTerm, error) {
+	if octal, err := strconv.ParseUint(s, 8, 32); err == nil {
+		if octal > 0o7777 {
+			return nil, fmt.Errorf("octal mode out of range: %s", s)
+		}
+		return []modeTerm{{who: 0o7777, op: '=', perm: uint32(octal)}}, nil
+	}
+
+	var terms []modeTerm
+	for _, clause := range strings.Split(s, ",") {
+		i := 0
+		var who uint32
+		for ; i < len(clause) && strings.IndexByte("ugoa", clause[i]) >= 0; i++ {
+			switch clause[i] {
+			case 'u':
+				who |= 0o4700
+			case 'g':
+				who |= 0o2070
+			case 'o':
+				who |= 0o1007
+			case 'a':
+				who |= 0o7777
+			}
+		}
+		if who == 0 {
+			who = 0o7777
+		}
+		if i == len(clause) {
+			return nil, fmt.Errorf("missing operator in %q", clause)
+		}
+		for i < len(clause) {
+			term := modeTerm{who: who, op: clause[i]}
+			if term.op != '+' && term.op != '-' && term.op != '=' {
+				return nil, fmt.Errorf("invalid operator %q in %q", clause[i], clause)
+			}
+			for i++; i < len(clause) && strings.IndexByte("+-=", clause[i]) < 0; i++ {
+				switch clause[i] {
+				case 'r':
+					term.perm |= 0o444
+				case 'w':
+					term.perm |= 0o222
+				case 'x':
+					term.perm |= 0o111
+				case 'X':
+					term.condX = true
+				case 's':
+					term.perm |= 0o6000
+				case 't':
+					term.perm |= 0o1000
+				default:
+					return nil, fmt.Errorf("invalid permission %q in %q", clause[i], clause)
+				}
+			}
+			if term.perm&0o6000 != 0 && who&0o6000 == 0 {
+				return nil, fmt.Errorf("s has no effect for others in %q", clause)
+			}
+			if term.perm&0o1000 != 0 && who&0o1000 == 0 {
+				return nil, fmt.Errorf("t only applies to others or all in %q", clause)
+			}
+			terms = append(terms, term)
+		}
+	}
+	return terms, nil
+}
+
+// applyModeTerms returns perm, in chmod's octal form, changed by terms.
+func applyModeTerms(terms []modeTerm, perm uint32, isDir bool) uint32 {
+	for _, t := range terms {
+		bits := t.perm
+		if t.condX && (isDir || perm&0o111 != 0) {
+			bits |= 0o111
+		}
+		bits &= t.who
+		switch t.op {
+		case '+':
+			perm |= bits
+		case '-':
+			perm &^= bits
+		case '=':
+			// Like chmod, = leaves the set-ID bits of directories alone
+			clear := t.who & 0o777
+			if t.who == 0o7777 || !isDir {
+				clear = t.who
+			}
+			perm = perm&^clear | bits
+		}
+	}
+	return perm
+}
+
+// unixPerm converts the permission bits of m to chmod's octal form.
+func unixPerm(m os.FileMode) uint32 {
+	perm := uint32(m.Perm())
+	if m&os.ModeSetuid != 0 {
+		perm |= 0o4000
+	}
+	if m&os.ModeSetgid != 0 {
+		perm |= 0o2000
+	}
+	if m&os.ModeSticky != 0 {
+		perm |= 0o1000
+	}
+	return perm
+}
+
+// fileMode converts chmod's octal form to an os.FileMode for os.Chmod.
+func fileMode(perm uint32) os.FileMode {
+	m := os.FileMode(perm & 0o777)
+	if perm&0o4000 != 0 {
+		m |= os.ModeSetuid
+	}
+	if perm&0o2000 != 0 {
+		m |= os.ModeSetgid
+	}
+	if perm&0o1000 != 0 {
+		m |= os.ModeSticky
+	}
+	return m
+}
+
+// permChange is the change of a single entry.
+type permChange struct {
+	path    string
+	fi      stat.FileInfo
+	uid     int    // Owner to set, or -1
+	gid     int    // Group to set, or -1
+	perm    uint32 // Mode to set in chmod's octal form
+	setMode bool   // Whether the mode changes
+}
+
+// plan returns the changes needed to bring infos in line with the
+// template, sorted by path, leaving out entries that already are.
+func (t *permTemplate) plan(infos []stat.FileInfo) []permChange {
+	var changes []permChange
+	for _, fi := range infos {
+		if fi.Archive != "" {
+			continue
+		}
+		c := permChange{path: fi.FullPath(), fi: fi, uid: -1, gid: -1}
+		if t.uid >= 0 && uint32(t.uid) != fi.UID {
+			c.uid = t.uid
+		}
+		if t.gid >= 0 && uint32(t.gid) != fi.GID {
+			c.gid = t.gid
+		}
+		if len(t.mode) > 0 && !fi.IsSymlink {
+			old := unixPerm(fi.Mode)
+			c.perm = applyModeTerms(t.mode, old, fi.IsDir)
+			c.setMode = c.perm != old
+		}
+		if c.uid >= 0 || c.gid >= 0 || c.setMode {
+			changes = append(changes, c)
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].path < changes[j].path })
+	return changes
+}
+
+// describe formats the change for dry runs, such as
+// "/srv/a: group 100 -> 2000, mode 0644 -> 0664".
+func (c *permChange) describe() string {
+	var parts []string
+	if c.uid >= 0 {
+		parts = append(parts, fmt.Sprintf("owner %s -> %d", stat.Username(c.fi.UID), c.uid))
+	}
+	if c.gid >= 0 {
+		parts = append(parts, fmt.Sprintf("group %s -> %d", stat.Groupname(c.fi.GID), c.gid))
+	}
+	if c.setMode {
+		parts = append(parts, fmt.Sprintf("mode %04o -> %04o", unixPerm(c.fi.Mode), c.perm))
+	}
+	return c.path + ": " + strings.Join(parts, ", ")
+}
+
+// apply makes the change. Ownership is changed first, since chown clears
+// the set-ID bits of files, and the mode is then set if it changes or
+// holds set-ID bits.
+func (c *permChange) apply() error {
+	chowned := false
+	if c.uid >= 0 || c.gid >= 0 {
+		if err := os.Lchown(c.path, c.uid, c.gid); err != nil {
+			return err
+		}
+		chowned = true
+	}
+	if c.setMode || chowned && !c.fi.IsSymlink && unixPerm(c.fi.Mode)&0o6000 != 0 {
+		perm := c.perm
+		if !c.setMode {
+			perm = unixPerm(c.fi.Mode)
+		}
+		return os.Chmod(c.path, fileMode(perm))
+	}
+	return nil
+}
+
+// permCounts counts the changes of fix-perms.
+type permCounts struct {
+	owner     int64 // Entries whose owner changed
+	group     int64 // Entries whose group changed
+	mode      int64 // Entries whose mode changed
+	unchanged int64 // Matching entries already in line with the template
+	failed    int64 // Entries that could not be changed
+}
+
+// add counts change c.
+func (p *permCounts) add(c *permChange) {
+	if c.uid >= 0 {
+		p.owner++
+	}
+	if c.gid >= 0 {
+		p.group++
+	}
+	if c.setMode {
+		p.mode++
+	}
+}
+
+// describe summarizes the counts, such as "owner of 0, group of 12 and
+// mode of 3 entries; 40 entries already matched".
+func (p *permCounts) describe() string {
+	return fmt.Sprintf("owner of %d, group of %d and mode of %d entries; %d entries already matched",
+		p.owner, p.group, p.mode, p.unchanged)
+}
+
+// applyPermChanges applies changes on a pool of workers and counts them.
+// Failures are reported on errs.
+func applyPermChanges(changes []permChange, workers int, errs io.Writer) permCounts {
+	var counts permCounts
+	var mu sync.Mutex
+	queue := make(chan *permChange)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for c := range queue {
+				err := c.apply()
+				mu.Lock()
+				if err != nil {
+					counts.failed++
+					fmt.Fprintf(errs, "%v\n", err)
+				} else {
+					counts.add(c)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for i := range changes {
+		queue <- &changes[i]
+	}
+	close(queue)
+	wg.Wait()
+	return counts
+}
@@ -0,0 +1,169 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/otuschhoff/cwalk/pkg/stat"
+)
+
+func TestApplyModeTerms(t *testing.T) {
+	tests := []struct {
+		mode  string
+		perm  uint32
+		isDir bool
+		want  uint32
+	}{
+		{"g+rw", 0o644, false, 0o664},
+		{"o-rwx", 0o755, true, 0o750},
+		{"g+rwX,o-rwx", 0o640, false, 0o660},
+		{"g+rwX,o-rwx", 0o744, false, 0o770},
+		{"g+rwX,o-rwx", 0o700, true, 0o770},
+		{"u=rw,go=r", 0o777, false, 0o644},
+		{"a=rX", 0o640, true, 0o555},
+		{"g+s", 0o775, true, 0o2775},
+		{"u=rwx", 0o4755, false, 0o755},
+		{"u=rwx", 0o2755, true, 0o2755},
+		{"+t", 0o777, true, 0o1777},
+		{"o+t", 0o777, true, 0o1777},
+		{"ug+s", 0o755, true, 0o6755},
+		{"go+s", 0o755, true, 0o2755},
+		{"a+st", 0o755, true, 0o7755},
+		{"u-s,o-t", 0o5755, true, 0o755},
+		{"u+r-x", 0o300, false, 0o600},
+		{"0640", 0o4777, false, 0o640},
+	}
+	for _, tt := range tests {
+		terms, err := parseModeTerms(tt.mode)
+		if err != nil {
+			t.Errorf("parseModeTerms(%q): %v", tt.mode, err)
+			continue
+		}
+		if got := applyModeTerms(terms, tt.perm, tt.isDir); got != tt.want {
+			t.Errorf("%q on %04o (dir %v) = %04o, want %04o", tt.mode, tt.perm, tt.isDir, got, tt.want)
+		}
+	}
+
+	for _, mode := range []string{"g", "g*w", "u+q", "17777", "", "o+s", "o=rs", "u+t", "g+t", "ug-t", "g+w,o+s"} {
+		if _, err := parseModeTerms(mode); err == nil {
+			t.Errorf("parseModeTerms(%q) should fail", mode)
+		}
+	}
+}
+
+func TestParsePermTemplate(t *testing.T) {
+	tmpl, err := parsePermTemplate("1001", "2000", "g+w")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tmpl.uid != 1001 || tmpl.gid != 2000 || len(tmpl.mode) != 1 {
+		t.Errorf("got %+v", tmpl)
+	}
+	if _, err := parsePermTemplate("", "", ""); err == nil {
+		t.Error("empty template should fail")
+	}
+	if _, err := parsePermTemplate("no-such-user-cwalk", "", ""); err == nil {
+		t.Error("unknown owner should fail")
+	}
+}
+
+func TestPermTemplatePlan(t *testing.T) {
+	tmpl, err := parsePermTemplate("", "2000", "g+w")
+	if err != nil {
+		t.Fatal(err)
+	}
+	infos := []stat.FileInfo{
+		{Root: "/p", Path: "ok", Mode: 0o664, GID: 2000},
+		{Root: "/p", Path: "group", Mode: 0o664, GID: 100},
+		{Root: "/p", Path: "both", Mode: 0o644, GID: 100},
+		{Root: "/p", Path: "link", Mode: os.ModeSymlink | 0o777, IsSymlink: true, GID: 2000},
+		{Root: "/p", Path: "a.tar/x", Mode: 0o600, GID: 100, Archive: "a.tar"},
+	}
+
+	changes := tmpl.plan(infos)
+	if len(changes) != 2 {
+		t.Fatalf("got %d changes, want 2", len(changes))
+	}
+	var counts permCounts
+	for _, c := range changes {
+		counts.add(&c)
+	}
+	if counts.owner != 0 || counts.group != 2 || counts.mode != 1 {
+		t.Errorf("got %s", counts.describe())
+	}
+	if got := changes[0].describe(); got != "/p/both: group "+stat.Groupname(100)+" -> 2000, mode 0644 -> 0664" {
+		t.Errorf("describe = %q", got)
+	}
+}
+
+func TestApplyPermChanges(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("no Unix permissions")
+	}
+	root := t.TempDir()
+	for _, name := range []string{"a", "b"} {
+		if err := os.WriteFile(filepath.Join(root, name), nil, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tmpl, err := parsePermTemplate("", "", "g+rw")
+	if err != nil {
+		t.Fatal(err)
+	}
+	results, err := stat.NewStatsWalker([]string{root}, 2, &stat.Filters{Types: map[string]bool{"file": true}}).Walk()
+	if err != nil {
+		t.Fatalf("walk failed: %v", err)
+	}
+	changes := tmpl.plan(results.AllFileInfos)
+
+	// Chown to the current owner always succeeds
+	changes[0].uid, changes[0].gid = os.Getuid(), os.Getgid()
+
+	var errs bytes.Buffer
+	counts := applyPermChanges(changes, 2, &errs)
+	if counts.mode != 2 || counts.owner != 1 || counts.failed != 0 {
+		t.Errorf("got %s, %d failed: %s", counts.describe(), counts.failed, errs.String())
+	}
+	for _, name := range []string{"a", "b"} {
+		info, err := os.Stat(filepath.Join(root, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Mode().Perm() != 0o660 {
+			t.Errorf("%s mode = %v, want 0660", name, info.Mode().Perm())
+		}
+	}
+}
+
+func TestBuildFixFilters(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		root     filterOptions
+		or       []string
+		all      bool
+		rejected bool
+	}{
+		{"no filters", filterOptions{}, nil, false, true},
+		{"no filters with --all", filterOptions{}, nil, true, false},
+		{"filter flag", filterOptions{uids: "1001"}, nil, false, false},
+		{"only --or", filterOptions{}, []string{"--name x"}, false, false},
+	} {
+		_, _, err := buildFixFilters(&tt.root, tt.or, nil, tt.all)
+		if (err != nil) != tt.rejected {
+			t.Errorf("%s: error %v, want rejected %v", tt.name, err, tt.rejected)
+		}
+	}
+
+	// Only the --or group selects entries
+	filters, _, err := buildFixFilters(&filterOptions{}, []string{"--name x"}, nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filters.Matches(&stat.FileInfo{Path: "", Mode: os.ModeDir | 0o755, IsDir: true}) {
+		t.Error("--or '--name x' matches the root directory")
+	}
+}
@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/otuschhoff/cwalk/pkg/stat"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// History compaction options
+	compactKeep      int
+	compactFullEvery int
+)
+
+// historyCmd groups maintenance commands for snapshot history directories
+// written with --history.
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Maintain snapshot history directories",
+}
+
+// historyCompactCmd rewrites a history directory, dropping old snapshots
+// and re-encoding the rest as periodic fulls with deltas in between.
+var historyCompactCmd = &cobra.Command{
+	Use:   "compact <history-dir>",
+	Short: "Drop old snapshots and re-encode a history directory",
+	Long: `Compact rewrites a snapshot history directory written with --history.
+
+Only the newest --keep snapshots are retained. The oldest retained snapshot
+becomes a full snapshot and the remaining ones are re-encoded as deltas, with
+a new full snapshot every --full-every snapshots.
+
+Examples:
+  cwalk history compact --keep 30 /var/lib/cwalk/history
+  cwalk history compact --keep 0 --full-every 14 /var/lib/cwalk/history`,
+	Args: cobra.ExactArgs(1),
+	RunE: runHistoryCompact,
+}
+
+// init registers the history commands and their flags.
+func init() {
+	historyCompactCmd.Flags().IntVar(&compactKeep, "keep", 0,
+		"Number of newest snapshots to keep (0 keeps all)")
+	historyCompactCmd.Flags().IntVar(&compactFullEvery, "full-every", stat.DefaultHistoryFullEvery,
+		"Store a full snapshot every N snapshots")
+
+	historyCmd.AddCommand(historyCompactCmd)
+	rootCmd.AddCommand(historyCmd)
+}
+
+// runHistoryCompact compacts the history directory given as argument.
+func runHistoryCompact(cmd *cobra.Command, args []string) error {
+	history := stat.NewHistory(args[0], compactFullEvery)
+
+	before, err := history.Len()
+	if err != nil {
+		return err
+	}
+	if err := history.Compact(compactKeep); err != nil {
+		return fmt.Errorf("failed to compact history: %w", err)
+	}
+	after, err := history.Len()
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Compacted %s: %d -> %d snapshots\n", args[0], before, after)
+	return nil
+}
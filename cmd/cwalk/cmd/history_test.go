@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/otuschhoff/cwalk/pkg/stat"
+)
+
+func TestHistoryCompactCommand(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "history")
+	history := stat.NewHistory(dir, 2)
+	for day := 1; day <= 4; day++ {
+		snapshot := &stat.Snapshot{
+			Version: stat.SnapshotVersion,
+			Time:    time.Date(2024, 1, day, 0, 0, 0, 0, time.UTC),
+		}
+		if err := history.Append(snapshot); err != nil {
+			t.Fatalf("Append failed: %v", err)
+		}
+	}
+
+	rootCmd.SetArgs([]string{"history", "compact", "--keep", "1", dir})
+	defer rootCmd.SetArgs(nil)
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("history compact failed: %v", err)
+	}
+
+	n, err := history.Len()
+	if err != nil {
+		t.Fatalf("Len failed: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("got %d snapshots after compaction, want 1", n)
+	}
+	latest, err := history.Latest()
+	if err != nil {
+		t.Fatalf("Latest failed: %v", err)
+	}
+	if latest.Time.Day() != 4 {
+		t.Errorf("kept snapshot from day %d, want day 4", latest.Time.Day())
+	}
+}
@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/otuschhoff/cwalk/pkg/output"
+	"github.com/otuschhoff/cwalk/pkg/stat"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// Homes options
+	homesFormat   string
+	homesFile     string
+	homesNoHeader bool
+	homesPlain    bool
+	homesWorkers  string
+)
+
+// homesCmd walks the given paths and reports each directory directly below
+// them as a user home.
+var homesCmd = &cobra.Command{
+	Use:   "homes <paths...>",
+	Short: "Report size, inodes, oldest file and owner mismatches per home directory",
+	Long: `Homes walks the given paths, such as /home, and treats each directory
+directly below them as a user home. Entries directly below the paths that are
+not directories are left out. For each home it reports:
+
+  Owner         user owning the home directory
+  Size, Inodes  totals of the home and everything below it
+  Oldest File   least recently modified file, with its modification time
+  Foreign       entries below the home owned by another user, and their size
+  Warnings      owner mismatches
+
+Warnings flag a home owned by a user other than the one it is named after, an
+owner that does not resolve to a user, and each other user owning entries in
+the home. Owners of homes under sftp:// roots are shown as UIDs and are not
+compared with the home names.
+
+Examples:
+  cwalk homes /home
+  cwalk homes --output-format csv --output-file homes.csv /home /srv/home`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runHomes,
+}
+
+// init registers the homes command and its flags.
+func init() {
+	homesCmd.Flags().StringVarP(&homesFormat, "output-format", "f", "table",
+		"Output format: table, json, csv, xlsx")
+	homesCmd.Flags().StringVarP(&homesFile, "output-file", "o", "",
+		"Write output to file (default: stdout)")
+	homesCmd.Flags().BoolVar(&homesNoHeader, "no-header", false,
+		"Hide table headers")
+	homesCmd.Flags().BoolVar(&homesPlain, "plain", false,
+		"Plain tab-separated tables without colors, box drawing or alignment padding")
+	homesCmd.Flags().StringVar(&homesWorkers, "workers", "4",
+		"Number of parallel workers, or \"auto\" to tune based on syscall latency and queue depth")
+
+	rootCmd.AddCommand(homesCmd)
+}
+
+// runHomes walks the paths given as arguments and writes the per-home
+// report.
+func runHomes(cmd *cobra.Command, args []string) error {
+	workers, maxWorkers, err := parseWorkers(homesWorkers)
+	if err != nil {
+		return fmt.Errorf("invalid --workers: %w", err)
+	}
+
+	walker := stat.NewStatsWalker(args, workers, &stat.Filters{})
+	walker.SetAutoWorkers(maxWorkers)
+	results, err := walker.Walk()
+	if err != nil {
+		return err
+	}
+
+	formatter := output.NewFormatter(homesFormat, "homes", homesNoHeader)
+	formatter.SetPlain(homesPlain)
+	out := formatter.Format(results)
+
+	if homesFile != "" {
+		if err := formatter.WriteToFile(out, homesFile); err != nil {
+			return fmt.Errorf("failed to write output: %w", err)
+		}
+		fmt.Fprintf(os.Stderr, "Output written to: %s\n", homesFile)
+	} else {
+		fmt.Print(out)
+	}
+	return nil
+}
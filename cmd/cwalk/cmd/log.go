@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+)
+
+var (
+	// Logging options
+	logLevel  string
+	logFormat string
+)
+
+// init registers the logging flags, which apply to every command.
+func init() {
+	rootCmd.PersistentFlags().StringVar(&logLevel, "log-level", "info",
+		"Least severe log messages written to stderr: debug (every directory read), info, warn, error (paths that could not be read)")
+	rootCmd.PersistentFlags().StringVar(&logFormat, "log-format", "text",
+		"Format of log messages: text (key=value pairs) or json (one object per line)")
+}
+
+// setupLogging makes the default slog logger, which the walkers log to,
+// write to stderr at the level and in the format selected by --log-level
+// and --log-format.
+func setupLogging() error {
+	handler, err := newLogHandler(os.Stderr, logLevel, logFormat)
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(slog.New(handler))
+	return nil
+}
+
+// newLogHandler returns a handler writing records of at least the given
+// level to w in the given format.
+func newLogHandler(w io.Writer, level, format string) (slog.Handler, error) {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("invalid --log-level: must be debug, info, warn or error: %s", level)
+	}
+	opts := &slog.HandlerOptions{Level: lvl}
+	switch format {
+	case "text":
+		return slog.NewTextHandler(w, opts), nil
+	case "json":
+		return slog.NewJSONHandler(w, opts), nil
+	default:
+		return nil, fmt.Errorf("invalid --log-format: must be text or json: %s", format)
+	}
+}
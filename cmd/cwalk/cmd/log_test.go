@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestNewLogHandler(t *testing.T) {
+	var buf bytes.Buffer
+	handler, err := newLogHandler(&buf, "warn", "json")
+	if err != nil {
+		t.Fatalf("newLogHandler: %v", err)
+	}
+	logger := slog.New(handler)
+	logger.Info("hidden")
+	logger.Error("processing failed", "path", "/data/a")
+	if out := buf.String(); strings.Contains(out, "hidden") || !strings.Contains(out, `"level":"ERROR","msg":"processing failed","path":"/data/a"`) {
+		t.Errorf("json log = %q", out)
+	}
+
+	buf.Reset()
+	handler, err = newLogHandler(&buf, "DEBUG", "text")
+	if err != nil {
+		t.Fatalf("newLogHandler: %v", err)
+	}
+	slog.New(handler).Debug("read directory", "entries", 3)
+	if out := buf.String(); !strings.Contains(out, `level=DEBUG msg="read directory" entries=3`) {
+		t.Errorf("text log = %q", out)
+	}
+
+	for _, args := range [][2]string{{"verbose", "text"}, {"info", "xml"}} {
+		if _, err := newLogHandler(&buf, args[0], args[1]); err == nil {
+			t.Errorf("newLogHandler(%q, %q) should fail", args[0], args[1])
+		}
+	}
+}
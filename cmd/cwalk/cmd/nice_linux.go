@@ -0,0 +1,41 @@
+//go:build linux
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	ioprioWhoProcess = 1       // IOPRIO_WHO_PROCESS
+	ioprioIdle       = 3 << 13 // IOPRIO_CLASS_IDLE << IOPRIO_CLASS_SHIFT
+)
+
+// lowerPriority moves the process to the lowest CPU priority (nice 19) and
+// the idle IO scheduling class, like running under `nice -n 19 ionice -c 3`.
+// Linux applies both per thread, so every existing thread of the process is
+// updated; threads created later inherit the settings from their creator.
+func lowerPriority() error {
+	tasks, err := os.ReadDir("/proc/self/task")
+	if err != nil {
+		return err
+	}
+
+	for _, task := range tasks {
+		tid, err := strconv.Atoi(task.Name())
+		if err != nil {
+			continue
+		}
+		if err := unix.Setpriority(unix.PRIO_PROCESS, tid, 19); err != nil {
+			return fmt.Errorf("setpriority: %w", err)
+		}
+		if _, _, errno := unix.Syscall(unix.SYS_IOPRIO_SET, ioprioWhoProcess, uintptr(tid), ioprioIdle); errno != 0 {
+			return fmt.Errorf("ioprio_set: %w", errno)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package cmd
+
+import "errors"
+
+// lowerPriority is only implemented on Linux.
+func lowerPriority() error {
+	return errors.New("not supported on this platform")
+}
@@ -0,0 +1,280 @@
+package cmd
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/smtp"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/otuschhoff/cwalk/pkg/output"
+	"github.com/otuschhoff/cwalk/pkg/stat"
+)
+
+// smtpPasswordEnv names the environment variable holding the password of
+// --smtp-user, so it does not show up in process listings.
+const smtpPasswordEnv = "CWALK_SMTP_PASSWORD"
+
+// notifyTimeout bounds the time a webhook may take to answer.
+const notifyTimeout = 30 * time.Second
+
+// Notification events
+const (
+	notifyDone   = "done"   // Every completed scan
+	notifyQuota  = "quota"  // A quota of --quota-file exceeded
+	notifyGrowth = "growth" // The total size grew more than --notify-growth
+)
+
+// notifier sends the results of a scan to a webhook and by email once the
+// scan completes or a threshold is exceeded.
+type notifier struct {
+	webhook string          // URL to POST to, or empty
+	format  string          // Webhook payload: json, text or slack
+	events  map[string]bool // Events that trigger a notification
+	growth  int64           // Growth threshold in bytes, or
+	pct     float64         // in percent of the previous total size
+
+	email  []string // Recipients, or none
+	server string   // SMTP server host:port
+	from   string   // Sender address
+	user   string   // SMTP user, or empty for no authentication
+	pass   string   // Password of user
+
+	client   *http.Client
+	sendMail func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+// parseNotifier builds the notifier of the --notify flags, or returns nil
+// if neither --notify-webhook nor --notify-email is given.
+func parseNotifier() (*notifier, error) {
+	if notifyWebhook == "" && notifyEmail == "" {
+		if notifyOn != notifyDone || notifyGrowthSpec != "" {
+			return nil, fmt.Errorf("--notify-on and --notify-growth require --notify-webhook or --notify-email")
+		}
+		return nil, nil
+	}
+
+	n := &notifier{
+		webhook:  notifyWebhook,
+		format:   notifyFormat,
+		events:   map[string]bool{},
+		email:    parseStringList(notifyEmail),
+		server:   smtpServer,
+		from:     smtpFrom,
+		user:     smtpUser,
+		pass:     os.Getenv(smtpPasswordEnv),
+		client:   &http.Client{Timeout: notifyTimeout},
+		sendMail: smtp.SendMail,
+	}
+	if n.webhook != "" && !strings.HasPrefix(n.webhook, "http://") && !strings.HasPrefix(n.webhook, "https://") {
+		return nil, fmt.Errorf("invalid --notify-webhook: not an http:// or https:// URL: %s", n.webhook)
+	}
+	if !slices.Contains([]string{"json", "text", "slack"}, n.format) {
+		return nil, fmt.Errorf("invalid --notify-format: %s (valid: json, text, slack)", n.format)
+	}
+	for _, event := range parseStringList(notifyOn) {
+		if !slices.Contains([]string{notifyDone, notifyQuota, notifyGrowth}, event) {
+			return nil, fmt.Errorf("invalid --notify-on: %s (valid: done, quota, growth)", event)
+		}
+		n.events[event] = true
+	}
+	if len(n.events) == 0 {
+		return nil, fmt.Errorf("invalid --notify-on: no events")
+	}
+
+	if n.events[notifyQuota] && quotaFile == "" {
+		return nil, fmt.Errorf("--notify-on quota requires --quota-file")
+	}
+	if n.events[notifyGrowth] != (notifyGrowthSpec != "") {
+		return nil, fmt.Errorf("--notify-on growth and --notify-growth require each other")
+	}
+	if n.events[notifyGrowth] && snapshotCompare == "" && historyDir == "" {
+		return nil, fmt.Errorf("--notify-on growth requires --snapshot-compare or --history")
+	}
+	if pct, ok := strings.CutSuffix(notifyGrowthSpec, "%"); ok {
+		v, err := strconv.ParseFloat(pct, 64)
+		if err != nil || v <= 0 {
+			return nil, fmt.Errorf("invalid --notify-growth: %s", notifyGrowthSpec)
+		}
+		n.pct = v
+	} else if notifyGrowthSpec != "" {
+		v, err := parseSize(notifyGrowthSpec)
+		if err != nil || v <= 0 {
+			return nil, fmt.Errorf("invalid --notify-growth: %s", notifyGrowthSpec)
+		}
+		n.growth = v
+	}
+
+	if len(n.email) > 0 {
+		if _, _, err := net.SplitHostPort(n.server); err != nil {
+			return nil, fmt.Errorf("invalid --smtp-server: %w", err)
+		}
+		if n.from == "" {
+			host, _ := os.Hostname()
+			n.from = "cwalk@" + cmp.Or(host, "localhost")
+		}
+		if n.user != "" && n.pass == "" {
+			return nil, fmt.Errorf("--smtp-user requires the password in $%s", smtpPasswordEnv)
+		}
+	}
+	return n, nil
+}
+
+// notifyReason is an event that occurred and its description.
+type notifyReason struct {
+	event string
+	text  string
+}
+
+// reasons returns why the results call for a notification, one per event
+// that occurred, or nothing.
+func (n *notifier) reasons(results *stat.Results, roots []string) []notifyReason {
+	var reasons []notifyReason
+	if n.events[notifyDone] {
+		reasons = append(reasons, notifyReason{notifyDone, fmt.Sprintf("scan of %s completed", strings.Join(roots, ", "))})
+	}
+	if n.events[notifyQuota] {
+		exceeded := 0
+		for i := range results.Quotas {
+			if results.Quotas[i].Exceeded() {
+				exceeded++
+			}
+		}
+		if exceeded > 0 {
+			reasons = append(reasons, notifyReason{notifyQuota, fmt.Sprintf("%d of %d quotas exceeded", exceeded, len(results.Quotas))})
+		}
+	}
+	if c := results.Churn; n.events[notifyGrowth] && c != nil {
+		growth := c.GrowthBytes()
+		var pct float64
+		if c.PrevBytes > 0 {
+			pct = 100 * float64(growth) / float64(c.PrevBytes)
+		}
+		if n.growth > 0 && growth > n.growth || n.pct > 0 && (pct > n.pct || c.PrevBytes == 0 && growth > 0) {
+			reasons = append(reasons, notifyReason{notifyGrowth, fmt.Sprintf("size grew by %s (%.1f%%) to %s", output.FormatBytes(growth), pct, output.FormatBytes(c.CurBytes))})
+		}
+	}
+	return reasons
+}
+
+// notify sends the results to the webhook and the email recipients if any
+// event occurred. The JSON payload holds the output modes of the scan; the
+// text forms hold the summary, quota and churn sections of the results.
+// Every destination is tried even if one fails.
+func (n *notifier) notify(results *stat.Results, roots, modes []string) error {
+	reasons := n.reasons(results, roots)
+	if len(reasons) == 0 {
+		return nil
+	}
+	var texts, events []string
+	for _, reason := range reasons {
+		texts = append(texts, reason.text)
+		events = append(events, reason.event)
+	}
+	subject := "cwalk: " + strings.Join(texts, "; ")
+
+	summaryModes := []string{"summary"}
+	if results.Quotas != nil {
+		summaryModes = append(summaryModes, "quota")
+	}
+	if results.Churn != nil {
+		summaryModes = append(summaryModes, "churn")
+	}
+	text := subject + "\n\n" + formatNotification(results, "table", summaryModes)
+
+	var errs []error
+	if n.webhook != "" {
+		if err := n.postWebhook(results, events, modes, text); err != nil {
+			errs = append(errs, fmt.Errorf("--notify-webhook: %w", err))
+		}
+	}
+	if len(n.email) > 0 {
+		if err := n.sendEmail(subject, text); err != nil {
+			errs = append(errs, fmt.Errorf("--notify-email: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// formatNotification formats the results in the given modes, with ASCII
+// borders and without colors for the table format.
+func formatNotification(results *stat.Results, format string, modes []string) string {
+	formatter := output.NewFormatter(format, modes[0], false)
+	if len(modes) > 1 {
+		formatter.SetModes(modes)
+	}
+	formatter.SetColor(false)
+	formatter.SetTableStyle(output.TableStylePlain)
+	return formatter.Format(results)
+}
+
+// postWebhook posts the results to the webhook in the configured format.
+// The events that occurred are listed in the X-Cwalk-Events header.
+func (n *notifier) postWebhook(results *stat.Results, events, modes []string, text string) error {
+	var body []byte
+	contentType := "application/json"
+	switch n.format {
+	case "json":
+		body = []byte(formatNotification(results, "json", modes))
+	case "text":
+		body, contentType = []byte(text), "text/plain; charset=utf-8"
+	case "slack":
+		// Tables only line up in a code block
+		subject, table, _ := strings.Cut(text, "\n\n")
+		var err error
+		if body, err = json.Marshal(map[string]string{"text": subject + "\n```\n" + table + "```"}); err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequest(http.MethodPost, n.webhook, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("X-Cwalk-Events", strings.Join(events, ","))
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%s returned %s", n.webhook, resp.Status)
+	}
+	return nil
+}
+
+// sendEmail mails text to the recipients through the SMTP server, which
+// is asked for STARTTLS if it offers it.
+func (n *notifier) sendEmail(subject, text string) error {
+	var auth smtp.Auth
+	if n.user != "" {
+		host, _, _ := net.SplitHostPort(n.server)
+		auth = smtp.PlainAuth("", n.user, n.pass, host)
+	}
+	return n.sendMail(n.server, auth, n.from, n.email, n.message(subject, text))
+}
+
+// message returns the email with the headers, text as the body and CRLF
+// line endings.
+func (n *notifier) message(subject, text string) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", n.from)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(n.email, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", strings.NewReplacer("\r", " ", "\n", " ").Replace(subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(strings.TrimRight(text, "\n"), "\n", "\r\n"))
+	b.WriteString("\r\n")
+	return []byte(b.String())
+}
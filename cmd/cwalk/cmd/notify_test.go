@@ -0,0 +1,153 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/otuschhoff/cwalk/pkg/stat"
+)
+
+func TestNotifierReasons(t *testing.T) {
+	results := &stat.Results{
+		Quotas: []stat.QuotaStat{
+			{Quota: stat.Quota{User: "alice", Limit: 10}, Used: 20},
+			{Quota: stat.Quota{User: "bob", Limit: 10}, Used: 5},
+		},
+		Churn: &stat.ChurnStat{PrevBytes: 1000, CurBytes: 1200},
+	}
+
+	tests := []struct {
+		name   string
+		n      notifier
+		events []string
+	}{
+		{"done", notifier{events: map[string]bool{"done": true}}, []string{"done"}},
+		{"quota", notifier{events: map[string]bool{"quota": true}}, []string{"quota"}},
+		{"growth over bytes", notifier{events: map[string]bool{"growth": true}, growth: 100}, []string{"growth"}},
+		{"growth under bytes", notifier{events: map[string]bool{"growth": true}, growth: 500}, nil},
+		{"growth over pct", notifier{events: map[string]bool{"growth": true}, pct: 10}, []string{"growth"}},
+		{"growth under pct", notifier{events: map[string]bool{"growth": true}, pct: 25}, nil},
+		{"all", notifier{events: map[string]bool{"done": true, "quota": true, "growth": true}, pct: 10}, []string{"done", "quota", "growth"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var events []string
+			for _, reason := range tt.n.reasons(results, []string{"/data"}) {
+				events = append(events, reason.event)
+			}
+			if strings.Join(events, ",") != strings.Join(tt.events, ",") {
+				t.Errorf("events = %q, want %q", events, tt.events)
+			}
+		})
+	}
+
+	if got := (&notifier{events: map[string]bool{"quota": true}}).reasons(&stat.Results{}, nil); len(got) != 0 {
+		t.Errorf("without quotas got %v", got)
+	}
+	reasons := (&notifier{events: map[string]bool{"quota": true}}).reasons(results, nil)
+	if len(reasons) != 1 || reasons[0].text != "1 of 2 quotas exceeded" {
+		t.Errorf("quota reasons = %v", reasons)
+	}
+}
+
+// notifyResults walks a small tree for the notification tests.
+func notifyResults(t *testing.T) *stat.Results {
+	t.Helper()
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "f"), []byte("data"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	results, err := stat.NewStatsWalker([]string{root}, 2, &stat.Filters{}).Walk()
+	if err != nil {
+		t.Fatalf("walk failed: %v", err)
+	}
+	return results
+}
+
+func TestNotifyWebhook(t *testing.T) {
+	results := notifyResults(t)
+
+	type request struct {
+		contentType, events string
+		body                []byte
+	}
+	var got request
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got = request{r.Header.Get("Content-Type"), r.Header.Get("X-Cwalk-Events"), body}
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	n := &notifier{webhook: server.URL, format: "json", events: map[string]bool{"done": true}, client: server.Client()}
+	if err := n.notify(results, []string{"/data"}, []string{"summary"}); err != nil {
+		t.Fatalf("notify failed: %v", err)
+	}
+	if got.contentType != "application/json" || got.events != "done" || !json.Valid(got.body) {
+		t.Errorf("json request = %q, %q, %s", got.contentType, got.events, got.body)
+	}
+
+	n.format = "slack"
+	if err := n.notify(results, []string{"/data"}, []string{"summary"}); err != nil {
+		t.Fatalf("notify failed: %v", err)
+	}
+	var msg struct{ Text string }
+	if err := json.Unmarshal(got.body, &msg); err != nil || !strings.HasPrefix(msg.Text, "cwalk: scan of /data completed\n```\n") {
+		t.Errorf("slack request = %s (%v)", got.body, err)
+	}
+
+	n.format = "text"
+	status = http.StatusInternalServerError
+	err := n.notify(results, []string{"/data"}, []string{"summary"})
+	if err == nil || !strings.Contains(err.Error(), "500") {
+		t.Errorf("failing webhook: got error %v", err)
+	}
+	if !strings.HasPrefix(got.contentType, "text/plain") || !strings.HasPrefix(string(got.body), "cwalk: scan of /data completed\n\n") {
+		t.Errorf("text request = %q, %s", got.contentType, got.body)
+	}
+}
+
+func TestNotifyEmail(t *testing.T) {
+	results := notifyResults(t)
+
+	var addr, from string
+	var to []string
+	var msg []byte
+	n := &notifier{
+		events: map[string]bool{"done": true},
+		email:  []string{"ops@example.com", "admin@example.com"},
+		server: "mail.example.com:587",
+		from:   "cwalk@example.com",
+		sendMail: func(a string, auth smtp.Auth, f string, t []string, m []byte) error {
+			addr, from, to, msg = a, f, t, m
+			return nil
+		},
+	}
+	if err := n.notify(results, []string{"/data"}, []string{"summary"}); err != nil {
+		t.Fatalf("notify failed: %v", err)
+	}
+	if addr != "mail.example.com:587" || from != "cwalk@example.com" || len(to) != 2 {
+		t.Errorf("sent to %s from %s to %q", addr, from, to)
+	}
+	head, body, ok := strings.Cut(string(msg), "\r\n\r\n")
+	if !ok || !strings.Contains(head, "Subject: cwalk: scan of /data completed\r\n") || !strings.Contains(head, "To: ops@example.com, admin@example.com\r\n") {
+		t.Errorf("headers:\n%s", head)
+	}
+	if strings.Contains(strings.ReplaceAll(body, "\r\n", ""), "\n") {
+		t.Errorf("body has bare line feeds:\n%s", body)
+	}
+
+	n.sendMail = func(string, smtp.Auth, string, []string, []byte) error { return errors.New("connection refused") }
+	if err := n.notify(results, []string{"/data"}, []string{"summary"}); err == nil || !strings.Contains(err.Error(), "--notify-email") {
+		t.Errorf("failing server: got error %v", err)
+	}
+}
@@ -0,0 +1,313 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+
+	"github.com/otuschhoff/cwalk/pkg/output"
+	"github.com/otuschhoff/cwalk/pkg/stat"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	// Policy options
+	policyDryRun  bool
+	policyLog     string
+	policyWorkers string
+)
+
+// policyCmd groups the commands working with retention policies.
+var policyCmd = &cobra.Command{
+	Use:   "policy",
+	Short: "Apply retention policies: rules of filters and what to do with the matching entries",
+	Long: `A retention policy is a YAML file of rules, each a set of filter flags in
+the form of a preset and an action for the entries that match them:
+
+  paths: [/var/log/app, /scratch]
+  rules:
+    - name: old-logs
+      match:
+        type: file
+        name: \.log$
+        mtime-older: 90d
+      action: delete
+    - name: old-data
+      match:
+        path: ^data/
+        mtime-older: 1y
+      action: archive
+      archive: /backup/old-data.tar.gz
+    - name: stale-scratch
+      match:
+        mtime-older: 30d
+      action: report
+
+Actions are report (count the entries), delete (remove them like "cwalk
+clean --delete", and empty matching directories with "dirs: true") and
+archive (write them to a tar archive like "cwalk archive"). Each entry is
+handled by the first rule it matches only, so a rule can keep entries from
+the rules below it with "action: report". Every rule needs at least one
+filter flag; match also takes "or" and "not" lists of filter groups.
+
+Examples:
+  cwalk policy apply --dry-run retention.yaml
+  cwalk policy apply --log /var/log/cwalk-policy.log retention.yaml /scratch`,
+}
+
+// policyApplyCmd walks the paths and applies the rules of a policy file.
+var policyApplyCmd = &cobra.Command{
+	Use:   "apply <policy.yaml> [paths...]",
+	Short: "Apply the rules of a policy file to the paths given or listed in the file",
+	Long: `Apply walks the paths given, or else the paths listed in the policy file,
+and applies each rule to the entries it matches first. With --dry-run
+nothing is removed or archived: apply reports what each rule would do.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runPolicyApply,
+}
+
+// init registers the policy commands and their flags.
+func init() {
+	policyApplyCmd.Flags().BoolVar(&policyDryRun, "dry-run", false,
+		"Only report what each rule would do; nothing is removed or archived")
+	policyApplyCmd.Flags().StringVar(&policyLog, "log", "",
+		"Append a line per entry (time, status, size, path) to this log")
+	policyApplyCmd.Flags().StringVar(&policyWorkers, "workers", "4",
+		"Number of parallel workers for walking and removing, or \"auto\" to tune the walk based on syscall latency and queue depth")
+
+	policyCmd.AddCommand(policyApplyCmd)
+	rootCmd.AddCommand(policyCmd)
+}
+
+// Policy rule actions
+const (
+	policyReport  = "report"
+	policyDelete  = "delete"
+	policyArchive = "archive"
+)
+
+// policy is the content of a policy file, see policyCmd.
+type policy struct {
+	Paths []string     `yaml:"paths"`
+	Rules []policyRule `yaml:"rules"`
+}
+
+// policyRule applies an action to the entries matching its filters.
+type policyRule struct {
+	Name    string         `yaml:"name"`
+	Match   map[string]any `yaml:"match"`   // Filter flags, like a preset
+	Action  string         `yaml:"action"`  // report, delete or archive
+	Dirs    bool           `yaml:"dirs"`    // Remove matching directories once empty
+	Archive string         `yaml:"archive"` // Archive file of the archive action
+
+	filters *stat.Filters   // Parsed from Match
+	sets    []*stat.Filters // Every filter set of Match, see buildFilters
+}
+
+// loadPolicy reads and checks a policy file and parses the filters of its
+// rules.
+func loadPolicy(path string) (*policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy file: %w", err)
+	}
+	var p policy
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&p); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("invalid policy file %s: %w", path, err)
+	}
+	if len(p.Rules) == 0 {
+		return nil, fmt.Errorf("invalid policy file %s: no rules", path)
+	}
+
+	names := map[string]bool{}
+	for i := range p.Rules {
+		rule := &p.Rules[i]
+		if rule.Name == "" {
+			rule.Name = fmt.Sprintf("rule %d", i+1)
+		}
+		if names[rule.Name] {
+			return nil, fmt.Errorf("invalid policy file %s: duplicate rule %q", path, rule.Name)
+		}
+		names[rule.Name] = true
+		if err := rule.parse(); err != nil {
+			return nil, fmt.Errorf("invalid policy file %s: rule %s: %w", path, rule.Name, err)
+		}
+	}
+	return &p, nil
+}
+
+// parse checks the action of the rule and parses its filters.
+func (r *policyRule) parse() error {
+	switch r.Action {
+	case policyReport, policyDelete:
+		if r.Archive != "" {
+			return fmt.Errorf("archive only applies to the archive action")
+		}
+	case policyArchive:
+		if r.Archive == "" || r.Archive == "-" {
+			return fmt.Errorf("the archive action requires an archive file")
+		}
+	case "":
+		return fmt.Errorf("missing action (valid: report, delete, archive)")
+	default:
+		return fmt.Errorf("invalid action %q (valid: report, delete, archive)", r.Action)
+	}
+	if r.Dirs && r.Action != policyDelete {
+		return fmt.Errorf("dirs only applies to the delete action")
+	}
+
+	names := filterFlagNames()
+	for name := range r.Match {
+		if !slices.Contains(names, name) {
+			return fmt.Errorf("%q is not a filter flag", name)
+		}
+	}
+
+	var opts filterOptions
+	var or, not []string
+	flags := pflag.NewFlagSet("policy rule", pflag.ContinueOnError)
+	opts.register(flags)
+	flags.StringArrayVar(&or, "or", nil, "")
+	flags.StringArrayVar(&not, "not", nil, "")
+	if err := applyFlagValues(flags, r.Match, map[string]bool{}); err != nil {
+		return err
+	}
+	var err error
+	if r.filters, r.sets, err = buildFilters(&opts, or, not); err != nil {
+		return err
+	}
+	// Matching everything below the roots is never what was meant
+	if selectsEverything(r.filters) {
+		return fmt.Errorf("match requires at least one filter flag")
+	}
+	return nil
+}
+
+// filters returns the filters of a walk for all rules: an entry must
+// match any rule. It also returns every filter set of the rules.
+func (p *policy) filters() (*stat.Filters, []*stat.Filters) {
+	var anyOf, sets []*stat.Filters
+	for i := range p.Rules {
+		anyOf = append(anyOf, p.Rules[i].filters)
+		sets = append(sets, p.Rules[i].sets...)
+	}
+	return &stat.Filters{AnyOf: anyOf}, sets
+}
+
+// assign splits the entries of results between the rules: each entry goes
+// to the first rule it matches. The results of each rule share the scan
+// information of results.
+func (p *policy) assign(results *stat.Results) []*stat.Results {
+	matched := make([]*stat.Results, len(p.Rules))
+	for i := range matched {
+		matched[i] = &stat.Results{Scan: results.Scan}
+	}
+	for _, fi := range results.AllFileInfos {
+		for i := range p.Rules {
+			if p.Rules[i].filters.Matches(&fi) {
+				matched[i].AllFileInfos = append(matched[i].AllFileInfos, fi)
+				break
+			}
+		}
+	}
+	return matched
+}
+
+// runPolicyApply walks the paths and applies the rules of the policy file
+// given as the first argument.
+func runPolicyApply(cmd *cobra.Command, args []string) error {
+	p, err := loadPolicy(args[0])
+	if err != nil {
+		return err
+	}
+	paths := args[1:]
+	if len(paths) == 0 {
+		paths = p.Paths
+	}
+	if len(paths) == 0 {
+		return fmt.Errorf("no paths given and none listed in %s", args[0])
+	}
+
+	filters, sets := p.filters()
+	results, workers, err := walkFiltered(paths, filters, sets, policyWorkers)
+	if err != nil {
+		return err
+	}
+
+	var log io.Writer
+	if policyLog != "" {
+		f, err := os.OpenFile(policyLog, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return fmt.Errorf("invalid --log: %w", err)
+		}
+		defer f.Close()
+		log = f
+	}
+
+	if err := p.apply(results, policyDryRun, workers, log, os.Stdout, os.Stderr); err != nil {
+		return err
+	}
+	if policyDryRun {
+		fmt.Println("Nothing was changed; run again without --dry-run to apply the policy.")
+	}
+	return nil
+}
+
+// apply applies the rules to the entries of results and prints a line per
+// rule to w. Deletions use jobs workers, and each entry is written to log,
+// if not nil, with the status of its rule. Failures are reported on errs
+// and returned as one error once every rule was applied.
+func (p *policy) apply(results *stat.Results, dryRun bool, jobs int, log, w, errs io.Writer) error {
+	var failed int64
+	for i, matched := range p.assign(results) {
+		rule := &p.Rules[i]
+		switch rule.Action {
+		case policyReport:
+			plan := newCleanPlan(matched, true)
+			plan.log(log, "reported")
+			fmt.Fprintf(w, "%s: %s (%s) reported\n", rule.Name, plan.describe(), output.FormatBytes(plan.bytes))
+
+		case policyDelete:
+			plan := newCleanPlan(matched, rule.Dirs)
+			if dryRun {
+				plan.log(log, "planned")
+				fmt.Fprintf(w, "%s: would remove %s, freeing %s\n", rule.Name, plan.describe(), output.FormatBytes(plan.bytes))
+				continue
+			}
+			done := plan.execute(jobs, log, errs)
+			fmt.Fprintf(w, "%s: removed %s, freeing %s\n", rule.Name, done.describe(), output.FormatBytes(done.bytes))
+			failed += done.failed
+
+		case policyArchive:
+			infos := archiveEntries(matched, rule.Archive)
+			if dryRun {
+				plan := newCleanPlan(matched, true)
+				plan.log(log, "planned")
+				fmt.Fprintf(w, "%s: would archive %s (%s) to %s\n", rule.Name, plan.describe(), output.FormatBytes(plan.bytes), rule.Archive)
+				continue
+			}
+			compression, _ := archiveCompressionFor(rule.Archive, "auto")
+			counts, err := createArchive(rule.Archive, compression, infos, errs)
+			if err != nil {
+				return fmt.Errorf("rule %s: %w", rule.Name, err)
+			}
+			if log != nil {
+				plan := newCleanPlan(matched, true)
+				plan.log(log, "archived")
+			}
+			fmt.Fprintf(w, "%s: archived %s (%s) to %s\n", rule.Name, counts.describe(), output.FormatBytes(counts.bytes), rule.Archive)
+			failed += counts.failed
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d entries could not be removed or archived", failed)
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/otuschhoff/cwalk/pkg/stat"
+)
+
+func TestLoadPolicy(t *testing.T) {
+	dir := t.TempDir()
+	write := func(content string) string {
+		path := filepath.Join(dir, "policy.yaml")
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	p, err := loadPolicy(write(`paths: [/scratch]
+rules:
+  - name: logs
+    match:
+      type: file
+      name-glob: "*.log"
+      not: ["--username root"]
+    action: delete
+  - match: {mtime-older: 30d}
+    action: report
+`))
+	if err != nil {
+		t.Fatalf("loadPolicy failed: %v", err)
+	}
+	if len(p.Paths) != 1 || len(p.Rules) != 2 || p.Rules[1].Name != "rule 2" {
+		t.Fatalf("got %+v", p)
+	}
+	if len(p.Rules[0].sets) != 2 || p.Rules[0].filters.Not == nil {
+		t.Errorf("rule logs: got %d filter sets, not %v", len(p.Rules[0].sets), p.Rules[0].filters.Not)
+	}
+
+	for content, want := range map[string]string{
+		"rules: []":                                               "no rules",
+		"rules: [{match: {type: file}}]":                          "missing action",
+		"rules: [{match: {type: file}, action: move}]":            "invalid action",
+		"rules: [{action: delete}]":                               "at least one filter flag",
+		"rules: [{match: {not: [--type dir]}, action: delete}]":   "at least one filter flag",
+		"rules: [{match: {selinux-type: ','}, action: delete}]":   "at least one filter flag",
+		"rules: [{match: {workers: 2}, action: report}]":          "not a filter flag",
+		"rules: [{match: {type: file}, action: archive}]":         "requires an archive file",
+		"rules: [{match: {type: file}, action: report, dirs: y}]": "dirs only applies",
+		"rules: [{match: {mtime-older: soon}, action: report}]":   "invalid --mtime-older",
+		"rules: [{match: {type: file}, action: report, when: x}]": "field when not found",
+		"rules: [{name: a, match: {type: file}, action: report}, {name: a, match: {type: dir}, action: report}]": "duplicate rule",
+	} {
+		if _, err := loadPolicy(write(content)); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: got error %v, want %q", content, err, want)
+		}
+	}
+}
+
+func TestPolicyOrRule(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	if err := os.WriteFile(path, []byte(`rules:
+  - name: logs
+    match: {or: ["--name '\\.log$'"]}
+    action: delete
+`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	p, err := loadPolicy(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	filters, _ := p.filters()
+	for name, want := range map[string]bool{"a.log": true, "important.db": false, "keep.txt": false} {
+		if got := filters.Matches(&stat.FileInfo{Path: name, Mode: 0o644}); got != want {
+			t.Errorf("Matches(%s) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestPolicyApply(t *testing.T) {
+	root := t.TempDir()
+	old := time.Now().Add(-100 * 24 * time.Hour)
+	for _, name := range []string{"a.log", "keep.log", "b.dat", "new.log"} {
+		path := filepath.Join(root, name)
+		if err := os.WriteFile(path, []byte("data"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if name != "new.log" {
+			if err := os.Chtimes(path, old, old); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	archive := filepath.Join(t.TempDir(), "old.tar")
+	policyFile := filepath.Join(t.TempDir(), "policy.yaml")
+	if err := os.WriteFile(policyFile, []byte(`rules:
+  - name: keep
+    match: {name: ^keep}
+    action: report
+  - name: old-logs
+    match: {name-glob: "*.log", mtime-older: 90d}
+    action: delete
+  - name: old-data
+    match: {name-glob: "*.dat", mtime-older: 90d}
+    action: archive
+    archive: `+archive+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	p, err := loadPolicy(policyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	filters, _ := p.filters()
+	results, err := stat.NewStatsWalker([]string{root}, 2, filters).Walk()
+	if err != nil {
+		t.Fatalf("walk failed: %v", err)
+	}
+
+	var out, log, errs bytes.Buffer
+	if err := p.apply(results, true, 2, &log, &out, &errs); err != nil {
+		t.Fatalf("dry run failed: %v", err)
+	}
+	if !strings.Contains(out.String(), "old-logs: would remove 1 files") || !strings.Contains(out.String(), "keep: 1 files") {
+		t.Errorf("dry run output:\n%s", out.String())
+	}
+	if _, err := os.Stat(filepath.Join(root, "a.log")); err != nil {
+		t.Errorf("dry run removed a.log: %v", err)
+	}
+	if _, err := os.Stat(archive); !os.IsNotExist(err) {
+		t.Errorf("dry run wrote the archive: %v", err)
+	}
+
+	out.Reset()
+	if err := p.apply(results, false, 2, &log, &out, &errs); err != nil {
+		t.Fatalf("apply failed: %v (%s)", err, errs.String())
+	}
+	for name, exists := range map[string]bool{"a.log": false, "keep.log": true, "b.dat": true, "new.log": true} {
+		if _, err := os.Stat(filepath.Join(root, name)); (err == nil) != exists {
+			t.Errorf("%s: exists = %v, want %v", name, err == nil, exists)
+		}
+	}
+	if !strings.Contains(out.String(), "old-data: archived 1 files") {
+		t.Errorf("apply output:\n%s", out.String())
+	}
+	if _, err := os.Stat(archive); err != nil {
+		t.Errorf("archive not written: %v", err)
+	}
+	if !strings.Contains(log.String(), "\treported\t") || !strings.Contains(log.String(), "\tremoved\t") {
+		t.Errorf("log:\n%s", log.String())
+	}
+}
@@ -0,0 +1,269 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	// Preset options
+	presetFilters filterOptions
+	presetOr      []string
+	presetNot     []string
+	presetForce   bool
+)
+
+// presetCmd groups the commands managing the filter presets of the config
+// file.
+var presetCmd = &cobra.Command{
+	Use:   "preset",
+	Short: "Save, list and delete named filter presets",
+	Long: `Presets are sets of filter flags saved under a name in the config file
+(~/.cwalk.yaml or --config), so frequent queries need not be retyped. Every
+command with filter flags applies a preset with --preset NAME; filter flags
+given on the command line take precedence over those of the preset.
+
+Examples:
+  cwalk preset save stale-logs --type file --name '\.log$' --mtime-older 90d
+  cwalk --preset stale-logs -m per-uid /var/log
+  cwalk clean --preset stale-logs --delete /var/log
+  cwalk preset list`,
+}
+
+// presetSaveCmd saves the filter flags it is given as a preset.
+var presetSaveCmd = &cobra.Command{
+	Use:   "save <name> [filter flags]",
+	Short: "Save filter flags under a name",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runPresetSave,
+}
+
+// presetListCmd prints the presets as command line flags.
+var presetListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the saved presets and their filters",
+	Args:  cobra.NoArgs,
+	RunE:  runPresetList,
+}
+
+// presetDeleteCmd removes a preset from the config file.
+var presetDeleteCmd = &cobra.Command{
+	Use:   "delete <name>",
+	Short: "Delete a saved preset",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runPresetDelete,
+}
+
+// init registers the preset commands and their flags.
+func init() {
+	presetFilters.register(presetSaveCmd.Flags())
+	presetSaveCmd.Flags().StringArrayVar(&presetOr, "or", nil,
+		"Alternative group of filter flags, e.g. \"--groupname bob\" (repeatable)")
+	presetSaveCmd.Flags().StringArrayVar(&presetNot, "not", nil,
+		"Group of filter flags whose entries are excluded, e.g. \"--username root\" (repeatable)")
+	presetSaveCmd.Flags().BoolVar(&presetForce, "force", false,
+		"Replace a preset of the same name")
+
+	presetCmd.AddCommand(presetSaveCmd, presetListCmd, presetDeleteCmd)
+	rootCmd.AddCommand(presetCmd)
+}
+
+// runPresetSave saves the filter flags given on the command line as the
+// preset named by the argument.
+func runPresetSave(cmd *cobra.Command, args []string) error {
+	if _, _, err := buildFilters(&presetFilters, presetOr, presetNot); err != nil {
+		return err
+	}
+	values := map[string]any{}
+	cmd.Flags().Visit(func(flag *pflag.Flag) {
+		if flag.Name == "force" || cmd.Flags().Lookup(flag.Name) != cmd.LocalFlags().Lookup(flag.Name) {
+			return
+		}
+		if list, ok := flag.Value.(pflag.SliceValue); ok {
+			values[flag.Name] = list.GetSlice()
+		} else {
+			values[flag.Name] = flag.Value.String()
+		}
+	})
+	if len(values) == 0 {
+		return fmt.Errorf("no filter flags given")
+	}
+
+	path, err := configPath()
+	if err != nil {
+		return err
+	}
+	if err := updatePresets(path, func(presets *yaml.Node) error {
+		i := mappingIndex(presets, args[0])
+		if i >= 0 && !presetForce {
+			return fmt.Errorf("preset %q exists; use --force to replace it", args[0])
+		}
+		var value yaml.Node
+		if err := value.Encode(values); err != nil {
+			return err
+		}
+		if i >= 0 {
+			presets.Content[i+1] = &value
+		} else {
+			presets.Content = append(presets.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: args[0]}, &value)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Saved preset %s to %s\n", args[0], path)
+	return nil
+}
+
+// runPresetList prints a line per preset with its filter flags.
+func runPresetList(cmd *cobra.Command, args []string) error {
+	path, err := configPath()
+	if err != nil {
+		return err
+	}
+	cfg, err := loadConfig(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	printPresets(os.Stdout, cfg.Presets)
+	return nil
+}
+
+// runPresetDelete removes the preset named by the argument.
+func runPresetDelete(cmd *cobra.Command, args []string) error {
+	path, err := configPath()
+	if err != nil {
+		return err
+	}
+	if err := updatePresets(path, func(presets *yaml.Node) error {
+		i := mappingIndex(presets, args[0])
+		if i < 0 {
+			return fmt.Errorf("unknown preset %q in %s", args[0], path)
+		}
+		presets.Content = slices.Delete(presets.Content, i, i+2)
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Deleted preset %s from %s\n", args[0], path)
+	return nil
+}
+
+// printPresets writes a line per preset, sorted by name, with its filters
+// as command line flags.
+func printPresets(w io.Writer, presets map[string]map[string]any) {
+	names := make([]string, 0, len(presets))
+	for name := range presets {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		flags := make([]string, 0, len(presets[name]))
+		for flag := range presets[name] {
+			flags = append(flags, flag)
+		}
+		slices.Sort(flags)
+
+		var args []string
+		for _, flag := range flags {
+			values, ok := presets[name][flag].([]any)
+			if !ok {
+				values = []any{presets[name][flag]}
+			}
+			for _, value := range values {
+				args = append(args, "--"+flag, shellQuote(fmt.Sprint(value)))
+			}
+		}
+		fmt.Fprintf(w, "%s\t%s\n", name, strings.Join(args, " "))
+	}
+}
+
+// updatePresets passes the presets mapping of the config file at path,
+// created if missing, to update and writes the file back. Comments and
+// the order of the other keys are kept.
+func updatePresets(path string, update func(presets *yaml.Node) error) error {
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var doc yaml.Node
+	if len(bytes.TrimSpace(data)) > 0 {
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return fmt.Errorf("invalid config file %s: %w", path, err)
+		}
+	}
+	if len(doc.Content) == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return fmt.Errorf("invalid config file %s: not a mapping of flags", path)
+	}
+
+	i := mappingIndex(root, "presets")
+	if i < 0 {
+		root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: "presets"}, &yaml.Node{Kind: yaml.MappingNode})
+		i = len(root.Content) - 2
+	}
+	presets := root.Content[i+1]
+	if presets.Kind == yaml.ScalarNode && presets.Tag == "!!null" {
+		presets.Kind, presets.Tag, presets.Value = yaml.MappingNode, "", ""
+	}
+	if presets.Kind != yaml.MappingNode {
+		return fmt.Errorf("invalid config file %s: presets is not a mapping", path)
+	}
+	if err := update(presets); err != nil {
+		return err
+	}
+
+	var out bytes.Buffer
+	enc := yaml.NewEncoder(&out)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, out.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	return nil
+}
+
+// mappingIndex returns the index of the key node of key in the content of
+// a mapping node, or -1.
+func mappingIndex(mapping *yaml.Node, key string) int {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// filterFlagNames returns the names of the flags a preset can hold: the
+// filter flags, --or and --not.
+func filterFlagNames() []string {
+	var opts filterOptions
+	flags := pflag.NewFlagSet("preset", pflag.ContinueOnError)
+	opts.register(flags)
+	names := []string{"or", "not"}
+	flags.VisitAll(func(flag *pflag.Flag) {
+		names = append(names, flag.Name)
+	})
+	return names
+}
@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestUpdatePresets(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cwalk.yaml")
+	if err := os.WriteFile(path, []byte("# defaults\nworkers: 16 # more\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	add := func(name string, values map[string]any) func(*yaml.Node) error {
+		return func(presets *yaml.Node) error {
+			var value yaml.Node
+			if err := value.Encode(values); err != nil {
+				return err
+			}
+			presets.Content = append(presets.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: name}, &value)
+			return nil
+		}
+	}
+	if err := updatePresets(path, add("stale-logs", map[string]any{"type": "file", "name": `\.log$`, "not": []string{"--name b"}})); err != nil {
+		t.Fatalf("updatePresets: %v", err)
+	}
+	if err := updatePresets(path, add("big", map[string]any{"size-min": "1G"})); err != nil {
+		t.Fatalf("updatePresets: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "# defaults\nworkers: 16 # more\n") {
+		t.Errorf("comments and other keys should be kept, got %q", data)
+	}
+	cfg, err := loadConfig(path)
+	if err != nil {
+		t.Fatalf("loadConfig: %v", err)
+	}
+	if len(cfg.Presets) != 2 || cfg.Presets["stale-logs"]["name"] != `\.log$` {
+		t.Errorf("presets = %v", cfg.Presets)
+	}
+
+	var b strings.Builder
+	printPresets(&b, cfg.Presets)
+	want := "big\t--size-min 1G\nstale-logs\t--name '\\.log$' --not '--name b' --type file\n"
+	if b.String() != want {
+		t.Errorf("printPresets = %q, want %q", b.String(), want)
+	}
+
+	if err := updatePresets(path, func(presets *yaml.Node) error {
+		if i := mappingIndex(presets, "big"); i >= 0 {
+			presets.Content = append(presets.Content[:i], presets.Content[i+2:]...)
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("updatePresets: %v", err)
+	}
+	if cfg, err = loadConfig(path); err != nil || len(cfg.Presets) != 1 {
+		t.Errorf("presets after deletion = %v, %v", cfg.Presets, err)
+	}
+}
+
+func TestLoadConfigPresets(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cwalk.yaml")
+	if err := os.WriteFile(path, []byte("presets:\n  p:\n    workers: 4\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadConfig(path); err == nil {
+		t.Error("loadConfig should reject presets with flags other than filters")
+	}
+}
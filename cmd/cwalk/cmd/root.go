@@ -6,15 +6,22 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
-	"regexp"
+	"path/filepath"
+	"runtime"
+	"slices"
 	"strconv"
 	"strings"
+	"text/template"
 	"time"
 
+	cwalk "github.com/otuschhoff/cwalk"
 	"github.com/otuschhoff/cwalk/pkg/output"
+	"github.com/otuschhoff/cwalk/pkg/sftp"
 	"github.com/otuschhoff/cwalk/pkg/stat"
+	"github.com/otuschhoff/cwalk/pkg/textfmt"
 	"github.com/spf13/cobra"
 )
 
@@ -22,25 +29,118 @@ var (
 	// Output options
 	outputFormat string
 	outputFile   string
+	outputs      []string
 	outputMode   string
 	noHeader     bool
+	plain        bool
+	colorMode    string
+	tableStyle   string
+	maxColWidth  int
+	wrapMode     string
+	footer       bool
+	timing       bool
+	splitByRoot  bool
+	totals       bool
+	rawBytes     bool
+	unitsName    string
+	countsSpec   string
+	countSep     string
+	groupBy      string
+	groupByExpr  string
+	sortBy       string
+	reverse      bool
+	limit        int
+	columns      string
+	nullSep      bool
+	splitSize    string
+	splitRows    string
+	templateFile string
+	csvDelimiter string
+	csvDecComma  bool
+	csvSizes     string
 
 	// Filter options
-	filterType            string
-	filterMtimeOlderStr   string
-	filterMtimeYoungerStr string
-	filterSizeMin         string
-	filterSizeMax         string
-	filterNameRegex       string
-	filterUsernames       string
-	filterUIDs            string
-	filterGroupnames      string
-	filterGIDs            string
-	filterPerms           string
-	filterPermsNot        string
+	filterOpts filterOptions
+	filterOr   []string
+	filterNot  []string
+
+	// Pruning options
+	maxDepth     int
+	excludePaths []string
+
+	// Watchlist options
+	watchlistFile string
+
+	// Quota options
+	quotaFile string
+
+	// Project options
+	projectMarkers string
+
+	// Baseline options
+	baselineFile  string
+	baselineAlert string
+
+	// Notification options
+	notifyWebhook    string
+	notifyFormat     string
+	notifyOn         string
+	notifyGrowthSpec string
+	notifyEmail      string
+	smtpServer       string
+	smtpFrom         string
+	smtpUser         string
+
+	// Archive options
+	archives bool
+
+	// Symlink options
+	symlinkCheck bool
+
+	// Exec options
+	execCmd   string
+	execBatch string
+	execJobs  int
+	dryRun    bool
+
+	// Name resolution options
+	preloadNames bool
+	numeric      bool
+
+	// Extended attribute options
+	xattrs  bool
+	selinux bool
+	lustre  bool
+
+	// Snapshot options
+	snapshotSave     string
+	snapshotCompare  string
+	snapshotLoad     string
+	historyDir       string
+	historyFullEvery int
+
+	// Checkpoint options
+	checkpointFile     string
+	checkpointInterval string
+	resumeScan         bool
 
 	// Worker options
-	workers int
+	workersFlag   string
+	ioConcurrency int
+	throttleSpec  string
+	nice          bool
+
+	// Metadata options
+	statxFields string
+	backendName string
+	traversal   string
+	inodeOrder  bool
+	dirBatch    int
+	maxOpenDirs int
+	physical    bool
+
+	// Remote options
+	sftpConns int
 )
 
 // rootCmd represents the base command when called without any subcommands.
@@ -56,9 +156,22 @@ Examples:
   cwalk /home/user
   cwalk -o summary /home /var
   cwalk --output-format json --output-file stats.json /opt
+  cwalk --output table:- --output json:stats.json /opt
   cwalk --type file --size-min 1M /tmp
-  cwalk --mtime-older 7d --output-mode per-year /home/user`,
-	Args: cobra.MinimumNArgs(1),
+  cwalk --mtime-older 7d --output-mode per-year /home/user
+  cwalk --group-by btime --output-mode per-year /home/user
+  cwalk --output-mode per-uid sftp://admin@fileserver/export/home
+  cwalk --snapshot-load scan.json --output-mode per-uid`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		// Paths are not needed when reporting on a saved snapshot
+		if snapshotLoad != "" {
+			if len(args) > 0 {
+				return fmt.Errorf("--snapshot-load cannot be combined with paths")
+			}
+			return nil
+		}
+		return cobra.MinimumNArgs(1)(cmd, args)
+	},
 	RunE: runWalk,
 }
 
@@ -67,164 +180,831 @@ Examples:
 func init() {
 	// Output format flags
 	rootCmd.Flags().StringVarP(&outputFormat, "output-format", "f", "table",
-		"Output format: table, json, csv, xlsx")
+		"Output format: table, json, csv, xlsx, html, template")
 	rootCmd.Flags().StringVarP(&outputFile, "output-file", "o", "",
 		"Write output to file (default: stdout)")
+	rootCmd.Flags().StringArrayVar(&outputs, "output", nil,
+		"Write the results in a format to a file or to stdout (-), e.g. json:stats.json or table:-, instead of --output-format and --output-file (repeatable)")
 	rootCmd.Flags().StringVarP(&outputMode, "output-mode", "m", "summary",
-		"Output mode: summary, per-year, per-uid")
+		"Output mode: summary, per-year, per-uid, groups, per-fs, per-depth, inode-usage, symlinks, random-names, watchlist, churn, list, paths, empty, fan-out, xattrs, selinux, audit, homes, projects, ownership, per-project-id, striping, stats; several comma-separated modes, or all (summary, per-year, per-uid, per-fs, per-depth, inode-usage, symlinks, fan-out), write one section each from a single walk")
 	rootCmd.Flags().BoolVar(&noHeader, "no-header", false,
 		"Hide table headers")
+	rootCmd.Flags().BoolVar(&plain, "plain", false,
+		"Plain tab-separated tables without colors, box drawing or alignment padding (screen-reader friendly)")
+	rootCmd.Flags().StringVar(&colorMode, "color", "auto",
+		"Color tables: auto (only on a terminal, unless NO_COLOR is set), always, never")
+	rootCmd.Flags().StringVar(&tableStyle, "table-style", "dark",
+		"Table style: dark (light text on dark backgrounds), light (dark text on light backgrounds), plain (ASCII borders without colors), borderless (no borders or colors)")
+	rootCmd.Flags().IntVar(&maxColWidth, "max-col-width", 0,
+		"Maximum width of table columns in characters; wider values are shortened as --wrap selects (0: no limit)")
+	rootCmd.Flags().StringVar(&wrapMode, "wrap", "soft",
+		"How values wider than --max-col-width are shortened: soft (wrap at word boundaries), hard (wrap at the width), truncate")
+	rootCmd.Flags().BoolVar(&footer, "footer", false,
+		"Add a table footer with the scanned paths, scan duration (walk and merge phases) and error count")
+	rootCmd.Flags().BoolVar(&timing, "timing", false,
+		"Add the stats section with wall time, dirs/sec, files/sec, syscalls, errors, peak queue depth and per-worker utilization, to tune --workers")
+	rootCmd.Flags().BoolVar(&splitByRoot, "separate-roots", false,
+		"Report each path given separately, followed by the combined total")
+	rootCmd.Flags().BoolVar(&totals, "totals", false,
+		"Add a row with grand totals and a \"Size %\" column with each row's share of the total size to per-year and per-uid tables")
+	rootCmd.Flags().StringVar(&unitsName, "units", "",
+		"Units of sizes in tables, csv, html and template output: binary (KiB, MiB, ...), si (kB, MB, ... powers of 1000) or raw (exact byte counts) (default: KB, MB, ... powers of 1024)")
+	rootCmd.Flags().StringVar(&countsSpec, "counts", "",
+		"Form of inode and file counts in table and html output: plain (1234567), grouped (1,234,567) or compact (1.2M); per format as FORMAT=FORM, e.g. table=compact,html=grouped (default: plain)")
+	rootCmd.Flags().StringVar(&countSep, "count-separator", "",
+		"Thousands separator of grouped counts (default: from LC_ALL, LC_NUMERIC or LANG, e.g. \".\" for de_DE, \",\" for en_US)")
+	rootCmd.Flags().BoolVar(&rawBytes, "show-raw-bytes", false,
+		"Follow sizes in tables with the exact byte count, e.g. \"1.5 GB (1610612736)\"")
+	rootCmd.Flags().StringVar(&groupBy, "group-by", "mtime",
+		"Timestamp years are taken from: mtime, btime (creation time); and/or dimensions to cross-tabulate in the groups output mode: uid, user, gid, group, year, ext, type (comma-separated, e.g. uid,year)")
+	rootCmd.Flags().StringVar(&groupByExpr, "group-by-expr", "",
+		"Go template computing a cross tabulation key per entry for the groups output mode, e.g. '{{.User}}/{{.Ext}}' (fields: FileInfo fields, Name, Ext, Dir, Top, Year, User, Group, Type; functions: lower, upper, prefix)")
+	rootCmd.Flags().StringVar(&sortBy, "sort", "",
+		"Sort per-year, per-uid and groups rows by: size, inodes, files, dirs, avg-file-size, files-per-dir, symlink-pct (largest first), name (per-uid and groups), year (per-year and groups, newest first); list and paths rows by: size (largest first), mtime (oldest first), path, owner")
+	rootCmd.Flags().BoolVar(&reverse, "reverse", false,
+		"Reverse the order of per-year, per-uid, groups, list and paths rows")
+	rootCmd.Flags().IntVar(&limit, "limit", 0,
+		"Show only the first N per-year, per-uid and groups rows after sorting, e.g. the biggest consumers with --sort size (0: all)")
+	rootCmd.Flags().StringVar(&splitSize, "split-size", "",
+		"Split the csv output file into numbered parts of at most this size (e.g., 1G) plus a manifest; requires exactly one csv file destination (--output-format csv --output-file, or --output csv:FILE)")
+	rootCmd.Flags().StringVar(&splitRows, "split-rows", "",
+		"Split the csv output file into numbered parts of at most this many rows (e.g., 10M) plus a manifest; requires exactly one csv file destination (--output-format csv --output-file, or --output csv:FILE)")
+	rootCmd.Flags().BoolVarP(&nullSep, "null", "0", false,
+		"Terminate paths with a NUL byte instead of a newline, like find -print0, for xargs -0; selects the paths output mode unless --output-mode is given")
+	rootCmd.Flags().StringVar(&templateFile, "template", "",
+		"Go text/template file rendering the results (fields: Summary, ByYear, ByUID, ...; functions: humanBytes, percent, date, username, groupname, uidsBySize, json, lower, upper); selects the template output format unless --output-format is given")
+	rootCmd.Flags().StringVar(&csvDelimiter, "csv-delimiter", "comma",
+		"Field delimiter of CSV output: comma, semicolon, tab, pipe or a single character")
+	rootCmd.Flags().BoolVar(&csvDecComma, "csv-decimal-comma", false,
+		"Write decimals in CSV output with a comma, as spreadsheets in many European locales expect (requires a delimiter other than comma)")
+	rootCmd.Flags().StringVar(&csvSizes, "csv-sizes", "human",
+		"Sizes in CSV output: human (e.g. 1.5 GB), raw (byte counts) or both (a byte count column after each size column)")
+	rootCmd.Flags().StringVar(&columns, "columns", "",
+		"Columns of list output: mode, octal, links, uid, gid, owner, group, size, mtime, type, depth, path (comma-separated, default: mode,links,owner,group,size,mtime,path)")
 
 	// Filter flags
-	rootCmd.Flags().StringVar(&filterType, "type", "",
-		"Filter by inode type: file, dir, symlink, other (comma-separated)")
-	rootCmd.Flags().StringVar(&filterMtimeOlderStr, "mtime-older", "",
-		"Filter files modified older than (e.g., 7d, 2w, 30m, 1y)")
-	rootCmd.Flags().StringVar(&filterMtimeYoungerStr, "mtime-younger", "",
-		"Filter files modified younger than (e.g., 1d, 24h)")
-	rootCmd.Flags().StringVar(&filterSizeMin, "size-min", "",
-		"Minimum file size (e.g., 1K, 100M, 1G)")
-	rootCmd.Flags().StringVar(&filterSizeMax, "size-max", "",
-		"Maximum file size (e.g., 1K, 100M, 1G)")
-	rootCmd.Flags().StringVar(&filterNameRegex, "name", "",
-		"Filter by filename regex pattern")
-	rootCmd.Flags().StringVar(&filterUsernames, "username", "",
-		"Filter by username (comma-separated)")
-	rootCmd.Flags().StringVar(&filterUIDs, "uid", "",
-		"Filter by UID (comma-separated)")
-	rootCmd.Flags().StringVar(&filterGroupnames, "groupname", "",
-		"Filter by group name (comma-separated)")
-	rootCmd.Flags().StringVar(&filterGIDs, "gid", "",
-		"Filter by GID (comma-separated)")
-	rootCmd.Flags().StringVar(&filterPerms, "perms-has", "",
-		"Filter by required permission bits (e.g., u+r,g+x)")
-	rootCmd.Flags().StringVar(&filterPermsNot, "perms-not", "",
-		"Filter by forbidden permission bits (e.g., o+w)")
+	filterOpts.register(rootCmd.Flags())
+	rootCmd.Flags().StringArrayVar(&filterOr, "or", nil,
+		"Alternative group of filter flags, e.g. \"--groupname bob\"; entries matching the other filter flags or any group are included (repeatable)")
+	rootCmd.Flags().StringArrayVar(&filterNot, "not", nil,
+		"Group of filter flags, e.g. \"--username root --type dir\"; entries matching any group are excluded (repeatable)")
+
+	// Pruning flags
+	rootCmd.Flags().IntVar(&maxDepth, "max-depth", -1,
+		"Walk at most this many levels below each path; deeper directories are not read (0: only the paths themselves, -1: unlimited)")
+	rootCmd.Flags().StringArrayVar(&excludePaths, "exclude-path", nil,
+		"Skip entries matching a shell-style pattern like --name-glob (e.g., '.snapshot', 'home/*/cache') without reading matching directories (repeatable)")
+
+	// Extended attribute options
+	rootCmd.Flags().BoolVar(&xattrs, "xattrs", false,
+		"Collect extended attributes and POSIX ACL presence for the xattrs output mode (Linux only)")
+	rootCmd.Flags().BoolVar(&selinux, "selinux", false,
+		"Collect SELinux security contexts for the selinux output mode (Linux only)")
+	rootCmd.Flags().BoolVar(&lustre, "lustre", false,
+		"Collect project IDs and Lustre stripe layouts for the per-project-id and striping output modes (Linux only)")
+
+	// Watchlist options
+	rootCmd.Flags().StringVar(&watchlistFile, "watchlist", "",
+		"Ransomware watchlist file replacing the built-in list (one extension or marker name per line)")
+
+	// Quota options
+	rootCmd.Flags().StringVar(&quotaFile, "quota-file", "",
+		"Check usage against the size limits of users and directories in this CSV or YAML file; exits with status 2 if a quota is exceeded")
+
+	// Project options
+	rootCmd.Flags().StringVar(&projectMarkers, "project-markers", strings.Join(stat.DefaultProjectMarkers, ","),
+		"Names of the entries that mark a project directory for the projects output mode (comma-separated)")
+
+	// Baseline options
+	rootCmd.Flags().StringVar(&baselineFile, "baseline", "",
+		"Previous result written with --output-format json; per-year, per-uid and groups tables show each group's growth since")
+	rootCmd.Flags().StringVar(&baselineAlert, "baseline-alert", "10%",
+		"Highlight groups that grew by more than this percentage since --baseline")
+
+	// Notification options
+	rootCmd.Flags().StringVar(&notifyWebhook, "notify-webhook", "",
+		"POST the results to this URL once the scan completes or a --notify-on event occurs")
+	rootCmd.Flags().StringVar(&notifyFormat, "notify-format", "json",
+		"Webhook payload: json (the JSON document of the output modes), text (a plain summary) or slack (the summary as a Slack-compatible message)")
+	rootCmd.Flags().StringVar(&notifyOn, "notify-on", notifyDone,
+		"Events to notify of, comma-separated: done (every completed scan), quota (a --quota-file quota is exceeded), growth (the size grew more than --notify-growth)")
+	rootCmd.Flags().StringVar(&notifyGrowthSpec, "notify-growth", "",
+		"Size growth since the previous snapshot of --snapshot-compare or --history that triggers the growth event (e.g., 100G or 10%)")
+	rootCmd.Flags().StringVar(&notifyEmail, "notify-email", "",
+		"Mail a plain summary to these addresses (comma-separated) once the scan completes or a --notify-on event occurs")
+	rootCmd.Flags().StringVar(&smtpServer, "smtp-server", "localhost:25",
+		"SMTP server host:port for --notify-email; STARTTLS is used if offered")
+	rootCmd.Flags().StringVar(&smtpFrom, "smtp-from", "",
+		"Sender address of --notify-email (default: cwalk@<hostname>)")
+	rootCmd.Flags().StringVar(&smtpUser, "smtp-user", "",
+		"SMTP user for --notify-email; the password is read from $"+smtpPasswordEnv)
+
+	// Archive options
+	rootCmd.Flags().BoolVar(&archives, "archives", false,
+		"Include the entries of .tar, .tar.gz, .tgz and .zip files under virtual paths below each archive")
+
+	// Name resolution options
+	rootCmd.Flags().BoolVar(&numeric, "numeric", false,
+		"Show numeric UIDs and GIDs instead of user and group names and skip all name lookups (like ls -n)")
+	rootCmd.Flags().BoolVar(&preloadNames, "preload-names", false,
+		"Load /etc/passwd and /etc/group into the name cache before walking; other users and groups are still looked up through NSS")
+
+	// Symlink options
+	rootCmd.Flags().BoolVar(&symlinkCheck, "symlink-check", false,
+		"Read symlink targets and report broken, absolute, relative and root-escaping links; selects the symlinks output mode unless --output-mode is given")
+
+	// Exec options
+	rootCmd.Flags().StringVar(&execCmd, "exec", "",
+		"Run a command on each matched entry instead of printing a report, e.g. 'chmod g+w {} ;' ({}: path, {/}: base name, {//}: parent, {.}: path without extension, {/.}: base name without extension)")
+	rootCmd.Flags().StringVar(&execBatch, "exec-batch", "",
+		"Run a command on batches of matched entries instead of printing a report, e.g. 'tar -rf out.tar {} +'")
+	rootCmd.Flags().IntVar(&execJobs, "exec-jobs", 0,
+		"Commands run in parallel by --exec and --exec-batch (0: GOMAXPROCS)")
+	rootCmd.Flags().BoolVar(&dryRun, "dry-run", false,
+		"Print the commands of --exec and --exec-batch instead of running them")
+
+	// Snapshot options
+	rootCmd.Flags().StringVar(&snapshotSave, "snapshot-save", "",
+		"Save a snapshot of all scanned entries to this file")
+	rootCmd.Flags().StringVar(&snapshotCompare, "snapshot-compare", "",
+		"Compute churn against a snapshot saved by a previous scan")
+	rootCmd.Flags().StringVar(&snapshotLoad, "snapshot-load", "",
+		"Report on a saved snapshot instead of scanning paths (offline exploration)")
+	rootCmd.Flags().StringVar(&historyDir, "history", "",
+		"Append a snapshot to this history directory and compute churn against the previous one")
+	rootCmd.Flags().IntVar(&historyFullEvery, "history-full-every", stat.DefaultHistoryFullEvery,
+		"Store a full snapshot in the history every N snapshots, deltas otherwise")
+
+	// Checkpoint options
+	rootCmd.Flags().StringVar(&checkpointFile, "checkpoint", "",
+		"Save the scan state to this file periodically and after each root, so an interrupted scan can be resumed; removed once the scan completes")
+	rootCmd.Flags().StringVar(&checkpointInterval, "checkpoint-interval", "5m",
+		"Interval between checkpoints (e.g., 30s, 5m, 1h)")
+	rootCmd.Flags().BoolVar(&resumeScan, "resume", false,
+		"Resume the scan saved in the --checkpoint file, if any; the paths and flags must be those of the interrupted scan")
 
 	// Worker options
-	rootCmd.Flags().IntVar(&workers, "workers", 4,
-		"Number of parallel workers")
+	rootCmd.Flags().StringVar(&workersFlag, "workers", "4",
+		"Number of parallel workers, or \"auto\" to start with GOMAXPROCS and tune based on syscall latency and queue depth")
+	rootCmd.Flags().StringVar(&throttleSpec, "throttle", "",
+		"Limit metadata load: calls per second (e.g., 500) and/or bytes per second (e.g., 2MB/s), comma-separated")
+	rootCmd.Flags().BoolVar(&nice, "nice", false,
+		"Run at the lowest CPU priority and in the idle IO class, like nice -n 19 ionice -c 3 (Linux only)")
+	rootCmd.Flags().IntVar(&ioConcurrency, "io-concurrency", 0,
+		"Max stat/readdir calls in flight across all workers; each worker stats directory entries concurrently (0: one call per worker)")
+
+	// Metadata options
+	rootCmd.Flags().StringVar(&statxFields, "statx", "",
+		"Use statx without forcing attribute sync, requesting only these fields: mode, size, mtime, owner, ino, btime, all (comma-separated, Linux only)")
+	rootCmd.Flags().StringVar(&backendName, "backend", "lstat",
+		"Metadata backend: lstat, statx, iouring (experimental, Linux only; falls back to statx when unsupported), or cephfs (totals of CephFS roots from their recursive statistics without walking, for the summary, stats and inode-usage modes only; walks with lstat otherwise)")
+	rootCmd.Flags().StringVar(&traversal, "traversal", "dfs",
+		"Order directories are read in: dfs (depth first), bfs (breadth first, spreads shallow wide trees over workers sooner) or largest (largest directories first, for steadier progress)")
+	rootCmd.Flags().BoolVar(&inodeOrder, "inode-order", false,
+		"Stat the entries of each directory in inode number order, which cuts seeks on spinning disks (Linux only)")
+	rootCmd.Flags().BoolVar(&physical, "physical", false,
+		"Report the physical size of regular files on disk after compression against their logical size in the summary, from Btrfs extents (needs root) or allocated blocks, as on ZFS (Linux only)")
+	rootCmd.Flags().IntVar(&dirBatch, "readdir-batch", 0,
+		"Read directories this many entries at a time, bounding memory for huge directories (0 reads whole directories)")
+	rootCmd.Flags().IntVar(&maxOpenDirs, "max-open-dirs", 0,
+		"Max directories open at once across all workers; more wait instead of failing (0: half the open file limit, -1: unlimited)")
+
+	// Remote options
+	rootCmd.Flags().IntVar(&sftpConns, "sftp-connections", 4,
+		"SSH connections per sftp://[user@]host[:port]/path root; requests of all workers are spread over them")
 }
 
 // runWalk executes the directory walk with specified filters and outputs results.
 // It parses all CLI flags into filter objects, performs the walk, and formats output.
 func runWalk(cmd *cobra.Command, args []string) error {
-	// Parse filters
-	filters := &stat.Filters{}
+	// Workbooks are binary and cannot be printed
+	if outputFormat == "xlsx" && outputFile == "" {
+		return fmt.Errorf("xlsx output requires --output-file")
+	}
+	if symlinkCheck && !cmd.Flags().Changed("output-mode") {
+		outputMode = "symlinks"
+	}
+	if nullSep && !cmd.Flags().Changed("output-mode") {
+		outputMode = "paths"
+	}
+	if templateFile != "" && !cmd.Flags().Changed("output-format") {
+		outputFormat = "template"
+	}
+
+	// Each --output is a destination, replacing --output-format and --output-file
+	dests := []outputDest{{format: outputFormat, path: outputFile}}
+	if outputFile == "" {
+		dests[0].path = "-"
+	}
+	if len(outputs) > 0 {
+		if cmd.Flags().Changed("output-format") || cmd.Flags().Changed("output-file") {
+			return fmt.Errorf("--output cannot be combined with --output-format or --output-file")
+		}
+		var err error
+		if dests, err = parseOutputs(outputs); err != nil {
+			return fmt.Errorf("invalid --output: %w", err)
+		}
+	}
+	var formats []string
+	for _, dest := range dests {
+		formats = append(formats, dest.format)
+	}
 
-	if filterType != "" {
-		filters.Types = parseInodeTypes(filterType)
+	if nullSep && (outputMode != "paths" || slices.ContainsFunc(formats, func(format string) bool { return format != "table" })) {
+		return fmt.Errorf("--null only applies to the paths output mode with the table output format")
+	}
+	tmpl, err := parseTemplateFile(templateFile, formats)
+	if err != nil {
+		return err
 	}
 
-	if filterMtimeOlderStr != "" {
-		older, err := parseDuration(filterMtimeOlderStr)
+	timeField, crossDims, err := parseGroupBy(groupBy)
+	if err != nil {
+		return fmt.Errorf("invalid --group-by: %w", err)
+	}
+	var groupExpr *stat.GroupExpr
+	if groupByExpr != "" {
+		groupExpr, err = stat.ParseGroupExpr(groupByExpr)
 		if err != nil {
-			return fmt.Errorf("invalid --mtime-older: %w", err)
+			return fmt.Errorf("invalid --group-by-expr: %w", err)
 		}
-		filters.MtimeOlderThan = &older
+	}
+	if (len(crossDims) > 0 || groupExpr != nil) && !cmd.Flags().Changed("output-mode") {
+		outputMode = "groups"
+	}
+	if quotaFile != "" && !cmd.Flags().Changed("output-mode") {
+		outputMode = "quota"
+	}
+	if cmd.Flags().Changed("project-markers") && !cmd.Flags().Changed("output-mode") {
+		outputMode = "projects"
+	}
+	modes, err := output.ParseModes(outputMode)
+	if err != nil {
+		return fmt.Errorf("invalid --output-mode: %w", err)
+	}
+	if timing && !slices.Contains(modes, "stats") {
+		modes = append(modes, "stats")
+	}
+	if slices.Contains(modes, "groups") && len(crossDims) == 0 && groupExpr == nil {
+		return fmt.Errorf("groups output requires --group-by with dimensions, e.g. uid,year, or --group-by-expr")
+	}
+	if slices.Contains(modes, "quota") && quotaFile == "" {
+		return fmt.Errorf("quota output requires --quota-file")
+	}
+	markers := parseStringList(projectMarkers)
+	if slices.Contains(modes, "projects") && len(markers) == 0 {
+		return fmt.Errorf("projects output requires --project-markers with at least one name")
+	}
+	for _, marker := range markers {
+		if strings.Contains(marker, "/") {
+			return fmt.Errorf("invalid --project-markers: %s is a path, not a name", marker)
+		}
+	}
+	var baseline *output.Baseline
+	var alert float64
+	if baselineFile != "" {
+		if !slices.ContainsFunc(modes, func(mode string) bool { return mode == "per-year" || mode == "per-uid" || mode == "groups" }) {
+			return fmt.Errorf("--baseline applies to the per-year, per-uid and groups output modes")
+		}
+		if baseline, err = output.LoadBaseline(baselineFile); err != nil {
+			return fmt.Errorf("invalid --baseline: %w", err)
+		}
+	}
+	if alert, err = strconv.ParseFloat(strings.TrimSuffix(baselineAlert, "%"), 64); err != nil || alert < 0 {
+		return fmt.Errorf("invalid --baseline-alert: %s", baselineAlert)
 	}
 
-	if filterMtimeYoungerStr != "" {
-		younger, err := parseDuration(filterMtimeYoungerStr)
-		if err != nil {
-			return fmt.Errorf("invalid --mtime-younger: %w", err)
+	var quotas []stat.Quota
+	if quotaFile != "" {
+		if quotas, err = stat.LoadQuotas(quotaFile); err != nil {
+			return fmt.Errorf("invalid --quota-file: %w", err)
 		}
-		filters.MtimeYoungerThan = &younger
 	}
 
-	if filterSizeMin != "" {
-		sizeMin, err := parseSize(filterSizeMin)
-		if err != nil {
-			return fmt.Errorf("invalid --size-min: %w", err)
+	// Parse filters
+	filters, filterSets, err := buildFilters(&filterOpts, filterOr, filterNot)
+	if err != nil {
+		return err
+	}
+	for _, f := range filterSets {
+		if numeric && (len(f.Usernames) > 0 || len(f.Groupnames) > 0) {
+			return fmt.Errorf("--numeric cannot be combined with --username or --groupname; use --uid or --gid")
 		}
-		filters.SizeMin = &sizeMin
 	}
+	needs := needsOf(filterSets)
+	stat.DefaultResolver().SetNumeric(numeric)
 
-	if filterSizeMax != "" {
-		sizeMax, err := parseSize(filterSizeMax)
-		if err != nil {
-			return fmt.Errorf("invalid --size-max: %w", err)
+	sortKey, err := parseModesSortKey(sortBy, modes)
+	if err != nil {
+		return fmt.Errorf("invalid --sort: %w", err)
+	}
+	if limit < 0 {
+		return fmt.Errorf("invalid --limit: %d", limit)
+	}
+	units, err := parseUnits(unitsName)
+	if err != nil {
+		return fmt.Errorf("invalid --units: %w", err)
+	}
+	counts, err := parseCounts(countsSpec)
+	if err != nil {
+		return fmt.Errorf("invalid --counts: %w", err)
+	}
+	if countSep == "" {
+		countSep = localeSeparator()
+	}
+	if !slices.Contains([]string{"auto", "always", "never"}, colorMode) {
+		return fmt.Errorf("invalid --color: must be auto, always or never: %s", colorMode)
+	}
+	style, err := parseTableStyle(tableStyle)
+	if err != nil {
+		return fmt.Errorf("invalid --table-style: %w", err)
+	}
+	if maxColWidth < 0 {
+		return fmt.Errorf("invalid --max-col-width: %d", maxColWidth)
+	}
+	wrap, err := parseWrap(wrapMode)
+	if err != nil {
+		return fmt.Errorf("invalid --wrap: %w", err)
+	}
+
+	listColumns, err := output.ParseColumns(columns)
+	if err != nil {
+		return fmt.Errorf("invalid --columns: %w", err)
+	}
+
+	csvOpts, err := parseCSVOptions(cmd, formats)
+	if err != nil {
+		return err
+	}
+
+	var split output.SplitLimits
+	if splitSize != "" {
+		if split.MaxBytes, err = parseSize(splitSize); err != nil || split.MaxBytes <= 0 {
+			return fmt.Errorf("invalid --split-size: %s", splitSize)
+		}
+	}
+	if splitRows != "" {
+		if split.MaxRows, err = parseCount(splitRows); err != nil || split.MaxRows <= 0 {
+			return fmt.Errorf("invalid --split-rows: %s", splitRows)
+		}
+	}
+	if split != (output.SplitLimits{}) {
+		if err := checkSplitDests(dests); err != nil {
+			return err
+		}
+		if len(modes) > 1 {
+			return fmt.Errorf("--split-size and --split-rows require a single output mode")
+		}
+		if splitByRoot {
+			return fmt.Errorf("--split-size and --split-rows cannot be combined with --separate-roots")
 		}
-		filters.SizeMax = &sizeMax
 	}
 
-	if filterNameRegex != "" {
-		re, err := regexp.Compile(filterNameRegex)
-		if err != nil {
-			return fmt.Errorf("invalid --name regex: %w", err)
+	var execs *execSpec
+	switch {
+	case execCmd != "" && execBatch != "":
+		return fmt.Errorf("--exec and --exec-batch cannot be combined")
+	case execCmd != "":
+		if execs, err = parseExec(execCmd, false); err != nil {
+			return fmt.Errorf("invalid --exec: %w", err)
 		}
-		filters.NameRegex = re
+	case execBatch != "":
+		if execs, err = parseExec(execBatch, true); err != nil {
+			return fmt.Errorf("invalid --exec-batch: %w", err)
+		}
+	case dryRun:
+		return fmt.Errorf("--dry-run requires --exec or --exec-batch")
+	}
+	if execs != nil && slices.ContainsFunc(args, sftp.IsURL) {
+		return fmt.Errorf("--exec and --exec-batch cannot run commands on sftp:// roots")
+	}
+	notify, err := parseNotifier()
+	if err != nil {
+		return err
+	}
+	if execs != nil && notify != nil {
+		return fmt.Errorf("--notify-webhook and --notify-email cannot be combined with --exec or --exec-batch")
+	}
+
+	workers, maxWorkers, err := parseWorkers(workersFlag)
+	if err != nil {
+		return fmt.Errorf("invalid --workers: %w", err)
 	}
 
-	if filterUsernames != "" {
-		filters.Usernames = parseStringList(filterUsernames)
+	// Create walker and collect stats
+	walker := stat.NewStatsWalker(args, workers, filters)
+	walker.SetAutoWorkers(maxWorkers)
+	walker.SetGroupBy(timeField)
+	walker.SetCrossTab(crossDims)
+	walker.SetGroupExpr(groupExpr)
+	walker.SetArchives(archives)
+	walker.SetSymlinkCheck(symlinkCheck)
+	walker.SetEmptyCheck(needs.empty || slices.Contains(modes, "empty"))
+	walker.SetXattrs(xattrs || slices.Contains(modes, "xattrs") || needs.xattrs)
+	walker.SetSELinux(selinux || slices.Contains(modes, "selinux") || needs.selinux)
+	walker.SetLustre(lustre || slices.Contains(modes, "per-project-id") || slices.Contains(modes, "striping"))
+	if slices.Contains(modes, "projects") {
+		walker.SetProjectMarkers(markers)
+	}
+	walker.SetSeparateRoots(splitByRoot)
+	walker.SetMaxDepth(maxDepth)
+	excludes, err := parseExcludePaths(excludePaths)
+	if err != nil {
+		return err
 	}
+	walker.SetExcludePaths(excludes)
 
-	if filterUIDs != "" {
-		uids, err := parseUintList(filterUIDs)
+	var mask cwalk.StatxMask
+	if statxFields != "" {
+		mask, err = parseStatxFields(statxFields)
 		if err != nil {
-			return fmt.Errorf("invalid --uid: %w", err)
+			return fmt.Errorf("invalid --statx: %w", err)
+		}
+	}
+	// Linux only reports birth times through statx
+	if needs.btime || timeField == stat.GroupByBtime {
+		if mask == 0 {
+			mask = cwalk.StatxAll
 		}
-		filters.UIDs = uids
+		mask |= cwalk.StatxBtime
+	}
+	walker.SetStatx(mask)
+
+	// The cephfs backend reads the recursive statistics of the roots and
+	// walks whatever they cannot answer with lstat
+	metadataBackend := backendName
+	if backendName == "cephfs" {
+		metadataBackend = "lstat"
+		totalsOnly := !slices.ContainsFunc(modes, func(mode string) bool {
+			return mode != "summary" && mode != "stats" && mode != "inode-usage"
+		})
+		walker.SetCephFS(totalsOnly && execs == nil && quotaFile == "" && snapshotSave == "" &&
+			snapshotCompare == "" && snapshotLoad == "" && historyDir == "")
 	}
+	backend, err := cwalk.ParseBackend(metadataBackend)
+	if err != nil {
+		return fmt.Errorf("invalid --backend: %w", err)
+	}
+	walker.SetBackend(backend)
 
-	if filterGroupnames != "" {
-		filters.Groupnames = parseStringList(filterGroupnames)
+	order, err := cwalk.ParseOrder(traversal)
+	if err != nil {
+		return fmt.Errorf("invalid --traversal: %w", err)
 	}
+	walker.SetOrder(order)
+	walker.SetInodeOrder(inodeOrder)
+	walker.SetPhysical(physical)
+	walker.SetReadDirBatch(dirBatch)
+	walker.SetMaxOpenDirs(maxOpenDirs)
+	walker.SetIOConcurrency(ioConcurrency)
+	walker.SetSFTPConnections(sftpConns)
 
-	if filterGIDs != "" {
-		gids, err := parseUintList(filterGIDs)
+	if throttleSpec != "" {
+		throttle, err := parseThrottle(throttleSpec)
 		if err != nil {
-			return fmt.Errorf("invalid --gid: %w", err)
+			return fmt.Errorf("invalid --throttle: %w", err)
 		}
-		filters.GIDs = gids
+		walker.SetThrottle(throttle)
 	}
 
-	if filterPerms != "" {
-		perms, err := parsePerms(filterPerms)
-		if err != nil {
-			return fmt.Errorf("invalid --perms-has: %w", err)
+	if nice {
+		if err := lowerPriority(); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: --nice: %v\n", err)
 		}
-		filters.PermsHas = perms
 	}
 
-	if filterPermsNot != "" {
-		perms, err := parsePerms(filterPermsNot)
-		if err != nil {
-			return fmt.Errorf("invalid --perms-not: %w", err)
+	if resumeScan && checkpointFile == "" {
+		return fmt.Errorf("--resume requires --checkpoint")
+	}
+	if checkpointFile != "" {
+		if snapshotLoad != "" {
+			return fmt.Errorf("--checkpoint cannot be combined with --snapshot-load")
+		}
+		interval, err := parseDuration(checkpointInterval)
+		if err != nil || interval <= 0 {
+			return fmt.Errorf("invalid --checkpoint-interval: %s", checkpointInterval)
+		}
+		walker.SetCheckpoint(checkpointFile, interval)
+	}
+	if resumeScan {
+		// A missing checkpoint means the previous scan never saved one
+		cp, err := stat.LoadCheckpoint(checkpointFile)
+		switch {
+		case err == nil:
+			walker.SetResume(cp)
+			fmt.Fprintf(os.Stderr, "Resuming scan from checkpoint of %s (%d entries, %d directories pending)\n",
+				cp.Time.Format(time.RFC3339), len(cp.Entries), len(cp.Pending))
+		case !errors.Is(err, os.ErrNotExist):
+			return fmt.Errorf("invalid --checkpoint: %w", err)
 		}
-		filters.PermsNot = perms
 	}
 
-	// Create walker and collect stats
-	walker := stat.NewStatsWalker(args, workers, filters)
-	results, err := walker.Walk()
-	if err != nil {
-		return err
+	if preloadNames && !numeric {
+		resolver := stat.DefaultResolver()
+		if err := resolver.LoadPasswd("/etc/passwd"); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: --preload-names: %v\n", err)
+		}
+		if err := resolver.LoadGroup("/etc/group"); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: --preload-names: %v\n", err)
+		}
 	}
 
-	// Format and output results
-	formatter := output.NewFormatter(outputFormat, outputMode, noHeader)
-	out := formatter.Format(results)
+	if slices.Contains(modes, "watchlist") || watchlistFile != "" {
+		watchlist := stat.DefaultWatchlist()
+		if watchlistFile != "" {
+			var err error
+			watchlist, err = stat.LoadWatchlist(watchlistFile)
+			if err != nil {
+				return fmt.Errorf("invalid --watchlist: %w", err)
+			}
+		}
+		walker.SetWatchlist(watchlist)
+	}
 
-	// Write output
-	if outputFile != "" {
-		if err := formatter.WriteToFile(out, outputFile); err != nil {
-			return fmt.Errorf("failed to write output: %w", err)
+	// Scan the paths, or replay a saved snapshot
+	var results *stat.Results
+	roots, scanTime := args, time.Now()
+	if snapshotLoad != "" {
+		loaded, err := stat.LoadSnapshot(snapshotLoad)
+		if err != nil {
+			return fmt.Errorf("invalid --snapshot-load: %w", err)
+		}
+		roots, scanTime = loaded.Roots, loaded.Time
+		results, err = walker.WalkSnapshot(loaded)
+		if err != nil {
+			return err
 		}
-		fmt.Fprintf(os.Stderr, "Output written to: %s\n", outputFile)
 	} else {
-		fmt.Print(out)
+		results, err = walker.Walk()
+		if err != nil {
+			return err
+		}
 	}
 
-	return nil
+	if snapshotSave != "" || snapshotCompare != "" || historyDir != "" {
+		snapshot := stat.NewSnapshot(roots, results, scanTime)
+
+		var history *stat.History
+		if historyDir != "" {
+			history = stat.NewHistory(historyDir, historyFullEvery)
+		}
+
+		if snapshotCompare != "" {
+			previous, err := stat.LoadSnapshot(snapshotCompare)
+			if err != nil {
+				return fmt.Errorf("invalid --snapshot-compare: %w", err)
+			}
+			results.Churn = stat.ComputeChurn(previous, snapshot)
+		} else if history != nil {
+			previous, err := history.Latest()
+			if err != nil {
+				return fmt.Errorf("invalid --history: %w", err)
+			}
+			if previous != nil {
+				results.Churn = stat.ComputeChurn(previous, snapshot)
+			}
+		}
+
+		if snapshotSave != "" {
+			if err := snapshot.Save(snapshotSave); err != nil {
+				return fmt.Errorf("failed to save snapshot: %w", err)
+			}
+		}
+		if history != nil {
+			if err := history.Append(snapshot); err != nil {
+				return fmt.Errorf("failed to append to history: %w", err)
+			}
+		}
+	}
+
+	if quotaFile != "" {
+		results.Quotas = results.CheckQuotas(quotas)
+	}
+
+	// Run commands on the matched entries instead of reporting
+	if execs != nil {
+		jobs := execJobs
+		if jobs <= 0 {
+			jobs = runtime.GOMAXPROCS(0)
+		}
+		runner := &execRunner{jobs: jobs, dryRun: dryRun, stdout: os.Stdout, stderr: os.Stderr}
+		return runner.run(execs.commands(execPaths(results)))
+	}
+
+	// Format and write the results to each destination
+	for _, dest := range dests {
+		formatter := output.NewFormatter(dest.format, modes[0], noHeader)
+		if len(modes) > 1 {
+			formatter.SetModes(modes)
+		}
+		formatter.SetSeparateRoots(splitByRoot)
+		formatter.SetPlain(plain)
+		formatter.SetColor(useColor(colorMode, dest.path))
+		formatter.SetTableStyle(style)
+		formatter.SetMaxColWidth(maxColWidth, wrap)
+		formatter.SetFooter(footer)
+		formatter.SetTotals(totals)
+		formatter.SetRawBytes(rawBytes)
+		formatter.SetUnits(units)
+		if form, ok := counts[dest.format]; ok {
+			formatter.SetCounts(form, countSep)
+		} else {
+			formatter.SetCounts(counts[""], countSep)
+		}
+		formatter.SetSort(sortKey)
+		formatter.SetReverse(reverse)
+		formatter.SetLimit(limit)
+		formatter.SetColumns(listColumns)
+		formatter.SetNull(nullSep)
+		formatter.SetTemplate(tmpl)
+		formatter.SetCSV(csvOpts)
+		formatter.SetBaseline(baseline, alert)
+		var out string
+		if dest.format == "template" {
+			if out, err = formatter.ExecuteTemplate(results); err != nil {
+				return fmt.Errorf("template failed: %w", err)
+			}
+		} else {
+			out = formatter.Format(results)
+		}
+
+		// Write output
+		if dest.path == "-" {
+			fmt.Print(out)
+		} else if split != (output.SplitLimits{}) && dest.format == "csv" {
+			manifest, err := formatter.WriteSplit(out, dest.path, split)
+			if err != nil {
+				return fmt.Errorf("failed to write output: %w", err)
+			}
+			fmt.Fprintf(os.Stderr, "Output parts listed in: %s\n", manifest)
+		} else {
+			if err := formatter.WriteToFile(out, dest.path); err != nil {
+				return fmt.Errorf("failed to write output: %w", err)
+			}
+			fmt.Fprintf(os.Stderr, "Output written to: %s\n", dest.path)
+		}
+	}
+
+	// Notify unattended scans once the reports are written
+	var notifyErr error
+	if notify != nil {
+		if notifyErr = notify.notify(results, roots, modes); notifyErr != nil {
+			cmd.SilenceUsage = true
+		}
+	}
+
+	// Signal quota violations to cron jobs once the report is written
+	exceeded := 0
+	for i := range results.Quotas {
+		if results.Quotas[i].Exceeded() {
+			exceeded++
+		}
+	}
+	if exceeded > 0 {
+		cmd.SilenceUsage = true
+		return &exitError{code: exitQuotaExceeded, err: errors.Join(fmt.Errorf("%d of %d quotas exceeded", exceeded, len(results.Quotas)), notifyErr)}
+	}
+	return notifyErr
 }
 
 // Execute adds all child commands to the root command and executes it.
 func Execute() error {
+	registerCompletions()
 	return rootCmd.Execute()
 }
 
+// exitQuotaExceeded is the exit status when a quota of --quota-file is
+// exceeded, telling violations apart from failures, which exit with 1.
+const exitQuotaExceeded = 2
+
+// exitError is an error that sets the exit status of cwalk.
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string { return e.err.Error() }
+func (e *exitError) Unwrap() error { return e.err }
+
+// ExitCode returns the exit status for an error returned by Execute: the
+// status set by the command, or 1.
+func ExitCode(err error) int {
+	var exit *exitError
+	if errors.As(err, &exit) {
+		return exit.code
+	}
+	return 1
+}
+
+// outputDest is a destination of the results: a format and a file, or
+// "-" for stdout.
+type outputDest struct {
+	format string
+	path   string
+}
+
+// outputFormats are the formats a destination can be written in.
+var outputFormats = []string{"table", "json", "csv", "xlsx", "html", "template"}
+
+// parseOutputs parses the --output flags, each a FORMAT:PATH pair with "-"
+// or no path standing for stdout. Only one destination can be stdout, and
+// binary XLSX workbooks must go to a file.
+func parseOutputs(specs []string) ([]outputDest, error) {
+	var dests []outputDest
+	for _, spec := range specs {
+		format, path, _ := strings.Cut(spec, ":")
+		dest := outputDest{format: strings.ToLower(strings.TrimSpace(format)), path: path}
+		if dest.path == "" {
+			dest.path = "-"
+		}
+		if !slices.Contains(outputFormats, dest.format) {
+			return nil, fmt.Errorf("unsupported format %q in %q (valid: %s)", dest.format, spec, strings.Join(outputFormats, ", "))
+		}
+		if dest.format == "xlsx" && dest.path == "-" {
+			return nil, fmt.Errorf("xlsx output requires a file, e.g. xlsx:stats.xlsx")
+		}
+		if slices.ContainsFunc(dests, func(d outputDest) bool { return d.path == dest.path }) {
+			if dest.path == "-" {
+				return nil, fmt.Errorf("only one destination can be stdout")
+			}
+			return nil, fmt.Errorf("%s is given twice", dest.path)
+		}
+		dests = append(dests, dest)
+	}
+	return dests, nil
+}
+
+// checkSplitDests checks that the destinations have exactly one csv file
+// for --split-size and --split-rows to split. Other destinations are
+// written whole.
+func checkSplitDests(dests []outputDest) error {
+	files := 0
+	for _, dest := range dests {
+		if dest.format == "csv" && dest.path != "-" {
+			files++
+		}
+	}
+	switch {
+	case files == 0:
+		return fmt.Errorf("--split-size and --split-rows require a csv output file (--output-format csv --output-file FILE, or --output csv:FILE)")
+	case files > 1:
+		return fmt.Errorf("--split-size and --split-rows split a single csv output file, got %d", files)
+	}
+	return nil
+}
+
+// parseTemplateFile reads and parses the --template file. Returns nil
+// without one, which is only valid if no output format is template.
+func parseTemplateFile(path string, formats []string) (*template.Template, error) {
+	switch {
+	case path == "" && slices.Contains(formats, "template"):
+		return nil, fmt.Errorf("template output requires --template")
+	case path == "":
+		return nil, nil
+	case !slices.Contains(formats, "template"):
+		return nil, fmt.Errorf("--template only applies to the template output format")
+	}
+
+	text, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --template: %w", err)
+	}
+	tmpl, err := output.ParseTemplate(filepath.Base(path), string(text))
+	if err != nil {
+		return nil, fmt.Errorf("invalid --template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// parseCSVOptions parses the --csv-* flags, which only apply to the csv
+// output format.
+func parseCSVOptions(cmd *cobra.Command, formats []string) (output.CSVOptions, error) {
+	var opts output.CSVOptions
+	for _, name := range []string{"csv-delimiter", "csv-decimal-comma", "csv-sizes"} {
+		if cmd.Flags().Changed(name) && !slices.Contains(formats, "csv") {
+			return opts, fmt.Errorf("--%s only applies to the csv output format", name)
+		}
+	}
+
+	delim, err := output.ParseCSVDelimiter(csvDelimiter)
+	if err != nil {
+		return opts, fmt.Errorf("invalid --csv-delimiter: %w", err)
+	}
+	if csvDecComma && delim == ',' {
+		return opts, fmt.Errorf("--csv-decimal-comma requires a --csv-delimiter other than comma, e.g. semicolon")
+	}
+	opts.Delimiter = delim
+	opts.DecimalComma = csvDecComma
+
+	switch sizes := output.CSVSizes(strings.ToLower(csvSizes)); sizes {
+	case output.CSVSizesHuman, output.CSVSizesRaw, output.CSVSizesBoth:
+		opts.Sizes = sizes
+	default:
+		return opts, fmt.Errorf("invalid --csv-sizes: %s (valid: human, raw, both)", csvSizes)
+	}
+	return opts, nil
+}
+
 // parseInodeTypes parses a comma-separated list of inode type filters.
 // Valid types are: file, dir, symlink, other.
 func parseInodeTypes(s string) map[string]bool {
@@ -327,6 +1107,31 @@ func parseSize(s string) (int64, error) {
 	return int64(num * float64(multiplier)), nil
 }
 
+// parseCount parses row counts with decimal unit multipliers.
+// Supported units: K (thousand), M (million), G (billion).
+// Examples: "500", "100K", "10M"
+func parseCount(s string) (int64, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	multiplier := 1.0
+	switch {
+	case strings.HasSuffix(s, "K"):
+		multiplier = 1e3
+	case strings.HasSuffix(s, "M"):
+		multiplier = 1e6
+	case strings.HasSuffix(s, "G"):
+		multiplier = 1e9
+	}
+	if multiplier != 1 {
+		s = s[:len(s)-1]
+	}
+
+	num, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, err
+	}
+	return int64(num * multiplier), nil
+}
+
 // parseStringList parses a comma-separated list of strings, trimming whitespace.
 func parseStringList(s string) []string {
 	var result []string
@@ -356,17 +1161,25 @@ func parseUintList(s string) ([]uint32, error) {
 	return result, nil
 }
 
-// parsePerms parses permission strings in the format "who+bits" or "who-bits".
+// parsePerms parses a comma-separated list of permissions, each either an
+// octal mask or in the format "who+bits" or "who-bits".
 // who: u (user), g (group), o (other), a (all)
-// bits: r (read), w (write), x (execute)
-// Examples: "u+r", "g+x", "o+w"
+// bits: r (read), w (write), x (execute), s (setuid for u, setgid for g,
+// both for a; not for o), t (sticky, for o or a)
+// Examples: "u+r", "g+x", "o+w", "u+s", "4000", "1000,o+w"
 func parsePerms(s string) (uint32, error) {
-	// Parse permission strings like "u+r", "g+x", "o+w"
 	var perms uint32
 
 	parts := strings.Split(s, ",")
 	for _, part := range parts {
 		part = strings.TrimSpace(part)
+		if octal, err := strconv.ParseUint(part, 8, 32); err == nil {
+			if octal > 0o7777 {
+				return 0, fmt.Errorf("octal permission out of range: %s", part)
+			}
+			perms |= uint32(octal)
+			continue
+		}
 		if len(part) < 3 {
 			return 0, fmt.Errorf("invalid permission format: %s", part)
 		}
@@ -375,15 +1188,34 @@ func parsePerms(s string) (uint32, error) {
 		op := part[1]
 		what := part[2:]
 
-		var bits uint32
-		if strings.Contains(what, "r") {
-			bits |= 4
-		}
-		if strings.Contains(what, "w") {
-			bits |= 2
-		}
-		if strings.Contains(what, "x") {
-			bits |= 1
+		var bits, special uint32
+		for _, c := range what {
+			switch c {
+			case 'r':
+				bits |= 4
+			case 'w':
+				bits |= 2
+			case 'x':
+				bits |= 1
+			case 's':
+				switch who {
+				case 'u':
+					special |= 0o4000
+				case 'g':
+					special |= 0o2000
+				case 'a':
+					special |= 0o6000
+				case 'o':
+					return 0, fmt.Errorf("setuid/setgid bit s does not apply to o: %s", part)
+				}
+			case 't':
+				if who == 'u' || who == 'g' {
+					return 0, fmt.Errorf("sticky bit t only applies to o or a: %s", part)
+				}
+				special |= 0o1000
+			default:
+				return 0, fmt.Errorf("invalid permission bit: %c", c)
+			}
 		}
 
 		switch who {
@@ -398,6 +1230,7 @@ func parsePerms(s string) (uint32, error) {
 		default:
 			return 0, fmt.Errorf("invalid permission who: %c", who)
 		}
+		perms |= special
 
 		if op != '+' && op != '-' {
 			return 0, fmt.Errorf("invalid permission operator: %c", op)
@@ -407,6 +1240,317 @@ func parsePerms(s string) (uint32, error) {
 	return perms, nil
 }
 
+// parseThrottle parses a comma-separated list of rates. A plain number
+// (optionally suffixed with /s) limits calls per second; a size with a
+// unit, as accepted by parseSize, limits metadata bytes per second.
+func parseThrottle(s string) (cwalk.Throttle, error) {
+	var t cwalk.Throttle
+	for _, item := range parseStringList(s) {
+		rate := strings.TrimSuffix(item, "/s")
+		if ops, err := strconv.ParseFloat(rate, 64); err == nil {
+			if ops <= 0 {
+				return t, fmt.Errorf("rate must be positive: %s", item)
+			}
+			t.OpsPerSec = ops
+			continue
+		}
+
+		bytes, err := parseSize(rate)
+		if err != nil {
+			return t, fmt.Errorf("invalid rate %q: %w", item, err)
+		}
+		if bytes <= 0 {
+			return t, fmt.Errorf("rate must be positive: %s", item)
+		}
+		t.BytesPerSec = float64(bytes)
+	}
+	if t.OpsPerSec == 0 && t.BytesPerSec == 0 {
+		return t, fmt.Errorf("no rate given")
+	}
+	return t, nil
+}
+
+// autoWorkersPerCPU bounds --workers=auto: the tuner may grow the pool to
+// this many workers per GOMAXPROCS, enough to keep network filesystems busy.
+const autoWorkersPerCPU = 16
+
+// parseWorkers parses the --workers value. It returns the initial worker
+// count and, for "auto", the maximum the tuner may grow to (0 otherwise).
+// Counts below one are passed through; the walker treats them as one.
+func parseWorkers(s string) (workers, maxWorkers int, err error) {
+	if s == "auto" {
+		procs := runtime.GOMAXPROCS(0)
+		return procs, procs * autoWorkersPerCPU, nil
+	}
+
+	workers, err = strconv.Atoi(s)
+	if err != nil {
+		return 0, 0, fmt.Errorf("must be a number or auto: %s", s)
+	}
+	return workers, 0, nil
+}
+
+// parseStatxFields parses a comma-separated list of statx field names.
+// Valid names are: mode, size, mtime, owner, ino, btime, all.
+func parseStatxFields(s string) (cwalk.StatxMask, error) {
+	var mask cwalk.StatxMask
+	for _, field := range parseStringList(s) {
+		switch field {
+		case "mode":
+			mask |= cwalk.StatxMode
+		case "size":
+			mask |= cwalk.StatxSize
+		case "mtime":
+			mask |= cwalk.StatxMtime
+		case "owner":
+			mask |= cwalk.StatxOwner
+		case "ino":
+			mask |= cwalk.StatxIno
+		case "btime":
+			mask |= cwalk.StatxBtime
+		case "all":
+			mask |= cwalk.StatxAll
+		default:
+			return 0, fmt.Errorf("unknown statx field: %s", field)
+		}
+	}
+	if mask == 0 {
+		return 0, fmt.Errorf("no statx fields given")
+	}
+	return mask, nil
+}
+
+// parseGroupBy parses a comma-separated list of the timestamp years are
+// taken from, mtime (the default) or btime, and the dimensions to
+// cross-tabulate. Dimensions are returned in the order given; none are
+// returned for a plain timestamp.
+func parseGroupBy(s string) (stat.TimeField, []stat.Dimension, error) {
+	field := stat.GroupByMtime
+	var dims []stat.Dimension
+	timestamps := 0
+	for _, item := range parseStringList(s) {
+		switch item {
+		case "mtime":
+			field = stat.GroupByMtime
+			timestamps++
+		case "btime":
+			field = stat.GroupByBtime
+			timestamps++
+		default:
+			dim, err := stat.ParseDimension(item)
+			if err != nil {
+				return 0, nil, fmt.Errorf("must be mtime, btime or a dimension: %w", err)
+			}
+			if slices.Contains(dims, dim) {
+				return 0, nil, fmt.Errorf("dimension given twice: %s", item)
+			}
+			dims = append(dims, dim)
+		}
+	}
+	if timestamps > 1 {
+		return 0, nil, fmt.Errorf("only one of mtime and btime can be given: %s", s)
+	}
+	if timestamps == 0 && len(dims) == 0 {
+		return 0, nil, fmt.Errorf("no timestamp or dimension given")
+	}
+	return field, dims, nil
+}
+
+// useColor reports whether table output written to path ("-" for stdout)
+// is colored with the given --color mode. Colors are automatically only
+// used on terminals, and never if the NO_COLOR environment variable is set,
+// so files and pipes get plain text.
+func useColor(mode, path string) bool {
+	switch mode {
+	case "always":
+		return true
+	case "never":
+		return false
+	}
+	if path != "-" || os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	fi, err := os.Stdout.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// parseTableStyle parses the --table-style flag.
+func parseTableStyle(s string) (output.TableStyle, error) {
+	switch strings.ToLower(s) {
+	case "dark":
+		return output.TableStyleDark, nil
+	case "light":
+		return output.TableStyleLight, nil
+	case "plain":
+		return output.TableStylePlain, nil
+	case "borderless":
+		return output.TableStyleBorderless, nil
+	default:
+		return 0, fmt.Errorf("must be dark, light, plain or borderless: %s", s)
+	}
+}
+
+// parseWrap parses the --wrap flag.
+func parseWrap(s string) (output.Wrap, error) {
+	switch strings.ToLower(s) {
+	case "soft":
+		return output.WrapSoft, nil
+	case "hard":
+		return output.WrapHard, nil
+	case "truncate":
+		return output.WrapTruncate, nil
+	default:
+		return 0, fmt.Errorf("must be soft, hard or truncate: %s", s)
+	}
+}
+
+// parseUnits parses the --units flag. Empty selects the default units,
+// powers of 1024 named KB, MB, ...
+func parseUnits(s string) (textfmt.Units, error) {
+	switch strings.ToLower(s) {
+	case "":
+		return textfmt.UnitsDefault, nil
+	case "binary":
+		return textfmt.UnitsBinary, nil
+	case "si":
+		return textfmt.UnitsSI, nil
+	case "raw":
+		return textfmt.UnitsRaw, nil
+	default:
+		return 0, fmt.Errorf("must be binary, si or raw: %s", s)
+	}
+}
+
+// parseCounts parses the --counts flag: comma-separated forms of counts,
+// each either for all formats or for one as FORMAT=FORM. Forms for a
+// format take precedence and are keyed by it, the form for all formats is
+// keyed by "".
+func parseCounts(s string) (map[string]textfmt.Counts, error) {
+	counts := map[string]textfmt.Counts{}
+	if s == "" {
+		return counts, nil
+	}
+	for _, spec := range strings.Split(s, ",") {
+		format, name, found := strings.Cut(strings.TrimSpace(spec), "=")
+		if !found {
+			format, name = "", format
+		} else if format = strings.ToLower(format); format != "table" && format != "html" {
+			return nil, fmt.Errorf("counts are only formatted in table and html output: %s", spec)
+		}
+		var form textfmt.Counts
+		switch strings.ToLower(name) {
+		case "plain":
+			form = textfmt.CountsPlain
+		case "grouped":
+			form = textfmt.CountsGrouped
+		case "compact":
+			form = textfmt.CountsCompact
+		default:
+			return nil, fmt.Errorf("must be plain, grouped or compact: %s", name)
+		}
+		if _, ok := counts[format]; ok {
+			return nil, fmt.Errorf("form given twice: %s", spec)
+		}
+		counts[format] = form
+	}
+	return counts, nil
+}
+
+// localeSeparator returns the thousands separator of the locale set by
+// LC_ALL, LC_NUMERIC or LANG, in this order: "." for German, Dutch, Italian,
+// Spanish, Portuguese, Danish, Turkish and Indonesian, "'" for Swiss
+// German, a space for French, Russian, Polish, Czech, Swedish, Finnish and
+// Norwegian, and "," otherwise.
+func localeSeparator() string {
+	locale := ""
+	for _, name := range []string{"LC_ALL", "LC_NUMERIC", "LANG"} {
+		if locale = os.Getenv(name); locale != "" {
+			break
+		}
+	}
+	if strings.HasPrefix(locale, "de_CH") {
+		return "'"
+	}
+	lang, _, _ := strings.Cut(locale, "_")
+	switch lang {
+	case "de", "nl", "it", "es", "pt", "da", "tr", "id":
+		return "."
+	case "fr", "ru", "pl", "cs", "sv", "fi", "nb", "nn":
+		return " "
+	}
+	return ","
+}
+
+// parseModesSortKey parses the --sort flag for several output modes, all
+// of which must accept it. Without --sort, list and paths sections next
+// to other modes keep their default order by path.
+func parseModesSortKey(s string, modes []string) (output.SortKey, error) {
+	var key output.SortKey
+	for i, mode := range modes {
+		k, err := parseSortKey(s, mode)
+		if err != nil {
+			return 0, err
+		}
+		if i > 0 && k != key {
+			// Only the defaults differ: by path and by group, which is
+			// also by path for list and paths output
+			k = output.SortByGroup
+		}
+		key = k
+	}
+	return key, nil
+}
+
+// parseSortKey parses the --sort flag for an output mode. An empty string
+// keeps the default order by year, UID or path.
+func parseSortKey(s, mode string) (output.SortKey, error) {
+	if mode == "list" || mode == "paths" {
+		switch s {
+		case "", "path":
+			return output.SortByPath, nil
+		case "size":
+			return output.SortBySize, nil
+		case "mtime":
+			return output.SortByMtime, nil
+		case "owner":
+			return output.SortByOwner, nil
+		default:
+			return 0, fmt.Errorf("must be size, mtime, path or owner for %s output: %s", mode, s)
+		}
+	}
+
+	switch s {
+	case "":
+		return output.SortByGroup, nil
+	case "size":
+		return output.SortBySize, nil
+	case "inodes":
+		return output.SortByInodes, nil
+	case "files":
+		return output.SortByFiles, nil
+	case "dirs":
+		return output.SortByDirs, nil
+	case "avg-file-size":
+		return output.SortByAvgFileSize, nil
+	case "files-per-dir":
+		return output.SortByFilesPerDir, nil
+	case "symlink-pct":
+		return output.SortBySymlinkPct, nil
+	case "name":
+		if mode == "per-year" {
+			return 0, fmt.Errorf("name does not apply to per-year output; use year")
+		}
+		return output.SortByName, nil
+	case "year":
+		if mode == "per-uid" {
+			return 0, fmt.Errorf("year does not apply to per-uid output")
+		}
+		return output.SortByYear, nil
+	default:
+		return 0, fmt.Errorf("must be size, inodes, files, dirs, avg-file-size, files-per-dir, symlink-pct, name or year: %s", s)
+	}
+}
+
 // isDigit returns true if the byte is a digit (0-9).
 func isDigit(c byte) bool {
 	return c >= '0' && c <= '9'
@@ -1,8 +1,15 @@
 package cmd
 
 import (
+	"runtime"
+	"slices"
 	"testing"
 	"time"
+
+	cwalk "github.com/otuschhoff/cwalk"
+	"github.com/otuschhoff/cwalk/pkg/output"
+	"github.com/otuschhoff/cwalk/pkg/stat"
+	"github.com/otuschhoff/cwalk/pkg/textfmt"
 )
 
 func TestParseInodeTypes(t *testing.T) {
@@ -116,6 +123,32 @@ func TestParseDuration(t *testing.T) {
 	}
 }
 
+func TestParseCount(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    int64
+		wantErr bool
+	}{
+		{input: "500", want: 500},
+		{input: "100K", want: 100000},
+		{input: "10M", want: 10000000},
+		{input: "1.5m", want: 1500000},
+		{input: "2G", want: 2000000000},
+		{input: "10X", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseCount(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseCount(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("parseCount(%q) = %d, want %d", tt.input, got, tt.want)
+		}
+	}
+}
+
 func TestParseSize(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -340,6 +373,36 @@ func TestParsePerms(t *testing.T) {
 			input:   "u+",
 			wantErr: true,
 		},
+		{
+			name:    "invalid bit",
+			input:   "u+q",
+			wantErr: true,
+		},
+		{
+			name:    "octal out of range",
+			input:   "17777",
+			wantErr: true,
+		},
+		{
+			name:    "setuid for other",
+			input:   "o+s",
+			wantErr: true,
+		},
+		{
+			name:    "sticky for user",
+			input:   "u+t",
+			wantErr: true,
+		},
+		{
+			name:    "sticky for group",
+			input:   "g-t",
+			wantErr: true,
+		},
+		{
+			name:    "setgid with other write",
+			input:   "g+s,o+w",
+			wantErr: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -352,6 +415,330 @@ func TestParsePerms(t *testing.T) {
 	}
 }
 
+func TestParsePermsBits(t *testing.T) {
+	tests := []struct {
+		input string
+		want  uint32
+	}{
+		{"u+rw,o+x", 0o601},
+		{"u+s", 0o4000},
+		{"g+s", 0o2000},
+		{"a+s", 0o6000},
+		{"o+t", 0o1000},
+		{"a+t", 0o1000},
+		{"u+xs", 0o4100},
+		{"4000", 0o4000},
+		{"0755", 0o755},
+		{"1000,o+w", 0o1002},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := parsePerms(tt.input)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %#o, want %#o", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseStatxFields(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    cwalk.StatxMask
+		wantErr bool
+	}{
+		{name: "single field", input: "size", want: cwalk.StatxSize},
+		{name: "multiple fields", input: "size, mtime,owner", want: cwalk.StatxSize | cwalk.StatxMtime | cwalk.StatxOwner},
+		{name: "all", input: "all", want: cwalk.StatxAll},
+		{name: "btime", input: "mtime,btime", want: cwalk.StatxMtime | cwalk.StatxBtime},
+		{name: "unknown field", input: "size,atime", wantErr: true},
+		{name: "empty list", input: ",", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseStatxFields(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error mismatch: got error %v, want error %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("mask mismatch: got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseWorkers(t *testing.T) {
+	procs := runtime.GOMAXPROCS(0)
+	tests := []struct {
+		name        string
+		input       string
+		wantWorkers int
+		wantMax     int
+		wantErr     bool
+	}{
+		{name: "fixed", input: "8", wantWorkers: 8},
+		{name: "auto", input: "auto", wantWorkers: procs, wantMax: procs * autoWorkersPerCPU},
+		{name: "zero passed through", input: "0", wantWorkers: 0},
+		{name: "garbage", input: "many", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workers, maxWorkers, err := parseWorkers(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error mismatch: got error %v, want error %v", err, tt.wantErr)
+			}
+			if workers != tt.wantWorkers || maxWorkers != tt.wantMax {
+				t.Errorf("got %d workers (max %d), want %d (max %d)", workers, maxWorkers, tt.wantWorkers, tt.wantMax)
+			}
+		})
+	}
+}
+
+func TestParseGroupBy(t *testing.T) {
+	tests := []struct {
+		input     string
+		wantField stat.TimeField
+		wantDims  []stat.Dimension
+		wantErr   bool
+	}{
+		{input: "mtime", wantField: stat.GroupByMtime},
+		{input: "btime", wantField: stat.GroupByBtime},
+		{input: "uid,year", wantField: stat.GroupByMtime, wantDims: []stat.Dimension{stat.DimUID, stat.DimYear}},
+		{input: "btime, user,ext", wantField: stat.GroupByBtime, wantDims: []stat.Dimension{stat.DimUser, stat.DimExt}},
+		{input: "ctime", wantErr: true},
+		{input: "uid,uid", wantErr: true},
+		{input: "mtime,btime", wantErr: true},
+		{input: ",", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			field, dims, err := parseGroupBy(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error mismatch: got %v, want error %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if field != tt.wantField || !slices.Equal(dims, tt.wantDims) {
+				t.Errorf("got %v %v, want %v %v", field, dims, tt.wantField, tt.wantDims)
+			}
+		})
+	}
+}
+
+func TestParseSortKey(t *testing.T) {
+	if got, err := parseSortKey("", "per-uid"); err != nil || got != output.SortByGroup {
+		t.Errorf("parseSortKey(\"\") = %v, %v", got, err)
+	}
+	if got, err := parseSortKey("files-per-dir", "per-year"); err != nil || got != output.SortByFilesPerDir {
+		t.Errorf("parseSortKey(files-per-dir) = %v, %v", got, err)
+	}
+	if _, err := parseSortKey("bogus", "per-uid"); err == nil {
+		t.Error("parseSortKey(bogus) should fail")
+	}
+	if _, err := parseSortKey("mtime", "per-year"); err == nil {
+		t.Error("parseSortKey(mtime) should fail outside list output")
+	}
+	if got, err := parseSortKey("", "list"); err != nil || got != output.SortByPath {
+		t.Errorf("parseSortKey(\"\", list) = %v, %v", got, err)
+	}
+	if got, err := parseSortKey("owner", "list"); err != nil || got != output.SortByOwner {
+		t.Errorf("parseSortKey(owner, list) = %v, %v", got, err)
+	}
+	if _, err := parseSortKey("inodes", "list"); err == nil {
+		t.Error("parseSortKey(inodes, list) should fail")
+	}
+	if got, err := parseSortKey("size", "paths"); err != nil || got != output.SortBySize {
+		t.Errorf("parseSortKey(size, paths) = %v, %v", got, err)
+	}
+	if got, err := parseSortKey("name", "per-uid"); err != nil || got != output.SortByName {
+		t.Errorf("parseSortKey(name, per-uid) = %v, %v", got, err)
+	}
+	if got, err := parseSortKey("year", "groups"); err != nil || got != output.SortByYear {
+		t.Errorf("parseSortKey(year, groups) = %v, %v", got, err)
+	}
+	if _, err := parseSortKey("name", "per-year"); err == nil {
+		t.Error("parseSortKey(name, per-year) should fail")
+	}
+	if _, err := parseSortKey("year", "per-uid"); err == nil {
+		t.Error("parseSortKey(year, per-uid) should fail")
+	}
+}
+
+func TestParseModesSortKey(t *testing.T) {
+	if got, err := parseModesSortKey("", []string{"summary", "list"}); err != nil || got != output.SortByGroup {
+		t.Errorf("parseModesSortKey(\"\", summary,list) = %v, %v", got, err)
+	}
+	if got, err := parseModesSortKey("", []string{"list", "paths"}); err != nil || got != output.SortByPath {
+		t.Errorf("parseModesSortKey(\"\", list,paths) = %v, %v", got, err)
+	}
+	if got, err := parseModesSortKey("size", []string{"per-uid", "list"}); err != nil || got != output.SortBySize {
+		t.Errorf("parseModesSortKey(size, per-uid,list) = %v, %v", got, err)
+	}
+	if _, err := parseModesSortKey("mtime", []string{"list", "per-year"}); err == nil {
+		t.Error("parseModesSortKey(mtime) should fail when a mode does not accept it")
+	}
+}
+
+func TestParseOutputs(t *testing.T) {
+	dests, err := parseOutputs([]string{"json:stats.json", "table:-", "CSV:out/stats.csv.gz"})
+	want := []outputDest{{"json", "stats.json"}, {"table", "-"}, {"csv", "out/stats.csv.gz"}}
+	if err != nil || !slices.Equal(dests, want) {
+		t.Errorf("parseOutputs() = %v, %v; want %v", dests, err, want)
+	}
+	if dests, err := parseOutputs([]string{"table"}); err != nil || dests[0].path != "-" {
+		t.Errorf("parseOutputs(table) = %v, %v; want stdout", dests, err)
+	}
+
+	for _, specs := range [][]string{
+		{"sqlite:scan.db"},
+		{"xlsx:-"},
+		{"table:-", "json"},
+		{"json:stats", "csv:stats"},
+	} {
+		if _, err := parseOutputs(specs); err == nil {
+			t.Errorf("parseOutputs(%v) should fail", specs)
+		}
+	}
+}
+
+func TestCheckSplitDests(t *testing.T) {
+	for _, dests := range [][]outputDest{
+		{{"csv", "stats.csv"}},
+		{{"csv", "stats.csv"}, {"table", "-"}, {"json", "stats.json"}},
+		{{"csv", "-"}, {"csv", "stats.csv"}},
+	} {
+		if err := checkSplitDests(dests); err != nil {
+			t.Errorf("checkSplitDests(%v) = %v", dests, err)
+		}
+	}
+	for _, dests := range [][]outputDest{
+		{{"csv", "-"}},
+		{{"table", "stats.txt"}},
+		{{"json", "stats.json"}, {"table", "-"}},
+		{{"csv", "a.csv"}, {"csv", "b.csv"}},
+	} {
+		if err := checkSplitDests(dests); err == nil {
+			t.Errorf("checkSplitDests(%v) should fail", dests)
+		}
+	}
+}
+
+func TestParseUnits(t *testing.T) {
+	for s, want := range map[string]textfmt.Units{"": textfmt.UnitsDefault, "binary": textfmt.UnitsBinary, "SI": textfmt.UnitsSI, "raw": textfmt.UnitsRaw} {
+		if got, err := parseUnits(s); err != nil || got != want {
+			t.Errorf("parseUnits(%q) = %v, %v; want %v", s, got, err, want)
+		}
+	}
+	if _, err := parseUnits("kb"); err == nil {
+		t.Error("parseUnits(kb) should fail")
+	}
+}
+
+func TestParseCounts(t *testing.T) {
+	got, err := parseCounts("grouped, html=Compact")
+	if err != nil || len(got) != 2 || got[""] != textfmt.CountsGrouped || got["html"] != textfmt.CountsCompact {
+		t.Errorf("parseCounts(grouped, html=Compact) = %v, %v", got, err)
+	}
+	if got, err := parseCounts(""); err != nil || len(got) != 0 {
+		t.Errorf("parseCounts(\"\") = %v, %v; want no forms", got, err)
+	}
+	for _, s := range []string{"short", "csv=grouped", "table=plain,table=compact"} {
+		if _, err := parseCounts(s); err == nil {
+			t.Errorf("parseCounts(%q) should fail", s)
+		}
+	}
+}
+
+func TestLocaleSeparator(t *testing.T) {
+	tests := []struct {
+		lcAll, lang string
+		want        string
+	}{
+		{"", "en_US.UTF-8", ","},
+		{"", "de_DE.UTF-8", "."},
+		{"", "de_CH.UTF-8", "'"},
+		{"fr_FR.UTF-8", "en_US.UTF-8", " "},
+		{"", "", ","},
+	}
+	for _, tt := range tests {
+		t.Setenv("LC_ALL", tt.lcAll)
+		t.Setenv("LC_NUMERIC", "")
+		t.Setenv("LANG", tt.lang)
+		if got := localeSeparator(); got != tt.want {
+			t.Errorf("localeSeparator() with LC_ALL=%q LANG=%q = %q, want %q", tt.lcAll, tt.lang, got, tt.want)
+		}
+	}
+}
+
+func TestUseColor(t *testing.T) {
+	if !useColor("always", "report.txt") {
+		t.Error("--color always should color files")
+	}
+	if useColor("never", "-") {
+		t.Error("--color never should not color stdout")
+	}
+	if useColor("auto", "report.txt") {
+		t.Error("--color auto should not color files")
+	}
+	t.Setenv("NO_COLOR", "1")
+	if useColor("auto", "-") {
+		t.Error("--color auto should not color with NO_COLOR set")
+	}
+}
+
+func TestParseTableStyle(t *testing.T) {
+	if got, err := parseTableStyle("Light"); err != nil || got != output.TableStyleLight {
+		t.Errorf("parseTableStyle(Light) = %v, %v", got, err)
+	}
+	if _, err := parseTableStyle("rounded"); err == nil {
+		t.Error("parseTableStyle(rounded) should fail")
+	}
+	if got, err := parseWrap("truncate"); err != nil || got != output.WrapTruncate {
+		t.Errorf("parseWrap(truncate) = %v, %v", got, err)
+	}
+	if _, err := parseWrap("ellipsis"); err == nil {
+		t.Error("parseWrap(ellipsis) should fail")
+	}
+}
+
+func TestParseThrottle(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    cwalk.Throttle
+		wantErr bool
+	}{
+		{name: "ops", input: "500", want: cwalk.Throttle{OpsPerSec: 500}},
+		{name: "ops per second suffix", input: "250/s", want: cwalk.Throttle{OpsPerSec: 250}},
+		{name: "bytes", input: "2MB/s", want: cwalk.Throttle{BytesPerSec: 2 * 1024 * 1024}},
+		{name: "both", input: "1000, 512K/s", want: cwalk.Throttle{OpsPerSec: 1000, BytesPerSec: 512 * 1024}},
+		{name: "zero", input: "0", wantErr: true},
+		{name: "unknown unit", input: "5XB/s", wantErr: true},
+		{name: "empty", input: ",", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseThrottle(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error mismatch: got error %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestIsDigit(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/otuschhoff/cwalk/pkg/output"
+	"github.com/spf13/cobra"
+)
+
+// schemaCmd prints the JSON Schema of JSON output.
+var schemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Print the JSON Schema of JSON output",
+	Long: `Schema prints the JSON Schema (draft 2020-12) describing the documents
+written by --output-format json, for validating output or generating types.
+
+Every document has a schemaVersion, the list of output modes, and one section
+per mode named after the mode in camel case, e.g. perYear for per-year. All
+keys are camel case, sizes are exact byte counts and times are RFC 3339
+strings. The schema version is increased when keys are renamed or removed or
+change their meaning; new keys may be added without a new version.
+
+Examples:
+  cwalk schema > cwalk-output.schema.json
+  cwalk -f json /home | jq '.summary.size'`,
+	Args: cobra.NoArgs,
+	RunE: runSchema,
+}
+
+// init registers the schema command.
+func init() {
+	rootCmd.AddCommand(schemaCmd)
+}
+
+// runSchema prints the JSON Schema to stdout.
+func runSchema(cmd *cobra.Command, args []string) error {
+	schema, err := output.JSONSchema()
+	if err != nil {
+		return err
+	}
+	_, err = os.Stdout.Write(schema)
+	return err
+}
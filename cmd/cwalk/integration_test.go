@@ -26,15 +26,7 @@ func TestCLIRunsConsistently(t *testing.T) {
 	mustWrite("a.txt", "data")
 	mustWrite(filepath.Join("sub", "b.txt"), "more")
 
-	binDir := t.TempDir()
-	binaryPath := filepath.Join(binDir, "cwalk_test_bin")
-
-	build := exec.Command("go", "build", "-o", binaryPath, ".")
-	build.Stdout = os.Stdout
-	build.Stderr = os.Stderr
-	if err := build.Run(); err != nil {
-		t.Fatalf("build cwalk: %v", err)
-	}
+	binaryPath := buildCLI(t)
 
 	const runs = 20
 	for i := 0; i < runs; i++ {
@@ -46,14 +38,76 @@ func TestCLIRunsConsistently(t *testing.T) {
 
 		var payload struct {
 			Summary struct {
-				TotalInodes int64 `json:"TotalInodes"`
+				Inodes int64 `json:"inodes"`
 			} `json:"summary"`
 		}
 		if err := json.Unmarshal(out, &payload); err != nil {
 			t.Fatalf("run %d: unmarshal json: %v", i, err)
 		}
-		if payload.Summary.TotalInodes == 0 {
+		if payload.Summary.Inodes == 0 {
 			t.Fatalf("run %d: walker returned zero inodes", i)
 		}
 	}
 }
+
+// TestCLIQuotaExitCode checks that an exceeded quota of --quota-file exits
+// with status 2 after writing the report, and a kept one with 0.
+func TestCLIQuotaExitCode(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "big"), make([]byte, 4096), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	binaryPath := buildCLI(t)
+
+	tests := []struct {
+		limit    string
+		wantCode int
+	}{
+		{"1M", 0},
+		{"1K", 2},
+	}
+	for _, tt := range tests {
+		quotas := filepath.Join(t.TempDir(), "quotas.csv")
+		if err := os.WriteFile(quotas, []byte(root+","+tt.limit+"\n"), 0o644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+		cmd := exec.Command(binaryPath, "--quota-file", quotas, "--output-format", "json", root)
+		out, err := cmd.Output()
+		code := 0
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			code = exitErr.ExitCode()
+		} else if err != nil {
+			t.Fatalf("limit %s: %v", tt.limit, err)
+		}
+		if code != tt.wantCode {
+			t.Errorf("limit %s: exit status %d, want %d", tt.limit, code, tt.wantCode)
+		}
+
+		var payload struct {
+			Quota []struct {
+				Used     int64 `json:"used"`
+				Exceeded bool  `json:"exceeded"`
+			} `json:"quota"`
+		}
+		if err := json.Unmarshal(out, &payload); err != nil {
+			t.Fatalf("limit %s: unmarshal json: %v", tt.limit, err)
+		}
+		if len(payload.Quota) != 1 || payload.Quota[0].Used != 4096 {
+			t.Errorf("limit %s: quota section %+v", tt.limit, payload.Quota)
+		}
+	}
+}
+
+// buildCLI builds the cwalk binary into a temporary directory and returns
+// its path.
+func buildCLI(t *testing.T) string {
+	t.Helper()
+	binaryPath := filepath.Join(t.TempDir(), "cwalk_test_bin")
+	build := exec.Command("go", "build", "-o", binaryPath, ".")
+	build.Stdout = os.Stdout
+	build.Stderr = os.Stderr
+	if err := build.Run(); err != nil {
+		t.Fatalf("build cwalk: %v", err)
+	}
+	return binaryPath
+}
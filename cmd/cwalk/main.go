@@ -26,7 +26,7 @@ import (
 
 func main() {
 	if err := cmd.Execute(); err != nil {
-		log.Fatal(err)
-		os.Exit(1)
+		log.Print(err)
+		os.Exit(cmd.ExitCode(err))
 	}
 }
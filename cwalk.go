@@ -25,19 +25,24 @@
 package cwalk
 
 import (
+	"container/heap"
 	"context"
 	"fmt"
-	"log"
+	"io/fs"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 const Version = "v0.1.0"
 
-// Logger defines the interface for logging in the walker.
-// If not set, logs will use the standard library log package.
+// Logger defines a printf-style interface for logging in the walker, see
+// SetLogger. SetSlogLogger takes a structured logger instead; if neither
+// is set, logs go to slog.Default().
 type Logger interface {
 	// Printf logs a formatted message similar to log.Printf
 	Printf(format string, v ...interface{})
@@ -46,39 +51,152 @@ type Logger interface {
 // Callbacks define optional handlers that are invoked during the walk.
 // All callbacks are optional (zero value means no callback).
 type Callbacks struct {
+	// OnRoot is called with the root path before each root is walked.
+	// Relative paths reported until the next call are relative to it.
+	OnRoot func(root string)
+
+	// OnEntry is called for each root and each entry found once its
+	// metadata has been fetched, or failed to, at the same point as
+	// OnLstat. The entry carries its paths, directory entry, metadata,
+	// depth and parent directory, so callbacks need not join paths or
+	// lstat entries themselves; OnLstat, OnFileOrSymlink and OnDirectory
+	// remain for callbacks that need less. The entry must not be modified
+	// and may be retained.
+	OnEntry func(e *Entry)
+
 	// OnLstat is called after successfully lstat'ing a path (both src and dst).
 	// Called for every path processed.
 	OnLstat func(isDir bool, relPath string, fileInfo os.FileInfo, err error)
 
 	// OnReadDir is called after successfully reading a directory.
-	// Called for each directory with its entries.
+	// Called for each directory with its entries. Not called when reading
+	// directories in batches (see SetReadDirBatch).
 	OnReadDir func(relPath string, entries []os.DirEntry, err error)
 
+	// OnReadDirBatch is called for each batch of entries read from a
+	// directory when reading directories in batches (see SetReadDirBatch),
+	// before the entries of the batch are processed. The last call for a
+	// directory has no entries and a nil error, or the error that ended
+	// the read.
+	OnReadDirBatch func(relPath string, entries []os.DirEntry, err error)
+
 	// OnFileOrSymlink is called for each non-directory entry.
 	OnFileOrSymlink func(relPath string, entry os.DirEntry)
 
 	// OnDirectory is called for each directory entry (before recursing).
 	OnDirectory func(relPath string, entry os.DirEntry)
+
+	// ShouldDescend is called for each directory entry not ignored, after
+	// OnDirectory and before the directory is queued. Returning false
+	// prunes it: the directory itself has been reported, but it is not
+	// read, so nothing below it is stat'ed. Nil descends everywhere.
+	ShouldDescend func(relPath string, entry os.DirEntry) bool
+
+	// OnDirectoryDone is called once the whole subtree of a directory has
+	// been processed, after the calls for all its subdirectories, with the
+	// totals of the entries below it. This allows du-style rollups and
+	// post-order actions such as removing empty directories.
+	OnDirectoryDone func(relPath string, totals DirTotals)
+
+	// OnPanic is called when another callback panics on a worker, with
+	// the recovered panic. The worker skips the rest of the directory and
+	// carries on; the panic is also counted in Stats.Errors and listed by
+	// Panics.
+	OnPanic func(err *PanicError)
 }
 
 // Walker recursively walks a directory tree with callbacks.
 type Walker struct {
-	rootPath   string
+	rootPath   string   // Root being walked
+	roots      []string // Roots walked one after another by Run
 	callbacks  Callbacks
-	logger     Logger
+	logger     *slog.Logger // nil logs to slog.Default()
 	monitorCtx context.Context
 	cancel     context.CancelFunc
-
-	ignoreNames map[string]struct{}
-	ignoreFunc  func(name, relPath string, info os.FileInfo) bool
-
-	// Worker pool management
-	numWorkers int
-	workers    []*walkWorker
-	workerMu   sync.Mutex
-	workQueue  chan *walkBranch
-	wg         sync.WaitGroup
-	shutdown   int32
+	stopMu     sync.Mutex     // Guards cancel, so Stop may race with Reset
+	runs       sync.WaitGroup // Run and Stream calls in progress
+
+	// fsys is the filesystem walked by NewWalkerFS; nil means the OS.
+	fsys fs.FS
+
+	ignoreNames  map[string]struct{}
+	ignoreFunc   func(name, relPath string, info os.FileInfo) bool
+	statxMask    StatxMask
+	backend      Backend
+	order        Order
+	inodeOrder   bool
+	readDirBatch int
+	streamBuffer int // Entries produced ahead of Entries and Stream
+
+	// Panics recovered from callbacks (see Panics).
+	panicMu sync.Mutex
+	panics  []*PanicError
+
+	// ringFallback logs the io_uring fallback warning only once per walk.
+	ringFallback sync.Once
+
+	// ioSem limits the stat and readdir calls in flight; nil means each
+	// worker issues one call at a time.
+	ioSem chan struct{}
+
+	// fdSem limits the directories held open (see SetMaxOpenDirs); nil
+	// is unlimited. fdWait logs reaching the limit only once per walk.
+	maxOpenDirs int
+	fdSem       chan struct{}
+	fdWait      sync.Once
+
+	// Token buckets pacing IO calls (see SetThrottle); nil is unlimited.
+	opsBucket   *tokenBucket
+	bytesBucket *tokenBucket
+
+	// Worker pool management. The workers slice is replaced rather than
+	// modified when workers are added or retired, so a copy obtained from
+	// workerList can be iterated without holding workerMu.
+	numWorkers   int
+	workers      []*walkWorker
+	workerMu     sync.Mutex
+	nextWorkerID int
+	workQueue    chan *walkBranch
+	wg           sync.WaitGroup
+	shutdown     int32
+
+	// Scheduling state. pending counts branches that are queued or being
+	// processed; the walk is finished once it drops to zero with no roots
+	// left. Idle workers park on schedCond until new work is queued or the
+	// walk finishes.
+	schedMu   sync.Mutex
+	schedCond *sync.Cond
+	pending   int
+	rootsLeft int // Roots not queued yet
+
+	// Worker count tuning (see SetAutoWorkers). active, target and idle
+	// are guarded by schedMu; ioOps and ioNanos accumulate syscall counts
+	// and latency between samples.
+	maxWorkers   int
+	tuneInterval time.Duration
+	active       int
+	target       int
+	idle         int
+	ioOps        atomic.Int64
+	ioNanos      atomic.Int64
+
+	// Checkpoints (see SetCheckpoint). While paused is set, workers wait
+	// before taking a branch; busy counts the workers processing one. Both
+	// are guarded by schedMu. resume holds the directories to continue from
+	// (see SetResume).
+	checkpointEvery time.Duration
+	checkpointFn    func(pending []string) error
+	paused          bool
+	busy            int
+	resume          []string
+
+	// Walk statistics (see Stats). queued counts the branches waiting in
+	// queues; peakQueue is guarded by schedMu and workerStats by workerMu.
+	syscalls     atomic.Int64
+	branchErrors atomic.Int64
+	queued       atomic.Int64
+	peakQueue    int64
+	workerStats  []WorkerStats
 }
 
 // walkWorker represents a single worker processing directories.
@@ -86,13 +204,27 @@ type walkWorker struct {
 	id     int
 	walker *Walker
 	queue  []*walkBranch
+	order  Order // Queue order, copied from the walker
 	mu     sync.Mutex
+
+	// io_uring state for BackendIOUring, owned by the worker goroutine.
+	ring    *ioURing
+	ringErr error
+
+	// Statistics, owned by the worker goroutine (see recordWorker).
+	started time.Time
+	dirs    int64
+	busy    time.Duration
 }
 
 // walkBranch represents a directory node in the traversal tree.
 type walkBranch struct {
 	parent   *walkBranch
 	basename string
+	info     os.FileInfo // lstat info from the parent's scan; nil for the root
+	resumed  bool        // queued by an interrupted walk and already reported
+	subtree  *subtree    // totals until the subtree is done; nil without OnDirectoryDone
+	entry    *Entry      // entry passed to OnEntry; nil until needed as a parent
 }
 
 func (cb *walkBranch) isRoot() bool {
@@ -110,11 +242,11 @@ func (cb *walkBranch) relPathElems() []string {
 	return append(cb.parent.relPathElems(), cb.basename)
 }
 
-func (cb *walkBranch) absPath(rootPath string) string {
+func (cb *walkBranch) absPath(c *Walker) string {
 	if cb.isRoot() {
-		return rootPath
+		return c.rootPath
 	}
-	return filepath.Join(rootPath, cb.relPath())
+	return c.join(c.rootPath, cb.relPath())
 }
 
 func (cw *walkWorker) queueLen() int {
@@ -126,12 +258,24 @@ func (cw *walkWorker) queueLen() int {
 func (cw *walkWorker) queuePush(item *walkBranch) {
 	cw.mu.Lock()
 	defer cw.mu.Unlock()
+	if cw.order == OrderLargestFirst {
+		heap.Push((*branchHeap)(&cw.queue), item)
+		return
+	}
 	cw.queue = append(cw.queue, item)
 }
 
+// queuePop removes the item the owner reads next in the traversal order
+// (see SetOrder).
 func (cw *walkWorker) queuePop() *walkBranch {
 	cw.mu.Lock()
 	defer cw.mu.Unlock()
+	switch cw.order {
+	case OrderBreadthFirst:
+		return cw.queueHead()
+	case OrderLargestFirst:
+		return cw.queueLargest()
+	}
 	if len(cw.queue) > 0 {
 		item := cw.queue[len(cw.queue)-1]
 		cw.queue = cw.queue[:len(cw.queue)-1]
@@ -140,6 +284,19 @@ func (cw *walkWorker) queuePop() *walkBranch {
 	return nil
 }
 
+// queueSteal removes an item for another worker. In depth-first order the
+// owner pops from the tail, while thieves take from the head, which tends
+// to hold branches closer to the root and therefore larger subtrees; in
+// the other orders thieves take what the owner would read next.
+func (cw *walkWorker) queueSteal() *walkBranch {
+	cw.mu.Lock()
+	defer cw.mu.Unlock()
+	if cw.order == OrderLargestFirst {
+		return cw.queueLargest()
+	}
+	return cw.queueHead()
+}
+
 // NewWalker creates a new Walker for the given root path.
 func NewWalker(rootPath string, numWorkers int, callbacks Callbacks) *Walker {
 	if numWorkers <= 0 {
@@ -148,102 +305,251 @@ func NewWalker(rootPath string, numWorkers int, callbacks Callbacks) *Walker {
 
 	ctx, cancel := context.WithCancel(context.Background())
 
-	return &Walker{
-		rootPath:    filepath.Clean(rootPath),
-		callbacks:   callbacks,
-		logger:      &stdLogger{},
-		monitorCtx:  ctx,
-		cancel:      cancel,
-		numWorkers:  numWorkers,
-		ignoreNames: map[string]struct{}{},
+	w := &Walker{
+		rootPath:     filepath.Clean(rootPath),
+		roots:        []string{filepath.Clean(rootPath)},
+		callbacks:    callbacks,
+		monitorCtx:   ctx,
+		cancel:       cancel,
+		numWorkers:   numWorkers,
+		ignoreNames:  map[string]struct{}{},
+		tuneInterval: defaultTuneInterval,
+		streamBuffer: defaultStreamBuffer,
 	}
+	w.schedCond = sync.NewCond(&w.schedMu)
+	return w
 }
 
-// Run starts the walking process.
+// Run starts the walking process. It returns context.Canceled if the walk
+// was stopped with Stop.
 func (c *Walker) Run() error {
+	c.runs.Add(1)
+	defer c.runs.Done()
+	c.initFDs()
+
 	// Initialize workers
 	c.workerMu.Lock()
 	for i := 0; i < c.numWorkers; i++ {
 		worker := &walkWorker{
 			id:     i,
 			walker: c,
+			order:  c.order,
 		}
 		c.workers = append(c.workers, worker)
+	}
+	c.nextWorkerID = c.numWorkers
+	c.workerMu.Unlock()
+	c.active, c.target = c.numWorkers, c.numWorkers
+
+	// Queue the first root before any worker starts, so no worker can
+	// observe an empty walk and exit early.
+	c.rootsLeft = len(c.roots)
+	if len(c.roots) > 0 {
+		c.startRoot(0)
+	}
+
+	for _, worker := range c.workers {
 		c.wg.Add(1)
 		go c.startWorker(worker)
 	}
-	c.workerMu.Unlock()
 
-	// Start with root directory
-	root := &walkBranch{}
-	c.workers[0].queuePush(root)
+	var stopTuner, tunerDone chan struct{}
+	if c.maxWorkers > 0 {
+		stopTuner, tunerDone = make(chan struct{}), make(chan struct{})
+		go func() {
+			defer close(tunerDone)
+			c.autoTune(stopTuner)
+		}()
+	}
+
+	var stopCheckpoints, checkpointsDone chan struct{}
+	if c.checkpointEvery > 0 && c.checkpointFn != nil {
+		stopCheckpoints, checkpointsDone = make(chan struct{}), make(chan struct{})
+		go func() {
+			defer close(checkpointsDone)
+			c.checkpoints(stopCheckpoints)
+		}()
+	}
+
+	// Walk the other roots once the previous one is done
+	for i := 1; i < len(c.roots); i++ {
+		c.startRoot(i)
+	}
 
 	// Wait for all workers to finish
 	c.wg.Wait()
+	if stopTuner != nil {
+		close(stopTuner)
+		<-tunerDone
+	}
+	if stopCheckpoints != nil {
+		close(stopCheckpoints)
+		<-checkpointsDone
+	}
 
-	return nil
+	return c.monitorCtx.Err()
 }
 
 // startWorker runs the main worker loop.
+// A worker processes its own queue, then tries to steal from others, and
+// parks when there is nothing to steal. It exits once no branch is
+// queued or in progress anywhere, or when the tuner retires it.
 func (c *Walker) startWorker(worker *walkWorker) {
 	defer c.wg.Done()
+	defer worker.closeRing()
+	defer c.recordWorker(worker)
+	worker.started = time.Now()
 
 	for {
+		if c.retire(worker) {
+			return
+		}
+
+		c.enterBranch()
 		branch := worker.queuePop()
+		if branch == nil {
+			branch = c.stealWork(worker)
+		}
 
-		if branch != nil {
-			if err := worker.processBranch(branch); err != nil {
-				c.logger.Printf("ERROR processing '%s': %v", branch.relPath(), err)
-			}
-		} else {
-			if !c.stealWork(worker) {
-				// No work available, exit
+		if branch == nil {
+			c.leaveBranch()
+			if !c.park() {
 				return
 			}
+			continue
+		}
+
+		c.queued.Add(-1)
+		if c.stopped() {
+			// Drop the branch, so the walk winds down
+			c.finishBranch()
+			c.leaveBranch()
+			continue
 		}
+		start := time.Now()
+		var own DirTotals
+		if err := c.guard(branch, func() error { return worker.processBranch(branch) }); err != nil {
+			c.branchErrors.Add(1)
+			c.log().Error("processing failed", "path", branch.absPath(c), "err", err)
+			own.Errors++
+		}
+		c.finishSubtree(branch, own)
+		worker.dirs++
+		worker.busy += time.Since(start)
+		c.finishBranch()
+		c.leaveBranch()
 	}
 }
 
-// stealWork attempts to steal work from other workers.
-func (c *Walker) stealWork(thief *walkWorker) bool {
-	c.workerMu.Lock()
-	defer c.workerMu.Unlock()
+// enqueue queues a branch on the given worker and wakes one parked worker.
+func (c *Walker) enqueue(worker *walkWorker, branch *walkBranch) {
+	worker.queuePush(branch)
+	queued := c.queued.Add(1)
+
+	c.schedMu.Lock()
+	c.pending++
+	c.peakQueue = max(c.peakQueue, queued)
+	c.schedMu.Unlock()
+	c.schedCond.Signal()
+}
 
-	for _, victim := range c.workers {
+// finishBranch marks a branch as fully processed. When the last pending
+// branch finishes, all parked workers are woken so they can exit, and Run
+// so it can start the next root.
+func (c *Walker) finishBranch() {
+	c.schedMu.Lock()
+	c.pending--
+	done := c.pending == 0
+	c.schedMu.Unlock()
+
+	if done {
+		c.schedCond.Broadcast()
+	}
+}
+
+// park blocks an idle worker until work is queued somewhere, the walk of
+// all roots has finished, or the tuner wants to retire workers. It returns
+// false if the walk has finished.
+func (c *Walker) park() bool {
+	c.schedMu.Lock()
+	defer c.schedMu.Unlock()
+
+	c.idle++
+	for !c.finished() && !c.hasQueuedWork() && !c.retireDue() {
+		c.schedCond.Wait()
+	}
+	c.idle--
+	return !c.finished()
+}
+
+// hasQueuedWork reports whether any worker has a branch waiting in its queue.
+func (c *Walker) hasQueuedWork() bool {
+	for _, worker := range c.workerList() {
+		if worker.queueLen() > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// stealWork attempts to steal a branch from another worker's queue.
+// Returns nil if no other worker has queued work.
+func (c *Walker) stealWork(thief *walkWorker) *walkBranch {
+	for _, victim := range c.workerList() {
 		if victim.id == thief.id {
 			continue
 		}
 
-		qlen := victim.queueLen()
-		if qlen > 1 {
-			stolenItem := victim.queuePop()
-			if stolenItem != nil {
-				thief.queuePush(stolenItem)
-				return true
-			}
+		if stolen := victim.queueSteal(); stolen != nil {
+			return stolen
 		}
 	}
 
-	return false
+	return nil
+}
+
+// workerList returns the current workers. The returned slice must not be
+// modified.
+func (c *Walker) workerList() []*walkWorker {
+	c.workerMu.Lock()
+	defer c.workerMu.Unlock()
+	return c.workers
 }
 
 // processBranch processes a single directory branch.
 func (w *walkWorker) processBranch(branch *walkBranch) error {
-	absPath := branch.absPath(w.walker.rootPath)
+	absPath := branch.absPath(w.walker)
 	relPath := branch.relPath()
 
-	// Call OnLstat for the directory itself
-	info, err := os.Lstat(absPath)
-	if w.walker.callbacks.OnLstat != nil {
-		w.walker.callbacks.OnLstat(true, relPath, info, err)
+	// Only the root needs an lstat here; every other directory was already
+	// lstat'ed (and reported via OnLstat) while scanning its parent, and
+	// resumed directories were reported by the interrupted walk.
+	if branch.info == nil && !branch.resumed {
+		start := w.walker.acquireIO(1)
+		info, err := w.walker.lstat(absPath)
+		w.walker.releaseIO(start)
+		branch.entry = w.walker.reportEntry(nil, relPath, absPath, nil, info, err)
+		if w.walker.callbacks.OnLstat != nil {
+			w.walker.callbacks.OnLstat(true, relPath, info, err)
+		}
+
+		if err != nil {
+			return fmt.Errorf("lstat failed for '%s': %w", absPath, err)
+		}
+		branch.info = info
 	}
 
-	if err != nil {
-		return fmt.Errorf("lstat failed for '%s': %w", absPath, err)
+	if w.walker.readDirBatch > 0 {
+		return w.processBatches(branch, absPath, relPath)
 	}
 
 	// ReadDir the current branch
-	entries, err := os.ReadDir(absPath)
+	w.walker.acquireFD()
+	start := w.walker.acquireIO(1)
+	entries, err := w.walker.readDir(absPath)
+	w.walker.releaseIO(start)
+	w.walker.releaseFD()
+	w.walker.chargeReadDir(entries)
 	if w.walker.callbacks.OnReadDir != nil {
 		w.walker.callbacks.OnReadDir(relPath, entries, err)
 	}
@@ -251,9 +557,34 @@ func (w *walkWorker) processBranch(branch *walkBranch) error {
 	if err != nil {
 		return fmt.Errorf("readdir failed for '%s': %w", absPath, err)
 	}
+	if log := w.walker.log(); log.Enabled(context.Background(), slog.LevelDebug) {
+		log.Debug("read directory", "path", absPath, "entries", len(entries))
+	}
+	return w.processEntries(branch, absPath, relPath, entries)
+}
 
-	// Process each entry
-	for _, entry := range entries {
+// processEntries fetches the metadata of entries read from the directory
+// of branch, reports them and queues the subdirectories.
+func (w *walkWorker) processEntries(branch *walkBranch, absPath, relPath string, entries []os.DirEntry) error {
+	// With io_uring, fetch metadata for the whole directory in one batch
+	var batch []statResult
+	if w.walker.backend == BackendIOUring && len(entries) > 0 {
+		paths := make([]string, len(entries))
+		for i, entry := range entries {
+			paths[i] = w.walker.join(absPath, entry.Name())
+		}
+		batch = w.statBatch(paths)
+	}
+
+	// With an IO concurrency limit, stat the entries concurrently
+	if batch == nil && w.walker.ioSem != nil && len(entries) > 0 {
+		batch = w.walker.statEntries(absPath, entries)
+	}
+
+	// Process each entry, counting it in the totals of the branch
+	var totals DirTotals
+	defer func() { branch.addTotals(totals) }()
+	for i, entry := range entries {
 		entryName := entry.Name()
 
 		childRelPath := relPath
@@ -263,8 +594,17 @@ func (w *walkWorker) processBranch(branch *walkBranch) error {
 			childRelPath = entryName
 		}
 
-		childAbsPath := filepath.Join(absPath, entryName)
-		childInfo, childErr := os.Lstat(childAbsPath)
+		childAbsPath := w.walker.join(absPath, entryName)
+		var childInfo os.FileInfo
+		var childErr error
+		if batch != nil {
+			childInfo, childErr = batch[i].info, batch[i].err
+		} else {
+			start := w.walker.acquireIO(1)
+			childInfo, childErr = w.walker.statEntry(childAbsPath, entry)
+			w.walker.releaseIO(start)
+		}
+		childEntry := w.walker.reportEntry(branch, childRelPath, childAbsPath, entry, childInfo, childErr)
 		if w.walker.callbacks.OnLstat != nil {
 			w.walker.callbacks.OnLstat(childErr == nil && childInfo.IsDir(), childRelPath, childInfo, childErr)
 		}
@@ -275,6 +615,7 @@ func (w *walkWorker) processBranch(branch *walkBranch) error {
 		if w.walker.shouldIgnore(entryName, childRelPath, childInfo) {
 			continue
 		}
+		totals.add(childInfo)
 
 		if childInfo.IsDir() {
 			// Call OnDirectory callback
@@ -282,12 +623,20 @@ func (w *walkWorker) processBranch(branch *walkBranch) error {
 				w.walker.callbacks.OnDirectory(childRelPath, entry)
 			}
 
+			if w.walker.callbacks.ShouldDescend != nil && !w.walker.callbacks.ShouldDescend(childRelPath, entry) {
+				continue
+			}
+
 			// Queue child branch for processing
 			childBranch := &walkBranch{
 				parent:   branch,
 				basename: entryName,
+				info:     childInfo,
+				entry:    childEntry,
+				subtree:  w.walker.newSubtree(),
 			}
-			w.queuePush(childBranch)
+			branch.openSubdir()
+			w.walker.enqueue(w, childBranch)
 		} else {
 			// Call OnFileOrSymlink callback
 			if w.walker.callbacks.OnFileOrSymlink != nil {
@@ -299,11 +648,30 @@ func (w *walkWorker) processBranch(branch *walkBranch) error {
 	return nil
 }
 
-// Stop cancels the walking process.
+// Stop cancels the walking process: workers drop the directories not read
+// yet and roots not started yet, and Run returns context.Canceled once the
+// directories in progress are done. Stop does not wait for that; it may be
+// called from any goroutine, including callbacks. Reset clears a Stop for
+// another Run.
 func (c *Walker) Stop() {
+	c.stopMu.Lock()
+	defer c.stopMu.Unlock()
 	c.cancel()
 }
 
+// StopAndWait stops the walk like Stop and blocks until Run, Stream and the
+// goroutines they started have returned. It must not be called from a
+// callback, which would wait for itself.
+func (c *Walker) StopAndWait() {
+	c.Stop()
+	c.runs.Wait()
+}
+
+// stopped reports whether Stop has been called.
+func (c *Walker) stopped() bool {
+	return c.monitorCtx.Err() != nil
+}
+
 // SetIgnoreNames sets names (files or directories) to be skipped during the walk.
 // Matching is case-sensitive and applies to entry basenames only.
 func (c *Walker) SetIgnoreNames(names []string) {
@@ -334,17 +702,11 @@ func (c *Walker) shouldIgnore(name, relPath string, info os.FileInfo) bool {
 	return false
 }
 
-// SetLogger sets a custom logger for the walker.
-// If not called, the default standard library logger is used.
+// SetLogger sets a printf-style logger for the walker. It receives the
+// messages of level Info and above as "LEVEL message key=value ...", see
+// SetSlogLogger. A nil logger is ignored.
 func (c *Walker) SetLogger(logger Logger) {
 	if logger != nil {
-		c.logger = logger
+		c.logger = slog.New(&printfHandler{logger: logger})
 	}
 }
-
-// stdLogger is the default logger using the standard library log package.
-type stdLogger struct{}
-
-func (s *stdLogger) Printf(format string, v ...interface{}) {
-	log.Printf(format, v...)
-}
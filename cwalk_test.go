@@ -2,9 +2,12 @@
 package cwalk
 
 import (
+	"context"
+	"errors"
 	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -230,7 +233,7 @@ func TestWalkBranchAbsPath(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := tt.branch.absPath(tt.rootPath)
+			got := tt.branch.absPath(&Walker{rootPath: tt.rootPath})
 			if got != tt.wantPath {
 				t.Errorf("got %q, want %q", got, tt.wantPath)
 			}
@@ -378,6 +381,33 @@ func TestWalkOnLstatCallback(t *testing.T) {
 	}
 }
 
+// TestWalkOnLstatOncePerPath verifies that no path is lstat'ed twice,
+// in particular directories, which are reported while scanning their parent.
+func TestWalkOnLstatOncePerPath(t *testing.T) {
+	tmpDir := setupTestDir(t)
+
+	seen := map[string]int{}
+	var mu sync.Mutex
+	callbacks := Callbacks{
+		OnLstat: func(isDir bool, relPath string, fileInfo os.FileInfo, err error) {
+			mu.Lock()
+			seen[relPath]++
+			mu.Unlock()
+		},
+	}
+
+	walker := NewWalker(tmpDir, 4, callbacks)
+	if err := walker.Run(); err != nil {
+		t.Fatalf("Walk failed: %v", err)
+	}
+
+	for relPath, n := range seen {
+		if n != 1 {
+			t.Errorf("OnLstat called %d times for %q, want 1", n, relPath)
+		}
+	}
+}
+
 // TestWalkOnReadDirCallback tests the OnReadDir callback.
 func TestWalkOnReadDirCallback(t *testing.T) {
 	tmpDir := setupTestDir(t)
@@ -479,6 +509,75 @@ func TestWalkStop(t *testing.T) {
 	}
 }
 
+// TestStopHaltsWalk verifies that Stop called during a walk skips the
+// directories not read yet and the roots not started, and that Run returns
+// context.Canceled.
+func TestStopHaltsWalk(t *testing.T) {
+	var roots []string
+	for i := 0; i < 3; i++ {
+		root := t.TempDir()
+		for j := 0; j < 100; j++ {
+			if err := os.Mkdir(filepath.Join(root, strconv.Itoa(j)), 0755); err != nil {
+				t.Fatal(err)
+			}
+		}
+		roots = append(roots, root)
+	}
+
+	var walker *Walker
+	var started, reads atomic.Int64
+	walker = NewWalkerRoots(roots, 4, Callbacks{
+		OnRoot: func(root string) { started.Add(1) },
+		OnReadDir: func(relPath string, entries []os.DirEntry, err error) {
+			if reads.Add(1) == 5 {
+				walker.Stop()
+			}
+		},
+	})
+	if err := walker.Run(); !errors.Is(err, context.Canceled) {
+		t.Errorf("Run() = %v, want context.Canceled", err)
+	}
+	if n := started.Load(); n != 1 {
+		t.Errorf("started %d roots, want 1", n)
+	}
+	// At most the directories in progress are read after Stop
+	if n := reads.Load(); n > 5+4 {
+		t.Errorf("read %d directories after stopping at 5", n)
+	}
+
+	// Reset clears the Stop
+	walker.Reset()
+	walker.callbacks.OnReadDir = nil
+	if err := walker.Run(); err != nil {
+		t.Errorf("Run() after Reset = %v", err)
+	}
+	if n := started.Load(); n != 4 {
+		t.Errorf("started %d roots in total, want 4", n)
+	}
+}
+
+// TestStopAndWait verifies that StopAndWait returns only once a Stream has
+// closed its channels.
+func TestStopAndWait(t *testing.T) {
+	walker := NewWalker(setupTestDir(t), 2, Callbacks{})
+	walker.SetStreamBuffer(0)
+	entries, errc := walker.Stream(context.Background())
+	<-entries
+	walker.StopAndWait()
+
+	for range entries {
+		// The entries sent before the stop
+	}
+	select {
+	case err := <-errc:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("error = %v, want context.Canceled", err)
+		}
+	default:
+		t.Error("error channel not ready after StopAndWait")
+	}
+}
+
 // TestIgnoreNames verifies that configured ignore basenames are skipped.
 func TestIgnoreNames(t *testing.T) {
 	tmpDir := t.TempDir()
@@ -584,6 +683,79 @@ func TestIgnoreFunc(t *testing.T) {
 	}
 }
 
+// TestQueueSteal verifies that owners pop the newest branch while thieves
+// take the oldest.
+func TestQueueSteal(t *testing.T) {
+	worker := &walkWorker{}
+	a := &walkBranch{basename: "a"}
+	b := &walkBranch{basename: "b"}
+	c := &walkBranch{basename: "c"}
+	worker.queuePush(a)
+	worker.queuePush(b)
+	worker.queuePush(c)
+
+	if got := worker.queueSteal(); got != a {
+		t.Errorf("queueSteal() = %q, want %q", got.basename, "a")
+	}
+	if got := worker.queuePop(); got != c {
+		t.Errorf("queuePop() = %q, want %q", got.basename, "c")
+	}
+	if got := worker.queueSteal(); got != b {
+		t.Errorf("queueSteal() = %q, want %q", got.basename, "b")
+	}
+	if got := worker.queueSteal(); got != nil {
+		t.Errorf("queueSteal() on empty queue = %q, want nil", got.basename)
+	}
+}
+
+// TestWalkDeepTreeCompletes walks a deep, narrow tree where idle workers
+// have nothing to steal for most of the walk. Workers must park rather
+// than exit, and every entry must be visited exactly once.
+func TestWalkDeepTreeCompletes(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	const depth = 40
+	dir := tmpDir
+	for i := 0; i < depth; i++ {
+		dir = filepath.Join(dir, "d")
+		if err := os.Mkdir(dir, 0755); err != nil {
+			t.Fatalf("failed to create dir: %v", err)
+		}
+		for _, name := range []string{"a", "b"} {
+			if err := os.WriteFile(filepath.Join(dir, name), nil, 0600); err != nil {
+				t.Fatalf("failed to create file: %v", err)
+			}
+		}
+	}
+
+	for _, numWorkers := range []int{1, 2, 4, 16} {
+		for run := 0; run < 10; run++ {
+			var files, dirs int64
+			callbacks := Callbacks{
+				OnFileOrSymlink: func(relPath string, entry os.DirEntry) {
+					atomic.AddInt64(&files, 1)
+				},
+				OnDirectory: func(relPath string, entry os.DirEntry) {
+					atomic.AddInt64(&dirs, 1)
+				},
+			}
+
+			walker := NewWalker(tmpDir, numWorkers, callbacks)
+			if err := walker.Run(); err != nil {
+				t.Fatalf("Walk with %d workers failed: %v", numWorkers, err)
+			}
+
+			if files != 2*depth || dirs != depth {
+				t.Fatalf("with %d workers: got %d files and %d dirs, want %d and %d",
+					numWorkers, files, dirs, 2*depth, depth)
+			}
+			if walker.pending != 0 {
+				t.Fatalf("with %d workers: %d branches still pending after Run", numWorkers, walker.pending)
+			}
+		}
+	}
+}
+
 // BenchmarkWalk benchmarks the walk operation with a single worker.
 func BenchmarkWalkSingleWorker(b *testing.B) {
 	tmpDir := setupTestDir(&testing.T{})
@@ -955,3 +1127,57 @@ func BenchmarkWalkLargeTreeManyWorkers(b *testing.B) {
 		_ = walker.Run()
 	}
 }
+
+// TestWalkShouldDescend tests pruning directories with ShouldDescend.
+func TestWalkShouldDescend(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, name := range []string{"keep/a", "keep/deep/b", "prune/c", "prune/sub/d"} {
+		path := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var mu sync.Mutex
+	seen := map[string]bool{}
+	var asked []string
+	walker := NewWalker(tmpDir, 2, Callbacks{
+		OnLstat: func(isDir bool, relPath string, info os.FileInfo, err error) {
+			mu.Lock()
+			seen[relPath] = true
+			mu.Unlock()
+		},
+		ShouldDescend: func(relPath string, entry os.DirEntry) bool {
+			mu.Lock()
+			asked = append(asked, relPath)
+			mu.Unlock()
+			if !entry.IsDir() {
+				t.Errorf("ShouldDescend called for non-directory %s", relPath)
+			}
+			return relPath != "prune"
+		},
+	})
+	if err := walker.Run(); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	for _, relPath := range []string{"", "keep", "keep/a", "keep/deep", "keep/deep/b", "prune"} {
+		if !seen[relPath] {
+			t.Errorf("%q not reported", relPath)
+		}
+	}
+	for _, relPath := range []string{"prune/c", "prune/sub"} {
+		if seen[relPath] {
+			t.Errorf("%q reported below a pruned directory", relPath)
+		}
+	}
+	if len(asked) != 3 {
+		t.Errorf("ShouldDescend called for %v, want keep, keep/deep and prune", asked)
+	}
+	if stats := walker.Stats(); stats.Dirs != 3 {
+		t.Errorf("read %d directories, want 3", stats.Dirs)
+	}
+}
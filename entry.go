@@ -0,0 +1,51 @@
+package cwalk
+
+import "os"
+
+// Entry describes an entry found by the walk, as passed to
+// Callbacks.OnEntry, so callbacks need not join paths or lstat entries
+// themselves.
+type Entry struct {
+	RelPath  string      // Slash-separated path relative to the root, "" for the root
+	AbsPath  string      // Path including the root
+	DirEntry os.DirEntry // Entry read from the parent directory; nil for a root
+	Info     os.FileInfo // lstat information; nil if Err is set
+	Err      error       // Error fetching the metadata
+	Depth    int         // Levels below the root: 0 for a root, 1 for its entries
+	Parent   *Entry      // Directory containing the entry; nil for a root
+}
+
+// IsDir reports whether the entry is a directory.
+func (e *Entry) IsDir() bool {
+	return e.Err == nil && e.Info != nil && e.Info.IsDir()
+}
+
+// reportEntry passes an entry found in the directory of parent, or a root
+// if parent is nil, to OnEntry and returns it. Without OnEntry, no entry
+// is built and nil is returned.
+func (c *Walker) reportEntry(parent *walkBranch, relPath, absPath string, dirEntry os.DirEntry, info os.FileInfo, err error) *Entry {
+	if c.callbacks.OnEntry == nil {
+		return nil
+	}
+	e := &Entry{RelPath: relPath, AbsPath: absPath, DirEntry: dirEntry, Info: info, Err: err}
+	if parent != nil {
+		e.Parent = parent.entryOf(c)
+		e.Depth = e.Parent.Depth + 1
+	}
+	c.callbacks.OnEntry(e)
+	return e
+}
+
+// entryOf returns the entry of the branch's directory. Directories not
+// reported in this walk, such as resumed ones and the directories above
+// them, get an entry without metadata.
+func (cb *walkBranch) entryOf(c *Walker) *Entry {
+	if cb.entry == nil {
+		cb.entry = &Entry{RelPath: cb.relPath(), AbsPath: cb.absPath(c)}
+		if cb.parent != nil {
+			cb.entry.Parent = cb.parent.entryOf(c)
+			cb.entry.Depth = cb.entry.Parent.Depth + 1
+		}
+	}
+	return cb.entry
+}
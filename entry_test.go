@@ -0,0 +1,80 @@
+package cwalk
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestOnEntry(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(tmpDir, "a", "b"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "a", "b", "file"), []byte("data"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	entries := map[string]*Entry{}
+	walker := NewWalker(tmpDir, 2, Callbacks{
+		OnEntry: func(e *Entry) {
+			mu.Lock()
+			entries[e.RelPath] = e
+			mu.Unlock()
+		},
+	})
+	if err := walker.Run(); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	if len(entries) != 4 {
+		t.Fatalf("got %d entries, want 4", len(entries))
+	}
+	root := entries[""]
+	if root.Parent != nil || root.DirEntry != nil || root.Depth != 0 || !root.IsDir() || root.AbsPath != tmpDir {
+		t.Errorf("root entry = %+v", root)
+	}
+
+	file := entries["a/b/file"]
+	if file.AbsPath != filepath.Join(tmpDir, "a", "b", "file") {
+		t.Errorf("file: AbsPath = %q", file.AbsPath)
+	}
+	if file.Depth != 3 || file.IsDir() || file.Info.Size() != 4 || file.DirEntry.Name() != "file" {
+		t.Errorf("file entry = %+v", file)
+	}
+	for e, want := file, []string{"a/b/file", "a/b", "a", ""}; e != nil; e = e.Parent {
+		if len(want) == 0 || e.RelPath != want[0] {
+			t.Fatalf("parent chain of file reaches %q, want %v", e.RelPath, want)
+		}
+		if e != entries[e.RelPath] {
+			t.Errorf("parent %q is not the entry reported for it", e.RelPath)
+		}
+		want = want[1:]
+	}
+}
+
+func TestOnEntryResume(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(tmpDir, "a", "b"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	var got *Entry
+	walker := NewWalker(tmpDir, 1, Callbacks{
+		OnEntry: func(e *Entry) { got = e },
+	})
+	walker.SetResume([]string{"a"})
+	if err := walker.Run(); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	// The resumed directory is not reported, but is the parent of its entries
+	if got == nil || got.RelPath != "a/b" || got.Depth != 2 {
+		t.Fatalf("got entry %+v, want a/b at depth 2", got)
+	}
+	if got.Parent.RelPath != "a" || got.Parent.Info != nil || got.Parent.Parent.RelPath != "" {
+		t.Errorf("parents of a/b = %+v and %+v", got.Parent, got.Parent.Parent)
+	}
+}
@@ -0,0 +1,94 @@
+// Command aggregator shows a custom aggregation on top of cwalk: it sums
+// file counts and sizes per file extension.
+//
+// Callbacks run concurrently on all workers, so shared state needs a lock.
+// For high entry rates, keep one map per worker or shard the state as
+// pkg/stat does; a single mutex is enough for most tools.
+//
+// Usage:
+//
+//	go run ./examples/aggregator /path/to/tree
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/otuschhoff/cwalk"
+)
+
+// extStat holds the totals of one extension.
+type extStat struct {
+	Files int64
+	Size  int64
+}
+
+// aggregate walks root and returns the totals of regular files by
+// extension. Files without an extension are counted under "".
+func aggregate(root string, workers int) (map[string]*extStat, error) {
+	var mu sync.Mutex
+	byExt := make(map[string]*extStat)
+
+	walker := cwalk.NewWalker(root, workers, cwalk.Callbacks{
+		OnLstat: func(isDir bool, relPath string, info os.FileInfo, err error) {
+			if err != nil || info == nil || !info.Mode().IsRegular() {
+				return
+			}
+			ext := strings.ToLower(path.Ext(relPath))
+
+			mu.Lock()
+			defer mu.Unlock()
+			s, ok := byExt[ext]
+			if !ok {
+				s = &extStat{}
+				byExt[ext] = s
+			}
+			s.Files++
+			s.Size += info.Size()
+		},
+	})
+	if err := walker.Run(); err != nil {
+		return nil, err
+	}
+	return byExt, nil
+}
+
+// report prints the totals, largest first.
+func report(w io.Writer, byExt map[string]*extStat) {
+	exts := make([]string, 0, len(byExt))
+	for ext := range byExt {
+		exts = append(exts, ext)
+	}
+	sort.Slice(exts, func(i, j int) bool {
+		if byExt[exts[i]].Size != byExt[exts[j]].Size {
+			return byExt[exts[i]].Size > byExt[exts[j]].Size
+		}
+		return exts[i] < exts[j]
+	})
+
+	for _, ext := range exts {
+		label := ext
+		if label == "" {
+			label = "(none)"
+		}
+		fmt.Fprintf(w, "%-10s %8d files %12d bytes\n", label, byExt[ext].Files, byExt[ext].Size)
+	}
+}
+
+func main() {
+	if len(os.Args) != 2 {
+		fmt.Fprintln(os.Stderr, "usage: aggregator <path>")
+		os.Exit(2)
+	}
+	byExt, err := aggregate(os.Args[1], 4)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	report(os.Stdout, byExt)
+}
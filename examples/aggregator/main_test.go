@@ -0,0 +1,44 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAggregate(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"a.txt":         "hello",
+		"sub/b.TXT":     "hi",
+		"sub/c.go":      "package c",
+		"sub/deep/Make": "all:",
+	}
+	for name, content := range files {
+		p := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	byExt, err := aggregate(root, 4)
+	if err != nil {
+		t.Fatalf("aggregate failed: %v", err)
+	}
+	if s := byExt[".txt"]; s == nil || s.Files != 2 || s.Size != 7 {
+		t.Errorf(".txt = %+v, want 2 files, 7 bytes", s)
+	}
+	if s := byExt[""]; s == nil || s.Files != 1 {
+		t.Errorf("no extension = %+v, want 1 file", s)
+	}
+
+	var out strings.Builder
+	report(&out, byExt)
+	if !strings.HasPrefix(out.String(), ".go") {
+		t.Errorf("largest extension should come first:\n%s", out.String())
+	}
+}
@@ -0,0 +1,86 @@
+// Command du shows post-order processing with OnDirectoryDone. Each
+// directory is reported once its whole subtree has been walked, with the
+// totals of the entries below it, so sizes roll up like du without the
+// program keeping a tree of its own. Empty directories are collected as
+// they are reported; with -prune they are removed, deepest first, and a
+// directory left empty by that is removed as well.
+//
+// Usage:
+//
+//	go run ./examples/du [-prune] /path/to/tree
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/otuschhoff/cwalk"
+)
+
+// dirUsage is the usage of a directory and its subtree.
+type dirUsage struct {
+	Path  string
+	Size  int64
+	Files int64
+}
+
+// usage walks root and returns the usage of each directory, largest
+// first, and the directories without files below them in the order they
+// were reported, so each comes after its subdirectories.
+func usage(root string, workers int) ([]dirUsage, []string, error) {
+	var mu sync.Mutex
+	var dirs []dirUsage
+	var empty []string
+
+	walker := cwalk.NewWalker(root, workers, cwalk.Callbacks{
+		OnDirectoryDone: func(relPath string, totals cwalk.DirTotals) {
+			mu.Lock()
+			defer mu.Unlock()
+			dirs = append(dirs, dirUsage{Path: relPath, Size: totals.Size, Files: totals.Files})
+			if totals.Files == 0 && totals.Errors == 0 && relPath != "" {
+				empty = append(empty, relPath)
+			}
+		},
+	})
+	if err := walker.Run(); err != nil {
+		return nil, nil, err
+	}
+
+	sort.Slice(dirs, func(i, j int) bool {
+		if dirs[i].Size != dirs[j].Size {
+			return dirs[i].Size > dirs[j].Size
+		}
+		return dirs[i].Path < dirs[j].Path
+	})
+	return dirs, empty, nil
+}
+
+func main() {
+	prune := flag.Bool("prune", false, "remove directories without files below them")
+	flag.Parse()
+	if flag.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: du [-prune] <path>")
+		os.Exit(2)
+	}
+	root := flag.Arg(0)
+
+	dirs, empty, err := usage(root, 4)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	for _, d := range dirs {
+		fmt.Printf("%12d %8d  %s\n", d.Size, d.Files, filepath.Join(root, d.Path))
+	}
+	if *prune {
+		for _, relPath := range empty {
+			if err := os.Remove(filepath.Join(root, relPath)); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+			}
+		}
+	}
+}
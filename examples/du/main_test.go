@@ -0,0 +1,42 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestUsage(t *testing.T) {
+	root := t.TempDir()
+	for name, size := range map[string]int{"a/b/x": 100, "a/y": 10, "c/z": 1} {
+		p := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, make([]byte, size), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.MkdirAll(filepath.Join(root, "old/empty"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	dirs, empty, err := usage(root, 4)
+	if err != nil {
+		t.Fatalf("usage failed: %v", err)
+	}
+	files := map[string]int64{}
+	for _, d := range dirs {
+		files[d.Path] = d.Files
+	}
+	if want := map[string]int64{"": 3, "a": 2, "a/b": 1, "c": 1, "old": 0, "old/empty": 0}; !reflect.DeepEqual(files, want) {
+		t.Errorf("files per directory = %v, want %v", files, want)
+	}
+	if dirs[0].Path != "" {
+		t.Errorf("largest directory = %q, want the root", dirs[0].Path)
+	}
+	if want := []string{"old/empty", "old"}; !reflect.DeepEqual(empty, want) {
+		t.Errorf("empty directories = %v, want %v", empty, want)
+	}
+}
@@ -0,0 +1,73 @@
+// Command pruned shows how to skip parts of a tree. Version control and
+// dependency directories are pruned by name with SetIgnoreNames, and
+// directories containing a ".nobackup" marker file are pruned by
+// SetIgnoreFunc, which sees each entry's lstat info.
+//
+// Pruned entries are still reported to OnLstat, since they are lstat'ed
+// before the ignore check, but pruned directories are not descended into
+// and neither OnFileOrSymlink nor OnDirectory is called for them.
+//
+// Usage:
+//
+//	go run ./examples/pruned /path/to/tree
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/otuschhoff/cwalk"
+)
+
+// ignoredNames are skipped wherever they occur.
+var ignoredNames = []string{".git", ".hg", "node_modules", "__pycache__"}
+
+// markerFile excludes the directory containing it.
+const markerFile = ".nobackup"
+
+// listFiles walks root and returns the relative paths of the files
+// outside pruned directories, sorted.
+func listFiles(root string, workers int) ([]string, error) {
+	var mu sync.Mutex
+	var files []string
+
+	walker := cwalk.NewWalker(root, workers, cwalk.Callbacks{
+		OnFileOrSymlink: func(relPath string, entry os.DirEntry) {
+			mu.Lock()
+			files = append(files, relPath)
+			mu.Unlock()
+		},
+	})
+	walker.SetIgnoreNames(ignoredNames)
+	walker.SetIgnoreFunc(func(name, relPath string, info os.FileInfo) bool {
+		if !info.IsDir() {
+			return false
+		}
+		_, err := os.Lstat(filepath.Join(root, relPath, markerFile))
+		return err == nil
+	})
+	if err := walker.Run(); err != nil {
+		return nil, err
+	}
+
+	sort.Strings(files)
+	return files, nil
+}
+
+func main() {
+	if len(os.Args) != 2 {
+		fmt.Fprintln(os.Stderr, "usage: pruned <path>")
+		os.Exit(2)
+	}
+	files, err := listFiles(os.Args[1], 4)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	for _, f := range files {
+		fmt.Println(f)
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestListFiles(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{
+		"src/main.go",
+		"src/.git/HEAD",
+		"web/node_modules/pkg/index.js",
+		"web/app.js",
+		"cache/.nobackup",
+		"cache/blob",
+	} {
+		p := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files, err := listFiles(root, 4)
+	if err != nil {
+		t.Fatalf("listFiles failed: %v", err)
+	}
+	if want := []string{"src/main.go", "web/app.js"}; !reflect.DeepEqual(files, want) {
+		t.Errorf("listFiles() = %v, want %v", files, want)
+	}
+}
@@ -0,0 +1,74 @@
+// Command skeleton shows a copy-style integration: it recreates the
+// directory structure of a tree under a destination, with the source
+// permissions, but without any files. Such skeletons are useful to prepare
+// a migration target or to share a layout without its data.
+//
+// OnDirectory can be called for a directory before its parent has been
+// created, since workers run concurrently, so each directory is created
+// with MkdirAll and permissions are applied after the walk.
+//
+// Usage:
+//
+//	go run ./examples/skeleton /path/to/source /path/to/destination
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/otuschhoff/cwalk"
+)
+
+// copySkeleton recreates the directories below src under dst and returns
+// how many were created. dst itself is created if needed.
+func copySkeleton(src, dst string, workers int) (int, error) {
+	var mu sync.Mutex
+	modes := make(map[string]os.FileMode)
+	var firstErr error
+
+	walker := cwalk.NewWalker(src, workers, cwalk.Callbacks{
+		OnLstat: func(isDir bool, relPath string, info os.FileInfo, err error) {
+			if err != nil || info == nil || !info.IsDir() {
+				return
+			}
+			// Create directories writable for now so children can be added
+			target := filepath.Join(dst, filepath.FromSlash(relPath))
+			mkErr := os.MkdirAll(target, 0700)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if mkErr != nil && firstErr == nil {
+				firstErr = mkErr
+			}
+			modes[target] = info.Mode().Perm()
+		},
+	})
+	if err := walker.Run(); err != nil {
+		return 0, err
+	}
+	if firstErr != nil {
+		return 0, firstErr
+	}
+
+	for target, mode := range modes {
+		if err := os.Chmod(target, mode); err != nil {
+			return 0, err
+		}
+	}
+	return len(modes) - 1, nil
+}
+
+func main() {
+	if len(os.Args) != 3 {
+		fmt.Fprintln(os.Stderr, "usage: skeleton <source> <destination>")
+		os.Exit(2)
+	}
+	n, err := copySkeleton(os.Args[1], os.Args[2], 4)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	fmt.Printf("%d directories created\n", n)
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestCopySkeleton(t *testing.T) {
+	src, dst := t.TempDir(), filepath.Join(t.TempDir(), "copy")
+	if err := os.MkdirAll(filepath.Join(src, "a", "b", "c"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(src, "private"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "a", "file"), []byte("data"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := copySkeleton(src, dst, 4)
+	if err != nil {
+		t.Fatalf("copySkeleton failed: %v", err)
+	}
+	if n != 4 {
+		t.Errorf("created %d directories, want 4", n)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "a", "b", "c")); err != nil {
+		t.Errorf("nested directory missing: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "a", "file")); !os.IsNotExist(err) {
+		t.Errorf("file should not be copied: %v", err)
+	}
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(filepath.Join(dst, "private"))
+		if err != nil || info.Mode().Perm() != 0700 {
+			t.Errorf("private mode = %v, %v, want 0700", info.Mode().Perm(), err)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+// Command streaming shows how to consume walk results as a stream: the
+// callbacks only send entries to a channel, and a single consumer
+// goroutine processes them in arrival order.
+//
+// This keeps the consumer free of locks and lets slow processing (hashing,
+// uploading, writing to a database) apply backpressure to the walk through
+// the channel buffer.
+//
+// Usage:
+//
+//	go run ./examples/streaming /path/to/tree
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/otuschhoff/cwalk"
+)
+
+// entry is one walked file system entry.
+type entry struct {
+	RelPath string
+	Size    int64
+	IsDir   bool
+}
+
+// stream walks root and returns a channel delivering every entry except
+// the root itself, and a channel delivering the walk's result once the
+// entries channel is closed.
+func stream(root string, workers, buffer int) (<-chan entry, <-chan error) {
+	entries := make(chan entry, buffer)
+	done := make(chan error, 1)
+
+	walker := cwalk.NewWalker(root, workers, cwalk.Callbacks{
+		OnLstat: func(isDir bool, relPath string, info os.FileInfo, err error) {
+			if err != nil || info == nil || relPath == "" {
+				return
+			}
+			entries <- entry{RelPath: relPath, Size: info.Size(), IsDir: isDir}
+		},
+	})
+
+	go func() {
+		err := walker.Run()
+		close(entries)
+		done <- err
+	}()
+	return entries, done
+}
+
+// consume prints every entry and returns the number of entries and bytes.
+func consume(w io.Writer, entries <-chan entry) (count, size int64) {
+	for e := range entries {
+		kind := "f"
+		if e.IsDir {
+			kind = "d"
+		}
+		fmt.Fprintf(w, "%s %10d %s\n", kind, e.Size, e.RelPath)
+		count++
+		size += e.Size
+	}
+	return count, size
+}
+
+func main() {
+	if len(os.Args) != 2 {
+		fmt.Fprintln(os.Stderr, "usage: streaming <path>")
+		os.Exit(2)
+	}
+	entries, done := stream(os.Args[1], 4, 1024)
+	count, size := consume(os.Stdout, entries)
+	if err := <-done; err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	fmt.Printf("%d entries, %d bytes\n", count, size)
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStream(t *testing.T) {
+	root := t.TempDir()
+	for i, name := range []string{"a", "b/c", "b/d/e"} {
+		p := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, make([]byte, i+1), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// An unbuffered channel makes the walk wait for the consumer.
+	entries, done := stream(root, 4, 0)
+	var files, fileSize int64
+	for e := range entries {
+		if !e.IsDir {
+			files++
+			fileSize += e.Size
+		}
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("walk failed: %v", err)
+	}
+	if files != 3 || fileSize != 6 {
+		t.Errorf("got %d files of %d bytes, want 3 of 6", files, fileSize)
+	}
+
+	entries, done = stream(root, 2, 16)
+	if count, _ := consume(io.Discard, entries); count != 5 {
+		t.Errorf("consume() counted %d entries, want 5", count)
+	}
+	<-done
+}
@@ -0,0 +1,55 @@
+package cwalk
+
+// SetMaxOpenDirs limits the directories held open at once across all
+// workers to n. A worker that would exceed the limit waits until another
+// directory is closed instead of failing with "too many open files", so
+// high worker counts cannot exhaust file descriptors.
+//
+// With n == 0 (the default), the limit is derived from RLIMIT_NOFILE when
+// Run starts: half the soft limit, leaving the rest to callbacks and the
+// program embedding the walker. Without such a limit, or with n < 0, open
+// directories are not limited.
+func (c *Walker) SetMaxOpenDirs(n int) {
+	c.maxOpenDirs = n
+}
+
+// initFDs sets up the open directory budget of a walk.
+func (c *Walker) initFDs() {
+	n := c.maxOpenDirs
+	if n == 0 {
+		if limit, ok := openFileLimit(); ok {
+			n = int(max(1, min(limit/2, uint64(maxInt))))
+		}
+	}
+	if n <= 0 {
+		c.fdSem = nil
+		return
+	}
+	c.fdSem = make(chan struct{}, n)
+	c.log().Debug("open directory limit", "dirs", n)
+}
+
+// maxInt is the largest int, for clamping limits reported as uint64.
+const maxInt = int(^uint(0) >> 1)
+
+// acquireFD blocks until a directory may be opened within the budget.
+func (c *Walker) acquireFD() {
+	if c.fdSem == nil {
+		return
+	}
+	select {
+	case c.fdSem <- struct{}{}:
+	default:
+		c.fdWait.Do(func() {
+			c.log().Warn("open directory limit reached, queuing directory reads", "dirs", cap(c.fdSem))
+		})
+		c.fdSem <- struct{}{}
+	}
+}
+
+// releaseFD returns a directory closed to the budget.
+func (c *Walker) releaseFD() {
+	if c.fdSem != nil {
+		<-c.fdSem
+	}
+}
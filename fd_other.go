@@ -0,0 +1,8 @@
+//go:build !unix
+
+package cwalk
+
+// openFileLimit reports no limit on platforms without RLIMIT_NOFILE.
+func openFileLimit() (uint64, bool) {
+	return 0, false
+}
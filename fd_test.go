@@ -0,0 +1,75 @@
+package cwalk
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestMaxOpenDirs(t *testing.T) {
+	tmpDir := t.TempDir()
+	for i := 0; i < 20; i++ {
+		dir := filepath.Join(tmpDir, strconv.Itoa(i))
+		if err := os.Mkdir(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		for j := 0; j < 5; j++ {
+			if err := os.WriteFile(filepath.Join(dir, strconv.Itoa(j)), nil, 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	// In batch mode a directory stays open from its first batch to the
+	// last call for it
+	var mu sync.Mutex
+	open, peak, dirs := map[string]bool{}, 0, 0
+	walker := NewWalker(tmpDir, 8, Callbacks{
+		OnReadDirBatch: func(relPath string, entries []os.DirEntry, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			if len(entries) > 0 {
+				open[relPath] = true
+				peak = max(peak, len(open))
+				return
+			}
+			delete(open, relPath)
+			dirs++
+		},
+	})
+	walker.SetReadDirBatch(2)
+	walker.SetMaxOpenDirs(2)
+	if err := walker.Run(); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	if dirs != 21 {
+		t.Errorf("read %d directories, want 21", dirs)
+	}
+	if peak > 2 {
+		t.Errorf("%d directories open at once, want at most 2", peak)
+	}
+}
+
+func TestMaxOpenDirsDefault(t *testing.T) {
+	walker := NewWalker(t.TempDir(), 1, Callbacks{})
+	walker.initFDs()
+	limit, ok := openFileLimit()
+	if !ok {
+		if walker.fdSem != nil {
+			t.Errorf("open directories limited to %d without a file limit", cap(walker.fdSem))
+		}
+		return
+	}
+	if want := int(max(1, limit/2)); walker.fdSem == nil || cap(walker.fdSem) != want {
+		t.Errorf("default open directory limit = %v, want %d", walker.fdSem, want)
+	}
+
+	walker.SetMaxOpenDirs(-1)
+	walker.initFDs()
+	if walker.fdSem != nil {
+		t.Errorf("open directories limited to %d with SetMaxOpenDirs(-1)", cap(walker.fdSem))
+	}
+}
@@ -0,0 +1,18 @@
+//go:build unix
+
+package cwalk
+
+import "golang.org/x/sys/unix"
+
+// openFileLimit returns the soft limit on open file descriptors, or false
+// if it is unlimited or unknown.
+func openFileLimit() (uint64, bool) {
+	var rl unix.Rlimit
+	if err := unix.Getrlimit(unix.RLIMIT_NOFILE, &rl); err != nil {
+		return 0, false
+	}
+	if rl.Cur == unix.RLIM_INFINITY {
+		return 0, false
+	}
+	return uint64(rl.Cur), true
+}
@@ -0,0 +1,61 @@
+package cwalk
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// NewWalkerFS creates a new Walker that walks the tree at root inside fsys
+// instead of the operating system's filesystem. This allows walking any
+// io/fs implementation, such as zip archives, fstest.MapFS or adapters for
+// object stores. root is a slash-separated path valid for fsys; use "." to
+// walk the whole filesystem.
+//
+// Entries are described by fs.DirEntry.Info. If fsys has an
+// Lstat(name string) (fs.FileInfo, error) method, it is used for the root
+// and whenever Info fails, so symlinks are reported as symlinks; otherwise
+// fs.Stat is used. The statx and io_uring backends do not apply and are
+// ignored.
+func NewWalkerFS(fsys fs.FS, root string, numWorkers int, callbacks Callbacks) *Walker {
+	w := NewWalker(".", numWorkers, callbacks)
+	w.fsys = fsys
+	w.SetRoots(root)
+	return w
+}
+
+// lstatFS is implemented by filesystems that can describe a symlink itself
+// rather than its target.
+type lstatFS interface {
+	fs.FS
+	Lstat(name string) (fs.FileInfo, error)
+}
+
+// join joins path elements with the separator of the walked filesystem.
+func (c *Walker) join(elem ...string) string {
+	if c.fsys != nil {
+		return path.Join(elem...)
+	}
+	return filepath.Join(elem...)
+}
+
+// readDir reads the directory at path, sorted by name, or by inode number
+// with SetInodeOrder.
+func (c *Walker) readDir(path string) ([]os.DirEntry, error) {
+	if c.fsys != nil {
+		return fs.ReadDir(c.fsys, path)
+	}
+	if c.inodeOrder {
+		return readDirInodeOrder(path)
+	}
+	return os.ReadDir(path)
+}
+
+// statFS returns file info for path inside the walker's fs.FS.
+func (c *Walker) statFS(path string) (os.FileInfo, error) {
+	if lfs, ok := c.fsys.(lstatFS); ok {
+		return lfs.Lstat(path)
+	}
+	return fs.Stat(c.fsys, path)
+}
@@ -0,0 +1,113 @@
+package cwalk
+
+import (
+	"os"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"testing/fstest"
+)
+
+func testMapFS() fstest.MapFS {
+	return fstest.MapFS{
+		"file1.txt":           {Data: []byte("one")},
+		"dir1/file2.txt":      {Data: []byte("two!")},
+		"dir1/dir2/file3.txt": {Data: []byte("three")},
+		"dir3/file4.txt":      {Data: []byte("four")},
+	}
+}
+
+// TestWalkFS verifies that an fs.FS is walked like a directory tree, with
+// the same callbacks and relative paths as an OS walk.
+func TestWalkFS(t *testing.T) {
+	var mu sync.Mutex
+	sizes := map[string]int64{}
+	var files, dirs []string
+
+	callbacks := Callbacks{
+		OnLstat: func(isDir bool, relPath string, fileInfo os.FileInfo, err error) {
+			if err != nil {
+				t.Errorf("OnLstat got error for %q: %v", relPath, err)
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if !isDir {
+				sizes[relPath] = fileInfo.Size()
+			}
+		},
+		OnFileOrSymlink: func(relPath string, entry os.DirEntry) {
+			mu.Lock()
+			defer mu.Unlock()
+			files = append(files, relPath)
+		},
+		OnDirectory: func(relPath string, entry os.DirEntry) {
+			mu.Lock()
+			defer mu.Unlock()
+			dirs = append(dirs, relPath)
+		},
+	}
+
+	walker := NewWalkerFS(testMapFS(), ".", 2, callbacks)
+	if err := walker.Run(); err != nil {
+		t.Fatalf("Walk failed: %v", err)
+	}
+
+	sort.Strings(files)
+	sort.Strings(dirs)
+	if want := []string{"dir1/dir2/file3.txt", "dir1/file2.txt", "dir3/file4.txt", "file1.txt"}; !reflect.DeepEqual(files, want) {
+		t.Errorf("files = %v, want %v", files, want)
+	}
+	if want := []string{"dir1", "dir1/dir2", "dir3"}; !reflect.DeepEqual(dirs, want) {
+		t.Errorf("dirs = %v, want %v", dirs, want)
+	}
+	if sizes["dir1/dir2/file3.txt"] != 5 {
+		t.Errorf("size of dir1/dir2/file3.txt = %d, want 5", sizes["dir1/dir2/file3.txt"])
+	}
+}
+
+// TestWalkFSSubdir verifies that relative paths are relative to the root
+// inside the fs.FS, and that OS-specific backends are ignored.
+func TestWalkFSSubdir(t *testing.T) {
+	var files []string
+	callbacks := Callbacks{
+		OnFileOrSymlink: func(relPath string, entry os.DirEntry) {
+			files = append(files, relPath)
+		},
+	}
+
+	walker := NewWalkerFS(testMapFS(), "dir1/", 1, callbacks)
+	walker.SetBackend(BackendIOUring)
+	walker.SetStatx(StatxAll)
+	if err := walker.Run(); err != nil {
+		t.Fatalf("Walk failed: %v", err)
+	}
+
+	sort.Strings(files)
+	if want := []string{"dir2/file3.txt", "file2.txt"}; !reflect.DeepEqual(files, want) {
+		t.Errorf("files = %v, want %v", files, want)
+	}
+}
+
+// TestWalkFSNonexistentRoot verifies that a missing root is reported via
+// OnLstat.
+func TestWalkFSNonexistentRoot(t *testing.T) {
+	var rootErr error
+	callbacks := Callbacks{
+		OnLstat: func(isDir bool, relPath string, fileInfo os.FileInfo, err error) {
+			if relPath == "" {
+				rootErr = err
+			}
+		},
+	}
+
+	walker := NewWalkerFS(testMapFS(), "missing", 1, callbacks)
+	walker.SetLogger(&mockLogger{})
+	if err := walker.Run(); err != nil {
+		t.Fatalf("Walk failed: %v", err)
+	}
+	if !os.IsNotExist(rootErr) {
+		t.Errorf("root error = %v, want not exist", rootErr)
+	}
+}
@@ -0,0 +1,16 @@
+package cwalk
+
+// SetInodeOrder makes the walker sort the entries of each directory by
+// inode number before fetching their metadata. On spinning disks with
+// file systems such as ext4, inodes are laid out in inode number order, so
+// stat'ing in that order turns random seeks into mostly sequential reads,
+// which can speed up cold scans of millions of files dramatically. It
+// does not help on SSDs or network file systems.
+//
+// Entries are then passed to the callbacks in inode order instead of name
+// order. Only supported on Linux, where the inode numbers come with the
+// directory read at no extra cost; elsewhere, and for walks of an fs.FS,
+// entries stay in name order.
+func (c *Walker) SetInodeOrder(inodeOrder bool) {
+	c.inodeOrder = inodeOrder
+}
@@ -0,0 +1,178 @@
+//go:build linux
+
+package cwalk
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/binary"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"golang.org/x/sys/unix"
+)
+
+// Offsets of the fields of struct linux_dirent64 returned by getdents64.
+const (
+	direntIno    = 0
+	direntReclen = 16
+	direntType   = 18
+	direntName   = 19
+)
+
+// readDirInodeOrder reads the directory at path with getdents64 and
+// returns its entries sorted by inode number.
+func readDirInodeOrder(path string) ([]os.DirEntry, error) {
+	dir, err := openInodeDir(path)
+	if err != nil {
+		return nil, err
+	}
+	defer dir.Close()
+
+	entries, err := dir.ReadDir(-1)
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// inodeDir is an open directory read with getdents64 whose batches are
+// sorted by inode number.
+type inodeDir struct {
+	path string
+	fd   int
+	buf  []byte
+	next []*inodeDirEntry // Entries read but not returned yet
+	eof  bool
+}
+
+// openInodeDir opens the directory at path for reading in inode order.
+func openInodeDir(path string) (*inodeDir, error) {
+	fd, err := unix.Open(path, unix.O_RDONLY|unix.O_DIRECTORY|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, &os.PathError{Op: "open", Path: path, Err: err}
+	}
+	return &inodeDir{path: path, fd: fd, buf: make([]byte, 32*1024)}, nil
+}
+
+// Close closes the directory.
+func (d *inodeDir) Close() error {
+	return unix.Close(d.fd)
+}
+
+// ReadDir returns the next n entries of the directory sorted by inode
+// number, or all remaining ones if n <= 0. Like (*os.File).ReadDir, it
+// returns io.EOF once the directory is exhausted and n > 0.
+func (d *inodeDir) ReadDir(n int) ([]os.DirEntry, error) {
+	for !d.eof && (n <= 0 || len(d.next) < n) {
+		if err := d.fill(); err != nil {
+			return nil, err
+		}
+	}
+
+	batch := d.next
+	if n > 0 && len(batch) > n {
+		batch = batch[:n]
+	}
+	d.next = d.next[len(batch):]
+	if n > 0 && len(batch) == 0 {
+		return nil, io.EOF
+	}
+
+	slices.SortFunc(batch, func(a, b *inodeDirEntry) int {
+		return cmp.Or(cmp.Compare(a.ino, b.ino), cmp.Compare(a.name, b.name))
+	})
+
+	result := make([]os.DirEntry, 0, len(batch))
+	for _, entry := range batch {
+		if entry.typ == fs.ModeIrregular {
+			// The file system does not report types in directory reads
+			info, err := os.Lstat(filepath.Join(d.path, entry.name))
+			if os.IsNotExist(err) {
+				continue // Removed since the directory was read
+			}
+			if err != nil {
+				return nil, err
+			}
+			entry.typ = info.Mode().Type()
+		}
+		result = append(result, entry)
+	}
+	return result, nil
+}
+
+// fill reads one buffer of entries with getdents64.
+func (d *inodeDir) fill() error {
+	n, err := unix.ReadDirent(d.fd, d.buf)
+	if err == unix.EINTR {
+		return nil
+	}
+	if err != nil {
+		return &os.PathError{Op: "readdirent", Path: d.path, Err: err}
+	}
+	if n <= 0 {
+		d.eof = true
+		return nil
+	}
+
+	buf := d.buf[:n]
+	for off := 0; off+direntName <= n; {
+		reclen := int(binary.NativeEndian.Uint16(buf[off+direntReclen:]))
+		if reclen == 0 || off+reclen > n {
+			break
+		}
+		name := buf[off+direntName : off+reclen]
+		if i := bytes.IndexByte(name, 0); i >= 0 {
+			name = name[:i]
+		}
+		if s := string(name); s != "." && s != ".." {
+			d.next = append(d.next, &inodeDirEntry{
+				dir:  d.path,
+				name: s,
+				ino:  binary.NativeEndian.Uint64(buf[off+direntIno:]),
+				typ:  direntMode(buf[off+direntType]),
+			})
+		}
+		off += reclen
+	}
+	return nil
+}
+
+// direntMode converts a d_type value to the type bits of a file mode.
+// Unknown types are returned as fs.ModeIrregular.
+func direntMode(typ byte) fs.FileMode {
+	switch typ {
+	case unix.DT_REG:
+		return 0
+	case unix.DT_DIR:
+		return fs.ModeDir
+	case unix.DT_LNK:
+		return fs.ModeSymlink
+	case unix.DT_FIFO:
+		return fs.ModeNamedPipe
+	case unix.DT_SOCK:
+		return fs.ModeSocket
+	case unix.DT_CHR:
+		return fs.ModeDevice | fs.ModeCharDevice
+	case unix.DT_BLK:
+		return fs.ModeDevice
+	}
+	return fs.ModeIrregular
+}
+
+// inodeDirEntry is a directory entry read together with its inode number.
+type inodeDirEntry struct {
+	dir  string
+	name string
+	ino  uint64
+	typ  fs.FileMode
+}
+
+func (e *inodeDirEntry) Name() string               { return e.name }
+func (e *inodeDirEntry) IsDir() bool                { return e.typ.IsDir() }
+func (e *inodeDirEntry) Type() fs.FileMode          { return e.typ }
+func (e *inodeDirEntry) String() string             { return fs.FormatDirEntry(e) }
+func (e *inodeDirEntry) Info() (fs.FileInfo, error) { return os.Lstat(filepath.Join(e.dir, e.name)) }
@@ -0,0 +1,17 @@
+//go:build !linux
+
+package cwalk
+
+import "os"
+
+// readDirInodeOrder reads a directory in name order on platforms whose
+// directory reads are not sorted by inode here.
+func readDirInodeOrder(path string) ([]os.DirEntry, error) {
+	return os.ReadDir(path)
+}
+
+// openInodeDir opens a directory for reading in directory order on
+// platforms whose directory reads are not sorted by inode here.
+func openInodeDir(path string) (*os.File, error) {
+	return os.Open(path)
+}
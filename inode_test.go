@@ -0,0 +1,64 @@
+//go:build linux
+
+package cwalk
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"testing"
+)
+
+func TestInodeOrder(t *testing.T) {
+	tmpDir := t.TempDir()
+	for i := 0; i < 50; i++ {
+		if err := os.WriteFile(filepath.Join(tmpDir, strconv.Itoa(i)), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(tmpDir, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("0", filepath.Join(tmpDir, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	var inodes []uint64
+	seen := 0
+	walker := NewWalker(tmpDir, 1, Callbacks{
+		OnReadDir: func(relPath string, entries []os.DirEntry, err error) {
+			if relPath != "" {
+				return
+			}
+			for _, entry := range entries {
+				info, err := entry.Info()
+				if err != nil {
+					t.Fatalf("Info(%s) failed: %v", entry.Name(), err)
+				}
+				names = append(names, entry.Name())
+				inodes = append(inodes, info.Sys().(*syscall.Stat_t).Ino)
+			}
+		},
+		OnLstat: func(isDir bool, relPath string, info os.FileInfo, err error) {
+			if err == nil && (relPath == "sub") != isDir && relPath != "" {
+				t.Errorf("%s: isDir = %v", relPath, isDir)
+			}
+			seen++
+		},
+	})
+	walker.SetInodeOrder(true)
+	if err := walker.Run(); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	if len(names) != 52 || seen != 53 {
+		t.Fatalf("got %d entries and %d lstat calls, want 52 and 53", len(names), seen)
+	}
+	for i := 1; i < len(inodes); i++ {
+		if inodes[i] < inodes[i-1] {
+			t.Fatalf("entries not in inode order: %s (%d) after %s (%d)", names[i], inodes[i], names[i-1], inodes[i-1])
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package cwalk
+
+import (
+	"os"
+	"sync"
+	"time"
+)
+
+// SetIOConcurrency limits the number of stat and readdir calls in flight
+// across all workers to n, and lets each worker issue the stat calls for a
+// directory's entries concurrently up to that limit. This separates IO
+// concurrency from the number of workers: on parallel filesystems a few
+// workers can keep hundreds of metadata requests outstanding without
+// spawning hundreds of workers.
+//
+// With n <= 0 (the default), each worker issues one call at a time, so the
+// number of workers bounds the calls in flight. The io_uring backend submits
+// the entries of a directory as a single batch and is not limited by n.
+func (c *Walker) SetIOConcurrency(n int) {
+	if n <= 0 {
+		c.ioSem = nil
+		return
+	}
+	c.ioSem = make(chan struct{}, n)
+}
+
+// acquireIO blocks until a call issuing ops stat or readdir requests may
+// start, honoring the throttle and the IO concurrency limit, and returns
+// the time the call starts. The time is only taken while tuning workers.
+func (c *Walker) acquireIO(ops int) time.Time {
+	c.throttle(ops)
+	c.syscalls.Add(int64(ops))
+	if c.ioSem != nil {
+		c.ioSem <- struct{}{}
+	}
+	if c.maxWorkers <= 0 {
+		return time.Time{}
+	}
+	return time.Now()
+}
+
+// releaseIO marks an IO call that started at start as finished and records
+// its latency for the worker count tuner.
+func (c *Walker) releaseIO(start time.Time) {
+	if c.maxWorkers > 0 {
+		c.ioOps.Add(1)
+		c.ioNanos.Add(int64(time.Since(start)))
+	}
+	if c.ioSem != nil {
+		<-c.ioSem
+	}
+}
+
+// statEntry returns lstat information for a directory entry at path.
+// DirEntry.Info can reuse data from the directory read on some platforms;
+// fall back to an explicit lstat if it fails. With statx enabled, always go
+// through statx to honor the field mask.
+func (c *Walker) statEntry(path string, entry os.DirEntry) (os.FileInfo, error) {
+	if c.useStatx() {
+		return c.lstat(path)
+	}
+
+	info, err := entry.Info()
+	if err != nil {
+		info, err = c.lstat(path)
+	}
+	return info, err
+}
+
+// statEntries fetches metadata for all entries of the directory at dirPath
+// concurrently, bounded by the IO semaphore. Results are returned in entry
+// order so callbacks still run in order on the worker goroutine.
+func (c *Walker) statEntries(dirPath string, entries []os.DirEntry) []statResult {
+	results := make([]statResult, len(entries))
+
+	var wg sync.WaitGroup
+	for i, entry := range entries {
+		start := c.acquireIO(1)
+		wg.Add(1)
+		go func(i int, entry os.DirEntry) {
+			defer wg.Done()
+			defer c.releaseIO(start)
+			info, err := c.statEntry(c.join(dirPath, entry.Name()), entry)
+			results[i] = statResult{info: info, err: err}
+		}(i, entry)
+	}
+	wg.Wait()
+
+	return results
+}
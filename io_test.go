@@ -0,0 +1,67 @@
+package cwalk
+
+import (
+	"os"
+	"sync"
+	"testing"
+)
+
+// TestWalkWithIOConcurrency verifies that concurrent stat calls report the
+// same entries as a serial walk, in directory order per worker, and that
+// every IO slot is released afterwards.
+func TestWalkWithIOConcurrency(t *testing.T) {
+	tmpDir := setupTestDir(t)
+
+	walk := func(ioConcurrency int) map[string]int64 {
+		var mu sync.Mutex
+		seen := map[string]int64{}
+		callbacks := Callbacks{
+			OnLstat: func(isDir bool, relPath string, fileInfo os.FileInfo, err error) {
+				if err != nil {
+					t.Errorf("OnLstat got error for %q: %v", relPath, err)
+					return
+				}
+				mu.Lock()
+				defer mu.Unlock()
+				seen[relPath] = fileInfo.Size()
+			},
+		}
+
+		walker := NewWalker(tmpDir, 2, callbacks)
+		walker.SetIOConcurrency(ioConcurrency)
+		if err := walker.Run(); err != nil {
+			t.Fatalf("Walk failed: %v", err)
+		}
+		if walker.ioSem != nil && len(walker.ioSem) != 0 {
+			t.Errorf("%d IO slots still held after walk", len(walker.ioSem))
+		}
+		return seen
+	}
+
+	want := walk(0)
+	for _, n := range []int{1, 3, 64} {
+		got := walk(n)
+		if len(got) != len(want) {
+			t.Errorf("io-concurrency %d: saw %d entries, want %d", n, len(got), len(want))
+		}
+		for path, size := range want {
+			if got[path] != size {
+				t.Errorf("io-concurrency %d: %s size = %d, want %d", n, path, got[path], size)
+			}
+		}
+	}
+}
+
+// TestSetIOConcurrencyDisable verifies that a non-positive limit restores
+// serial stat calls.
+func TestSetIOConcurrencyDisable(t *testing.T) {
+	walker := NewWalker(t.TempDir(), 1, Callbacks{})
+	walker.SetIOConcurrency(8)
+	if cap(walker.ioSem) != 8 {
+		t.Fatalf("semaphore capacity = %d, want 8", cap(walker.ioSem))
+	}
+	walker.SetIOConcurrency(0)
+	if walker.ioSem != nil {
+		t.Error("expected semaphore to be removed")
+	}
+}
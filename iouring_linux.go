@@ -0,0 +1,233 @@
+//go:build linux
+
+package cwalk
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync/atomic"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// io_uring ABI constants from <linux/io_uring.h>.
+const (
+	ioringOpStatx        = 21
+	ioringEnterGetEvents = 1 << 0
+	ioringOffSQRing      = 0
+	ioringOffCQRing      = 0x8000000
+	ioringOffSQEs        = 0x10000000
+)
+
+// ioSQRingOffsets mirrors struct io_sqring_offsets.
+type ioSQRingOffsets struct {
+	head, tail, ringMask, ringEntries, flags, dropped, array, resv1 uint32
+	userAddr                                                        uint64
+}
+
+// ioCQRingOffsets mirrors struct io_cqring_offsets.
+type ioCQRingOffsets struct {
+	head, tail, ringMask, ringEntries, overflow, cqes, flags, resv1 uint32
+	userAddr                                                        uint64
+}
+
+// ioURingParams mirrors struct io_uring_params.
+type ioURingParams struct {
+	sqEntries, cqEntries, flags, sqThreadCPU, sqThreadIdle, features, wqFd uint32
+	resv                                                                   [3]uint32
+	sqOff                                                                  ioSQRingOffsets
+	cqOff                                                                  ioCQRingOffsets
+}
+
+// ioURingSQE mirrors struct io_uring_sqe, with the fields named as used by
+// IORING_OP_STATX.
+type ioURingSQE struct {
+	opcode      uint8
+	flags       uint8
+	ioprio      uint16
+	fd          int32  // Directory fd the path is relative to
+	off         uint64 // Address of the struct statx buffer
+	addr        uint64 // Address of the NUL-terminated path
+	len         uint32 // STATX_* request mask
+	opFlags     uint32 // AT_* flags
+	userData    uint64
+	bufIndex    uint16
+	personality uint16
+	spliceFdIn  int32
+	addr3       uint64
+	pad         uint64
+}
+
+// ioURingCQE mirrors struct io_uring_cqe.
+type ioURingCQE struct {
+	userData uint64
+	res      int32
+	flags    uint32
+}
+
+// ioURing is a minimal io_uring instance used to batch statx calls.
+// It is owned by a single worker and is not safe for concurrent use.
+type ioURing struct {
+	fd int
+
+	sqRing, cqRing, sqeMem []byte
+
+	sqTail  *uint32
+	sqMask  uint32
+	sqArray []uint32
+	sqes    []ioURingSQE
+
+	cqHead *uint32
+	cqTail *uint32
+	cqMask uint32
+	cqes   []ioURingCQE
+}
+
+// newIOURing sets up an io_uring with the given submission queue size and
+// maps its rings.
+func newIOURing(entries uint32) (*ioURing, error) {
+	var p ioURingParams
+	fd, _, errno := unix.Syscall(unix.SYS_IO_URING_SETUP, uintptr(entries), uintptr(unsafe.Pointer(&p)), 0)
+	if errno != 0 {
+		return nil, os.NewSyscallError("io_uring_setup", errno)
+	}
+
+	r := &ioURing{fd: int(fd)}
+	var err error
+	mmap := func(offset int64, size uint32) []byte {
+		if err != nil {
+			return nil
+		}
+		var b []byte
+		b, err = unix.Mmap(r.fd, offset, int(size), unix.PROT_READ|unix.PROT_WRITE, unix.MAP_SHARED|unix.MAP_POPULATE)
+		return b
+	}
+	r.sqRing = mmap(ioringOffSQRing, p.sqOff.array+p.sqEntries*4)
+	r.cqRing = mmap(ioringOffCQRing, p.cqOff.cqes+p.cqEntries*uint32(unsafe.Sizeof(ioURingCQE{})))
+	r.sqeMem = mmap(ioringOffSQEs, p.sqEntries*uint32(unsafe.Sizeof(ioURingSQE{})))
+	if err != nil {
+		r.close()
+		return nil, os.NewSyscallError("mmap", err)
+	}
+
+	r.sqTail = (*uint32)(unsafe.Pointer(&r.sqRing[p.sqOff.tail]))
+	r.sqMask = *(*uint32)(unsafe.Pointer(&r.sqRing[p.sqOff.ringMask]))
+	r.sqArray = unsafe.Slice((*uint32)(unsafe.Pointer(&r.sqRing[p.sqOff.array])), p.sqEntries)
+	r.sqes = unsafe.Slice((*ioURingSQE)(unsafe.Pointer(&r.sqeMem[0])), p.sqEntries)
+
+	r.cqHead = (*uint32)(unsafe.Pointer(&r.cqRing[p.cqOff.head]))
+	r.cqTail = (*uint32)(unsafe.Pointer(&r.cqRing[p.cqOff.tail]))
+	r.cqMask = *(*uint32)(unsafe.Pointer(&r.cqRing[p.cqOff.ringMask]))
+	r.cqes = unsafe.Slice((*ioURingCQE)(unsafe.Pointer(&r.cqRing[p.cqOff.cqes])), p.cqEntries)
+
+	return r, nil
+}
+
+// statxBatch runs statx for every path through the ring and returns the
+// results in the same order. Paths are submitted in chunks no larger than
+// the submission queue, and each chunk is fully reaped before the next, so
+// the completion queue (twice the size) cannot overflow.
+//
+// Operations the kernel rejects as unsupported (kernels before 5.6 lack
+// IORING_OP_STATX) are retried with a synchronous statx call. If the ring
+// itself fails, statxBatch returns an error and the ring must not be used
+// again.
+func (r *ioURing) statxBatch(paths []string, mask StatxMask) ([]statResult, error) {
+	results := make([]statResult, len(paths))
+	bufs := make([]unix.Statx_t, len(paths))
+	cpaths := make([]*byte, len(paths))
+	req := uint32(statxRequestMask(mask))
+
+	for start := 0; start < len(paths); start += len(r.sqes) {
+		end := start + len(r.sqes)
+		if end > len(paths) {
+			end = len(paths)
+		}
+
+		tail := atomic.LoadUint32(r.sqTail)
+		submitted := 0
+		for i := start; i < end; i++ {
+			p, err := unix.BytePtrFromString(paths[i])
+			if err != nil {
+				results[i].err = &os.PathError{Op: "statx", Path: paths[i], Err: err}
+				continue
+			}
+			cpaths[i] = p
+
+			idx := tail & r.sqMask
+			r.sqes[idx] = ioURingSQE{
+				opcode:   ioringOpStatx,
+				fd:       unix.AT_FDCWD,
+				off:      uint64(uintptr(unsafe.Pointer(&bufs[i]))),
+				addr:     uint64(uintptr(unsafe.Pointer(p))),
+				len:      req,
+				opFlags:  statxFlags,
+				userData: uint64(i),
+			}
+			r.sqArray[idx] = idx
+			tail++
+			submitted++
+		}
+		atomic.StoreUint32(r.sqTail, tail)
+
+		toSubmit := submitted
+		for submitted > 0 {
+			_, _, errno := unix.Syscall6(unix.SYS_IO_URING_ENTER, uintptr(r.fd),
+				uintptr(toSubmit), uintptr(submitted), ioringEnterGetEvents, 0, 0)
+			if errno == unix.EINTR {
+				continue
+			}
+			if errno != 0 {
+				runtime.KeepAlive(cpaths)
+				runtime.KeepAlive(bufs)
+				return nil, os.NewSyscallError("io_uring_enter", errno)
+			}
+			toSubmit = 0
+
+			head := atomic.LoadUint32(r.cqHead)
+			for cqTail := atomic.LoadUint32(r.cqTail); head != cqTail; head++ {
+				cqe := r.cqes[head&r.cqMask]
+				i := int(cqe.userData)
+				results[i] = r.complete(paths[i], &bufs[i], cqe.res, mask)
+				submitted--
+			}
+			atomic.StoreUint32(r.cqHead, head)
+		}
+	}
+
+	runtime.KeepAlive(cpaths)
+	runtime.KeepAlive(bufs)
+	return results, nil
+}
+
+// complete converts a statx completion into a statResult.
+func (r *ioURing) complete(path string, stx *unix.Statx_t, res int32, mask StatxMask) statResult {
+	if res < 0 {
+		errno := syscall.Errno(-res)
+		if errors.Is(errno, unix.EINVAL) || errors.Is(errno, unix.EOPNOTSUPP) {
+			info, err := statx(path, mask)
+			return statResult{info: info, err: err}
+		}
+		return statResult{err: &os.PathError{Op: "statx", Path: path, Err: errno}}
+	}
+	return statResult{info: newStatxFileInfo(filepath.Base(path), stx)}
+}
+
+// close unmaps the rings and closes the io_uring fd. It is safe to call
+// more than once.
+func (r *ioURing) close() {
+	for _, b := range [][]byte{r.sqRing, r.cqRing, r.sqeMem} {
+		if b != nil {
+			unix.Munmap(b)
+		}
+	}
+	r.sqRing, r.cqRing, r.sqeMem = nil, nil, nil
+	if r.fd >= 0 {
+		unix.Close(r.fd)
+		r.fd = -1
+	}
+}
@@ -0,0 +1,19 @@
+//go:build !linux
+
+package cwalk
+
+import "errors"
+
+// ioURing is a placeholder on platforms without io_uring.
+type ioURing struct{}
+
+// newIOURing always fails on platforms without io_uring.
+func newIOURing(entries uint32) (*ioURing, error) {
+	return nil, errors.New("io_uring is only supported on Linux")
+}
+
+func (r *ioURing) statxBatch(paths []string, mask StatxMask) ([]statResult, error) {
+	return nil, errors.New("io_uring is only supported on Linux")
+}
+
+func (r *ioURing) close() {}
@@ -0,0 +1,100 @@
+package cwalk
+
+import (
+	"iter"
+	"os"
+	"strings"
+	"sync/atomic"
+)
+
+// Entries returns an iterator over the entries of the walk, as an
+// alternative to callbacks: ranging over it runs the walk and yields each
+// entry passed to OnEntry, with Entry.Err as the error. A directory that
+// cannot be read is yielded once more with the read error. The workers
+// wait while the loop body is busy, so entries do not pile up in memory,
+// and breaking out of the loop stops the walk.
+//
+// Callbacks set on the walker are still called. As with Run, the walker
+// walks once; call Reset to range over the entries again.
+func (c *Walker) Entries() iter.Seq2[Entry, error] {
+	return func(yield func(Entry, error) bool) {
+		results := make(chan Entry, c.streamBuffer)
+		done := make(chan struct{})
+		var runErr error
+		go func() {
+			runErr = c.walkEntries(func(e Entry) bool {
+				select {
+				case results <- e:
+					return true
+				case <-done:
+					return false
+				}
+			})
+			close(results)
+		}()
+
+		stopped := false
+		for e := range results {
+			if !yield(e, e.Err) {
+				stopped = true
+				close(done)
+				break
+			}
+		}
+		for range results {
+			// Wait for the workers to wind down
+		}
+		if runErr != nil && !stopped {
+			yield(Entry{}, runErr)
+		}
+	}
+}
+
+// walkEntries runs the walk and passes each entry reported to OnEntry, and
+// each directory that cannot be read with the read error in Err, to send
+// on the worker goroutines. Once send returns false, the walk is stopped
+// and send is not called again. The callbacks set on the walker are called
+// as well.
+func (c *Walker) walkEntries(send func(Entry) bool) error {
+	var stopped atomic.Bool
+	deliver := func(e Entry) {
+		if !stopped.Load() && !send(e) {
+			stopped.Store(true)
+			c.Stop()
+		}
+	}
+	readFailed := func(relPath string, err error) {
+		e := Entry{RelPath: relPath, AbsPath: c.rootPath, Err: err}
+		if relPath != "" {
+			e.AbsPath = c.join(c.rootPath, relPath)
+			e.Depth = strings.Count(relPath, "/") + 1
+		}
+		deliver(e)
+	}
+
+	callbacks := c.callbacks
+	defer func() { c.callbacks = callbacks }()
+	c.callbacks.OnEntry = func(e *Entry) {
+		if callbacks.OnEntry != nil {
+			callbacks.OnEntry(e)
+		}
+		deliver(*e)
+	}
+	c.callbacks.OnReadDir = func(relPath string, entries []os.DirEntry, err error) {
+		if callbacks.OnReadDir != nil {
+			callbacks.OnReadDir(relPath, entries, err)
+		}
+		if err != nil {
+			readFailed(relPath, err)
+		}
+	}
+	c.callbacks.OnReadDirBatch = func(relPath string, entries []os.DirEntry, err error) {
+		if callbacks.OnReadDirBatch != nil {
+			callbacks.OnReadDirBatch(relPath, entries, err)
+		}
+		if err != nil {
+			readFailed(relPath, err)
+		}
+	}
+	return c.Run()
+}
@@ -0,0 +1,76 @@
+package cwalk
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"testing"
+)
+
+func TestEntries(t *testing.T) {
+	tmpDir := t.TempDir()
+	for i := 0; i < 10; i++ {
+		dir := filepath.Join(tmpDir, strconv.Itoa(i))
+		if err := os.Mkdir(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		for j := 0; j < 50; j++ {
+			if err := os.WriteFile(filepath.Join(dir, strconv.Itoa(j)), nil, 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	var lstats atomic.Int64
+	walker := NewWalker(tmpDir, 4, Callbacks{
+		OnLstat: func(isDir bool, relPath string, info os.FileInfo, err error) { lstats.Add(1) },
+	})
+	seen := map[string]bool{}
+	for e, err := range walker.Entries() {
+		if err != nil {
+			t.Fatalf("%s: %v", e.RelPath, err)
+		}
+		if seen[e.RelPath] {
+			t.Errorf("%q yielded twice", e.RelPath)
+		}
+		seen[e.RelPath] = true
+		if e.AbsPath != filepath.Join(tmpDir, e.RelPath) {
+			t.Errorf("%q: AbsPath = %q", e.RelPath, e.AbsPath)
+		}
+	}
+	if len(seen) != 511 || lstats.Load() != 511 {
+		t.Errorf("yielded %d entries with %d OnLstat calls, want 511", len(seen), lstats.Load())
+	}
+
+	// Breaking out of the loop stops the walk
+	walker.Reset()
+	n := 0
+	for range walker.Entries() {
+		n++
+		if n == 20 {
+			break
+		}
+	}
+	if n != 20 {
+		t.Errorf("yielded %d entries before the break, want 20", n)
+	}
+	if stats := walker.Stats(); stats.Dirs == 11 {
+		t.Error("all directories read after the break")
+	}
+}
+
+func TestEntriesError(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing")
+	walker := NewWalker(missing, 1, Callbacks{})
+	n := 0
+	for e, err := range walker.Entries() {
+		n++
+		if err == nil || e.AbsPath != missing || e.Err != err {
+			t.Errorf("got %+v, %v; want the lstat error of the root", e, err)
+		}
+	}
+	if n != 1 {
+		t.Errorf("yielded %d times, want 1", n)
+	}
+}
@@ -0,0 +1,71 @@
+package cwalk
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// SetSlogLogger makes the walker log through logger: paths that could not
+// be read at level Error, with "path" and "err" attributes, fallbacks of
+// the metadata backend at Warn, and every directory read at Debug. It
+// replaces a logger set with SetLogger. Without either, slog.Default() is
+// used at the time of logging, so programs embedding cwalk control the
+// verbosity and format with slog.SetDefault.
+func (c *Walker) SetSlogLogger(logger *slog.Logger) {
+	c.logger = logger
+}
+
+// log returns the logger of the walker.
+func (c *Walker) log() *slog.Logger {
+	if c.logger != nil {
+		return c.logger
+	}
+	return slog.Default()
+}
+
+// printfHandler is a slog.Handler writing records at level Info and above
+// to a Logger, as "LEVEL message key=value ...".
+type printfHandler struct {
+	logger Logger
+	attrs  []slog.Attr
+	group  string // Prefix of the keys of later attributes
+}
+
+// Enabled implements slog.Handler.
+func (h *printfHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= slog.LevelInfo
+}
+
+// Handle implements slog.Handler.
+func (h *printfHandler) Handle(_ context.Context, r slog.Record) error {
+	var b strings.Builder
+	b.WriteString(r.Level.String() + " " + r.Message)
+	for _, attr := range h.attrs {
+		fmt.Fprintf(&b, " %s=%v", attr.Key, attr.Value)
+	}
+	r.Attrs(func(attr slog.Attr) bool {
+		fmt.Fprintf(&b, " %s%s=%v", h.group, attr.Key, attr.Value)
+		return true
+	})
+	h.logger.Printf("%s", b.String())
+	return nil
+}
+
+// WithAttrs implements slog.Handler.
+func (h *printfHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	out := *h
+	out.attrs = append([]slog.Attr(nil), h.attrs...)
+	for _, attr := range attrs {
+		out.attrs = append(out.attrs, slog.Attr{Key: h.group + attr.Key, Value: attr.Value})
+	}
+	return &out
+}
+
+// WithGroup implements slog.Handler.
+func (h *printfHandler) WithGroup(name string) slog.Handler {
+	out := *h
+	out.group = h.group + name + "."
+	return &out
+}
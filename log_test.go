@@ -0,0 +1,68 @@
+package cwalk
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSetSlogLogger(t *testing.T) {
+	tmpDir := setupTestDir(t)
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	walker := NewWalker(tmpDir, 2, Callbacks{})
+	walker.SetSlogLogger(logger)
+	if err := walker.Run(); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if n := strings.Count(buf.String(), `"msg":"read directory"`); n != 4 {
+		t.Errorf("got %d directory reads logged at debug level, want 4:\n%s", n, buf.String())
+	}
+
+	buf.Reset()
+	missing := filepath.Join(tmpDir, "missing")
+	walker = NewWalker(missing, 1, Callbacks{})
+	walker.SetSlogLogger(slog.New(slog.NewJSONHandler(&buf, nil)))
+	if err := walker.Run(); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	var record map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("log is not a JSON record: %v\n%s", err, buf.String())
+	}
+	if record["level"] != "ERROR" || record["path"] != missing || record["err"] == nil {
+		t.Errorf("record = %v, want an error with the path", record)
+	}
+}
+
+// formattingLogger records formatted log messages.
+type formattingLogger struct {
+	messages []string
+}
+
+func (l *formattingLogger) Printf(format string, v ...interface{}) {
+	l.messages = append(l.messages, fmt.Sprintf(format, v...))
+}
+
+func TestPrintfHandler(t *testing.T) {
+	printf := &formattingLogger{}
+	logger := slog.New(&printfHandler{logger: printf})
+
+	logger.Debug("hidden")
+	logger.With("root", "/data").WithGroup("walk").Error("processing failed", "path", "a", "err", "denied")
+	logger.Warn("io_uring unavailable, falling back to statx")
+
+	want := []string{
+		"ERROR processing failed root=/data walk.path=a walk.err=denied",
+		"WARN io_uring unavailable, falling back to statx",
+	}
+	if !slices.Equal(printf.messages, want) {
+		t.Errorf("messages = %q, want %q", printf.messages, want)
+	}
+}
@@ -0,0 +1,103 @@
+package cwalk
+
+import (
+	"container/heap"
+	"fmt"
+)
+
+// Order selects the order in which each worker reads the directories it
+// has queued. Idle workers steal from the same queues, so the order
+// applies to the walk as a whole only approximately.
+type Order int
+
+const (
+	// OrderDepthFirst reads the most recently found directory first
+	// (LIFO). It keeps queues short, since a worker finishes a subtree
+	// before moving on. This is the default.
+	OrderDepthFirst Order = iota
+	// OrderBreadthFirst reads directories in the order they were found
+	// (FIFO), level by level. On shallow, wide trees it spreads work over
+	// all workers sooner, at the cost of longer queues.
+	OrderBreadthFirst
+	// OrderLargestFirst reads the queued directory with the largest size
+	// first. On most file systems the size of a directory grows with its
+	// entry count, so the bulk of the walk is done early, which makes
+	// progress easier to estimate.
+	OrderLargestFirst
+)
+
+// String returns the order name as accepted by ParseOrder.
+func (o Order) String() string {
+	switch o {
+	case OrderDepthFirst:
+		return "dfs"
+	case OrderBreadthFirst:
+		return "bfs"
+	case OrderLargestFirst:
+		return "largest"
+	default:
+		return fmt.Sprintf("Order(%d)", int(o))
+	}
+}
+
+// ParseOrder parses a traversal order name: dfs, bfs or largest.
+func ParseOrder(s string) (Order, error) {
+	for _, o := range []Order{OrderDepthFirst, OrderBreadthFirst, OrderLargestFirst} {
+		if s == o.String() {
+			return o, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown traversal order: %s", s)
+}
+
+// SetOrder selects the order in which queued directories are read. The
+// callbacks see the same entries in any order.
+func (c *Walker) SetOrder(o Order) {
+	c.order = o
+}
+
+// branchHeap orders a worker queue by directory size, largest first, for
+// OrderLargestFirst.
+type branchHeap []*walkBranch
+
+func (h branchHeap) Len() int           { return len(h) }
+func (h branchHeap) Less(i, j int) bool { return h[i].size() > h[j].size() }
+func (h branchHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *branchHeap) Push(x any)        { *h = append(*h, x.(*walkBranch)) }
+
+func (h *branchHeap) Pop() any {
+	old := *h
+	item := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+	return item
+}
+
+// size returns the size of the directory as reported by lstat, or 0 if it
+// is unknown (the root and resumed directories).
+func (cb *walkBranch) size() int64 {
+	if cb.info == nil {
+		return 0
+	}
+	return cb.info.Size()
+}
+
+// queueHead removes the oldest item from the queue. The caller holds mu.
+func (cw *walkWorker) queueHead() *walkBranch {
+	if len(cw.queue) == 0 {
+		return nil
+	}
+	item := cw.queue[0]
+	cw.queue[0] = nil
+	cw.queue = cw.queue[1:]
+	return item
+}
+
+// queueLargest removes the largest item from the queue. The caller holds
+// mu.
+func (cw *walkWorker) queueLargest() *walkBranch {
+	if len(cw.queue) == 0 {
+		return nil
+	}
+	return heap.Pop((*branchHeap)(&cw.queue)).(*walkBranch)
+}
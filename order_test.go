@@ -0,0 +1,52 @@
+package cwalk
+
+import (
+	"io/fs"
+	"os"
+	"slices"
+	"testing"
+	"testing/fstest"
+)
+
+func TestOrder(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a":   {Mode: fs.ModeDir | 0755, Data: make([]byte, 100)},
+		"a/x": {Mode: fs.ModeDir | 0755, Data: make([]byte, 50)},
+		"b":   {Mode: fs.ModeDir | 0755},
+	}
+	tests := []struct {
+		order Order
+		want  []string
+	}{
+		{OrderDepthFirst, []string{"", "b", "a", "a/x"}},
+		{OrderBreadthFirst, []string{"", "a", "b", "a/x"}},
+		{OrderLargestFirst, []string{"", "a", "a/x", "b"}},
+	}
+
+	for _, tt := range tests {
+		var got []string
+		walker := NewWalkerFS(fsys, ".", 1, Callbacks{
+			OnReadDir: func(relPath string, entries []os.DirEntry, err error) {
+				got = append(got, relPath)
+			},
+		})
+		walker.SetOrder(tt.order)
+		if err := walker.Run(); err != nil {
+			t.Fatalf("%s: Run failed: %v", tt.order, err)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: read %q, want %q", tt.order, got, tt.want)
+		}
+	}
+}
+
+func TestParseOrder(t *testing.T) {
+	for _, o := range []Order{OrderDepthFirst, OrderBreadthFirst, OrderLargestFirst} {
+		if got, err := ParseOrder(o.String()); err != nil || got != o {
+			t.Errorf("ParseOrder(%q) = %v, %v", o.String(), got, err)
+		}
+	}
+	if _, err := ParseOrder("random"); err == nil {
+		t.Error("ParseOrder should reject unknown orders")
+	}
+}
@@ -0,0 +1,47 @@
+package cwalk
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// PanicError is a panic recovered from a callback while a worker processed
+// a directory. The rest of that directory is skipped; other directories and
+// workers carry on.
+type PanicError struct {
+	RelPath string // Directory being processed
+	Value   any    // Value passed to panic
+	Stack   []byte // Stack of the panicking goroutine
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic processing '%s': %v", e.RelPath, e.Value)
+}
+
+// Panics returns the panics recovered from callbacks during the walk, in
+// the order they occurred.
+func (c *Walker) Panics() []*PanicError {
+	c.panicMu.Lock()
+	defer c.panicMu.Unlock()
+	return append([]*PanicError(nil), c.panics...)
+}
+
+// guard calls fn for the branch, recovering a panic in it as a PanicError,
+// which is recorded and passed to OnPanic.
+func (c *Walker) guard(branch *walkBranch, fn func() error) (err error) {
+	defer func() {
+		v := recover()
+		if v == nil {
+			return
+		}
+		perr := &PanicError{RelPath: branch.relPath(), Value: v, Stack: debug.Stack()}
+		c.panicMu.Lock()
+		c.panics = append(c.panics, perr)
+		c.panicMu.Unlock()
+		if c.callbacks.OnPanic != nil {
+			c.callbacks.OnPanic(perr)
+		}
+		err = perr
+	}()
+	return fn()
+}
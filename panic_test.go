@@ -0,0 +1,80 @@
+package cwalk
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestPanicRecovery(t *testing.T) {
+	tmpDir := t.TempDir()
+	for i := 0; i < 10; i++ {
+		if err := os.MkdirAll(filepath.Join(tmpDir, strconv.Itoa(i), "sub"), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var mu sync.Mutex
+	var recovered []*PanicError
+	var reads atomic.Int64
+	rootDone := false
+	walker := NewWalker(tmpDir, 4, Callbacks{
+		OnReadDir: func(relPath string, entries []os.DirEntry, err error) {
+			if relPath == "3" {
+				panic("boom")
+			}
+			reads.Add(1)
+		},
+		OnDirectoryDone: func(relPath string, totals DirTotals) {
+			switch relPath {
+			case "5":
+				panic(errors.New("done boom"))
+			case "":
+				rootDone = true
+			}
+		},
+		OnPanic: func(err *PanicError) {
+			mu.Lock()
+			recovered = append(recovered, err)
+			mu.Unlock()
+		},
+	})
+	if err := walker.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Everything but 3 and its unqueued subdirectory is read
+	if n := reads.Load(); n != 1+9*2 {
+		t.Errorf("read %d directories, want 19", n)
+	}
+	if !rootDone {
+		t.Error("root not done after a panic in OnDirectoryDone")
+	}
+	if len(recovered) != 2 {
+		t.Fatalf("OnPanic called %d times, want 2", len(recovered))
+	}
+	panics := walker.Panics()
+	if len(panics) != 2 {
+		t.Fatalf("Panics() = %d, want 2", len(panics))
+	}
+	for _, p := range panics {
+		if len(p.Stack) == 0 {
+			t.Errorf("%s: no stack", p.RelPath)
+		}
+		if p.RelPath == "3" && p.Value != "boom" {
+			t.Errorf("%s: value %v", p.RelPath, p.Value)
+		}
+	}
+	if stats := walker.Stats(); stats.Errors != 2 {
+		t.Errorf("Stats.Errors = %d, want 2", stats.Errors)
+	}
+
+	walker.Reset()
+	if len(walker.Panics()) != 0 {
+		t.Error("Reset kept the panics")
+	}
+}
@@ -0,0 +1,466 @@
+// Package copier replicates directory trees using the cwalk parallel walker.
+//
+// Directories are created as the walk reaches them, while regular files are
+// handed to per-worker copy queues so that walking and copying overlap.
+// Symlinks are recreated, and permissions, modification times, owners (when
+// running as root) and optionally extended attributes are carried over.
+// Directory metadata is applied last, deepest first, so copying into a
+// read-only directory and bumping its mtime cannot get in the way.
+//
+// Files whose size and modification time already match the destination are
+// skipped, and a state file can record completed files so that an
+// interrupted copy resumes where it stopped.
+package copier
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	cwalk "github.com/otuschhoff/cwalk"
+)
+
+// queueSize is the number of files each copy queue buffers before the walk
+// waits for its copy worker.
+const queueSize = 256
+
+// Report describes a finished copy.
+type Report struct {
+	Files    int64         // Regular files copied
+	Dirs     int64         // Directories created or updated
+	Symlinks int64         // Symlinks created
+	Skipped  int64         // Files and symlinks already up to date, or completed according to the state file
+	Other    int64         // Devices, sockets and pipes, which are not copied
+	Bytes    int64         // Bytes of file data copied
+	Errors   int64         // Entries that could not be read or copied
+	Duration time.Duration // Wall time of the copy
+}
+
+// Throughput returns the bytes copied per second.
+func (r *Report) Throughput() float64 {
+	if r.Duration <= 0 {
+		return 0
+	}
+	return float64(r.Bytes) / r.Duration.Seconds()
+}
+
+// FileRate returns the files copied per second.
+func (r *Report) FileRate() float64 {
+	if r.Duration <= 0 {
+		return 0
+	}
+	return float64(r.Files) / r.Duration.Seconds()
+}
+
+// Copier copies the tree below a source directory into a destination
+// directory.
+type Copier struct {
+	src, dst   string
+	workers    int
+	maxWorkers int
+	xattrs     bool
+	statePath  string
+	errs       io.Writer
+	chown      bool
+
+	done  map[string]bool // Files completed according to the state file
+	state *stateLog
+
+	errMu sync.Mutex
+	dirMu sync.Mutex
+	dirs  []dirMeta
+
+	queues []chan copyJob
+	next   atomic.Uint64
+
+	files, dirCount, symlinks, skipped, other, bytes, errors atomic.Int64
+}
+
+// dirMeta is the metadata of a source directory, applied to its copy once
+// all of its entries are in place.
+type dirMeta struct {
+	relPath string
+	info    os.FileInfo
+}
+
+// copyJob is a regular file waiting in a copy queue.
+type copyJob struct {
+	relPath string
+	info    os.FileInfo
+}
+
+// New creates a Copier from src to dst using the given number of walk and
+// copy workers. dst is created if it does not exist.
+func New(src, dst string, workers int) *Copier {
+	if workers <= 0 {
+		workers = 1
+	}
+	return &Copier{
+		src:     filepath.Clean(src),
+		dst:     filepath.Clean(dst),
+		workers: workers,
+		errs:    os.Stderr,
+		chown:   os.Geteuid() == 0,
+	}
+}
+
+// SetAutoWorkers lets the walk tune its worker count up to maxWorkers, see
+// cwalk.Walker.SetAutoWorkers. The number of copy workers stays fixed.
+func (c *Copier) SetAutoWorkers(maxWorkers int) {
+	c.maxWorkers = maxWorkers
+}
+
+// SetXattrs enables copying extended attributes. Only supported on Linux;
+// elsewhere it has no effect.
+func (c *Copier) SetXattrs(enabled bool) {
+	c.xattrs = enabled
+}
+
+// SetStateFile sets a file recording completed files. Files listed in it
+// are skipped, and every file copied or found up to date is appended, so
+// an interrupted copy can be resumed by running it again with the same
+// state file.
+func (c *Copier) SetStateFile(path string) {
+	c.statePath = path
+}
+
+// SetErrorOutput sets where errors are reported; os.Stderr by default.
+func (c *Copier) SetErrorOutput(w io.Writer) {
+	c.errs = w
+}
+
+// Run copies the tree and reports what was copied. Errors on individual
+// entries are reported on the error output and counted in the report;
+// only problems with the source, the destination or the state file are
+// returned.
+func (c *Copier) Run() (*Report, error) {
+	start := time.Now()
+
+	info, err := os.Stat(c.src)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("not a directory: %s", c.src)
+	}
+	if within(c.dst, c.src) {
+		return nil, fmt.Errorf("destination %s is inside the source %s", c.dst, c.src)
+	}
+	if err := os.MkdirAll(c.dst, 0o700); err != nil {
+		return nil, err
+	}
+
+	if c.statePath != "" {
+		c.done, err = loadState(c.statePath)
+		if err != nil {
+			return nil, err
+		}
+		c.state, err = openState(c.statePath)
+		if err != nil {
+			return nil, err
+		}
+		defer c.state.Close()
+	}
+
+	var wg sync.WaitGroup
+	c.queues = make([]chan copyJob, c.workers)
+	for i := range c.queues {
+		c.queues[i] = make(chan copyJob, queueSize)
+		wg.Add(1)
+		go func(queue chan copyJob) {
+			defer wg.Done()
+			for job := range queue {
+				c.copyFile(job.relPath, job.info)
+			}
+		}(c.queues[i])
+	}
+
+	walker := cwalk.NewWalker(c.src, c.workers, cwalk.Callbacks{
+		OnLstat: c.onLstat,
+		OnReadDir: func(relPath string, entries []os.DirEntry, err error) {
+			if err != nil {
+				c.fail(err)
+			}
+		},
+	})
+	walker.SetLogger(discardLogger{})
+	walker.SetAutoWorkers(c.maxWorkers)
+	walkErr := walker.Run()
+
+	for _, queue := range c.queues {
+		close(queue)
+	}
+	wg.Wait()
+	c.applyDirs()
+
+	if walkErr != nil {
+		return nil, walkErr
+	}
+	if c.state != nil {
+		if err := c.state.Close(); err != nil {
+			return nil, err
+		}
+	}
+
+	return &Report{
+		Files:    c.files.Load(),
+		Dirs:     c.dirCount.Load(),
+		Symlinks: c.symlinks.Load(),
+		Skipped:  c.skipped.Load(),
+		Other:    c.other.Load(),
+		Bytes:    c.bytes.Load(),
+		Errors:   c.errors.Load(),
+		Duration: time.Since(start),
+	}, nil
+}
+
+// onLstat handles an entry found by the walk: directories are created right
+// away so their entries can be copied into them, files are queued and
+// symlinks recreated.
+func (c *Copier) onLstat(isDir bool, relPath string, info os.FileInfo, err error) {
+	if err != nil {
+		c.fail(err)
+		return
+	}
+	dstPath := c.dstPath(relPath)
+
+	switch mode := info.Mode(); {
+	case mode.IsDir():
+		if err := mkdir(dstPath); err != nil {
+			c.fail(err)
+			return
+		}
+		c.dirMu.Lock()
+		c.dirs = append(c.dirs, dirMeta{relPath: relPath, info: info})
+		c.dirMu.Unlock()
+	case mode.IsRegular():
+		if c.done[relPath] {
+			c.skipped.Add(1)
+			return
+		}
+		// Spread files over the queues; the walk only blocks when the
+		// chosen queue is full
+		i := c.next.Add(1) % uint64(len(c.queues))
+		c.queues[i] <- copyJob{relPath: relPath, info: info}
+	case mode&os.ModeSymlink != 0:
+		c.copySymlink(relPath, info)
+	default:
+		c.other.Add(1)
+	}
+}
+
+// copyFile copies a regular file to a temporary file next to its
+// destination and renames it into place once data and metadata are
+// written, so the destination never holds a partial copy.
+func (c *Copier) copyFile(relPath string, info os.FileInfo) {
+	srcPath, dstPath := c.srcPath(relPath), c.dstPath(relPath)
+
+	if upToDate(dstPath, info) {
+		c.skipped.Add(1)
+		c.record(relPath)
+		return
+	}
+
+	tmpPath := filepath.Join(filepath.Dir(dstPath), "."+filepath.Base(dstPath)+".cwalk-tmp")
+	n, err := c.writeFile(srcPath, tmpPath, info)
+	if err == nil {
+		err = os.Rename(tmpPath, dstPath)
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		c.fail(err)
+		return
+	}
+
+	c.files.Add(1)
+	c.bytes.Add(n)
+	c.record(relPath)
+}
+
+// writeFile copies the data of srcPath to a new file at tmpPath and applies
+// the metadata of info. Returns the number of bytes copied.
+func (c *Copier) writeFile(srcPath, tmpPath string, info os.FileInfo) (int64, error) {
+	in, err := os.Open(srcPath)
+	if err != nil {
+		return 0, err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return 0, err
+	}
+	n, err := io.Copy(out, in)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return n, err
+	}
+	return n, c.applyMeta(srcPath, tmpPath, info)
+}
+
+// copySymlink recreates a symlink, replacing whatever is at the destination
+// unless it is a symlink with the same target already.
+func (c *Copier) copySymlink(relPath string, info os.FileInfo) {
+	srcPath, dstPath := c.srcPath(relPath), c.dstPath(relPath)
+
+	target, err := os.Readlink(srcPath)
+	if err != nil {
+		c.fail(err)
+		return
+	}
+	if existing, err := os.Readlink(dstPath); err == nil && existing == target {
+		c.skipped.Add(1)
+		return
+	}
+
+	if err := os.Remove(dstPath); err != nil && !os.IsNotExist(err) {
+		c.fail(err)
+		return
+	}
+	if err := os.Symlink(target, dstPath); err != nil {
+		c.fail(err)
+		return
+	}
+	if c.chown {
+		if uid, gid, ok := owner(info); ok {
+			if err := os.Lchown(dstPath, uid, gid); err != nil {
+				c.fail(err)
+			}
+		}
+	}
+	c.symlinks.Add(1)
+}
+
+// applyDirs applies the metadata of the copied directories, deepest first,
+// so setting a directory's mode and mtime comes after everything below it
+// has been written.
+func (c *Copier) applyDirs() {
+	sort.Slice(c.dirs, func(i, j int) bool {
+		di, dj := depth(c.dirs[i].relPath), depth(c.dirs[j].relPath)
+		if di != dj {
+			return di > dj
+		}
+		return c.dirs[i].relPath < c.dirs[j].relPath
+	})
+	for _, dir := range c.dirs {
+		if err := c.applyMeta(c.srcPath(dir.relPath), c.dstPath(dir.relPath), dir.info); err != nil {
+			c.fail(err)
+			continue
+		}
+		c.dirCount.Add(1)
+	}
+}
+
+// applyMeta gives the file or directory at dstPath the owner, mode,
+// extended attributes and modification time of info, which describes
+// srcPath. The owner comes first since changing it clears set-ID bits.
+func (c *Copier) applyMeta(srcPath, dstPath string, info os.FileInfo) error {
+	if c.chown {
+		if uid, gid, ok := owner(info); ok {
+			if err := os.Lchown(dstPath, uid, gid); err != nil {
+				return err
+			}
+		}
+	}
+	mode := info.Mode() & (os.ModePerm | os.ModeSetuid | os.ModeSetgid | os.ModeSticky)
+	if err := os.Chmod(dstPath, mode); err != nil {
+		return err
+	}
+	if c.xattrs {
+		if err := copyXattrs(srcPath, dstPath); err != nil {
+			return err
+		}
+	}
+	return os.Chtimes(dstPath, info.ModTime(), info.ModTime())
+}
+
+// record appends a completed file to the state file, if any.
+func (c *Copier) record(relPath string) {
+	if c.state == nil {
+		return
+	}
+	if err := c.state.add(relPath); err != nil {
+		c.fail(err)
+	}
+}
+
+// fail counts and reports an error on an entry.
+func (c *Copier) fail(err error) {
+	c.errors.Add(1)
+	c.errMu.Lock()
+	defer c.errMu.Unlock()
+	fmt.Fprintf(c.errs, "%v\n", err)
+}
+
+// srcPath returns the source path of an entry.
+func (c *Copier) srcPath(relPath string) string {
+	return filepath.Join(c.src, filepath.FromSlash(relPath))
+}
+
+// dstPath returns the destination path of an entry.
+func (c *Copier) dstPath(relPath string) string {
+	return filepath.Join(c.dst, filepath.FromSlash(relPath))
+}
+
+// mkdir creates the directory at path, or makes an existing one writable,
+// such as one made read-only by an earlier copy. The mode is restricted
+// until applyDirs sets the final one.
+func mkdir(path string) error {
+	err := os.Mkdir(path, 0o700)
+	if !os.IsExist(err) {
+		return err
+	}
+	info, err := os.Lstat(path)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("cannot create directory %s: exists and is not a directory", path)
+	}
+	return os.Chmod(path, info.Mode().Perm()|0o700)
+}
+
+// depth returns the depth of an entry below the source; the source itself
+// has depth 0.
+func depth(relPath string) int {
+	if relPath == "" {
+		return 0
+	}
+	return strings.Count(relPath, "/") + 1
+}
+
+// upToDate reports whether the destination is a regular file with the
+// size and modification time of the source, as rsync's quick check does.
+func upToDate(dstPath string, info os.FileInfo) bool {
+	dst, err := os.Lstat(dstPath)
+	if err != nil || !dst.Mode().IsRegular() {
+		return false
+	}
+	return dst.Size() == info.Size() && dst.ModTime().Equal(info.ModTime())
+}
+
+// within reports whether path is dir or below it.
+func within(path, dir string) bool {
+	absPath, err1 := filepath.Abs(path)
+	absDir, err2 := filepath.Abs(dir)
+	if err1 != nil || err2 != nil {
+		return false
+	}
+	rel, err := filepath.Rel(absDir, absPath)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// discardLogger drops the walker's log messages; the copier reports the
+// same errors through its callbacks.
+type discardLogger struct{}
+
+// Printf implements cwalk.Logger.
+func (discardLogger) Printf(format string, v ...interface{}) {}
@@ -0,0 +1,148 @@
+package copier
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// makeTree creates a small source tree below root.
+func makeTree(t *testing.T, root string) {
+	t.Helper()
+	for _, dir := range []string{"a/b", "empty", "ro"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	files := map[string]string{"top.txt": "top", "a/one.txt": "one", "a/b/two.txt": "two!", "ro/three.txt": "three"}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(data), 0o640); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink("a/one.txt", filepath.Join(root, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	old := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	for _, name := range []string{"top.txt", "a/b", "ro/three.txt"} {
+		if err := os.Chtimes(filepath.Join(root, name), old, old); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Chmod(filepath.Join(root, "ro"), 0o555); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chmod(filepath.Join(root, "ro"), 0o755) })
+}
+
+func TestCopy(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks need privileges")
+	}
+	src := t.TempDir()
+	dst := filepath.Join(t.TempDir(), "copy")
+	makeTree(t, src)
+	t.Cleanup(func() { os.Chmod(filepath.Join(dst, "ro"), 0o755) })
+
+	var errs bytes.Buffer
+	c := New(src, dst, 3)
+	c.SetErrorOutput(&errs)
+	report, err := c.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Files != 4 || report.Dirs != 5 || report.Symlinks != 1 || report.Bytes != 15 || report.Errors != 0 {
+		t.Errorf("report = %+v, errors: %s", report, errs.String())
+	}
+
+	data, err := os.ReadFile(filepath.Join(dst, "a/b/two.txt"))
+	if err != nil || string(data) != "two!" {
+		t.Errorf("two.txt = %q, %v", data, err)
+	}
+	if target, err := os.Readlink(filepath.Join(dst, "link")); err != nil || target != "a/one.txt" {
+		t.Errorf("link target = %q, %v", target, err)
+	}
+
+	for name, want := range map[string]os.FileMode{"top.txt": 0o640, "ro": 0o555, "a": 0o755} {
+		info, err := os.Lstat(filepath.Join(dst, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Mode().Perm() != want {
+			t.Errorf("%s mode = %v, want %v", name, info.Mode().Perm(), want)
+		}
+	}
+	for _, name := range []string{"top.txt", "a/b", "ro/three.txt"} {
+		src, err1 := os.Lstat(filepath.Join(src, name))
+		dst, err2 := os.Lstat(filepath.Join(dst, name))
+		if err1 != nil || err2 != nil {
+			t.Fatal(err1, err2)
+		}
+		if !dst.ModTime().Equal(src.ModTime()) {
+			t.Errorf("%s mtime = %v, want %v", name, dst.ModTime(), src.ModTime())
+		}
+	}
+
+	// A second copy finds everything up to date, even below the read-only
+	// directory
+	report, err = New(src, dst, 2).Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Files != 0 || report.Skipped != 5 || report.Errors != 0 {
+		t.Errorf("second report = %+v", report)
+	}
+}
+
+func TestCopyResume(t *testing.T) {
+	src := t.TempDir()
+	dst := filepath.Join(t.TempDir(), "copy")
+	for _, name := range []string{"a", "b", "c"} {
+		if err := os.WriteFile(filepath.Join(src, name), []byte(name), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	state := filepath.Join(t.TempDir(), "state")
+	if err := os.WriteFile(state, []byte(`"a"`+"\n"+`"b`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := New(src, dst, 2)
+	c.SetStateFile(state)
+	report, err := c.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a is done according to the state file, the incomplete line for b is
+	// ignored
+	if report.Files != 2 || report.Skipped != 1 {
+		t.Errorf("report = %+v", report)
+	}
+	if _, err := os.Lstat(filepath.Join(dst, "a")); !os.IsNotExist(err) {
+		t.Errorf("a was copied despite the state file: %v", err)
+	}
+
+	done, err := loadState(state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"a", "b", "c"} {
+		if !done[name] {
+			t.Errorf("state file does not list %s", name)
+		}
+	}
+}
+
+func TestCopyRejectsNestedDestination(t *testing.T) {
+	src := t.TempDir()
+	if _, err := New(src, filepath.Join(src, "sub", "copy"), 1).Run(); err == nil {
+		t.Error("copying into the source should fail")
+	}
+	if !within(src, src) || within(src+"-other", src) {
+		t.Error("within is wrong")
+	}
+}
@@ -0,0 +1,17 @@
+//go:build !windows
+
+package copier
+
+import (
+	"os"
+	"syscall"
+)
+
+// owner returns the owner of the entry described by info.
+func owner(info os.FileInfo) (uid, gid int, ok bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, 0, false
+	}
+	return int(stat.Uid), int(stat.Gid), true
+}
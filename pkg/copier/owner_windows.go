@@ -0,0 +1,10 @@
+//go:build windows
+
+package copier
+
+import "os"
+
+// owner is not supported on Windows, where ownership is not carried over.
+func owner(info os.FileInfo) (uid, gid int, ok bool) {
+	return 0, 0, false
+}
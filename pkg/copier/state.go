@@ -0,0 +1,97 @@
+package copier
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+)
+
+// stateLog appends completed files to a state file, one quoted relative
+// path per line. Lines are buffered, so a killed copy loses the last few
+// and copies those files again when resumed.
+type stateLog struct {
+	mu sync.Mutex
+	f  *os.File
+	w  *bufio.Writer
+}
+
+// loadState reads the completed files of a state file. A missing state
+// file means nothing was completed yet. An incomplete last line, left by
+// an interrupted copy, is ignored.
+func loadState(path string) (map[string]bool, error) {
+	done := make(map[string]bool)
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return done, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		relPath, err := strconv.Unquote(scanner.Text())
+		if err != nil {
+			continue
+		}
+		done[relPath] = true
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("cannot read state file %s: %w", path, err)
+	}
+	return done, nil
+}
+
+// openState opens a state file for appending. An incomplete last line is
+// terminated first, so the next entry starts on a line of its own.
+func openState(path string) (*stateLog, error) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	s := &stateLog{f: f, w: bufio.NewWriter(f)}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if size := info.Size(); size > 0 {
+		last := make([]byte, 1)
+		if _, err := f.ReadAt(last, size-1); err != nil {
+			f.Close()
+			return nil, err
+		}
+		if last[0] != '\n' {
+			s.w.WriteByte('\n')
+		}
+	}
+	return s, nil
+}
+
+// add records a completed file.
+func (s *stateLog) add(relPath string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err := s.w.WriteString(strconv.Quote(relPath) + "\n")
+	return err
+}
+
+// Close flushes and closes the state file. Closing it again is a no-op.
+func (s *stateLog) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.f == nil {
+		return nil
+	}
+	err := s.w.Flush()
+	if closeErr := s.f.Close(); err == nil {
+		err = closeErr
+	}
+	s.f = nil
+	return err
+}
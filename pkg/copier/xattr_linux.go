@@ -0,0 +1,62 @@
+package copier
+
+import (
+	"bytes"
+	"errors"
+
+	"golang.org/x/sys/unix"
+)
+
+// copyXattrs copies the extended attributes of srcPath to dstPath, without
+// following symlinks. Attributes the destination filesystem does not
+// support are an error, while a source without attributes is not.
+func copyXattrs(srcPath, dstPath string) error {
+	list, err := readXattrBuf(func(dest []byte) (int, error) { return unix.Llistxattr(srcPath, dest) })
+	if errors.Is(err, unix.ENOTSUP) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, name := range bytes.Split(bytes.TrimRight(list, "\x00"), []byte{0}) {
+		if len(name) == 0 {
+			continue
+		}
+		value, err := readXattrBuf(func(dest []byte) (int, error) { return unix.Lgetxattr(srcPath, string(name), dest) })
+		if errors.Is(err, unix.ENODATA) {
+			continue // removed since listed
+		}
+		if err != nil {
+			return err
+		}
+		if err := unix.Lsetxattr(dstPath, string(name), value, 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readXattrBuf calls a listxattr or getxattr style function, first to
+// learn the size and then to fill a buffer of that size. Retries if the
+// attributes grow in between.
+func readXattrBuf(call func(dest []byte) (int, error)) ([]byte, error) {
+	for {
+		size, err := call(nil)
+		if err != nil {
+			return nil, err
+		}
+		if size == 0 {
+			return nil, nil
+		}
+		buf := make([]byte, size)
+		n, err := call(buf)
+		if errors.Is(err, unix.ERANGE) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return buf[:n], nil
+	}
+}
@@ -0,0 +1,8 @@
+//go:build !linux
+
+package copier
+
+// copyXattrs is not supported on this platform.
+func copyXattrs(srcPath, dstPath string) error {
+	return nil
+}
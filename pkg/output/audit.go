@@ -0,0 +1,99 @@
+package output
+
+import (
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/otuschhoff/cwalk/pkg/stat"
+)
+
+// auditColumns are the list columns shown for each audit entry.
+var auditColumns = []string{"path", "type", "mode", "owner", "group", "size"}
+
+// auditSection is one titled section of the audit report.
+type auditSection struct {
+	title string // Section column value in tables, CSV and XLSX
+	infos []stat.FileInfo
+}
+
+// auditSections returns the sections of report in output order.
+func auditSections(report *stat.AuditReport) []auditSection {
+	return []auditSection{
+		{"World-writable", report.WorldWritable},
+		{"Setuid/setgid", report.SetID},
+		{"Orphaned owner", report.Orphaned},
+		{"Dangling symlink", report.DanglingSymlinks},
+	}
+}
+
+// jsonAudit returns the audit section of JSON output, with the uid of
+// each entry in addition to the columns of the other formats.
+func jsonAudit(results *stat.Results) *JSONAudit {
+	report := results.Audit()
+	cols := append(append([]string{}, auditColumns...), "uid")
+	return &JSONAudit{
+		WorldWritable:    jsonEntries(report.WorldWritable, cols),
+		SetID:            jsonEntries(report.SetID, cols),
+		Orphaned:         jsonEntries(report.Orphaned, cols),
+		DanglingSymlinks: jsonEntries(report.DanglingSymlinks, cols),
+	}
+}
+
+// formatAudit formats the security audit report: world-writable entries,
+// setuid and setgid files, entries owned by unresolvable UIDs and dangling
+// symlinks. Tables, CSV and XLSX have one row per finding with the section
+// in the first column; JSON has one array per section.
+func (f *Formatter) formatAudit(results *stat.Results) string {
+	sections := auditSections(results.Audit())
+
+	headers := []string{"Section", "Path", "Type", "Mode", "Owner", "Group", "Size"}
+	switch f.format {
+	case "csv", "xlsx":
+		var data []map[string]interface{}
+		for _, s := range sections {
+			for _, fi := range s.infos {
+				row := map[string]interface{}{"Section": s.title}
+				for i, col := range auditColumns {
+					row[headers[i+1]] = listValue(fi, col, false)
+				}
+				data = append(data, row)
+			}
+		}
+		if f.format == "csv" {
+			return f.toCSV(headers, data)
+		}
+		return f.toXLSX(headers, data)
+	}
+
+	t := table.NewWriter()
+	if !f.noHeader {
+		t.AppendHeader(table.Row{"Section", "Path", "Type", "Mode", "Owner", "Group", "Size"})
+	}
+
+	var sizes []int64
+	for _, s := range sections {
+		for _, fi := range s.infos {
+			sizes = append(sizes, fi.Size)
+		}
+	}
+	sizeCol := f.column(sizes, true)
+
+	idx := 0
+	for _, s := range sections {
+		for _, fi := range s.infos {
+			row := table.Row{s.title}
+			for _, col := range auditColumns {
+				if col == "size" {
+					row = append(row, sizeCol[idx])
+				} else {
+					row = append(row, listValue(fi, col, true))
+				}
+			}
+			t.AppendRow(row)
+			idx++
+		}
+		if !f.plain && len(s.infos) > 0 {
+			t.AppendSeparator()
+		}
+	}
+
+	return f.render(t, len(headers), &results.Scan)
+}
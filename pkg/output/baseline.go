@@ -0,0 +1,196 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/jedib0t/go-pretty/v6/text"
+	"github.com/otuschhoff/cwalk/pkg/stat"
+)
+
+// Baseline holds the group sizes of a previous JSON result, so tables can
+// show how each group grew since. See SetBaseline.
+type Baseline struct {
+	years  map[string]int64     // Size per year, "0" for unknown years
+	uids   map[string]int64     // Size per UID
+	groups map[string]int64     // Size per group, keyed by groupKey
+	dims   []string             // Dimensions of groups
+	roots  map[string]*Baseline // Baselines of the roots of a document with separate roots
+}
+
+// LoadBaseline reads a document written with the json output format. It
+// must have a perYear, perUid or groups section.
+func LoadBaseline(filename string) (*Baseline, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var doc JSONDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	if doc.SchemaVersion != SchemaVersion {
+		return nil, fmt.Errorf("%s: unsupported schema version %d (want %d)", filename, doc.SchemaVersion, SchemaVersion)
+	}
+	b := newBaseline(&doc)
+	if b.years == nil && b.uids == nil && b.groups == nil {
+		return nil, fmt.Errorf("%s: no perYear, perUid or groups section", filename)
+	}
+	return b, nil
+}
+
+// newBaseline returns the baseline of the sections of doc and its roots.
+func newBaseline(doc *JSONDocument) *Baseline {
+	b := &Baseline{}
+	if doc.PerYear != nil {
+		b.years = make(map[string]int64)
+		for _, row := range doc.PerYear {
+			year := 0
+			if row.Year != nil {
+				year = *row.Year
+			}
+			b.years[strconv.Itoa(year)] = row.Size
+		}
+	}
+	if doc.PerUID != nil {
+		b.uids = make(map[string]int64)
+		for _, row := range doc.PerUID {
+			b.uids[strconv.FormatUint(uint64(row.UID), 10)] = row.Size
+		}
+	}
+	if doc.Groups != nil {
+		b.groups = make(map[string]int64)
+		b.dims = doc.Groups.Dimensions
+		for _, row := range doc.Groups.Groups {
+			keys := make([]string, len(b.dims))
+			for i, dim := range b.dims {
+				keys[i] = row.Keys[dim]
+			}
+			b.groups[groupKey(keys)] = row.Size
+		}
+	}
+	for _, root := range doc.Roots {
+		if b.roots == nil {
+			b.roots = make(map[string]*Baseline)
+		}
+		b.roots[root.Root] = newBaseline(root)
+	}
+	return b
+}
+
+// groupKey returns the key of a cross-tabulated group in a baseline.
+func groupKey(keys []string) string {
+	return strings.Join(keys, "\x00")
+}
+
+// root returns the baseline of a root of a document with separate roots,
+// or nil.
+func (b *Baseline) root(root string) *Baseline {
+	if b == nil {
+		return nil
+	}
+	return b.roots[root]
+}
+
+// SetBaseline adds columns to per-year, per-UID and groups tables with
+// the growth of each group since the baseline: the size difference, such
+// as "+40.0 GB", and the growth in percent, such as "+12.0%", or "new" for
+// groups missing from the baseline. Groups that grew by more than alert
+// percent are highlighted in red, or marked with "!" without colors. The
+// sections of each root are compared to the root of the same path in the
+// baseline. A nil baseline removes the columns.
+func (f *Formatter) SetBaseline(b *Baseline, alert float64) {
+	f.baseline, f.alert = b, alert
+}
+
+// growthColumns returns the size difference and growth columns of table
+// rows with the given keys and sizes against the baseline sizes of their
+// mode, or nil without a baseline. The totals row, if any, has the key "",
+// compared against the baseline total.
+func (f *Formatter) growthColumns(base map[string]int64, keys []string, sizes []int64) (deltas, pcts []string) {
+	if base == nil {
+		return nil, nil
+	}
+	var total int64
+	for _, size := range base {
+		total += size
+	}
+
+	deltas = make([]string, len(keys))
+	pcts = make([]string, len(keys))
+	for i, key := range keys {
+		prev, ok := base[key]
+		if key == "" {
+			prev, ok = total, true
+		}
+		if !ok {
+			deltas[i] = "new"
+			continue
+		}
+		delta := sizes[i] - prev
+		deltas[i] = "+" + f.formatSize(delta)
+		if delta < 0 {
+			deltas[i] = "-" + f.formatSize(-delta)
+		}
+		if prev <= 0 {
+			continue
+		}
+		pct := 100 * float64(delta) / float64(prev)
+		pcts[i] = fmt.Sprintf("%+.1f%%", pct)
+		if pct > f.alert {
+			if f.noColor || f.plain {
+				pcts[i] += " !"
+			} else {
+				deltas[i] = text.Colors{text.FgRed, text.Bold}.Sprint(deltas[i])
+				pcts[i] = text.Colors{text.FgRed, text.Bold}.Sprint(pcts[i])
+			}
+		}
+	}
+	if !f.plain {
+		deltas, pcts = padLeft(deltas), padLeft(pcts)
+	}
+	return deltas, pcts
+}
+
+// padLeft right-aligns values to the width of the widest one, ignoring
+// ANSI escape sequences.
+func padLeft(values []string) []string {
+	width := 0
+	for _, v := range values {
+		width = max(width, text.RuneWidthWithoutEscSequences(v))
+	}
+	out := slices.Clone(values)
+	for i, v := range out {
+		out[i] = strings.Repeat(" ", width-text.RuneWidthWithoutEscSequences(v)) + v
+	}
+	return out
+}
+
+// yearBaseline returns the baseline sizes of the per-year table, or nil.
+func (f *Formatter) yearBaseline() map[string]int64 {
+	if f.baseline == nil {
+		return nil
+	}
+	return f.baseline.years
+}
+
+// uidBaseline returns the baseline sizes of the per-UID table, or nil.
+func (f *Formatter) uidBaseline() map[string]int64 {
+	if f.baseline == nil {
+		return nil
+	}
+	return f.baseline.uids
+}
+
+// groupsBaseline returns the baseline sizes of the groups table of
+// results, or nil unless the baseline has the same dimensions.
+func (f *Formatter) groupsBaseline(results *stat.Results) map[string]int64 {
+	if f.baseline == nil || !slices.Equal(f.baseline.dims, results.GroupColumns) {
+		return nil
+	}
+	return f.baseline.groups
+}
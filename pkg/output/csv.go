@@ -0,0 +1,148 @@
+package output
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CSVSizes selects how sizes are written in CSV output.
+type CSVSizes string
+
+const (
+	// CSVSizesHuman writes sizes in human-readable units, e.g. "1.5 GB".
+	CSVSizesHuman CSVSizes = "human"
+	// CSVSizesRaw writes sizes as exact byte counts.
+	CSVSizesRaw CSVSizes = "raw"
+	// CSVSizesBoth writes sizes in human-readable units, each followed by
+	// a column with the exact byte count named after it, e.g. "SizeBytes".
+	CSVSizesBoth CSVSizes = "both"
+)
+
+// CSVOptions adapt CSV output to the spreadsheet or tool reading it. The
+// zero value writes comma-separated values with decimal points and
+// human-readable sizes.
+type CSVOptions struct {
+	Delimiter    rune     // Field separator; ',' if zero
+	DecimalComma bool     // Write decimals with a comma, as European locales expect
+	Sizes        CSVSizes // How sizes are written; CSVSizesHuman if empty
+}
+
+// SetCSV sets the delimiter, decimal separator and size columns of CSV
+// output. Spreadsheets in many European locales expect semicolons with
+// decimal commas, and tab-separated values suit Unix tools.
+func (f *Formatter) SetCSV(opts CSVOptions) {
+	f.csv = opts
+}
+
+// ParseCSVDelimiter parses a delimiter given by name (comma, semicolon,
+// tab, pipe) or as a single character.
+func ParseCSVDelimiter(s string) (rune, error) {
+	switch strings.ToLower(s) {
+	case "comma", ",":
+		return ',', nil
+	case "semicolon", ";":
+		return ';', nil
+	case "tab", `\t`, "\t":
+		return '\t', nil
+	case "pipe", "|":
+		return '|', nil
+	}
+	r := []rune(s)
+	if len(r) != 1 || r[0] == '"' || r[0] == '\r' || r[0] == '\n' || r[0] == '.' {
+		return 0, fmt.Errorf("unsupported delimiter %q (valid: comma, semicolon, tab, pipe or a single character)", s)
+	}
+	return r[0], nil
+}
+
+// newCSVWriter returns a CSV writer using the delimiter of the formatter.
+func (f *Formatter) newCSVWriter(buf *bytes.Buffer) *csv.Writer {
+	w := csv.NewWriter(buf)
+	if f.csv.Delimiter != 0 {
+		w.Comma = f.csv.Delimiter
+	}
+	return w
+}
+
+// newCSVReader returns a CSV reader using the delimiter of the formatter,
+// to read back output written by toCSV.
+func (f *Formatter) newCSVReader(content string) *csv.Reader {
+	r := csv.NewReader(strings.NewReader(content))
+	if f.csv.Delimiter != 0 {
+		r.Comma = f.csv.Delimiter
+	}
+	r.FieldsPerRecord = -1
+	return r
+}
+
+// csvSizeColumns returns the columns that get a byte count column with
+// CSVSizesBoth: those holding sizes in any row. Returns nil otherwise.
+func (f *Formatter) csvSizeColumns(headers []string, data []map[string]interface{}) map[string]bool {
+	if f.csv.Sizes != CSVSizesBoth {
+		return nil
+	}
+	cols := make(map[string]bool)
+	for _, row := range data {
+		for _, header := range headers {
+			if _, ok := row[header].(byteSize); ok {
+				cols[header] = true
+			}
+		}
+	}
+	return cols
+}
+
+// csvHeaders returns the CSV header row: headers, with a byte count column
+// following each of sizeCols.
+func csvHeaders(headers []string, sizeCols map[string]bool) []string {
+	var out []string
+	for _, header := range headers {
+		out = append(out, header)
+		if sizeCols[header] {
+			out = append(out, header+"Bytes")
+		}
+	}
+	return out
+}
+
+// csvRecord returns the CSV fields of row, in the order of csvHeaders.
+// Times are written in RFC 3339 format.
+func (f *Formatter) csvRecord(headers []string, row map[string]interface{}, sizeCols map[string]bool) []string {
+	var values []string
+	for _, header := range headers {
+		switch val := row[header].(type) {
+		case time.Time:
+			values = append(values, val.Format(time.RFC3339))
+		case byteSize:
+			if f.csv.Sizes == CSVSizesRaw {
+				values = append(values, strconv.FormatInt(int64(val), 10))
+			} else {
+				values = append(values, f.csvDecimal(f.formatSize(int64(val))))
+			}
+		case float64:
+			values = append(values, f.csvDecimal(strconv.FormatFloat(val, 'f', -1, 64)))
+		default:
+			values = append(values, fmt.Sprintf("%v", val))
+		}
+		if sizeCols[header] {
+			if size, ok := row[header].(byteSize); ok {
+				values = append(values, strconv.FormatInt(int64(size), 10))
+			} else {
+				values = append(values, "")
+			}
+		}
+	}
+	return values
+}
+
+// csvDecimal replaces the decimal point of a formatted number with a comma
+// if the options ask for it.
+func (f *Formatter) csvDecimal(s string) string {
+	if f.csv.DecimalComma {
+		return strings.Replace(s, ".", ",", 1)
+	}
+	return s
+}
@@ -0,0 +1,92 @@
+package output
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseCSVDelimiter(t *testing.T) {
+	tests := []struct {
+		in   string
+		want rune
+	}{
+		{"comma", ','},
+		{"Semicolon", ';'},
+		{";", ';'},
+		{"tab", '\t'},
+		{`\t`, '\t'},
+		{"pipe", '|'},
+		{":", ':'},
+	}
+	for _, tt := range tests {
+		got, err := ParseCSVDelimiter(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseCSVDelimiter(%q) = %q, %v, want %q", tt.in, got, err, tt.want)
+		}
+	}
+	for _, in := range []string{"", "\"", ".", "\n", "colon"} {
+		if _, err := ParseCSVDelimiter(in); err == nil {
+			t.Errorf("ParseCSVDelimiter(%q) should fail", in)
+		}
+	}
+}
+
+func TestFormatCSVOptions(t *testing.T) {
+	headers := []string{"Name", "Size", "Ratio"}
+	data := []map[string]interface{}{
+		{"Name": "a;b", "Size": byteSize(1536), "Ratio": 0.25},
+		{"Name": "c", "Size": byteSize(10), "Ratio": 2.0},
+	}
+
+	tests := []struct {
+		name string
+		opts CSVOptions
+		want string
+	}{
+		{"default", CSVOptions{},
+			"Name,Size,Ratio\na;b,1.5 KB,0.25\nc,10 B,2\n"},
+		{"semicolon decimal comma", CSVOptions{Delimiter: ';', DecimalComma: true},
+			"Name;Size;Ratio\n\"a;b\";1,5 KB;0,25\nc;10 B;2\n"},
+		{"tab raw", CSVOptions{Delimiter: '\t', Sizes: CSVSizesRaw},
+			"Name\tSize\tRatio\na;b\t1536\t0.25\nc\t10\t2\n"},
+		{"both", CSVOptions{Sizes: CSVSizesBoth},
+			"Name,Size,SizeBytes,Ratio\na;b,1.5 KB,1536,0.25\nc,10 B,10,2\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := NewFormatter("csv", "summary", false)
+			f.SetCSV(tt.opts)
+			if got := f.toCSV(headers, data); got != tt.want {
+				t.Errorf("toCSV() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteSplitDelimiter(t *testing.T) {
+	dir := t.TempDir()
+	content := "PATH;SIZE\n\"/a;b\";1\n/c;2\n/d;3\n"
+
+	f := NewFormatter("csv", "list", false)
+	f.SetCSV(CSVOptions{Delimiter: ';'})
+	if _, err := f.WriteSplit(content, filepath.Join(dir, "inventory.csv"), SplitLimits{MaxRows: 2}); err != nil {
+		t.Fatalf("WriteSplit failed: %v", err)
+	}
+
+	b, err := os.ReadFile(filepath.Join(dir, "inventory-0001.csv"))
+	if err != nil {
+		t.Fatalf("failed to read part: %v", err)
+	}
+	if got := string(b); got != "PATH;SIZE\n\"/a;b\";1\n/c;2\n" {
+		t.Errorf("first part = %q", got)
+	}
+	b, err = os.ReadFile(filepath.Join(dir, "inventory-0002.csv"))
+	if err != nil {
+		t.Fatalf("failed to read part: %v", err)
+	}
+	if !strings.HasPrefix(string(b), "PATH;SIZE\n") {
+		t.Errorf("second part lacks the header: %q", b)
+	}
+}
@@ -0,0 +1,115 @@
+package output
+
+import (
+	"fmt"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/otuschhoff/cwalk/pkg/stat"
+)
+
+// cumInodesPct returns the share of inodes at each depth or above, in
+// percent.
+func cumInodesPct(depths []*stat.DepthStat) []float64 {
+	var total int64
+	for _, s := range depths {
+		total += s.TotalInodes
+	}
+	cumPct := make([]float64, len(depths))
+	var seen int64
+	for i, s := range depths {
+		seen += s.TotalInodes
+		if total > 0 {
+			cumPct[i] = float64(seen) * 100 / float64(total)
+		}
+	}
+	return cumPct
+}
+
+// jsonPerDepth returns the perDepth section of JSON output.
+func jsonPerDepth(results *stat.Results) []JSONDepth {
+	depths := results.SortedDepths()
+	cumPct := cumInodesPct(depths)
+	rows := make([]JSONDepth, 0, len(depths))
+	for i, s := range depths {
+		rows = append(rows, JSONDepth{
+			Depth:        s.Depth,
+			Size:         s.TotalSize,
+			Inodes:       s.TotalInodes,
+			Files:        s.Files,
+			Dirs:         s.Dirs,
+			Symlinks:     s.Symlinks,
+			Others:       s.Others,
+			FilesSize:    s.FilesSize,
+			CumInodesPct: round2(cumPct[i]),
+		})
+	}
+	return rows
+}
+
+// formatPerDepth formats statistics grouped by depth below the walk roots,
+// from the roots down, with the running share of inodes at that depth or
+// above, which tells how deep a tree must be copied to cover most of it.
+func (f *Formatter) formatPerDepth(results *stat.Results) string {
+	depths := results.SortedDepths()
+	cumPct := cumInodesPct(depths)
+
+	headers := []string{"Depth", "Size", "Inodes", "Files", "Dirs", "Symlinks", "Others", "FilesSize", "CumInodesPct"}
+	switch f.format {
+	case "csv", "xlsx":
+		data := make([]map[string]interface{}, 0, len(depths))
+		for i, s := range depths {
+			data = append(data, map[string]interface{}{
+				"Depth":        s.Depth,
+				"Size":         byteSize(s.TotalSize),
+				"Inodes":       s.TotalInodes,
+				"Files":        s.Files,
+				"Dirs":         s.Dirs,
+				"Symlinks":     s.Symlinks,
+				"Others":       s.Others,
+				"FilesSize":    byteSize(s.FilesSize),
+				"CumInodesPct": round2(cumPct[i]),
+			})
+		}
+		if f.format == "csv" {
+			return f.toCSV(headers, data)
+		}
+		return f.toXLSX(headers, data)
+	}
+
+	return f.perDepthTable(depths, cumPct, &results.Scan)
+}
+
+// perDepthTable renders per-depth statistics as a table with aligned size
+// and count columns.
+func (f *Formatter) perDepthTable(depths []*stat.DepthStat, cumPct []float64, scan *stat.ScanStat) string {
+	t := table.NewWriter()
+	headers := table.Row{"Depth", "Size", "Inodes", "Files", "Dirs", "Symlinks", "Others", "Cum. Inodes"}
+	if !f.noHeader {
+		t.AppendHeader(headers)
+	}
+
+	n := len(depths)
+	sizes, inodes := make([]int64, n), make([]int64, n)
+	files, dirs := make([]int64, n), make([]int64, n)
+	symlinks, others := make([]int64, n), make([]int64, n)
+	for i, s := range depths {
+		sizes[i], inodes[i] = s.TotalSize, s.TotalInodes
+		files[i], dirs[i] = s.Files, s.Dirs
+		symlinks[i], others[i] = s.Symlinks, s.Others
+	}
+	sizeCol := f.column(sizes, true)
+	inodeCol := f.column(inodes, false)
+	fileCol := f.column(files, false)
+	dirCol := f.column(dirs, false)
+	symlinkCol := f.column(symlinks, false)
+	otherCol := f.column(others, false)
+
+	for i, s := range depths {
+		t.AppendRow(table.Row{
+			s.Depth, sizeCol[i], inodeCol[i], fileCol[i], dirCol[i], symlinkCol[i], otherCol[i],
+			fmt.Sprintf("%.1f%%", cumPct[i]),
+		})
+	}
+
+	return f.render(t, len(headers), scan)
+}
@@ -0,0 +1,74 @@
+package output
+
+import (
+	"fmt"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/otuschhoff/cwalk/pkg/stat"
+)
+
+// emptyColumns are the list columns shown for each empty entry.
+var emptyColumns = []string{"path", "owner", "group", "mtime"}
+
+// jsonEmpty returns the empty section of JSON output.
+func jsonEmpty(results *stat.Results) *JSONEmpty {
+	report := results.Empty()
+	return &JSONEmpty{
+		EmptyFiles: len(report.Files),
+		EmptyDirs:  len(report.Dirs),
+		Total:      len(report.Files) + len(report.Dirs),
+		Files:      jsonEntries(report.Files, emptyColumns),
+		Dirs:       jsonEntries(report.Dirs, emptyColumns),
+	}
+}
+
+// formatEmpty formats the empty files and directories with their counts.
+// Tables, CSV and XLSX have one row per entry with its type in the first
+// column, and tables end with the totals; JSON has the counts and one
+// array per type.
+func (f *Formatter) formatEmpty(results *stat.Results) string {
+	report := results.Empty()
+	sections := []struct {
+		title string
+		infos []stat.FileInfo
+	}{
+		{"file", report.Files},
+		{"dir", report.Dirs},
+	}
+
+	headers := []string{"Type", "Path", "Owner", "Group", "Modified"}
+	switch f.format {
+	case "csv", "xlsx":
+		var data []map[string]interface{}
+		for _, s := range sections {
+			for _, fi := range s.infos {
+				row := map[string]interface{}{"Type": s.title}
+				for i, col := range emptyColumns {
+					row[headers[i+1]] = listValue(fi, col, false)
+				}
+				data = append(data, row)
+			}
+		}
+		if f.format == "csv" {
+			return f.toCSV(headers, data)
+		}
+		return f.toXLSX(headers, data)
+	}
+
+	t := table.NewWriter()
+	if !f.noHeader {
+		t.AppendHeader(table.Row{"Type", "Path", "Owner", "Group", "Modified"})
+	}
+	for _, s := range sections {
+		for _, fi := range s.infos {
+			row := table.Row{s.title}
+			for _, col := range emptyColumns {
+				row = append(row, listValue(fi, col, true))
+			}
+			t.AppendRow(row)
+		}
+	}
+	t.AppendFooter(table.Row{"Total", fmt.Sprintf("%d empty files, %d empty dirs", len(report.Files), len(report.Dirs))})
+
+	return f.render(t, len(headers), &results.Scan)
+}
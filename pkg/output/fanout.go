@@ -0,0 +1,77 @@
+package output
+
+import (
+	"fmt"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/otuschhoff/cwalk/pkg/stat"
+)
+
+// jsonFanOut returns the fanOut section of JSON output.
+func jsonFanOut(results *stat.Results) *JSONFanOut {
+	fo := results.FanOut
+	if fo == nil {
+		fo = &stat.FanOutStat{}
+	}
+	data := &JSONFanOut{
+		Dirs:         fo.Dirs,
+		Entries:      fo.Entries,
+		AvgFanOut:    round2(fo.AvgFanOut()),
+		MaxEntries:   fo.MaxEntries,
+		MaxPath:      fo.MaxPath,
+		HugeDirs:     fo.Huge,
+		HugePaths:    append([]string{}, fo.HugePaths...),
+		Distribution: make([]JSONFanOutBucket, 0, len(stat.FanOutBuckets)),
+	}
+	for i, entries := range stat.FanOutBuckets {
+		var dirs int64
+		if i < len(fo.Buckets) {
+			dirs = fo.Buckets[i]
+		}
+		data.Distribution = append(data.Distribution, JSONFanOutBucket{Entries: entries, Dirs: dirs})
+	}
+	return data
+}
+
+// formatFanOut formats the distribution of entries per directory: totals,
+// the fullest directory, the count of directories per entry count range
+// and the paths of directories with at least stat.HugeDirEntries entries.
+func (f *Formatter) formatFanOut(results *stat.Results) string {
+	fo := results.FanOut
+	if fo == nil {
+		fo = &stat.FanOutStat{}
+	}
+	buckets := make([]int64, len(stat.FanOutBuckets))
+	copy(buckets, fo.Buckets)
+
+	data := []map[string]interface{}{
+		{"Metric": "Directories", "Value": fo.Dirs},
+		{"Metric": "Entries", "Value": fo.Entries},
+		{"Metric": "Avg Fan-Out", "Value": fmt.Sprintf("%.2f", fo.AvgFanOut())},
+		{"Metric": "Max Entries", "Value": fo.MaxEntries},
+		{"Metric": "Fullest Directory", "Value": fo.MaxPath},
+	}
+	for i, n := range buckets {
+		data = append(data, map[string]interface{}{"Metric": "Dirs with " + stat.FanOutBuckets[i] + " Entries", "Value": n})
+	}
+	for _, p := range fo.HugePaths {
+		data = append(data, map[string]interface{}{"Metric": "Huge Directory", "Value": p})
+	}
+
+	switch f.format {
+	case "csv":
+		return f.toCSV([]string{"Metric", "Value"}, data)
+	case "xlsx":
+		return f.toXLSX([]string{"Metric", "Value"}, data)
+	}
+
+	t := table.NewWriter()
+	if !f.noHeader {
+		t.AppendHeader(table.Row{"Metric", "Value"})
+	}
+	for _, row := range data {
+		t.AppendRow(table.Row{row["Metric"], row["Value"]})
+	}
+
+	return f.render(t, 2, &results.Scan)
+}
@@ -7,26 +7,62 @@ package output
 
 import (
 	"bytes"
-	"encoding/csv"
 	"encoding/json"
 	"fmt"
-	"math"
 	"os"
-	"sort"
+	"strconv"
 	"strings"
+	"text/template"
+	"time"
 
 	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/jedib0t/go-pretty/v6/text"
 	"github.com/otuschhoff/cwalk/pkg/stat"
+	"github.com/otuschhoff/cwalk/pkg/textfmt"
 )
 
+// dimBelow is the fraction of a column's maximum below which table values
+// are dimmed.
+const dimBelow = 0.001
+
 // Formatter handles formatting and exporting statistics in various formats and modes.
 //
-// Supported formats: "table" (ASCII tables), "json" (JSON), "csv" (CSV), "xlsx" (Excel).
-// Supported modes: "summary" (total statistics), "per-year" (grouped by year), "per-uid" (grouped by owner).
+// Supported formats: "table" (ASCII tables), "json" (JSON), "csv" (CSV), "xlsx" (Excel),
+// "template" (a Go template, see SetTemplate), "html" (a standalone report with charts);
+// the mode does not apply to the last two.
+// Supported modes: "summary" (total statistics), "per-year" (grouped by year), "per-uid" (grouped by owner),
+// "symlinks" (symlink chain depth statistics), "random-names" (directories of random-looking names),
+// "watchlist" (entries matching the ransomware watchlist), "churn" (change since a previous snapshot), "list" (one row per entry), "paths" (one path per line),
+// "per-fs" (grouped by file system), "per-depth" (grouped by depth below the roots), "inode-usage" (file system capacity of each root),
+// "xattrs" (extended attributes and ACLs), "selinux" (SELinux security contexts).
 type Formatter struct {
-	format   string // "table", "json", "csv", "xlsx"
-	mode     string // "summary", "per-year", "per-uid"
-	noHeader bool   // Omit header row in table output
+	format   string        // "table", "json", "csv", "xlsx", "template", "html"
+	mode     string        // "summary", "per-year", "per-uid", "symlinks", "random-names", "watchlist", "churn", "list", "paths", "per-fs", "per-depth", "inode-usage", "xattrs", "selinux"
+	noHeader bool          // Omit header row in table output
+	plain    bool          // Render tables as plain tab-separated text
+	noColor  bool          // Render tables without ANSI colors and dimming
+	footer   bool          // Append a footer row describing the scan to tables
+	totals   bool          // Add a totals row and a size share column to per-year and per-UID tables
+	sort     SortKey       // Column per-year, per-UID and list rows are sorted by
+	reverse  bool          // Reverse the row order
+	limit    int           // Maximum number of per-year, per-UID and groups rows; 0 for all
+	rawBytes bool          // Follow sizes in tables with the exact byte count
+	units    textfmt.Units // Units of sizes in tables, CSV, HTML and template output
+	columns  []string      // Columns of list output (nil for the defaults)
+	null     bool          // Terminate paths output with NUL instead of newline
+	baseline *Baseline     // Sizes of a previous result to show growth against
+	alert    float64       // Growth in percent highlighted against the baseline
+
+	modes  []string           // Modes of a document with several sections (nil for mode)
+	roots  bool               // Write a section per root of Results.ByRoot before the total
+	sheets *[]xlsxSheet       // Collects the XLSX sheets of several modes
+	csv    CSVOptions         // Delimiter, decimals and sizes of CSV output
+	style  TableStyle         // Look of tables
+	width  int                // Maximum width of table columns; 0 for no limit
+	wrap   Wrap               // How values wider than width are shortened
+	counts textfmt.Counts     // Form of counts in tables and HTML output
+	sep    string             // Thousands separator of grouped counts
+	tmpl   *template.Template // Template of template output
 }
 
 // NewFormatter creates a new Formatter with the specified format and output mode.
@@ -38,39 +74,188 @@ func NewFormatter(format, mode string, noHeader bool) *Formatter {
 	}
 }
 
+// SetPlain makes table output screen-reader friendly: tables are rendered
+// as tab-separated lines without colors, box-drawing characters, dimming or
+// alignment padding, and every value is printed in full with its unit.
+func (f *Formatter) SetPlain(plain bool) {
+	f.plain = plain
+}
+
+// SetColor enables or disables ANSI colors and the dimming of negligible
+// values in table output, for terminals and for files or pipes
+// respectively. Tables are colored by default; other formats never are.
+func (f *Formatter) SetColor(color bool) {
+	f.noColor = !color
+}
+
+// SetFooter appends a footer row to table output showing the scanned
+// roots, the scan duration with per-phase timing, and the number of entries
+// and errors, so reports carry their operational context.
+func (f *Formatter) SetFooter(footer bool) {
+	f.footer = footer
+}
+
+// SetTotals adds a row with the grand totals and a column with each row's
+// share of the total size to per-year and per-UID tables. The totals
+// include rows dropped by SetLimit.
+func (f *Formatter) SetTotals(totals bool) {
+	f.totals = totals
+}
+
+// SetUnits sets the units of sizes in tables, in CSV output with
+// human-readable sizes, in HTML reports and in the humanBytes template
+// function: KB or KiB for powers of 1024, kB for powers of 1000, or exact
+// byte counts. JSON and XLSX output always carry exact values.
+func (f *Formatter) SetUnits(units textfmt.Units) {
+	f.units = units
+}
+
+// SetCounts sets the form of counts such as inode and file counts in
+// tables and HTML reports: plain digits, groups of thousands separated by
+// separator ("," if empty), or compact as in "1.2M". CSV, JSON, XLSX and
+// template output always carry exact values.
+func (f *Formatter) SetCounts(counts textfmt.Counts, separator string) {
+	f.counts, f.sep = counts, separator
+}
+
+// SetRawBytes follows every size in table output with the exact byte
+// count, as in "1.5 GB (1610612736)", so values copied from a table can be
+// compared precisely. JSON and XLSX output always carry exact values.
+func (f *Formatter) SetRawBytes(raw bool) {
+	f.rawBytes = raw
+}
+
+// SetSort sorts per-year and per-UID output by the given column, including
+// the computed average file size, files per directory and symlink share,
+// and list output by size, modification time, path or owner.
+func (f *Formatter) SetSort(key SortKey) {
+	f.sort = key
+}
+
+// SetReverse reverses the row order of per-year, per-UID, groups and list output,
+// after sorting.
+func (f *Formatter) SetReverse(reverse bool) {
+	f.reverse = reverse
+}
+
+// SetLimit keeps only the first n rows of per-year, per-UID and groups
+// output after sorting, e.g. the biggest consumers with SortBySize. 0
+// keeps all rows.
+func (f *Formatter) SetLimit(n int) {
+	f.limit = n
+}
+
 // Format converts results to the appropriate output format as a string.
 // The actual formatting depends on the Formatter's format and mode settings.
 func (f *Formatter) Format(results *stat.Results) string {
+	if f.roots && len(results.ByRoot) > 0 {
+		return f.formatRoots(results)
+	}
+	switch f.format {
+	case "template":
+		return f.formatTemplate(results)
+	case "html":
+		return f.formatHTML(results)
+	case "json":
+		return f.formatJSON(results)
+	}
+	if len(f.modes) > 0 {
+		return f.formatModes(results)
+	}
+
 	switch f.mode {
 	case "per-year":
 		return f.formatPerYear(results)
 	case "per-uid":
 		return f.formatPerUID(results)
+	case "symlinks":
+		return f.formatSymlinks(results)
+	case "random-names":
+		return f.formatRandomNames(results)
+	case "watchlist":
+		return f.formatWatchlist(results)
+	case "churn":
+		return f.formatChurn(results)
+	case "list":
+		return f.formatList(results)
+	case "paths":
+		return f.formatPaths(results)
+	case "per-fs":
+		return f.formatPerFS(results)
+	case "inode-usage":
+		return f.formatInodeUsage(results)
+	case "xattrs":
+		return f.formatXattrs(results)
+	case "selinux":
+		return f.formatSELinux(results)
+	case "audit":
+		return f.formatAudit(results)
+	case "homes":
+		return f.formatHomes(results)
+	case "projects":
+		return f.formatProjects(results)
+	case "ownership":
+		return f.formatOwnership(results)
+	case "per-project-id":
+		return f.formatPerProjectID(results)
+	case "striping":
+		return f.formatStriping(results)
+	case "quota":
+		return f.formatQuota(results)
+	case "empty":
+		return f.formatEmpty(results)
+	case "fan-out":
+		return f.formatFanOut(results)
+	case "per-depth":
+		return f.formatPerDepth(results)
+	case "groups":
+		return f.formatGroups(results)
+	case "stats":
+		return f.formatStats(results)
 	default:
 		return f.formatSummary(results)
 	}
 }
 
-// WriteToFile writes formatted output to a file, handling format-specific options.
-// For XLSX format, content is interpreted as filename base. For other formats,
-// content is written as-is to the file.
+// WriteToFile writes formatted output to a file. XLSX output is the raw
+// workbook and is written as-is like the other formats. Output is
+// gzip-compressed if filename ends in ".gz".
 func (f *Formatter) WriteToFile(content string, filename string) error {
-	switch f.format {
-	case "xlsx":
-		return f.writeXLSX(filename, content)
-	default:
-		return os.WriteFile(filename, []byte(content), 0644)
+	data, err := encodeFile(filename, []byte(content))
+	if err != nil {
+		return err
 	}
+	return os.WriteFile(filename, data, 0644)
 }
 
-// formatSummary formats summary statistics in the specified format (table/json/csv).
+// jsonSummary returns the summary section of JSON output.
+func jsonSummary(results *stat.Results) *JSONSummary {
+	sum := results.Summary
+	return &JSONSummary{
+		Size:         sum.TotalSize,
+		Inodes:       sum.TotalInodes,
+		Files:        sum.Files,
+		Dirs:         sum.Dirs,
+		Symlinks:     sum.Symlinks,
+		Others:       sum.Others,
+		FilesSize:    sum.FilesSize,
+		DirsSize:     sum.DirsSize,
+		SymlinksSize: sum.SymlinksSize,
+		OthersSize:   sum.OthersSize,
+		PhysicalSize: sum.PhysicalSize,
+		Compression:  round2(sum.CompressionRatio()),
+		Extremes:     jsonExtremes(&sum.Extremes),
+	}
+}
+
+// formatSummary formats summary statistics in the specified format (table/csv/xlsx).
 func (f *Formatter) formatSummary(results *stat.Results) string {
 	sum := results.Summary
 
 	data := []map[string]interface{}{
 		{
 			"Metric":   "Total Size",
-			"Value":    formatBytes(sum.TotalSize),
+			"Value":    byteSize(sum.TotalSize),
 			"Files":    sum.FilesSize,
 			"Dirs":     sum.DirsSize,
 			"Symlinks": sum.SymlinksSize,
@@ -85,127 +270,365 @@ func (f *Formatter) formatSummary(results *stat.Results) string {
 			"Others":   sum.Others,
 		},
 	}
-
-	if f.format == "json" {
-		return f.toJSON(map[string]interface{}{
-			"summary": sum,
-			"totals": map[string]interface{}{
-				"totalSize":    sum.TotalSize,
-				"totalInodes":  sum.TotalInodes,
-				"files":        sum.Files,
-				"dirs":         sum.Dirs,
-				"symlinks":     sum.Symlinks,
-				"others":       sum.Others,
-				"filesSize":    sum.FilesSize,
-				"dirsSize":     sum.DirsSize,
-				"symlinksSize": sum.SymlinksSize,
-				"othersSize":   sum.OthersSize,
-			},
-		})
+	for _, row := range append(f.physicalRows(sum), f.extremesRows(&sum.Extremes)...) {
+		data = append(data, map[string]interface{}{"Metric": row[0], "Value": row[1]})
 	}
 
-	if f.format == "csv" {
+	switch f.format {
+	case "csv":
 		return f.toCSV([]string{"Metric", "Value", "Files", "Dirs", "Symlinks", "Others"}, data)
+	case "xlsx":
+		return f.toXLSX([]string{"Metric", "Value", "Files", "Dirs", "Symlinks", "Others"}, data)
 	}
 
-	return f.summaryTable(sum)
+	return f.summaryTable(sum, &results.Scan)
 }
 
-// formatPerYear formats statistics grouped by year
-func (f *Formatter) formatPerYear(results *stat.Results) string {
-	// Sort years
-	var years []int
-	for year := range results.ByYear {
-		years = append(years, year)
+// jsonPerYear returns the perYear section of JSON output, in the order of
+// the other formats.
+func (f *Formatter) jsonPerYear(results *stat.Results) []JSONYear {
+	rows := make([]JSONYear, 0, len(results.ByYear))
+	for _, year := range f.sortedYears(results.ByYear) {
+		s := results.ByYear[year]
+		m := yearMetrics(s)
+		row := JSONYear{
+			Size:         s.TotalSize,
+			Inodes:       s.TotalInodes,
+			Files:        s.Files,
+			Dirs:         s.Dirs,
+			Symlinks:     s.Symlinks,
+			Others:       s.Others,
+			FilesSize:    s.FilesSize,
+			DirsSize:     s.DirsSize,
+			SymlinksSize: s.SymlinksSize,
+			OthersSize:   s.OthersSize,
+			AvgFileSize:  m.avgFileSize(),
+			FilesPerDir:  round2(m.filesPerDir()),
+			SymlinkPct:   round2(m.symlinkPct()),
+		}
+		if year != 0 {
+			row.Year = ptr(year)
+		}
+		rows = append(rows, row)
 	}
-	sort.Sort(sort.Reverse(sort.IntSlice(years)))
+	return rows
+}
 
-	if f.format == "json" {
-		return f.toJSON(results.ByYear)
-	}
+// formatPerYear formats statistics grouped by year
+func (f *Formatter) formatPerYear(results *stat.Results) string {
+	years := f.sortedYears(results.ByYear)
 
 	data := []map[string]interface{}{}
 	for _, year := range years {
 		stat := results.ByYear[year]
+		m := yearMetrics(stat)
 		data = append(data, map[string]interface{}{
-			"Year":      year,
-			"Size":      formatBytes(stat.TotalSize),
-			"Inodes":    stat.TotalInodes,
-			"Files":     stat.Files,
-			"Dirs":      stat.Dirs,
-			"Symlinks":  stat.Symlinks,
-			"Others":    stat.Others,
-			"FilesSize": formatBytes(stat.FilesSize),
-			"DirsSize":  formatBytes(stat.DirsSize),
+			"Year":        yearLabel(year),
+			"Size":        byteSize(stat.TotalSize),
+			"Inodes":      stat.TotalInodes,
+			"Files":       stat.Files,
+			"Dirs":        stat.Dirs,
+			"Symlinks":    stat.Symlinks,
+			"Others":      stat.Others,
+			"FilesSize":   byteSize(stat.FilesSize),
+			"DirsSize":    byteSize(stat.DirsSize),
+			"AvgFileSize": byteSize(m.avgFileSize()),
+			"FilesPerDir": round2(m.filesPerDir()),
+			"SymlinkPct":  round2(m.symlinkPct()),
 		})
 	}
 
-	if f.format == "csv" {
-		headers := []string{"Year", "Size", "Inodes", "Files", "Dirs", "Symlinks", "Others", "FilesSize", "DirsSize"}
+	headers := []string{"Year", "Size", "Inodes", "Files", "Dirs", "Symlinks", "Others", "FilesSize", "DirsSize", "AvgFileSize", "FilesPerDir", "SymlinkPct"}
+	switch f.format {
+	case "csv":
 		return f.toCSV(headers, data)
+	case "xlsx":
+		return f.toXLSX(headers, data)
 	}
 
-	return f.perYearTable(results.ByYear)
+	return f.perYearTable(results.ByYear, &results.Scan)
+}
+
+// jsonPerUID returns the perUid section of JSON output, in the order of
+// the other formats.
+func (f *Formatter) jsonPerUID(results *stat.Results) []JSONUID {
+	rows := make([]JSONUID, 0, len(results.ByUID))
+	for _, uid := range f.sortedUIDs(results.ByUID) {
+		s := results.ByUID[uid]
+		m := uidMetrics(s)
+		rows = append(rows, JSONUID{
+			UID:          uid,
+			Username:     s.Username,
+			Size:         s.TotalSize,
+			Inodes:       s.TotalInodes,
+			Files:        s.Files,
+			Dirs:         s.Dirs,
+			Symlinks:     s.Symlinks,
+			Others:       s.Others,
+			FilesSize:    s.FilesSize,
+			DirsSize:     s.DirsSize,
+			SymlinksSize: s.SymlinksSize,
+			OthersSize:   s.OthersSize,
+			AvgFileSize:  m.avgFileSize(),
+			FilesPerDir:  round2(m.filesPerDir()),
+			SymlinkPct:   round2(m.symlinkPct()),
+		})
+	}
+	return rows
 }
 
 // formatPerUID formats statistics grouped by UID (file owner).
 // Groups all files by their owner UID and presents statistics for each user.
 func (f *Formatter) formatPerUID(results *stat.Results) string {
-	// Sort UIDs
-	var uids []uint32
-	for uid := range results.ByUID {
-		uids = append(uids, uid)
-	}
-	sort.Slice(uids, func(i, j int) bool { return uids[i] < uids[j] })
-
-	if f.format == "json" {
-		// Convert to a more JSON-friendly format
-		uidData := make([]map[string]interface{}, 0)
-		for _, uid := range uids {
-			stat := results.ByUID[uid]
-			uidData = append(uidData, map[string]interface{}{
-				"uid":       uid,
-				"username":  stat.Username,
-				"size":      stat.TotalSize,
-				"inodes":    stat.TotalInodes,
-				"files":     stat.Files,
-				"dirs":      stat.Dirs,
-				"symlinks":  stat.Symlinks,
-				"others":    stat.Others,
-				"filesSize": stat.FilesSize,
-				"dirsSize":  stat.DirsSize,
-			})
-		}
-		return f.toJSON(uidData)
-	}
+	uids := f.sortedUIDs(results.ByUID)
 
 	data := []map[string]interface{}{}
 	for _, uid := range uids {
 		stat := results.ByUID[uid]
+		m := uidMetrics(stat)
+		data = append(data, map[string]interface{}{
+			"UID":         uid,
+			"Username":    stat.Username,
+			"Size":        byteSize(stat.TotalSize),
+			"Inodes":      stat.TotalInodes,
+			"Files":       stat.Files,
+			"Dirs":        stat.Dirs,
+			"Symlinks":    stat.Symlinks,
+			"Others":      stat.Others,
+			"FilesSize":   byteSize(stat.FilesSize),
+			"DirsSize":    byteSize(stat.DirsSize),
+			"AvgFileSize": byteSize(m.avgFileSize()),
+			"FilesPerDir": round2(m.filesPerDir()),
+			"SymlinkPct":  round2(m.symlinkPct()),
+		})
+	}
+
+	headers := []string{"UID", "Username", "Size", "Inodes", "Files", "Dirs", "Symlinks", "Others", "FilesSize", "DirsSize", "AvgFileSize", "FilesPerDir", "SymlinkPct"}
+	switch f.format {
+	case "csv":
+		return f.toCSV(headers, data)
+	case "xlsx":
+		return f.toXLSX(headers, data)
+	}
+
+	return f.perUIDTable(results.ByUID, &results.Scan)
+}
+
+// jsonSymlinks returns the symlinks section of JSON output, which also
+// lists the broken and escaping paths.
+func jsonSymlinks(results *stat.Results) *JSONSymlinks {
+	chains := results.SymlinkChains
+	if chains == nil {
+		chains = &stat.SymlinkChainStat{}
+	}
+	data := &JSONSymlinks{
+		Chains: JSONSymlinkChains{
+			Chains:   chains.Chains,
+			MaxDepth: chains.MaxDepth,
+			AvgDepth: chains.AvgDepth(),
+			Loops:    chains.Loops,
+		},
+	}
+	if links := results.Symlinks; links != nil {
+		data.Check = &JSONSymlinkCheck{
+			Symlinks:      links.Symlinks,
+			Broken:        links.Broken,
+			Absolute:      links.Absolute,
+			Relative:      links.Relative,
+			Escaping:      links.Escaping,
+			BrokenPaths:   append([]string{}, links.BrokenPaths...),
+			EscapingPaths: append([]string{}, links.EscapingPaths...),
+		}
+	}
+	return data
+}
+
+// formatSymlinks formats symlink chain depth statistics.
+// Reports how many chains were resolved, their maximum and average depth,
+// and how many loops were detected. If symlink targets were checked, the
+// broken, absolute, relative and root-escaping links are counted as well.
+func (f *Formatter) formatSymlinks(results *stat.Results) string {
+	chains := results.SymlinkChains
+	if chains == nil {
+		chains = &stat.SymlinkChainStat{}
+	}
+	links := results.Symlinks
+
+	avgDepth := fmt.Sprintf("%.2f", chains.AvgDepth())
+	data := []map[string]interface{}{
+		{"Metric": "Symlinks", "Value": chains.Chains},
+		{"Metric": "Max Chain Depth", "Value": chains.MaxDepth},
+		{"Metric": "Avg Chain Depth", "Value": avgDepth},
+		{"Metric": "Loops", "Value": chains.Loops},
+	}
+	if links != nil {
+		data = append(data,
+			map[string]interface{}{"Metric": "Broken", "Value": links.Broken},
+			map[string]interface{}{"Metric": "Absolute Targets", "Value": links.Absolute},
+			map[string]interface{}{"Metric": "Relative Targets", "Value": links.Relative},
+			map[string]interface{}{"Metric": "Escaping Root", "Value": links.Escaping},
+		)
+	}
+
+	switch f.format {
+	case "csv":
+		return f.toCSV([]string{"Metric", "Value"}, data)
+	case "xlsx":
+		return f.toXLSX([]string{"Metric", "Value"}, data)
+	}
+
+	t := table.NewWriter()
+	if !f.noHeader {
+		t.AppendHeader(table.Row{"Metric", "Value"})
+	}
+	for _, row := range data {
+		t.AppendRow(table.Row{row["Metric"], row["Value"]})
+	}
+
+	return f.render(t, 2, &results.Scan)
+}
+
+// jsonRandomNames returns the randomNames section of JSON output.
+func jsonRandomNames(results *stat.Results) []JSONRandomNameDir {
+	dirs := results.RandomNameDirs(stat.DefaultRandomNameMinEntries, stat.DefaultRandomNameMinRatio)
+	rows := make([]JSONRandomNameDir, 0, len(dirs))
+	for _, d := range dirs {
+		rows = append(rows, JSONRandomNameDir{
+			Path:    d.Path,
+			Entries: d.Entries,
+			Random:  d.Random,
+			Ratio:   d.Ratio(),
+		})
+	}
+	return rows
+}
+
+// formatRandomNames formats the directories dominated by random-looking file names.
+// Directories are listed by random-name count, largest first.
+func (f *Formatter) formatRandomNames(results *stat.Results) string {
+	dirs := results.RandomNameDirs(stat.DefaultRandomNameMinEntries, stat.DefaultRandomNameMinRatio)
+
+	data := []map[string]interface{}{}
+	for _, d := range dirs {
 		data = append(data, map[string]interface{}{
-			"UID":       uid,
-			"Username":  stat.Username,
-			"Size":      formatBytes(stat.TotalSize),
-			"Inodes":    stat.TotalInodes,
-			"Files":     stat.Files,
-			"Dirs":      stat.Dirs,
-			"Symlinks":  stat.Symlinks,
-			"Others":    stat.Others,
-			"FilesSize": formatBytes(stat.FilesSize),
-			"DirsSize":  formatBytes(stat.DirsSize),
+			"Directory": d.Path,
+			"Entries":   d.Entries,
+			"Random":    d.Random,
+			"Ratio":     fmt.Sprintf("%.0f%%", d.Ratio()*100),
 		})
 	}
 
-	if f.format == "csv" {
-		headers := []string{"UID", "Username", "Size", "Inodes", "Files", "Dirs", "Symlinks", "Others", "FilesSize", "DirsSize"}
+	headers := []string{"Directory", "Entries", "Random", "Ratio"}
+	switch f.format {
+	case "csv":
 		return f.toCSV(headers, data)
+	case "xlsx":
+		return f.toXLSX(headers, data)
+	}
+
+	t := table.NewWriter()
+	if !f.noHeader {
+		t.AppendHeader(table.Row{"Directory", "Entries", "Random", "Ratio"})
+	}
+	for _, row := range data {
+		t.AppendRow(table.Row{row["Directory"], row["Entries"], row["Random"], row["Ratio"]})
 	}
 
-	return f.perUIDTable(results.ByUID)
+	return f.render(t, len(headers), &results.Scan)
+}
+
+// jsonWatchlist returns the watchlist section of JSON output.
+func jsonWatchlist(results *stat.Results) []JSONWatchlistMatch {
+	rows := make([]JSONWatchlistMatch, 0, len(results.WatchlistMatches))
+	for _, m := range results.WatchlistMatches {
+		rows = append(rows, JSONWatchlistMatch{Path: m.Path, Pattern: m.Pattern})
+	}
+	return rows
+}
+
+// formatWatchlist formats entries that matched the ransomware watchlist.
+func (f *Formatter) formatWatchlist(results *stat.Results) string {
+	data := []map[string]interface{}{}
+	for _, m := range results.WatchlistMatches {
+		data = append(data, map[string]interface{}{
+			"Path":    m.Path,
+			"Pattern": m.Pattern,
+		})
+	}
+
+	switch f.format {
+	case "csv":
+		return f.toCSV([]string{"Path", "Pattern"}, data)
+	case "xlsx":
+		return f.toXLSX([]string{"Path", "Pattern"}, data)
+	}
+
+	t := table.NewWriter()
+	if !f.noHeader {
+		t.AppendHeader(table.Row{"Path", "Pattern"})
+	}
+	for _, row := range data {
+		t.AppendRow(table.Row{row["Path"], row["Pattern"]})
+	}
+
+	return f.render(t, 2, &results.Scan)
+}
+
+// jsonChurn returns the churn section of JSON output, or nil if no
+// comparison was made.
+func jsonChurn(results *stat.Results) *JSONChurn {
+	churn := results.Churn
+	if churn == nil {
+		return nil
+	}
+	return &JSONChurn{
+		IntervalSeconds: int64(churn.Interval.Seconds()),
+		Added:           churn.Added,
+		Deleted:         churn.Deleted,
+		Modified:        churn.Modified,
+		AddedBytes:      churn.AddedBytes,
+		DeletedBytes:    churn.DeletedBytes,
+		ModifiedBytes:   churn.ModifiedBytes,
+		TurnoverBytes:   churn.TurnoverBytes(),
+	}
+}
+
+// formatChurn formats change-rate metrics against a previous snapshot.
+// Returns an explanatory message if no comparison was made.
+func (f *Formatter) formatChurn(results *stat.Results) string {
+	churn := results.Churn
+	if churn == nil {
+		return "No churn data: compare against a previous snapshot to compute churn\n"
+	}
+
+	data := []map[string]interface{}{
+		{"Metric": "Interval", "Count": churn.Interval.String(), "Size": ""},
+		{"Metric": "Added", "Count": churn.Added, "Size": byteSize(churn.AddedBytes)},
+		{"Metric": "Deleted", "Count": churn.Deleted, "Size": byteSize(churn.DeletedBytes)},
+		{"Metric": "Modified", "Count": churn.Modified, "Size": byteSize(churn.ModifiedBytes)},
+		{"Metric": "Turnover", "Count": churn.Added + churn.Deleted + churn.Modified, "Size": byteSize(churn.TurnoverBytes())},
+	}
+
+	switch f.format {
+	case "csv":
+		return f.toCSV([]string{"Metric", "Count", "Size"}, data)
+	case "xlsx":
+		return f.toXLSX([]string{"Metric", "Count", "Size"}, data)
+	}
+
+	t := table.NewWriter()
+	if !f.noHeader {
+		t.AppendHeader(table.Row{"Metric", "Count", "Size"})
+	}
+	for _, row := range data {
+		t.AppendRow(table.Row{row["Metric"], row["Count"], row["Size"]})
+	}
+
+	return f.render(t, 3, &results.Scan)
 }
 
 // summaryTable creates a formatted summary table, showing only columns with non-zero values
-func (f *Formatter) summaryTable(sum *stat.SummaryStat) string {
+func (f *Formatter) summaryTable(sum *stat.SummaryStat, scan *stat.ScanStat) string {
 	t := table.NewWriter()
 
 	// Determine which columns to show (those with non-zero values)
@@ -234,38 +657,38 @@ func (f *Formatter) summaryTable(sum *stat.SummaryStat) string {
 
 	// Build inodes row
 	var inodesRow []interface{}
-	inodesRow = append(inodesRow, "Total Inodes", sum.TotalInodes)
+	inodesRow = append(inodesRow, "Total Inodes", f.formatCount(sum.TotalInodes))
 	if sum.Files > 0 {
-		inodesRow = append(inodesRow, sum.Files)
+		inodesRow = append(inodesRow, f.formatCount(sum.Files))
 	}
 	if sum.Dirs > 0 {
-		inodesRow = append(inodesRow, sum.Dirs)
+		inodesRow = append(inodesRow, f.formatCount(sum.Dirs))
 	}
 	if sum.Symlinks > 0 {
-		inodesRow = append(inodesRow, sum.Symlinks)
+		inodesRow = append(inodesRow, f.formatCount(sum.Symlinks))
 	}
 	if sum.Others > 0 {
-		inodesRow = append(inodesRow, sum.Others)
+		inodesRow = append(inodesRow, f.formatCount(sum.Others))
 	}
 
 	// Build size row
 	var sizeRow []interface{}
-	countSizeCol := formatAlignedColumn([]int64{sum.TotalSize}, true)
+	countSizeCol := f.column([]int64{sum.TotalSize}, true)
 	sizeRow = append(sizeRow, "Total Size", countSizeCol[0])
 	if sum.Files > 0 {
-		filesSizeCol := formatAlignedColumn([]int64{sum.FilesSize}, true)
+		filesSizeCol := f.column([]int64{sum.FilesSize}, true)
 		sizeRow = append(sizeRow, filesSizeCol[0])
 	}
 	if sum.Dirs > 0 {
-		dirsSizeCol := formatAlignedColumn([]int64{sum.DirsSize}, true)
+		dirsSizeCol := f.column([]int64{sum.DirsSize}, true)
 		sizeRow = append(sizeRow, dirsSizeCol[0])
 	}
 	if sum.Symlinks > 0 {
-		symlinksSizeCol := formatAlignedColumn([]int64{sum.SymlinksSize}, true)
+		symlinksSizeCol := f.column([]int64{sum.SymlinksSize}, true)
 		sizeRow = append(sizeRow, symlinksSizeCol[0])
 	}
 	if sum.Others > 0 {
-		othersSizeCol := formatAlignedColumn([]int64{sum.OthersSize}, true)
+		othersSizeCol := f.column([]int64{sum.OthersSize}, true)
 		sizeRow = append(sizeRow, othersSizeCol[0])
 	}
 
@@ -273,25 +696,71 @@ func (f *Formatter) summaryTable(sum *stat.SummaryStat) string {
 		inodesRow,
 		sizeRow,
 	})
+	for _, row := range append(f.physicalRows(sum), f.extremesRows(&sum.Extremes)...) {
+		t.AppendRow(table.Row{row[0], row[1]})
+	}
 
-	t.SetStyle(table.StyleColoredDark)
-	return fmt.Sprintf("%s\n", t.Render())
+	return f.render(t, len(headers), scan)
+}
+
+// physicalRows returns the metric and value rows comparing the physical
+// size of the regular files of a summary with their logical size, or none
+// if the physical size was not collected.
+func (f *Formatter) physicalRows(sum *stat.SummaryStat) [][2]string {
+	if sum.PhysicalSize == 0 {
+		return nil
+	}
+	return [][2]string{
+		{"Physical Size", fmt.Sprintf("%s (%.1f%% of %s logical)", f.formatSize(sum.PhysicalSize),
+			100*float64(sum.PhysicalSize)/float64(max(sum.FilesSize, 1)), f.formatSize(sum.FilesSize))},
+		{"Compression Ratio", fmt.Sprintf("%.2fx", sum.CompressionRatio())},
+	}
+}
+
+// extremesRows returns the metric and value rows describing the extremes
+// of a summary, leaving out those without an entry.
+func (f *Formatter) extremesRows(e *stat.Extremes) [][2]string {
+	var rows [][2]string
+	if e.NewestPath != "" {
+		rows = append(rows,
+			[2]string{"Newest Modified", fmt.Sprintf("%s (%s)", e.NewestPath, e.NewestMtime.Format("2006-01-02 15:04"))},
+			[2]string{"Oldest Modified", fmt.Sprintf("%s (%s)", e.OldestPath, e.OldestMtime.Format("2006-01-02 15:04"))})
+	}
+	if e.LargestPath != "" {
+		rows = append(rows, [2]string{"Largest File", fmt.Sprintf("%s (%s)", e.LargestPath, f.formatSize(e.LargestSize))})
+	}
+	if e.DeepestPath != "" {
+		rows = append(rows, [2]string{"Deepest Path", fmt.Sprintf("%s (depth %d)", e.DeepestPath, e.MaxDepth)})
+	}
+	return rows
+}
+
+// yearLabel returns the label of a per-year row. Year 0 holds entries
+// whose timestamp is unknown, such as birth times on filesystems that do
+// not record them.
+func yearLabel(year int) interface{} {
+	if year == 0 {
+		return "unknown"
+	}
+	return year
 }
 
 // perYearTable creates a formatted per-year table, showing only columns with non-zero values
-func (f *Formatter) perYearTable(byYear map[int]*stat.YearStat) string {
+func (f *Formatter) perYearTable(byYear map[int]*stat.YearStat, scan *stat.ScanStat) string {
 	t := table.NewWriter()
 
-	// Sort years descending
-	var years []int
-	for year := range byYear {
-		years = append(years, year)
-	}
-	sort.Sort(sort.Reverse(sort.IntSlice(years)))
+	years := f.sortedYears(byYear)
 
 	// Determine which columns to show (those with non-zero values across all years)
 	var headers []string
-	headers = append(headers, "Year", "Size", "Inodes")
+	headers = append(headers, "Year", "Size")
+	if f.totals {
+		headers = append(headers, "Size %")
+	}
+	if f.yearBaseline() != nil {
+		headers = append(headers, "Size Δ", "Growth %")
+	}
+	headers = append(headers, "Inodes")
 
 	hasFiles := false
 	hasDirs := false
@@ -308,9 +777,34 @@ func (f *Formatter) perYearTable(byYear map[int]*stat.YearStat) string {
 	var others []int64
 	var filesSizes []int64
 	var dirsSizes []int64
+	var sizePcts []float64
+	var avgFileSizes []int64
+	var filesPerDir []float64
+	var symlinkPcts []float64
 
+	// The totals row follows the rows, of all years even beyond the limit
+	stats := make([]*stat.YearStat, 0, len(years)+1)
 	for _, year := range years {
-		s := byYear[year]
+		stats = append(stats, byYear[year])
+	}
+	total := yearTotal(byYear)
+	if f.totals {
+		stats = append(stats, &total)
+	}
+	keys := make([]string, 0, len(stats))
+	for _, year := range years {
+		keys = append(keys, strconv.Itoa(year))
+	}
+	if f.totals {
+		keys = append(keys, "")
+	}
+
+	for _, s := range stats {
+		m := yearMetrics(s)
+		sizePcts = append(sizePcts, percentOf(s.TotalSize, total.TotalSize))
+		avgFileSizes = append(avgFileSizes, m.avgFileSize())
+		filesPerDir = append(filesPerDir, m.filesPerDir())
+		symlinkPcts = append(symlinkPcts, m.symlinkPct())
 		totalSizes = append(totalSizes, s.TotalSize)
 		inodes = append(inodes, s.TotalInodes)
 		files = append(files, s.Files)
@@ -358,6 +852,15 @@ func (f *Formatter) perYearTable(byYear map[int]*stat.YearStat) string {
 	if hasDirsSize {
 		headers = append(headers, "Dirs Size")
 	}
+	if hasFiles {
+		headers = append(headers, "Avg File Size")
+	}
+	if hasFiles && hasDirs {
+		headers = append(headers, "Files/Dir")
+	}
+	if hasSymlinks {
+		headers = append(headers, "Symlinks %")
+	}
 
 	if !f.noHeader {
 		headerRow := make(table.Row, len(headers))
@@ -367,18 +870,29 @@ func (f *Formatter) perYearTable(byYear map[int]*stat.YearStat) string {
 		t.AppendHeader(headerRow)
 	}
 
-	sizeCol := formatAlignedColumn(totalSizes, true)
-	inodeCol := formatAlignedColumn(inodes, false)
-	filesCol := formatAlignedColumn(files, false)
-	dirsCol := formatAlignedColumn(dirs, false)
-	symlinkCol := formatAlignedColumn(symlinks, false)
-	othersCol := formatAlignedColumn(others, false)
-	filesSizeCol := formatAlignedColumn(filesSizes, true)
-	dirsSizeCol := formatAlignedColumn(dirsSizes, true)
-
-	for idx, year := range years {
-		var row []interface{}
-		row = append(row, year, sizeCol[idx], inodeCol[idx])
+	sizeCol := f.column(totalSizes, true)
+	inodeCol := f.column(inodes, false)
+	filesCol := f.column(files, false)
+	dirsCol := f.column(dirs, false)
+	symlinkCol := f.column(symlinks, false)
+	othersCol := f.column(others, false)
+	filesSizeCol := f.column(filesSizes, true)
+	dirsSizeCol := f.column(dirsSizes, true)
+	avgFileSizeCol := f.column(avgFileSizes, true)
+	filesPerDirCol := f.ratioColumn(filesPerDir)
+	symlinkPctCol := f.ratioColumn(symlinkPcts)
+	sizePctCol := f.ratioColumn(sizePcts)
+	deltaCol, growthCol := f.growthColumns(f.yearBaseline(), keys, totalSizes)
+
+	row := func(idx int, labels ...interface{}) table.Row {
+		row := append(labels, sizeCol[idx])
+		if f.totals {
+			row = append(row, sizePctCol[idx])
+		}
+		if deltaCol != nil {
+			row = append(row, deltaCol[idx], growthCol[idx])
+		}
+		row = append(row, inodeCol[idx])
 
 		if hasFiles {
 			row = append(row, filesCol[idx])
@@ -398,28 +912,43 @@ func (f *Formatter) perYearTable(byYear map[int]*stat.YearStat) string {
 		if hasDirsSize {
 			row = append(row, dirsSizeCol[idx])
 		}
-
-		t.AppendRow(table.Row(row))
+		if hasFiles {
+			row = append(row, avgFileSizeCol[idx])
+		}
+		if hasFiles && hasDirs {
+			row = append(row, filesPerDirCol[idx])
+		}
+		if hasSymlinks {
+			row = append(row, symlinkPctCol[idx])
+		}
+		return table.Row(row)
+	}
+	for idx, year := range years {
+		t.AppendRow(row(idx, yearLabel(year)))
+	}
+	if f.totals {
+		t.AppendFooter(row(len(years), "Total"))
 	}
 
-	t.SetStyle(table.StyleColoredDark)
-	return fmt.Sprintf("%s\n", t.Render())
+	return f.render(t, len(headers), scan)
 }
 
 // perUIDTable creates a formatted per-UID table, showing only columns with non-zero values
-func (f *Formatter) perUIDTable(byUID map[uint32]*stat.UIDStat) string {
+func (f *Formatter) perUIDTable(byUID map[uint32]*stat.UIDStat, scan *stat.ScanStat) string {
 	t := table.NewWriter()
 
-	// Sort UIDs
-	var uids []uint32
-	for uid := range byUID {
-		uids = append(uids, uid)
-	}
-	sort.Slice(uids, func(i, j int) bool { return uids[i] < uids[j] })
+	uids := f.sortedUIDs(byUID)
 
 	// Determine which columns to show (those with non-zero values across all UIDs)
 	var headers []string
-	headers = append(headers, "UID", "Username", "Size", "Inodes")
+	headers = append(headers, "UID", "Username", "Size")
+	if f.totals {
+		headers = append(headers, "Size %")
+	}
+	if f.uidBaseline() != nil {
+		headers = append(headers, "Size Δ", "Growth %")
+	}
+	headers = append(headers, "Inodes")
 
 	hasFiles := false
 	hasDirs := false
@@ -436,9 +965,34 @@ func (f *Formatter) perUIDTable(byUID map[uint32]*stat.UIDStat) string {
 	var others []int64
 	var filesSizes []int64
 	var dirsSizes []int64
+	var sizePcts []float64
+	var avgFileSizes []int64
+	var filesPerDir []float64
+	var symlinkPcts []float64
 
+	// The totals row follows the rows, of all UIDs even beyond the limit
+	stats := make([]*stat.UIDStat, 0, len(uids)+1)
+	for _, uid := range uids {
+		stats = append(stats, byUID[uid])
+	}
+	total := uidTotal(byUID)
+	if f.totals {
+		stats = append(stats, &total)
+	}
+	keys := make([]string, 0, len(stats))
 	for _, uid := range uids {
-		s := byUID[uid]
+		keys = append(keys, strconv.FormatUint(uint64(uid), 10))
+	}
+	if f.totals {
+		keys = append(keys, "")
+	}
+
+	for _, s := range stats {
+		m := uidMetrics(s)
+		sizePcts = append(sizePcts, percentOf(s.TotalSize, total.TotalSize))
+		avgFileSizes = append(avgFileSizes, m.avgFileSize())
+		filesPerDir = append(filesPerDir, m.filesPerDir())
+		symlinkPcts = append(symlinkPcts, m.symlinkPct())
 		sizes = append(sizes, s.TotalSize)
 		inodes = append(inodes, s.TotalInodes)
 		files = append(files, s.Files)
@@ -486,6 +1040,15 @@ func (f *Formatter) perUIDTable(byUID map[uint32]*stat.UIDStat) string {
 	if hasDirsSize {
 		headers = append(headers, "Dirs Size")
 	}
+	if hasFiles {
+		headers = append(headers, "Avg File Size")
+	}
+	if hasFiles && hasDirs {
+		headers = append(headers, "Files/Dir")
+	}
+	if hasSymlinks {
+		headers = append(headers, "Symlinks %")
+	}
 
 	if !f.noHeader {
 		headerRow := make(table.Row, len(headers))
@@ -495,19 +1058,29 @@ func (f *Formatter) perUIDTable(byUID map[uint32]*stat.UIDStat) string {
 		t.AppendHeader(headerRow)
 	}
 
-	sizeCol := formatAlignedColumn(sizes, true)
-	inodeCol := formatAlignedColumn(inodes, false)
-	filesCol := formatAlignedColumn(files, false)
-	dirsCol := formatAlignedColumn(dirs, false)
-	symlinkCol := formatAlignedColumn(symlinks, false)
-	othersCol := formatAlignedColumn(others, false)
-	filesSizeCol := formatAlignedColumn(filesSizes, true)
-	dirsSizeCol := formatAlignedColumn(dirsSizes, true)
-
-	for idx, uid := range uids {
-		stat := byUID[uid]
-		var row []interface{}
-		row = append(row, uid, stat.Username, sizeCol[idx], inodeCol[idx])
+	sizeCol := f.column(sizes, true)
+	inodeCol := f.column(inodes, false)
+	filesCol := f.column(files, false)
+	dirsCol := f.column(dirs, false)
+	symlinkCol := f.column(symlinks, false)
+	othersCol := f.column(others, false)
+	filesSizeCol := f.column(filesSizes, true)
+	dirsSizeCol := f.column(dirsSizes, true)
+	avgFileSizeCol := f.column(avgFileSizes, true)
+	filesPerDirCol := f.ratioColumn(filesPerDir)
+	symlinkPctCol := f.ratioColumn(symlinkPcts)
+	sizePctCol := f.ratioColumn(sizePcts)
+	deltaCol, growthCol := f.growthColumns(f.uidBaseline(), keys, sizes)
+
+	row := func(idx int, labels ...interface{}) table.Row {
+		row := append(labels, sizeCol[idx])
+		if f.totals {
+			row = append(row, sizePctCol[idx])
+		}
+		if deltaCol != nil {
+			row = append(row, deltaCol[idx], growthCol[idx])
+		}
+		row = append(row, inodeCol[idx])
 
 		if hasFiles {
 			row = append(row, filesCol[idx])
@@ -527,14 +1100,124 @@ func (f *Formatter) perUIDTable(byUID map[uint32]*stat.UIDStat) string {
 		if hasDirsSize {
 			row = append(row, dirsSizeCol[idx])
 		}
+		if hasFiles {
+			row = append(row, avgFileSizeCol[idx])
+		}
+		if hasFiles && hasDirs {
+			row = append(row, filesPerDirCol[idx])
+		}
+		if hasSymlinks {
+			row = append(row, symlinkPctCol[idx])
+		}
+		return table.Row(row)
+	}
+	for idx, uid := range uids {
+		t.AppendRow(row(idx, uid, byUID[uid].Username))
+	}
+	if f.totals {
+		t.AppendFooter(row(len(uids), "Total", ""))
+	}
+
+	return f.render(t, len(headers), scan)
+}
 
-		t.AppendRow(table.Row(row))
+// render renders a table of the given number of columns in the configured
+// style, with a footer describing the scan if enabled.
+func (f *Formatter) render(t table.Writer, columns int, scan *stat.ScanStat) string {
+	if f.footer {
+		footer := footerText(scan)
+		if f.plain {
+			t.AppendFooter(table.Row{footer})
+		} else {
+			// Span the footer across all columns
+			row := make(table.Row, columns)
+			for i := range row {
+				row[i] = footer
+			}
+			t.AppendFooter(row, table.RowConfig{AutoMerge: true, AutoMergeAlign: text.AlignLeft})
+		}
 	}
 
-	t.SetStyle(table.StyleColoredDark)
+	if f.plain {
+		return fmt.Sprintf("%s\n", t.RenderTSV())
+	}
+	t.SetStyle(f.tableStyle())
+	t.SetColumnConfigs(f.columnConfigs(columns))
 	return fmt.Sprintf("%s\n", t.Render())
 }
 
+// dimBelow returns the fraction of a column's maximum below which table
+// values are dimmed, 0 to disable dimming without colors.
+func (f *Formatter) dimBelow() float64 {
+	if f.noColor {
+		return 0
+	}
+	return dimBelow
+}
+
+// footerText describes a scan in one line, for example
+// "Scanned 1200 paths under /home in 1.52s (walk 1.5s, merge 20ms), 3 errors".
+func footerText(scan *stat.ScanStat) string {
+	errors := "errors"
+	if scan.Errors == 1 {
+		errors = "error"
+	}
+	return fmt.Sprintf("Scanned %d paths under %s in %v (walk %v, merge %v), %d %s",
+		scan.Entries, strings.Join(scan.Roots, ", "), roundDuration(scan.Duration),
+		roundDuration(scan.WalkTime), roundDuration(scan.MergeTime), scan.Errors, errors)
+}
+
+// roundDuration rounds d to about three significant digits for display.
+func roundDuration(d time.Duration) time.Duration {
+	switch {
+	case d >= time.Second:
+		return d.Round(10 * time.Millisecond)
+	case d >= time.Millisecond:
+		return d.Round(10 * time.Microsecond)
+	default:
+		return d.Round(time.Microsecond)
+	}
+}
+
+// column formats a numeric table column: aligned and dimmed by
+// textfmt.AlignColumn, or value by value in plain mode. With raw bytes
+// enabled, sizes are followed by the exact byte count in parentheses.
+func (f *Formatter) column(values []int64, isBytes bool) []string {
+	var out []string
+	if !f.plain {
+		out = textfmt.AlignColumn(values, textfmt.Options{
+			Bytes:          isBytes,
+			Units:          f.units,
+			Counts:         f.counts,
+			Separator:      f.sep,
+			SuffixEveryRow: true,
+			DimBelow:       f.dimBelow(),
+		})
+	} else {
+		out = make([]string, len(values))
+		for i, v := range values {
+			if isBytes {
+				out[i] = f.formatSize(v)
+			} else {
+				out[i] = f.formatCount(v)
+			}
+		}
+	}
+
+	if isBytes && f.rawBytes && f.units != textfmt.UnitsRaw {
+		width := 0
+		if !f.plain {
+			for _, v := range values {
+				width = max(width, len(strconv.FormatInt(v, 10)))
+			}
+		}
+		for i, v := range values {
+			out[i] = fmt.Sprintf("%s (%*d)", out[i], width, v)
+		}
+	}
+	return out
+}
+
 // toJSON converts data to a JSON string using indented formatting.
 func (f *Formatter) toJSON(data interface{}) string {
 	b, err := json.MarshalIndent(data, "", "  ")
@@ -546,221 +1229,43 @@ func (f *Formatter) toJSON(data interface{}) string {
 
 // toCSV converts tabular data to CSV format.
 // Headers are written first, followed by rows with values in header column order.
+// Delimiter, decimals and size columns follow the CSV options, see SetCSV.
 func (f *Formatter) toCSV(headers []string, data []map[string]interface{}) string {
 	var buf bytes.Buffer
-	writer := csv.NewWriter(&buf)
+	writer := f.newCSVWriter(&buf)
+	sizeCols := f.csvSizeColumns(headers, data)
 
 	// Write headers
-	writer.Write(headers)
+	writer.Write(csvHeaders(headers, sizeCols))
 
 	// Write data rows
 	for _, row := range data {
-		var values []string
-		for _, header := range headers {
-			val := row[header]
-			values = append(values, fmt.Sprintf("%v", val))
-		}
-		writer.Write(values)
+		writer.Write(f.csvRecord(headers, row, sizeCols))
 	}
 
 	writer.Flush()
 	return buf.String()
 }
 
-// writeXLSX writes data to an Excel file.
-// Current implementation writes JSON to a .json file as placeholder.
-// TODO: Enhance to use excelize for proper Excel output.
-func (f *Formatter) writeXLSX(filename string, content string) error {
-	// For now, just write as JSON
-	// You can enhance this to use excelize for proper Excel output
-	return os.WriteFile(filename+".json", []byte(content), 0644)
+// FormatBytes formats a byte count like table output does, such as
+// "1.5 GB", for messages outside of reports.
+func FormatBytes(b int64) string {
+	return formatBytes(b)
 }
 
 // formatBytes formats bytes to a human-readable string with binary unit suffixes.
 // Uses standard binary prefixes (K, M, G, T, P, E).
 // Examples: "1.5 KB", "2.3 MB", "1.0 GB"
 func formatBytes(b int64) string {
-	const unit = 1024
-	if b < unit {
-		return fmt.Sprintf("%d B", b)
-	}
-	div, exp := int64(unit), 0
-	for n := b / unit; n >= unit; n /= unit {
-		div *= unit
-		exp++
-	}
-	return fmt.Sprintf("%.1f %cB", float64(b)/float64(div), "KMGTPE"[exp])
+	return textfmt.FormatBytes(b, textfmt.UnitsDefault)
 }
 
-// formatAlignedColumn formats a numeric column with consistent scaling, alignment, and dimming.
-// - Uses the scale of the highest value in the column for all rows (for bytes: KB/MB/GB, etc.).
-// - Aligns decimal points vertically across the column.
-// - Prints empty string for zero values.
-// - Dims values that are < 1/1000th of the column maximum.
-func formatAlignedColumn(values []int64, isBytes bool) []string {
-	if len(values) == 0 {
-		return []string{}
-	}
-
-	maxVal := int64(0)
-	for _, v := range values {
-		if v > maxVal {
-			maxVal = v
-		}
-	}
-	maxValOriginal := maxVal
-
-	// If all zeros, return empty strings.
-	if maxVal == 0 {
-		out := make([]string, len(values))
-		for i := range out {
-			out[i] = ""
-		}
-		return out
-	}
-
-	unitSuffix := ""
-	factor := 1.0
-
-	if isBytes {
-		// Determine unit based on maxVal
-		units := []string{"B", "KB", "MB", "GB", "TB", "PB", "EB"}
-		idx := 0
-		unitMax := maxVal
-		for unitMax >= 1024 && idx < len(units)-1 {
-			unitMax = unitMax / 1024
-			idx++
-		}
-		unitSuffix = units[idx]
-		factor = math.Pow(1024, float64(idx))
-	}
-
-	// First pass: format raw numbers (scaled) to find alignment widths.
-	raw := make([]string, len(values))
-	isLessThanThreshold := make([]bool, len(values)) // Track values below threshold
-	maxLeft, maxRight := 0, 0
-	for i, v := range values {
-		if v == 0 {
-			raw[i] = ""
-			continue
-		}
-		scaled := float64(v) / factor
-		decimals := 0
-		if scaled < 1 {
-			decimals = 2
-		} else if isBytes {
-			decimals = 1
-		}
-
-		if decimals == 0 {
-			raw[i] = fmt.Sprintf("%d", int64(math.Round(scaled)))
-		} else {
-			raw[i] = fmt.Sprintf("%.*f", decimals, scaled)
-			// Check if rounded value is effectively zero (all zeros after decimal)
-			if strings.HasPrefix(raw[i], "0.") && strings.TrimLeft(raw[i][2:], "0") == "" {
-				isLessThanThreshold[i] = true
-				raw[i] = "<"
-			} else {
-				if strings.HasPrefix(raw[i], "0.") {
-					raw[i] = raw[i][1:]
-				}
-				if strings.HasPrefix(raw[i], ".") {
-					raw[i] = replaceLeadingFractionZeros(raw[i])
-				}
-			}
-		}
-
-		parts := strings.Split(raw[i], ".")
-		left := len(parts[0])
-		right := 0
-		if len(parts) > 1 {
-			right = len(parts[1])
-		}
-		if left > maxLeft {
-			maxLeft = left
-		}
-		if right > maxRight {
-			maxRight = right
-		}
-	}
-
-	out := make([]string, len(values))
-	maxValFloat := 0.0
-	for _, v := range values {
-		if float64(v) > maxValFloat {
-			maxValFloat = float64(v)
-		}
-	}
-
-	for i, v := range values {
-		if v == 0 {
-			out[i] = ""
-			continue
-		}
-		
-		// If value is below threshold, display "<" aligned with decimal point and dimmed
-		if isLessThanThreshold[i] {
-			// Align "<" where the decimal point would be
-			leftPad := strings.Repeat(" ", maxLeft)
-			rightPad := ""
-			if maxRight > 0 {
-				rightPad = strings.Repeat(" ", maxRight)
-			}
-			formatted := leftPad + "<" + rightPad
-			// Always dim threshold values
-			formatted = "\x1b[90m" + formatted + "\x1b[0m"
-			out[i] = formatted
-			continue
-		}
-		
-		parts := strings.Split(raw[i], ".")
-		leftPart := parts[0]
-		rightPart := ""
-		if len(parts) > 1 {
-			rightPart = parts[1]
-		}
-
-		// Pad left and right to align decimal points
-		leftPad := strings.Repeat(" ", maxLeft-len(leftPart))
-		rightPad := ""
-		if maxRight > 0 {
-			rightPad = strings.Repeat(" ", maxRight-len(rightPart))
-		}
-
-		formatted := leftPad + leftPart
-		if maxRight > 0 {
-			formatted += "." + rightPart + rightPad
-		}
-		if unitSuffix != "" && v == maxValOriginal {
-			formatted += " " + unitSuffix
-		}
-
-		// Dim if < 1/1000th of max
-		if float64(v) < maxValFloat/1000.0 {
-			formatted = "\x1b[90m" + formatted + "\x1b[0m"
-		}
-
-		out[i] = formatted
-	}
-
-	return out
+// formatSize formats a size in the units set by SetUnits.
+func (f *Formatter) formatSize(b int64) string {
+	return textfmt.FormatBytes(b, f.units)
 }
 
-// replaceLeadingFractionZeros replaces zeros between the decimal point and the
-// first non-zero digit with spaces (e.g., ".06" -> ". 6").
-func replaceLeadingFractionZeros(s string) string {
-	if len(s) < 3 || s[0] != '.' {
-		return s
-	}
-	firstNonZero := -1
-	for i := 1; i < len(s); i++ {
-		if s[i] != '0' {
-			firstNonZero = i
-			break
-		}
-	}
-	if firstNonZero == -1 || firstNonZero == 1 {
-		return s
-	}
-	return "." + strings.Repeat(" ", firstNonZero-1) + s[firstNonZero:]
+// formatCount formats a count in the form set by SetCounts.
+func (f *Formatter) formatCount(n int64) string {
+	return textfmt.FormatCount(n, f.counts, f.sep)
 }
@@ -1,10 +1,14 @@
 package output
 
 import (
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/otuschhoff/cwalk/pkg/stat"
+	"github.com/otuschhoff/cwalk/pkg/textfmt"
 )
 
 func TestNewFormatter(t *testing.T) {
@@ -118,6 +122,45 @@ func TestFormatSummary(t *testing.T) {
 	}
 }
 
+func TestFormatSummaryExtremes(t *testing.T) {
+	mtime := time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)
+	results := &stat.Results{Summary: &stat.SummaryStat{
+		TotalInodes: 2,
+		Files:       2,
+		Extremes: stat.Extremes{
+			OldestMtime: mtime.AddDate(-1, 0, 0), OldestPath: "/r/old",
+			NewestMtime: mtime, NewestPath: "/r/new",
+			LargestSize: 2048, LargestPath: "/r/big",
+			MaxDepth: 3, DeepestPath: "/r/a/b/c",
+		},
+	}}
+
+	tests := []struct {
+		format string
+		want   []string
+	}{
+		{"json", []string{`"newestPath": "/r/new"`, `"largestSize": 2048`, `"maxDepth": 3`}},
+		{"csv", []string{"Newest Modified,/r/new (2024-05-01 12:30)", "Largest File,/r/big (2.0 KB)", "Deepest Path,/r/a/b/c (depth 3)"}},
+		{"table", []string{"Oldest Modified", "/r/old (2023-05-01 12:30)"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			output := NewFormatter(tt.format, "summary", false).Format(results)
+			for _, want := range tt.want {
+				if !strings.Contains(output, want) {
+					t.Errorf("output missing %q:\n%s", want, output)
+				}
+			}
+		})
+	}
+
+	// Without entries, no extremes rows are shown
+	empty := NewFormatter("csv", "summary", false).Format(&stat.Results{Summary: &stat.SummaryStat{}})
+	if strings.Contains(empty, "Modified") {
+		t.Errorf("empty summary shows extremes:\n%s", empty)
+	}
+}
+
 func TestFormatSummaryConditionalColumns(t *testing.T) {
 	// Test table output hides columns with zero values
 	results := &stat.Results{
@@ -156,6 +199,388 @@ func TestFormatSummaryConditionalColumns(t *testing.T) {
 	}
 }
 
+func TestFormatPlain(t *testing.T) {
+	results := &stat.Results{
+		Summary: &stat.SummaryStat{TotalSize: 1048576, TotalInodes: 100, Files: 80, Dirs: 20, FilesSize: 1048000, DirsSize: 576},
+		ByYear: map[int]*stat.YearStat{
+			2024: {Year: 2024, TotalSize: 1048000, TotalInodes: 90, Files: 80, Dirs: 10, FilesSize: 1048000},
+			2023: {Year: 2023, TotalSize: 576, TotalInodes: 10, Dirs: 10, DirsSize: 576},
+		},
+		ByUID: map[uint32]*stat.UIDStat{
+			1000: {UID: 1000, Username: "alice", TotalSize: 1048576, TotalInodes: 100, Files: 80, Dirs: 20},
+		},
+		TotalFiles:  make(map[string]int64),
+		TotalSize:   make(map[string]int64),
+		TotalInodes: make(map[string]int64),
+	}
+
+	for _, mode := range []string{"summary", "per-year", "per-uid", "symlinks", "churn"} {
+		t.Run(mode, func(t *testing.T) {
+			f := NewFormatter("table", mode, false)
+			f.SetPlain(true)
+			output := f.Format(results)
+
+			if strings.Contains(output, "\x1b") {
+				t.Errorf("plain output contains escape sequences: %q", output)
+			}
+			if strings.ContainsAny(output, "─│┌┐└┘├┤┬┴┼") {
+				t.Errorf("plain output contains box-drawing characters: %q", output)
+			}
+			for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+				if line != strings.TrimSpace(line) || strings.Contains(line, "  ") {
+					t.Errorf("plain output line is padded: %q", line)
+				}
+			}
+		})
+	}
+
+	f := NewFormatter("table", "per-year", false)
+	f.SetPlain(true)
+	output := f.Format(results)
+	if !strings.Contains(output, "2023\t576 B\t10\t") {
+		t.Errorf("per-year plain output should print full values with units, got %q", output)
+	}
+}
+
+func TestFormatFooter(t *testing.T) {
+	results := &stat.Results{
+		Summary: &stat.SummaryStat{TotalSize: 1024, TotalInodes: 2, Files: 1, Dirs: 1, FilesSize: 1024},
+		ByYear:  map[int]*stat.YearStat{2024: {Year: 2024, TotalSize: 1024, TotalInodes: 2, Files: 1, Dirs: 1}},
+		ByUID:   map[uint32]*stat.UIDStat{1000: {UID: 1000, Username: "alice", TotalSize: 1024, TotalInodes: 2}},
+		Scan: stat.ScanStat{
+			Roots:     []string{"/Data"},
+			Entries:   3,
+			Errors:    1,
+			Duration:  1500 * time.Millisecond,
+			WalkTime:  1400 * time.Millisecond,
+			MergeTime: 100 * time.Millisecond,
+		},
+	}
+	want := "Scanned 3 paths under /Data in 1.5s (walk 1.4s, merge 100ms), 1 error"
+
+	for _, mode := range []string{"summary", "per-year", "per-uid", "symlinks"} {
+		t.Run(mode, func(t *testing.T) {
+			f := NewFormatter("table", mode, false)
+			if output := f.Format(results); strings.Contains(output, "Scanned") {
+				t.Errorf("footer shown although not enabled: %q", output)
+			}
+
+			f.SetFooter(true)
+			if output := f.Format(results); !strings.Contains(output, want) {
+				t.Errorf("output should contain footer %q, got %q", want, output)
+			}
+
+			f.SetPlain(true)
+			if output := f.Format(results); !strings.Contains(output, "\n"+want) {
+				t.Errorf("plain output should contain footer line %q, got %q", want, output)
+			}
+		})
+	}
+
+	f := NewFormatter("csv", "summary", false)
+	f.SetFooter(true)
+	if output := f.Format(results); strings.Contains(output, "Scanned") {
+		t.Errorf("footer should only be added to tables, got %q", output)
+	}
+}
+
+func TestFormatTotals(t *testing.T) {
+	results := &stat.Results{
+		ByYear: map[int]*stat.YearStat{
+			2023: {Year: 2023, TotalSize: 3072, TotalInodes: 3, Files: 3},
+			2024: {Year: 2024, TotalSize: 1024, TotalInodes: 1, Files: 1},
+		},
+		ByUID: map[uint32]*stat.UIDStat{
+			1000: {UID: 1000, Username: "alice", TotalSize: 3072, TotalInodes: 3, Files: 3},
+			1001: {UID: 1001, Username: "bob", TotalSize: 1024, TotalInodes: 1, Files: 1},
+		},
+	}
+
+	tests := []struct {
+		mode string
+		want []string
+	}{
+		{"per-year", []string{"2024\t1.0 KB\t25.0\t1", "2023\t3.0 KB\t75.0\t3", "Total\t4.0 KB\t100.0\t4\t4"}},
+		{"per-uid", []string{"1000\talice\t3.0 KB\t75.0\t3", "Total\t\t4.0 KB\t100.0\t4\t4"}},
+	}
+	for _, tt := range tests {
+		f := NewFormatter("table", tt.mode, false)
+		f.SetPlain(true)
+		if output := f.Format(results); strings.Contains(output, "Total") || strings.Contains(output, "Size %") {
+			t.Errorf("%s: totals shown although not enabled: %q", tt.mode, output)
+		}
+
+		f.SetTotals(true)
+		output := f.Format(results)
+		for _, want := range append(tt.want, "Size %") {
+			if !strings.Contains(output, want) {
+				t.Errorf("%s: output should contain %q, got %q", tt.mode, want, output)
+			}
+		}
+
+		// The totals include the rows beyond the limit
+		f.SetLimit(1)
+		if output := f.Format(results); !strings.Contains(output, tt.want[len(tt.want)-1]) {
+			t.Errorf("%s: limited output should contain totals %q, got %q", tt.mode, tt.want[len(tt.want)-1], output)
+		}
+	}
+}
+
+func TestFormatUnits(t *testing.T) {
+	results := &stat.Results{
+		ByUID: map[uint32]*stat.UIDStat{1000: {UID: 1000, Username: "alice", TotalSize: 1610612736, TotalInodes: 1, Files: 1, FilesSize: 1610612736}},
+	}
+
+	tests := []struct {
+		format string
+		units  textfmt.Units
+		want   string
+	}{
+		{"table", textfmt.UnitsBinary, "1000\talice\t1.5 GiB\t1"},
+		{"table", textfmt.UnitsSI, "1000\talice\t1.6 GB\t1"},
+		{"table", textfmt.UnitsRaw, "1000\talice\t1610612736\t1"},
+		{"csv", textfmt.UnitsSI, "1000,alice,1.6 GB,1,"},
+		{"csv", textfmt.UnitsRaw, "1000,alice,1610612736,1,"},
+	}
+	for _, tt := range tests {
+		f := NewFormatter(tt.format, "per-uid", false)
+		f.SetPlain(true)
+		f.SetUnits(tt.units)
+		if output := f.Format(results); !strings.Contains(output, tt.want) {
+			t.Errorf("%s output in units %d should contain %q, got %q", tt.format, tt.units, tt.want, output)
+		}
+	}
+}
+
+func TestFormatCounts(t *testing.T) {
+	results := &stat.Results{
+		Summary: &stat.SummaryStat{TotalSize: 1024, TotalInodes: 1234567, Files: 1234566, Dirs: 1, FilesSize: 1024},
+		ByUID:   map[uint32]*stat.UIDStat{1000: {UID: 1000, Username: "alice", TotalSize: 1024, TotalInodes: 1234567, Files: 1234566, Dirs: 1}},
+	}
+
+	tests := []struct {
+		format string
+		mode   string
+		counts textfmt.Counts
+		want   string
+	}{
+		{"table", "per-uid", textfmt.CountsGrouped, "1000\talice\t1.0 KB\t1.234.567"},
+		{"table", "per-uid", textfmt.CountsCompact, "1000\talice\t1.0 KB\t1.2M"},
+		{"table", "summary", textfmt.CountsGrouped, "Total Inodes\t1.234.567\t1.234.566"},
+		{"html", "summary", textfmt.CountsCompact, "<td>1.2M</td>"},
+		{"csv", "per-uid", textfmt.CountsGrouped, "1000,alice,1.0 KB,1234567,1234566,"},
+	}
+	for _, tt := range tests {
+		f := NewFormatter(tt.format, tt.mode, false)
+		f.SetPlain(true)
+		f.SetCounts(tt.counts, ".")
+		if output := f.Format(results); !strings.Contains(output, tt.want) {
+			t.Errorf("%s %s output with counts %d should contain %q, got %q", tt.format, tt.mode, tt.counts, tt.want, output)
+		}
+	}
+}
+
+func TestFormatColor(t *testing.T) {
+	results := &stat.Results{
+		ByUID: map[uint32]*stat.UIDStat{
+			1000: {UID: 1000, Username: "alice", TotalSize: 1 << 30, TotalInodes: 1, Files: 1},
+			1001: {UID: 1001, Username: "bob", TotalSize: 1, TotalInodes: 1, Files: 1}, // Dimmed
+		},
+	}
+
+	f := NewFormatter("table", "per-uid", false)
+	if output := f.Format(results); !strings.Contains(output, "\x1b[") {
+		t.Errorf("tables should be colored by default, got %q", output)
+	}
+
+	f.SetColor(false)
+	output := f.Format(results)
+	if strings.Contains(output, "\x1b") {
+		t.Errorf("tables without colors should have no escape sequences, got %q", output)
+	}
+	if !strings.Contains(output, "bob") {
+		t.Errorf("table should still list bob, got %q", output)
+	}
+}
+
+func TestFormatRawBytes(t *testing.T) {
+	results := &stat.Results{
+		Summary: &stat.SummaryStat{TotalSize: 1610612736, TotalInodes: 2, Files: 1, Dirs: 1, FilesSize: 1610612736},
+		ByYear: map[int]*stat.YearStat{
+			2024: {Year: 2024, TotalSize: 1610612736, TotalInodes: 1, Files: 1, FilesSize: 1610612736},
+			2023: {Year: 2023, TotalSize: 512, TotalInodes: 1, Files: 1, FilesSize: 512},
+		},
+	}
+
+	f := NewFormatter("table", "summary", false)
+	if output := f.Format(results); strings.Contains(output, "1610612736") {
+		t.Errorf("raw bytes shown although not enabled: %q", output)
+	}
+
+	f.SetRawBytes(true)
+	f.SetPlain(true)
+	if output := f.Format(results); !strings.Contains(output, "1.5 GB (1610612736)") {
+		t.Errorf("output should contain the exact byte count, got %q", output)
+	}
+
+	// Byte counts are right-aligned within the column
+	f = NewFormatter("table", "per-year", false)
+	f.SetRawBytes(true)
+	output := f.Format(results)
+	if !strings.Contains(output, "(1610612736)") || !strings.Contains(output, "(       512)") {
+		t.Errorf("byte counts should be aligned, got %q", output)
+	}
+
+	f = NewFormatter("csv", "per-year", false)
+	f.SetRawBytes(true)
+	if output := f.Format(results); strings.Contains(output, "(") {
+		t.Errorf("raw bytes should only be added to tables, got %q", output)
+	}
+}
+
+func TestFormatSymlinks(t *testing.T) {
+	results := &stat.Results{
+		Summary:       &stat.SummaryStat{},
+		SymlinkChains: &stat.SymlinkChainStat{Chains: 4, TotalDepth: 6, MaxDepth: 3, Loops: 1},
+	}
+
+	tests := []struct {
+		format string
+		want   []string
+	}{
+		{"json", []string{`"maxDepth": 3`, `"avgDepth": 1.5`, `"loops": 1`}},
+		{"csv", []string{"Max Chain Depth,3", "Avg Chain Depth,1.50", "Loops,1"}},
+		{"table", []string{"Max Chain Depth", "1.50", "Loops"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			output := NewFormatter(tt.format, "symlinks", false).Format(results)
+			for _, want := range tt.want {
+				if !strings.Contains(output, want) {
+					t.Errorf("output missing %q:\n%s", want, output)
+				}
+			}
+		})
+	}
+}
+
+func TestFormatSymlinkCheck(t *testing.T) {
+	results := &stat.Results{
+		Summary:       &stat.SummaryStat{},
+		SymlinkChains: &stat.SymlinkChainStat{Chains: 3, TotalDepth: 3, MaxDepth: 1},
+		Symlinks: &stat.SymlinkStat{
+			Symlinks: 3, Broken: 1, Absolute: 1, Relative: 2, Escaping: 1,
+			BrokenPaths:   []string{"/r/dangling"},
+			EscapingPaths: []string{"/r/etc-link"},
+		},
+	}
+
+	tests := []struct {
+		format string
+		want   []string
+	}{
+		{"json", []string{`"broken": 1`, `"/r/dangling"`, `"escapingPaths": [`, `"/r/etc-link"`}},
+		{"csv", []string{"Broken,1", "Absolute Targets,1", "Relative Targets,2", "Escaping Root,1"}},
+		{"table", []string{"Broken", "Escaping Root"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			output := NewFormatter(tt.format, "symlinks", false).Format(results)
+			for _, want := range tt.want {
+				if !strings.Contains(output, want) {
+					t.Errorf("output missing %q:\n%s", want, output)
+				}
+			}
+		})
+	}
+
+	output := NewFormatter("csv", "symlinks", false).Format(&stat.Results{Summary: &stat.SummaryStat{}})
+	if strings.Contains(output, "Broken") {
+		t.Errorf("unchecked output should not report broken links:\n%s", output)
+	}
+}
+
+func TestFormatRandomNames(t *testing.T) {
+	results := &stat.Results{
+		Summary: &stat.SummaryStat{},
+		NameEntropy: map[string]*stat.DirEntropyStat{
+			"spool": {Path: "spool", Entries: 20, Random: 18},
+			"docs":  {Path: "docs", Entries: 40, Random: 1},
+		},
+	}
+
+	for _, format := range []string{"json", "csv", "table"} {
+		t.Run(format, func(t *testing.T) {
+			output := NewFormatter(format, "random-names", false).Format(results)
+			if !strings.Contains(output, "spool") {
+				t.Errorf("output should list spool:\n%s", output)
+			}
+			if strings.Contains(output, "docs") {
+				t.Errorf("output should not list docs:\n%s", output)
+			}
+		})
+	}
+}
+
+func TestFormatWatchlist(t *testing.T) {
+	results := &stat.Results{
+		Summary: &stat.SummaryStat{},
+		WatchlistMatches: []stat.WatchlistMatch{
+			{Path: "share/budget.xlsx.locky", Pattern: ".locky"},
+		},
+	}
+
+	for _, format := range []string{"json", "csv", "table"} {
+		t.Run(format, func(t *testing.T) {
+			output := NewFormatter(format, "watchlist", false).Format(results)
+			if !strings.Contains(output, "share/budget.xlsx.locky") || !strings.Contains(output, ".locky") {
+				t.Errorf("output should list the match:\n%s", output)
+			}
+		})
+	}
+}
+
+func TestFormatChurn(t *testing.T) {
+	results := &stat.Results{
+		Summary: &stat.SummaryStat{},
+		Churn: &stat.ChurnStat{
+			Interval:      24 * time.Hour,
+			Added:         3,
+			Deleted:       1,
+			AddedBytes:    2048,
+			DeletedBytes:  1024,
+			ModifiedBytes: 1024,
+		},
+	}
+
+	tests := []struct {
+		format string
+		want   []string
+	}{
+		{"json", []string{`"added": 3`, `"turnoverBytes": 4096`, `"intervalSeconds": 86400`}},
+		{"csv", []string{"Added,3,2.0 KB", "Turnover,4,4.0 KB"}},
+		{"table", []string{"Added", "Turnover", "24h0m0s"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			output := NewFormatter(tt.format, "churn", false).Format(results)
+			for _, want := range tt.want {
+				if !strings.Contains(output, want) {
+					t.Errorf("output missing %q:\n%s", want, output)
+				}
+			}
+		})
+	}
+
+	output := NewFormatter("table", "churn", false).Format(&stat.Results{Summary: &stat.SummaryStat{}})
+	if !strings.Contains(output, "No churn data") {
+		t.Errorf("expected explanatory message without churn data, got:\n%s", output)
+	}
+}
+
 func TestFormatJSON(t *testing.T) {
 	f := NewFormatter("json", "summary", false)
 
@@ -229,54 +654,551 @@ func TestFormatterFields(t *testing.T) {
 	}
 }
 
-func TestFormatAlignedColumnThreshold(t *testing.T) {
+func TestFormatPerFS(t *testing.T) {
+	results := &stat.Results{
+		Summary: &stat.SummaryStat{},
+		ByFS: map[uint64]*stat.FSStat{
+			2049: {Dev: 2049, Mountpoint: "/", Type: "ext4", Source: "/dev/sda1", TotalSize: 2048, TotalInodes: 3, Files: 2, Dirs: 1},
+			2065: {Dev: 2065, Mountpoint: "/srv", Type: "xfs", Source: "/dev/sdb1", TotalSize: 1024, TotalInodes: 1, Files: 1},
+		},
+	}
+
 	tests := []struct {
-		name      string
-		values    []int64
-		isBytes   bool
-		shouldHas bool   // Whether output should contain "<"
-		checkDim  bool   // Whether to check for dimming ANSI code
+		format string
+		want   []string
 	}{
-		{
-			name:      "bytes below threshold",
-			values:    []int64{1024 * 1024, 100}, // 1MB, 100B - 100B is 0.00 MB
-			isBytes:   true,
-			shouldHas: true,
-			checkDim:  true,
+		{"json", []string{`"mountpoint": "/srv"`, `"type": "xfs"`, `"inodes": 3`}},
+		{"csv", []string{"/,ext4,/dev/sda1,2049,2.0 KB,3,2,1,0,0", "/srv,xfs,/dev/sdb1,2065,1.0 KB,1,1,0,0,0"}},
+		{"table", []string{"/dev/sda1", "/srv", "xfs"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			output := NewFormatter(tt.format, "per-fs", false).Format(results)
+			for _, want := range tt.want {
+				if !strings.Contains(output, want) {
+					t.Errorf("output missing %q:\n%s", want, output)
+				}
+			}
+		})
+	}
+}
+
+func TestFormatInodeUsage(t *testing.T) {
+	results := &stat.Results{
+		Summary: &stat.SummaryStat{},
+		Usage: []stat.UsageStat{{
+			Root:         "/data",
+			TotalBytes:   4096,
+			FreeBytes:    1024,
+			AvailBytes:   1024,
+			TotalInodes:  1000,
+			FreeInodes:   100,
+			WalkedSize:   2048,
+			WalkedInodes: 600,
+		}},
+	}
+
+	tests := []struct {
+		format string
+		want   []string
+	}{
+		{"json", []string{`"usedInodes": 900`, `"inodesPct": 90`, `"bytesPct": 75`}},
+		{"csv", []string{"/data,600,900,1000,90,2.0 KB,3.0 KB,1.0 KB,4.0 KB,75"}},
+		{"table", []string{"/data", "90.0", "75.0"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			output := NewFormatter(tt.format, "inode-usage", false).Format(results)
+			for _, want := range tt.want {
+				if !strings.Contains(output, want) {
+					t.Errorf("output missing %q:\n%s", want, output)
+				}
+			}
+		})
+	}
+
+	output := NewFormatter("table", "inode-usage", false).Format(&stat.Results{Summary: &stat.SummaryStat{}})
+	if !strings.Contains(output, "No file system usage") {
+		t.Errorf("expected explanatory message without usage, got:\n%s", output)
+	}
+}
+
+func TestFormatXattrs(t *testing.T) {
+	results := &stat.Results{
+		Summary: &stat.SummaryStat{},
+		Xattrs: &stat.XattrStat{
+			Entries: 3,
+			Bytes:   2048,
+			ACLs:    1,
+			ByName: map[string]*stat.XattrNameStat{
+				"user.backup":             {Name: "user.backup", Entries: 2, Bytes: 1024},
+				"system.posix_acl_access": {Name: "system.posix_acl_access", Entries: 1, Bytes: 1024},
+			},
 		},
-		{
-			name:      "all byte values above threshold",
-			values:    []int64{1024 * 1024, 1024 * 1024 / 2}, // 1MB, 0.5MB
-			isBytes:   true,
-			shouldHas: false,
-			checkDim:  false,
+	}
+
+	tests := []struct {
+		format string
+		want   []string
+	}{
+		{"json", []string{`"acls": 1`, `"name": "user.backup"`}},
+		{"csv", []string{"Any attribute,3,2.0 KB", "POSIX ACL,1,\n", "user.backup,2,1.0 KB\nsystem.posix_acl_access,1,1.0 KB"}},
+		{"table", []string{"POSIX ACL", "user.backup"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			output := NewFormatter(tt.format, "xattrs", false).Format(results)
+			for _, want := range tt.want {
+				if !strings.Contains(output, want) {
+					t.Errorf("output missing %q:\n%s", want, output)
+				}
+			}
+		})
+	}
+}
+
+func TestFormatSELinux(t *testing.T) {
+	results := &stat.Results{
+		Summary: &stat.SummaryStat{TotalInodes: 5, TotalSize: 4096},
+		ByLabel: map[string]*stat.LabelStat{
+			"system_u:object_r:httpd_sys_content_t:s0": {Label: "system_u:object_r:httpd_sys_content_t:s0", Entries: 3, Size: 2048},
+			"system_u:object_r:user_home_t:s0":         {Label: "system_u:object_r:user_home_t:s0", Entries: 1, Size: 1024},
 		},
 	}
 
+	tests := []struct {
+		format string
+		want   []string
+	}{
+		{"json", []string{`"type": "httpd_sys_content_t"`, `"unlabeledEntries": 1`}},
+		{"csv", []string{"system_u:object_r:httpd_sys_content_t:s0,httpd_sys_content_t,3,2.0 KB\nsystem_u:object_r:user_home_t:s0", "(unlabeled),,1,1.0 KB"}},
+		{"table", []string{"httpd_sys_content_t", "(unlabeled)"}},
+	}
+
 	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			result := formatAlignedColumn(tt.values, tt.isBytes)
-			
-			hasLess := false
-			hasDimming := false
-			for _, v := range result {
-				if strings.Contains(v, "<") {
-					hasLess = true
-					if strings.Contains(v, "\x1b[90m") {
-						hasDimming = true
-					}
+		t.Run(tt.format, func(t *testing.T) {
+			output := NewFormatter(tt.format, "selinux", false).Format(results)
+			for _, want := range tt.want {
+				if !strings.Contains(output, want) {
+					t.Errorf("output missing %q:\n%s", want, output)
+				}
+			}
+		})
+	}
+}
+
+func TestFormatAudit(t *testing.T) {
+	results := &stat.Results{AllFileInfos: []stat.FileInfo{
+		{Root: "/r", Path: "shared", Mode: os.ModeDir | 0o777, IsDir: true},
+		{Root: "/r", Path: "su", Mode: os.ModeSetuid | 0o755, Size: 1024},
+		{Root: "/r", Path: "link", Mode: os.ModeSymlink | 0o777, IsSymlink: true, Dangling: true},
+	}}
+
+	tests := []struct {
+		format string
+		want   []string
+	}{
+		{"json", []string{`"worldWritable": [`, `"path": "/r/su"`, `"orphaned": []`, `"danglingSymlinks": [`}},
+		{"csv", []string{"Section,Path,Type,Mode,Owner,Group,Size\n", "World-writable,/r/shared,dir,drwxrwxrwx,", "Setuid/setgid,/r/su,file,-rwsr-xr-x,", "Dangling symlink,/r/link,symlink,"}},
+		{"table", []string{"World-writable", "Setuid/setgid", "Dangling symlink", "/r/link"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			output := NewFormatter(tt.format, "audit", false).Format(results)
+			for _, want := range tt.want {
+				if !strings.Contains(output, want) {
+					t.Errorf("output missing %q:\n%s", want, output)
+				}
+			}
+		})
+	}
+}
+
+func TestFormatHomes(t *testing.T) {
+	mtime := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+	results := &stat.Results{AllFileInfos: []stat.FileInfo{
+		{Root: "/home", Path: "root", Mode: os.ModeDir | 0o700, IsDir: true},
+		{Root: "/home", Path: "root/a", Mode: 0o644, Size: 2048, ModTime: mtime},
+		{Root: "/home", Path: "root/b", Mode: 0o644, Size: 10, UID: 3999999, ModTime: mtime.Add(time.Hour)},
+		{Root: "/home", Path: "empty", Mode: os.ModeDir | 0o700, IsDir: true},
+	}}
+
+	tests := []struct {
+		format string
+		want   []string
+	}{
+		{"json", []string{`"homes": [`, `"path": "/home/root"`, `"oldestPath": "/home/root/a"`, `"foreignEntries": 1`, `"oldestPath": null`, `"1 entries owned by uid:3999999"`}},
+		{"csv", []string{"Home,Owner,Size,Inodes,Oldest File,Oldest Modified,Foreign,Foreign Size,Warnings\n", "/home/root,root,2.0 KB,3,/home/root/a,2020-05-01T12:00:00Z,1,10 B,1 entries owned by uid:3999999\n", "/home/empty,root,0 B,1,,,0,0 B,\"owned by root, not empty\"\n"}},
+		{"table", []string{"HOME", "/home/root", "2020-05-01 12:00", "owned by root, not empty"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			output := NewFormatter(tt.format, "homes", false).Format(results)
+			for _, want := range tt.want {
+				if !strings.Contains(output, want) {
+					t.Errorf("output missing %q:\n%s", want, output)
+				}
+			}
+		})
+	}
+}
+
+func TestFormatQuota(t *testing.T) {
+	results := &stat.Results{Quotas: []stat.QuotaStat{
+		{Quota: stat.Quota{User: "alice", Limit: 1024}, Used: 2048, Inodes: 3},
+		{Quota: stat.Quota{Dir: "/srv/x", Limit: 4096}, Used: 1024, Inodes: 1},
+	}}
+
+	tests := []struct {
+		format string
+		want   []string
+	}{
+		{"json", []string{`"quota": [`, `"target": "alice"`, `"usedPct": 200`, `"exceeded": true`, `"type": "dir"`, `"exceeded": false`}},
+		{"csv", []string{"Target,Type,Limit,Used,Inodes,UsedPct,Status\nalice,user,1.0 KB,2.0 KB,3,200,EXCEEDED\n/srv/x,dir,4.0 KB,1.0 KB,1,25,ok\n"}},
+		{"table", []string{"TARGET", "alice", "EXCEEDED", "/srv/x"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			output := NewFormatter(tt.format, "quota", false).Format(results)
+			for _, want := range tt.want {
+				if !strings.Contains(output, want) {
+					t.Errorf("output missing %q:\n%s", want, output)
 				}
 			}
-			
-			if hasLess != tt.shouldHas {
-				t.Errorf("formatAlignedColumn(%v, %v) has '<'=%v, want %v. Output: %v", 
-					tt.values, tt.isBytes, hasLess, tt.shouldHas, result)
+		})
+	}
+
+	if output := NewFormatter("table", "quota", false).Format(&stat.Results{}); !strings.Contains(output, "--quota-file") {
+		t.Errorf("without quotas: %q", output)
+	}
+}
+
+func TestFormatProjects(t *testing.T) {
+	mtime := time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC)
+	results := &stat.Results{
+		ProjectDirs: map[string][]string{"/src/app": {".git"}},
+		AllFileInfos: []stat.FileInfo{
+			{Root: "/src", Path: "app", Mode: os.ModeDir | 0o755, IsDir: true},
+			{Root: "/src", Path: "app/main.go", Mode: 0o644, Size: 3072, ModTime: mtime},
+			{Root: "/src", Path: "notes", Mode: 0o644, Size: 1024},
+		},
+	}
+
+	tests := []struct {
+		format string
+		want   []string
+	}{
+		{"json", []string{`"projects": [`, `"path": "/src/app"`, `"markers": [`, `"path": null`, `"newest": null`}},
+		{"csv", []string{"Project,Markers,Size,Size %,Inodes,Files,Newest\n/src/app,.git,3.0 KB,75,2,1,2024-03-01T08:00:00Z\n(no project),,1.0 KB,25,1,1,\n"}},
+		{"table", []string{"PROJECT", "/src/app", "(no project)", "2024-03-01 08:00"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			output := NewFormatter(tt.format, "projects", false).Format(results)
+			for _, want := range tt.want {
+				if !strings.Contains(output, want) {
+					t.Errorf("output missing %q:\n%s", want, output)
+				}
 			}
-			
-			if tt.checkDim && tt.shouldHas && !hasDimming {
-				t.Errorf("formatAlignedColumn(%v, %v) has '<' but not dimmed. Output: %v",
-					tt.values, tt.isBytes, result)
+		})
+	}
+}
+
+func TestFormatOwnership(t *testing.T) {
+	results := &stat.Results{AllFileInfos: []stat.FileInfo{
+		{Root: "/srv", Path: "shared", Mode: os.ModeDir | 0o775, IsDir: true},
+		{Root: "/srv", Path: "shared/a", Mode: 0o644, Size: 3072, UID: 3999998},
+		{Root: "/srv", Path: "shared/b", Mode: 0o644, Size: 1024, UID: 3999999},
+	}}
+
+	tests := []struct {
+		format string
+		want   []string
+	}{
+		{"json", []string{`"ownership": [`, `"path": "/srv/shared"`, `"owners": 3`, `"uid": 3999998`, `"ownerPct": 75`, `"entropy": 0.811`}},
+		{"csv", []string{"Directory,Owner,Owner Size,Owner %,Owners,Entropy,Size,Inodes\n/srv/shared,uid:3999998,3.0 KB,75,3,0.811,4.0 KB,3\n"}},
+		{"table", []string{"DIRECTORY", "/srv/shared", "uid:3999998", "0.81"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			output := NewFormatter(tt.format, "ownership", false).Format(results)
+			for _, want := range tt.want {
+				if !strings.Contains(output, want) {
+					t.Errorf("output missing %q:\n%s", want, output)
+				}
 			}
 		})
 	}
 }
+
+func TestFormatLustre(t *testing.T) {
+	results := &stat.Results{AllFileInfos: []stat.FileInfo{
+		{Path: "a", Mode: 0o644, Size: 3072, ProjectID: 7, StripeCount: 1, StripeSize: 1 << 20},
+		{Path: "b", Mode: 0o644, Size: 1024, ProjectID: 7, StripeCount: 4, StripeSize: 1 << 20},
+		{Path: "c", Mode: 0o644, Size: 10},
+	}}
+
+	tests := []struct {
+		format, mode string
+		want         []string
+	}{
+		{"json", "per-project-id", []string{`"perProjectId": [`, `"projectId": 7`, `"size": 4096`, `"projectId": 0`}},
+		{"csv", "per-project-id", []string{"Project ID,Size,Size %,Inodes,Files\n7,4.0 KB,99.76,2,2\n0,10 B,0.24,1,1\n"}},
+		{"table", "per-project-id", []string{"PROJECT ID", "99.8"}},
+		{"json", "striping", []string{`"striping": [`, `"stripeCount": 0`, `"stripeCount": 4`, `"stripeSize": 1048576`, `"largest": 3072`}},
+		{"csv", "striping", []string{"Stripe Count,Stripe Size,Files,Size,Size %,Largest\n-,0 B,1,10 B,0.24,10 B\n1,1.0 MB,1,3.0 KB,74.82,3.0 KB\n"}},
+		{"table", "striping", []string{"STRIPE COUNT", "74.8"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format+" "+tt.mode, func(t *testing.T) {
+			output := NewFormatter(tt.format, tt.mode, false).Format(results)
+			for _, want := range tt.want {
+				if !strings.Contains(output, want) {
+					t.Errorf("output missing %q:\n%s", want, output)
+				}
+			}
+		})
+	}
+}
+
+func TestFormatGroups(t *testing.T) {
+	results := &stat.Results{
+		GroupColumns: []string{"uid", "year"},
+		Groups: map[string]*stat.GroupStat{
+			"1000\x002020": {Keys: []string{"1000", "2020"}, TotalSize: 100, TotalInodes: 2, Files: 2, FilesSize: 100},
+			"1000\x002024": {Keys: []string{"1000", "2024"}, TotalSize: 5000, TotalInodes: 1, Files: 1, FilesSize: 5000},
+			"0\x002024":    {Keys: []string{"0", "2024"}, TotalSize: 10, TotalInodes: 1, Dirs: 1},
+		},
+	}
+
+	tests := []struct {
+		format string
+		sort   SortKey
+		want   []string
+	}{
+		{"json", SortByGroup, []string{`"dimensions": [`, `"uid": "1000"`, `"year": "2020"`}},
+		{"csv", SortByGroup, []string{"UID,Year,Size,Inodes,Files,Dirs,Symlinks,Others\n0,2024,10 B,1,0,1,0,0\n1000,2020,100 B,2,2,0,0,0\n1000,2024,4.9 KB,1,1,0,0,0\n"}},
+		{"csv", SortBySize, []string{"Others\n1000,2024,4.9 KB,"}},
+		{"table", SortByGroup, []string{"UID", "YEAR", "2020"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			f := NewFormatter(tt.format, "groups", false)
+			f.SetSort(tt.sort)
+			output := f.Format(results)
+			for _, want := range tt.want {
+				if !strings.Contains(output, want) {
+					t.Errorf("output missing %q:\n%s", want, output)
+				}
+			}
+		})
+	}
+}
+
+func TestFormatEmpty(t *testing.T) {
+	results := &stat.Results{AllFileInfos: []stat.FileInfo{
+		{Root: "/r", Path: "tmp", Mode: os.ModeDir | 0o755, IsDir: true, EmptyDir: true},
+		{Root: "/r", Path: "full", Mode: os.ModeDir | 0o755, IsDir: true},
+		{Root: "/r", Path: "lock", Mode: 0o644},
+		{Root: "/r", Path: "data", Mode: 0o644, Size: 10},
+	}}
+
+	tests := []struct {
+		format string
+		want   []string
+	}{
+		{"json", []string{`"emptyFiles": 1`, `"emptyDirs": 1`, `"total": 2`, `"path": "/r/lock"`}},
+		{"csv", []string{"Type,Path,Owner,Group,Modified\n", "file,/r/lock,", "dir,/r/tmp,"}},
+		{"table", []string{"/r/lock", "/r/tmp", "1 empty files, 1 empty dirs"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			output := NewFormatter(tt.format, "empty", false).Format(results)
+			for _, want := range tt.want {
+				if !strings.Contains(output, want) {
+					t.Errorf("output missing %q:\n%s", want, output)
+				}
+			}
+			for _, unwanted := range []string{"/r/full", "/r/data"} {
+				if strings.Contains(output, unwanted) {
+					t.Errorf("output contains non-empty entry %q", unwanted)
+				}
+			}
+		})
+	}
+}
+
+func TestFormatFanOut(t *testing.T) {
+	results := &stat.Results{FanOut: &stat.FanOutStat{
+		Dirs:       3,
+		Entries:    150003,
+		MaxEntries: 150000,
+		MaxPath:    "/r/flat",
+		Huge:       1,
+		HugePaths:  []string{"/r/flat"},
+		Buckets:    []int64{0, 2, 0, 0, 0, 0, 1},
+	}}
+
+	tests := []struct {
+		format string
+		want   []string
+	}{
+		{"json", []string{`"avgFanOut": 50001`, `"maxPath": "/r/flat"`, `"hugePaths": [`, `"entries": "100k+"`}},
+		{"csv", []string{"Avg Fan-Out,50001.00\n", "Dirs with 1-9 Entries,2\n", "Huge Directory,/r/flat\n"}},
+		{"table", []string{"Fullest Directory", "/r/flat"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			output := NewFormatter(tt.format, "fan-out", false).Format(results)
+			for _, want := range tt.want {
+				if !strings.Contains(output, want) {
+					t.Errorf("output missing %q:\n%s", want, output)
+				}
+			}
+		})
+	}
+}
+
+func TestFormatPerDepth(t *testing.T) {
+	results := &stat.Results{ByDepth: map[int]*stat.DepthStat{
+		0: {Depth: 0, TotalInodes: 1, Dirs: 1},
+		1: {Depth: 1, TotalSize: 3000, TotalInodes: 3, Files: 2, Dirs: 1, FilesSize: 3000},
+	}}
+
+	tests := []struct {
+		format string
+		want   []string
+	}{
+		{"json", []string{`"depth": 1`, `"cumInodesPct": 25`, `"cumInodesPct": 100`}},
+		{"csv", []string{"Depth,Size,Inodes,Files,Dirs,Symlinks,Others,FilesSize,CumInodesPct\n", "0,0 B,1,0,1,0,0,0 B,25\n", "1,2.9 KB,3,2,1,0,0,2.9 KB,100\n"}},
+		{"table", []string{"CUM. INODES", "25.0%", "100.0%"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			output := NewFormatter(tt.format, "per-depth", false).Format(results)
+			for _, want := range tt.want {
+				if !strings.Contains(output, want) {
+					t.Errorf("output missing %q:\n%s", want, output)
+				}
+			}
+		})
+	}
+}
+
+func TestFormatBaseline(t *testing.T) {
+	prev := &stat.Results{
+		ByYear: map[int]*stat.YearStat{
+			2023: {Year: 2023, TotalSize: 1000, TotalInodes: 1, Files: 1},
+			2024: {Year: 2024, TotalSize: 1000, TotalInodes: 1, Files: 1},
+		},
+		ByUID: map[uint32]*stat.UIDStat{
+			1000: {UID: 1000, Username: "alice", TotalSize: 2000, TotalInodes: 2, Files: 2},
+		},
+		GroupColumns: []string{"uid", "year"},
+		Groups: map[string]*stat.GroupStat{
+			"1000\x002024": {Keys: []string{"1000", "2024"}, TotalSize: 1000, TotalInodes: 1, Files: 1},
+		},
+	}
+	f := NewFormatter("json", "per-year", false)
+	f.SetModes([]string{"per-year", "per-uid", "groups"})
+	path := filepath.Join(t.TempDir(), "prev.json")
+	if err := os.WriteFile(path, []byte(f.Format(prev)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	baseline, err := LoadBaseline(path)
+	if err != nil {
+		t.Fatalf("LoadBaseline failed: %v", err)
+	}
+
+	cur := &stat.Results{
+		ByYear: map[int]*stat.YearStat{
+			2023: {Year: 2023, TotalSize: 1050, TotalInodes: 1, Files: 1},
+			2024: {Year: 2024, TotalSize: 500, TotalInodes: 1, Files: 1},
+			2025: {Year: 2025, TotalSize: 300, TotalInodes: 1, Files: 1},
+		},
+		ByUID: map[uint32]*stat.UIDStat{
+			1000: {UID: 1000, Username: "alice", TotalSize: 3000, TotalInodes: 3, Files: 3},
+		},
+		GroupColumns: []string{"uid", "year"},
+		Groups: map[string]*stat.GroupStat{
+			"1000\x002024": {Keys: []string{"1000", "2024"}, TotalSize: 1100, TotalInodes: 1, Files: 1},
+		},
+	}
+
+	tests := []struct {
+		mode string
+		want []string
+	}{
+		{"per-year", []string{"Size Δ\tGrowth %", "2025\t300 B\t16.2\tnew\t\t1", "2024\t500 B\t27.0\t-500 B\t-50.0%\t1", "2023\t1.0 KB\t56.8\t+50 B\t+5.0%\t1", "Total\t1.8 KB\t100.0\t-150 B\t-7.5%\t3"}},
+		{"per-uid", []string{"1000\talice\t2.9 KB\t100.0\t+1000 B\t+50.0% !\t3"}},
+		{"groups", []string{"UID\tYear\tSize\tSize Δ\tGrowth %\tInodes", "1000\t2024\t1.1 KB\t+100 B\t+10.0%\t1"}},
+	}
+	for _, tt := range tests {
+		f := NewFormatter("table", tt.mode, false)
+		f.SetPlain(true)
+		f.SetTotals(true)
+		f.SetBaseline(baseline, 10)
+		output := f.Format(cur)
+		for _, want := range tt.want {
+			if !strings.Contains(output, want) {
+				t.Errorf("%s: output should contain %q, got:\n%s", tt.mode, want, output)
+			}
+		}
+	}
+
+	// Groups of other dimensions have nothing to compare to
+	cur.GroupColumns = []string{"uid"}
+	f = NewFormatter("table", "groups", false)
+	f.SetBaseline(baseline, 10)
+	if output := f.Format(cur); strings.Contains(output, "GROWTH") {
+		t.Errorf("groups of other dimensions compared:\n%s", output)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"schemaVersion": 1, "modes": ["summary"], "summary": {}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadBaseline(path); err == nil || !strings.Contains(err.Error(), "no perYear, perUid or groups section") {
+		t.Errorf("baseline without groups: got error %v", err)
+	}
+}
+
+func TestFormatPhysical(t *testing.T) {
+	results := &stat.Results{Summary: &stat.SummaryStat{TotalSize: 4096, TotalInodes: 1, Files: 1, FilesSize: 4096, PhysicalSize: 1024}}
+
+	tests := []struct {
+		format string
+		want   []string
+	}{
+		{"json", []string{`"physicalSize": 1024`, `"compressionRatio": 4`}},
+		{"csv", []string{"Physical Size,1.0 KB (25.0% of 4.0 KB logical)", "Compression Ratio,4.00x"}},
+		{"table", []string{"Physical Size", "4.00x"}},
+		{"html", []string{"Compression Ratio"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			output := NewFormatter(tt.format, "summary", false).Format(results)
+			for _, want := range tt.want {
+				if !strings.Contains(output, want) {
+					t.Errorf("output missing %q:\n%s", want, output)
+				}
+			}
+		})
+	}
+
+	// Not collected
+	results.Summary.PhysicalSize = 0
+	if output := NewFormatter("json", "summary", false).Format(results); strings.Contains(output, "physicalSize") {
+		t.Errorf("physical size reported without being collected:\n%s", output)
+	}
+}
@@ -0,0 +1,161 @@
+package output
+
+import (
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/otuschhoff/cwalk/pkg/stat"
+)
+
+// groupMetricsOf returns the counters of a cross-tabulated group.
+func groupMetricsOf(s *stat.GroupStat) groupMetrics {
+	return groupMetrics{s.TotalSize, s.TotalInodes, s.Files, s.Dirs, s.Symlinks, s.FilesSize}
+}
+
+// sortedGroups returns the cross-tabulated groups in output order: by keys
+// unless SetSort selected a column, largest first, or the year dimension.
+func (f *Formatter) sortedGroups(results *stat.Results) []*stat.GroupStat {
+	groups := results.SortedGroups()
+	switch f.sort {
+	case SortByGroup, SortByName:
+	case SortByYear:
+		if col := slices.Index(results.GroupColumns, "year"); col >= 0 {
+			// Unknown years do not parse and count as 0
+			sort.SliceStable(groups, func(i, j int) bool {
+				a, _ := strconv.Atoi(groups[i].Keys[col])
+				b, _ := strconv.Atoi(groups[j].Keys[col])
+				return a > b
+			})
+		}
+	default:
+		sort.SliceStable(groups, func(i, j int) bool {
+			return groupMetricsOf(groups[i]).value(f.sort) > groupMetricsOf(groups[j]).value(f.sort)
+		})
+	}
+	if f.reverse {
+		slices.Reverse(groups)
+	}
+	return limited(f, groups)
+}
+
+// jsonGroups returns the groups section of JSON output, in the order of
+// the other formats.
+func (f *Formatter) jsonGroups(results *stat.Results) *JSONGroups {
+	groups := f.sortedGroups(results)
+	cols := results.GroupColumns
+	data := &JSONGroups{
+		Dimensions: append([]string{}, cols...),
+		Groups:     make([]JSONGroup, 0, len(groups)),
+	}
+	for _, gs := range groups {
+		keys := make(map[string]string, len(cols))
+		for i, col := range cols {
+			keys[col] = gs.Keys[i]
+		}
+		data.Groups = append(data.Groups, JSONGroup{
+			Keys:      keys,
+			Size:      gs.TotalSize,
+			Inodes:    gs.TotalInodes,
+			Files:     gs.Files,
+			Dirs:      gs.Dirs,
+			Symlinks:  gs.Symlinks,
+			Others:    gs.Others,
+			FilesSize: gs.FilesSize,
+			Extremes:  jsonExtremes(&gs.Extremes),
+		})
+	}
+	return data
+}
+
+// formatGroups formats cross-tabulated statistics, one row per combination
+// of dimension values, such as owner and year. The dimension columns come
+// first, in the order they were given, followed by the key of a group-by
+// expression.
+func (f *Formatter) formatGroups(results *stat.Results) string {
+	groups := f.sortedGroups(results)
+	cols := results.GroupColumns
+
+	headers := make([]string, 0, len(cols)+6)
+	for _, col := range cols {
+		headers = append(headers, dimensionHeader(col))
+	}
+	headers = append(headers, "Size", "Inodes", "Files", "Dirs", "Symlinks", "Others")
+
+	switch f.format {
+	case "csv", "xlsx":
+		data := make([]map[string]interface{}, 0, len(groups))
+		for _, gs := range groups {
+			row := map[string]interface{}{
+				"Size":     byteSize(gs.TotalSize),
+				"Inodes":   gs.TotalInodes,
+				"Files":    gs.Files,
+				"Dirs":     gs.Dirs,
+				"Symlinks": gs.Symlinks,
+				"Others":   gs.Others,
+			}
+			for i := range cols {
+				row[headers[i]] = gs.Keys[i]
+			}
+			data = append(data, row)
+		}
+		if f.format == "csv" {
+			return f.toCSV(headers, data)
+		}
+		return f.toXLSX(headers, data)
+	}
+
+	t := table.NewWriter()
+	if f.groupsBaseline(results) != nil {
+		headers = slices.Insert(headers, len(cols)+1, "Size Δ", "Growth %")
+	}
+	if !f.noHeader {
+		headerRow := make(table.Row, len(headers))
+		for i, h := range headers {
+			headerRow[i] = h
+		}
+		t.AppendHeader(headerRow)
+	}
+
+	columns := make([][]int64, 6)
+	keys := make([]string, 0, len(groups))
+	for _, gs := range groups {
+		for i, v := range []int64{gs.TotalSize, gs.TotalInodes, gs.Files, gs.Dirs, gs.Symlinks, gs.Others} {
+			columns[i] = append(columns[i], v)
+		}
+		keys = append(keys, groupKey(gs.Keys))
+	}
+	formatted := make([][]string, len(columns))
+	for i, values := range columns {
+		formatted[i] = f.column(values, i == 0)
+	}
+	deltaCol, growthCol := f.growthColumns(f.groupsBaseline(results), keys, columns[0])
+
+	for idx, gs := range groups {
+		row := make(table.Row, 0, len(headers))
+		for _, key := range gs.Keys {
+			row = append(row, key)
+		}
+		row = append(row, formatted[0][idx])
+		if deltaCol != nil {
+			row = append(row, deltaCol[idx], growthCol[idx])
+		}
+		for _, col := range formatted[1:] {
+			row = append(row, col[idx])
+		}
+		t.AppendRow(row)
+	}
+
+	return f.render(t, len(headers), &results.Scan)
+}
+
+// dimensionHeader returns the column header of a dimension, matching the
+// per-UID headers: "UID" and "GID" in upper case, other names capitalized.
+func dimensionHeader(dim string) string {
+	if dim == "uid" || dim == "gid" {
+		return strings.ToUpper(dim)
+	}
+	return strings.ToUpper(dim[:1]) + dim[1:]
+}
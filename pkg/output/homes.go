@@ -0,0 +1,95 @@
+package output
+
+import (
+	"strings"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/otuschhoff/cwalk/pkg/stat"
+)
+
+// jsonHomes returns the homes section of JSON output.
+func jsonHomes(results *stat.Results) []JSONHome {
+	homes := results.Homes()
+	rows := make([]JSONHome, 0, len(homes))
+	for _, h := range homes {
+		row := JSONHome{
+			Path:           h.Path,
+			UID:            h.UID,
+			Owner:          h.Owner,
+			Size:           h.Size,
+			Inodes:         h.Inodes,
+			ForeignEntries: h.Foreign,
+			ForeignSize:    h.ForeignSize,
+			Warnings:       append([]string{}, h.Warnings...),
+		}
+		if h.OldestPath != "" {
+			row.OldestPath, row.OldestMtime = ptr(h.OldestPath), ptr(h.OldestMtime)
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// formatHomes formats the per-home report: each directory directly below
+// a root with its owner, size, inodes, oldest file, the entries owned by
+// other users and the owner mismatch warnings, joined by "; ".
+func (f *Formatter) formatHomes(results *stat.Results) string {
+	homes := results.Homes()
+	if len(homes) == 0 {
+		return "No homes: no directories directly below the scanned roots\n"
+	}
+
+	headers := []string{"Home", "Owner", "Size", "Inodes", "Oldest File", "Oldest Modified", "Foreign", "Foreign Size", "Warnings"}
+	switch f.format {
+	case "csv", "xlsx":
+		data := make([]map[string]interface{}, 0, len(homes))
+		for _, h := range homes {
+			row := map[string]interface{}{
+				"Home":            h.Path,
+				"Owner":           h.Owner,
+				"Size":            byteSize(h.Size),
+				"Inodes":          h.Inodes,
+				"Oldest File":     h.OldestPath,
+				"Oldest Modified": "",
+				"Foreign":         h.Foreign,
+				"Foreign Size":    byteSize(h.ForeignSize),
+				"Warnings":        strings.Join(h.Warnings, "; "),
+			}
+			if h.OldestPath != "" {
+				row["Oldest Modified"] = h.OldestMtime
+			}
+			data = append(data, row)
+		}
+		if f.format == "csv" {
+			return f.toCSV(headers, data)
+		}
+		return f.toXLSX(headers, data)
+	}
+
+	n := len(homes)
+	sizes, inodes, foreign, foreignSizes := make([]int64, n), make([]int64, n), make([]int64, n), make([]int64, n)
+	for i, h := range homes {
+		sizes[i], inodes[i], foreign[i], foreignSizes[i] = h.Size, h.Inodes, h.Foreign, h.ForeignSize
+	}
+	sizeCol := f.column(sizes, true)
+	inodeCol := f.column(inodes, false)
+	foreignCol := f.column(foreign, false)
+	foreignSizeCol := f.column(foreignSizes, true)
+
+	t := table.NewWriter()
+	if !f.noHeader {
+		t.AppendHeader(table.Row{"Home", "Owner", "Size", "Inodes", "Oldest File", "Oldest Modified", "Foreign", "Foreign Size", "Warnings"})
+	}
+	for i, h := range homes {
+		var oldest string
+		if h.OldestPath != "" {
+			oldest = h.OldestMtime.Format("2006-01-02 15:04")
+		}
+		t.AppendRow(table.Row{
+			h.Path, h.Owner, sizeCol[i], inodeCol[i], h.OldestPath, oldest,
+			foreignCol[i], foreignSizeCol[i], strings.Join(h.Warnings, "; "),
+		})
+	}
+
+	return f.render(t, len(headers), &results.Scan)
+}
@@ -0,0 +1,489 @@
+package output
+
+import (
+	"fmt"
+	"html/template"
+	"math"
+	"path"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/otuschhoff/cwalk/pkg/stat"
+)
+
+// Limits of the HTML report charts; smaller entries are merged into a
+// single "other" tile or slice.
+const (
+	htmlTreemapTiles = 30
+	htmlPieSlices    = 8
+)
+
+// Size of the chart drawing areas in SVG user units.
+const (
+	htmlTreemapWidth, htmlTreemapHeight = 960, 420
+	htmlBarsWidth, htmlBarsHeight       = 960, 260
+	htmlPieRadius                       = 120
+)
+
+// htmlPalette holds the fill colors of tiles and slices, used in turn.
+var htmlPalette = []string{
+	"#4e79a7", "#f28e2b", "#e15759", "#76b7b2", "#59a14f",
+	"#edc948", "#b07aa1", "#ff9da7", "#9c755f", "#bab0ac",
+}
+
+// htmlOtherColor is the fill color of merged "other" tiles and slices.
+const htmlOtherColor = "#d3d3d3"
+
+// htmlReport is the data the HTML report template is executed on. Chart
+// geometry is computed up front, so the report is plain SVG without
+// scripts.
+type htmlReport struct {
+	Generated string
+	Summary   [][2]string
+	Scan      [][2]string
+	Stats     [][2]string // Performance of the scan, only with the stats mode
+	Treemap   []htmlTile
+	Bars      []htmlBar
+	BarsAxis  string // Label of the largest bar, shown on the axis
+	Slices    []htmlSlice
+	Roots     []htmlRoot // Summary of each root, with separate roots
+}
+
+// htmlRoot is the summary table of a single root.
+type htmlRoot struct {
+	Root    string
+	Summary [][2]string
+	Scan    [][2]string
+}
+
+// htmlTile is a rectangle of the directory treemap.
+type htmlTile struct {
+	X, Y, W, H float64
+	Label      string
+	Title      string // Tooltip with the full path and size
+	Color      string
+	ShowLabel  bool // Whether the tile is large enough for its label
+}
+
+// htmlBar is a bar of the per-year chart.
+type htmlBar struct {
+	X, Y, W, H float64
+	LabelX     float64 // Center of the year label below the bar
+	Label      string
+	Title      string
+}
+
+// htmlSlice is a slice of the per-user pie chart.
+type htmlSlice struct {
+	Path    string // SVG path data
+	LegendY int    // Top of the legend entry
+	Color   string
+	Label   string
+	Size    string
+	Percent string
+}
+
+// formatHTML renders a standalone HTML report of the results: summary
+// tables, a treemap of directory sizes, a per-year bar chart and a
+// per-user pie chart, and a table of the scan performance with the stats
+// mode. Charts are inline SVG, so the file can be emailed and opened
+// anywhere without network access.
+func (f *Formatter) formatHTML(results *stat.Results) string {
+	return renderHTML(f.htmlReport(results))
+}
+
+// htmlReport returns the data of the HTML report of results.
+func (f *Formatter) htmlReport(results *stat.Results) *htmlReport {
+	report := &htmlReport{
+		Generated: time.Now().Format("2006-01-02 15:04"),
+		Summary:   f.htmlSummary(results.Summary),
+		Scan:      htmlScan(&results.Scan),
+		Treemap:   f.htmlTreemap(results),
+		Slices:    f.htmlPie(results.ByUID),
+	}
+	report.Bars, report.BarsAxis = f.htmlBars(results.ByYear)
+	if slices.Contains(f.jsonModes(), "stats") {
+		report.Stats = f.statsRows(&results.Scan)
+	}
+	return report
+}
+
+// renderHTML executes the HTML report template on report.
+func renderHTML(report *htmlReport) string {
+	var b strings.Builder
+	if err := htmlTemplate.Execute(&b, report); err != nil {
+		return fmt.Sprintf("Error: %v\n", err)
+	}
+	return b.String()
+}
+
+// htmlSummary returns the rows of the summary table.
+func (f *Formatter) htmlSummary(sum *stat.SummaryStat) [][2]string {
+	if sum == nil {
+		return nil
+	}
+	rows := [][2]string{
+		{"Total Size", f.formatSize(sum.TotalSize)},
+		{"Total Inodes", f.formatCount(sum.TotalInodes)},
+		{"Files", fmt.Sprintf("%s (%s)", f.formatCount(sum.Files), f.formatSize(sum.FilesSize))},
+		{"Directories", f.formatCount(sum.Dirs)},
+		{"Symlinks", f.formatCount(sum.Symlinks)},
+		{"Others", f.formatCount(sum.Others)},
+	}
+	rows = append(rows, f.physicalRows(sum)...)
+	return append(rows, f.extremesRows(&sum.Extremes)...)
+}
+
+// htmlScan returns the rows describing the scan.
+func htmlScan(scan *stat.ScanStat) [][2]string {
+	var rows [][2]string
+	if len(scan.Roots) > 0 {
+		rows = append(rows, [2]string{"Scanned", strings.Join(scan.Roots, ", ")})
+	}
+	if scan.Duration > 0 {
+		rows = append(rows, [2]string{"Duration", scan.Duration.Round(time.Millisecond).String()})
+	}
+	rows = append(rows,
+		[2]string{"Entries Seen", strconv.FormatInt(scan.Entries, 10)},
+		[2]string{"Errors", strconv.FormatInt(scan.Errors, 10)})
+	return rows
+}
+
+// htmlTreemap lays out the sizes of the directories directly below the
+// roots as a squarified treemap. Files directly below a root are counted
+// as one "(files)" tile, and entries inside archives are left out since
+// the archive itself is counted.
+func (f *Formatter) htmlTreemap(results *stat.Results) []htmlTile {
+	sizes := make(map[string]int64)
+	for i := range results.AllFileInfos {
+		fi := &results.AllFileInfos[i]
+		if fi.IsDir || fi.Path == "" || fi.Archive != "" || fi.Size <= 0 {
+			continue
+		}
+		top, _, found := strings.Cut(fi.Path, "/")
+		if !found {
+			top = "(files)"
+		}
+		sizes[path.Join(fi.Root, top)] += fi.Size
+	}
+
+	type dir struct {
+		path string
+		size int64
+	}
+	dirs := make([]dir, 0, len(sizes))
+	for p, size := range sizes {
+		dirs = append(dirs, dir{p, size})
+	}
+	sort.Slice(dirs, func(i, j int) bool {
+		if dirs[i].size != dirs[j].size {
+			return dirs[i].size > dirs[j].size
+		}
+		return dirs[i].path < dirs[j].path
+	})
+	merged := len(dirs) > htmlTreemapTiles
+	if merged {
+		var other int64
+		for _, d := range dirs[htmlTreemapTiles-1:] {
+			other += d.size
+		}
+		n := len(dirs) - htmlTreemapTiles + 1
+		dirs = append(dirs[:htmlTreemapTiles-1], dir{fmt.Sprintf("(%d other directories)", n), other})
+	}
+
+	values := make([]float64, len(dirs))
+	for i, d := range dirs {
+		values[i] = float64(d.size)
+	}
+	rects := squarify(values, rect{0, 0, htmlTreemapWidth, htmlTreemapHeight})
+
+	tiles := make([]htmlTile, len(dirs))
+	for i, d := range dirs {
+		r := rects[i]
+		label := path.Base(d.path)
+		color := htmlPalette[i%len(htmlPalette)]
+		if merged && i == len(dirs)-1 {
+			color = htmlOtherColor
+		}
+		tiles[i] = htmlTile{
+			X: r.x, Y: r.y, W: r.w, H: r.h,
+			Label:     label,
+			Title:     fmt.Sprintf("%s: %s", d.path, f.formatSize(d.size)),
+			Color:     color,
+			ShowLabel: r.w >= float64(7*len(label)+8) && r.h >= 34,
+		}
+	}
+	return tiles
+}
+
+// htmlBars lays out the total size per year as bars, oldest year first,
+// with entries of unknown year last. Returns the bars and the size of the
+// largest one.
+func (f *Formatter) htmlBars(byYear map[int]*stat.YearStat) ([]htmlBar, string) {
+	years := make([]int, 0, len(byYear))
+	var largest int64
+	for year, s := range byYear {
+		years = append(years, year)
+		largest = max(largest, s.TotalSize)
+	}
+	sort.Slice(years, func(i, j int) bool {
+		if (years[i] == 0) != (years[j] == 0) {
+			return years[j] == 0
+		}
+		return years[i] < years[j]
+	})
+	if len(years) == 0 || largest == 0 {
+		return nil, ""
+	}
+
+	const top, bottom = 10, 24 // Room for the axis label and year labels
+	slot := float64(htmlBarsWidth) / float64(len(years))
+	bars := make([]htmlBar, len(years))
+	for i, year := range years {
+		s := byYear[year]
+		h := float64(htmlBarsHeight-top-bottom) * float64(s.TotalSize) / float64(largest)
+		bars[i] = htmlBar{
+			X:      float64(i)*slot + slot*0.15,
+			Y:      float64(htmlBarsHeight-bottom) - h,
+			W:      slot * 0.7,
+			H:      h,
+			LabelX: float64(i)*slot + slot/2,
+			Label:  fmt.Sprint(yearLabel(year)),
+			Title:  fmt.Sprintf("%v: %s in %d inodes", yearLabel(year), f.formatSize(s.TotalSize), s.TotalInodes),
+		}
+	}
+	return bars, f.formatSize(largest)
+}
+
+// htmlPie lays out the total size per owner as pie slices, largest first,
+// merging the smallest owners into one "others" slice.
+func (f *Formatter) htmlPie(byUID map[uint32]*stat.UIDStat) []htmlSlice {
+	users := make([]*stat.UIDStat, 0, len(byUID))
+	var total int64
+	for _, s := range byUID {
+		if s.TotalSize > 0 {
+			users = append(users, s)
+			total += s.TotalSize
+		}
+	}
+	if total == 0 {
+		return nil
+	}
+	sort.Slice(users, func(i, j int) bool {
+		if users[i].TotalSize != users[j].TotalSize {
+			return users[i].TotalSize > users[j].TotalSize
+		}
+		return users[i].UID < users[j].UID
+	})
+
+	type share struct {
+		label, color string
+		size         int64
+	}
+	var shares []share
+	for i, u := range users {
+		if i == htmlPieSlices-1 && len(users) > htmlPieSlices {
+			var other int64
+			for _, rest := range users[i:] {
+				other += rest.TotalSize
+			}
+			shares = append(shares, share{fmt.Sprintf("%d others", len(users)-i), htmlOtherColor, other})
+			break
+		}
+		label := u.Username
+		if label == "" {
+			label = fmt.Sprintf("uid:%d", u.UID)
+		}
+		shares = append(shares, share{label, htmlPalette[i%len(htmlPalette)], u.TotalSize})
+	}
+
+	slices := make([]htmlSlice, len(shares))
+	var start float64
+	for i, s := range shares {
+		frac := float64(s.size) / float64(total)
+		slices[i] = htmlSlice{
+			Path:    pieSlicePath(htmlPieRadius, start, start+frac),
+			LegendY: 24 * i,
+			Color:   s.color,
+			Label:   s.label,
+			Size:    f.formatSize(s.size),
+			Percent: fmt.Sprintf("%.1f%%", frac*100),
+		}
+		start += frac
+	}
+	return slices
+}
+
+// pieSlicePath returns the SVG path of the slice of a pie of radius r,
+// centered on the origin, from fraction start to end of the full circle,
+// clockwise from 12 o'clock.
+func pieSlicePath(r, start, end float64) string {
+	point := func(frac float64) (float64, float64) {
+		angle := 2*math.Pi*frac - math.Pi/2
+		return r * math.Cos(angle), r * math.Sin(angle)
+	}
+	if end-start >= 0.9999 {
+		// A single arc cannot describe a full circle
+		return fmt.Sprintf("M0,%.2f A%.2f,%.2f 0 1,1 0,%.2f A%.2f,%.2f 0 1,1 0,%.2f Z", -r, r, r, r, r, r, -r)
+	}
+	x1, y1 := point(start)
+	x2, y2 := point(end)
+	large := 0
+	if end-start > 0.5 {
+		large = 1
+	}
+	return fmt.Sprintf("M0,0 L%.2f,%.2f A%.2f,%.2f 0 %d,1 %.2f,%.2f Z", x1, y1, r, r, large, x2, y2)
+}
+
+// rect is an axis-aligned rectangle.
+type rect struct {
+	x, y, w, h float64
+}
+
+// squarify lays out values, sorted largest first, as rectangles filling
+// bounds with areas proportional to the values, using the squarified
+// treemap algorithm: rows of rectangles are laid along the shorter side
+// and grow as long as that improves their worst aspect ratio.
+func squarify(values []float64, bounds rect) []rect {
+	rects := make([]rect, len(values))
+	var total float64
+	for _, v := range values {
+		total += v
+	}
+	if total <= 0 {
+		return rects
+	}
+	scale := bounds.w * bounds.h / total
+	areas := make([]float64, len(values))
+	for i, v := range values {
+		areas[i] = v * scale
+	}
+
+	// worst returns the largest aspect ratio of a row of areas laid along
+	// a side of the given length
+	worst := func(row []float64, side float64) float64 {
+		var sum, maxA, minA float64 = 0, 0, math.Inf(1)
+		for _, a := range row {
+			sum += a
+			maxA = math.Max(maxA, a)
+			minA = math.Min(minA, a)
+		}
+		if sum == 0 || minA == 0 {
+			return math.Inf(1)
+		}
+		return math.Max(side*side*maxA/(sum*sum), sum*sum/(side*side*minA))
+	}
+
+	free := bounds
+	for start := 0; start < len(areas); {
+		side := math.Min(free.w, free.h)
+		end := start + 1
+		for end < len(areas) && worst(areas[start:end+1], side) <= worst(areas[start:end], side) {
+			end++
+		}
+
+		var sum float64
+		for _, a := range areas[start:end] {
+			sum += a
+		}
+		if free.w >= free.h {
+			// Lay the row out as a column at the left
+			colW := sum / free.h
+			y := free.y
+			for i := start; i < end; i++ {
+				h := areas[i] / colW
+				rects[i] = rect{free.x, y, colW, h}
+				y += h
+			}
+			free.x += colW
+			free.w -= colW
+		} else {
+			// Lay the row out at the top
+			rowH := sum / free.w
+			x := free.x
+			for i := start; i < end; i++ {
+				w := areas[i] / rowH
+				rects[i] = rect{x, free.y, w, rowH}
+				x += w
+			}
+			free.y += rowH
+			free.h -= rowH
+		}
+		start = end
+	}
+	return rects
+}
+
+// htmlFuncs are the functions of the HTML report template.
+var htmlFuncs = template.FuncMap{
+	"f1":  func(v float64) string { return strconv.FormatFloat(v, 'f', 1, 64) },
+	"add": func(a, b float64) float64 { return a + b },
+}
+
+// htmlTemplate renders the HTML report; see htmlReport.
+var htmlTemplate = template.Must(template.New("report").Funcs(htmlFuncs).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>cwalk report</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em auto; max-width: 1000px; color: #222; }
+h1 { font-size: 1.6em; margin-bottom: 0.2em; }
+h2 { font-size: 1.2em; margin-top: 2em; border-bottom: 1px solid #ddd; padding-bottom: 0.2em; }
+.generated { color: #777; margin-top: 0; }
+table { border-collapse: collapse; }
+td { padding: 0.25em 1.5em 0.25em 0; vertical-align: top; }
+td:first-child { color: #555; white-space: nowrap; }
+svg text { font-size: 12px; }
+.tile text { fill: #fff; }
+.axis { fill: #777; }
+.empty { color: #777; font-style: italic; }
+</style>
+</head>
+<body>
+<h1>cwalk report</h1>
+<p class="generated">Generated {{.Generated}}</p>
+
+<h2>Summary</h2>
+<table>
+{{range .Summary}}<tr><td>{{index . 0}}</td><td>{{index . 1}}</td></tr>
+{{end}}{{range .Scan}}<tr><td>{{index . 0}}</td><td>{{index . 1}}</td></tr>
+{{end}}</table>
+{{range .Roots}}
+<h2>{{.Root}}</h2>
+<table>
+{{range .Summary}}<tr><td>{{index . 0}}</td><td>{{index . 1}}</td></tr>
+{{end}}{{range .Scan}}<tr><td>{{index . 0}}</td><td>{{index . 1}}</td></tr>
+{{end}}</table>
+{{end}}{{if .Stats}}
+<h2>Scan Statistics</h2>
+<table>
+{{range .Stats}}<tr><td>{{index . 0}}</td><td>{{index . 1}}</td></tr>
+{{end}}</table>
+{{end}}
+<h2>Directory Sizes</h2>
+{{if .Treemap}}<svg width="100%" viewBox="0 0 960 420" role="img" aria-label="Treemap of directory sizes">
+{{range .Treemap}}<g class="tile"><title>{{.Title}}</title><rect x="{{f1 .X}}" y="{{f1 .Y}}" width="{{f1 .W}}" height="{{f1 .H}}" fill="{{.Color}}" stroke="#fff" stroke-width="2"/>{{if .ShowLabel}}<text x="{{f1 (add .X 6)}}" y="{{f1 (add .Y 18)}}">{{.Label}}</text>{{end}}</g>
+{{end}}</svg>{{else}}<p class="empty">No file sizes to show.</p>{{end}}
+
+<h2>Size by Year</h2>
+{{if .Bars}}<svg width="100%" viewBox="0 0 960 260" role="img" aria-label="Bar chart of size by year">
+<text class="axis" x="0" y="10">{{.BarsAxis}}</text>
+{{range .Bars}}<g><title>{{.Title}}</title><rect x="{{f1 .X}}" y="{{f1 .Y}}" width="{{f1 .W}}" height="{{f1 .H}}" fill="#4e79a7"/><text class="axis" x="{{f1 .LabelX}}" y="252" text-anchor="middle">{{.Label}}</text></g>
+{{end}}</svg>{{else}}<p class="empty">No sizes by year to show.</p>{{end}}
+
+<h2>Size by User</h2>
+{{if .Slices}}<svg width="100%" viewBox="0 0 960 260" role="img" aria-label="Pie chart of size by user">
+<g transform="translate(130,130)">
+{{range .Slices}}<path d="{{.Path}}" fill="{{.Color}}" stroke="#fff" stroke-width="1"><title>{{.Label}}: {{.Size}} ({{.Percent}})</title></path>
+{{end}}</g>
+<g transform="translate(300,30)">
+{{range .Slices}}<g transform="translate(0,{{.LegendY}})"><rect width="14" height="14" fill="{{.Color}}"/><text x="22" y="12">{{.Label}}: {{.Size}} ({{.Percent}})</text></g>
+{{end}}</g>
+</svg>{{else}}<p class="empty">No sizes by user to show.</p>{{end}}
+</body>
+</html>
+`))
@@ -0,0 +1,90 @@
+package output
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/otuschhoff/cwalk/pkg/stat"
+)
+
+func TestSquarify(t *testing.T) {
+	values := []float64{6, 6, 4, 3, 2, 2, 1}
+	bounds := rect{0, 0, 600, 400}
+	rects := squarify(values, bounds)
+
+	var total float64
+	for _, v := range values {
+		total += v
+	}
+	for i, r := range rects {
+		want := values[i] / total * bounds.w * bounds.h
+		if math.Abs(r.w*r.h-want) > 1e-6 {
+			t.Errorf("rect %d area = %.2f, want %.2f", i, r.w*r.h, want)
+		}
+		if r.x < -1e-9 || r.y < -1e-9 || r.x+r.w > bounds.w+1e-9 || r.y+r.h > bounds.h+1e-9 {
+			t.Errorf("rect %d = %+v outside the bounds", i, r)
+		}
+	}
+	// The first row of the classic example is the two largest values
+	// stacked at the left
+	if rects[0].x != 0 || rects[1].x != 0 || rects[0].w != rects[1].w {
+		t.Errorf("first row = %+v, %+v", rects[0], rects[1])
+	}
+
+	if rects := squarify([]float64{0, 0}, bounds); rects[0] != (rect{}) {
+		t.Errorf("zero values laid out as %+v", rects)
+	}
+}
+
+func TestPieSlicePath(t *testing.T) {
+	if got, want := pieSlicePath(10, 0, 0.25), "M0,0 L0.00,-10.00 A10.00,10.00 0 0,1 10.00,0.00 Z"; got != want {
+		t.Errorf("quarter = %q, want %q", got, want)
+	}
+	if got := pieSlicePath(10, 0.25, 0.9); !strings.Contains(got, " 0 1,1 ") {
+		t.Errorf("slice over half a circle should use the large arc: %q", got)
+	}
+	if got := pieSlicePath(10, 0, 1); strings.Count(got, "A") != 2 {
+		t.Errorf("full circle should use two arcs: %q", got)
+	}
+}
+
+func TestFormatHTML(t *testing.T) {
+	results := &stat.Results{
+		Summary: &stat.SummaryStat{TotalSize: 3000, TotalInodes: 4, Files: 3, Dirs: 1},
+		ByYear: map[int]*stat.YearStat{
+			2023: {Year: 2023, TotalSize: 1000},
+			2024: {Year: 2024, TotalSize: 2000},
+		},
+		ByUID: map[uint32]*stat.UIDStat{
+			1000: {UID: 1000, Username: "alice", TotalSize: 1000},
+			1001: {UID: 1001, TotalSize: 2000},
+		},
+		AllFileInfos: []stat.FileInfo{
+			{Root: "/data", Path: "", IsDir: true},
+			{Root: "/data", Path: "<proj>/a", Size: 2000},
+			{Root: "/data", Path: "b", Size: 1000},
+		},
+	}
+
+	out := NewFormatter("html", "per-uid", false).Format(results)
+	for _, want := range []string{
+		"<!DOCTYPE html>",
+		"<title>/data/&lt;proj&gt;: 2.0 KB</title>",
+		"<title>/data/(files): 1000 B</title>",
+		">2024</text>",
+		"uid:1001: 2.0 KB (66.7%)",
+		"alice: 1000 B (33.3%)",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("report does not contain %q", want)
+		}
+	}
+	if strings.Contains(out, "<proj>") {
+		t.Error("directory names are not escaped")
+	}
+
+	if out := NewFormatter("html", "summary", false).Format(&stat.Results{}); !strings.Contains(out, "No sizes by year to show.") {
+		t.Error("empty results should render without charts")
+	}
+}
@@ -0,0 +1,539 @@
+package output
+
+import (
+	"slices"
+	"time"
+
+	"github.com/otuschhoff/cwalk/pkg/stat"
+)
+
+// SchemaVersion is the version of the JSON output schema. It is increased
+// when keys are renamed or removed or change their meaning; adding keys
+// keeps the version, so consumers should ignore keys they do not know.
+const SchemaVersion = 1
+
+// JSONDocument is the top level of JSON output. Each output mode writes its
+// section under the mode name in camel case, e.g. "perYear" for per-year.
+// All keys are camel case, sizes are exact byte counts and times are
+// RFC 3339 strings. JSONSchema describes the document.
+type JSONDocument struct {
+	SchemaVersion int      `json:"schemaVersion"` // SchemaVersion of the document
+	Modes         []string `json:"modes"`         // Output modes whose sections follow
+
+	Summary      *JSONSummary         `json:"summary,omitzero"`
+	PerYear      []JSONYear           `json:"perYear,omitzero"`
+	PerUID       []JSONUID            `json:"perUid,omitzero"`
+	Groups       *JSONGroups          `json:"groups,omitzero"`
+	PerFS        []JSONFS             `json:"perFs,omitzero"`
+	PerDepth     []JSONDepth          `json:"perDepth,omitzero"`
+	InodeUsage   []JSONInodeUsage     `json:"inodeUsage,omitzero"`
+	Symlinks     *JSONSymlinks        `json:"symlinks,omitzero"`
+	RandomNames  []JSONRandomNameDir  `json:"randomNames,omitzero"`
+	Watchlist    []JSONWatchlistMatch `json:"watchlist,omitzero"`
+	Churn        *JSONChurn           `json:"churn,omitzero"` // Omitted without a previous snapshot
+	List         []JSONEntry          `json:"list,omitzero"`
+	Paths        []string             `json:"paths,omitzero"`
+	Empty        *JSONEmpty           `json:"empty,omitzero"`
+	FanOut       *JSONFanOut          `json:"fanOut,omitzero"`
+	Xattrs       *JSONXattrs          `json:"xattrs,omitzero"`
+	SELinux      *JSONSELinux         `json:"selinux,omitzero"`
+	Audit        *JSONAudit           `json:"audit,omitzero"`
+	Homes        []JSONHome           `json:"homes,omitzero"`
+	Quota        []JSONQuota          `json:"quota,omitzero"`
+	Projects     []JSONProject        `json:"projects,omitzero"`
+	Ownership    []JSONOwnership      `json:"ownership,omitzero"`
+	PerProjectID []JSONProjectID      `json:"perProjectId,omitzero"`
+	Striping     []JSONStripe         `json:"striping,omitzero"`
+	Stats        *JSONStats           `json:"stats,omitzero"`
+
+	Root  string          `json:"root,omitzero"`  // Root path of a document in Roots
+	Roots []*JSONDocument `json:"roots,omitzero"` // Sections of each root, with separate roots
+}
+
+// JSONExtremes are the oldest and newest modification, largest file and
+// deepest entry of a summary or group, null if there is none.
+type JSONExtremes struct {
+	NewestMtime *time.Time `json:"newestMtime"`
+	NewestPath  *string    `json:"newestPath"`
+	OldestMtime *time.Time `json:"oldestMtime"`
+	OldestPath  *string    `json:"oldestPath"`
+	LargestSize *int64     `json:"largestSize"`
+	LargestPath *string    `json:"largestPath"`
+	MaxDepth    *int       `json:"maxDepth"`
+	DeepestPath *string    `json:"deepestPath"`
+}
+
+// JSONSummary is the summary section.
+type JSONSummary struct {
+	Size         int64        `json:"size"`
+	Inodes       int64        `json:"inodes"`
+	Files        int64        `json:"files"`
+	Dirs         int64        `json:"dirs"`
+	Symlinks     int64        `json:"symlinks"`
+	Others       int64        `json:"others"`
+	FilesSize    int64        `json:"filesSize"`
+	DirsSize     int64        `json:"dirsSize"`
+	SymlinksSize int64        `json:"symlinksSize"`
+	OthersSize   int64        `json:"othersSize"`
+	PhysicalSize int64        `json:"physicalSize,omitzero"`     // Bytes regular files take on disk after compression
+	Compression  float64      `json:"compressionRatio,omitzero"` // Logical size of regular files over physical size
+	Extremes     JSONExtremes `json:"extremes"`
+}
+
+// JSONYear is a row of the perYear section.
+type JSONYear struct {
+	Year         *int    `json:"year"` // Null for entries whose timestamp is unknown
+	Size         int64   `json:"size"`
+	Inodes       int64   `json:"inodes"`
+	Files        int64   `json:"files"`
+	Dirs         int64   `json:"dirs"`
+	Symlinks     int64   `json:"symlinks"`
+	Others       int64   `json:"others"`
+	FilesSize    int64   `json:"filesSize"`
+	DirsSize     int64   `json:"dirsSize"`
+	SymlinksSize int64   `json:"symlinksSize"`
+	OthersSize   int64   `json:"othersSize"`
+	AvgFileSize  int64   `json:"avgFileSize"`
+	FilesPerDir  float64 `json:"filesPerDir"`
+	SymlinkPct   float64 `json:"symlinkPct"`
+}
+
+// JSONUID is a row of the perUid section.
+type JSONUID struct {
+	UID          uint32  `json:"uid"`
+	Username     string  `json:"username"`
+	Size         int64   `json:"size"`
+	Inodes       int64   `json:"inodes"`
+	Files        int64   `json:"files"`
+	Dirs         int64   `json:"dirs"`
+	Symlinks     int64   `json:"symlinks"`
+	Others       int64   `json:"others"`
+	FilesSize    int64   `json:"filesSize"`
+	DirsSize     int64   `json:"dirsSize"`
+	SymlinksSize int64   `json:"symlinksSize"`
+	OthersSize   int64   `json:"othersSize"`
+	AvgFileSize  int64   `json:"avgFileSize"`
+	FilesPerDir  float64 `json:"filesPerDir"`
+	SymlinkPct   float64 `json:"symlinkPct"`
+}
+
+// JSONGroups is the groups section: the cross-tabulated dimensions and one
+// row per combination of their values.
+type JSONGroups struct {
+	Dimensions []string    `json:"dimensions"`
+	Groups     []JSONGroup `json:"groups"`
+}
+
+// JSONGroup is a row of the groups section.
+type JSONGroup struct {
+	Keys      map[string]string `json:"keys"` // Dimension -> value
+	Size      int64             `json:"size"`
+	Inodes    int64             `json:"inodes"`
+	Files     int64             `json:"files"`
+	Dirs      int64             `json:"dirs"`
+	Symlinks  int64             `json:"symlinks"`
+	Others    int64             `json:"others"`
+	FilesSize int64             `json:"filesSize"`
+	Extremes  JSONExtremes      `json:"extremes"`
+}
+
+// JSONFS is a row of the perFs section.
+type JSONFS struct {
+	Dev        uint64 `json:"dev"`
+	Mountpoint string `json:"mountpoint"`
+	Type       string `json:"type"`
+	Source     string `json:"source"`
+	Size       int64  `json:"size"`
+	Inodes     int64  `json:"inodes"`
+	Files      int64  `json:"files"`
+	Dirs       int64  `json:"dirs"`
+	Symlinks   int64  `json:"symlinks"`
+	Others     int64  `json:"others"`
+}
+
+// JSONDepth is a row of the perDepth section.
+type JSONDepth struct {
+	Depth        int     `json:"depth"`
+	Size         int64   `json:"size"`
+	Inodes       int64   `json:"inodes"`
+	Files        int64   `json:"files"`
+	Dirs         int64   `json:"dirs"`
+	Symlinks     int64   `json:"symlinks"`
+	Others       int64   `json:"others"`
+	FilesSize    int64   `json:"filesSize"`
+	CumInodesPct float64 `json:"cumInodesPct"` // Share of inodes at this depth or above
+}
+
+// JSONInodeUsage is a row of the inodeUsage section.
+type JSONInodeUsage struct {
+	Root         string  `json:"root"`
+	WalkedInodes int64   `json:"walkedInodes"`
+	UsedInodes   uint64  `json:"usedInodes"`
+	FreeInodes   uint64  `json:"freeInodes"`
+	TotalInodes  uint64  `json:"totalInodes"`
+	InodesPct    float64 `json:"inodesPct"`
+	WalkedSize   int64   `json:"walkedSize"`
+	UsedBytes    uint64  `json:"usedBytes"`
+	AvailBytes   uint64  `json:"availBytes"`
+	TotalBytes   uint64  `json:"totalBytes"`
+	BytesPct     float64 `json:"bytesPct"`
+}
+
+// JSONSymlinks is the symlinks section.
+type JSONSymlinks struct {
+	Chains JSONSymlinkChains `json:"chains"`
+	Check  *JSONSymlinkCheck `json:"check"` // Null unless targets were checked
+}
+
+// JSONSymlinkChains describes the resolved symlink chains.
+type JSONSymlinkChains struct {
+	Chains   int64   `json:"chains"`
+	MaxDepth int64   `json:"maxDepth"`
+	AvgDepth float64 `json:"avgDepth"`
+	Loops    int64   `json:"loops"`
+}
+
+// JSONSymlinkCheck describes the checked symlink targets.
+type JSONSymlinkCheck struct {
+	Symlinks      int64    `json:"symlinks"`
+	Broken        int64    `json:"broken"`
+	Absolute      int64    `json:"absolute"`
+	Relative      int64    `json:"relative"`
+	Escaping      int64    `json:"escaping"`
+	BrokenPaths   []string `json:"brokenPaths"`
+	EscapingPaths []string `json:"escapingPaths"`
+}
+
+// JSONRandomNameDir is a row of the randomNames section.
+type JSONRandomNameDir struct {
+	Path    string  `json:"path"`
+	Entries int64   `json:"entries"`
+	Random  int64   `json:"random"`
+	Ratio   float64 `json:"ratio"`
+}
+
+// JSONWatchlistMatch is a row of the watchlist section.
+type JSONWatchlistMatch struct {
+	Path    string `json:"path"`
+	Pattern string `json:"pattern"`
+}
+
+// JSONChurn is the churn section.
+type JSONChurn struct {
+	IntervalSeconds int64 `json:"intervalSeconds"`
+	Added           int64 `json:"added"`
+	Deleted         int64 `json:"deleted"`
+	Modified        int64 `json:"modified"`
+	AddedBytes      int64 `json:"addedBytes"`
+	DeletedBytes    int64 `json:"deletedBytes"`
+	ModifiedBytes   int64 `json:"modifiedBytes"`
+	TurnoverBytes   int64 `json:"turnoverBytes"`
+}
+
+// JSONEntry is a single entry of the list, audit and empty sections. List
+// entries only have the keys of the selected columns.
+type JSONEntry struct {
+	Mode  *string    `json:"mode,omitempty"`
+	Octal *string    `json:"octal,omitempty"`
+	Links *uint64    `json:"links,omitempty"`
+	UID   *uint32    `json:"uid,omitempty"`
+	GID   *uint32    `json:"gid,omitempty"`
+	Owner *string    `json:"owner,omitempty"`
+	Group *string    `json:"group,omitempty"`
+	Size  *int64     `json:"size,omitempty"`
+	Mtime *time.Time `json:"mtime,omitempty"`
+	Type  *string    `json:"type,omitempty"`
+	Depth *int       `json:"depth,omitempty"`
+	Path  *string    `json:"path,omitempty"`
+}
+
+// JSONEmpty is the empty section.
+type JSONEmpty struct {
+	EmptyFiles int         `json:"emptyFiles"`
+	EmptyDirs  int         `json:"emptyDirs"`
+	Total      int         `json:"total"`
+	Files      []JSONEntry `json:"files"`
+	Dirs       []JSONEntry `json:"dirs"`
+}
+
+// JSONFanOut is the fanOut section.
+type JSONFanOut struct {
+	Dirs         int64              `json:"dirs"`
+	Entries      int64              `json:"entries"`
+	AvgFanOut    float64            `json:"avgFanOut"`
+	MaxEntries   int64              `json:"maxEntries"`
+	MaxPath      string             `json:"maxPath"`
+	HugeDirs     int64              `json:"hugeDirs"`
+	HugePaths    []string           `json:"hugePaths"`
+	Distribution []JSONFanOutBucket `json:"distribution"`
+}
+
+// JSONFanOutBucket counts the directories of an entry count range.
+type JSONFanOutBucket struct {
+	Entries string `json:"entries"` // Range of entry counts, e.g. "10-99"
+	Dirs    int64  `json:"dirs"`
+}
+
+// JSONXattrs is the xattrs section.
+type JSONXattrs struct {
+	Entries int64           `json:"entries"`
+	ACLs    int64           `json:"acls"`
+	Size    int64           `json:"size"`
+	Names   []JSONXattrName `json:"names"`
+}
+
+// JSONXattrName is a row of the names of the xattrs section.
+type JSONXattrName struct {
+	Name    string `json:"name"`
+	Entries int64  `json:"entries"`
+	Size    int64  `json:"size"`
+}
+
+// JSONSELinux is the selinux section.
+type JSONSELinux struct {
+	Labels           []JSONSELinuxLabel `json:"labels"`
+	UnlabeledEntries int64              `json:"unlabeledEntries"`
+	UnlabeledSize    int64              `json:"unlabeledSize"`
+}
+
+// JSONSELinuxLabel is a row of the labels of the selinux section.
+type JSONSELinuxLabel struct {
+	Label   string `json:"label"`
+	Type    string `json:"type"`
+	Entries int64  `json:"entries"`
+	Size    int64  `json:"size"`
+}
+
+// JSONAudit is the audit section.
+type JSONAudit struct {
+	WorldWritable    []JSONEntry `json:"worldWritable"`
+	SetID            []JSONEntry `json:"setid"`
+	Orphaned         []JSONEntry `json:"orphaned"`
+	DanglingSymlinks []JSONEntry `json:"danglingSymlinks"`
+}
+
+// JSONHome is a row of the homes section.
+type JSONHome struct {
+	Path           string     `json:"path"`
+	UID            uint32     `json:"uid"`
+	Owner          string     `json:"owner"`
+	Size           int64      `json:"size"`
+	Inodes         int64      `json:"inodes"`
+	OldestPath     *string    `json:"oldestPath"` // Null if the home has no files
+	OldestMtime    *time.Time `json:"oldestMtime"`
+	ForeignEntries int64      `json:"foreignEntries"` // Entries owned by another user
+	ForeignSize    int64      `json:"foreignSize"`
+	Warnings       []string   `json:"warnings"`
+}
+
+// JSONQuota is a row of the quota section.
+type JSONQuota struct {
+	Target   string  `json:"target"` // User name or UID, or directory
+	Type     string  `json:"type"`   // "user" or "dir"
+	Limit    int64   `json:"limit"`
+	Used     int64   `json:"used"`
+	Inodes   int64   `json:"inodes"`
+	UsedPct  float64 `json:"usedPct"`
+	Exceeded bool    `json:"exceeded"`
+}
+
+// JSONProject is a row of the projects section.
+type JSONProject struct {
+	Path    *string    `json:"path"`    // Project directory, null for the entries outside all projects
+	Markers []string   `json:"markers"` // Marker entries found in the directory
+	Size    int64      `json:"size"`
+	Inodes  int64      `json:"inodes"`
+	Files   int64      `json:"files"`
+	Newest  *time.Time `json:"newest"` // Null if no entry has a modification time
+}
+
+// JSONOwnership is a row of the ownership section.
+type JSONOwnership struct {
+	Path      string  `json:"path"`
+	Size      int64   `json:"size"`
+	Inodes    int64   `json:"inodes"`
+	Owners    int     `json:"owners"` // Distinct owners
+	UID       uint32  `json:"uid"`    // Dominant owner by bytes
+	Owner     string  `json:"owner"`
+	OwnerSize int64   `json:"ownerSize"`
+	OwnerPct  float64 `json:"ownerPct"`
+	Entropy   float64 `json:"entropy"` // Shannon entropy of the bytes per owner, in bits
+}
+
+// JSONProjectID is a row of the perProjectId section.
+type JSONProjectID struct {
+	ProjectID uint32 `json:"projectId"` // 0 for entries without one
+	Size      int64  `json:"size"`
+	Inodes    int64  `json:"inodes"`
+	Files     int64  `json:"files"`
+}
+
+// JSONStripe is a row of the striping section.
+type JSONStripe struct {
+	StripeCount int   `json:"stripeCount"` // 0 for files without a known layout
+	StripeSize  int64 `json:"stripeSize"`
+	Files       int64 `json:"files"`
+	Size        int64 `json:"size"`
+	Largest     int64 `json:"largest"`
+}
+
+// JSONStats is the stats section. Rates are per second of walk time.
+type JSONStats struct {
+	WallSeconds    float64      `json:"wallSeconds"`
+	WalkSeconds    float64      `json:"walkSeconds"`
+	MergeSeconds   float64      `json:"mergeSeconds"`
+	Entries        int64        `json:"entries"`
+	Dirs           int64        `json:"dirs"`
+	Files          int64        `json:"files"`
+	DirsPerSec     float64      `json:"dirsPerSec"`
+	FilesPerSec    float64      `json:"filesPerSec"`
+	Syscalls       int64        `json:"syscalls"`
+	SyscallsPerSec float64      `json:"syscallsPerSec"`
+	Errors         int64        `json:"errors"`
+	PeakQueue      int64        `json:"peakQueue"`
+	RstatsRoots    []string     `json:"rstatsRoots,omitempty"` // Roots whose totals came from CephFS recursive statistics
+	Workers        []JSONWorker `json:"workers"`
+}
+
+// JSONWorker is a row of the workers of the stats section.
+type JSONWorker struct {
+	ID          int     `json:"id"`
+	Dirs        int64   `json:"dirs"`
+	BusySeconds float64 `json:"busySeconds"`
+	Utilization float64 `json:"utilization"` // Fraction of the worker's lifetime it was busy
+}
+
+// formatJSON writes the sections of the output modes as a JSONDocument.
+func (f *Formatter) formatJSON(results *stat.Results) string {
+	return f.toJSON(f.jsonDocument(results))
+}
+
+// jsonDocument returns the JSON document of the modes of results.
+func (f *Formatter) jsonDocument(results *stat.Results) *JSONDocument {
+	doc := &JSONDocument{SchemaVersion: SchemaVersion, Modes: []string{}}
+	for _, mode := range f.jsonModes() {
+		switch mode {
+		case "per-year":
+			doc.PerYear = f.jsonPerYear(results)
+		case "per-uid":
+			doc.PerUID = f.jsonPerUID(results)
+		case "symlinks":
+			doc.Symlinks = jsonSymlinks(results)
+		case "random-names":
+			doc.RandomNames = jsonRandomNames(results)
+		case "watchlist":
+			doc.Watchlist = jsonWatchlist(results)
+		case "churn":
+			doc.Churn = jsonChurn(results)
+		case "list":
+			doc.List = f.jsonList(results)
+		case "paths":
+			doc.Paths = f.jsonPaths(results)
+		case "per-fs":
+			doc.PerFS = jsonPerFS(results)
+		case "inode-usage":
+			doc.InodeUsage = jsonInodeUsage(results)
+		case "xattrs":
+			doc.Xattrs = jsonXattrs(results)
+		case "selinux":
+			doc.SELinux = jsonSELinux(results)
+		case "audit":
+			doc.Audit = jsonAudit(results)
+		case "homes":
+			doc.Homes = jsonHomes(results)
+		case "quota":
+			doc.Quota = jsonQuota(results)
+		case "projects":
+			doc.Projects = jsonProjects(results)
+		case "ownership":
+			doc.Ownership = jsonOwnership(results)
+		case "per-project-id":
+			doc.PerProjectID = jsonPerProjectID(results)
+		case "striping":
+			doc.Striping = jsonStriping(results)
+		case "empty":
+			doc.Empty = jsonEmpty(results)
+		case "fan-out":
+			doc.FanOut = jsonFanOut(results)
+		case "per-depth":
+			doc.PerDepth = jsonPerDepth(results)
+		case "groups":
+			doc.Groups = f.jsonGroups(results)
+		case "stats":
+			doc.Stats = jsonStats(results)
+		default:
+			mode = "summary"
+			doc.Summary = jsonSummary(results)
+		}
+		if !slices.Contains(doc.Modes, mode) {
+			doc.Modes = append(doc.Modes, mode)
+		}
+	}
+	return doc
+}
+
+// jsonExtremes returns the extremes of a summary or group, with nil for
+// those without an entry.
+func jsonExtremes(e *stat.Extremes) JSONExtremes {
+	var data JSONExtremes
+	if e.NewestPath != "" {
+		data.NewestMtime, data.NewestPath = &e.NewestMtime, &e.NewestPath
+		data.OldestMtime, data.OldestPath = &e.OldestMtime, &e.OldestPath
+	}
+	if e.LargestPath != "" {
+		data.LargestSize, data.LargestPath = &e.LargestSize, &e.LargestPath
+	}
+	if e.DeepestPath != "" {
+		data.MaxDepth, data.DeepestPath = &e.MaxDepth, &e.DeepestPath
+	}
+	return data
+}
+
+// jsonEntry returns the columns cols of an entry, see listValue.
+func jsonEntry(fi stat.FileInfo, cols []string) JSONEntry {
+	var e JSONEntry
+	for _, col := range cols {
+		switch col {
+		case "mode":
+			e.Mode = ptr(symbolicMode(fi.Mode))
+		case "octal":
+			e.Octal = ptr(octalMode(fi.Mode))
+		case "links":
+			e.Links = ptr(fi.Links)
+		case "uid":
+			e.UID = ptr(fi.UID)
+		case "gid":
+			e.GID = ptr(fi.GID)
+		case "owner":
+			e.Owner = ptr(stat.Username(fi.UID))
+		case "group":
+			e.Group = ptr(stat.Groupname(fi.GID))
+		case "size":
+			e.Size = ptr(fi.Size)
+		case "mtime":
+			e.Mtime = ptr(fi.ModTime)
+		case "type":
+			e.Type = ptr(entryType(fi))
+		case "depth":
+			e.Depth = ptr(fi.Depth())
+		case "path":
+			e.Path = ptr(fi.FullPath())
+		}
+	}
+	return e
+}
+
+// jsonEntries returns the columns cols of entries, as an empty array
+// rather than null if there are none.
+func jsonEntries(infos []stat.FileInfo, cols []string) []JSONEntry {
+	entries := make([]JSONEntry, 0, len(infos))
+	for _, fi := range infos {
+		entries = append(entries, jsonEntry(fi, cols))
+	}
+	return entries
+}
+
+// ptr returns a pointer to a copy of v, for optional keys.
+func ptr[T any](v T) *T {
+	return &v
+}
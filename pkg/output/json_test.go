@@ -0,0 +1,113 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/otuschhoff/cwalk/pkg/stat"
+)
+
+func TestFormatJSONDocument(t *testing.T) {
+	mtime := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+	results := &stat.Results{
+		Summary: &stat.SummaryStat{TotalSize: 2048, TotalInodes: 2, Files: 1, Dirs: 1, FilesSize: 2048},
+		ByYear: map[int]*stat.YearStat{
+			0:    {TotalInodes: 1, Dirs: 1},
+			2020: {Year: 2020, TotalSize: 2048, TotalInodes: 1, Files: 1, FilesSize: 2048},
+		},
+		ByUID: map[uint32]*stat.UIDStat{1000: {UID: 1000, TotalSize: 2048, TotalInodes: 2, Files: 1, Dirs: 1}},
+		AllFileInfos: []stat.FileInfo{
+			{Root: "/r", Path: "", Mode: os.ModeDir | 0o755, IsDir: true, UID: 1000},
+			{Root: "/r", Path: "a.txt", Mode: 0o644, Size: 2048, ModTime: mtime, UID: 1000},
+		},
+		Churn: &stat.ChurnStat{Interval: time.Hour, Added: 1, AddedBytes: 2048},
+	}
+	camelCase := regexp.MustCompile(`^[a-z][a-zA-Z0-9]*$`)
+
+	for _, mode := range Modes {
+		t.Run(mode, func(t *testing.T) {
+			output := NewFormatter("json", mode, false).Format(results)
+
+			var doc JSONDocument
+			if err := json.Unmarshal([]byte(output), &doc); err != nil {
+				t.Fatalf("output is not a JSON document: %v\n%s", err, output)
+			}
+			if doc.SchemaVersion != SchemaVersion || len(doc.Modes) != 1 || doc.Modes[0] != mode {
+				t.Errorf("schemaVersion %d, modes %v", doc.SchemaVersion, doc.Modes)
+			}
+
+			var top map[string]any
+			json.Unmarshal([]byte(output), &top)
+			if len(top) != 3 {
+				t.Errorf("document has keys %v, want schemaVersion, modes and one section", keys(top))
+			}
+			walkKeys(top, func(key string) {
+				if !camelCase.MatchString(key) {
+					t.Errorf("key %q is not camel case", key)
+				}
+			})
+		})
+	}
+}
+
+func TestFormatJSONSizes(t *testing.T) {
+	results := &stat.Results{
+		Summary: &stat.SummaryStat{TotalSize: 1610612736, TotalInodes: 1, Files: 1, FilesSize: 1610612736},
+		ByYear:  map[int]*stat.YearStat{0: {TotalInodes: 1}},
+	}
+
+	var doc JSONDocument
+	json.Unmarshal([]byte(NewFormatter("json", "summary", false).Format(results)), &doc)
+	if doc.Summary == nil || doc.Summary.Size != 1610612736 || doc.Summary.Extremes.LargestPath != nil {
+		t.Errorf("summary = %+v", doc.Summary)
+	}
+
+	json.Unmarshal([]byte(NewFormatter("json", "per-year", false).Format(results)), &doc)
+	if len(doc.PerYear) != 1 || doc.PerYear[0].Year != nil {
+		t.Errorf("unknown year should be null: %+v", doc.PerYear)
+	}
+}
+
+func TestJSONSchemaPublished(t *testing.T) {
+	schema, err := JSONSchema()
+	if err != nil {
+		t.Fatalf("JSONSchema failed: %v", err)
+	}
+	published, err := os.ReadFile("../../schema/output.schema.json")
+	if err != nil {
+		t.Fatalf("failed to read published schema: %v", err)
+	}
+	if !bytes.Equal(schema, published) {
+		t.Error("schema/output.schema.json is out of date; regenerate it with: go run ./cmd/cwalk schema > schema/output.schema.json")
+	}
+}
+
+// walkKeys calls fn with every object key in a decoded JSON value, except
+// those of group keys, which are dimension names.
+func walkKeys(v any, fn func(string)) {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, child := range v {
+			fn(k)
+			if k != "keys" {
+				walkKeys(child, fn)
+			}
+		}
+	case []any:
+		for _, child := range v {
+			walkKeys(child, fn)
+		}
+	}
+}
+
+func keys(m map[string]any) []string {
+	var out []string
+	for k := range m {
+		out = append(out, k)
+	}
+	return out
+}
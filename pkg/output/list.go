@@ -0,0 +1,339 @@
+package output
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/otuschhoff/cwalk/pkg/stat"
+)
+
+// listColumns are the columns available in list output, in the order they
+// are documented. Headers are the column names in upper case.
+var listColumns = []string{"mode", "octal", "links", "uid", "gid", "owner", "group", "size", "mtime", "type", "depth", "path"}
+
+// defaultListColumns mirror the fields of ls -l.
+var defaultListColumns = []string{"mode", "links", "owner", "group", "size", "mtime", "path"}
+
+// ParseColumns parses a comma-separated list of list output columns. An
+// empty string selects the default columns.
+func ParseColumns(s string) ([]string, error) {
+	if s == "" {
+		return defaultListColumns, nil
+	}
+
+	var cols []string
+	for _, col := range strings.Split(s, ",") {
+		col = strings.ToLower(strings.TrimSpace(col))
+		if !isListColumn(col) {
+			return nil, fmt.Errorf("unknown column %q (available: %s)", col, strings.Join(listColumns, ", "))
+		}
+		cols = append(cols, col)
+	}
+	return cols, nil
+}
+
+func isListColumn(col string) bool {
+	for _, c := range listColumns {
+		if c == col {
+			return true
+		}
+	}
+	return false
+}
+
+// SetColumns selects the columns of list output, as returned by
+// ParseColumns. Other modes are not affected.
+func (f *Formatter) SetColumns(cols []string) {
+	f.columns = cols
+}
+
+// SetNull terminates the paths of paths output with a NUL byte instead
+// of a newline, for file names containing newlines.
+func (f *Formatter) SetNull(null bool) {
+	f.null = null
+}
+
+// jsonList returns the list section of JSON output, with the keys of the
+// selected columns.
+func (f *Formatter) jsonList(results *stat.Results) []JSONEntry {
+	cols := f.columns
+	if len(cols) == 0 {
+		cols = defaultListColumns
+	}
+	return jsonEntries(f.sortedInfos(results.AllFileInfos), cols)
+}
+
+// jsonPaths returns the paths section of JSON output, sorted like list
+// output.
+func (f *Formatter) jsonPaths(results *stat.Results) []string {
+	infos := f.sortedInfos(results.AllFileInfos)
+	paths := make([]string, 0, len(infos))
+	for _, fi := range infos {
+		paths = append(paths, fi.FullPath())
+	}
+	return paths
+}
+
+// formatList formats one row per entry, like a scripted ls -lR inventory.
+// Entries are sorted by path unless SetSort selected another order.
+func (f *Formatter) formatList(results *stat.Results) string {
+	cols := f.columns
+	if len(cols) == 0 {
+		cols = defaultListColumns
+	}
+
+	infos := f.sortedInfos(results.AllFileInfos)
+
+	headers := make([]string, len(cols))
+	for i, col := range cols {
+		headers[i] = strings.ToUpper(col)
+	}
+	switch f.format {
+	case "csv", "xlsx":
+		data := make([]map[string]interface{}, 0, len(infos))
+		for _, fi := range infos {
+			row := map[string]interface{}{}
+			for i, col := range cols {
+				row[headers[i]] = listValue(fi, col, false)
+			}
+			data = append(data, row)
+		}
+		if f.format == "csv" {
+			return f.toCSV(headers, data)
+		}
+		return f.toXLSX(headers, data)
+	}
+
+	return f.listTable(infos, cols, headers, &results.Scan)
+}
+
+// formatPaths prints the full path of each entry on its own line, like
+// find -print, or terminated by NUL with SetNull, like find -print0, so
+// output can be piped to xargs. Entries are sorted like list output. CSV,
+// XLSX and JSON output hold the same paths in a single column or array.
+func (f *Formatter) formatPaths(results *stat.Results) string {
+	infos := f.sortedInfos(results.AllFileInfos)
+
+	switch f.format {
+	case "csv", "xlsx":
+		data := make([]map[string]interface{}, 0, len(infos))
+		for _, fi := range infos {
+			data = append(data, map[string]interface{}{"PATH": fi.FullPath()})
+		}
+		if f.format == "csv" {
+			return f.toCSV([]string{"PATH"}, data)
+		}
+		return f.toXLSX([]string{"PATH"}, data)
+	}
+
+	sep := "\n"
+	if f.null {
+		sep = "\x00"
+	}
+	var b strings.Builder
+	for _, fi := range infos {
+		b.WriteString(fi.FullPath())
+		b.WriteString(sep)
+	}
+	return b.String()
+}
+
+// sortedInfos returns a copy of infos in list output order. Sizes sort
+// largest first, modification times oldest first, and ties are broken by
+// path so the order is stable across runs.
+func (f *Formatter) sortedInfos(infos []stat.FileInfo) []stat.FileInfo {
+	sorted := make([]listEntry, len(infos))
+	for i, fi := range infos {
+		sorted[i] = listEntry{FileInfo: fi, path: fi.FullPath()}
+	}
+
+	var less func(a, b *listEntry) bool
+	switch f.sort {
+	case SortBySize:
+		less = func(a, b *listEntry) bool { return a.Size > b.Size }
+	case SortByMtime:
+		less = func(a, b *listEntry) bool { return a.ModTime.Before(b.ModTime) }
+	case SortByOwner:
+		for i := range sorted {
+			sorted[i].owner = stat.Username(sorted[i].UID)
+		}
+		less = func(a, b *listEntry) bool { return a.owner < b.owner }
+	default:
+		less = func(a, b *listEntry) bool { return false }
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		a, b := &sorted[i], &sorted[j]
+		if less(a, b) {
+			return true
+		}
+		if less(b, a) {
+			return false
+		}
+		return a.path < b.path
+	})
+
+	out := make([]stat.FileInfo, len(sorted))
+	for i := range sorted {
+		out[i] = sorted[i].FileInfo
+	}
+	if f.reverse {
+		slices.Reverse(out)
+	}
+	return out
+}
+
+// listEntry caches the sort keys of an entry that are costly to compute.
+type listEntry struct {
+	stat.FileInfo
+	path  string
+	owner string
+}
+
+// listTable renders list output as a table. Sizes are formatted like in
+// the other tables.
+func (f *Formatter) listTable(infos []stat.FileInfo, cols, headers []string, scan *stat.ScanStat) string {
+	t := table.NewWriter()
+	if !f.noHeader {
+		headerRow := make(table.Row, len(headers))
+		for i, h := range headers {
+			headerRow[i] = h
+		}
+		t.AppendHeader(headerRow)
+	}
+
+	sizes := make([]int64, len(infos))
+	for i, fi := range infos {
+		sizes[i] = fi.Size
+	}
+	sizeCol := f.column(sizes, true)
+
+	for idx, fi := range infos {
+		row := make(table.Row, len(cols))
+		for i, col := range cols {
+			if col == "size" {
+				row[i] = sizeCol[idx]
+			} else {
+				row[i] = listValue(fi, col, true)
+			}
+		}
+		t.AppendRow(row)
+	}
+
+	return f.render(t, len(cols), scan)
+}
+
+// listValue returns the value of a list column for an entry. Times are
+// returned as time.Time for CSV, XLSX and JSON, and formatted like ls
+// --time-style=long-iso for tables.
+func listValue(fi stat.FileInfo, col string, forTable bool) interface{} {
+	switch col {
+	case "mode":
+		return symbolicMode(fi.Mode)
+	case "octal":
+		return octalMode(fi.Mode)
+	case "links":
+		return fi.Links
+	case "uid":
+		return fi.UID
+	case "gid":
+		return fi.GID
+	case "owner":
+		return stat.Username(fi.UID)
+	case "group":
+		return stat.Groupname(fi.GID)
+	case "size":
+		return fi.Size
+	case "mtime":
+		if forTable {
+			return fi.ModTime.Format("2006-01-02 15:04")
+		}
+		return fi.ModTime
+	case "type":
+		return entryType(fi)
+	case "depth":
+		return fi.Depth()
+	case "path":
+		return fi.FullPath()
+	default:
+		return nil
+	}
+}
+
+// entryType returns the inode type as used by the --type filter.
+func entryType(fi stat.FileInfo) string {
+	switch {
+	case fi.IsDir:
+		return "dir"
+	case fi.IsSymlink:
+		return "symlink"
+	case fi.Mode.IsRegular():
+		return "file"
+	default:
+		return "other"
+	}
+}
+
+// symbolicMode formats a mode like ls -l, for example "-rw-r--r--" or
+// "drwxrwxrwt". os.FileMode.String differs from ls for symlinks, devices
+// and the setuid, setgid and sticky bits.
+func symbolicMode(m os.FileMode) string {
+	b := []byte("?---------")
+	switch {
+	case m.IsDir():
+		b[0] = 'd'
+	case m&os.ModeSymlink != 0:
+		b[0] = 'l'
+	case m&os.ModeNamedPipe != 0:
+		b[0] = 'p'
+	case m&os.ModeSocket != 0:
+		b[0] = 's'
+	case m&os.ModeCharDevice != 0:
+		b[0] = 'c'
+	case m&os.ModeDevice != 0:
+		b[0] = 'b'
+	case m.IsRegular():
+		b[0] = '-'
+	}
+
+	const rwx = "rwxrwxrwx"
+	for i := 0; i < 9; i++ {
+		if m&(1<<uint(8-i)) != 0 {
+			b[i+1] = rwx[i]
+		}
+	}
+
+	special := func(pos int, set bool, lower, upper byte) {
+		if !set {
+			return
+		}
+		if b[pos] == 'x' {
+			b[pos] = lower
+		} else {
+			b[pos] = upper
+		}
+	}
+	special(3, m&os.ModeSetuid != 0, 's', 'S')
+	special(6, m&os.ModeSetgid != 0, 's', 'S')
+	special(9, m&os.ModeSticky != 0, 't', 'T')
+	return string(b)
+}
+
+// octalMode formats the permission bits of a mode in octal, including the
+// setuid, setgid and sticky bits, for example "0644" or "1777".
+func octalMode(m os.FileMode) string {
+	v := uint32(m.Perm())
+	if m&os.ModeSetuid != 0 {
+		v |= 04000
+	}
+	if m&os.ModeSetgid != 0 {
+		v |= 02000
+	}
+	if m&os.ModeSticky != 0 {
+		v |= 01000
+	}
+	return fmt.Sprintf("%04o", v)
+}
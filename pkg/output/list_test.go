@@ -0,0 +1,147 @@
+package output
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/otuschhoff/cwalk/pkg/stat"
+)
+
+func TestSymbolicMode(t *testing.T) {
+	tests := []struct {
+		mode os.FileMode
+		want string
+	}{
+		{0644, "-rw-r--r--"},
+		{os.ModeDir | 0755, "drwxr-xr-x"},
+		{os.ModeSymlink | 0777, "lrwxrwxrwx"},
+		{os.ModeDir | os.ModeSticky | 0777, "drwxrwxrwt"},
+		{os.ModeDir | os.ModeSticky | 0770, "drwxrwx--T"},
+		{os.ModeSetuid | 0755, "-rwsr-xr-x"},
+		{os.ModeSetgid | 0640, "-rw-r-S---"},
+		{os.ModeDevice | os.ModeCharDevice | 0620, "crw--w----"},
+		{os.ModeNamedPipe | 0600, "prw-------"},
+	}
+	for _, tt := range tests {
+		if got := symbolicMode(tt.mode); got != tt.want {
+			t.Errorf("symbolicMode(%v) = %q, want %q", tt.mode, got, tt.want)
+		}
+	}
+}
+
+func TestOctalMode(t *testing.T) {
+	tests := []struct {
+		mode os.FileMode
+		want string
+	}{
+		{0644, "0644"},
+		{os.ModeDir | 0755, "0755"},
+		{os.ModeDir | os.ModeSticky | 0777, "1777"},
+		{os.ModeSetuid | os.ModeSetgid | 0750, "6750"},
+	}
+	for _, tt := range tests {
+		if got := octalMode(tt.mode); got != tt.want {
+			t.Errorf("octalMode(%v) = %q, want %q", tt.mode, got, tt.want)
+		}
+	}
+}
+
+func TestParseColumns(t *testing.T) {
+	cols, err := ParseColumns("")
+	if err != nil || len(cols) != len(defaultListColumns) {
+		t.Errorf("ParseColumns(\"\") = %v, %v, want the defaults", cols, err)
+	}
+
+	cols, err = ParseColumns("octal, Owner,path")
+	if err != nil || len(cols) != 3 || cols[0] != "octal" || cols[1] != "owner" || cols[2] != "path" {
+		t.Errorf("ParseColumns() = %v, %v, want [octal owner path]", cols, err)
+	}
+
+	if _, err := ParseColumns("mode,inode"); err == nil {
+		t.Error("ParseColumns should reject unknown columns")
+	}
+}
+
+func TestFormatList(t *testing.T) {
+	mtime := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	results := &stat.Results{
+		AllFileInfos: []stat.FileInfo{
+			{Root: "/data", Path: "b.txt", Size: 5, Mode: 0644, ModTime: mtime, Links: 1},
+			{Root: "/data", Path: "", Size: 4096, Mode: os.ModeDir | os.ModeSticky | 0777, ModTime: mtime, IsDir: true, Links: 2},
+			{Root: "/data", Path: "a", Size: 3, Mode: os.ModeSymlink | 0777, ModTime: mtime, IsSymlink: true, Links: 1},
+		},
+	}
+
+	f := NewFormatter("csv", "list", false)
+	f.SetColumns([]string{"mode", "octal", "links", "size", "mtime", "type", "path"})
+	want := "MODE,OCTAL,LINKS,SIZE,MTIME,TYPE,PATH\n" +
+		"drwxrwxrwt,1777,2,4096,2024-03-01T12:00:00Z,dir,/data\n" +
+		"lrwxrwxrwx,0777,1,3,2024-03-01T12:00:00Z,symlink,/data/a\n" +
+		"-rw-r--r--,0644,1,5,2024-03-01T12:00:00Z,file,/data/b.txt\n"
+	if output := f.Format(results); output != want {
+		t.Errorf("csv output = %q, want %q", output, want)
+	}
+}
+
+func TestFormatListSort(t *testing.T) {
+	results := &stat.Results{
+		AllFileInfos: []stat.FileInfo{
+			{Root: "/data", Path: "new", Size: 10, Mode: 0644, ModTime: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+			{Root: "/data", Path: "big", Size: 300, Mode: 0644, ModTime: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)},
+			{Root: "/data", Path: "old", Size: 10, Mode: 0644, ModTime: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)},
+		},
+	}
+
+	tests := []struct {
+		key     SortKey
+		reverse bool
+		want    string
+	}{
+		{SortByPath, false, "PATH\n/data/big\n/data/new\n/data/old\n"},
+		{SortByPath, true, "PATH\n/data/old\n/data/new\n/data/big\n"},
+		{SortBySize, false, "PATH\n/data/big\n/data/new\n/data/old\n"},
+		{SortByMtime, false, "PATH\n/data/old\n/data/big\n/data/new\n"},
+		{SortByMtime, true, "PATH\n/data/new\n/data/big\n/data/old\n"},
+	}
+	for _, tt := range tests {
+		f := NewFormatter("csv", "list", false)
+		f.SetColumns([]string{"path"})
+		f.SetSort(tt.key)
+		f.SetReverse(tt.reverse)
+		if output := f.Format(results); output != tt.want {
+			t.Errorf("sort %v reverse %v: output = %q, want %q", tt.key, tt.reverse, output, tt.want)
+		}
+	}
+}
+
+func TestFormatPaths(t *testing.T) {
+	results := &stat.Results{
+		AllFileInfos: []stat.FileInfo{
+			{Root: "/data", Path: "b.txt", Size: 5, Mode: 0644},
+			{Root: "/data", Path: "", Mode: os.ModeDir | 0755, IsDir: true},
+			{Root: "/data", Path: "a\nb", Size: 300, Mode: 0644},
+		},
+	}
+
+	tests := []struct {
+		format string
+		null   bool
+		sort   SortKey
+		want   string
+	}{
+		{"table", false, SortByPath, "/data\n/data/a\nb\n/data/b.txt\n"},
+		{"table", true, SortByPath, "/data\x00/data/a\nb\x00/data/b.txt\x00"},
+		{"table", true, SortBySize, "/data/a\nb\x00/data/b.txt\x00/data\x00"},
+		{"csv", false, SortByPath, "PATH\n/data\n\"/data/a\nb\"\n/data/b.txt\n"},
+		{"json", false, SortByPath, "{\n  \"schemaVersion\": 1,\n  \"modes\": [\n    \"paths\"\n  ],\n  \"paths\": [\n    \"/data\",\n    \"/data/a\\nb\",\n    \"/data/b.txt\"\n  ]\n}"},
+	}
+	for _, tt := range tests {
+		f := NewFormatter(tt.format, "paths", false)
+		f.SetNull(tt.null)
+		f.SetSort(tt.sort)
+		if output := f.Format(results); output != tt.want {
+			t.Errorf("%s null %v sort %v: output = %q, want %q", tt.format, tt.null, tt.sort, output, tt.want)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package output
+
+import (
+	"strconv"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/otuschhoff/cwalk/pkg/stat"
+)
+
+// jsonPerProjectID returns the perProjectId section of JSON output.
+func jsonPerProjectID(results *stat.Results) []JSONProjectID {
+	stats := results.ByProjectID()
+	rows := make([]JSONProjectID, 0, len(stats))
+	for _, s := range stats {
+		rows = append(rows, JSONProjectID{ProjectID: s.ProjectID, Size: s.Size, Inodes: s.Inodes, Files: s.Files})
+	}
+	return rows
+}
+
+// jsonStriping returns the striping section of JSON output.
+func jsonStriping(results *stat.Results) []JSONStripe {
+	stats := results.Striping()
+	rows := make([]JSONStripe, 0, len(stats))
+	for _, s := range stats {
+		rows = append(rows, JSONStripe{StripeCount: s.Count, StripeSize: s.Size, Files: s.Files, Size: s.Bytes, Largest: s.Largest})
+	}
+	return rows
+}
+
+// formatPerProjectID formats the size, inodes and files of each project
+// ID, largest first.
+func (f *Formatter) formatPerProjectID(results *stat.Results) string {
+	stats := results.ByProjectID()
+	if len(stats) == 0 {
+		return "No entries\n"
+	}
+
+	var total int64
+	for _, s := range stats {
+		total += s.Size
+	}
+	pct := func(size int64) float64 {
+		if total == 0 {
+			return 0
+		}
+		return 100 * float64(size) / float64(total)
+	}
+
+	headers := []string{"Project ID", "Size", "Size %", "Inodes", "Files"}
+	switch f.format {
+	case "csv", "xlsx":
+		data := make([]map[string]interface{}, 0, len(stats))
+		for _, s := range stats {
+			data = append(data, map[string]interface{}{
+				"Project ID": s.ProjectID,
+				"Size":       byteSize(s.Size),
+				"Size %":     round2(pct(s.Size)),
+				"Inodes":     s.Inodes,
+				"Files":      s.Files,
+			})
+		}
+		if f.format == "csv" {
+			return f.toCSV(headers, data)
+		}
+		return f.toXLSX(headers, data)
+	}
+
+	n := len(stats)
+	sizes, pcts, inodes, files := make([]int64, n), make([]float64, n), make([]int64, n), make([]int64, n)
+	for i, s := range stats {
+		sizes[i], pcts[i], inodes[i], files[i] = s.Size, pct(s.Size), s.Inodes, s.Files
+	}
+	sizeCol := f.column(sizes, true)
+	pctCol := f.ratioColumn(pcts)
+	inodeCol := f.column(inodes, false)
+	fileCol := f.column(files, false)
+
+	t := table.NewWriter()
+	if !f.noHeader {
+		t.AppendHeader(table.Row{"Project ID", "Size", "Size %", "Inodes", "Files"})
+	}
+	for i, s := range stats {
+		t.AppendRow(table.Row{s.ProjectID, sizeCol[i], pctCol[i], inodeCol[i], fileCol[i]})
+	}
+
+	return f.render(t, len(headers), &results.Scan)
+}
+
+// stripeCount returns the stripe count column of a stripe, "-" for files
+// without a known layout.
+func stripeCount(s *stat.StripeStat) string {
+	if s.Count == 0 {
+		return "-"
+	}
+	return strconv.Itoa(s.Count)
+}
+
+// formatStriping formats the distribution of the regular files over
+// Lustre stripe counts and sizes.
+func (f *Formatter) formatStriping(results *stat.Results) string {
+	stats := results.Striping()
+	if len(stats) == 0 {
+		return "No regular files\n"
+	}
+
+	var total int64
+	for _, s := range stats {
+		total += s.Bytes
+	}
+	pct := func(size int64) float64 {
+		if total == 0 {
+			return 0
+		}
+		return 100 * float64(size) / float64(total)
+	}
+
+	headers := []string{"Stripe Count", "Stripe Size", "Files", "Size", "Size %", "Largest"}
+	switch f.format {
+	case "csv", "xlsx":
+		data := make([]map[string]interface{}, 0, len(stats))
+		for i := range stats {
+			s := &stats[i]
+			data = append(data, map[string]interface{}{
+				"Stripe Count": stripeCount(s),
+				"Stripe Size":  byteSize(s.Size),
+				"Files":        s.Files,
+				"Size":         byteSize(s.Bytes),
+				"Size %":       round2(pct(s.Bytes)),
+				"Largest":      byteSize(s.Largest),
+			})
+		}
+		if f.format == "csv" {
+			return f.toCSV(headers, data)
+		}
+		return f.toXLSX(headers, data)
+	}
+
+	n := len(stats)
+	stripeSizes, files, sizes, pcts, largest := make([]int64, n), make([]int64, n), make([]int64, n), make([]float64, n), make([]int64, n)
+	for i, s := range stats {
+		stripeSizes[i], files[i], sizes[i], pcts[i], largest[i] = s.Size, s.Files, s.Bytes, pct(s.Bytes), s.Largest
+	}
+	stripeSizeCol := f.column(stripeSizes, true)
+	fileCol := f.column(files, false)
+	sizeCol := f.column(sizes, true)
+	pctCol := f.ratioColumn(pcts)
+	largestCol := f.column(largest, true)
+
+	t := table.NewWriter()
+	if !f.noHeader {
+		t.AppendHeader(table.Row{"Stripe Count", "Stripe Size", "Files", "Size", "Size %", "Largest"})
+	}
+	for i := range stats {
+		stripeSize := stripeSizeCol[i]
+		if stats[i].Count == 0 {
+			stripeSize = ""
+		}
+		t.AppendRow(table.Row{stripeCount(&stats[i]), stripeSize, fileCol[i], sizeCol[i], pctCol[i], largestCol[i]})
+	}
+
+	return f.render(t, len(headers), &results.Scan)
+}
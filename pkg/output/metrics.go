@@ -0,0 +1,237 @@
+package output
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+
+	"github.com/otuschhoff/cwalk/pkg/stat"
+)
+
+// SortKey selects the column per-year, per-UID, groups and list output is
+// sorted by.
+type SortKey int
+
+const (
+	// SortByGroup sorts by the grouping column: years descending, UIDs
+	// ascending, cross-tabulated groups by their keys (the default).
+	SortByGroup SortKey = iota
+	// SortBySize sorts by total size, largest first.
+	SortBySize
+	// SortByInodes sorts by inode count, largest first.
+	SortByInodes
+	// SortByFiles sorts by regular file count, largest first.
+	SortByFiles
+	// SortByDirs sorts by directory count, largest first.
+	SortByDirs
+	// SortByAvgFileSize sorts by average regular file size, largest first.
+	SortByAvgFileSize
+	// SortByFilesPerDir sorts by regular files per directory, largest first.
+	SortByFilesPerDir
+	// SortBySymlinkPct sorts by the share of symlinks, largest first.
+	SortBySymlinkPct
+	// SortByMtime sorts list output by modification time, oldest first.
+	SortByMtime
+	// SortByPath sorts list output by path. It is the default for list
+	// output.
+	SortByPath
+	// SortByOwner sorts list output by owner name, then by path.
+	SortByOwner
+	// SortByName sorts per-UID output by username, then by UID, and
+	// cross-tabulated groups by their keys.
+	SortByName
+	// SortByYear sorts per-year output and cross-tabulated groups with a
+	// year dimension by year, newest first, with unknown years last.
+	SortByYear
+)
+
+// groupMetrics holds the counters of one per-year or per-UID group, from
+// which the computed columns are derived.
+type groupMetrics struct {
+	size      int64
+	inodes    int64
+	files     int64
+	dirs      int64
+	symlinks  int64
+	filesSize int64
+}
+
+func yearMetrics(s *stat.YearStat) groupMetrics {
+	return groupMetrics{s.TotalSize, s.TotalInodes, s.Files, s.Dirs, s.Symlinks, s.FilesSize}
+}
+
+func uidMetrics(s *stat.UIDStat) groupMetrics {
+	return groupMetrics{s.TotalSize, s.TotalInodes, s.Files, s.Dirs, s.Symlinks, s.FilesSize}
+}
+
+// avgFileSize returns the average size of regular files, or 0 if there
+// are none.
+func (g groupMetrics) avgFileSize() int64 {
+	if g.files == 0 {
+		return 0
+	}
+	return g.filesSize / g.files
+}
+
+// filesPerDir returns the average number of regular files per directory,
+// or 0 if there are no directories.
+func (g groupMetrics) filesPerDir() float64 {
+	if g.dirs == 0 {
+		return 0
+	}
+	return float64(g.files) / float64(g.dirs)
+}
+
+// symlinkPct returns the percentage of inodes that are symlinks.
+func (g groupMetrics) symlinkPct() float64 {
+	if g.inodes == 0 {
+		return 0
+	}
+	return 100 * float64(g.symlinks) / float64(g.inodes)
+}
+
+// value returns the value of the column selected by key.
+func (g groupMetrics) value(key SortKey) float64 {
+	switch key {
+	case SortBySize:
+		return float64(g.size)
+	case SortByInodes:
+		return float64(g.inodes)
+	case SortByFiles:
+		return float64(g.files)
+	case SortByDirs:
+		return float64(g.dirs)
+	case SortByAvgFileSize:
+		return float64(g.avgFileSize())
+	case SortByFilesPerDir:
+		return g.filesPerDir()
+	case SortBySymlinkPct:
+		return g.symlinkPct()
+	default:
+		return 0
+	}
+}
+
+// sortedYears returns the years of byYear in output order.
+func (f *Formatter) sortedYears(byYear map[int]*stat.YearStat) []int {
+	var years []int
+	for year := range byYear {
+		years = append(years, year)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(years)))
+	if f.sort != SortByGroup && f.sort != SortByYear {
+		sort.SliceStable(years, func(i, j int) bool {
+			return yearMetrics(byYear[years[i]]).value(f.sort) > yearMetrics(byYear[years[j]]).value(f.sort)
+		})
+	}
+	if f.reverse {
+		slices.Reverse(years)
+	}
+	return limited(f, years)
+}
+
+// sortedUIDs returns the UIDs of byUID in output order.
+func (f *Formatter) sortedUIDs(byUID map[uint32]*stat.UIDStat) []uint32 {
+	var uids []uint32
+	for uid := range byUID {
+		uids = append(uids, uid)
+	}
+	sort.Slice(uids, func(i, j int) bool { return uids[i] < uids[j] })
+	switch f.sort {
+	case SortByGroup:
+	case SortByName:
+		sort.SliceStable(uids, func(i, j int) bool {
+			return byUID[uids[i]].Username < byUID[uids[j]].Username
+		})
+	default:
+		sort.SliceStable(uids, func(i, j int) bool {
+			return uidMetrics(byUID[uids[i]]).value(f.sort) > uidMetrics(byUID[uids[j]]).value(f.sort)
+		})
+	}
+	if f.reverse {
+		slices.Reverse(uids)
+	}
+	return limited(f, uids)
+}
+
+// limited returns the first rows of sorted output, as many as SetLimit
+// allows.
+func limited[T any](f *Formatter, rows []T) []T {
+	if f.limit > 0 && len(rows) > f.limit {
+		return rows[:f.limit]
+	}
+	return rows
+}
+
+// yearTotal returns the sum of the statistics of all years.
+func yearTotal(byYear map[int]*stat.YearStat) stat.YearStat {
+	var t stat.YearStat
+	for _, s := range byYear {
+		t.TotalSize += s.TotalSize
+		t.TotalInodes += s.TotalInodes
+		t.Files += s.Files
+		t.Dirs += s.Dirs
+		t.Symlinks += s.Symlinks
+		t.Others += s.Others
+		t.FilesSize += s.FilesSize
+		t.DirsSize += s.DirsSize
+		t.SymlinksSize += s.SymlinksSize
+		t.OthersSize += s.OthersSize
+	}
+	return t
+}
+
+// uidTotal returns the sum of the statistics of all UIDs.
+func uidTotal(byUID map[uint32]*stat.UIDStat) stat.UIDStat {
+	var t stat.UIDStat
+	for _, s := range byUID {
+		t.TotalSize += s.TotalSize
+		t.TotalInodes += s.TotalInodes
+		t.Files += s.Files
+		t.Dirs += s.Dirs
+		t.Symlinks += s.Symlinks
+		t.Others += s.Others
+		t.FilesSize += s.FilesSize
+		t.DirsSize += s.DirsSize
+		t.SymlinksSize += s.SymlinksSize
+		t.OthersSize += s.OthersSize
+	}
+	return t
+}
+
+// percentOf returns the percentage of total that v is, or 0 if total is 0.
+func percentOf(v, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return 100 * float64(v) / float64(total)
+}
+
+// round2 rounds a ratio to two decimals for CSV, XLSX and JSON output.
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// round3 rounds seconds and fractions to three decimals for JSON output.
+func round3(v float64) float64 {
+	return math.Round(v*1000) / 1000
+}
+
+// ratioColumn formats a table column of ratios with one decimal, padded to
+// a common width unless in plain mode.
+func (f *Formatter) ratioColumn(values []float64) []string {
+	out := make([]string, len(values))
+	width := 0
+	for i, v := range values {
+		out[i] = strconv.FormatFloat(v, 'f', 1, 64)
+		width = max(width, len(out[i]))
+	}
+	if !f.plain {
+		for i := range out {
+			out[i] = fmt.Sprintf("%*s", width, out[i])
+		}
+	}
+	return out
+}
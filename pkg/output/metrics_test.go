@@ -0,0 +1,106 @@
+package output
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/otuschhoff/cwalk/pkg/stat"
+)
+
+func TestGroupMetrics(t *testing.T) {
+	m := groupMetrics{size: 4096, inodes: 8, files: 4, dirs: 3, symlinks: 2, filesSize: 4000}
+	if got := m.avgFileSize(); got != 1000 {
+		t.Errorf("avgFileSize() = %d, want 1000", got)
+	}
+	if got := round2(m.filesPerDir()); got != 1.33 {
+		t.Errorf("filesPerDir() = %v, want 1.33", got)
+	}
+	if got := m.symlinkPct(); got != 25 {
+		t.Errorf("symlinkPct() = %v, want 25", got)
+	}
+
+	// Empty groups must not divide by zero
+	var empty groupMetrics
+	if empty.avgFileSize() != 0 || empty.filesPerDir() != 0 || empty.symlinkPct() != 0 {
+		t.Errorf("metrics of an empty group should be 0")
+	}
+}
+
+func TestFormatComputedColumns(t *testing.T) {
+	results := &stat.Results{
+		ByUID: map[uint32]*stat.UIDStat{
+			1000: {UID: 1000, Username: "alice", TotalSize: 3000, TotalInodes: 4, Files: 2, Dirs: 1, Symlinks: 1, FilesSize: 3000},
+			1001: {UID: 1001, Username: "bob", TotalSize: 100, TotalInodes: 11, Files: 10, Dirs: 1, FilesSize: 100},
+		},
+	}
+
+	f := NewFormatter("csv", "per-uid", false)
+	want := "UID,Username,Size,Inodes,Files,Dirs,Symlinks,Others,FilesSize,DirsSize,AvgFileSize,FilesPerDir,SymlinkPct\n" +
+		"1000,alice,2.9 KB,4,2,1,1,0,2.9 KB,0 B,1.5 KB,2,25\n" +
+		"1001,bob,100 B,11,10,1,0,0,100 B,0 B,10 B,10,0\n"
+	if output := f.Format(results); output != want {
+		t.Errorf("csv output = %q, want %q", output, want)
+	}
+
+	f = NewFormatter("table", "per-uid", false)
+	output := f.Format(results)
+	for _, header := range []string{"AVG FILE SIZE", "FILES/DIR", "SYMLINKS %"} {
+		if !strings.Contains(output, header) {
+			t.Errorf("table should contain column %q, got %q", header, output)
+		}
+	}
+}
+
+func TestFormatSort(t *testing.T) {
+	results := &stat.Results{
+		ByYear: map[int]*stat.YearStat{
+			2022: {Year: 2022, TotalSize: 100, TotalInodes: 11, Files: 10, Dirs: 1, FilesSize: 100},
+			2023: {Year: 2023, TotalSize: 3000, TotalInodes: 3, Files: 2, Dirs: 1, FilesSize: 3000},
+			2024: {Year: 2024, TotalSize: 50, TotalInodes: 2, Files: 1, Dirs: 1, FilesSize: 50},
+		},
+		ByUID: map[uint32]*stat.UIDStat{
+			1000: {UID: 1000, Username: "zoe", TotalSize: 3000, TotalInodes: 3, Files: 2, Dirs: 1, FilesSize: 3000},
+			1001: {UID: 1001, Username: "adam", TotalSize: 100, TotalInodes: 11, Files: 10, Dirs: 1, FilesSize: 100},
+		},
+		GroupColumns: []string{"ext", "year"},
+		Groups: map[string]*stat.GroupStat{
+			"go\x002023":     {Keys: []string{"go", "2023"}, TotalSize: 10},
+			"md\x002024":     {Keys: []string{"md", "2024"}, TotalSize: 30},
+			"txt\x00unknown": {Keys: []string{"txt", "unknown"}, TotalSize: 20},
+		},
+	}
+
+	tests := []struct {
+		mode  string
+		key   SortKey
+		limit int
+		want  []string
+	}{
+		{"per-year", SortByGroup, 0, []string{"2024", "2023", "2022"}},
+		{"per-year", SortBySize, 0, []string{"2023", "2022", "2024"}},
+		{"per-year", SortByFilesPerDir, 0, []string{"2022", "2023", "2024"}},
+		{"per-year", SortByYear, 0, []string{"2024", "2023", "2022"}},
+		{"per-year", SortBySize, 2, []string{"2023", "2022"}},
+		{"per-uid", SortByGroup, 0, []string{"1000", "1001"}},
+		{"per-uid", SortByAvgFileSize, 0, []string{"1000", "1001"}},
+		{"per-uid", SortByInodes, 0, []string{"1001", "1000"}},
+		{"per-uid", SortByName, 0, []string{"1001", "1000"}},
+		{"per-uid", SortByGroup, 1, []string{"1000"}},
+		{"groups", SortByGroup, 0, []string{"go", "md", "txt"}},
+		{"groups", SortByYear, 0, []string{"md", "go", "txt"}},
+		{"groups", SortBySize, 2, []string{"md", "txt"}},
+	}
+	for _, tt := range tests {
+		f := NewFormatter("csv", tt.mode, false)
+		f.SetSort(tt.key)
+		f.SetLimit(tt.limit)
+		lines := strings.Split(strings.TrimSpace(f.Format(results)), "\n")[1:]
+		var got []string
+		for _, line := range lines {
+			got = append(got, strings.SplitN(line, ",", 2)[0])
+		}
+		if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+			t.Errorf("%s sorted by %d, limit %d = %v, want %v", tt.mode, tt.key, tt.limit, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package output
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/otuschhoff/cwalk/pkg/stat"
+)
+
+// Modes are the output modes, in the order they are documented.
+var Modes = []string{
+	"summary", "per-year", "per-uid", "groups", "per-fs", "per-depth", "inode-usage",
+	"symlinks", "random-names", "watchlist", "churn", "list", "paths", "empty",
+	"fan-out", "xattrs", "selinux", "audit", "homes", "quota", "projects", "ownership", "per-project-id", "striping", "stats",
+}
+
+// AllModes are the modes selected by "all": the aggregate reports that
+// need neither extra collection during the walk nor options such as
+// --group-by, and do not list every entry.
+var AllModes = []string{"summary", "per-year", "per-uid", "per-fs", "per-depth", "inode-usage", "symlinks", "fan-out"}
+
+// ParseModes parses a comma-separated list of output modes, in which "all"
+// stands for AllModes. Modes given twice are only kept once.
+func ParseModes(s string) ([]string, error) {
+	var modes []string
+	for _, mode := range strings.Split(s, ",") {
+		mode = strings.ToLower(strings.TrimSpace(mode))
+		switch {
+		case mode == "all":
+			for _, m := range AllModes {
+				if !slices.Contains(modes, m) {
+					modes = append(modes, m)
+				}
+			}
+		case !slices.Contains(Modes, mode):
+			return nil, fmt.Errorf("unknown output mode %q (available: %s, all)", mode, strings.Join(Modes, ", "))
+		case !slices.Contains(modes, mode):
+			modes = append(modes, mode)
+		}
+	}
+	return modes, nil
+}
+
+// SetModes makes Format write a section per mode, all from the same
+// results, instead of the mode given to NewFormatter: JSON output is a
+// single document, XLSX output has a sheet per mode, and table and CSV
+// output have a section per mode, headed by its name and separated by an
+// empty line.
+func (f *Formatter) SetModes(modes []string) {
+	f.modes = modes
+}
+
+// formatModes formats the sections of the modes set by SetModes. Tables
+// only have the footer after the last section.
+func (f *Formatter) formatModes(results *stat.Results) string {
+	if f.format == "json" {
+		return f.formatJSON(results)
+	}
+
+	var b strings.Builder
+	if f.format == "xlsx" {
+		if err := writeXLSX(&b, f.modeSheets(results)); err != nil {
+			return fmt.Sprintf("Error: %v\n", err)
+		}
+		return b.String()
+	}
+
+	for i, mode := range f.modes {
+		section := *f
+		section.mode, section.modes = mode, nil
+		section.footer = f.footer && i == len(f.modes)-1
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(mode + "\n")
+		b.WriteString(section.Format(results))
+	}
+	return b.String()
+}
+
+// modeSheets returns the XLSX sheets of the modes of results, one per
+// mode, named after it.
+func (f *Formatter) modeSheets(results *stat.Results) []xlsxSheet {
+	var sheets []xlsxSheet
+	for _, mode := range f.jsonModes() {
+		section := *f
+		section.mode, section.modes, section.roots = mode, nil, false
+		section.sheets = &sheets
+		if out := section.Format(results); out != "" {
+			// Modes without data describe why instead of adding a sheet
+			sheets = append(sheets, xlsxSheet{mode, [][]interface{}{{strings.TrimSpace(out)}}})
+		}
+	}
+	return sheets
+}
+
+// jsonModes returns the modes of JSON output: those set by SetModes, or
+// the mode given to NewFormatter.
+func (f *Formatter) jsonModes() []string {
+	if len(f.modes) > 0 {
+		return f.modes
+	}
+	return []string{f.mode}
+}
@@ -0,0 +1,81 @@
+package output
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/otuschhoff/cwalk/pkg/stat"
+)
+
+func TestParseModes(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    []string
+		wantErr bool
+	}{
+		{input: "summary", want: []string{"summary"}},
+		{input: "summary, Per-Year,per-uid", want: []string{"summary", "per-year", "per-uid"}},
+		{input: "per-uid,per-uid", want: []string{"per-uid"}},
+		{input: "all", want: AllModes},
+		{input: "list,all,summary", want: append([]string{"list"}, AllModes...)},
+		{input: "summary,bogus", wantErr: true},
+		{input: "summary,", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseModes(tt.input)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseModes(%q) should fail", tt.input)
+			}
+			continue
+		}
+		if err != nil || !slices.Equal(got, tt.want) {
+			t.Errorf("ParseModes(%q) = %v, %v; want %v", tt.input, got, err, tt.want)
+		}
+	}
+}
+
+func TestFormatModes(t *testing.T) {
+	results := &stat.Results{
+		Summary: &stat.SummaryStat{TotalSize: 2048, TotalInodes: 2, Files: 1, Dirs: 1, FilesSize: 2048},
+		ByUID:   map[uint32]*stat.UIDStat{1000: {UID: 1000, Username: "alice", TotalSize: 2048, TotalInodes: 2, Files: 1, Dirs: 1}},
+	}
+	modes := []string{"summary", "per-uid"}
+
+	f := NewFormatter("csv", "summary", false)
+	f.SetModes(modes)
+	out := f.Format(results)
+	sections := strings.Split(out, "\n\n")
+	if len(sections) != 2 || !strings.HasPrefix(sections[0], "summary\n") || !strings.HasPrefix(sections[1], "per-uid\n") {
+		t.Errorf("csv output should have a section per mode, got %q", out)
+	}
+	if !strings.Contains(sections[1], "alice") {
+		t.Errorf("per-uid section should list alice, got %q", sections[1])
+	}
+
+	f = NewFormatter("json", "summary", false)
+	f.SetModes(modes)
+	var doc JSONDocument
+	if err := json.Unmarshal([]byte(f.Format(results)), &doc); err != nil {
+		t.Fatalf("output is not a JSON document: %v", err)
+	}
+	if !slices.Equal(doc.Modes, modes) || doc.Summary == nil || len(doc.PerUID) != 1 {
+		t.Errorf("JSON document should have both sections, got %+v", doc)
+	}
+
+	f = NewFormatter("xlsx", "summary", false)
+	f.SetModes(modes)
+	out = f.Format(results)
+	workbook := readXLSXPart(t, out, "xl/workbook.xml")
+	for _, want := range []string{`<sheet name="summary"`, `<sheet name="per-uid"`} {
+		if !strings.Contains(workbook, want) {
+			t.Errorf("workbook should contain %s, got %s", want, workbook)
+		}
+	}
+	if sheet := readXLSXPart(t, out, "xl/worksheets/sheet2.xml"); !strings.Contains(sheet, "alice") {
+		t.Errorf("second sheet should list alice, got %s", sheet)
+	}
+}
@@ -0,0 +1,91 @@
+package output
+
+import (
+	"strconv"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/otuschhoff/cwalk/pkg/stat"
+)
+
+// jsonOwnership returns the ownership section of JSON output.
+func jsonOwnership(results *stat.Results) []JSONOwnership {
+	dirs := results.Ownership()
+	rows := make([]JSONOwnership, 0, len(dirs))
+	for i := range dirs {
+		d := &dirs[i]
+		rows = append(rows, JSONOwnership{
+			Path:      d.Path,
+			Size:      d.Size,
+			Inodes:    d.Inodes,
+			Owners:    d.Owners,
+			UID:       d.UID,
+			Owner:     d.Owner,
+			OwnerSize: d.OwnerSize,
+			OwnerPct:  round2(d.OwnerPct()),
+			Entropy:   round3(d.Entropy),
+		})
+	}
+	return rows
+}
+
+// formatOwnership formats the ownership of each top-level directory: its
+// dominant owner by bytes, the owner's share and the ownership entropy in
+// bits, most mixed first.
+func (f *Formatter) formatOwnership(results *stat.Results) string {
+	dirs := results.Ownership()
+	if len(dirs) == 0 {
+		return "No directories directly below the scanned roots\n"
+	}
+
+	headers := []string{"Directory", "Owner", "Owner Size", "Owner %", "Owners", "Entropy", "Size", "Inodes"}
+	switch f.format {
+	case "csv", "xlsx":
+		data := make([]map[string]interface{}, 0, len(dirs))
+		for i := range dirs {
+			d := &dirs[i]
+			data = append(data, map[string]interface{}{
+				"Directory":  d.Path,
+				"Owner":      d.Owner,
+				"Owner Size": byteSize(d.OwnerSize),
+				"Owner %":    round2(d.OwnerPct()),
+				"Owners":     d.Owners,
+				"Entropy":    round3(d.Entropy),
+				"Size":       byteSize(d.Size),
+				"Inodes":     d.Inodes,
+			})
+		}
+		if f.format == "csv" {
+			return f.toCSV(headers, data)
+		}
+		return f.toXLSX(headers, data)
+	}
+
+	n := len(dirs)
+	ownerSizes, pcts, owners, sizes, inodes := make([]int64, n), make([]float64, n), make([]int64, n), make([]int64, n), make([]int64, n)
+	entropies := make([]string, n)
+	for i := range dirs {
+		d := &dirs[i]
+		ownerSizes[i], pcts[i], owners[i], sizes[i], inodes[i] = d.OwnerSize, d.OwnerPct(), int64(d.Owners), d.Size, d.Inodes
+		entropies[i] = strconv.FormatFloat(d.Entropy, 'f', 2, 64)
+	}
+	ownerSizeCol := f.column(ownerSizes, true)
+	pctCol := f.ratioColumn(pcts)
+	ownerCol := f.column(owners, false)
+	sizeCol := f.column(sizes, true)
+	inodeCol := f.column(inodes, false)
+	if !f.plain {
+		entropies = padLeft(entropies)
+	}
+
+	t := table.NewWriter()
+	if !f.noHeader {
+		t.AppendHeader(table.Row{"Directory", "Owner", "Owner Size", "Owner %", "Owners", "Entropy", "Size", "Inodes"})
+	}
+	for i := range dirs {
+		t.AppendRow(table.Row{
+			dirs[i].Path, dirs[i].Owner, ownerSizeCol[i], pctCol[i], ownerCol[i], entropies[i], sizeCol[i], inodeCol[i],
+		})
+	}
+
+	return f.render(t, len(headers), &results.Scan)
+}
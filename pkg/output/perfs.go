@@ -0,0 +1,106 @@
+package output
+
+import (
+	"strconv"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/otuschhoff/cwalk/pkg/stat"
+)
+
+// jsonPerFS returns the perFs section of JSON output.
+func jsonPerFS(results *stat.Results) []JSONFS {
+	filesystems := results.SortedFS()
+	rows := make([]JSONFS, 0, len(filesystems))
+	for _, s := range filesystems {
+		rows = append(rows, JSONFS{
+			Dev:        s.Dev,
+			Mountpoint: s.Mountpoint,
+			Type:       s.Type,
+			Source:     s.Source,
+			Size:       s.TotalSize,
+			Inodes:     s.TotalInodes,
+			Files:      s.Files,
+			Dirs:       s.Dirs,
+			Symlinks:   s.Symlinks,
+			Others:     s.Others,
+		})
+	}
+	return rows
+}
+
+// formatPerFS formats statistics grouped by the file system entries reside
+// on, ordered by mount point. Entries whose device is unknown, such as
+// those read over SFTP or from archives, are grouped under device 0.
+func (f *Formatter) formatPerFS(results *stat.Results) string {
+	filesystems := results.SortedFS()
+
+	headers := []string{"Mountpoint", "Type", "Source", "Dev", "Size", "Inodes", "Files", "Dirs", "Symlinks", "Others"}
+	switch f.format {
+	case "csv", "xlsx":
+		data := make([]map[string]interface{}, 0, len(filesystems))
+		for _, s := range filesystems {
+			data = append(data, map[string]interface{}{
+				"Mountpoint": s.Mountpoint,
+				"Type":       s.Type,
+				"Source":     s.Source,
+				"Dev":        s.Dev,
+				"Size":       byteSize(s.TotalSize),
+				"Inodes":     s.TotalInodes,
+				"Files":      s.Files,
+				"Dirs":       s.Dirs,
+				"Symlinks":   s.Symlinks,
+				"Others":     s.Others,
+			})
+		}
+		if f.format == "csv" {
+			return f.toCSV(headers, data)
+		}
+		return f.toXLSX(headers, data)
+	}
+
+	return f.perFSTable(filesystems, &results.Scan)
+}
+
+// perFSTable renders per-file-system statistics as a table with aligned
+// size and count columns.
+func (f *Formatter) perFSTable(filesystems []*stat.FSStat, scan *stat.ScanStat) string {
+	t := table.NewWriter()
+	headers := table.Row{"Mountpoint", "Type", "Source", "Dev", "Size", "Inodes", "Files", "Dirs", "Symlinks", "Others"}
+	if !f.noHeader {
+		t.AppendHeader(headers)
+	}
+
+	n := len(filesystems)
+	sizes, inodes := make([]int64, n), make([]int64, n)
+	files, dirs := make([]int64, n), make([]int64, n)
+	symlinks, others := make([]int64, n), make([]int64, n)
+	for i, s := range filesystems {
+		sizes[i], inodes[i] = s.TotalSize, s.TotalInodes
+		files[i], dirs[i] = s.Files, s.Dirs
+		symlinks[i], others[i] = s.Symlinks, s.Others
+	}
+	sizeCol := f.column(sizes, true)
+	inodeCol := f.column(inodes, false)
+	fileCol := f.column(files, false)
+	dirCol := f.column(dirs, false)
+	symlinkCol := f.column(symlinks, false)
+	otherCol := f.column(others, false)
+
+	for i, s := range filesystems {
+		t.AppendRow(table.Row{
+			s.Mountpoint, s.Type, s.Source, devLabel(s.Dev),
+			sizeCol[i], inodeCol[i], fileCol[i], dirCol[i], symlinkCol[i], otherCol[i],
+		})
+	}
+
+	return f.render(t, len(headers), scan)
+}
+
+// devLabel formats a device number for tables, leaving unknown devices
+// blank.
+func devLabel(dev uint64) string {
+	if dev == 0 {
+		return ""
+	}
+	return strconv.FormatUint(dev, 10)
+}
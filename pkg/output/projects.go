@@ -0,0 +1,116 @@
+package output
+
+import (
+	"strings"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/otuschhoff/cwalk/pkg/stat"
+)
+
+// noProject labels the entries outside all projects in tables.
+const noProject = "(no project)"
+
+// projectName returns the project column of a project.
+func projectName(p *stat.ProjectStat) string {
+	if p.Path == "" {
+		return noProject
+	}
+	return p.Path
+}
+
+// jsonProjects returns the projects section of JSON output.
+func jsonProjects(results *stat.Results) []JSONProject {
+	projects := results.Projects()
+	rows := make([]JSONProject, 0, len(projects))
+	for _, p := range projects {
+		row := JSONProject{
+			Markers: append([]string{}, p.Markers...),
+			Size:    p.Size,
+			Inodes:  p.Inodes,
+			Files:   p.Files,
+		}
+		if p.Path != "" {
+			row.Path = ptr(p.Path)
+		}
+		if !p.Newest.IsZero() {
+			row.Newest = ptr(p.Newest)
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// formatProjects formats the per-project report: each project directory
+// with its markers, the size, inodes and files attributed to it, and its
+// latest modification, largest first, followed by the entries outside all
+// projects.
+func (f *Formatter) formatProjects(results *stat.Results) string {
+	projects := results.Projects()
+	if len(projects) == 0 {
+		return "No projects: no entries\n"
+	}
+
+	var total int64
+	for _, p := range projects {
+		total += p.Size
+	}
+	pct := func(size int64) float64 {
+		if total == 0 {
+			return 0
+		}
+		return 100 * float64(size) / float64(total)
+	}
+
+	headers := []string{"Project", "Markers", "Size", "Size %", "Inodes", "Files", "Newest"}
+	switch f.format {
+	case "csv", "xlsx":
+		data := make([]map[string]interface{}, 0, len(projects))
+		for i := range projects {
+			p := &projects[i]
+			row := map[string]interface{}{
+				"Project": projectName(p),
+				"Markers": strings.Join(p.Markers, " "),
+				"Size":    byteSize(p.Size),
+				"Size %":  round2(pct(p.Size)),
+				"Inodes":  p.Inodes,
+				"Files":   p.Files,
+				"Newest":  "",
+			}
+			if !p.Newest.IsZero() {
+				row["Newest"] = p.Newest
+			}
+			data = append(data, row)
+		}
+		if f.format == "csv" {
+			return f.toCSV(headers, data)
+		}
+		return f.toXLSX(headers, data)
+	}
+
+	n := len(projects)
+	sizes, inodes, files, pcts := make([]int64, n), make([]int64, n), make([]int64, n), make([]float64, n)
+	for i, p := range projects {
+		sizes[i], inodes[i], files[i], pcts[i] = p.Size, p.Inodes, p.Files, pct(p.Size)
+	}
+	sizeCol := f.column(sizes, true)
+	pctCol := f.ratioColumn(pcts)
+	inodeCol := f.column(inodes, false)
+	fileCol := f.column(files, false)
+
+	t := table.NewWriter()
+	if !f.noHeader {
+		t.AppendHeader(table.Row{"Project", "Markers", "Size", "Size %", "Inodes", "Files", "Newest"})
+	}
+	for i := range projects {
+		p := &projects[i]
+		var newest string
+		if !p.Newest.IsZero() {
+			newest = p.Newest.Format("2006-01-02 15:04")
+		}
+		t.AppendRow(table.Row{
+			projectName(p), strings.Join(p.Markers, " "), sizeCol[i], pctCol[i], inodeCol[i], fileCol[i], newest,
+		})
+	}
+
+	return f.render(t, len(headers), &results.Scan)
+}
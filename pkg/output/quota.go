@@ -0,0 +1,94 @@
+package output
+
+import (
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/otuschhoff/cwalk/pkg/stat"
+)
+
+// quotaType returns the type column of a quota: user or dir.
+func quotaType(q *stat.QuotaStat) string {
+	if q.Dir != "" {
+		return "dir"
+	}
+	return "user"
+}
+
+// quotaStatus returns the status column of a quota.
+func quotaStatus(q *stat.QuotaStat) string {
+	if q.Exceeded() {
+		return "EXCEEDED"
+	}
+	return "ok"
+}
+
+// jsonQuota returns the quota section of JSON output, empty unless quotas
+// were checked.
+func jsonQuota(results *stat.Results) []JSONQuota {
+	rows := make([]JSONQuota, 0, len(results.Quotas))
+	for i := range results.Quotas {
+		q := &results.Quotas[i]
+		rows = append(rows, JSONQuota{
+			Target:   q.Target(),
+			Type:     quotaType(q),
+			Limit:    q.Limit,
+			Used:     q.Used,
+			Inodes:   q.Inodes,
+			UsedPct:  round2(q.Pct()),
+			Exceeded: q.Exceeded(),
+		})
+	}
+	return rows
+}
+
+// formatQuota formats the usage of each quota against its limit, in the
+// order of the quota file, flagging the exceeded ones.
+func (f *Formatter) formatQuota(results *stat.Results) string {
+	quotas := results.Quotas
+	if quotas == nil {
+		return "No quotas: quotas are only checked with --quota-file\n"
+	}
+
+	headers := []string{"Target", "Type", "Limit", "Used", "Inodes", "UsedPct", "Status"}
+	switch f.format {
+	case "csv", "xlsx":
+		data := make([]map[string]interface{}, 0, len(quotas))
+		for i := range quotas {
+			q := &quotas[i]
+			data = append(data, map[string]interface{}{
+				"Target":  q.Target(),
+				"Type":    quotaType(q),
+				"Limit":   byteSize(q.Limit),
+				"Used":    byteSize(q.Used),
+				"Inodes":  q.Inodes,
+				"UsedPct": round2(q.Pct()),
+				"Status":  quotaStatus(q),
+			})
+		}
+		if f.format == "csv" {
+			return f.toCSV(headers, data)
+		}
+		return f.toXLSX(headers, data)
+	}
+
+	n := len(quotas)
+	limits, used, inodes, pcts := make([]int64, n), make([]int64, n), make([]int64, n), make([]float64, n)
+	for i := range quotas {
+		q := &quotas[i]
+		limits[i], used[i], inodes[i], pcts[i] = q.Limit, q.Used, q.Inodes, q.Pct()
+	}
+	limitCol := f.column(limits, true)
+	usedCol := f.column(used, true)
+	inodeCol := f.column(inodes, false)
+	pctCol := f.ratioColumn(pcts)
+
+	t := table.NewWriter()
+	if !f.noHeader {
+		t.AppendHeader(table.Row{"Target", "Type", "Limit", "Used", "Inodes", "Used %", "Status"})
+	}
+	for i := range quotas {
+		q := &quotas[i]
+		t.AppendRow(table.Row{q.Target(), quotaType(q), limitCol[i], usedCol[i], inodeCol[i], pctCol[i], quotaStatus(q)})
+	}
+
+	return f.render(t, len(headers), &results.Scan)
+}
@@ -0,0 +1,93 @@
+package output
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/otuschhoff/cwalk/pkg/stat"
+)
+
+// SetSeparateRoots makes Format write the sections of each root in
+// Results.ByRoot, in the order the roots were scanned, followed by those
+// of the combined results: JSON output lists the roots under "roots" next
+// to the total sections, XLSX output numbers the sheets of each root after
+// a "roots" sheet naming them, HTML reports add a summary table per root,
+// and table and CSV output head each part with its root or "total". It
+// has no effect on results without ByRoot.
+func (f *Formatter) SetSeparateRoots(separate bool) {
+	f.roots = separate
+}
+
+// sortedRoots returns the roots of results.ByRoot in scan order.
+func sortedRoots(results *stat.Results) []string {
+	var roots []string
+	for _, root := range results.Scan.Roots {
+		if _, ok := results.ByRoot[root]; ok && !slices.Contains(roots, root) {
+			roots = append(roots, root)
+		}
+	}
+	return roots
+}
+
+// formatRoots formats the sections of each root followed by those of the
+// combined results. Tables only have the footer after the total.
+func (f *Formatter) formatRoots(results *stat.Results) string {
+	roots := sortedRoots(results)
+	total := *f
+	total.roots = false
+
+	switch f.format {
+	case "json":
+		doc := total.jsonDocument(results)
+		for _, root := range roots {
+			sub := total.jsonDocument(results.ByRoot[root])
+			sub.Root = root
+			doc.Roots = append(doc.Roots, sub)
+		}
+		return f.toJSON(doc)
+	case "html":
+		report := total.htmlReport(results)
+		for _, root := range roots {
+			r := results.ByRoot[root]
+			report.Roots = append(report.Roots, htmlRoot{root, total.htmlSummary(r.Summary), htmlScan(&r.Scan)})
+		}
+		return renderHTML(report)
+	case "xlsx":
+		sheets := []xlsxSheet{{"roots", [][]interface{}{{xlsxHeader("Sheet"), xlsxHeader("Root")}}}}
+		for i, root := range roots {
+			prefix := fmt.Sprint(i + 1)
+			sheets[0].rows = append(sheets[0].rows, []interface{}{prefix, root})
+			sheets = append(sheets, prefixSheets(prefix, total.modeSheets(results.ByRoot[root]))...)
+		}
+		sheets = append(sheets, prefixSheets("total", total.modeSheets(results))...)
+
+		var b strings.Builder
+		if err := writeXLSX(&b, sheets); err != nil {
+			return fmt.Sprintf("Error: %v\n", err)
+		}
+		return b.String()
+	}
+
+	var b strings.Builder
+	for _, root := range roots {
+		section := total
+		section.footer = false
+		section.baseline = f.baseline.root(root)
+		b.WriteString(root + "\n")
+		b.WriteString(section.Format(results.ByRoot[root]))
+		b.WriteString("\n")
+	}
+	b.WriteString("total\n")
+	b.WriteString(total.Format(results))
+	return b.String()
+}
+
+// prefixSheets prefixes the names of sheets, to tell apart the sheets of
+// the same mode for different roots.
+func prefixSheets(prefix string, sheets []xlsxSheet) []xlsxSheet {
+	for i := range sheets {
+		sheets[i].name = prefix + " " + sheets[i].name
+	}
+	return sheets
+}
@@ -0,0 +1,56 @@
+package output
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/otuschhoff/cwalk/pkg/stat"
+)
+
+func TestFormatRoots(t *testing.T) {
+	root := func(name string, size int64) *stat.Results {
+		return &stat.Results{
+			Summary: &stat.SummaryStat{TotalSize: size, TotalInodes: 1, Files: 1, FilesSize: size},
+			Scan:    stat.ScanStat{Roots: []string{name}},
+		}
+	}
+	results := root("/b", 3072)
+	results.Scan.Roots = []string{"/b", "/a"}
+	results.ByRoot = map[string]*stat.Results{"/a": root("/a", 1024), "/b": root("/b", 2048)}
+
+	f := NewFormatter("csv", "summary", false)
+	f.SetSeparateRoots(true)
+	out := f.Format(results)
+	b, a, total := strings.Index(out, "/b\n"), strings.Index(out, "/a\n"), strings.Index(out, "total\n")
+	if b < 0 || a < b || total < a {
+		t.Fatalf("want sections /b, /a and total in scan order:\n%s", out)
+	}
+	if !strings.Contains(out[b:a], "2.0 KB") || !strings.Contains(out[total:], "3.0 KB") {
+		t.Errorf("sections hold the wrong results:\n%s", out)
+	}
+
+	f = NewFormatter("json", "summary", false)
+	f.SetSeparateRoots(true)
+	var doc JSONDocument
+	if err := json.Unmarshal([]byte(f.Format(results)), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.Summary.Size != 3072 || len(doc.Roots) != 2 || doc.Roots[0].Root != "/b" || doc.Roots[1].Summary.Size != 1024 {
+		t.Errorf("document = %+v", doc)
+	}
+
+	f = NewFormatter("html", "summary", false)
+	f.SetSeparateRoots(true)
+	if html := f.Format(results); !strings.Contains(html, "<h2>/a</h2>") || !strings.Contains(html, "<h2>/b</h2>") {
+		t.Error("html report lacks the root sections")
+	}
+
+	// Without ByRoot only the total is written
+	results.ByRoot = nil
+	f = NewFormatter("csv", "summary", false)
+	f.SetSeparateRoots(true)
+	if out := f.Format(results); strings.Contains(out, "total\n") {
+		t.Errorf("got root sections without ByRoot:\n%s", out)
+	}
+}
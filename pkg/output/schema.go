@@ -0,0 +1,93 @@
+package output
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// JSONSchema returns the JSON Schema (draft 2020-12) of JSON output. It is
+// generated from JSONDocument and the types of its sections, so it always
+// matches the output. Keys without omitempty or omitzero are required,
+// and pointers without them may be null.
+func JSONSchema() ([]byte, error) {
+	b := &schemaBuilder{defs: make(map[string]any)}
+	root := b.object(reflect.TypeFor[JSONDocument]())
+	root["properties"].(map[string]any)["schemaVersion"] = map[string]any{"type": "integer", "const": SchemaVersion}
+	root["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	root["title"] = "cwalk JSON output"
+	root["$defs"] = b.defs
+	out, err := json.MarshalIndent(root, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(out, '\n'), nil
+}
+
+// schemaBuilder collects the definitions of the struct types referenced by
+// a schema, named after the types without their JSON prefix.
+type schemaBuilder struct {
+	defs map[string]any
+}
+
+// schema returns the schema of values of type t.
+func (b *schemaBuilder) schema(t reflect.Type) map[string]any {
+	if t == reflect.TypeFor[time.Time]() {
+		return map[string]any{"type": "string", "format": "date-time"}
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		return b.schema(t.Elem())
+	case reflect.Struct:
+		name := strings.TrimPrefix(t.Name(), "JSON")
+		if _, ok := b.defs[name]; !ok {
+			b.defs[name] = nil // Placeholder for recursive types
+			b.defs[name] = b.object(t)
+		}
+		return map[string]any{"$ref": "#/$defs/" + name}
+	case reflect.Slice:
+		return map[string]any{"type": "array", "items": b.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": b.schema(t.Elem())}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]any{"type": "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer", "minimum": 0}
+	}
+	panic("output: no JSON schema for " + t.String())
+}
+
+// object returns the schema of a struct type, with a property per
+// exported field named by its json tag.
+func (b *schemaBuilder) object(t reflect.Type) map[string]any {
+	props := make(map[string]any)
+	required := []string{}
+	for i := range t.NumField() {
+		field := t.Field(i)
+		name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if !field.IsExported() || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		s := b.schema(field.Type)
+		optional := strings.Contains(opts, "omitempty") || strings.Contains(opts, "omitzero")
+		if field.Type.Kind() == reflect.Pointer && !optional {
+			s = map[string]any{"anyOf": []any{s, map[string]any{"type": "null"}}}
+		}
+		props[name] = s
+		if !optional {
+			required = append(required, name)
+		}
+	}
+	return map[string]any{"type": "object", "properties": props, "required": required}
+}
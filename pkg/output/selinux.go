@@ -0,0 +1,91 @@
+package output
+
+import (
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/otuschhoff/cwalk/pkg/stat"
+)
+
+// unlabeledLabel is shown for entries without an SELinux context.
+const unlabeledLabel = "(unlabeled)"
+
+// unlabeledOf returns the count and size of the entries without an SELinux
+// context: those of the summary not counted under any of labels.
+func unlabeledOf(results *stat.Results, labels []*stat.LabelStat) (entries, size int64) {
+	if results.Summary != nil {
+		entries, size = results.Summary.TotalInodes, results.Summary.TotalSize
+	}
+	for _, ls := range labels {
+		entries -= ls.Entries
+		size -= ls.Size
+	}
+	return entries, size
+}
+
+// jsonSELinux returns the selinux section of JSON output.
+func jsonSELinux(results *stat.Results) *JSONSELinux {
+	labels := results.SortedLabels()
+	data := &JSONSELinux{Labels: make([]JSONSELinuxLabel, 0, len(labels))}
+	data.UnlabeledEntries, data.UnlabeledSize = unlabeledOf(results, labels)
+	for _, ls := range labels {
+		data.Labels = append(data.Labels, JSONSELinuxLabel{
+			Label:   ls.Label,
+			Type:    stat.SELinuxType(ls.Label),
+			Entries: ls.Entries,
+			Size:    ls.Size,
+		})
+	}
+	return data
+}
+
+// formatSELinux formats entry counts and sizes per SELinux security
+// context, most common first, followed by the entries without a context.
+func (f *Formatter) formatSELinux(results *stat.Results) string {
+	labels := results.SortedLabels()
+	unlabeled, unlabeledSize := unlabeledOf(results, labels)
+
+	headers := []string{"Label", "Type", "Entries", "Size"}
+	data := make([]map[string]interface{}, 0, len(labels)+1)
+	for _, ls := range labels {
+		data = append(data, map[string]interface{}{
+			"Label":   ls.Label,
+			"Type":    stat.SELinuxType(ls.Label),
+			"Entries": ls.Entries,
+			"Size":    byteSize(ls.Size),
+		})
+	}
+	data = append(data, map[string]interface{}{
+		"Label":   unlabeledLabel,
+		"Type":    "",
+		"Entries": unlabeled,
+		"Size":    byteSize(unlabeledSize),
+	})
+
+	switch f.format {
+	case "csv":
+		return f.toCSV(headers, data)
+	case "xlsx":
+		return f.toXLSX(headers, data)
+	}
+
+	t := table.NewWriter()
+	if !f.noHeader {
+		t.AppendHeader(table.Row{"Label", "Type", "Entries", "Size"})
+	}
+
+	entries := make([]int64, 0, len(data))
+	sizes := make([]int64, 0, len(data))
+	for _, ls := range labels {
+		entries = append(entries, ls.Entries)
+		sizes = append(sizes, ls.Size)
+	}
+	entries = append(entries, unlabeled)
+	sizes = append(sizes, unlabeledSize)
+	entryCol := f.column(entries, false)
+	sizeCol := f.column(sizes, true)
+
+	for i, row := range data {
+		t.AppendRow(table.Row{row["Label"], row["Type"], entryCol[i], sizeCol[i]})
+	}
+
+	return f.render(t, len(headers), &results.Scan)
+}
@@ -0,0 +1,182 @@
+package output
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SplitLimits bound the parts written by WriteSplit. A part is closed
+// before it would exceed either limit; zero disables a limit.
+type SplitLimits struct {
+	MaxBytes int64 // Max uncompressed bytes per part, including the header
+	MaxRows  int64 // Max data rows per part, excluding the header
+}
+
+// Manifest describes the parts written by WriteSplit so loaders can verify
+// that an export is complete.
+type Manifest struct {
+	Format string         `json:"format"` // Output format of the parts
+	Header []string       `json:"header"` // Header row repeated in every part
+	Rows   int64          `json:"rows"`   // Data rows across all parts
+	Parts  []ManifestPart `json:"parts"`  // Parts in order
+}
+
+// ManifestPart describes one part file.
+type ManifestPart struct {
+	File   string `json:"file"`   // File name, relative to the manifest
+	Rows   int64  `json:"rows"`   // Data rows, excluding the header
+	Bytes  int64  `json:"bytes"`  // Size of the file as written (compressed if gzipped)
+	SHA256 string `json:"sha256"` // Checksum of the file as written
+}
+
+// WriteSplit writes CSV output as numbered parts of at most limits each,
+// every part starting with the header row, plus a manifest listing them.
+// For filename "inventory.csv.gz" the parts are "inventory-0001.csv.gz",
+// "inventory-0002.csv.gz" and so on, and the manifest is
+// "inventory.manifest.json". Parts are gzip-compressed if filename ends
+// in ".gz". Returns the path of the manifest.
+func (f *Formatter) WriteSplit(content, filename string, limits SplitLimits) (string, error) {
+	if f.format != "csv" {
+		return "", fmt.Errorf("splitting is only supported for csv output, not %s", f.format)
+	}
+
+	r := f.newCSVReader(content)
+	header, err := r.Read()
+	if err == io.EOF {
+		header = nil
+	} else if err != nil {
+		return "", fmt.Errorf("failed to parse output: %w", err)
+	}
+
+	headerBytes := f.csvBytes(header)
+	stem, ext := splitName(filename)
+	manifest := Manifest{Format: f.format, Header: header}
+
+	var buf bytes.Buffer
+	var rows int64
+	flush := func() error {
+		part, err := writePart(fmt.Sprintf("%s-%04d%s", stem, len(manifest.Parts)+1, ext), buf.Bytes(), rows)
+		if err != nil {
+			return err
+		}
+		manifest.Parts = append(manifest.Parts, part)
+		manifest.Rows += rows
+		buf.Reset()
+		rows = 0
+		return nil
+	}
+
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to parse output: %w", err)
+		}
+
+		line := f.csvBytes(record)
+		full := limits.MaxRows > 0 && rows >= limits.MaxRows ||
+			limits.MaxBytes > 0 && rows > 0 && int64(buf.Len()+len(line)) > limits.MaxBytes
+		if full {
+			if err := flush(); err != nil {
+				return "", err
+			}
+		}
+		if buf.Len() == 0 {
+			buf.Write(headerBytes)
+		}
+		buf.Write(line)
+		rows++
+	}
+	if rows > 0 || len(manifest.Parts) == 0 {
+		if buf.Len() == 0 {
+			buf.Write(headerBytes)
+		}
+		if err := flush(); err != nil {
+			return "", err
+		}
+	}
+
+	manifestPath := stem + ".manifest.json"
+	b, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(manifestPath, append(b, '\n'), 0644); err != nil {
+		return "", err
+	}
+	return manifestPath, nil
+}
+
+// splitName splits a file name into the part before the extension and the
+// extension, keeping ".gz" with the extension before it: "a/inv.csv.gz"
+// yields "a/inv" and ".csv.gz".
+func splitName(filename string) (stem, ext string) {
+	base := filename
+	if strings.HasSuffix(base, ".gz") {
+		base = strings.TrimSuffix(base, ".gz")
+		ext = ".gz"
+	}
+	slash := strings.LastIndexAny(base, `/\`)
+	if dot := strings.LastIndexByte(base, '.'); dot > slash+1 {
+		return base[:dot], base[dot:] + ext
+	}
+	return base, ext
+}
+
+// csvBytes encodes a single CSV record.
+func (f *Formatter) csvBytes(record []string) []byte {
+	if record == nil {
+		return nil
+	}
+	var buf bytes.Buffer
+	w := f.newCSVWriter(&buf)
+	w.Write(record)
+	w.Flush()
+	return buf.Bytes()
+}
+
+// writePart writes one part, gzip-compressed if its name ends in ".gz",
+// and returns its manifest entry.
+func writePart(path string, data []byte, rows int64) (ManifestPart, error) {
+	out, err := encodeFile(path, data)
+	if err != nil {
+		return ManifestPart{}, err
+	}
+	if err := os.WriteFile(path, out, 0644); err != nil {
+		return ManifestPart{}, err
+	}
+	sum := sha256.Sum256(out)
+	return ManifestPart{
+		File:   filepath.Base(path),
+		Rows:   rows,
+		Bytes:  int64(len(out)),
+		SHA256: hex.EncodeToString(sum[:]),
+	}, nil
+}
+
+// encodeFile returns data as it is written to path: gzip-compressed if the
+// name ends in ".gz", unchanged otherwise.
+func encodeFile(path string, data []byte) ([]byte, error) {
+	if !strings.HasSuffix(path, ".gz") {
+		return data, nil
+	}
+	var out bytes.Buffer
+	zw := gzip.NewWriter(&out)
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
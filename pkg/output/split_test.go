@@ -0,0 +1,95 @@
+package output
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSplitName(t *testing.T) {
+	tests := []struct {
+		in, stem, ext string
+	}{
+		{"inventory.csv.gz", "inventory", ".csv.gz"},
+		{"out/inventory.csv", "out/inventory", ".csv"},
+		{"inventory", "inventory", ""},
+		{"dir.d/inventory", "dir.d/inventory", ""},
+	}
+	for _, tt := range tests {
+		if stem, ext := splitName(tt.in); stem != tt.stem || ext != tt.ext {
+			t.Errorf("splitName(%q) = %q, %q, want %q, %q", tt.in, stem, ext, tt.stem, tt.ext)
+		}
+	}
+}
+
+func TestWriteSplit(t *testing.T) {
+	dir := t.TempDir()
+	content := "PATH,SIZE\n/a,1\n\"/b\nc\",2\n/d,3\n/e,4\n/f,5\n"
+
+	f := NewFormatter("csv", "list", false)
+	manifestPath, err := f.WriteSplit(content, filepath.Join(dir, "inventory.csv.gz"), SplitLimits{MaxRows: 2})
+	if err != nil {
+		t.Fatalf("WriteSplit failed: %v", err)
+	}
+	if manifestPath != filepath.Join(dir, "inventory.manifest.json") {
+		t.Errorf("manifest path = %q", manifestPath)
+	}
+
+	b, err := os.ReadFile(manifestPath)
+	if err != nil {
+		t.Fatalf("failed to read manifest: %v", err)
+	}
+	var m Manifest
+	if err := json.Unmarshal(b, &m); err != nil {
+		t.Fatalf("failed to parse manifest: %v", err)
+	}
+	if m.Rows != 5 || len(m.Parts) != 3 {
+		t.Fatalf("manifest has %d rows in %d parts, want 5 in 3", m.Rows, len(m.Parts))
+	}
+	if m.Parts[0].File != "inventory-0001.csv.gz" || m.Parts[2].Rows != 1 {
+		t.Errorf("unexpected parts: %+v", m.Parts)
+	}
+
+	zf, err := os.Open(filepath.Join(dir, "inventory-0001.csv.gz"))
+	if err != nil {
+		t.Fatalf("failed to open part: %v", err)
+	}
+	defer zf.Close()
+	zr, err := gzip.NewReader(zf)
+	if err != nil {
+		t.Fatalf("part is not gzipped: %v", err)
+	}
+	part, _ := io.ReadAll(zr)
+	if want := "PATH,SIZE\n/a,1\n\"/b\nc\",2\n"; string(part) != want {
+		t.Errorf("part 1 = %q, want %q", part, want)
+	}
+}
+
+func TestWriteSplitBytes(t *testing.T) {
+	dir := t.TempDir()
+	content := "PATH\n/aaaa\n/bbbb\n/cccc\n"
+
+	f := NewFormatter("csv", "list", false)
+	manifestPath, err := f.WriteSplit(content, filepath.Join(dir, "out.csv"), SplitLimits{MaxBytes: 16})
+	if err != nil {
+		t.Fatalf("WriteSplit failed: %v", err)
+	}
+	b, _ := os.ReadFile(manifestPath)
+	var m Manifest
+	json.Unmarshal(b, &m)
+	if len(m.Parts) != 3 {
+		t.Fatalf("got %d parts, want 3", len(m.Parts))
+	}
+	for _, p := range m.Parts {
+		if p.Bytes > 16 {
+			t.Errorf("part %s has %d bytes, want at most 16", p.File, p.Bytes)
+		}
+	}
+
+	if _, err := NewFormatter("json", "list", false).WriteSplit("[]", filepath.Join(dir, "out.json"), SplitLimits{MaxRows: 1}); err == nil {
+		t.Error("WriteSplit should reject json output")
+	}
+}
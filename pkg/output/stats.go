@@ -0,0 +1,98 @@
+package output
+
+import (
+	"fmt"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/otuschhoff/cwalk/pkg/stat"
+)
+
+// jsonStats returns the stats section of JSON output.
+func jsonStats(results *stat.Results) *JSONStats {
+	scan := &results.Scan
+	data := &JSONStats{
+		WallSeconds:    round3(scan.Duration.Seconds()),
+		WalkSeconds:    round3(scan.WalkTime.Seconds()),
+		MergeSeconds:   round3(scan.MergeTime.Seconds()),
+		Entries:        scan.Entries,
+		Dirs:           scan.Dirs,
+		Files:          scan.Files,
+		DirsPerSec:     round2(scan.DirsPerSec()),
+		FilesPerSec:    round2(scan.FilesPerSec()),
+		Syscalls:       scan.Syscalls,
+		SyscallsPerSec: round2(scan.SyscallsPerSec()),
+		Errors:         scan.Errors,
+		PeakQueue:      scan.PeakQueue,
+		RstatsRoots:    scan.Rstats,
+		Workers:        make([]JSONWorker, 0, len(scan.Workers)),
+	}
+	for _, w := range scan.Workers {
+		data.Workers = append(data.Workers, JSONWorker{
+			ID:          w.ID,
+			Dirs:        w.Dirs,
+			BusySeconds: round3(w.Busy.Seconds()),
+			Utilization: round3(w.Utilization()),
+		})
+	}
+	return data
+}
+
+// statsRows returns the metrics of the stats section as name and value
+// pairs, followed by a row per root taken from CephFS recursive statistics
+// and a row per worker.
+func (f *Formatter) statsRows(scan *stat.ScanStat) [][2]string {
+	rows := [][2]string{
+		{"Wall Time", roundDuration(scan.Duration).String()},
+		{"Walk Time", roundDuration(scan.WalkTime).String()},
+		{"Merge Time", roundDuration(scan.MergeTime).String()},
+		{"Entries", f.formatCount(scan.Entries)},
+		{"Directories", f.formatCount(scan.Dirs)},
+		{"Files", f.formatCount(scan.Files)},
+		{"Dirs/sec", fmt.Sprintf("%.1f", scan.DirsPerSec())},
+		{"Files/sec", fmt.Sprintf("%.1f", scan.FilesPerSec())},
+		{"Syscalls", f.formatCount(scan.Syscalls)},
+		{"Syscalls/sec", fmt.Sprintf("%.1f", scan.SyscallsPerSec())},
+		{"Errors", f.formatCount(scan.Errors)},
+		{"Peak Queue Depth", f.formatCount(scan.PeakQueue)},
+		{"Workers", fmt.Sprint(len(scan.Workers))},
+	}
+	for _, root := range scan.Rstats {
+		rows = append(rows, [2]string{"CephFS Rstats Root", root})
+	}
+	for _, w := range scan.Workers {
+		rows = append(rows, [2]string{
+			fmt.Sprintf("Worker %d", w.ID),
+			fmt.Sprintf("%.1f%% busy, %s dirs", w.Utilization()*100, f.formatCount(w.Dirs)),
+		})
+	}
+	return rows
+}
+
+// formatStats formats the performance of the scan: wall, walk and merge
+// time, directory, file and syscall rates, errors, the peak queue depth,
+// the roots taken from CephFS recursive statistics and the utilization of
+// each worker, to help tune --workers.
+func (f *Formatter) formatStats(results *stat.Results) string {
+	rows := f.statsRows(&results.Scan)
+	data := make([]map[string]interface{}, 0, len(rows))
+	for _, row := range rows {
+		data = append(data, map[string]interface{}{"Metric": row[0], "Value": row[1]})
+	}
+
+	switch f.format {
+	case "csv":
+		return f.toCSV([]string{"Metric", "Value"}, data)
+	case "xlsx":
+		return f.toXLSX([]string{"Metric", "Value"}, data)
+	}
+
+	t := table.NewWriter()
+	if !f.noHeader {
+		t.AppendHeader(table.Row{"Metric", "Value"})
+	}
+	for _, row := range rows {
+		t.AppendRow(table.Row{row[0], row[1]})
+	}
+
+	return f.render(t, 2, &results.Scan)
+}
@@ -0,0 +1,58 @@
+package output
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/otuschhoff/cwalk"
+	"github.com/otuschhoff/cwalk/pkg/stat"
+)
+
+func TestFormatStats(t *testing.T) {
+	results := &stat.Results{
+		Summary: &stat.SummaryStat{},
+		Scan: stat.ScanStat{
+			Duration:  2 * time.Second,
+			WalkTime:  1500 * time.Millisecond,
+			MergeTime: 500 * time.Millisecond,
+			Entries:   4000,
+			Files:     3700,
+			Dirs:      300,
+			Syscalls:  4300,
+			Errors:    2,
+			PeakQueue: 42,
+			Rstats:    []string{"/mnt/cephfs/archive"},
+			Workers: []cwalk.WorkerStats{
+				{ID: 0, Dirs: 200, Busy: 1200 * time.Millisecond, Lifetime: 1500 * time.Millisecond},
+				{ID: 1, Dirs: 100, Busy: 300 * time.Millisecond, Lifetime: 1500 * time.Millisecond},
+			},
+		},
+	}
+
+	out := NewFormatter("table", "stats", false).Format(results)
+	for _, want := range []string{"Wall Time", "2s", "Dirs/sec", "200.0", "Files/sec", "2466.7", "Peak Queue Depth", "42", "Worker 0", "80.0% busy, 200 dirs", "20.0% busy, 100 dirs", "CephFS Rstats Root", "/mnt/cephfs/archive"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("table does not contain %q:\n%s", want, out)
+		}
+	}
+
+	csv := NewFormatter("csv", "stats", false).Format(results)
+	if !strings.Contains(csv, "Syscalls/sec,2866.7\n") {
+		t.Errorf("csv = %q", csv)
+	}
+
+	var doc JSONDocument
+	if err := json.Unmarshal([]byte(NewFormatter("json", "stats", false).Format(results)), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if s := doc.Stats; s == nil || s.DirsPerSec != 200 || s.WallSeconds != 2 || len(s.Workers) != 2 || s.Workers[1].Utilization != 0.2 || len(s.RstatsRoots) != 1 {
+		t.Errorf("stats section = %+v", doc.Stats)
+	}
+
+	html := NewFormatter("html", "stats", false).Format(results)
+	if !strings.Contains(html, "<h2>Scan Statistics</h2>") || strings.Contains(NewFormatter("html", "summary", false).Format(results), "Scan Statistics") {
+		t.Error("html reports should have scan statistics only with the stats mode")
+	}
+}
@@ -0,0 +1,91 @@
+package output
+
+import (
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/jedib0t/go-pretty/v6/text"
+)
+
+// TableStyle selects the look of table output.
+type TableStyle int
+
+const (
+	// TableStyleDark renders light text on dark backgrounds without
+	// borders (the default).
+	TableStyleDark TableStyle = iota
+	// TableStyleLight renders dark text on light backgrounds without
+	// borders, for terminals with light themes.
+	TableStyleLight
+	// TableStylePlain renders ASCII borders without colors, for log
+	// captures and terminals without color support.
+	TableStylePlain
+	// TableStyleBorderless renders neither borders nor colors.
+	TableStyleBorderless
+)
+
+// Wrap selects how table values wider than the maximum column width are
+// shortened.
+type Wrap int
+
+const (
+	// WrapSoft wraps values at word boundaries where possible (the
+	// default).
+	WrapSoft Wrap = iota
+	// WrapHard wraps values at the column width.
+	WrapHard
+	// WrapTruncate cuts values off at the column width.
+	WrapTruncate
+)
+
+// SetTableStyle sets the look of table output. Colors of the style are
+// left out if SetColor disabled them.
+func (f *Formatter) SetTableStyle(style TableStyle) {
+	f.style = style
+}
+
+// SetMaxColWidth limits the width of every table column to width
+// characters, shortening wider values as wrap selects. 0 leaves columns
+// as wide as their values.
+func (f *Formatter) SetMaxColWidth(width int, wrap Wrap) {
+	f.width, f.wrap = width, wrap
+}
+
+// tableStyle returns the go-pretty style of table output.
+func (f *Formatter) tableStyle() table.Style {
+	var style table.Style
+	switch f.style {
+	case TableStyleLight:
+		style = table.StyleColoredBright
+	case TableStylePlain:
+		style = table.StyleDefault
+	case TableStyleBorderless:
+		style = table.StyleColoredDark
+		style.Color = table.ColorOptions{}
+	default:
+		style = table.StyleColoredDark
+	}
+	style.Format.Footer = text.FormatDefault // Keep the case of paths
+	if f.noColor {
+		style.Color = table.ColorOptions{}
+	}
+	return style
+}
+
+// columnConfigs returns the configuration of the columns of a table with
+// the given number of columns, limiting their width if enabled.
+func (f *Formatter) columnConfigs(columns int) []table.ColumnConfig {
+	if f.width <= 0 {
+		return nil
+	}
+	enforcer := text.WrapSoft
+	switch f.wrap {
+	case WrapHard:
+		enforcer = text.WrapHard
+	case WrapTruncate:
+		enforcer = text.Trim
+	}
+	configs := make([]table.ColumnConfig, columns)
+	for i := range configs {
+		configs[i] = table.ColumnConfig{Number: i + 1, WidthMax: f.width, WidthMaxEnforcer: enforcer}
+	}
+	return configs
+}
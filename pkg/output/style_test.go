@@ -0,0 +1,69 @@
+package output
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/otuschhoff/cwalk/pkg/stat"
+)
+
+func TestFormatTableStyle(t *testing.T) {
+	results := &stat.Results{
+		ByUID: map[uint32]*stat.UIDStat{1000: {UID: 1000, Username: "alice", TotalSize: 1024, TotalInodes: 1, Files: 1}},
+	}
+
+	tests := []struct {
+		style   TableStyle
+		want    string
+		colored bool
+	}{
+		{TableStyleDark, "\x1b[96;100m", true},
+		{TableStyleLight, "\x1b[", true},
+		{TableStylePlain, "+------+", false},
+		{TableStyleBorderless, " UID  USERNAME", false},
+	}
+	for _, tt := range tests {
+		f := NewFormatter("table", "per-uid", false)
+		f.SetTableStyle(tt.style)
+		output := f.Format(results)
+		if !strings.Contains(output, tt.want) {
+			t.Errorf("style %d should contain %q, got %q", tt.style, tt.want, output)
+		}
+		if colored := strings.Contains(output, "\x1b"); colored != tt.colored {
+			t.Errorf("style %d colored = %v, want %v", tt.style, colored, tt.colored)
+		}
+
+		f.SetColor(false)
+		if output := f.Format(results); strings.Contains(output, "\x1b") {
+			t.Errorf("style %d without colors should have no escape sequences, got %q", tt.style, output)
+		}
+	}
+}
+
+func TestFormatMaxColWidth(t *testing.T) {
+	results := &stat.Results{
+		ByUID: map[uint32]*stat.UIDStat{1000: {UID: 1000, Username: "verylongusername", TotalSize: 1024, TotalInodes: 1, Files: 1}},
+	}
+
+	tests := []struct {
+		wrap Wrap
+		want []string
+	}{
+		{WrapHard, []string{"| 1000 | verylo |", "|      | nguser |"}},
+		{WrapTruncate, []string{"| 1000 | verylo |"}},
+	}
+	for _, tt := range tests {
+		f := NewFormatter("table", "per-uid", false)
+		f.SetTableStyle(TableStylePlain)
+		f.SetMaxColWidth(6, tt.wrap)
+		output := f.Format(results)
+		for _, want := range tt.want {
+			if !strings.Contains(output, want) {
+				t.Errorf("wrap %d should contain %q, got %q", tt.wrap, want, output)
+			}
+		}
+		if tt.wrap == WrapTruncate && strings.Contains(output, "ngus") {
+			t.Errorf("truncated output should not contain the rest of the name, got %q", output)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/otuschhoff/cwalk/pkg/stat"
+	"github.com/otuschhoff/cwalk/pkg/textfmt"
+)
+
+// TemplateData is what report templates are executed on: the results,
+// whose fields such as .Summary, .ByYear and .ByUID are promoted, and the
+// time the report was generated.
+type TemplateData struct {
+	*stat.Results
+	Generated time.Time // Time the template was executed
+}
+
+// templateFuncs are the functions available to report templates.
+var templateFuncs = template.FuncMap{
+	// humanBytes formats a byte count like table output, e.g. "1.5 GB"
+	"humanBytes": humanBytes(textfmt.UnitsDefault),
+	// percent formats part as a percentage of total, e.g. "12.5%"
+	"percent": func(part, total any) (string, error) {
+		p, err := toInt64(part)
+		if err != nil {
+			return "", err
+		}
+		t, err := toInt64(total)
+		if err != nil {
+			return "", err
+		}
+		if t == 0 {
+			return "0.0%", nil
+		}
+		return fmt.Sprintf("%.1f%%", float64(p)*100/float64(t)), nil
+	},
+	// date formats a time as "2006-01-02 15:04", or "-" if zero
+	"date": func(t time.Time) string {
+		if t.IsZero() {
+			return "-"
+		}
+		return t.Format("2006-01-02 15:04")
+	},
+	"username":  stat.Username,
+	"groupname": stat.Groupname,
+	// uidsBySize returns the per-UID stats, largest total size first
+	"uidsBySize": func(byUID map[uint32]*stat.UIDStat) []*stat.UIDStat {
+		stats := make([]*stat.UIDStat, 0, len(byUID))
+		for _, s := range byUID {
+			stats = append(stats, s)
+		}
+		sort.Slice(stats, func(i, j int) bool {
+			if stats[i].TotalSize != stats[j].TotalSize {
+				return stats[i].TotalSize > stats[j].TotalSize
+			}
+			return stats[i].UID < stats[j].UID
+		})
+		return stats
+	},
+	// json encodes a value, e.g. to embed data in an HTML report
+	"json": func(v any) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+}
+
+// ParseTemplate parses a report template, making the helper functions
+// humanBytes, percent, date, username, groupname, uidsBySize, json, lower
+// and upper available. name is used in error messages.
+func ParseTemplate(name, text string) (*template.Template, error) {
+	return template.New(name).Funcs(templateFuncs).Option("missingkey=error").Parse(text)
+}
+
+// SetTemplate sets the template the "template" output format renders
+// results with, see ParseTemplate.
+func (f *Formatter) SetTemplate(tmpl *template.Template) {
+	f.tmpl = tmpl
+}
+
+// humanBytes returns the humanBytes template function for units.
+func humanBytes(units textfmt.Units) func(any) (string, error) {
+	return func(b any) (string, error) {
+		n, err := toInt64(b)
+		if err != nil {
+			return "", err
+		}
+		return textfmt.FormatBytes(n, units), nil
+	}
+}
+
+// ExecuteTemplate renders results with the template set by SetTemplate.
+// humanBytes formats sizes in the units set by SetUnits.
+func (f *Formatter) ExecuteTemplate(results *stat.Results) (string, error) {
+	if f.tmpl == nil {
+		return "", fmt.Errorf("template output requires a template")
+	}
+	tmpl := f.tmpl
+	if f.units != textfmt.UnitsDefault {
+		var err error
+		if tmpl, err = tmpl.Clone(); err != nil {
+			return "", err
+		}
+		tmpl.Funcs(template.FuncMap{"humanBytes": humanBytes(f.units)})
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, &TemplateData{Results: results, Generated: time.Now()}); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// formatTemplate renders results with the template, or describes the
+// error like the other formats do.
+func (f *Formatter) formatTemplate(results *stat.Results) string {
+	out, err := f.ExecuteTemplate(results)
+	if err != nil {
+		return fmt.Sprintf("Error: %v\n", err)
+	}
+	return out
+}
+
+// toInt64 converts an integer of any type to int64, for template helpers
+// called with both struct fields and literals.
+func toInt64(v any) (int64, error) {
+	switch n := v.(type) {
+	case int:
+		return int64(n), nil
+	case int32:
+		return int64(n), nil
+	case int64:
+		return n, nil
+	case uint32:
+		return int64(n), nil
+	case uint64:
+		return int64(n), nil
+	case float64:
+		return int64(n), nil
+	}
+	return 0, fmt.Errorf("not a number: %v", v)
+}
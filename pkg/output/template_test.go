@@ -0,0 +1,52 @@
+package output
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/otuschhoff/cwalk/pkg/stat"
+)
+
+func TestFormatTemplate(t *testing.T) {
+	results := &stat.Results{
+		Summary: &stat.SummaryStat{TotalSize: 3 << 20, TotalInodes: 4, Files: 3, Dirs: 1},
+		ByYear: map[int]*stat.YearStat{
+			2024: {Year: 2024, TotalSize: 1 << 20},
+			2023: {Year: 2023, TotalSize: 2 << 20},
+		},
+		ByUID: map[uint32]*stat.UIDStat{
+			1000: {UID: 1000, Username: "alice", TotalSize: 1 << 20},
+			1001: {UID: 1001, Username: "bob", TotalSize: 2 << 20},
+		},
+	}
+
+	tmpl, err := ParseTemplate("report.md", `# {{humanBytes .Summary.TotalSize}} in {{.Summary.Files}} files
+{{range .ByYear}}- {{.Year}}: {{humanBytes .TotalSize}} ({{percent .TotalSize $.Summary.TotalSize}})
+{{end}}{{range uidsBySize .ByUID}}{{.Username}} {{end}}{{humanBytes 1536}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f := NewFormatter("template", "per-year", false)
+	f.SetTemplate(tmpl)
+	want := "# 3.0 MB in 3 files\n- 2023: 2.0 MB (66.7%)\n- 2024: 1.0 MB (33.3%)\nbob alice 1.5 KB"
+	if got := f.Format(results); got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+
+	tmpl, err = ParseTemplate("bad", `{{humanBytes .Summary.Nope}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.SetTemplate(tmpl)
+	if _, err := f.ExecuteTemplate(results); err == nil {
+		t.Error("unknown field should fail")
+	}
+	if got := f.Format(results); !strings.HasPrefix(got, "Error: ") {
+		t.Errorf("Format = %q, want the error", got)
+	}
+
+	if _, err := ParseTemplate("bad", `{{nosuchfunc 1}}`); err == nil {
+		t.Error("unknown function should fail to parse")
+	}
+}
@@ -0,0 +1,107 @@
+package output
+
+import (
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/otuschhoff/cwalk/pkg/stat"
+)
+
+// jsonInodeUsage returns the inodeUsage section of JSON output, empty
+// unless usage is available.
+func jsonInodeUsage(results *stat.Results) []JSONInodeUsage {
+	rows := make([]JSONInodeUsage, 0, len(results.Usage))
+	for i := range results.Usage {
+		u := &results.Usage[i]
+		rows = append(rows, JSONInodeUsage{
+			Root:         u.Root,
+			WalkedInodes: u.WalkedInodes,
+			UsedInodes:   u.UsedInodes(),
+			FreeInodes:   u.FreeInodes,
+			TotalInodes:  u.TotalInodes,
+			InodesPct:    round2(u.InodesPct()),
+			WalkedSize:   u.WalkedSize,
+			UsedBytes:    u.UsedBytes(),
+			AvailBytes:   u.AvailBytes,
+			TotalBytes:   u.TotalBytes,
+			BytesPct:     round2(u.BytesPct()),
+		})
+	}
+	return rows
+}
+
+// formatInodeUsage formats the capacity and usage of the file system of
+// each scanned root next to the totals walked below it. Percentages are of
+// the whole file system, as reported by df and df -i.
+func (f *Formatter) formatInodeUsage(results *stat.Results) string {
+	usage := results.Usage
+	if len(usage) == 0 {
+		return "No file system usage: usage is only available for local roots scanned live\n"
+	}
+
+	headers := []string{"Root", "WalkedInodes", "UsedInodes", "TotalInodes", "InodesPct", "WalkedSize", "UsedSize", "AvailSize", "TotalSize", "SizePct"}
+	switch f.format {
+	case "csv", "xlsx":
+		data := make([]map[string]interface{}, 0, len(usage))
+		for i := range usage {
+			u := &usage[i]
+			data = append(data, map[string]interface{}{
+				"Root":         u.Root,
+				"WalkedInodes": u.WalkedInodes,
+				"UsedInodes":   u.UsedInodes(),
+				"TotalInodes":  u.TotalInodes,
+				"InodesPct":    round2(u.InodesPct()),
+				"WalkedSize":   byteSize(u.WalkedSize),
+				"UsedSize":     byteSize(u.UsedBytes()),
+				"AvailSize":    byteSize(u.AvailBytes),
+				"TotalSize":    byteSize(u.TotalBytes),
+				"SizePct":      round2(u.BytesPct()),
+			})
+		}
+		if f.format == "csv" {
+			return f.toCSV(headers, data)
+		}
+		return f.toXLSX(headers, data)
+	}
+
+	return f.usageTable(usage, &results.Scan)
+}
+
+// usageTable renders file system usage as a table with aligned counts,
+// sizes and percentages.
+func (f *Formatter) usageTable(usage []stat.UsageStat, scan *stat.ScanStat) string {
+	t := table.NewWriter()
+	headers := table.Row{"Root", "Walked Inodes", "Used Inodes", "Total Inodes", "Inodes %", "Walked Size", "Used Size", "Avail Size", "Total Size", "Size %"}
+	if !f.noHeader {
+		t.AppendHeader(headers)
+	}
+
+	n := len(usage)
+	walkedInodes, usedInodes, totalInodes := make([]int64, n), make([]int64, n), make([]int64, n)
+	walkedSizes, usedSizes, availSizes, totalSizes := make([]int64, n), make([]int64, n), make([]int64, n), make([]int64, n)
+	inodesPcts, sizePcts := make([]float64, n), make([]float64, n)
+	for i := range usage {
+		u := &usage[i]
+		walkedInodes[i], usedInodes[i], totalInodes[i] = u.WalkedInodes, int64(u.UsedInodes()), int64(u.TotalInodes)
+		walkedSizes[i], usedSizes[i] = u.WalkedSize, int64(u.UsedBytes())
+		availSizes[i], totalSizes[i] = int64(u.AvailBytes), int64(u.TotalBytes)
+		inodesPcts[i], sizePcts[i] = u.InodesPct(), u.BytesPct()
+	}
+	walkedInodeCol := f.column(walkedInodes, false)
+	usedInodeCol := f.column(usedInodes, false)
+	totalInodeCol := f.column(totalInodes, false)
+	inodesPctCol := f.ratioColumn(inodesPcts)
+	walkedSizeCol := f.column(walkedSizes, true)
+	usedSizeCol := f.column(usedSizes, true)
+	availSizeCol := f.column(availSizes, true)
+	totalSizeCol := f.column(totalSizes, true)
+	sizePctCol := f.ratioColumn(sizePcts)
+
+	for i := range usage {
+		t.AppendRow(table.Row{
+			usage[i].Root,
+			walkedInodeCol[i], usedInodeCol[i], totalInodeCol[i], inodesPctCol[i],
+			walkedSizeCol[i], usedSizeCol[i], availSizeCol[i], totalSizeCol[i], sizePctCol[i],
+		})
+	}
+
+	return f.render(t, len(headers), scan)
+}
@@ -0,0 +1,83 @@
+package output
+
+import (
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/otuschhoff/cwalk/pkg/stat"
+)
+
+// Row labels of the totals preceding the per-attribute rows.
+const (
+	xattrAnyLabel = "Any attribute"
+	xattrACLLabel = "POSIX ACL"
+)
+
+// jsonXattrs returns the xattrs section of JSON output.
+func jsonXattrs(results *stat.Results) *JSONXattrs {
+	xs := results.Xattrs
+	if xs == nil {
+		xs = &stat.XattrStat{}
+	}
+	names := xs.SortedNames()
+	data := &JSONXattrs{
+		Entries: xs.Entries,
+		ACLs:    xs.ACLs,
+		Size:    xs.Bytes,
+		Names:   make([]JSONXattrName, 0, len(names)),
+	}
+	for _, ns := range names {
+		data.Names = append(data.Names, JSONXattrName{Name: ns.Name, Entries: ns.Entries, Size: ns.Bytes})
+	}
+	return data
+}
+
+// formatXattrs formats extended attribute statistics: the entries carrying
+// any attribute and a POSIX ACL, followed by one row per attribute name,
+// most common first.
+func (f *Formatter) formatXattrs(results *stat.Results) string {
+	xs := results.Xattrs
+	if xs == nil {
+		xs = &stat.XattrStat{}
+	}
+	names := xs.SortedNames()
+
+	headers := []string{"Attribute", "Entries", "Size"}
+	data := []map[string]interface{}{
+		{"Attribute": xattrAnyLabel, "Entries": xs.Entries, "Size": byteSize(xs.Bytes)},
+		{"Attribute": xattrACLLabel, "Entries": xs.ACLs, "Size": ""},
+	}
+	for _, ns := range names {
+		data = append(data, map[string]interface{}{
+			"Attribute": ns.Name,
+			"Entries":   ns.Entries,
+			"Size":      byteSize(ns.Bytes),
+		})
+	}
+
+	switch f.format {
+	case "csv":
+		return f.toCSV(headers, data)
+	case "xlsx":
+		return f.toXLSX(headers, data)
+	}
+
+	t := table.NewWriter()
+	if !f.noHeader {
+		t.AppendHeader(table.Row{"Attribute", "Entries", "Size"})
+	}
+
+	entries := []int64{xs.Entries, xs.ACLs}
+	sizes := []int64{xs.Bytes, 0}
+	for _, ns := range names {
+		entries = append(entries, ns.Entries)
+		sizes = append(sizes, ns.Bytes)
+	}
+	entryCol := f.column(entries, false)
+	sizeCol := f.column(sizes, true)
+	sizeCol[1] = ""
+
+	for i, row := range data {
+		t.AppendRow(table.Row{row["Attribute"], entryCol[i], sizeCol[i]})
+	}
+
+	return f.render(t, len(headers), &results.Scan)
+}
@@ -0,0 +1,210 @@
+package output
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cell styles defined in styles.xml. They use built-in number formats,
+// which Excel renders according to the reader's locale (digit grouping,
+// decimal separator and date order).
+const (
+	xlsxStyleGeneral = 0 // Default
+	xlsxStyleNumber  = 1 // Built-in format 3: #,##0
+	xlsxStyleDate    = 2 // Built-in format 22: m/d/yy h:mm
+	xlsxStyleHeader  = 3 // Bold
+)
+
+// excelEpoch is day zero of Excel's 1900 date system, adjusted for its
+// fictitious 1900-02-29.
+var excelEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+// byteSize is a size in bytes. It prints in human-readable units in CSV and
+// table output, and is written as a numeric cell in XLSX output so
+// spreadsheets can sum and sort it.
+type byteSize int64
+
+// String formats the size with binary unit suffixes.
+func (b byteSize) String() string {
+	return formatBytes(int64(b))
+}
+
+// toXLSX builds an XLSX workbook with a single sheet holding the headers
+// and rows, with values in header column order. The workbook is returned
+// as a string of raw bytes for WriteToFile. If the formatter collects
+// sheets for a workbook of several modes, the sheet is added to them
+// instead and nothing is returned.
+func (f *Formatter) toXLSX(headers []string, data []map[string]interface{}) string {
+	rows := make([][]interface{}, 0, len(data)+1)
+	if !f.noHeader {
+		header := make([]interface{}, len(headers))
+		for i, h := range headers {
+			header[i] = xlsxHeader(h)
+		}
+		rows = append(rows, header)
+	}
+	for _, row := range data {
+		values := make([]interface{}, len(headers))
+		for i, header := range headers {
+			values[i] = row[header]
+		}
+		rows = append(rows, values)
+	}
+
+	if f.sheets != nil {
+		*f.sheets = append(*f.sheets, xlsxSheet{f.mode, rows})
+		return ""
+	}
+	var buf strings.Builder
+	if err := writeXLSX(&buf, []xlsxSheet{{f.mode, rows}}); err != nil {
+		return fmt.Sprintf("Error: %v\n", err)
+	}
+	return buf.String()
+}
+
+// xlsxHeader marks a header cell, which is written in bold.
+type xlsxHeader string
+
+// xlsxSheet is a named sheet of a workbook.
+type xlsxSheet struct {
+	name string
+	rows [][]interface{}
+}
+
+// writeXLSX writes a minimal SpreadsheetML workbook with the sheets to w.
+// Strings are stored inline, so no shared string table is needed.
+func writeXLSX(w io.Writer, sheets []xlsxSheet) error {
+	var types, entries, rels strings.Builder
+	for i, sheet := range sheets {
+		var name strings.Builder
+		xml.EscapeText(&name, []byte(sheet.name))
+		fmt.Fprintf(&types, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, i+1)
+		fmt.Fprintf(&entries, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, name.String(), i+1, i+1)
+		fmt.Fprintf(&rels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, i+1, i+1)
+	}
+
+	parts := []struct{ name, body string }{
+		{"[Content_Types].xml", xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+			`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+			`<Default Extension="xml" ContentType="application/xml"/>` +
+			`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+			types.String() +
+			`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>` +
+			`</Types>`},
+		{"_rels/.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+			`</Relationships>`},
+		{"xl/workbook.xml", xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" ` +
+			`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+			`<sheets>` + entries.String() + `</sheets></workbook>`},
+		{"xl/_rels/workbook.xml.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			rels.String() +
+			fmt.Sprintf(`<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`, len(sheets)+1) +
+			`</Relationships>`},
+		{"xl/styles.xml", xml.Header + `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+			`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+			`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+			`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+			`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+			`<cellXfs count="4">` +
+			`<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+			`<xf numFmtId="3" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+			`<xf numFmtId="22" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+			`<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/>` +
+			`</cellXfs></styleSheet>`},
+	}
+	for i, sheet := range sheets {
+		parts = append(parts, struct{ name, body string }{fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1), xlsxSheetXML(sheet.rows)})
+	}
+
+	zw := zip.NewWriter(w)
+	for _, part := range parts {
+		pw, err := zw.Create(part.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(pw, part.body); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// xlsxSheetXML returns the worksheet XML of rows.
+func xlsxSheetXML(rows [][]interface{}) string {
+	var sheetXML strings.Builder
+	sheetXML.WriteString(xml.Header)
+	sheetXML.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	for r, row := range rows {
+		fmt.Fprintf(&sheetXML, `<row r="%d">`, r+1)
+		for c, v := range row {
+			writeXLSXCell(&sheetXML, xlsxCellRef(c, r), v)
+		}
+		sheetXML.WriteString(`</row>`)
+	}
+	sheetXML.WriteString(`</sheetData></worksheet>`)
+	return sheetXML.String()
+}
+
+// writeXLSXCell writes a single cell, choosing the cell type and style from
+// the Go type of v. Sizes become numbers with digit grouping and times
+// become date cells; other numbers are written in the general format so
+// years and IDs are not grouped.
+func writeXLSXCell(sb *strings.Builder, ref string, v interface{}) {
+	number := func(style int, n string) {
+		fmt.Fprintf(sb, `<c r="%s" s="%d"><v>%s</v></c>`, ref, style, n)
+	}
+
+	switch v := v.(type) {
+	case nil:
+		return
+	case byteSize:
+		number(xlsxStyleNumber, strconv.FormatInt(int64(v), 10))
+	case int:
+		number(xlsxStyleGeneral, strconv.Itoa(v))
+	case int64:
+		number(xlsxStyleGeneral, strconv.FormatInt(v, 10))
+	case uint32:
+		number(xlsxStyleGeneral, strconv.FormatUint(uint64(v), 10))
+	case uint64:
+		number(xlsxStyleGeneral, strconv.FormatUint(v, 10))
+	case float64:
+		number(xlsxStyleGeneral, strconv.FormatFloat(v, 'f', -1, 64))
+	case time.Time:
+		number(xlsxStyleDate, strconv.FormatFloat(excelSerial(v), 'f', -1, 64))
+	case xlsxHeader:
+		writeXLSXString(sb, ref, xlsxStyleHeader, string(v))
+	default:
+		writeXLSXString(sb, ref, xlsxStyleGeneral, fmt.Sprintf("%v", v))
+	}
+}
+
+// writeXLSXString writes an inline string cell.
+func writeXLSXString(sb *strings.Builder, ref string, style int, s string) {
+	fmt.Fprintf(sb, `<c r="%s" s="%d" t="inlineStr"><is><t xml:space="preserve">`, ref, style)
+	xml.EscapeText(sb, []byte(s))
+	sb.WriteString(`</t></is></c>`)
+}
+
+// xlsxCellRef returns the A1-style reference of a zero-based column and row.
+func xlsxCellRef(col, row int) string {
+	name := ""
+	for col++; col > 0; col = (col - 1) / 26 {
+		name = string(rune('A'+(col-1)%26)) + name
+	}
+	return name + strconv.Itoa(row+1)
+}
+
+// excelSerial converts t to an Excel date serial number (days since the
+// epoch, with the time of day as fraction). Excel dates carry no time
+// zone, so the wall clock time in t's location is used.
+func excelSerial(t time.Time) float64 {
+	_, offset := t.Zone()
+	wall := t.Add(time.Duration(offset) * time.Second).UTC()
+	return wall.Sub(excelEpoch).Hours() / 24
+}
@@ -0,0 +1,94 @@
+package output
+
+import (
+	"archive/zip"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/otuschhoff/cwalk/pkg/stat"
+)
+
+// readXLSXPart returns the contents of a part of an XLSX workbook.
+func readXLSXPart(t *testing.T, workbook, name string) string {
+	t.Helper()
+	zr, err := zip.NewReader(strings.NewReader(workbook), int64(len(workbook)))
+	if err != nil {
+		t.Fatalf("output is not a zip archive: %v", err)
+	}
+	rc, err := zr.Open(name)
+	if err != nil {
+		t.Fatalf("workbook has no %s: %v", name, err)
+	}
+	defer rc.Close()
+	b, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", name, err)
+	}
+	return string(b)
+}
+
+func TestFormatXLSX(t *testing.T) {
+	results := &stat.Results{
+		Summary: &stat.SummaryStat{},
+		ByUID: map[uint32]*stat.UIDStat{
+			1000: {UID: 1000, Username: "alice & bob", TotalSize: 1048576, TotalInodes: 100, Files: 80, Dirs: 20, FilesSize: 1048000},
+		},
+	}
+
+	f := NewFormatter("xlsx", "per-uid", false)
+	sheet := readXLSXPart(t, f.Format(results), "xl/worksheets/sheet1.xml")
+
+	for _, want := range []string{
+		`<c r="A1" s="3" t="inlineStr"><is><t xml:space="preserve">UID</t></is></c>`, // Bold header
+		`<c r="A2" s="0"><v>1000</v></c>`,                                            // UID without digit grouping
+		`<t xml:space="preserve">alice &amp; bob</t>`,                                // Escaped string
+		`<c r="C2" s="1"><v>1048576</v></c>`,                                         // Size as grouped number
+		`<c r="D2" s="0"><v>100</v></c>`,                                             // Count
+	} {
+		if !strings.Contains(sheet, want) {
+			t.Errorf("sheet should contain %s, got %s", want, sheet)
+		}
+	}
+
+	f = NewFormatter("xlsx", "per-uid", true)
+	sheet = readXLSXPart(t, f.Format(results), "xl/worksheets/sheet1.xml")
+	if strings.Contains(sheet, "Username") {
+		t.Errorf("sheet should have no header row, got %s", sheet)
+	}
+
+	workbook := readXLSXPart(t, f.Format(results), "xl/workbook.xml")
+	if !strings.Contains(workbook, `<sheet name="per-uid"`) {
+		t.Errorf("sheet should be named after the mode, got %s", workbook)
+	}
+}
+
+func TestXLSXDateCell(t *testing.T) {
+	var sb strings.Builder
+	mtime := time.Date(2024, 3, 1, 12, 0, 0, 0, time.FixedZone("CET", 3600))
+	writeXLSXCell(&sb, "A1", mtime)
+
+	// 2024-03-01 is day 45352; noon local time adds half a day
+	if want := `<c r="A1" s="2"><v>45352.5</v></c>`; sb.String() != want {
+		t.Errorf("date cell = %s, want %s", sb.String(), want)
+	}
+}
+
+func TestXLSXCellRef(t *testing.T) {
+	tests := []struct {
+		col, row int
+		want     string
+	}{
+		{0, 0, "A1"},
+		{25, 9, "Z10"},
+		{26, 0, "AA1"},
+		{701, 1, "ZZ2"},
+		{702, 2, "AAA3"},
+	}
+	for _, tt := range tests {
+		if got := xlsxCellRef(tt.col, tt.row); got != tt.want {
+			t.Errorf("xlsxCellRef(%d, %d) = %s, want %s", tt.col, tt.row, got, tt.want)
+		}
+	}
+}
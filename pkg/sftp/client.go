@@ -0,0 +1,448 @@
+// Package sftp implements a minimal SFTP (protocol version 3) client for
+// scanning remote directory trees.
+//
+// It speaks the protocol over the sftp subsystem of the system ssh client,
+// so the remote machine only needs an SSH server, and the user's keys,
+// agent and ssh_config apply unchanged. Only the metadata requests needed
+// for a walk are supported: listing directories and stat'ing entries. File
+// contents are never read.
+package sftp
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Packet types (draft-ietf-secsh-filexfer-02).
+const (
+	fxpInit     = 1
+	fxpVersion  = 2
+	fxpClose    = 4
+	fxpLstat    = 7
+	fxpOpendir  = 11
+	fxpReaddir  = 12
+	fxpStat     = 17
+	fxpStatus   = 101
+	fxpHandle   = 102
+	fxpName     = 104
+	fxpAttrs    = 105
+	fxpProtocol = 3
+)
+
+// Status codes.
+const (
+	fxOK               = 0
+	fxEOF              = 1
+	fxNoSuchFile       = 2
+	fxPermissionDenied = 3
+)
+
+// Attribute flags.
+const (
+	attrSize        = 0x00000001
+	attrUIDGID      = 0x00000002
+	attrPermissions = 0x00000004
+	attrACModTime   = 0x00000008
+	attrExtended    = 0x80000000
+)
+
+// maxPacket bounds the size of a packet accepted from the server.
+const maxPacket = 4 << 20
+
+// Attrs holds the attributes the server reported for an entry. It is the
+// Sys() value of the os.FileInfo returned by the client. Fields whose flag
+// is not set in Flags are zero.
+type Attrs struct {
+	Flags       uint32
+	Size        uint64
+	UID         uint32
+	GID         uint32
+	Permissions uint32 // Unix st_mode, including the file type bits
+	Atime       uint32 // Seconds since the Unix epoch
+	Mtime       uint32 // Seconds since the Unix epoch
+}
+
+// Client is a connection to an SFTP server. It is safe for concurrent use;
+// requests from several goroutines are pipelined on the connection.
+type Client struct {
+	conn io.ReadWriteCloser
+	wmu  sync.Mutex // Serializes packet writes
+
+	mu       sync.Mutex
+	nextID   uint32
+	inflight map[uint32]chan packet
+	err      error // Set once the connection has failed
+}
+
+// packet is a response from the server, without length and request ID.
+type packet struct {
+	typ  byte
+	data []byte
+}
+
+// NewClient performs the SFTP handshake on conn and returns a client
+// using it. The client takes ownership of conn.
+func NewClient(conn io.ReadWriteCloser) (*Client, error) {
+	var b buffer
+	b.uint32(fxpProtocol)
+	if err := writePacket(conn, fxpInit, b); err != nil {
+		return nil, fmt.Errorf("sftp handshake failed: %w", err)
+	}
+	typ, data, err := readPacket(conn)
+	if err != nil {
+		return nil, fmt.Errorf("sftp handshake failed: %w", err)
+	}
+	d := decoder{b: data}
+	if version := d.uint32(); typ != fxpVersion || d.err != nil || version < fxpProtocol {
+		return nil, fmt.Errorf("sftp handshake failed: unsupported server (packet type %d, version %d)", typ, version)
+	}
+
+	c := &Client{conn: conn, inflight: map[uint32]chan packet{}}
+	go c.readLoop()
+	return c, nil
+}
+
+// Close closes the connection. Pending requests fail.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// Lstat returns the attributes of the entry at p without following
+// symlinks.
+func (c *Client) Lstat(p string) (os.FileInfo, error) {
+	return c.stat(fxpLstat, "lstat", p)
+}
+
+// Stat returns the attributes of the entry at p, following symlinks.
+func (c *Client) Stat(p string) (os.FileInfo, error) {
+	return c.stat(fxpStat, "stat", p)
+}
+
+func (c *Client) stat(typ byte, op, p string) (os.FileInfo, error) {
+	var b buffer
+	b.string(p)
+	resp, err := c.request(typ, b)
+	if err != nil {
+		return nil, &fs.PathError{Op: op, Path: p, Err: err}
+	}
+	if resp.typ != fxpAttrs {
+		return nil, &fs.PathError{Op: op, Path: p, Err: responseError(resp)}
+	}
+	d := decoder{b: resp.data}
+	attrs := d.attrs()
+	if d.err != nil {
+		return nil, &fs.PathError{Op: op, Path: p, Err: d.err}
+	}
+	return &fileInfo{name: path.Base(p), attrs: attrs}, nil
+}
+
+// ReadDir lists the directory at p, sorted by name. The attributes of each
+// entry come with the listing, so DirEntry.Info needs no further request.
+func (c *Client) ReadDir(p string) ([]fs.DirEntry, error) {
+	var b buffer
+	b.string(p)
+	resp, err := c.request(fxpOpendir, b)
+	if err != nil {
+		return nil, &fs.PathError{Op: "readdir", Path: p, Err: err}
+	}
+	if resp.typ != fxpHandle {
+		return nil, &fs.PathError{Op: "readdir", Path: p, Err: responseError(resp)}
+	}
+	d := decoder{b: resp.data}
+	handle := d.string()
+	defer func() {
+		var b buffer
+		b.string(handle)
+		c.request(fxpClose, b)
+	}()
+
+	var entries []fs.DirEntry
+	for {
+		var b buffer
+		b.string(handle)
+		resp, err := c.request(fxpReaddir, b)
+		if err != nil {
+			return nil, &fs.PathError{Op: "readdir", Path: p, Err: err}
+		}
+		if resp.typ != fxpName {
+			if err := responseError(resp); err != io.EOF {
+				return nil, &fs.PathError{Op: "readdir", Path: p, Err: err}
+			}
+			break
+		}
+
+		d := decoder{b: resp.data}
+		count := d.uint32()
+		for i := uint32(0); i < count && d.err == nil; i++ {
+			name := d.string()
+			d.string() // Long name as printed by ls -l
+			attrs := d.attrs()
+			if name != "." && name != ".." {
+				entries = append(entries, fs.FileInfoToDirEntry(&fileInfo{name: name, attrs: attrs}))
+			}
+		}
+		if d.err != nil {
+			return nil, &fs.PathError{Op: "readdir", Path: p, Err: d.err}
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	return entries, nil
+}
+
+// request sends a request and waits for its response.
+func (c *Client) request(typ byte, payload buffer) (packet, error) {
+	ch := make(chan packet, 1)
+	c.mu.Lock()
+	if c.err != nil {
+		c.mu.Unlock()
+		return packet{}, c.err
+	}
+	c.nextID++
+	id := c.nextID
+	c.inflight[id] = ch
+	c.mu.Unlock()
+
+	var b buffer
+	b.uint32(id)
+	b = append(b, payload...)
+	c.wmu.Lock()
+	err := writePacket(c.conn, typ, b)
+	c.wmu.Unlock()
+	if err != nil {
+		c.fail(err)
+	}
+
+	resp, ok := <-ch
+	if !ok {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		return packet{}, c.err
+	}
+	return resp, nil
+}
+
+// readLoop dispatches responses to the waiting requests until the
+// connection fails.
+func (c *Client) readLoop() {
+	for {
+		typ, data, err := readPacket(c.conn)
+		if err == nil && len(data) < 4 {
+			err = errors.New("sftp: short packet")
+		}
+		if err != nil {
+			c.fail(err)
+			return
+		}
+
+		id := binary.BigEndian.Uint32(data)
+		c.mu.Lock()
+		ch := c.inflight[id]
+		delete(c.inflight, id)
+		c.mu.Unlock()
+		if ch != nil {
+			ch <- packet{typ: typ, data: data[4:]}
+		}
+	}
+}
+
+// fail marks the connection as failed and fails all pending requests.
+func (c *Client) fail(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return
+	}
+	if errors.Is(err, io.EOF) {
+		err = io.ErrUnexpectedEOF
+	}
+	c.err = fmt.Errorf("sftp connection failed: %w", err)
+	for id, ch := range c.inflight {
+		close(ch)
+		delete(c.inflight, id)
+	}
+	c.conn.Close()
+}
+
+// responseError converts an unexpected response to an error. A status
+// response is mapped to io.EOF, fs.ErrNotExist or fs.ErrPermission where
+// possible, so callers can use errors.Is and os.IsNotExist.
+func responseError(resp packet) error {
+	if resp.typ != fxpStatus {
+		return fmt.Errorf("sftp: unexpected packet type %d", resp.typ)
+	}
+	d := decoder{b: resp.data}
+	code := d.uint32()
+	msg := d.string()
+	switch code {
+	case fxOK:
+		return nil
+	case fxEOF:
+		return io.EOF
+	case fxNoSuchFile:
+		return fs.ErrNotExist
+	case fxPermissionDenied:
+		return fs.ErrPermission
+	default:
+		return fmt.Errorf("sftp: %s (status %d)", msg, code)
+	}
+}
+
+// fileInfo implements os.FileInfo for attributes returned by the server.
+type fileInfo struct {
+	name  string
+	attrs Attrs
+}
+
+func (fi *fileInfo) Name() string       { return fi.name }
+func (fi *fileInfo) Size() int64        { return int64(fi.attrs.Size) }
+func (fi *fileInfo) Mode() os.FileMode  { return fileMode(fi.attrs.Permissions) }
+func (fi *fileInfo) ModTime() time.Time { return time.Unix(int64(fi.attrs.Mtime), 0) }
+func (fi *fileInfo) IsDir() bool        { return fi.Mode().IsDir() }
+func (fi *fileInfo) Sys() interface{}   { return &fi.attrs }
+
+// fileMode converts a Unix st_mode to an os.FileMode.
+func fileMode(m uint32) os.FileMode {
+	mode := os.FileMode(m & 0777)
+	switch m & 0170000 {
+	case 0040000:
+		mode |= os.ModeDir
+	case 0120000:
+		mode |= os.ModeSymlink
+	case 0010000:
+		mode |= os.ModeNamedPipe
+	case 0140000:
+		mode |= os.ModeSocket
+	case 0020000:
+		mode |= os.ModeDevice | os.ModeCharDevice
+	case 0060000:
+		mode |= os.ModeDevice
+	}
+	if m&04000 != 0 {
+		mode |= os.ModeSetuid
+	}
+	if m&02000 != 0 {
+		mode |= os.ModeSetgid
+	}
+	if m&01000 != 0 {
+		mode |= os.ModeSticky
+	}
+	return mode
+}
+
+// buffer encodes packet payloads.
+type buffer []byte
+
+func (b *buffer) uint32(v uint32) {
+	*b = binary.BigEndian.AppendUint32(*b, v)
+}
+
+func (b *buffer) string(s string) {
+	b.uint32(uint32(len(s)))
+	*b = append(*b, s...)
+}
+
+// decoder decodes packet payloads. The first error sticks and later reads
+// return zero values.
+type decoder struct {
+	b   []byte
+	err error
+}
+
+func (d *decoder) next(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if len(d.b) < n {
+		d.err = errors.New("sftp: short packet")
+		return nil
+	}
+	v := d.b[:n]
+	d.b = d.b[n:]
+	return v
+}
+
+func (d *decoder) uint32() uint32 {
+	if v := d.next(4); v != nil {
+		return binary.BigEndian.Uint32(v)
+	}
+	return 0
+}
+
+func (d *decoder) uint64() uint64 {
+	if v := d.next(8); v != nil {
+		return binary.BigEndian.Uint64(v)
+	}
+	return 0
+}
+
+func (d *decoder) string() string {
+	n := d.uint32()
+	if n > maxPacket {
+		d.err = errors.New("sftp: string too long")
+		return ""
+	}
+	return string(d.next(int(n)))
+}
+
+func (d *decoder) attrs() Attrs {
+	var a Attrs
+	a.Flags = d.uint32()
+	if a.Flags&attrSize != 0 {
+		a.Size = d.uint64()
+	}
+	if a.Flags&attrUIDGID != 0 {
+		a.UID = d.uint32()
+		a.GID = d.uint32()
+	}
+	if a.Flags&attrPermissions != 0 {
+		a.Permissions = d.uint32()
+	}
+	if a.Flags&attrACModTime != 0 {
+		a.Atime = d.uint32()
+		a.Mtime = d.uint32()
+	}
+	if a.Flags&attrExtended != 0 {
+		n := d.uint32()
+		for i := uint32(0); i < n && d.err == nil; i++ {
+			d.string() // Type
+			d.string() // Data
+		}
+	}
+	return a
+}
+
+// writePacket writes a packet of the given type and payload.
+func writePacket(w io.Writer, typ byte, payload buffer) error {
+	var b buffer
+	b.uint32(uint32(len(payload) + 1))
+	b = append(b, typ)
+	b = append(b, payload...)
+	_, err := w.Write(b)
+	return err
+}
+
+// readPacket reads a packet and returns its type and payload.
+func readPacket(r io.Reader) (byte, []byte, error) {
+	var header [4]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return 0, nil, err
+	}
+	n := binary.BigEndian.Uint32(header[:])
+	if n == 0 || n > maxPacket {
+		return 0, nil, fmt.Errorf("sftp: invalid packet length %d", n)
+	}
+	body := make([]byte, n)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return 0, nil, err
+	}
+	return body[0], body[1:], nil
+}
@@ -0,0 +1,334 @@
+package sftp
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	cwalk "github.com/otuschhoff/cwalk"
+)
+
+var testMtime = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func testFiles() fstest.MapFS {
+	return fstest.MapFS{
+		"a.txt":         {Data: []byte("hello"), Mode: 0644, ModTime: testMtime},
+		"dir/b.txt":     {Data: []byte("world!"), Mode: 0600, ModTime: testMtime},
+		"dir/sub/c.txt": {Data: []byte("c"), Mode: 0644, ModTime: testMtime},
+		"link":          {Data: []byte("a.txt"), Mode: fs.ModeSymlink | 0777, ModTime: testMtime},
+	}
+}
+
+// unixMode converts an fs.FileMode back to a Unix st_mode.
+func unixMode(m fs.FileMode) uint32 {
+	mode := uint32(m.Perm())
+	switch {
+	case m.IsDir():
+		mode |= 0040000
+	case m&fs.ModeSymlink != 0:
+		mode |= 0120000
+	default:
+		mode |= 0100000
+	}
+	return mode
+}
+
+func appendAttrs(b *buffer, info fs.FileInfo) {
+	b.uint32(attrSize | attrUIDGID | attrPermissions | attrACModTime)
+	b.uint32(uint32(uint64(info.Size()) >> 32))
+	b.uint32(uint32(info.Size()))
+	b.uint32(1000) // UID
+	b.uint32(100)  // GID
+	b.uint32(unixMode(info.Mode()))
+	b.uint32(uint32(info.ModTime().Unix()))
+	b.uint32(uint32(info.ModTime().Unix()))
+}
+
+func status(b *buffer, code uint32) byte {
+	b.uint32(code)
+	b.string("")
+	b.string("")
+	return fxpStatus
+}
+
+// serveSFTP answers SFTP requests on conn with the entries of files, which
+// appear below the remote directory /srv. Directory listings are returned
+// in reverse order to check that clients sort them.
+func serveSFTP(conn net.Conn, files fstest.MapFS) {
+	defer conn.Close()
+	if _, _, err := readPacket(conn); err != nil {
+		return
+	}
+	var hello buffer
+	hello.uint32(fxpProtocol)
+	writePacket(conn, fxpVersion, hello)
+
+	lstat := func(name string) (fs.FileInfo, error) {
+		if f, ok := files[name]; ok {
+			return mapInfo{name, f}, nil
+		}
+		return fs.Stat(files, name)
+	}
+
+	listed := map[string]bool{}
+	for {
+		typ, data, err := readPacket(conn)
+		if err != nil {
+			return
+		}
+		d := decoder{b: data}
+		id := d.uint32()
+		arg := d.string()
+		name := strings.TrimPrefix(strings.TrimPrefix(arg, "/srv"), "/")
+		if name == "" {
+			name = "."
+		}
+
+		var resp buffer
+		resp.uint32(id)
+		respType := byte(fxpStatus)
+		switch typ {
+		case fxpLstat, fxpStat:
+			info, err := lstat(name)
+			if err != nil {
+				respType = status(&resp, fxNoSuchFile)
+				break
+			}
+			if name != "." {
+				info = renamed{info, name}
+			}
+			respType = fxpAttrs
+			appendAttrs(&resp, info)
+		case fxpOpendir:
+			if _, err := fs.ReadDir(files, name); err != nil {
+				respType = status(&resp, fxNoSuchFile)
+				break
+			}
+			respType = fxpHandle
+			resp.string(name)
+		case fxpReaddir:
+			if listed[arg] {
+				respType = status(&resp, fxEOF)
+				break
+			}
+			listed[arg] = true
+			entries, _ := fs.ReadDir(files, arg)
+			respType = fxpName
+			resp.uint32(uint32(len(entries) + 1))
+			resp.string(".")
+			resp.string("")
+			dot, _ := fs.Stat(files, arg)
+			appendAttrs(&resp, dot)
+			for i := len(entries) - 1; i >= 0; i-- {
+				info, _ := entries[i].Info()
+				resp.string(entries[i].Name())
+				resp.string("-rw-r--r-- 1 alice users")
+				appendAttrs(&resp, info)
+			}
+		case fxpClose:
+			delete(listed, arg)
+			respType = status(&resp, fxOK)
+		default:
+			respType = status(&resp, 8) // Unsupported
+		}
+		if err := writePacket(conn, respType, resp); err != nil {
+			return
+		}
+	}
+}
+
+// mapInfo describes a MapFS entry without following symlinks.
+type mapInfo struct {
+	name string
+	f    *fstest.MapFile
+}
+
+func (m mapInfo) Name() string       { return m.name }
+func (m mapInfo) Size() int64        { return int64(len(m.f.Data)) }
+func (m mapInfo) Mode() fs.FileMode  { return m.f.Mode }
+func (m mapInfo) ModTime() time.Time { return m.f.ModTime }
+func (m mapInfo) IsDir() bool        { return m.f.Mode.IsDir() }
+func (m mapInfo) Sys() interface{}   { return nil }
+
+// renamed overrides the name of a FileInfo.
+type renamed struct {
+	fs.FileInfo
+	name string
+}
+
+func (r renamed) Name() string { return r.name }
+
+// newTestFS returns an FS backed by n connections to fake servers.
+func newTestFS(t *testing.T, n int) *FS {
+	t.Helper()
+	var clients []*Client
+	for i := 0; i < n; i++ {
+		client, server := net.Pipe()
+		go serveSFTP(server, testFiles())
+		c, err := NewClient(client)
+		if err != nil {
+			t.Fatalf("NewClient failed: %v", err)
+		}
+		clients = append(clients, c)
+	}
+	fsys := NewFS("/srv", clients...)
+	t.Cleanup(func() { fsys.Close() })
+	return fsys
+}
+
+func TestClientLstat(t *testing.T) {
+	fsys := newTestFS(t, 1)
+
+	info, err := fsys.Lstat("dir/b.txt")
+	if err != nil {
+		t.Fatalf("Lstat failed: %v", err)
+	}
+	if info.Name() != "b.txt" || info.Size() != 6 || info.Mode() != 0600 || !info.ModTime().Equal(testMtime) {
+		t.Errorf("Lstat(dir/b.txt) = %s %d %v %v", info.Name(), info.Size(), info.Mode(), info.ModTime())
+	}
+	if attrs, ok := info.Sys().(*Attrs); !ok || attrs.UID != 1000 || attrs.GID != 100 {
+		t.Errorf("Sys() = %#v, want owner 1000:100", info.Sys())
+	}
+
+	info, err = fsys.Lstat("link")
+	if err != nil || info.Mode()&fs.ModeSymlink == 0 {
+		t.Errorf("Lstat(link) = %v, %v, want a symlink", info, err)
+	}
+
+	if _, err := fsys.Lstat("missing"); !os.IsNotExist(err) {
+		t.Errorf("Lstat(missing) error = %v, want not exist", err)
+	}
+	if _, err := fsys.Lstat("../etc"); !errors.Is(err, fs.ErrInvalid) {
+		t.Errorf("Lstat(../etc) error = %v, want invalid", err)
+	}
+}
+
+func TestClientReadDir(t *testing.T) {
+	fsys := newTestFS(t, 1)
+
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		t.Fatalf("ReadDir failed: %v", err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	if strings.Join(names, " ") != "a.txt dir link" {
+		t.Errorf("ReadDir(.) = %v, want sorted [a.txt dir link] without . and ..", names)
+	}
+	if !entries[1].IsDir() || entries[2].Type() != fs.ModeSymlink {
+		t.Errorf("entry types = %v %v, want dir and symlink", entries[1].Type(), entries[2].Type())
+	}
+
+	if _, err := fs.ReadDir(fsys, "missing"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("ReadDir(missing) error = %v, want not exist", err)
+	}
+
+	// Directories opened through fs.FS list the same entries
+	f, err := fsys.Open("dir")
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+	dir, ok := f.(fs.ReadDirFile)
+	if !ok {
+		t.Fatal("Open(dir) should return a fs.ReadDirFile")
+	}
+	if entries, err := dir.ReadDir(1); err != nil || len(entries) != 1 || entries[0].Name() != "b.txt" {
+		t.Errorf("ReadDir(1) = %v, %v", entries, err)
+	}
+	if entries, err := dir.ReadDir(1); err != nil || len(entries) != 1 || entries[0].Name() != "sub" {
+		t.Errorf("ReadDir(1) = %v, %v", entries, err)
+	}
+	if _, err := dir.ReadDir(1); err != io.EOF {
+		t.Errorf("ReadDir(1) at end error = %v, want EOF", err)
+	}
+}
+
+// TestWalkSFTP verifies that cwalk walks an FS concurrently over a pool of
+// connections without following symlinks.
+func TestWalkSFTP(t *testing.T) {
+	fsys := newTestFS(t, 3)
+
+	var mu sync.Mutex
+	var paths []string
+	walker := cwalk.NewWalkerFS(fsys, ".", 4, cwalk.Callbacks{
+		OnLstat: func(isDir bool, relPath string, info os.FileInfo, err error) {
+			if err != nil {
+				t.Errorf("OnLstat got error for %q: %v", relPath, err)
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			paths = append(paths, relPath)
+		},
+	})
+	if err := walker.Run(); err != nil {
+		t.Fatalf("Walk failed: %v", err)
+	}
+
+	sort.Strings(paths)
+	if want := ", a.txt, dir, dir/b.txt, dir/sub, dir/sub/c.txt, link"; strings.Join(paths, ", ") != want {
+		t.Errorf("walked %q, want %q", strings.Join(paths, ", "), want)
+	}
+}
+
+func TestClientConnectionLost(t *testing.T) {
+	client, server := net.Pipe()
+	go func() {
+		readPacket(server)
+		var hello buffer
+		hello.uint32(fxpProtocol)
+		writePacket(server, fxpVersion, hello)
+		readPacket(server) // Drop the first request
+		server.Close()
+	}()
+
+	c, err := NewClient(client)
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	if _, err := c.Lstat("/srv"); err == nil || !strings.Contains(err.Error(), "connection failed") {
+		t.Errorf("Lstat error = %v, want connection failure", err)
+	}
+	if _, err := c.Lstat("/srv"); err == nil {
+		t.Error("requests after a failure should fail")
+	}
+}
+
+func TestFileMode(t *testing.T) {
+	tests := []struct {
+		mode uint32
+		want fs.FileMode
+	}{
+		{0100644, 0644},
+		{0040755, fs.ModeDir | 0755},
+		{0120777, fs.ModeSymlink | 0777},
+		{0041777, fs.ModeDir | fs.ModeSticky | 0777},
+		{0104755, fs.ModeSetuid | 0755},
+		{0020620, fs.ModeDevice | fs.ModeCharDevice | 0620},
+	}
+	for _, tt := range tests {
+		if got := fileMode(tt.mode); got != tt.want {
+			t.Errorf("fileMode(%o) = %v, want %v", tt.mode, got, tt.want)
+		}
+	}
+}
+
+func TestIsURL(t *testing.T) {
+	if !IsURL("sftp://alice@host/data") || IsURL("/data") || IsURL("ssh://host") {
+		t.Error("IsURL should only accept sftp:// URLs")
+	}
+	if _, err := Dial("sftp:///data", 1); err == nil {
+		t.Error("Dial should reject URLs without host")
+	}
+}
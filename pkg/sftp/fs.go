@@ -0,0 +1,240 @@
+package sftp
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+	"sync/atomic"
+)
+
+// FS presents a remote directory as an fs.FS. It holds a pool of client
+// connections and spreads requests over them round-robin, so a parallel
+// walk is not limited by the latency of a single connection.
+//
+// Besides Open, FS implements ReadDir, Stat and Lstat, which cwalk's
+// NewWalkerFS uses to walk without following symlinks. Files can be
+// opened for Stat and ReadDir only; reading contents is not supported.
+type FS struct {
+	root    string
+	clients []*Client
+	next    atomic.Uint32
+}
+
+// IsURL reports whether s is an sftp:// URL.
+func IsURL(s string) bool {
+	return strings.HasPrefix(s, "sftp://")
+}
+
+// NewFS returns an FS rooted at the remote directory root, using the given
+// clients. At least one client is required.
+func NewFS(root string, clients ...*Client) *FS {
+	if root == "" {
+		root = "."
+	}
+	return &FS{root: root, clients: clients}
+}
+
+// Dial opens conns connections to the host of an
+// sftp://[user@]host[:port]/path URL and returns an FS rooted at its path.
+// Each connection runs "ssh -s host sftp" in batch mode, so authentication
+// must not need a password prompt. Without a path, the FS is rooted at the
+// remote user's home directory.
+func Dial(target string, conns int) (*FS, error) {
+	u, err := url.Parse(target)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "sftp" || u.Hostname() == "" {
+		return nil, fmt.Errorf("invalid sftp URL: %s", target)
+	}
+
+	dest := u.Hostname()
+	if u.User != nil {
+		dest = u.User.Username() + "@" + dest
+	}
+	args := []string{"-o", "BatchMode=yes"}
+	if port := u.Port(); port != "" {
+		args = append(args, "-p", port)
+	}
+	args = append(args, "-s", dest, "sftp")
+
+	if conns < 1 {
+		conns = 1
+	}
+	clients := make([]*Client, 0, conns)
+	for i := 0; i < conns; i++ {
+		c, err := dialSSH(args)
+		if err != nil {
+			for _, c := range clients {
+				c.Close()
+			}
+			return nil, fmt.Errorf("failed to connect to %s: %w", dest, err)
+		}
+		clients = append(clients, c)
+	}
+	return NewFS(u.Path, clients...), nil
+}
+
+// dialSSH starts an ssh process running the sftp subsystem and performs
+// the handshake over its standard input and output.
+func dialSSH(args []string) (*Client, error) {
+	cmd := exec.Command("ssh", args...)
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	c, err := NewClient(&sshConn{WriteCloser: stdin, Reader: stdout, cmd: cmd})
+	if err != nil {
+		cmd.Process.Kill()
+		cmd.Wait()
+		return nil, err
+	}
+	return c, nil
+}
+
+// sshConn is the connection through an ssh process.
+type sshConn struct {
+	io.WriteCloser
+	io.Reader
+	cmd *exec.Cmd
+}
+
+// Close closes the standard input of ssh, which ends the session, and
+// waits for the process to exit.
+func (c *sshConn) Close() error {
+	err := c.WriteCloser.Close()
+	c.cmd.Wait()
+	return err
+}
+
+// Close closes all connections.
+func (f *FS) Close() error {
+	var errs []error
+	for _, c := range f.clients {
+		errs = append(errs, c.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// client returns the next connection of the pool.
+func (f *FS) client() *Client {
+	return f.clients[int(f.next.Add(1))%len(f.clients)]
+}
+
+// remotePath returns the remote path for name, which must be a valid
+// fs.FS path.
+func (f *FS) remotePath(op, name string) (string, error) {
+	if !fs.ValidPath(name) {
+		return "", &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	return path.Join(f.root, name), nil
+}
+
+// Open returns a file that supports Stat and ReadDir.
+func (f *FS) Open(name string) (fs.File, error) {
+	info, err := f.Stat(name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: errors.Unwrap(err)}
+	}
+	return &file{fsys: f, name: name, info: info}, nil
+}
+
+// ReadDir lists the directory name, sorted by name.
+func (f *FS) ReadDir(name string) ([]fs.DirEntry, error) {
+	p, err := f.remotePath("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := f.client().ReadDir(p)
+	return entries, rename(err, name)
+}
+
+// Stat returns the attributes of name, following symlinks.
+func (f *FS) Stat(name string) (fs.FileInfo, error) {
+	p, err := f.remotePath("stat", name)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.client().Stat(p)
+	return info, rename(err, name)
+}
+
+// Lstat returns the attributes of name without following symlinks.
+func (f *FS) Lstat(name string) (fs.FileInfo, error) {
+	p, err := f.remotePath("lstat", name)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.client().Lstat(p)
+	return info, rename(err, name)
+}
+
+// rename reports a client error under the fs.FS name instead of the
+// remote path.
+func rename(err error, name string) error {
+	var pe *fs.PathError
+	if errors.As(err, &pe) {
+		return &fs.PathError{Op: pe.Op, Path: name, Err: pe.Err}
+	}
+	return err
+}
+
+// file is an open remote file or directory.
+type file struct {
+	fsys    *FS
+	name    string
+	info    fs.FileInfo
+	entries []fs.DirEntry // Remaining entries for ReadDir, nil until listed
+	listed  bool
+}
+
+func (f *file) Stat() (fs.FileInfo, error) {
+	return f.info, nil
+}
+
+func (f *file) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: f.name, Err: errors.ErrUnsupported}
+}
+
+func (f *file) Close() error {
+	return nil
+}
+
+// ReadDir implements fs.ReadDirFile.
+func (f *file) ReadDir(n int) ([]fs.DirEntry, error) {
+	if !f.listed {
+		entries, err := f.fsys.ReadDir(f.name)
+		if err != nil {
+			return nil, err
+		}
+		f.entries, f.listed = entries, true
+	}
+
+	if n <= 0 {
+		entries := f.entries
+		f.entries = nil
+		return entries, nil
+	}
+	if len(f.entries) == 0 {
+		return nil, io.EOF
+	}
+	n = min(n, len(f.entries))
+	entries := f.entries[:n]
+	f.entries = f.entries[n:]
+	return entries, nil
+}
@@ -0,0 +1,128 @@
+package stat
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// archiveFormat identifies the archive formats whose entries can be walked.
+type archiveFormat int
+
+const (
+	notArchive archiveFormat = iota
+	archiveTar
+	archiveTarGz
+	archiveZip
+)
+
+// archiveFormatOf returns the archive format of a file by its name.
+func archiveFormatOf(name string) archiveFormat {
+	lower := strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(lower, ".tar"):
+		return archiveTar
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		return archiveTarGz
+	case strings.HasSuffix(lower, ".zip"):
+		return archiveZip
+	default:
+		return notArchive
+	}
+}
+
+// walkArchive records the entries of an archive found by the walk under
+// virtual paths below the archive's own path, such as
+// "backups/home.tar.gz/alice/notes.txt". Tar entries keep the owner stored
+// in the archive; zip archives store none, so their entries are attributed
+// to the owner of the archive file. Nested archives are not opened. A
+// corrupt archive counts as one error and keeps the entries read before it.
+func (sw *StatsWalker) walkArchive(archive FileInfo) {
+	var err error
+	switch archiveFormatOf(archive.Path) {
+	case archiveTar, archiveTarGz:
+		err = sw.walkTar(archive)
+	case archiveZip:
+		err = sw.walkZip(archive)
+	}
+	if err != nil {
+		sw.errors.Add(1)
+		sw.log().Error("reading archive failed", "path", archive.FullPath(), "err", err)
+	}
+}
+
+// walkTar records the entries of a tar archive, gzip-compressed or not.
+func (sw *StatsWalker) walkTar(archive FileInfo) error {
+	f, err := os.Open(filepath.Join(archive.Root, archive.Path))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if archiveFormatOf(archive.Path) == archiveTarGz {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		sw.recordArchiveEntry(archive, hdr.Name, hdr.FileInfo(), uint32(hdr.Uid), uint32(hdr.Gid))
+	}
+}
+
+// walkZip records the entries of a zip archive.
+func (sw *StatsWalker) walkZip(archive FileInfo) error {
+	zr, err := zip.OpenReader(filepath.Join(archive.Root, archive.Path))
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+
+	for _, f := range zr.File {
+		sw.recordArchiveEntry(archive, f.Name, f.FileInfo(), archive.UID, archive.GID)
+	}
+	return nil
+}
+
+// recordArchiveEntry filters and aggregates a single archive entry. Entry
+// names are cleaned so "./a", "/a" and "a/" all map to "a"; the archive's
+// own root entry is skipped.
+func (sw *StatsWalker) recordArchiveEntry(archive FileInfo, name string, info fs.FileInfo, uid, gid uint32) {
+	name = strings.TrimPrefix(path.Clean("/"+name), "/")
+	if name == "" {
+		return
+	}
+
+	sw.entries.Add(1)
+	sw.record(FileInfo{
+		Root:      archive.Root,
+		Path:      archive.Path + "/" + name,
+		Archive:   archive.Path,
+		Size:      info.Size(),
+		Mode:      info.Mode(),
+		ModTime:   info.ModTime(),
+		IsDir:     info.IsDir(),
+		IsSymlink: info.Mode()&os.ModeSymlink != 0,
+		UID:       uid,
+		GID:       gid,
+	}, false)
+}
@@ -0,0 +1,147 @@
+package stat
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+)
+
+// writeTar writes a tar archive with a directory and two files to w.
+func writeTar(t *testing.T, w io.Writer) {
+	t.Helper()
+	mtime := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+	tw := tar.NewWriter(w)
+	for _, hdr := range []*tar.Header{
+		{Name: "./", Typeflag: tar.TypeDir, Mode: 0755, ModTime: mtime, Uid: 1234},
+		{Name: "./docs/", Typeflag: tar.TypeDir, Mode: 0755, ModTime: mtime, Uid: 1234},
+		{Name: "./docs/a.txt", Typeflag: tar.TypeReg, Mode: 0644, Size: 3, ModTime: mtime, Uid: 1234},
+		{Name: "./link", Typeflag: tar.TypeSymlink, Linkname: "docs/a.txt", ModTime: mtime, Uid: 1234},
+	} {
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatalf("failed to write tar header: %v", err)
+		}
+		if hdr.Size > 0 {
+			tw.Write([]byte("abc"))
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("failed to close tar: %v", err)
+	}
+}
+
+// setupArchiveTree creates a directory with a tar, a tar.gz and a zip
+// archive, plus a corrupt zip.
+func setupArchiveTree(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+
+	f, err := os.Create(filepath.Join(root, "plain.tar"))
+	if err != nil {
+		t.Fatalf("failed to create archive: %v", err)
+	}
+	writeTar(t, f)
+	f.Close()
+
+	f, err = os.Create(filepath.Join(root, "packed.TGZ"))
+	if err != nil {
+		t.Fatalf("failed to create archive: %v", err)
+	}
+	gz := gzip.NewWriter(f)
+	writeTar(t, gz)
+	gz.Close()
+	f.Close()
+
+	f, err = os.Create(filepath.Join(root, "files.zip"))
+	if err != nil {
+		t.Fatalf("failed to create archive: %v", err)
+	}
+	zw := zip.NewWriter(f)
+	w, _ := zw.Create("dir/b.txt")
+	w.Write([]byte("hello"))
+	zw.Close()
+	f.Close()
+
+	if err := os.WriteFile(filepath.Join(root, "broken.zip"), []byte("not a zip"), 0644); err != nil {
+		t.Fatalf("failed to create file: %v", err)
+	}
+	return root
+}
+
+func TestArchiveFormatOf(t *testing.T) {
+	tests := []struct {
+		name string
+		want archiveFormat
+	}{
+		{"a.tar", archiveTar},
+		{"a.tar.gz", archiveTarGz},
+		{"A.TGZ", archiveTarGz},
+		{"dir/a.zip", archiveZip},
+		{"a.gz", notArchive},
+		{"tar", notArchive},
+	}
+	for _, tt := range tests {
+		if got := archiveFormatOf(tt.name); got != tt.want {
+			t.Errorf("archiveFormatOf(%q) = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestWalkArchives(t *testing.T) {
+	root := setupArchiveTree(t)
+
+	// Off by default
+	res, err := NewStatsWalker([]string{root}, 2, &Filters{}).Walk()
+	if err != nil {
+		t.Fatalf("walk failed: %v", err)
+	}
+	if res.Summary.TotalInodes != 5 {
+		t.Errorf("inodes = %d, want 5 without archive entries", res.Summary.TotalInodes)
+	}
+
+	sw := NewStatsWalker([]string{root}, 2, &Filters{})
+	sw.SetArchives(true)
+	res, err = sw.Walk()
+	if err != nil {
+		t.Fatalf("walk failed: %v", err)
+	}
+
+	var paths []string
+	for _, fi := range res.AllFileInfos {
+		if fi.Archive != "" {
+			paths = append(paths, fi.Path)
+		}
+	}
+	sort.Strings(paths)
+	want := []string{
+		"files.zip/dir/b.txt",
+		"packed.TGZ/docs", "packed.TGZ/docs/a.txt", "packed.TGZ/link",
+		"plain.tar/docs", "plain.tar/docs/a.txt", "plain.tar/link",
+	}
+	if len(paths) != len(want) {
+		t.Fatalf("archive entries = %v, want %v", paths, want)
+	}
+	for i := range want {
+		if paths[i] != want[i] {
+			t.Errorf("archive entry %d = %q, want %q", i, paths[i], want[i])
+		}
+	}
+
+	// Tar entries keep their owner and timestamps
+	if s := res.ByUID[1234]; s == nil || s.Files != 2 || s.Dirs != 2 || s.Symlinks != 2 {
+		t.Errorf("uid 1234 stats = %+v, want 2 files, 2 dirs and 2 symlinks", s)
+	}
+	if s := res.ByYear[2020]; s == nil || s.TotalInodes != 6 {
+		t.Errorf("2020 stats = %+v, want the 6 tar entries", s)
+	}
+
+	// The broken zip is reported as an error
+	if res.Scan.Errors != 1 {
+		t.Errorf("errors = %d, want 1", res.Scan.Errors)
+	}
+}
@@ -0,0 +1,46 @@
+package stat
+
+import (
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/otuschhoff/cwalk/pkg/sftp"
+)
+
+// AuditReport lists the entries of a walk that deserve a security review.
+// Each section is sorted by path; an entry may appear in several sections.
+type AuditReport struct {
+	WorldWritable    []FileInfo // Files and directories writable by others (symlinks excluded)
+	SetID            []FileInfo // Regular files with the setuid or setgid bit
+	Orphaned         []FileInfo // Entries owned by a UID that no longer resolves to a user
+	DanglingSymlinks []FileInfo // Symlinks whose chain ends at a missing target
+}
+
+// Audit builds the audit report from the collected entries. Owners of
+// entries under sftp:// roots are not checked, since remote UIDs cannot be
+// resolved against the local user database.
+func (r *Results) Audit() *AuditReport {
+	report := &AuditReport{}
+	for _, fi := range r.AllFileInfos {
+		if !fi.IsSymlink && fi.Mode.Perm()&0o002 != 0 {
+			report.WorldWritable = append(report.WorldWritable, fi)
+		}
+		if fi.Mode.IsRegular() && fi.Mode&(os.ModeSetuid|os.ModeSetgid) != 0 {
+			report.SetID = append(report.SetID, fi)
+		}
+		if !sftp.IsURL(fi.Root) && strings.HasPrefix(Username(fi.UID), "uid:") {
+			report.Orphaned = append(report.Orphaned, fi)
+		}
+		if fi.IsSymlink && fi.Dangling {
+			report.DanglingSymlinks = append(report.DanglingSymlinks, fi)
+		}
+	}
+
+	for _, section := range [][]FileInfo{report.WorldWritable, report.SetID, report.Orphaned, report.DanglingSymlinks} {
+		sort.Slice(section, func(i, j int) bool {
+			return section[i].FullPath() < section[j].FullPath()
+		})
+	}
+	return report
+}
@@ -0,0 +1,52 @@
+package stat
+
+import (
+	"os"
+	"testing"
+)
+
+func TestAudit(t *testing.T) {
+	const orphanUID = 3999999 // Not expected to resolve to a user
+
+	results := &Results{AllFileInfos: []FileInfo{
+		{Root: "/r", Path: "tmp", Mode: os.ModeDir | os.ModeSticky | 0o777, IsDir: true},
+		{Root: "/r", Path: "bin/su", Mode: os.ModeSetuid | 0o755},
+		{Root: "/r", Path: "bin/wall", Mode: os.ModeSetgid | 0o755},
+		{Root: "/r", Path: "data/b", Mode: 0o666, UID: orphanUID},
+		{Root: "/r", Path: "data/a", Mode: 0o644},
+		{Root: "/r", Path: "link", Mode: os.ModeSymlink | 0o777, IsSymlink: true, Dangling: true},
+		{Root: "/r", Path: "ok-link", Mode: os.ModeSymlink | 0o777, IsSymlink: true},
+		{Root: "sftp://host/r", Path: "remote", Mode: 0o644, UID: orphanUID},
+	}}
+
+	report := results.Audit()
+
+	tests := []struct {
+		name  string
+		infos []FileInfo
+		want  []string
+	}{
+		{"world-writable", report.WorldWritable, []string{"/r/data/b", "/r/tmp"}},
+		{"setid", report.SetID, []string{"/r/bin/su", "/r/bin/wall"}},
+		{"orphaned", report.Orphaned, []string{"/r/data/b"}},
+		{"dangling", report.DanglingSymlinks, []string{"/r/link"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, fi := range tt.infos {
+				got = append(got, fi.FullPath())
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("got %v, want %v", got, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
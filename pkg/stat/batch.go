@@ -0,0 +1,37 @@
+package stat
+
+import "sync"
+
+// SetReadDirBatch makes the walk read directories in batches of at most n
+// entries, bounding memory for huge directories. See
+// cwalk.Walker.SetReadDirBatch.
+func (sw *StatsWalker) SetReadDirBatch(n int) {
+	sw.batchSize = n
+}
+
+// batchCounts sums the entries of directories read in batches until their
+// last batch.
+type batchCounts struct {
+	mu   sync.Mutex
+	dirs map[string]int
+}
+
+// add counts n more entries of the directory at fullPath.
+func (b *batchCounts) add(fullPath string, n int) {
+	b.mu.Lock()
+	if b.dirs == nil {
+		b.dirs = make(map[string]int)
+	}
+	b.dirs[fullPath] += n
+	b.mu.Unlock()
+}
+
+// take removes and returns the entries counted for the directory at
+// fullPath.
+func (b *batchCounts) take(fullPath string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	n := b.dirs[fullPath]
+	delete(b.dirs, fullPath)
+	return n
+}
@@ -0,0 +1,46 @@
+package stat
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestReadDirBatchWalk(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{"big", "empty"} {
+		if err := os.Mkdir(filepath.Join(root, dir), 0755); err != nil {
+			t.Fatalf("create dir: %v", err)
+		}
+	}
+	for i := 0; i < 30; i++ {
+		if err := os.WriteFile(filepath.Join(root, "big", strconv.Itoa(i)), nil, 0644); err != nil {
+			t.Fatalf("create file: %v", err)
+		}
+	}
+
+	sw := NewStatsWalker([]string{root}, 2, &Filters{})
+	sw.SetReadDirBatch(7)
+	sw.SetEmptyCheck(true)
+	res, err := sw.Walk()
+	if err != nil {
+		t.Fatalf("walk failed: %v", err)
+	}
+	if res.Summary.Files != 30 || res.Summary.Dirs != 3 {
+		t.Errorf("got %d files and %d dirs, want 30 and 3", res.Summary.Files, res.Summary.Dirs)
+	}
+
+	fanOut := res.FanOut
+	if fanOut.Dirs != 3 || fanOut.Entries != 32 || fanOut.MaxEntries != 30 {
+		t.Errorf("fan-out = %d dirs, %d entries, max %d; want 3, 32, 30", fanOut.Dirs, fanOut.Entries, fanOut.MaxEntries)
+	}
+	if fanOut.MaxPath != filepath.Join(root, "big") {
+		t.Errorf("fullest directory = %s, want big", fanOut.MaxPath)
+	}
+
+	report := res.Empty()
+	if len(report.Dirs) != 1 || report.Dirs[0].Path != "empty" {
+		t.Errorf("empty dirs = %v, want [empty]", report.Dirs)
+	}
+}
@@ -0,0 +1,63 @@
+package stat
+
+// Extended attributes of CephFS directories holding the recursive
+// statistics the metadata servers maintain for the whole subtree.
+const (
+	cephRbytes   = "ceph.dir.rbytes"   // Bytes of all files below
+	cephRfiles   = "ceph.dir.rfiles"   // Non-directory entries below
+	cephRsubdirs = "ceph.dir.rsubdirs" // Directories below, including the directory itself
+)
+
+// CephRstats are the recursive statistics of a CephFS directory. The
+// metadata servers propagate them lazily, so they can lag behind recent
+// changes by a few seconds.
+type CephRstats struct {
+	Bytes   int64 // Size of all files below the directory
+	Files   int64 // Files, symlinks and other non-directory entries below it
+	Subdirs int64 // Directories below it, including the directory itself
+}
+
+// SetCephFS makes Walk take the totals of roots on CephFS from their
+// recursive statistics instead of walking them, which takes constant time
+// however large the tree. This only sets the summary totals, and counts
+// symlinks and other entries as files, so it is only done for walks that
+// need nothing else: without filters, a maximum depth, excluded paths,
+// separate roots, checkpoints, cross tabulation or a watchlist, and
+// without collecting extended attributes, SELinux contexts, project IDs,
+// physical sizes, project markers, symlink targets, archive entries or
+// empty directories.
+// Other walks, roots whose statistics cannot be read and sftp:// roots are
+// walked as usual. Only supported on Linux.
+func (sw *StatsWalker) SetCephFS(cephfs bool) {
+	sw.cephfs = cephfs
+}
+
+// rstatsUsable reports whether the walk needs nothing but the totals
+// recursive statistics provide, see SetCephFS.
+func (sw *StatsWalker) rstatsUsable() bool {
+	return sw.cephfs && sw.filters.IsEmpty() && !sw.depthLimit && len(sw.excludes) == 0 &&
+		!sw.separateRoots && sw.checkpointPath == "" && sw.resumeFrom == nil &&
+		len(sw.crossDims) == 0 && sw.groupExpr == nil && sw.watchlist == nil &&
+		!sw.xattrs && !sw.selinux && !sw.lustre && sw.physical == nil && len(sw.markers) == 0 &&
+		!sw.linkCheck && !sw.archives && !sw.emptyCheck
+}
+
+// addRstats adds the recursive statistics of a root to the totals.
+func (sw *StatsWalker) addRstats(root string, rs CephRstats) {
+	shard := sw.shards[0]
+	shard.mu.Lock()
+	r := shard.results
+	r.TotalFiles["file"] += rs.Files
+	r.TotalSize["file"] += rs.Bytes
+	r.TotalInodes["file"] += rs.Files
+	r.TotalFiles["dir"] += rs.Subdirs
+	r.TotalInodes["dir"] += rs.Subdirs
+	shard.mu.Unlock()
+
+	sw.entries.Add(rs.Files + rs.Subdirs)
+	sw.files.Add(rs.Files)
+	if sw.rstats == nil {
+		sw.rstats = make(map[string]CephRstats)
+	}
+	sw.rstats[root] = rs
+}
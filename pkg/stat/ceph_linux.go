@@ -0,0 +1,26 @@
+package stat
+
+import (
+	"strconv"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// readCephRstats returns the recursive statistics of the CephFS directory
+// at path, or false if it is not one or they cannot be read.
+func readCephRstats(path string) (CephRstats, bool) {
+	var rs CephRstats
+	for name, dest := range map[string]*int64{cephRbytes: &rs.Bytes, cephRfiles: &rs.Files, cephRsubdirs: &rs.Subdirs} {
+		value, err := readXattrBuf(func(buf []byte) (int, error) { return unix.Getxattr(path, name, buf) })
+		if err != nil {
+			return CephRstats{}, false
+		}
+		n, err := strconv.ParseInt(strings.TrimRight(string(value), "\x00\n"), 10, 64)
+		if err != nil || n < 0 {
+			return CephRstats{}, false
+		}
+		*dest = n
+	}
+	return rs, true
+}
@@ -0,0 +1,8 @@
+//go:build !linux
+
+package stat
+
+// readCephRstats is not supported on this platform.
+func readCephRstats(path string) (CephRstats, bool) {
+	return CephRstats{}, false
+}
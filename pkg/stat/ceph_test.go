@@ -0,0 +1,69 @@
+package stat
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRstatsUsable(t *testing.T) {
+	sizeMin := int64(1)
+	tests := []struct {
+		name  string
+		setup func(sw *StatsWalker)
+		want  bool
+	}{
+		{"totals only", func(sw *StatsWalker) {}, true},
+		{"empty filters", func(sw *StatsWalker) { sw.filters = &Filters{Types: map[string]bool{}} }, true},
+		{"disabled", func(sw *StatsWalker) { sw.SetCephFS(false) }, false},
+		{"filters", func(sw *StatsWalker) { sw.filters = &Filters{SizeMin: &sizeMin} }, false},
+		{"max depth", func(sw *StatsWalker) { sw.SetMaxDepth(2) }, false},
+		{"separate roots", func(sw *StatsWalker) { sw.SetSeparateRoots(true) }, false},
+		{"cross tabulation", func(sw *StatsWalker) { sw.SetCrossTab([]Dimension{DimUID}) }, false},
+		{"xattrs", func(sw *StatsWalker) { sw.SetXattrs(true) }, false},
+		{"project markers", func(sw *StatsWalker) { sw.SetProjectMarkers(DefaultProjectMarkers) }, false},
+		{"checkpoint", func(sw *StatsWalker) { sw.SetCheckpoint("cp.json", time.Minute) }, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sw := NewStatsWalker([]string{"/ceph"}, 2, nil)
+			sw.SetCephFS(true)
+			tt.setup(sw)
+			if got := sw.rstatsUsable(); got != tt.want {
+				t.Errorf("rstatsUsable() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddRstats(t *testing.T) {
+	sw := NewStatsWalker([]string{"/ceph/a", "/ceph/b"}, 2, nil)
+	sw.addRstats("/ceph/a", CephRstats{Bytes: 1000, Files: 10, Subdirs: 3})
+	sw.finish(sw.paths, time.Now())
+
+	sum := sw.results.Summary
+	if sum.TotalSize != 1000 || sum.Files != 10 || sum.Dirs != 3 || sum.TotalInodes != 13 {
+		t.Errorf("summary = %+v", sum)
+	}
+	if scan := sw.results.Scan; scan.Entries != 13 || scan.Files != 10 || len(scan.Rstats) != 1 || scan.Rstats[0] != "/ceph/a" {
+		t.Errorf("scan = %+v", scan)
+	}
+}
+
+func TestCephFSFallback(t *testing.T) {
+	// Not on CephFS: the root is walked
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "f"), []byte("data"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	sw := NewStatsWalker([]string{root}, 2, nil)
+	sw.SetCephFS(true)
+	results, err := sw.Walk()
+	if err != nil {
+		t.Fatalf("walk failed: %v", err)
+	}
+	if len(results.AllFileInfos) != 2 || results.Summary.FilesSize != 4 || results.Scan.Rstats != nil {
+		t.Errorf("got %d entries, %d bytes, rstats roots %v", len(results.AllFileInfos), results.Summary.FilesSize, results.Scan.Rstats)
+	}
+}
@@ -0,0 +1,312 @@
+package stat
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"time"
+)
+
+// CheckpointVersion is the current checkpoint file format version.
+const CheckpointVersion = 2
+
+// Checkpoint is the state of an interrupted walk: the entries aggregated
+// so far and the directories still to read. A walk resumed from it with
+// SetResume reports the same results as an uninterrupted one.
+//
+// The entries are kept in an entry log next to the checkpoint file, see
+// EntryLogPath, which a walk only appends the entries recorded since the
+// previous checkpoint to, so that each checkpoint costs time and IO in
+// proportion to the progress since the last one rather than to the size
+// of the walk. The checkpoint file holds the length of the log it covers,
+// and the aggregates that are not derived from the entries.
+type Checkpoint struct {
+	Version     int                   `json:"version"`
+	Time        time.Time             `json:"time"`                 // When the checkpoint was taken
+	Roots       []string              `json:"roots"`                // Root paths of the walk
+	Done        int                   `json:"done"`                 // Count of roots walked completely
+	Pending     []string              `json:"pending,omitempty"`    // Directories of Roots[Done] not read yet, relative to it
+	Entries     []FileInfo            `json:"-"`                    // Entries recorded so far, stored in the entry log
+	EntriesSize int64                 `json:"entriesSize"`          // Bytes of the entry log holding Entries
+	Held        []FileInfo            `json:"held,omitempty"`       // Directories held for the empty check
+	FanOut      FanOutStat            `json:"fanout"`               // Fan-out of the directories read so far
+	RootFanOut  map[string]FanOutStat `json:"rootFanout,omitempty"` // Fan-out by root (separate roots only)
+	Projects    map[string][]string   `json:"projects,omitempty"`   // Project directories found so far, see SetProjectMarkers
+	Seen        int64                 `json:"seen"`                 // Entries seen, including filtered ones
+	Files       int64                 `json:"files"`                // Non-directory entries seen
+	Errors      int64                 `json:"errors"`               // Read errors seen
+}
+
+// EntryLogPath returns the path of the entry log of the checkpoint file
+// filename, which holds the entries as JSON lines.
+func EntryLogPath(filename string) string {
+	return filename + ".entries"
+}
+
+// entryLog is the entry log a walk appends to at each checkpoint.
+type entryLog struct {
+	file *os.File
+	size int64 // Bytes written, as covered by the last checkpoint
+}
+
+// SetCheckpoint makes Walk save a checkpoint to path every interval and
+// after each root, so an interrupted walk can be resumed with SetResume.
+// The file is removed once the walk completes. An empty path disables
+// checkpoints.
+func (sw *StatsWalker) SetCheckpoint(path string, interval time.Duration) {
+	sw.checkpointPath, sw.checkpointEvery = path, interval
+}
+
+// SetResume makes Walk continue the walk saved in cp instead of starting
+// over. The walker must be configured as for the interrupted walk; Walk
+// fails if the roots differ.
+func (sw *StatsWalker) SetResume(cp *Checkpoint) {
+	sw.resumeFrom = cp
+}
+
+// restore loads the state saved in cp and returns the index of the root to
+// continue with and its directories not read yet.
+func (sw *StatsWalker) restore(cp *Checkpoint) (int, []string, error) {
+	if !slices.Equal(cp.Roots, sw.paths) {
+		return 0, nil, fmt.Errorf("checkpoint roots %v differ from %v", cp.Roots, sw.paths)
+	}
+	for _, fi := range cp.Entries {
+		sw.record(fi, false)
+	}
+	// The restored entries are in the entry log already
+	for _, shard := range sw.shards {
+		shard.saved = len(shard.results.AllFileInfos)
+	}
+	for _, fi := range cp.Held {
+		sw.pending.put(fi)
+	}
+	sw.shards[0].results.FanOut.merge(&cp.FanOut)
+	sw.shards[0].results.mergeProjects(&Results{ProjectDirs: cp.Projects})
+	for root, s := range cp.RootFanOut {
+		sw.rootTally(root).fanOut.merge(&s)
+	}
+	sw.entries.Store(cp.Seen)
+	sw.files.Store(cp.Files)
+	sw.errors.Store(cp.Errors)
+	return cp.Done, cp.Pending, nil
+}
+
+// saveCheckpoint saves the state of the walk while the workers are paused:
+// done roots walked completely and the directories of the next one not
+// read yet. Only the entries recorded since the previous checkpoint are
+// appended to the entry log.
+func (sw *StatsWalker) saveCheckpoint(done int, pending []string) error {
+	cp := &Checkpoint{
+		Version: CheckpointVersion,
+		Time:    time.Now(),
+		Roots:   sw.paths,
+		Done:    done,
+		Pending: pending,
+		Held:    sw.pending.held(),
+		Seen:    sw.entries.Load(),
+		Files:   sw.files.Load(),
+		Errors:  sw.errors.Load(),
+	}
+	if sw.separateRoots {
+		cp.RootFanOut = make(map[string]FanOutStat)
+		for root, t := range sw.roots {
+			var fanOut FanOutStat
+			fanOut.merge(&t.fanOut)
+			cp.RootFanOut[root] = fanOut
+		}
+	}
+	var fresh []FileInfo
+	recorded := make([]int, len(sw.shards))
+	for i, shard := range sw.shards {
+		shard.mu.Lock()
+		recorded[i] = len(shard.results.AllFileInfos)
+		fresh = append(fresh, shard.results.AllFileInfos[shard.saved:]...)
+		cp.FanOut.merge(shard.results.FanOut)
+		if len(shard.results.ProjectDirs) > 0 {
+			if cp.Projects == nil {
+				cp.Projects = make(map[string][]string)
+			}
+			for dir, markers := range shard.results.ProjectDirs {
+				cp.Projects[dir] = append(cp.Projects[dir], markers...)
+			}
+		}
+		for root, s := range shard.fanOut {
+			fanOut := cp.RootFanOut[root]
+			fanOut.merge(s)
+			cp.RootFanOut[root] = fanOut
+		}
+		shard.mu.Unlock()
+	}
+
+	// The log is appended to before the checkpoint covering it is replaced,
+	// so an interruption in between leaves a tail the checkpoint ignores
+	log, err := sw.openEntryLog()
+	if err != nil {
+		return err
+	}
+	n, err := writeEntries(log.file, fresh)
+	if err != nil {
+		return err
+	}
+	log.size += n
+	cp.EntriesSize = log.size
+	if err := cp.saveState(sw.checkpointPath); err != nil {
+		return err
+	}
+	for i, shard := range sw.shards {
+		shard.saved = recorded[i]
+	}
+	return nil
+}
+
+// openEntryLog returns the entry log of the walk, opening it on the first
+// checkpoint: a new log, or that of the resumed checkpoint cut to the part
+// it covers.
+func (sw *StatsWalker) openEntryLog() (*entryLog, error) {
+	if sw.entryLog != nil {
+		return sw.entryLog, nil
+	}
+	path := EntryLogPath(sw.checkpointPath)
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	var size int64
+	if sw.resumeFrom != nil {
+		flags, size = os.O_WRONLY|os.O_CREATE, sw.resumeFrom.EntriesSize
+	}
+	f, err := os.OpenFile(path, flags, 0644)
+	if err != nil {
+		return nil, err
+	}
+	if err := f.Truncate(size); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if _, err := f.Seek(size, io.SeekStart); err != nil {
+		f.Close()
+		return nil, err
+	}
+	sw.entryLog = &entryLog{file: f, size: size}
+	return sw.entryLog, nil
+}
+
+// removeCheckpoint removes the checkpoint and its entry log once the walk
+// is complete.
+func (sw *StatsWalker) removeCheckpoint() error {
+	if sw.entryLog != nil {
+		sw.entryLog.file.Close()
+		sw.entryLog = nil
+	}
+	if err := os.Remove(EntryLogPath(sw.checkpointPath)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return os.Remove(sw.checkpointPath)
+}
+
+// writeEntries writes entries to w as JSON lines and returns the bytes
+// written.
+func writeEntries(w io.Writer, entries []FileInfo) (int64, error) {
+	bw := bufio.NewWriter(w)
+	var n int64
+	for i := range entries {
+		b, err := json.Marshal(&entries[i])
+		if err != nil {
+			return n, err
+		}
+		b = append(b, '\n')
+		if _, err := bw.Write(b); err != nil {
+			return n, err
+		}
+		n += int64(len(b))
+	}
+	return n, bw.Flush()
+}
+
+// Save writes the checkpoint to a file as JSON, and its entries to the
+// entry log next to it. Both files are replaced atomically, so an
+// interruption while saving keeps the previous ones.
+func (cp *Checkpoint) Save(filename string) error {
+	logPath := EntryLogPath(filename)
+	f, err := os.Create(logPath + ".tmp")
+	if err != nil {
+		return err
+	}
+	n, err := writeEntries(f, cp.Entries)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(logPath+".tmp", logPath); err != nil {
+		return err
+	}
+	cp.EntriesSize = n
+	return cp.saveState(filename)
+}
+
+// saveState writes the checkpoint file without the entries, replacing it
+// atomically.
+func (cp *Checkpoint) saveState(filename string) error {
+	b, err := json.Marshal(cp)
+	if err != nil {
+		return err
+	}
+	tmp := filename + ".tmp"
+	if err := os.WriteFile(tmp, b, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filename)
+}
+
+// LoadCheckpoint reads a checkpoint previously written by Save or a walk,
+// with the entries of its entry log. Entries appended to the log after the
+// checkpoint was written are ignored.
+func LoadCheckpoint(filename string) (*Checkpoint, error) {
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var cp Checkpoint
+	if err := json.Unmarshal(b, &cp); err != nil {
+		return nil, fmt.Errorf("parse checkpoint %s: %w", filename, err)
+	}
+	if cp.Version != CheckpointVersion {
+		return nil, fmt.Errorf("checkpoint %s: unsupported version %d", filename, cp.Version)
+	}
+	if cp.EntriesSize == 0 {
+		return &cp, nil
+	}
+
+	logPath := EntryLogPath(filename)
+	f, err := os.Open(logPath)
+	if err != nil {
+		return nil, fmt.Errorf("checkpoint %s: %w", filename, err)
+	}
+	defer f.Close()
+	r := bufio.NewReader(io.LimitReader(f, cp.EntriesSize))
+	var read int64
+	for {
+		line, err := r.ReadBytes('\n')
+		if err == io.EOF && len(line) == 0 {
+			break
+		} else if err == io.EOF {
+			return nil, fmt.Errorf("checkpoint %s: entry log %s is truncated", filename, logPath)
+		} else if err != nil {
+			return nil, fmt.Errorf("read checkpoint entries %s: %w", logPath, err)
+		}
+		var fi FileInfo
+		if err := json.Unmarshal(line, &fi); err != nil {
+			return nil, fmt.Errorf("parse checkpoint entries %s: %w", logPath, err)
+		}
+		cp.Entries = append(cp.Entries, fi)
+		read += int64(len(line))
+	}
+	if read != cp.EntriesSize {
+		return nil, fmt.Errorf("checkpoint %s: entry log %s is truncated", filename, logPath)
+	}
+	return &cp, nil
+}
@@ -0,0 +1,190 @@
+package stat
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckpointSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scan.ckpt")
+	cp := &Checkpoint{
+		Version: CheckpointVersion,
+		Time:    time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		Roots:   []string{"/data"},
+		Pending: []string{"a", "b/c"},
+		Entries: []FileInfo{{Root: "/data", Path: "a", IsDir: true, Mode: os.ModeDir | 0755}},
+		Seen:    3,
+	}
+	if err := cp.Save(path); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	got, err := LoadCheckpoint(path)
+	if err != nil {
+		t.Fatalf("LoadCheckpoint: %v", err)
+	}
+	if len(got.Pending) != 2 || got.Pending[1] != "b/c" || len(got.Entries) != 1 || got.Entries[0].Mode != cp.Entries[0].Mode || got.Seen != 3 {
+		t.Errorf("loaded %+v, want %+v", got, cp)
+	}
+
+	cp.Version = CheckpointVersion + 1
+	if err := cp.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadCheckpoint(path); err == nil {
+		t.Error("LoadCheckpoint should reject an unknown version")
+	}
+	if _, err := LoadCheckpoint(filepath.Join(t.TempDir(), "missing")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("LoadCheckpoint of a missing file = %v, want os.ErrNotExist", err)
+	}
+}
+
+// TestWalkResumeMatchesWalk resumes from a checkpoint holding the entries
+// outside the second half of the directories, as an interrupted walk would
+// have left it, and compares the results with an uninterrupted walk.
+func TestWalkResumeMatchesWalk(t *testing.T) {
+	root := setupStatsTree(t, 10, 5)
+	want, err := NewStatsWalker([]string{root}, 2, &Filters{}).Walk()
+	if err != nil {
+		t.Fatalf("walk failed: %v", err)
+	}
+
+	cp := &Checkpoint{Version: CheckpointVersion, Roots: []string{root}}
+	for d := 5; d < 10; d++ {
+		cp.Pending = append(cp.Pending, fmt.Sprintf("dir%03d", d))
+	}
+	for _, fi := range want.AllFileInfos {
+		if dir, _, ok := strings.Cut(fi.Path, "/"); ok && dir >= "dir005" {
+			continue
+		}
+		cp.Entries = append(cp.Entries, fi)
+	}
+	cp.Seen = int64(len(cp.Entries))
+
+	path := filepath.Join(t.TempDir(), "scan.ckpt")
+	sw := NewStatsWalker([]string{root}, 2, &Filters{})
+	sw.SetCheckpoint(path, time.Hour)
+	sw.SetResume(cp)
+	got, err := sw.Walk()
+	if err != nil {
+		t.Fatalf("resumed walk failed: %v", err)
+	}
+	if *got.Summary != *want.Summary {
+		t.Errorf("summary = %+v, want %+v", *got.Summary, *want.Summary)
+	}
+	if got.Scan.Entries != want.Scan.Entries {
+		t.Errorf("entries = %d, want %d", got.Scan.Entries, want.Scan.Entries)
+	}
+	for _, name := range []string{path, EntryLogPath(path)} {
+		if _, err := os.Stat(name); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("%s should be removed after the walk, stat: %v", name, err)
+		}
+	}
+
+	cp.Roots = []string{"/elsewhere"}
+	sw = NewStatsWalker([]string{root}, 2, &Filters{})
+	sw.SetResume(cp)
+	if _, err := sw.Walk(); err == nil {
+		t.Error("resuming a checkpoint of other roots should fail")
+	}
+}
+
+func TestWalkCheckpointAfterRoot(t *testing.T) {
+	first, second := setupStatsTree(t, 2, 2), setupStatsTree(t, 2, 2)
+	path := filepath.Join(t.TempDir(), "scan.ckpt")
+
+	sw := NewStatsWalker([]string{first, second}, 2, &Filters{})
+	sw.SetCheckpoint(path, time.Hour)
+	if err := sw.walkPath(0, first, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := sw.saveCheckpoint(1, nil); err != nil {
+		t.Fatalf("saveCheckpoint: %v", err)
+	}
+	cp, err := LoadCheckpoint(path)
+	if err != nil {
+		t.Fatalf("LoadCheckpoint: %v", err)
+	}
+	if cp.Done != 1 || len(cp.Pending) != 0 || len(cp.Entries) != 7 || cp.FanOut.Dirs != 3 {
+		t.Errorf("checkpoint has done %d, %d pending, %d entries and %d dirs read, want 1, 0, 7 and 3",
+			cp.Done, len(cp.Pending), len(cp.Entries), cp.FanOut.Dirs)
+	}
+
+	resumed := NewStatsWalker([]string{first, second}, 2, &Filters{})
+	resumed.SetResume(cp)
+	got, err := resumed.Walk()
+	if err != nil {
+		t.Fatalf("resumed walk failed: %v", err)
+	}
+	if got.Summary.Files != 8 || got.Summary.Dirs != 6 || got.FanOut.Dirs != 6 {
+		t.Errorf("got %d files, %d dirs and %d dirs read, want 8, 6 and 6", got.Summary.Files, got.Summary.Dirs, got.FanOut.Dirs)
+	}
+}
+
+func TestWalkCheckpointAppendsEntries(t *testing.T) {
+	first, second := setupStatsTree(t, 2, 2), setupStatsTree(t, 2, 2)
+	path := filepath.Join(t.TempDir(), "scan.ckpt")
+	logSize := func() int64 {
+		t.Helper()
+		info, err := os.Stat(EntryLogPath(path))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return info.Size()
+	}
+
+	sw := NewStatsWalker([]string{first, second}, 2, &Filters{})
+	sw.SetCheckpoint(path, time.Hour)
+	if err := sw.walkPath(0, first, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := sw.saveCheckpoint(1, nil); err != nil {
+		t.Fatalf("saveCheckpoint: %v", err)
+	}
+	size := logSize()
+	if err := sw.saveCheckpoint(1, nil); err != nil {
+		t.Fatalf("saveCheckpoint: %v", err)
+	}
+	if got := logSize(); got != size {
+		t.Errorf("entry log grew from %d to %d bytes without new entries", size, got)
+	}
+
+	// A resumed walk appends to the part of the log its checkpoint covers
+	cp, err := LoadCheckpoint(path)
+	if err != nil {
+		t.Fatalf("LoadCheckpoint: %v", err)
+	}
+	f, err := os.OpenFile(EntryLogPath(path), os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"partial`)
+	f.Close()
+
+	resumed := NewStatsWalker([]string{first, second}, 2, &Filters{})
+	resumed.SetCheckpoint(path, time.Hour)
+	resumed.SetResume(cp)
+	if _, _, err := resumed.restore(cp); err != nil {
+		t.Fatal(err)
+	}
+	if err := resumed.walkPath(1, second, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := resumed.saveCheckpoint(2, nil); err != nil {
+		t.Fatalf("saveCheckpoint: %v", err)
+	}
+	if got := logSize(); got <= size || got >= 3*size {
+		t.Errorf("entry log has %d bytes after the second root, want the %d of the first and the new entries only", got, size)
+	}
+	cp, err = LoadCheckpoint(path)
+	if err != nil {
+		t.Fatalf("LoadCheckpoint: %v", err)
+	}
+	if cp.Done != 2 || len(cp.Entries) != 14 {
+		t.Errorf("checkpoint has done %d and %d entries, want 2 and 14", cp.Done, len(cp.Entries))
+	}
+}
@@ -0,0 +1,67 @@
+package stat
+
+import "time"
+
+// ChurnStat holds change-rate metrics between two consecutive scans.
+// Churn often predicts capacity problems better than absolute size.
+type ChurnStat struct {
+	Interval time.Duration // Time between the two scans
+
+	Added    int64 // Entries present only in the newer scan
+	Deleted  int64 // Entries present only in the older scan
+	Modified int64 // Entries whose size or mtime changed
+
+	AddedBytes    int64 // Size of added entries
+	DeletedBytes  int64 // Size of deleted entries
+	ModifiedBytes int64 // Current size of modified entries
+
+	PrevBytes int64 // Total size of the older scan
+	CurBytes  int64 // Total size of the newer scan
+}
+
+// TurnoverBytes returns the total bytes turned over between the scans:
+// bytes written as new or modified entries plus bytes deleted.
+func (c *ChurnStat) TurnoverBytes() int64 {
+	return c.AddedBytes + c.DeletedBytes + c.ModifiedBytes
+}
+
+// GrowthBytes returns the change of the total size between the scans,
+// negative if it shrank.
+func (c *ChurnStat) GrowthBytes() int64 {
+	return c.CurBytes - c.PrevBytes
+}
+
+// ComputeChurn compares two snapshots and returns the churn from prev to cur.
+// Both snapshots must have their entries sorted by path, as produced by
+// NewSnapshot.
+func ComputeChurn(prev, cur *Snapshot) *ChurnStat {
+	c := &ChurnStat{Interval: cur.Time.Sub(prev.Time)}
+
+	i, j := 0, 0
+	for i < len(prev.Entries) || j < len(cur.Entries) {
+		switch {
+		case j >= len(cur.Entries) || (i < len(prev.Entries) && prev.Entries[i].Path < cur.Entries[j].Path):
+			c.Deleted++
+			c.DeletedBytes += prev.Entries[i].Size
+			c.PrevBytes += prev.Entries[i].Size
+			i++
+		case i >= len(prev.Entries) || cur.Entries[j].Path < prev.Entries[i].Path:
+			c.Added++
+			c.AddedBytes += cur.Entries[j].Size
+			c.CurBytes += cur.Entries[j].Size
+			j++
+		default:
+			p, n := prev.Entries[i], cur.Entries[j]
+			c.PrevBytes += p.Size
+			c.CurBytes += n.Size
+			if p.Size != n.Size || !p.ModTime.Equal(n.ModTime) {
+				c.Modified++
+				c.ModifiedBytes += n.Size
+			}
+			i++
+			j++
+		}
+	}
+
+	return c
+}
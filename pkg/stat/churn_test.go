@@ -0,0 +1,68 @@
+package stat
+
+import (
+	"testing"
+	"time"
+)
+
+func TestComputeChurn(t *testing.T) {
+	t0 := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	t1 := t0.Add(24 * time.Hour)
+
+	prev := &Snapshot{
+		Time: t0,
+		Entries: []SnapshotEntry{
+			{Path: "/data/a", Size: 100, ModTime: t0},
+			{Path: "/data/b", Size: 200, ModTime: t0},
+			{Path: "/data/c", Size: 300, ModTime: t0},
+			{Path: "/data/d", Size: 400, ModTime: t0},
+		},
+	}
+	cur := &Snapshot{
+		Time: t1,
+		Entries: []SnapshotEntry{
+			{Path: "/data/a", Size: 100, ModTime: t0}, // unchanged
+			{Path: "/data/b", Size: 250, ModTime: t1}, // resized
+			{Path: "/data/c", Size: 300, ModTime: t1}, // touched
+			{Path: "/data/e", Size: 50, ModTime: t1},  // added
+			{Path: "/data/f", Size: 60, ModTime: t1},  // added
+		},
+	}
+
+	got := ComputeChurn(prev, cur)
+	want := ChurnStat{
+		Interval:      24 * time.Hour,
+		Added:         2,
+		Deleted:       1,
+		Modified:      2,
+		AddedBytes:    110,
+		DeletedBytes:  400,
+		ModifiedBytes: 550,
+		PrevBytes:     1000,
+		CurBytes:      760,
+	}
+	if *got != want {
+		t.Errorf("got %+v, want %+v", *got, want)
+	}
+	if got.TurnoverBytes() != 1060 {
+		t.Errorf("turnover: got %d, want 1060", got.TurnoverBytes())
+	}
+	if got.GrowthBytes() != -240 {
+		t.Errorf("growth: got %d, want -240", got.GrowthBytes())
+	}
+}
+
+func TestComputeChurnEmpty(t *testing.T) {
+	t0 := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	cur := &Snapshot{
+		Time:    t0,
+		Entries: []SnapshotEntry{{Path: "/x", Size: 10, ModTime: t0}},
+	}
+
+	if got := ComputeChurn(&Snapshot{Time: t0}, cur); got.Added != 1 || got.AddedBytes != 10 {
+		t.Errorf("from empty: got %+v", *got)
+	}
+	if got := ComputeChurn(cur, &Snapshot{Time: t0}); got.Deleted != 1 || got.DeletedBytes != 10 {
+		t.Errorf("to empty: got %+v", *got)
+	}
+}
@@ -0,0 +1,67 @@
+package stat
+
+import (
+	"sort"
+	"strings"
+)
+
+// DepthStat holds statistics of the entries at one depth below the walk
+// roots, to show the shape of a tree before moving it.
+type DepthStat struct {
+	Depth       int   // Path components below the root (the root is 0)
+	TotalSize   int64 // Total size of entries at this depth
+	TotalInodes int64 // Total count of entries at this depth
+	Files       int64 // Count of regular files
+	Dirs        int64 // Count of directories
+	Symlinks    int64 // Count of symbolic links
+	Others      int64 // Count of other inode types
+	FilesSize   int64 // Total size of regular files
+}
+
+// Depth returns the number of path components of the entry below its
+// root: 0 for the root itself, 1 for its direct children.
+func (fi *FileInfo) Depth() int {
+	if fi.Path == "" {
+		return 0
+	}
+	return strings.Count(fi.Path, "/") + 1
+}
+
+// add records an entry of the given type ("file", "dir", "symlink" or
+// "other").
+func (s *DepthStat) add(fileType string, size int64) {
+	s.TotalInodes++
+	s.TotalSize += size
+	switch fileType {
+	case "file":
+		s.Files++
+		s.FilesSize += size
+	case "dir":
+		s.Dirs++
+	case "symlink":
+		s.Symlinks++
+	default:
+		s.Others++
+	}
+}
+
+// merge folds the statistics of other into s.
+func (s *DepthStat) merge(other *DepthStat) {
+	s.TotalSize += other.TotalSize
+	s.TotalInodes += other.TotalInodes
+	s.Files += other.Files
+	s.Dirs += other.Dirs
+	s.Symlinks += other.Symlinks
+	s.Others += other.Others
+	s.FilesSize += other.FilesSize
+}
+
+// SortedDepths returns the per-depth statistics from the root down.
+func (r *Results) SortedDepths() []*DepthStat {
+	out := make([]*DepthStat, 0, len(r.ByDepth))
+	for _, s := range r.ByDepth {
+		out = append(out, s)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Depth < out[j].Depth })
+	return out
+}
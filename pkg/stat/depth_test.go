@@ -0,0 +1,65 @@
+package stat
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileInfoDepth(t *testing.T) {
+	tests := []struct {
+		path string
+		want int
+	}{
+		{"", 0},
+		{"a", 1},
+		{"a/b", 2},
+		{"a/b/c.txt", 3},
+	}
+	for _, tt := range tests {
+		fi := FileInfo{Root: "/r", Path: tt.path}
+		if got := fi.Depth(); got != tt.want {
+			t.Errorf("Depth(%q) = %d, want %d", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestDepthWalk(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "a", "b"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"top.txt", "a/one.txt", "a/b/two.txt", "a/b/three.txt"} {
+		if err := os.WriteFile(filepath.Join(root, name), []byte("12345"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	res, err := NewStatsWalker([]string{root}, 2, nil).Walk()
+	if err != nil {
+		t.Fatalf("walk failed: %v", err)
+	}
+
+	depths := res.SortedDepths()
+	want := []DepthStat{
+		{Depth: 0, TotalInodes: 1, Dirs: 1},
+		{Depth: 1, TotalInodes: 2, Files: 1, Dirs: 1, FilesSize: 5},
+		{Depth: 2, TotalInodes: 2, Files: 1, Dirs: 1, FilesSize: 5},
+		{Depth: 3, TotalInodes: 2, Files: 2, FilesSize: 10},
+	}
+	if len(depths) != len(want) {
+		t.Fatalf("got %d depths, want %d", len(depths), len(want))
+	}
+	for i, w := range want {
+		got := *depths[i]
+		// Directory sizes depend on the file system
+		got.TotalSize = got.FilesSize
+		w.TotalSize = w.FilesSize
+		if got != w {
+			t.Errorf("depth %d = %+v, want %+v", w.Depth, got, w)
+		}
+	}
+	if res.Summary.MaxDepth != 3 {
+		t.Errorf("max depth = %d, want 3", res.Summary.MaxDepth)
+	}
+}
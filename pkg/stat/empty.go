@@ -0,0 +1,109 @@
+package stat
+
+import (
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// EmptyReport lists the empty entries of a walk, sorted by path, for
+// cleanup workflows.
+type EmptyReport struct {
+	Files []FileInfo // Regular files of size zero
+	Dirs  []FileInfo // Directories without entries (requires SetEmptyCheck)
+}
+
+// IsEmpty reports whether the entry is a zero-byte regular file or a
+// directory known to have no entries.
+func (fi *FileInfo) IsEmpty() bool {
+	return fi.Mode.IsRegular() && fi.Size == 0 || fi.IsDir && fi.EmptyDir
+}
+
+// Empty builds the report of empty files and directories from the
+// collected entries.
+func (r *Results) Empty() *EmptyReport {
+	report := &EmptyReport{}
+	for _, fi := range r.AllFileInfos {
+		switch {
+		case fi.IsDir && fi.EmptyDir:
+			report.Dirs = append(report.Dirs, fi)
+		case fi.IsEmpty():
+			report.Files = append(report.Files, fi)
+		}
+	}
+
+	for _, section := range [][]FileInfo{report.Files, report.Dirs} {
+		sort.Slice(section, func(i, j int) bool {
+			return section[i].FullPath() < section[j].FullPath()
+		})
+	}
+	return report
+}
+
+// SetEmptyCheck makes the walk find out which directories have no
+// entries, for FileInfo.EmptyDir, the Empty filter and Results.Empty.
+// Directories are then recorded once they have been read rather than when
+// they are found, which holds the queued directories in memory.
+func (sw *StatsWalker) SetEmptyCheck(check bool) {
+	sw.emptyCheck = check
+}
+
+// pendingDirs holds the directories found but not read yet, by full path,
+// while checking for empty directories.
+type pendingDirs struct {
+	mu   sync.Mutex
+	dirs map[string]FileInfo
+}
+
+// put holds fi until its directory has been read.
+func (p *pendingDirs) put(fi FileInfo) {
+	p.mu.Lock()
+	if p.dirs == nil {
+		p.dirs = make(map[string]FileInfo)
+	}
+	p.dirs[fi.FullPath()] = fi
+	p.mu.Unlock()
+}
+
+// take removes and returns the held directory at fullPath.
+func (p *pendingDirs) take(fullPath string) (FileInfo, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	fi, ok := p.dirs[fullPath]
+	delete(p.dirs, fullPath)
+	return fi, ok
+}
+
+// drain removes and returns all held directories, such as those left
+// unread by a failed walk.
+func (p *pendingDirs) drain() []FileInfo {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	out := make([]FileInfo, 0, len(p.dirs))
+	for _, fi := range p.dirs {
+		out = append(out, fi)
+	}
+	p.dirs = nil
+	return out
+}
+
+// held returns the held directories without removing them.
+func (p *pendingDirs) held() []FileInfo {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	out := make([]FileInfo, 0, len(p.dirs))
+	for _, fi := range p.dirs {
+		out = append(out, fi)
+	}
+	return out
+}
+
+// snapshotParents returns the paths of the snapshot entries that contain
+// other entries, so that empty directories can be told apart offline.
+func snapshotParents(s *Snapshot) map[string]bool {
+	parents := make(map[string]bool)
+	for _, entry := range s.Entries {
+		parents[filepath.Dir(entry.Path)] = true
+	}
+	return parents
+}
@@ -0,0 +1,78 @@
+package stat
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEmptyCheckWalk(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{"empty", "full", "full/nested-empty"} {
+		if err := os.Mkdir(filepath.Join(root, dir), 0755); err != nil {
+			t.Fatalf("create dir: %v", err)
+		}
+	}
+	for name, data := range map[string]string{"zero": "", "data": "data", "full/zero": ""} {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(data), 0644); err != nil {
+			t.Fatalf("create file: %v", err)
+		}
+	}
+
+	sw := NewStatsWalker([]string{root}, 2, &Filters{})
+	sw.SetEmptyCheck(true)
+	res, err := sw.Walk()
+	if err != nil {
+		t.Fatalf("walk failed: %v", err)
+	}
+	if res.Summary.Dirs != 4 {
+		t.Errorf("got %d dirs, want 4 including the root", res.Summary.Dirs)
+	}
+
+	report := res.Empty()
+	var files, dirs []string
+	for _, fi := range report.Files {
+		files = append(files, fi.Path)
+	}
+	for _, fi := range report.Dirs {
+		dirs = append(dirs, fi.Path)
+	}
+	if len(files) != 2 || files[0] != "full/zero" || files[1] != "zero" {
+		t.Errorf("empty files = %v, want [full/zero zero]", files)
+	}
+	if len(dirs) != 2 || dirs[0] != "empty" || dirs[1] != "full/nested-empty" {
+		t.Errorf("empty dirs = %v, want [empty full/nested-empty]", dirs)
+	}
+
+	// The filter keeps only the empty entries
+	sw = NewStatsWalker([]string{root}, 2, &Filters{Empty: true})
+	sw.SetEmptyCheck(true)
+	res, err = sw.Walk()
+	if err != nil {
+		t.Fatalf("walk failed: %v", err)
+	}
+	if res.Summary.Files != 2 || res.Summary.Dirs != 2 {
+		t.Errorf("filtered: got %d files and %d dirs, want 2 and 2", res.Summary.Files, res.Summary.Dirs)
+	}
+}
+
+func TestEmptySnapshot(t *testing.T) {
+	s := &Snapshot{
+		Roots: []string{"/r"},
+		Entries: []SnapshotEntry{
+			{Path: "/r", Mode: os.ModeDir | 0755},
+			{Path: "/r/a", Mode: os.ModeDir | 0755},
+			{Path: "/r/a/f", Mode: 0644, Size: 1},
+			{Path: "/r/b", Mode: os.ModeDir | 0755},
+		},
+	}
+	sw := NewStatsWalker(nil, 1, &Filters{Empty: true})
+	sw.SetEmptyCheck(true)
+	res, err := sw.WalkSnapshot(s)
+	if err != nil {
+		t.Fatalf("walk snapshot failed: %v", err)
+	}
+	if len(res.AllFileInfos) != 1 || res.AllFileInfos[0].Path != "b" {
+		t.Errorf("got %v, want only b", res.AllFileInfos)
+	}
+}
@@ -0,0 +1,149 @@
+package stat
+
+import (
+	"math"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Thresholds for the random-name heuristic.
+const (
+	// randomNameMinLen is the minimum stem length considered. Short names
+	// cannot be told apart from abbreviations.
+	randomNameMinLen = 12
+
+	// randomNameMinEntropy is the minimum Shannon entropy in bits per
+	// character. UUIDs and hex digests score close to 4.
+	randomNameMinEntropy = 3.5
+
+	// randomNameTransitionRatio is the minimum number of letter/digit
+	// transitions per character (as 1/n). Random strings switch between
+	// classes often, while words and dates rarely do.
+	randomNameTransitionRatio = 6
+
+	// DefaultRandomNameMinEntries is the default minimum number of entries a
+	// directory needs before it can be flagged.
+	DefaultRandomNameMinEntries = 10
+
+	// DefaultRandomNameMinRatio is the default fraction of random-looking
+	// names above which a directory is flagged.
+	DefaultRandomNameMinRatio = 0.5
+)
+
+// DirEntropyStat holds random-name counts for the entries of one directory.
+type DirEntropyStat struct {
+	Path    string // Full path of the directory, including its root
+	Entries int64  // Count of entries seen in the directory
+	Random  int64  // Count of entries with random-looking names
+}
+
+// Ratio returns the fraction of entries with random-looking names.
+func (s *DirEntropyStat) Ratio() float64 {
+	if s.Entries == 0 {
+		return 0
+	}
+	return float64(s.Random) / float64(s.Entries)
+}
+
+// RandomNameDirs returns the directories dominated by random-looking names,
+// such as UUID soup left by crashed pipelines or possible ransomware
+// artifacts. A directory qualifies if it has at least minEntries entries and
+// at least minRatio of them look random. Results are sorted by random count,
+// largest first.
+func (r *Results) RandomNameDirs(minEntries int64, minRatio float64) []*DirEntropyStat {
+	var dirs []*DirEntropyStat
+	for _, s := range r.NameEntropy {
+		if s.Entries >= minEntries && s.Ratio() >= minRatio {
+			dirs = append(dirs, s)
+		}
+	}
+
+	sort.Slice(dirs, func(i, j int) bool {
+		if dirs[i].Random != dirs[j].Random {
+			return dirs[i].Random > dirs[j].Random
+		}
+		return dirs[i].Path < dirs[j].Path
+	})
+	return dirs
+}
+
+// addNameEntropy records the entry fi in its parent directory's tally,
+// keyed by the full path of the directory so that directories of the same
+// name under different roots are told apart.
+func (r *Results) addNameEntropy(fi *FileInfo) {
+	if fi.Path == "" {
+		return // the walk root has no name of its own
+	}
+
+	parent, name := "", fi.Path
+	if i := strings.LastIndexByte(fi.Path, '/'); i >= 0 {
+		parent, name = fi.Path[:i], fi.Path[i+1:]
+	}
+	dir := (&FileInfo{Root: fi.Root, Path: parent}).FullPath()
+
+	s, ok := r.NameEntropy[dir]
+	if !ok {
+		s = &DirEntropyStat{Path: dir}
+		r.NameEntropy[dir] = s
+	}
+	s.Entries++
+	if isRandomName(name) {
+		s.Random++
+	}
+}
+
+// isRandomName reports whether a file name looks randomly generated.
+// The extension is ignored; the remaining stem must be long, have high
+// per-character entropy, and switch between letters and digits often.
+func isRandomName(name string) bool {
+	stem := strings.TrimSuffix(name, path.Ext(name))
+	if len(stem) < randomNameMinLen {
+		return false
+	}
+
+	if nameEntropy(stem) < randomNameMinEntropy {
+		return false
+	}
+
+	transitions := 0
+	for i := 1; i < len(stem); i++ {
+		if isDigit(stem[i-1]) != isDigit(stem[i]) && isAlnum(stem[i-1]) && isAlnum(stem[i]) {
+			transitions++
+		}
+	}
+	return transitions*randomNameTransitionRatio >= len(stem)
+}
+
+// nameEntropy returns the Shannon entropy of s in bits per byte.
+func nameEntropy(s string) float64 {
+	if s == "" {
+		return 0
+	}
+
+	var counts [256]int
+	for i := 0; i < len(s); i++ {
+		counts[s[i]]++
+	}
+
+	n := float64(len(s))
+	entropy := 0.0
+	for _, c := range counts {
+		if c == 0 {
+			continue
+		}
+		p := float64(c) / n
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+// isDigit returns true if the byte is a digit (0-9).
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// isAlnum returns true if the byte is an ASCII letter or digit.
+func isAlnum(c byte) bool {
+	return isDigit(c) || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
@@ -0,0 +1,120 @@
+package stat
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIsRandomName(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"3f2a9c1e-7b4d-4e2a-9f1c-2d8e6a0b5c47", true},
+		{"3f2a9c1e-7b4d-4e2a-9f1c-2d8e6a0b5c47.tmp", true},
+		{"a8f5f167f44f4964e6c998dee827110c", true},
+		{"x7Kq9ZpL2mN4vB8w.locked", true},
+		{"report_2024_final.pdf", false},
+		{"IMG_20240512_123456.jpg", false},
+		{"thisisaverylongfilenamewithwords.txt", false},
+		{"implementation_notes.md", false},
+		{"kJ8dL2qPz0", false}, // too short
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isRandomName(tt.name); got != tt.want {
+				t.Errorf("isRandomName(%q) = %v, want %v", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNameEntropy(t *testing.T) {
+	if got := nameEntropy(""); got != 0 {
+		t.Errorf("empty string: got %v, want 0", got)
+	}
+	if got := nameEntropy("aaaa"); got != 0 {
+		t.Errorf("single symbol: got %v, want 0", got)
+	}
+	if got := nameEntropy("abcd"); got != 2 {
+		t.Errorf("four distinct symbols: got %v, want 2", got)
+	}
+}
+
+func TestRandomNameDirs(t *testing.T) {
+	root := t.TempDir()
+
+	uuidDir := filepath.Join(root, "spool")
+	plainDir := filepath.Join(root, "docs")
+	for _, dir := range []string{uuidDir, plainDir} {
+		if err := os.Mkdir(dir, 0755); err != nil {
+			t.Fatalf("create dir: %v", err)
+		}
+	}
+	for i := 0; i < 12; i++ {
+		sum := sha256.Sum256([]byte{byte(i)})
+		name := hex.EncodeToString(sum[:16]) + ".part"
+		if err := os.WriteFile(filepath.Join(uuidDir, name), nil, 0644); err != nil {
+			t.Fatalf("create file: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(plainDir, fmt.Sprintf("chapter_%02d.txt", i)), nil, 0644); err != nil {
+			t.Fatalf("create file: %v", err)
+		}
+	}
+
+	res, err := NewStatsWalker([]string{root}, 2, &Filters{}).Walk()
+	if err != nil {
+		t.Fatalf("walk failed: %v", err)
+	}
+
+	dirs := res.RandomNameDirs(DefaultRandomNameMinEntries, DefaultRandomNameMinRatio)
+	if len(dirs) != 1 || dirs[0].Path != uuidDir {
+		t.Fatalf("got %v, want only spool flagged", dirs)
+	}
+	// The heuristic is statistical, so a digest may occasionally slip through
+	if dirs[0].Entries != 12 || dirs[0].Random < 9 {
+		t.Errorf("spool: got %d/%d random, want at least 9/12", dirs[0].Random, dirs[0].Entries)
+	}
+	if s := res.NameEntropy[plainDir]; s == nil || s.Entries != 12 || s.Random != 0 {
+		t.Errorf("docs: got %+v, want 12 entries, 0 random", s)
+	}
+}
+
+func TestNameEntropyRoots(t *testing.T) {
+	var roots []string
+	for range 2 {
+		root := t.TempDir()
+		if err := os.Mkdir(filepath.Join(root, "x"), 0755); err != nil {
+			t.Fatalf("create dir: %v", err)
+		}
+		for i := range 10 {
+			if err := os.WriteFile(filepath.Join(root, "x", fmt.Sprintf("file_%02d", i)), nil, 0644); err != nil {
+				t.Fatalf("create file: %v", err)
+			}
+		}
+		roots = append(roots, root)
+	}
+
+	res, err := NewStatsWalker(roots, 2, &Filters{}).Walk()
+	if err != nil {
+		t.Fatalf("walk failed: %v", err)
+	}
+	for _, root := range roots {
+		dir := filepath.Join(root, "x")
+		if s := res.NameEntropy[dir]; s == nil || s.Path != dir || s.Entries != 10 {
+			t.Errorf("%s: got %+v, want 10 entries", dir, s)
+		}
+		if s := res.NameEntropy[root]; s == nil || s.Entries != 1 {
+			t.Errorf("%s: got %+v, want 1 entry", root, s)
+		}
+	}
+	if _, ok := res.NameEntropy["x"]; ok {
+		t.Error("directories should be keyed by their full path")
+	}
+}
@@ -0,0 +1,80 @@
+package stat
+
+import "time"
+
+// Extremes holds the extreme entries of a set of entries, such as when
+// the tree was last touched. Ties go to the lexically smallest path, so
+// results do not depend on the order entries were seen in.
+type Extremes struct {
+	OldestMtime time.Time // Earliest modification time (zero if none known)
+	OldestPath  string    // Full path of the entry with the earliest modification time
+	NewestMtime time.Time // Latest modification time (zero if none known)
+	NewestPath  string    // Full path of the entry with the latest modification time
+	LargestSize int64     // Size of the largest regular file
+	LargestPath string    // Full path of the largest regular file (empty if none)
+	MaxDepth    int       // Depth of the deepest entry below its root (root is 0)
+	DeepestPath string    // Full path of the deepest entry
+}
+
+// add updates the extremes with a single entry. Paths are only built for
+// entries that tie or win, since add runs for every entry.
+func (e *Extremes) add(fi *FileInfo) {
+	if t := fi.ModTime; !t.IsZero() {
+		if e.OldestPath == "" || !t.After(e.OldestMtime) {
+			e.takeOldest(t, fi.FullPath())
+		}
+		if e.NewestPath == "" || !t.Before(e.NewestMtime) {
+			e.takeNewest(t, fi.FullPath())
+		}
+	}
+	if fi.Mode.IsRegular() && !fi.IsDir && !fi.IsSymlink && (e.LargestPath == "" || fi.Size >= e.LargestSize) {
+		e.takeLargest(fi.Size, fi.FullPath())
+	}
+	if d := fi.Depth(); e.DeepestPath == "" || d >= e.MaxDepth {
+		e.takeDeepest(d, fi.FullPath())
+	}
+}
+
+// merge folds the extremes of other into e.
+func (e *Extremes) merge(other *Extremes) {
+	if other.OldestPath != "" {
+		e.takeOldest(other.OldestMtime, other.OldestPath)
+	}
+	if other.NewestPath != "" {
+		e.takeNewest(other.NewestMtime, other.NewestPath)
+	}
+	if other.LargestPath != "" {
+		e.takeLargest(other.LargestSize, other.LargestPath)
+	}
+	if other.DeepestPath != "" {
+		e.takeDeepest(other.MaxDepth, other.DeepestPath)
+	}
+}
+
+// takeOldest records t and p as the oldest modification if they are.
+func (e *Extremes) takeOldest(t time.Time, p string) {
+	if e.OldestPath == "" || t.Before(e.OldestMtime) || t.Equal(e.OldestMtime) && p < e.OldestPath {
+		e.OldestMtime, e.OldestPath = t, p
+	}
+}
+
+// takeNewest records t and p as the newest modification if they are.
+func (e *Extremes) takeNewest(t time.Time, p string) {
+	if e.NewestPath == "" || t.After(e.NewestMtime) || t.Equal(e.NewestMtime) && p < e.NewestPath {
+		e.NewestMtime, e.NewestPath = t, p
+	}
+}
+
+// takeLargest records size and p as the largest file if they are.
+func (e *Extremes) takeLargest(size int64, p string) {
+	if e.LargestPath == "" || size > e.LargestSize || size == e.LargestSize && p < e.LargestPath {
+		e.LargestSize, e.LargestPath = size, p
+	}
+}
+
+// takeDeepest records d and p as the deepest entry if they are.
+func (e *Extremes) takeDeepest(d int, p string) {
+	if e.DeepestPath == "" || d > e.MaxDepth || d == e.MaxDepth && p < e.DeepestPath {
+		e.MaxDepth, e.DeepestPath = d, p
+	}
+}
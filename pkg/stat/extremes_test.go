@@ -0,0 +1,53 @@
+package stat
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestExtremes(t *testing.T) {
+	t0 := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	entries := []FileInfo{
+		{Root: "/r", Path: "", IsDir: true, Mode: os.ModeDir, ModTime: t0.Add(time.Hour)},
+		{Root: "/r", Path: "a/b/c", Size: 10, ModTime: t0},
+		{Root: "/r", Path: "a/b/d", Size: 500, ModTime: t0.Add(48 * time.Hour)},
+		{Root: "/r", Path: "a/b/e", Size: 500, ModTime: t0.Add(48 * time.Hour)},
+		{Root: "/r", Path: "a/b/f/g", IsSymlink: true, Mode: os.ModeSymlink, Size: 9000},
+		{Root: "/r", Path: "a/b/f", IsDir: true, Mode: os.ModeDir, Size: 4096, ModTime: t0.Add(time.Minute)},
+	}
+
+	// Any split into partial results merges to the same extremes
+	for split := 0; split <= len(entries); split++ {
+		var left, right Extremes
+		for i := range entries {
+			if i < split {
+				left.add(&entries[len(entries)-1-i])
+			} else {
+				right.add(&entries[len(entries)-1-i])
+			}
+		}
+		right.merge(&left)
+
+		want := Extremes{
+			OldestMtime: t0, OldestPath: "/r/a/b/c",
+			NewestMtime: t0.Add(48 * time.Hour), NewestPath: "/r/a/b/d",
+			LargestSize: 500, LargestPath: "/r/a/b/d",
+			MaxDepth: 4, DeepestPath: "/r/a/b/f/g",
+		}
+		if right != want {
+			t.Errorf("split %d: got %+v, want %+v", split, right, want)
+		}
+	}
+}
+
+func TestExtremesEmpty(t *testing.T) {
+	var e Extremes
+	e.add(&FileInfo{Root: "/r", Path: "dir", IsDir: true, Mode: os.ModeDir})
+	if e.LargestPath != "" || e.NewestPath != "" {
+		t.Errorf("directory without mtime set largest or newest: %+v", e)
+	}
+	if e.DeepestPath != "/r/dir" || e.MaxDepth != 1 {
+		t.Errorf("deepest = %q at %d, want /r/dir at 1", e.DeepestPath, e.MaxDepth)
+	}
+}
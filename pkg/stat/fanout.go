@@ -0,0 +1,130 @@
+package stat
+
+import (
+	"path/filepath"
+	"sort"
+)
+
+// HugeDirEntries is the entry count from which a directory counts as huge
+// in FanOutStat. Directories this flat slow down lookups, backups and
+// scans on most file systems.
+const HugeDirEntries = 100000
+
+// FanOutBuckets labels the ranges of FanOutStat.Buckets.
+var FanOutBuckets = []string{"0", "1-9", "10-99", "100-999", "1k-9999", "10k-99999", "100k+"}
+
+// fanOutBounds are the lower bounds of the buckets after the first.
+var fanOutBounds = []int{1, 10, 100, 1000, 10000, HugeDirEntries}
+
+// FanOutStat holds the distribution of entries per directory. It covers
+// every directory that was read, regardless of filters, since flat
+// directories slow down the walk whatever is counted.
+type FanOutStat struct {
+	Dirs       int64    // Count of directories read
+	Entries    int64    // Total entries in those directories
+	MaxEntries int64    // Entries in the fullest directory
+	MaxPath    string   // Path of the fullest directory
+	Huge       int64    // Directories with at least HugeDirEntries entries
+	HugePaths  []string // Paths of huge directories, sorted
+	Buckets    []int64  // Directories per entry count range, see FanOutBuckets
+}
+
+// AvgFanOut returns the average number of entries per directory, or 0 if
+// no directories were read.
+func (s *FanOutStat) AvgFanOut() float64 {
+	if s.Dirs == 0 {
+		return 0
+	}
+	return float64(s.Entries) / float64(s.Dirs)
+}
+
+// add records a directory with n entries.
+func (s *FanOutStat) add(path string, n int) {
+	if s.Buckets == nil {
+		s.Buckets = make([]int64, len(FanOutBuckets))
+	}
+	s.Dirs++
+	s.Entries += int64(n)
+	if int64(n) > s.MaxEntries || s.MaxPath == "" || int64(n) == s.MaxEntries && path < s.MaxPath {
+		s.MaxEntries, s.MaxPath = int64(n), path
+	}
+	if n >= HugeDirEntries {
+		s.Huge++
+		s.HugePaths = append(s.HugePaths, path)
+	}
+	s.Buckets[sort.SearchInts(fanOutBounds, n+1)]++
+}
+
+// merge folds the statistics of other into s.
+func (s *FanOutStat) merge(other *FanOutStat) {
+	if other.Dirs == 0 {
+		return
+	}
+	if s.Buckets == nil {
+		s.Buckets = make([]int64, len(FanOutBuckets))
+	}
+	if other.MaxEntries > s.MaxEntries || s.MaxPath == "" || other.MaxEntries == s.MaxEntries && other.MaxPath < s.MaxPath {
+		s.MaxEntries, s.MaxPath = other.MaxEntries, other.MaxPath
+	}
+	s.Dirs += other.Dirs
+	s.Entries += other.Entries
+	s.Huge += other.Huge
+	s.HugePaths = append(s.HugePaths, other.HugePaths...)
+	for i, n := range other.Buckets {
+		s.Buckets[i] += n
+	}
+}
+
+// sort orders the huge directory paths.
+func (s *FanOutStat) sort() {
+	sort.Strings(s.HugePaths)
+}
+
+// snapshotFanOut derives the fan-out of the directories in a snapshot from
+// the parents of its entries.
+func snapshotFanOut(s *Snapshot) *FanOutStat {
+	children := snapshotChildren(s)
+	stat := &FanOutStat{}
+	for _, entry := range s.Entries {
+		if entry.Mode.IsDir() {
+			stat.add(entry.Path, children[entry.Path])
+		}
+	}
+	stat.sort()
+	return stat
+}
+
+// snapshotRootFanOuts derives the fan-out of the directories in a snapshot
+// below each of its roots.
+func snapshotRootFanOuts(s *Snapshot) map[string]*FanOutStat {
+	children := snapshotChildren(s)
+	stats := make(map[string]*FanOutStat)
+	for _, entry := range s.Entries {
+		if entry.Mode.IsDir() {
+			root, _ := s.splitPath(entry.Path)
+			rootFanOut(stats, root).add(entry.Path, children[entry.Path])
+		}
+	}
+	return stats
+}
+
+// snapshotChildren counts the entries of each directory in a snapshot.
+func snapshotChildren(s *Snapshot) map[string]int {
+	children := make(map[string]int)
+	for _, entry := range s.Entries {
+		if parent := filepath.Dir(entry.Path); parent != entry.Path {
+			children[parent]++
+		}
+	}
+	return children
+}
+
+// rootFanOut returns the fan-out of a root in stats, adding it if missing.
+func rootFanOut(stats map[string]*FanOutStat, root string) *FanOutStat {
+	s, ok := stats[root]
+	if !ok {
+		s = &FanOutStat{}
+		stats[root] = s
+	}
+	return s
+}
@@ -0,0 +1,63 @@
+package stat
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+func TestFanOutStat(t *testing.T) {
+	var a, b FanOutStat
+	a.add("/r", 0)
+	a.add("/r/a", 9)
+	a.add("/r/b", 10)
+	b.add("/r/c", 99999)
+	b.add("/r/huge", HugeDirEntries)
+	b.add("/r/huger", 2*HugeDirEntries)
+	a.merge(&b)
+	a.sort()
+
+	if a.Dirs != 6 || a.Entries != 9+10+99999+3*HugeDirEntries {
+		t.Errorf("got %d dirs and %d entries", a.Dirs, a.Entries)
+	}
+	if a.MaxEntries != 2*HugeDirEntries || a.MaxPath != "/r/huger" {
+		t.Errorf("max = %d at %q", a.MaxEntries, a.MaxPath)
+	}
+	if a.Huge != 2 || !slices.Equal(a.HugePaths, []string{"/r/huge", "/r/huger"}) {
+		t.Errorf("huge = %d %v", a.Huge, a.HugePaths)
+	}
+	if want := []int64{1, 1, 1, 0, 0, 1, 2}; !slices.Equal(a.Buckets, want) {
+		t.Errorf("buckets = %v, want %v", a.Buckets, want)
+	}
+	if avg := (&FanOutStat{}).AvgFanOut(); avg != 0 {
+		t.Errorf("empty average = %v, want 0", avg)
+	}
+}
+
+func TestFanOutWalk(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "flat"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 12; i++ {
+		if err := os.WriteFile(filepath.Join(root, "flat", strconv.Itoa(i)), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Filters do not hide directories from the fan-out
+	sw := NewStatsWalker([]string{root}, 2, &Filters{Types: map[string]bool{"symlink": true}})
+	res, err := sw.Walk()
+	if err != nil {
+		t.Fatalf("walk failed: %v", err)
+	}
+	fo := res.FanOut
+	if fo.Dirs != 2 || fo.Entries != 13 || fo.MaxEntries != 12 || fo.MaxPath != filepath.Join(root, "flat") {
+		t.Errorf("got %+v", fo)
+	}
+	if fo.AvgFanOut() != 6.5 {
+		t.Errorf("average = %v, want 6.5", fo.AvgFanOut())
+	}
+}
@@ -0,0 +1,461 @@
+package stat
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FilterExpr is a parsed filter expression such as
+//
+//	size > 1M && mtime < now-30d && name =~ '\.log$'
+//
+// Comparisons of an entry field with a value are combined with && (and),
+// || (or) and ! (not), grouped with parentheses. && binds tighter than ||.
+//
+// Numeric fields are size, links, uid and gid, compared with ==, !=, <, <=,
+// > and >=; sizes accept the units K, M, G and T (binary). Time fields are
+// mtime and btime, compared with the same operators against now, now-<d>,
+// now+<d> (d in s, m, h, d, w or y units) or a date like 2024-01-31;
+// entries with an unknown creation time never match btime comparisons.
+// String fields are name, path, ext, user, group and type, compared with
+// ==, != and the regular expression operators =~ and !~. Values may be
+// quoted with single or double quotes.
+type FilterExpr struct {
+	src  string
+	root exprNode
+}
+
+// ParseFilterExpr parses a filter expression. Relative times are resolved
+// against the current time once, when parsing.
+func ParseFilterExpr(s string) (*FilterExpr, error) {
+	p := &exprParser{src: s, now: time.Now()}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.pos < len(p.src) {
+		return nil, p.errorf("unexpected %q", p.src[p.pos:])
+	}
+	return &FilterExpr{src: s, root: root}, nil
+}
+
+// String returns the source of the expression.
+func (e *FilterExpr) String() string {
+	return e.src
+}
+
+// Matches reports whether fi satisfies the expression.
+func (e *FilterExpr) Matches(fi *FileInfo) bool {
+	return e.root.eval(fi)
+}
+
+// exprNode is a node of a parsed filter expression.
+type exprNode interface {
+	eval(fi *FileInfo) bool
+}
+
+type andNode struct{ left, right exprNode }
+
+func (n andNode) eval(fi *FileInfo) bool { return n.left.eval(fi) && n.right.eval(fi) }
+
+type orNode struct{ left, right exprNode }
+
+func (n orNode) eval(fi *FileInfo) bool { return n.left.eval(fi) || n.right.eval(fi) }
+
+type notNode struct{ expr exprNode }
+
+func (n notNode) eval(fi *FileInfo) bool { return !n.expr.eval(fi) }
+
+// fieldKind is the type of values a filter expression field holds.
+type fieldKind int
+
+const (
+	numberField fieldKind = iota
+	timeField
+	stringField
+)
+
+// exprFields are the fields filter expressions can compare, by kind.
+var exprFields = map[string]fieldKind{
+	"size":  numberField,
+	"links": numberField,
+	"uid":   numberField,
+	"gid":   numberField,
+	"mtime": timeField,
+	"btime": timeField,
+	"name":  stringField,
+	"path":  stringField,
+	"ext":   stringField,
+	"user":  stringField,
+	"group": stringField,
+	"type":  stringField,
+}
+
+// compareNode compares a field of the entry with a constant.
+type compareNode struct {
+	field string
+	op    string
+	num   int64
+	time  time.Time
+	str   string
+	re    *regexp.Regexp
+}
+
+func (n compareNode) eval(fi *FileInfo) bool {
+	switch exprFields[n.field] {
+	case numberField:
+		var v int64
+		switch n.field {
+		case "size":
+			v = fi.Size
+		case "links":
+			v = int64(fi.Links)
+		case "uid":
+			v = int64(fi.UID)
+		case "gid":
+			v = int64(fi.GID)
+		}
+		return compareOrdered(v, n.num, n.op)
+	case timeField:
+		t := fi.ModTime
+		if n.field == "btime" {
+			t = fi.BirthTime
+			if t.IsZero() {
+				return false
+			}
+		}
+		return compareOrdered(t.UnixNano(), n.time.UnixNano(), n.op)
+	default:
+		v := n.stringValue(fi)
+		switch n.op {
+		case "==":
+			return v == n.str
+		case "!=":
+			return v != n.str
+		case "=~":
+			return n.re.MatchString(v)
+		default: // "!~"
+			return !n.re.MatchString(v)
+		}
+	}
+}
+
+// stringValue returns the value of a string field of fi.
+func (n compareNode) stringValue(fi *FileInfo) string {
+	switch n.field {
+	case "name":
+		return fi.Path[strings.LastIndex(fi.Path, "/")+1:]
+	case "path":
+		return fi.Path
+	case "ext":
+		if fi.IsDir {
+			return ""
+		}
+		return strings.ToLower(strings.TrimPrefix(path.Ext(fi.Path), "."))
+	case "user":
+		return Username(fi.UID)
+	case "group":
+		return Groupname(fi.GID)
+	default: // "type"
+		return getFileType(fi)
+	}
+}
+
+// compareOrdered applies a comparison operator to two ordered values.
+func compareOrdered(a, b int64, op string) bool {
+	switch op {
+	case "==":
+		return a == b
+	case "!=":
+		return a != b
+	case "<":
+		return a < b
+	case "<=":
+		return a <= b
+	case ">":
+		return a > b
+	default: // ">="
+		return a >= b
+	}
+}
+
+// exprParser is a recursive descent parser for filter expressions.
+type exprParser struct {
+	src string
+	pos int
+	now time.Time
+}
+
+func (p *exprParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("at offset %d: %s", p.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *exprParser) skipSpace() {
+	for p.pos < len(p.src) && strings.ContainsRune(" \t\n\r", rune(p.src[p.pos])) {
+		p.pos++
+	}
+}
+
+// consume skips whitespace and the token tok if it comes next.
+func (p *exprParser) consume(tok string) bool {
+	p.skipSpace()
+	if strings.HasPrefix(p.src[p.pos:], tok) {
+		p.pos += len(tok)
+		return true
+	}
+	return false
+}
+
+// parseOr parses a disjunction: and ('||' and)*.
+func (p *exprParser) parseOr() (exprNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.consume("||") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orNode{left, right}
+	}
+	return left, nil
+}
+
+// parseAnd parses a conjunction: unary ('&&' unary)*.
+func (p *exprParser) parseAnd() (exprNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.consume("&&") {
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = andNode{left, right}
+	}
+	return left, nil
+}
+
+// parseUnary parses a negation, a parenthesized expression or a
+// comparison.
+func (p *exprParser) parseUnary() (exprNode, error) {
+	p.skipSpace()
+	if strings.HasPrefix(p.src[p.pos:], "!") && !strings.HasPrefix(p.src[p.pos:], "!=") {
+		p.pos++
+		expr, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{expr}, nil
+	}
+	if p.consume("(") {
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.consume(")") {
+			return nil, p.errorf("missing )")
+		}
+		return expr, nil
+	}
+	return p.parseComparison()
+}
+
+// comparisonOps are the comparison operators, longest first.
+var comparisonOps = []string{"==", "!=", "<=", ">=", "=~", "!~", "<", ">"}
+
+// parseComparison parses field op value.
+func (p *exprParser) parseComparison() (exprNode, error) {
+	p.skipSpace()
+	start := p.pos
+	for p.pos < len(p.src) && (isLetter(p.src[p.pos])) {
+		p.pos++
+	}
+	field := p.src[start:p.pos]
+	if field == "" {
+		if p.pos == len(p.src) {
+			return nil, p.errorf("unexpected end of expression")
+		}
+		return nil, p.errorf("expected a field name")
+	}
+	kind, ok := exprFields[field]
+	if !ok {
+		p.pos = start
+		return nil, p.errorf("unknown field %q", field)
+	}
+
+	p.skipSpace()
+	op := ""
+	for _, candidate := range comparisonOps {
+		if strings.HasPrefix(p.src[p.pos:], candidate) {
+			op = candidate
+			break
+		}
+	}
+	if op == "" {
+		return nil, p.errorf("expected a comparison operator after %s", field)
+	}
+	p.pos += len(op)
+
+	valuePos := p.pos
+	value, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+
+	n := compareNode{field: field, op: op}
+	switch kind {
+	case numberField:
+		if op == "=~" || op == "!~" {
+			return nil, p.errorf("%s cannot be matched with %s", field, op)
+		}
+		if field == "size" {
+			n.num, err = parseSizeValue(value)
+		} else {
+			n.num, err = strconv.ParseInt(value, 10, 64)
+		}
+	case timeField:
+		if op == "=~" || op == "!~" {
+			return nil, p.errorf("%s cannot be matched with %s", field, op)
+		}
+		n.time, err = p.parseTimeValue(value)
+	default:
+		switch op {
+		case "==", "!=":
+			n.str = value
+		case "=~", "!~":
+			n.re, err = regexp.Compile(value)
+		default:
+			return nil, p.errorf("%s cannot be compared with %s", field, op)
+		}
+	}
+	if err != nil {
+		p.pos = valuePos
+		return nil, p.errorf("invalid value for %s: %v", field, err)
+	}
+	return n, nil
+}
+
+// parseValue parses a quoted string or a bare word, which ends at
+// whitespace, a parenthesis or a logical operator.
+func (p *exprParser) parseValue() (string, error) {
+	p.skipSpace()
+	if p.pos == len(p.src) {
+		return "", p.errorf("missing value")
+	}
+	if q := p.src[p.pos]; q == '\'' || q == '"' {
+		end := strings.IndexByte(p.src[p.pos+1:], q)
+		if end < 0 {
+			return "", p.errorf("unterminated string")
+		}
+		value := p.src[p.pos+1 : p.pos+1+end]
+		p.pos += end + 2
+		return value, nil
+	}
+
+	start := p.pos
+	for p.pos < len(p.src) {
+		rest := p.src[p.pos:]
+		if strings.ContainsRune(" \t\n\r()", rune(rest[0])) || strings.HasPrefix(rest, "&&") || strings.HasPrefix(rest, "||") {
+			break
+		}
+		p.pos++
+	}
+	if p.pos == start {
+		return "", p.errorf("missing value")
+	}
+	return p.src[start:p.pos], nil
+}
+
+// parseTimeValue parses now, now-<duration>, now+<duration> or a date.
+func (p *exprParser) parseTimeValue(s string) (time.Time, error) {
+	if rest, ok := strings.CutPrefix(s, "now"); ok {
+		if rest == "" {
+			return p.now, nil
+		}
+		sign := rest[0]
+		if sign != '-' && sign != '+' {
+			return time.Time{}, fmt.Errorf("expected now-<duration> or now+<duration>: %s", s)
+		}
+		d, err := parseExprDuration(rest[1:])
+		if err != nil {
+			return time.Time{}, err
+		}
+		if sign == '-' {
+			d = -d
+		}
+		return p.now.Add(d), nil
+	}
+	for _, layout := range []string{"2006-01-02", time.RFC3339} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("expected now, now-<duration> or a date like 2006-01-02: %s", s)
+}
+
+// exprDurationUnits are the duration units of filter expressions, as
+// accepted by the --mtime-older flag.
+var exprDurationUnits = map[string]time.Duration{
+	"s": time.Second,
+	"m": time.Minute,
+	"h": time.Hour,
+	"d": 24 * time.Hour,
+	"w": 7 * 24 * time.Hour,
+	"y": 365 * 24 * time.Hour,
+}
+
+// parseExprDuration parses a duration like 30d or 12h.
+func parseExprDuration(s string) (time.Duration, error) {
+	i := 0
+	for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+		i++
+	}
+	num, err := strconv.ParseInt(s[:i], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration: %s", s)
+	}
+	unit, ok := exprDurationUnits[s[i:]]
+	if !ok {
+		return 0, fmt.Errorf("unknown duration unit: %q", s[i:])
+	}
+	return time.Duration(num) * unit, nil
+}
+
+// exprSizeUnits are the binary size units of filter expressions, as
+// accepted by the --size-min flag.
+var exprSizeUnits = map[string]int64{
+	"": 1, "B": 1,
+	"K": 1 << 10, "KB": 1 << 10,
+	"M": 1 << 20, "MB": 1 << 20,
+	"G": 1 << 30, "GB": 1 << 30,
+	"T": 1 << 40, "TB": 1 << 40,
+}
+
+// parseSizeValue parses a size like 1.5G.
+func parseSizeValue(s string) (int64, error) {
+	i := 0
+	for i < len(s) && (s[i] >= '0' && s[i] <= '9' || s[i] == '.') {
+		i++
+	}
+	num, err := strconv.ParseFloat(s[:i], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size: %s", s)
+	}
+	unit, ok := exprSizeUnits[strings.ToUpper(s[i:])]
+	if !ok {
+		return 0, fmt.Errorf("unknown size unit: %q", s[i:])
+	}
+	return int64(num * float64(unit)), nil
+}
+
+func isLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
@@ -0,0 +1,99 @@
+package stat
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFilterExprMatches(t *testing.T) {
+	now := time.Now()
+	log := &FileInfo{Path: "var/log/app.log", Size: 2 << 20, Links: 1, UID: 1000, ModTime: now.Add(-60 * 24 * time.Hour)}
+	small := &FileInfo{Path: "notes.TXT", Size: 100, Links: 2, UID: 0, ModTime: now.Add(-time.Hour)}
+	dir := &FileInfo{Path: "var/log", IsDir: true, Size: 4096, ModTime: now}
+
+	tests := []struct {
+		expr string
+		fi   *FileInfo
+		want bool
+	}{
+		{"size > 1M", log, true},
+		{"size > 1M", small, false},
+		{"size >= 2M", log, true},
+		{"size <= 100", small, true},
+		{"size == 0.5k", &FileInfo{Size: 512}, true},
+		{"links != 1", small, true},
+		{"uid == 1000", log, true},
+		{"mtime < now-30d", log, true},
+		{"mtime < now-30d", small, false},
+		{"mtime > 2000-01-01", small, true},
+		{"btime < now", small, false},
+		{"name =~ '\\.log$'", log, true},
+		{`name =~ "\.log$"`, small, false},
+		{"name == app.log", log, true},
+		{"path =~ ^var/", log, true},
+		{"ext == txt", small, true},
+		{"ext == log", dir, false},
+		{"type == dir", dir, true},
+		{"type != dir", log, true},
+		{"size > 1M && mtime < now-30d && name =~ '\\.log$'", log, true},
+		{"size > 1M && mtime < now-30d && name =~ '\\.log$'", small, false},
+		{"size > 1M || ext == txt", small, true},
+		{"size > 1M || ext == txt", dir, false},
+		{"!(type == dir)", dir, false},
+		{"! type == dir", log, true},
+		{"ext == txt || size > 1M && type == dir", log, false},
+		{"(ext == txt || size > 1M) && type != dir", log, true},
+		{"name !~ '^notes' && ext==txt", small, false},
+	}
+
+	for _, tt := range tests {
+		e, err := ParseFilterExpr(tt.expr)
+		if err != nil {
+			t.Fatalf("ParseFilterExpr(%q): %v", tt.expr, err)
+		}
+		if got := e.Matches(tt.fi); got != tt.want {
+			t.Errorf("%q on %s = %v, want %v", tt.expr, tt.fi.Path, got, tt.want)
+		}
+	}
+}
+
+func TestParseFilterExprErrors(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"size",
+		"size >",
+		"bogus == 1",
+		"size =~ 1",
+		"name < foo",
+		"size > 1X",
+		"mtime < yesterday",
+		"mtime < now-3q",
+		"name =~ '('",
+		"name == 'open",
+		"(size > 1",
+		"size > 1 &&",
+		"size > 1 size < 2",
+	} {
+		if _, err := ParseFilterExpr(expr); err == nil {
+			t.Errorf("ParseFilterExpr(%q) succeeded, want error", expr)
+		}
+	}
+}
+
+func TestFiltersExpr(t *testing.T) {
+	e, err := ParseFilterExpr("size > 1K || type == dir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &Filters{Expr: e, Types: map[string]bool{"file": true}}
+
+	if !f.Matches(&FileInfo{Path: "big", Size: 4096}) {
+		t.Error("large file should match")
+	}
+	if f.Matches(&FileInfo{Path: "small", Size: 10}) {
+		t.Error("small file should not match the expression")
+	}
+	if f.Matches(&FileInfo{Path: "dir", IsDir: true}) {
+		t.Error("directory should not match the type filter")
+	}
+}
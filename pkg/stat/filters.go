@@ -1,7 +1,10 @@
 package stat
 
 import (
+	"os"
+	"reflect"
 	"regexp"
+	"slices"
 	"time"
 )
 
@@ -18,28 +21,95 @@ type Filters struct {
 	MtimeOlderThan   *time.Duration // Include files modified older than this duration
 	MtimeYoungerThan *time.Duration // Include files modified younger than this duration
 
+	// Birth time filtering - creation time bounds relative to current time.
+	// Entries with unknown birth times never match.
+	BtimeOlderThan   *time.Duration // Include files created older than this duration
+	BtimeYoungerThan *time.Duration // Include files created younger than this duration
+
 	// Size filtering - file size bounds
 	SizeMin *int64 // Minimum file size in bytes
 	SizeMax *int64 // Maximum file size in bytes
 
+	// Empty filtering - zero-byte regular files and directories without
+	// entries. Empty directories are only recognized with
+	// StatsWalker.SetEmptyCheck.
+	Empty bool
+
 	// Name filtering - regex pattern for filename matching
 	NameRegex *regexp.Regexp
 
+	// Path filtering - regex pattern for the path relative to the walk
+	// root, such as "(^|/)cache/" for anything under a cache directory
+	PathRegex *regexp.Regexp
+
+	// Glob filtering - shell-style pattern for the filename, or for the
+	// relative path if the pattern contains a /
+	NameGlob *Glob
+
 	// User/Group filtering - owner criteria
 	Usernames  []string // List of usernames to include
 	UIDs       []uint32 // List of user IDs to include
-	Groupnames []string // List of group names to include (not implemented)
+	Groupnames []string // List of group names to include
 	GIDs       []uint32 // List of group IDs to include
 
-	// Permission filtering - permission bit matching
+	// Permission filtering - permission bit matching on Unix mode bits
+	// (0o7777), including setuid (0o4000), setgid (0o2000) and sticky (0o1000)
 	PermsHas uint32 // File must have ALL these permission bits
 	PermsNot uint32 // File must NOT have ANY of these permission bits
+
+	// Expression filtering - a filter expression the entry must satisfy,
+	// which can combine criteria with OR and negation
+	Expr *FilterExpr
+
+	// Composition - nested filter sets, for criteria the fields above cannot
+	// express since they are all combined with AND
+	AllOf []*Filters // Entry must match every filter set
+	AnyOf []*Filters // Entry must match at least one filter set, if any
+	Not   *Filters   // Entry must NOT match this filter set
+
+	// Extended attribute filtering - attributes the entry must carry.
+	// Requires extended attributes to be collected (StatsWalker.SetXattrs).
+	Xattrs []XattrMatch
+
+	// SELinux filtering - types of security contexts to include, e.g.
+	// "httpd_sys_content_t". Requires contexts to be collected
+	// (StatsWalker.SetSELinux); unlabeled entries never match.
+	SELinuxTypes []string
+}
+
+// IsEmpty reports whether the filters match every entry because no
+// criterion is set. A nil Filters is empty.
+func (f *Filters) IsEmpty() bool {
+	if f == nil {
+		return true
+	}
+	v := reflect.ValueOf(*f)
+	for i := range v.NumField() {
+		field := v.Field(i)
+		switch field.Kind() {
+		case reflect.Map, reflect.Slice:
+			if field.Len() > 0 {
+				return false
+			}
+		default:
+			if !field.IsZero() {
+				return false
+			}
+		}
+	}
+	return true
 }
 
 // Matches checks if a FileInfo passes all active filters.
 // Returns true only if the file passes all enabled filter criteria.
-// Filters are combined with AND logic: all must pass for a match.
+// Filters are combined with AND logic: all must pass for a match. OR and
+// negation are expressed with the AnyOf and Not filter sets. A nil Filters
+// matches everything.
 func (f *Filters) Matches(fi *FileInfo) bool {
+	if f == nil {
+		return true
+	}
+
 	// Type filter
 	if len(f.Types) > 0 {
 		fileType := getFileType(fi)
@@ -65,6 +135,25 @@ func (f *Filters) Matches(fi *FileInfo) bool {
 		}
 	}
 
+	// Btime filters
+	if (f.BtimeOlderThan != nil || f.BtimeYoungerThan != nil) && fi.BirthTime.IsZero() {
+		return false // Creation time unknown
+	}
+
+	if f.BtimeOlderThan != nil {
+		cutoff := now.Add(-*f.BtimeOlderThan)
+		if fi.BirthTime.After(cutoff) {
+			return false // File is too new
+		}
+	}
+
+	if f.BtimeYoungerThan != nil {
+		cutoff := now.Add(-*f.BtimeYoungerThan)
+		if fi.BirthTime.Before(cutoff) {
+			return false // File is too old
+		}
+	}
+
 	// Size filters
 	if f.SizeMin != nil && fi.Size < *f.SizeMin {
 		return false
@@ -74,6 +163,11 @@ func (f *Filters) Matches(fi *FileInfo) bool {
 		return false
 	}
 
+	// Empty filter
+	if f.Empty && !fi.IsEmpty() {
+		return false
+	}
+
 	// Name filter
 	if f.NameRegex != nil {
 		// Extract filename from path
@@ -94,6 +188,16 @@ func (f *Filters) Matches(fi *FileInfo) bool {
 		}
 	}
 
+	// Path filter
+	if f.PathRegex != nil && !f.PathRegex.MatchString(fi.Path) {
+		return false
+	}
+
+	// Glob filter
+	if f.NameGlob != nil && !f.NameGlob.Matches(fi) {
+		return false
+	}
+
 	// UID filter
 	if len(f.UIDs) > 0 {
 		found := false
@@ -122,25 +226,69 @@ func (f *Filters) Matches(fi *FileInfo) bool {
 		}
 	}
 
+	// Username and group name filters. Names are resolved per ID through
+	// the cached lookups, so each UID and GID is looked up once per process.
+	if len(f.Usernames) > 0 && !slices.Contains(f.Usernames, Username(fi.UID)) {
+		return false
+	}
+	if len(f.Groupnames) > 0 && !slices.Contains(f.Groupnames, Groupname(fi.GID)) {
+		return false
+	}
+
 	// Permission filters
 	if f.PermsHas != 0 {
-		mode := fi.Mode.Perm()
-		if (uint32(mode) & f.PermsHas) != f.PermsHas {
+		if unixPerm(fi.Mode)&f.PermsHas != f.PermsHas {
 			return false
 		}
 	}
 
 	if f.PermsNot != 0 {
-		mode := fi.Mode.Perm()
-		if (uint32(mode) & f.PermsNot) != 0 {
+		if unixPerm(fi.Mode)&f.PermsNot != 0 {
+			return false
+		}
+	}
+
+	// Expression filter
+	if f.Expr != nil && !f.Expr.Matches(fi) {
+		return false
+	}
+
+	// Extended attribute filters
+	for _, m := range f.Xattrs {
+		if !m.Matches(fi) {
+			return false
+		}
+	}
+
+	// SELinux type filter
+	if len(f.SELinuxTypes) > 0 {
+		labelType := SELinuxType(fi.Label)
+		found := false
+		for _, t := range f.SELinuxTypes {
+			if labelType == t {
+				found = true
+				break
+			}
+		}
+		if !found {
 			return false
 		}
 	}
 
-	// Note: Username and Groupname filters are applied separately
-	// during the aggregation since they require lookups
-	_ = f.Usernames
-	_ = f.Groupnames
+	// Composition
+	for _, sub := range f.AllOf {
+		if !sub.Matches(fi) {
+			return false
+		}
+	}
+
+	if len(f.AnyOf) > 0 && !slices.ContainsFunc(f.AnyOf, func(sub *Filters) bool { return sub.Matches(fi) }) {
+		return false
+	}
+
+	if f.Not != nil && f.Not.Matches(fi) {
+		return false
+	}
 
 	return true
 }
@@ -159,3 +307,20 @@ func getFileType(fi *FileInfo) string {
 	}
 	return "other"
 }
+
+// unixPerm returns the permission bits of m as in a Unix st_mode, with
+// setuid, setgid and sticky at 0o4000, 0o2000 and 0o1000. os.FileMode keeps
+// them outside the permission bits.
+func unixPerm(m os.FileMode) uint32 {
+	perm := uint32(m.Perm())
+	if m&os.ModeSetuid != 0 {
+		perm |= 0o4000
+	}
+	if m&os.ModeSetgid != 0 {
+		perm |= 0o2000
+	}
+	if m&os.ModeSticky != 0 {
+		perm |= 0o1000
+	}
+	return perm
+}
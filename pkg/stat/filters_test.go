@@ -116,6 +116,52 @@ func TestFiltersMatches(t *testing.T) {
 			},
 			want: false,
 		},
+		{
+			name: "btime older than - match",
+			filters: &Filters{
+				BtimeOlderThan: &oneWeekAgo,
+			},
+			fi: &FileInfo{
+				Path:      "/test/file",
+				ModTime:   now.Add(-1 * time.Hour),
+				BirthTime: now.Add(-8 * 24 * time.Hour),
+			},
+			want: true,
+		},
+		{
+			name: "btime older than - no match",
+			filters: &Filters{
+				BtimeOlderThan: &oneWeekAgo,
+			},
+			fi: &FileInfo{
+				Path:      "/test/file",
+				ModTime:   now.Add(-8 * 24 * time.Hour),
+				BirthTime: now.Add(-1 * time.Hour),
+			},
+			want: false,
+		},
+		{
+			name: "btime older than - unknown btime",
+			filters: &Filters{
+				BtimeOlderThan: &oneWeekAgo,
+			},
+			fi: &FileInfo{
+				Path:    "/test/file",
+				ModTime: now.Add(-8 * 24 * time.Hour),
+			},
+			want: false,
+		},
+		{
+			name: "btime younger than - match",
+			filters: &Filters{
+				BtimeYoungerThan: &oneHourAgo,
+			},
+			fi: &FileInfo{
+				Path:      "/test/file",
+				BirthTime: now.Add(-30 * time.Minute),
+			},
+			want: true,
+		},
 		{
 			name: "name regex - match",
 			filters: &Filters{
@@ -132,6 +178,22 @@ func TestFiltersMatches(t *testing.T) {
 			fi:   &FileInfo{Path: "/test/file.log"},
 			want: false,
 		},
+		{
+			name: "path regex - match in parent directory",
+			filters: &Filters{
+				PathRegex: regexp.MustCompile(`(^|/)cache/`),
+			},
+			fi:   &FileInfo{Path: "alice/cache/blob"},
+			want: true,
+		},
+		{
+			name: "path regex - no match",
+			filters: &Filters{
+				PathRegex: regexp.MustCompile(`(^|/)cache/`),
+			},
+			fi:   &FileInfo{Path: "alice/.cache/blob"},
+			want: false,
+		},
 		{
 			name: "uid filter - match",
 			filters: &Filters{
@@ -288,6 +350,64 @@ func TestUIDFilter(t *testing.T) {
 	}
 }
 
+func TestNameFilter(t *testing.T) {
+	const unknownID = 3999999 // Not expected to resolve to a user or group
+	owner, group := Username(0), Groupname(0)
+
+	tests := []struct {
+		name    string
+		filters *Filters
+		fi      *FileInfo
+		want    bool
+	}{
+		{
+			name:    "username match",
+			filters: &Filters{Usernames: []string{"nobody-else", owner}},
+			fi:      &FileInfo{UID: 0},
+			want:    true,
+		},
+		{
+			name:    "username no match",
+			filters: &Filters{Usernames: []string{owner}},
+			fi:      &FileInfo{UID: unknownID},
+			want:    false,
+		},
+		{
+			name:    "unresolved uid by name",
+			filters: &Filters{Usernames: []string{"uid:3999999"}},
+			fi:      &FileInfo{UID: unknownID},
+			want:    true,
+		},
+		{
+			name:    "groupname match",
+			filters: &Filters{Groupnames: []string{group}},
+			fi:      &FileInfo{GID: 0},
+			want:    true,
+		},
+		{
+			name:    "groupname no match",
+			filters: &Filters{Groupnames: []string{group}},
+			fi:      &FileInfo{GID: unknownID},
+			want:    false,
+		},
+		{
+			name:    "username and uid both required",
+			filters: &Filters{Usernames: []string{owner}, UIDs: []uint32{1000}},
+			fi:      &FileInfo{UID: 0},
+			want:    false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := tt.filters.Matches(tt.fi)
+			if result != tt.want {
+				t.Errorf("name filter mismatch: got %v, want %v", result, tt.want)
+			}
+		})
+	}
+}
+
 func TestPermissionFilter(t *testing.T) {
 	// Create a FileInfo with specific permissions (0755)
 	// Owner: rwx (7), Group: r-x (5), Other: r-x (5)
@@ -323,6 +443,38 @@ func TestPermissionFilter(t *testing.T) {
 			fi:   &FileInfo{Mode: mode},
 			want: true,
 		},
+		{
+			name: "perms has - setuid missing",
+			filters: &Filters{
+				PermsHas: 0o4000, // Setuid
+			},
+			fi:   &FileInfo{Mode: mode},
+			want: false,
+		},
+		{
+			name: "perms has - setuid",
+			filters: &Filters{
+				PermsHas: 0o4100, // Setuid and user execute
+			},
+			fi:   &FileInfo{Mode: mode | os.ModeSetuid},
+			want: true,
+		},
+		{
+			name: "perms has - setgid",
+			filters: &Filters{
+				PermsHas: 0o2000, // Setgid
+			},
+			fi:   &FileInfo{Mode: mode | os.ModeSetgid},
+			want: true,
+		},
+		{
+			name: "perms not - sticky",
+			filters: &Filters{
+				PermsNot: 0o1000, // Sticky
+			},
+			fi:   &FileInfo{Mode: os.ModeDir | os.ModeSticky | 0o777},
+			want: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -334,3 +486,32 @@ func TestPermissionFilter(t *testing.T) {
 		})
 	}
 }
+
+func TestFiltersComposition(t *testing.T) {
+	alice := &Filters{UIDs: []uint32{1000}}
+	bobGroup := &Filters{GIDs: []uint32{2000}}
+	dirs := &Filters{Types: map[string]bool{"dir": true}}
+
+	tests := []struct {
+		name    string
+		filters *Filters
+		fi      *FileInfo
+		want    bool
+	}{
+		{"any of - first", &Filters{AnyOf: []*Filters{alice, bobGroup}}, &FileInfo{Path: "a", UID: 1000}, true},
+		{"any of - second", &Filters{AnyOf: []*Filters{alice, bobGroup}}, &FileInfo{Path: "b", GID: 2000}, true},
+		{"any of - none", &Filters{AnyOf: []*Filters{alice, bobGroup}}, &FileInfo{Path: "c"}, false},
+		{"all of", &Filters{AllOf: []*Filters{alice, bobGroup}}, &FileInfo{Path: "d", UID: 1000}, false},
+		{"all of - both", &Filters{AllOf: []*Filters{alice, bobGroup}}, &FileInfo{Path: "e", UID: 1000, GID: 2000}, true},
+		{"not", &Filters{Not: dirs}, &FileInfo{Path: "f", IsDir: true}, false},
+		{"not - other type", &Filters{Not: dirs}, &FileInfo{Path: "g"}, true},
+		{"combined with fields", &Filters{SizeMin: &[]int64{10}[0], AnyOf: []*Filters{alice, bobGroup}}, &FileInfo{Path: "h", UID: 1000, Size: 5}, false},
+		{"nil filters", nil, &FileInfo{Path: "i"}, true},
+	}
+
+	for _, tt := range tests {
+		if got := tt.filters.Matches(tt.fi); got != tt.want {
+			t.Errorf("%s: Matches = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package stat
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Glob is a compiled shell-style pattern. * matches any run of characters
+// except /, ? matches one character except /, [abc], [a-z] and [!abc] match
+// one character of a class, and {a,b} matches either alternative; braces
+// may nest. ** as a whole path component matches any number of
+// directories, so "**/cache/*.tmp" matches at any depth. A backslash
+// escapes the next character.
+//
+// Patterns without a / match the entry name, like find -name; patterns
+// with a / match the whole path relative to the walk root.
+type Glob struct {
+	pattern  string
+	re       *regexp.Regexp
+	fullPath bool
+}
+
+// CompileGlob compiles a shell-style pattern.
+func CompileGlob(pattern string) (*Glob, error) {
+	if pattern == "" {
+		return nil, fmt.Errorf("empty glob pattern")
+	}
+	fullPath := strings.Contains(pattern, "/")
+	expr, err := globToRegexp(strings.TrimPrefix(pattern, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid glob %q: %w", pattern, err)
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid glob %q: %w", pattern, err)
+	}
+	return &Glob{pattern: pattern, re: re, fullPath: fullPath}, nil
+}
+
+// String returns the source pattern.
+func (g *Glob) String() string {
+	return g.pattern
+}
+
+// MatchString reports whether name, or a path relative to the walk root
+// for patterns containing a /, matches the pattern.
+func (g *Glob) MatchString(name string) bool {
+	return g.re.MatchString(name)
+}
+
+// Matches reports whether the entry's name or relative path matches the
+// pattern.
+func (g *Glob) Matches(fi *FileInfo) bool {
+	if g.fullPath {
+		return g.re.MatchString(fi.Path)
+	}
+	return g.re.MatchString(fi.Path[strings.LastIndex(fi.Path, "/")+1:])
+}
+
+// globToRegexp translates a glob pattern into an anchored regular
+// expression.
+func globToRegexp(pattern string) (string, error) {
+	var b strings.Builder
+	b.WriteString("^")
+	depth := 0 // Open braces
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch c {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				atStart := i == 0 || pattern[i-1] == '/'
+				switch {
+				case atStart && i+2 == len(pattern):
+					b.WriteString(".*")
+					i++
+					continue
+				case atStart && pattern[i+2] == '/':
+					b.WriteString("(?:.*/)?")
+					i += 2
+					continue
+				}
+				i++ // ** inside a component is the same as *
+			}
+			b.WriteString("[^/]*")
+		case '?':
+			b.WriteString("[^/]")
+		case '[':
+			end, class, err := globClass(pattern, i)
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(class)
+			i = end
+		case '{':
+			depth++
+			b.WriteString("(?:")
+		case ',':
+			if depth > 0 {
+				b.WriteString("|")
+			} else {
+				b.WriteString(",")
+			}
+		case '}':
+			if depth > 0 {
+				depth--
+				b.WriteString(")")
+			} else {
+				b.WriteString(`\}`)
+			}
+		case '\\':
+			if i+1 == len(pattern) {
+				return "", fmt.Errorf("trailing backslash")
+			}
+			i++
+			b.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		default:
+			b.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		}
+	}
+	if depth > 0 {
+		return "", fmt.Errorf("unclosed {")
+	}
+	b.WriteString("$")
+	return b.String(), nil
+}
+
+// globClass translates the character class starting at pattern[start],
+// returning the index of its closing ] and the regular expression class.
+func globClass(pattern string, start int) (int, string, error) {
+	var b strings.Builder
+	b.WriteString("[")
+	i := start + 1
+	if i < len(pattern) && (pattern[i] == '!' || pattern[i] == '^') {
+		b.WriteString("^/")
+		i++
+	}
+	for first := true; i < len(pattern); i++ {
+		c := pattern[i]
+		if c == ']' && !first {
+			b.WriteString("]")
+			return i, b.String(), nil
+		}
+		first = false
+		if c == '\\' && i+1 < len(pattern) {
+			i++
+			c = pattern[i]
+		}
+		if c == '\\' || c == '[' || c == ']' || c == '^' {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(c)
+	}
+	return 0, "", fmt.Errorf("unclosed [")
+}
@@ -0,0 +1,62 @@
+package stat
+
+import "testing"
+
+func TestGlobMatches(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"*.log", "app.log", true},
+		{"*.log", "var/log/app.log", true},
+		{"*.log", "app.log.1", false},
+		{"data_??.csv", "data_01.csv", true},
+		{"data_??.csv", "data_1.csv", false},
+		{"*.{csv,tsv}", "x/report.tsv", true},
+		{"*.{csv,tsv}", "report.json", false},
+		{"{a,b{1,2}}.txt", "b2.txt", true},
+		{"{a,b{1,2}}.txt", "b3.txt", false},
+		{"[abc]*", "beta", true},
+		{"[!abc]*", "beta", false},
+		{"[a-c]x", "cx", true},
+		{"[]]", "]", true},
+		{`\*.txt`, "*.txt", true},
+		{`\*.txt`, "a.txt", false},
+		{"a+b(c).txt", "a+b(c).txt", true},
+		{"{x}", "{x}", false},
+		{"x,y}", "x,y}", true},
+		{"var/*", "var/log", true},
+		{"var/*", "var/log/app.log", false},
+		{"/var/*", "var/log", true},
+		{"var/**", "var/log/app.log", true},
+		{"**/cache/*", "cache/x", true},
+		{"**/cache/*", "home/u/.cache/x", false},
+		{"**/cache/*", "home/u/cache/x", true},
+		{"**/cache/**", "a/cache/b/c", true},
+		{"**/*.go", "main.go", true},
+		{"**/*.go", "pkg/stat/glob.go", true},
+		{"src/**/*.c", "src/a/b/x.c", true},
+		{"src/**/*.c", "src/x.c", true},
+		{"src/a**/x.c", "src/ab/x.c", true},
+		{"src/a**/x.c", "src/a/b/x.c", false},
+	}
+
+	for _, tt := range tests {
+		g, err := CompileGlob(tt.pattern)
+		if err != nil {
+			t.Fatalf("CompileGlob(%q): %v", tt.pattern, err)
+		}
+		if got := g.Matches(&FileInfo{Path: tt.path}); got != tt.want {
+			t.Errorf("%q on %q = %v, want %v", tt.pattern, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestCompileGlobErrors(t *testing.T) {
+	for _, pattern := range []string{"", "[abc", "{a,b", `abc\`} {
+		if _, err := CompileGlob(pattern); err == nil {
+			t.Errorf("CompileGlob(%q) succeeded, want error", pattern)
+		}
+	}
+}
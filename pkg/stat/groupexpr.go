@@ -0,0 +1,143 @@
+package stat
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// groupExprColumn is the cross tabulation column of a group-by expression.
+const groupExprColumn = "key"
+
+// groupExprErrorKey is the group of entries an expression failed on.
+const groupExprErrorKey = "(error)"
+
+// GroupExpr computes cross tabulation keys from entries with a Go
+// text/template, such as "{{.User}}/{{.Ext}}". The template is executed on
+// a GroupEntry, so FileInfo fields like .UID and .Size are available along
+// with the computed .Name, .Ext, .Dir, .Top, .Year, .User, .Group and
+// .Type. The functions lower, upper and prefix are provided as well.
+type GroupExpr struct {
+	src  string
+	tmpl *template.Template
+}
+
+// groupExprFuncs are the functions available to group-by expressions.
+var groupExprFuncs = template.FuncMap{
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+	// prefix returns the first n slash-separated components of a path.
+	"prefix": func(n int, p string) string {
+		parts := strings.Split(p, "/")
+		if n < len(parts) {
+			parts = parts[:n]
+		}
+		return strings.Join(parts, "/")
+	},
+}
+
+// ParseGroupExpr parses a group-by expression. The template is executed
+// once on an empty entry, so unknown fields are reported here rather than
+// during the walk.
+func ParseGroupExpr(s string) (*GroupExpr, error) {
+	tmpl, err := template.New("group-by-expr").Funcs(groupExprFuncs).Option("missingkey=error").Parse(s)
+	if err != nil {
+		return nil, err
+	}
+	e := &GroupExpr{src: s, tmpl: tmpl}
+	if _, err := e.execute(&GroupEntry{FileInfo: &FileInfo{}}); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// String returns the source of the expression.
+func (e *GroupExpr) String() string {
+	return e.src
+}
+
+// Key returns the key of fi, counted under year (0 if unknown). Entries the
+// expression fails on are keyed "(error)".
+func (e *GroupExpr) Key(fi *FileInfo, year int) string {
+	key, err := e.execute(&GroupEntry{FileInfo: fi, year: year})
+	if err != nil {
+		return groupExprErrorKey
+	}
+	return key
+}
+
+func (e *GroupExpr) execute(entry *GroupEntry) (string, error) {
+	var b strings.Builder
+	if err := e.tmpl.Execute(&b, entry); err != nil {
+		return "", fmt.Errorf("group-by expression: %w", err)
+	}
+	return b.String(), nil
+}
+
+// GroupEntry is the data group-by expressions are executed on: the entry's
+// FileInfo plus computed attributes.
+type GroupEntry struct {
+	*FileInfo
+	year int
+}
+
+// Name returns the base name of the entry.
+func (e *GroupEntry) Name() string {
+	return path.Base(e.Path)
+}
+
+// Ext returns the lower-case file name extension without the dot, or ""
+// for directories and names without one.
+func (e *GroupEntry) Ext() string {
+	if e.IsDir {
+		return ""
+	}
+	return strings.ToLower(strings.TrimPrefix(path.Ext(e.Path), "."))
+}
+
+// Dir returns the directory of the entry relative to its root, "." for
+// entries directly below the root.
+func (e *GroupEntry) Dir() string {
+	return path.Dir(e.Path)
+}
+
+// Top returns the first path component below the root, such as the home
+// directory of each user when walking /home, or "." for the root itself.
+func (e *GroupEntry) Top() string {
+	if e.Path == "" {
+		return "."
+	}
+	top, _, _ := strings.Cut(e.Path, "/")
+	return top
+}
+
+// Year returns the year the entry is counted under, or "unknown".
+func (e *GroupEntry) Year() string {
+	if e.year == 0 {
+		return unknownYearKey
+	}
+	return strconv.Itoa(e.year)
+}
+
+// User returns the owner name of the entry.
+func (e *GroupEntry) User() string {
+	return Username(e.UID)
+}
+
+// Group returns the group name of the entry.
+func (e *GroupEntry) Group() string {
+	return Groupname(e.GID)
+}
+
+// Type returns the inode type of the entry: file, dir, symlink or other.
+func (e *GroupEntry) Type() string {
+	return getFileType(e.FileInfo)
+}
+
+// SetGroupExpr makes the walk aggregate Results.Groups by the key computed
+// by e, after any dimensions set with SetCrossTab.
+func (sw *StatsWalker) SetGroupExpr(e *GroupExpr) {
+	sw.groupExpr = e
+}
@@ -0,0 +1,83 @@
+package stat
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGroupExprKey(t *testing.T) {
+	fi := &FileInfo{Root: "/home", Path: "alice/src/Main.GO", UID: 3999999, Size: 10, Mode: 0o644}
+
+	tests := []struct {
+		expr string
+		year int
+		want string
+	}{
+		{"{{.UID}}/{{.Ext}}", 2024, "3999999/go"},
+		{"{{.User}}", 0, "uid:3999999"},
+		{"{{.Top}}:{{.Year}}", 0, "alice:unknown"},
+		{"{{.Top}}:{{.Year}}", 2021, "alice:2021"},
+		{"{{upper .Name}}", 0, "MAIN.GO"},
+		{"{{prefix 2 .Path}}", 0, "alice/src"},
+		{"{{.Type}} {{.Dir}}", 0, "file alice/src"},
+		{"{{if gt .Size 1024}}big{{else}}small{{end}}", 0, "small"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			e, err := ParseGroupExpr(tt.expr)
+			if err != nil {
+				t.Fatalf("ParseGroupExpr: %v", err)
+			}
+			if got := e.Key(fi, tt.year); got != tt.want {
+				t.Errorf("Key() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseGroupExprErrors(t *testing.T) {
+	for _, expr := range []string{"{{.UID", "{{.Color}}", "{{nosuchfunc .Path}}"} {
+		if _, err := ParseGroupExpr(expr); err == nil {
+			t.Errorf("ParseGroupExpr(%q) should fail", expr)
+		}
+	}
+}
+
+func TestWalkGroupExpr(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{"alice", "bob"} {
+		if err := os.MkdirAll(filepath.Join(root, dir, "sub"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(root, dir, "sub", "f.txt"), []byte("data"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	e, err := ParseGroupExpr("{{.Top}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sw := NewStatsWalker([]string{root}, 2, &Filters{Types: map[string]bool{"file": true}})
+	sw.SetCrossTab([]Dimension{DimType})
+	sw.SetGroupExpr(e)
+	res, err := sw.Walk()
+	if err != nil {
+		t.Fatalf("walk failed: %v", err)
+	}
+
+	if len(res.GroupColumns) != 2 || res.GroupColumns[0] != "type" || res.GroupColumns[1] != "key" {
+		t.Errorf("columns = %v, want [type key]", res.GroupColumns)
+	}
+	groups := res.SortedGroups()
+	if len(groups) != 2 {
+		t.Fatalf("got %d groups, want 2", len(groups))
+	}
+	for i, top := range []string{"alice", "bob"} {
+		if groups[i].Keys[0] != "file" || groups[i].Keys[1] != top || groups[i].Files != 1 {
+			t.Errorf("group %d = %v with %d files, want [file %s] with 1", i, groups[i].Keys, groups[i].Files, top)
+		}
+	}
+}
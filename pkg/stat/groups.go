@@ -0,0 +1,204 @@
+package stat
+
+import (
+	"cmp"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Dimension is an entry attribute that cross-tabulated statistics can be
+// grouped by.
+type Dimension int
+
+const (
+	// DimUID groups by numeric owner.
+	DimUID Dimension = iota
+	// DimUser groups by owner name.
+	DimUser
+	// DimGID groups by numeric group.
+	DimGID
+	// DimGroup groups by group name.
+	DimGroup
+	// DimYear groups by the year of the timestamp selected with
+	// StatsWalker.SetGroupBy.
+	DimYear
+	// DimExt groups by lower-case file name extension.
+	DimExt
+	// DimType groups by inode type: file, dir, symlink or other.
+	DimType
+)
+
+// dimensionNames are the names of the dimensions, indexed by Dimension.
+var dimensionNames = []string{"uid", "user", "gid", "group", "year", "ext", "type"}
+
+// Group keys of entries without a value in a dimension.
+const (
+	unknownYearKey = "unknown"
+	noExtKey       = "(none)"
+)
+
+// String returns the name of the dimension as accepted by ParseDimension.
+func (d Dimension) String() string {
+	if int(d) < len(dimensionNames) {
+		return dimensionNames[d]
+	}
+	return fmt.Sprintf("Dimension(%d)", int(d))
+}
+
+// ParseDimension parses a dimension name: uid, user, gid, group, year, ext
+// or type.
+func ParseDimension(s string) (Dimension, error) {
+	for i, name := range dimensionNames {
+		if s == name {
+			return Dimension(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown dimension %q (available: %s)", s, strings.Join(dimensionNames, ", "))
+}
+
+// GroupStat holds the statistics of one combination of dimension values in
+// a cross tabulation.
+type GroupStat struct {
+	Keys        []string // Values of the columns, in Results.GroupColumns order
+	TotalSize   int64    // Total size of entries in the group
+	TotalInodes int64    // Total count of inodes in the group
+	Files       int64    // Count of regular files
+	Dirs        int64    // Count of directories
+	Symlinks    int64    // Count of symbolic links
+	Others      int64    // Count of other inode types
+	FilesSize   int64    // Total size of regular files
+
+	Extremes // Oldest and newest modification, largest file and deepest path
+}
+
+// SetCrossTab makes the walk aggregate Results.Groups, one group per
+// combination of values in dims, such as owner and year. Name dimensions
+// are resolved through the cached name lookups.
+func (sw *StatsWalker) SetCrossTab(dims []Dimension) {
+	sw.crossDims = dims
+}
+
+// groupColumns returns the names of the cross tabulation columns.
+func (sw *StatsWalker) groupColumns() []string {
+	var cols []string
+	for _, d := range sw.crossDims {
+		cols = append(cols, d.String())
+	}
+	if sw.groupExpr != nil {
+		cols = append(cols, groupExprColumn)
+	}
+	return cols
+}
+
+// groupKeys returns the values of fi in dims. year is the year the entry is
+// counted under, 0 if unknown.
+func groupKeys(fi *FileInfo, year int, dims []Dimension) []string {
+	keys := make([]string, len(dims))
+	for i, d := range dims {
+		switch d {
+		case DimUID:
+			keys[i] = strconv.FormatUint(uint64(fi.UID), 10)
+		case DimUser:
+			keys[i] = Username(fi.UID)
+		case DimGID:
+			keys[i] = strconv.FormatUint(uint64(fi.GID), 10)
+		case DimGroup:
+			keys[i] = Groupname(fi.GID)
+		case DimYear:
+			keys[i] = unknownYearKey
+			if year != 0 {
+				keys[i] = strconv.Itoa(year)
+			}
+		case DimExt:
+			keys[i] = noExtKey
+			if ext := strings.ToLower(strings.TrimPrefix(path.Ext(fi.Path), ".")); ext != "" && !fi.IsDir {
+				keys[i] = ext
+			}
+		case DimType:
+			keys[i] = getFileType(fi)
+		}
+	}
+	return keys
+}
+
+// addGroup records fi in the group of keys.
+func (r *Results) addGroup(keys []string, fi *FileInfo) {
+	id := strings.Join(keys, "\x00")
+	gs, ok := r.Groups[id]
+	if !ok {
+		gs = &GroupStat{Keys: keys}
+		r.Groups[id] = gs
+	}
+	gs.TotalInodes++
+	gs.TotalSize += fi.Size
+	gs.Extremes.add(fi)
+	switch getFileType(fi) {
+	case "file":
+		gs.Files++
+		gs.FilesSize += fi.Size
+	case "dir":
+		gs.Dirs++
+	case "symlink":
+		gs.Symlinks++
+	default:
+		gs.Others++
+	}
+}
+
+// mergeGroups folds the groups of other into r.
+func (r *Results) mergeGroups(other *Results) {
+	for id, s := range other.Groups {
+		gs, ok := r.Groups[id]
+		if !ok {
+			gs = &GroupStat{Keys: s.Keys}
+			r.Groups[id] = gs
+		}
+		gs.TotalSize += s.TotalSize
+		gs.TotalInodes += s.TotalInodes
+		gs.Files += s.Files
+		gs.Dirs += s.Dirs
+		gs.Symlinks += s.Symlinks
+		gs.Others += s.Others
+		gs.FilesSize += s.FilesSize
+		gs.Extremes.merge(&s.Extremes)
+	}
+}
+
+// SortedGroups returns the cross-tabulated groups ordered by their keys,
+// dimension by dimension. Numeric keys such as UIDs and years sort
+// numerically and before other keys.
+func (r *Results) SortedGroups() []*GroupStat {
+	out := make([]*GroupStat, 0, len(r.Groups))
+	for _, gs := range r.Groups {
+		out = append(out, gs)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		a, b := out[i].Keys, out[j].Keys
+		for k := 0; k < len(a) && k < len(b); k++ {
+			if c := compareKeys(a[k], b[k]); c != 0 {
+				return c < 0
+			}
+		}
+		return len(a) < len(b)
+	})
+	return out
+}
+
+// compareKeys compares two group keys, numerically if both are numbers.
+func compareKeys(a, b string) int {
+	na, errA := strconv.ParseInt(a, 10, 64)
+	nb, errB := strconv.ParseInt(b, 10, 64)
+	switch {
+	case errA == nil && errB == nil:
+		return cmp.Compare(na, nb)
+	case errA == nil:
+		return -1
+	case errB == nil:
+		return 1
+	default:
+		return strings.Compare(a, b)
+	}
+}
@@ -0,0 +1,85 @@
+package stat
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestWalkCrossTab(t *testing.T) {
+	root := t.TempDir()
+	old := time.Date(2019, 6, 1, 0, 0, 0, 0, time.UTC)
+	for _, name := range []string{"a.log", "b.LOG", "c.txt", "d"} {
+		p := filepath.Join(root, name)
+		if err := os.WriteFile(p, []byte("data"), 0644); err != nil {
+			t.Fatalf("failed to create file: %v", err)
+		}
+		if name != "c.txt" {
+			if err := os.Chtimes(p, old, old); err != nil {
+				t.Fatalf("failed to set mtime: %v", err)
+			}
+		}
+	}
+
+	sw := NewStatsWalker([]string{root}, 2, &Filters{Types: map[string]bool{"file": true}})
+	sw.SetCrossTab([]Dimension{DimExt, DimYear})
+	res, err := sw.Walk()
+	if err != nil {
+		t.Fatalf("walk failed: %v", err)
+	}
+
+	year := time.Now().Year()
+	if got := len(res.GroupColumns); got != 2 {
+		t.Fatalf("got %d dimensions, want 2", got)
+	}
+	groups := res.SortedGroups()
+	want := []struct {
+		keys  [2]string
+		files int64
+	}{
+		{[2]string{"(none)", "2019"}, 1},
+		{[2]string{"log", "2019"}, 2},
+		{[2]string{"txt", strconv.Itoa(year)}, 1},
+	}
+	if len(groups) != len(want) {
+		t.Fatalf("got %d groups, want %d", len(groups), len(want))
+	}
+	for i, w := range want {
+		gs := groups[i]
+		if gs.Keys[0] != w.keys[0] || gs.Keys[1] != w.keys[1] || gs.Files != w.files || gs.TotalSize != 4*w.files {
+			t.Errorf("group %d: got %v with %d files, %d bytes, want %v with %d files", i, gs.Keys, gs.Files, gs.TotalSize, w.keys, w.files)
+		}
+	}
+}
+
+func TestCompareKeys(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"9", "10", -1},
+		{"2024", "unknown", -1},
+		{"alice", "1000", 1},
+		{"alice", "bob", -1},
+		{"log", "log", 0},
+	}
+	for _, tt := range tests {
+		if got := compareKeys(tt.a, tt.b); got != tt.want {
+			t.Errorf("compareKeys(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestParseDimension(t *testing.T) {
+	for _, name := range dimensionNames {
+		d, err := ParseDimension(name)
+		if err != nil || d.String() != name {
+			t.Errorf("ParseDimension(%q) = %v, %v", name, d, err)
+		}
+	}
+	if _, err := ParseDimension("color"); err == nil {
+		t.Error("ParseDimension(color) should fail")
+	}
+}
@@ -0,0 +1,346 @@
+package stat
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultHistoryFullEvery is the default number of snapshots per full
+// snapshot in a history: one full followed by DefaultHistoryFullEvery-1 deltas.
+const DefaultHistoryFullEvery = 7
+
+// History stores a sequence of snapshots in a directory. To keep the
+// on-disk size small for daily scans of very large trees, only every
+// FullEvery-th snapshot is stored in full; the others are stored as deltas
+// against the most recent full snapshot.
+//
+// Files are named by sequence number: 00000001.full.json, 00000002.delta.json, ...
+type History struct {
+	Dir       string // Directory holding the history files
+	FullEvery int    // Store a full snapshot every FullEvery snapshots
+}
+
+// SnapshotDelta holds the difference between a snapshot and a full base snapshot.
+type SnapshotDelta struct {
+	Version int             `json:"version"`
+	Time    time.Time       `json:"time"`
+	Roots   []string        `json:"roots"`
+	Base    int             `json:"base"`    // Sequence number of the full base snapshot
+	Upserts []SnapshotEntry `json:"upserts"` // Entries added or changed since the base, sorted by Path
+	Deletes []string        `json:"deletes"` // Paths removed since the base, sorted
+}
+
+// historyFile describes one file in a history directory.
+type historyFile struct {
+	seq  int
+	full bool
+}
+
+// name returns the file name for the entry.
+func (f historyFile) name() string {
+	kind := "delta"
+	if f.full {
+		kind = "full"
+	}
+	return fmt.Sprintf("%08d.%s.json", f.seq, kind)
+}
+
+// NewHistory returns a History rooted at dir. If fullEvery is less than 1,
+// DefaultHistoryFullEvery is used.
+func NewHistory(dir string, fullEvery int) *History {
+	if fullEvery < 1 {
+		fullEvery = DefaultHistoryFullEvery
+	}
+	return &History{Dir: dir, FullEvery: fullEvery}
+}
+
+// Len returns the number of snapshots in the history.
+func (h *History) Len() (int, error) {
+	files, err := h.files()
+	if err != nil {
+		return 0, err
+	}
+	return len(files), nil
+}
+
+// Append adds a snapshot to the history, creating the directory if needed.
+// The snapshot is stored in full if there is no full snapshot yet or if
+// FullEvery-1 deltas have been written since the last one.
+func (h *History) Append(s *Snapshot) error {
+	if err := os.MkdirAll(h.Dir, 0755); err != nil {
+		return err
+	}
+
+	files, err := h.files()
+	if err != nil {
+		return err
+	}
+
+	next := historyFile{seq: 1, full: true}
+	var base *historyFile
+	if len(files) > 0 {
+		next.seq = files[len(files)-1].seq + 1
+		for i := len(files) - 1; i >= 0; i-- {
+			if files[i].full {
+				base = &files[i]
+				break
+			}
+		}
+		next.full = base == nil || next.seq-base.seq >= h.FullEvery
+	}
+
+	if next.full {
+		return h.writeJSON(next, s)
+	}
+
+	baseSnap, err := h.readFull(*base)
+	if err != nil {
+		return err
+	}
+	return h.writeJSON(next, newSnapshotDelta(base.seq, baseSnap, s))
+}
+
+// Latest returns the most recent snapshot, or nil if the history is empty.
+func (h *History) Latest() (*Snapshot, error) {
+	files, err := h.files()
+	if err != nil || len(files) == 0 {
+		return nil, err
+	}
+	return h.load(files, len(files)-1)
+}
+
+// All returns every snapshot in the history, oldest first.
+func (h *History) All() ([]*Snapshot, error) {
+	files, err := h.files()
+	if err != nil {
+		return nil, err
+	}
+
+	snaps := make([]*Snapshot, 0, len(files))
+	for i := range files {
+		s, err := h.load(files, i)
+		if err != nil {
+			return nil, err
+		}
+		snaps = append(snaps, s)
+	}
+	return snaps, nil
+}
+
+// Compact drops all but the newest keep snapshots and rewrites the rest so
+// the oldest kept snapshot becomes a full one, with fulls and deltas
+// re-laid out according to FullEvery. Sequence numbers restart at 1.
+// If keep is less than 1, all snapshots are kept and only re-encoded.
+func (h *History) Compact(keep int) error {
+	if _, err := os.Stat(h.Dir); os.IsNotExist(err) {
+		return nil
+	}
+
+	snaps, err := h.All()
+	if err != nil {
+		return err
+	}
+	if keep >= 1 && len(snaps) > keep {
+		snaps = snaps[len(snaps)-keep:]
+	}
+
+	// Write the compacted history next to the old one, then swap it in
+	tmp := NewHistory(h.Dir+".compact", h.FullEvery)
+	if err := os.RemoveAll(tmp.Dir); err != nil {
+		return err
+	}
+	for _, s := range snaps {
+		if err := tmp.Append(s); err != nil {
+			os.RemoveAll(tmp.Dir)
+			return err
+		}
+	}
+
+	old := h.Dir + ".old"
+	if err := os.RemoveAll(old); err != nil {
+		return err
+	}
+	if err := os.Rename(h.Dir, old); err != nil {
+		return err
+	}
+	if len(snaps) == 0 {
+		if err := os.MkdirAll(tmp.Dir, 0755); err != nil {
+			return err
+		}
+	}
+	if err := os.Rename(tmp.Dir, h.Dir); err != nil {
+		return err
+	}
+	return os.RemoveAll(old)
+}
+
+// files lists the history files sorted by sequence number. A missing
+// directory is treated as an empty history.
+func (h *History) files() ([]historyFile, error) {
+	entries, err := os.ReadDir(h.Dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var files []historyFile
+	for _, entry := range entries {
+		parts := strings.Split(entry.Name(), ".")
+		if len(parts) != 3 || parts[2] != "json" || (parts[1] != "full" && parts[1] != "delta") {
+			continue
+		}
+		seq, err := strconv.Atoi(parts[0])
+		if err != nil {
+			continue
+		}
+		files = append(files, historyFile{seq: seq, full: parts[1] == "full"})
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].seq < files[j].seq })
+	return files, nil
+}
+
+// load reconstructs the snapshot stored in files[i].
+func (h *History) load(files []historyFile, i int) (*Snapshot, error) {
+	f := files[i]
+	if f.full {
+		return h.readFull(f)
+	}
+
+	var d SnapshotDelta
+	if err := h.readJSON(f, &d); err != nil {
+		return nil, err
+	}
+	base, err := h.readFull(historyFile{seq: d.Base, full: true})
+	if err != nil {
+		return nil, fmt.Errorf("%s: load base: %w", f.name(), err)
+	}
+	return d.apply(base), nil
+}
+
+// readFull reads a full snapshot file.
+func (h *History) readFull(f historyFile) (*Snapshot, error) {
+	var s Snapshot
+	if err := h.readJSON(f, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// readJSON reads and decodes a history file, checking its version.
+func (h *History) readJSON(f historyFile, v interface{}) error {
+	b, err := os.ReadFile(filepath.Join(h.Dir, f.name()))
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(b, v); err != nil {
+		return fmt.Errorf("parse %s: %w", f.name(), err)
+	}
+
+	var version int
+	switch x := v.(type) {
+	case *Snapshot:
+		version = x.Version
+	case *SnapshotDelta:
+		version = x.Version
+	}
+	if version != SnapshotVersion {
+		return fmt.Errorf("%s: unsupported version %d", f.name(), version)
+	}
+	return nil
+}
+
+// writeJSON encodes v to the file for f.
+func (h *History) writeJSON(f historyFile, v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(h.Dir, f.name()), b, 0644)
+}
+
+// newSnapshotDelta computes the delta that turns base into s.
+// Both snapshots must have their entries sorted by path.
+func newSnapshotDelta(baseSeq int, base, s *Snapshot) *SnapshotDelta {
+	d := &SnapshotDelta{
+		Version: SnapshotVersion,
+		Time:    s.Time,
+		Roots:   s.Roots,
+		Base:    baseSeq,
+		Upserts: []SnapshotEntry{},
+		Deletes: []string{},
+	}
+
+	i, j := 0, 0
+	for i < len(base.Entries) || j < len(s.Entries) {
+		switch {
+		case j >= len(s.Entries) || (i < len(base.Entries) && base.Entries[i].Path < s.Entries[j].Path):
+			d.Deletes = append(d.Deletes, base.Entries[i].Path)
+			i++
+		case i >= len(base.Entries) || s.Entries[j].Path < base.Entries[i].Path:
+			d.Upserts = append(d.Upserts, s.Entries[j])
+			j++
+		default:
+			if !base.Entries[i].equal(s.Entries[j]) {
+				d.Upserts = append(d.Upserts, s.Entries[j])
+			}
+			i++
+			j++
+		}
+	}
+
+	return d
+}
+
+// apply reconstructs the full snapshot from the delta and its base.
+func (d *SnapshotDelta) apply(base *Snapshot) *Snapshot {
+	s := &Snapshot{
+		Version: SnapshotVersion,
+		Time:    d.Time,
+		Roots:   d.Roots,
+		Entries: make([]SnapshotEntry, 0, len(base.Entries)+len(d.Upserts)-len(d.Deletes)),
+	}
+
+	i, j, k := 0, 0, 0
+	for i < len(base.Entries) || j < len(d.Upserts) {
+		if i < len(base.Entries) {
+			// Skip deleted base entries
+			for k < len(d.Deletes) && d.Deletes[k] < base.Entries[i].Path {
+				k++
+			}
+			if k < len(d.Deletes) && d.Deletes[k] == base.Entries[i].Path {
+				i++
+				continue
+			}
+		}
+
+		switch {
+		case j >= len(d.Upserts) || (i < len(base.Entries) && base.Entries[i].Path < d.Upserts[j].Path):
+			s.Entries = append(s.Entries, base.Entries[i])
+			i++
+		case i >= len(base.Entries) || d.Upserts[j].Path < base.Entries[i].Path:
+			s.Entries = append(s.Entries, d.Upserts[j])
+			j++
+		default:
+			s.Entries = append(s.Entries, d.Upserts[j])
+			i++
+			j++
+		}
+	}
+
+	return s
+}
+
+// equal reports whether two entries hold the same metadata.
+func (e SnapshotEntry) equal(o SnapshotEntry) bool {
+	return e.Path == o.Path && e.Size == o.Size && e.ModTime.Equal(o.ModTime) &&
+		e.Mode == o.Mode && e.UID == o.UID && e.GID == o.GID
+}
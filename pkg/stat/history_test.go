@@ -0,0 +1,164 @@
+package stat
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// historyTestSnapshot builds a snapshot of n files where the file named by
+// changed (if any) has a different size.
+func historyTestSnapshot(day, n int, changed string) *Snapshot {
+	t := time.Date(2024, 1, day, 0, 0, 0, 0, time.UTC)
+	mtime := time.Date(2023, 12, 1, 0, 0, 0, 0, time.UTC)
+	s := &Snapshot{Version: SnapshotVersion, Time: t, Roots: []string{"/data"}}
+	for i := 0; i < n; i++ {
+		path := fmt.Sprintf("/data/f%03d", i)
+		size := int64(100)
+		if path == changed {
+			size = 999
+		}
+		s.Entries = append(s.Entries, SnapshotEntry{Path: path, Size: size, ModTime: mtime})
+	}
+	return s
+}
+
+func TestHistoryAppendAndLoad(t *testing.T) {
+	h := NewHistory(filepath.Join(t.TempDir(), "history"), 3)
+
+	want := []*Snapshot{
+		historyTestSnapshot(1, 10, ""),
+		historyTestSnapshot(2, 12, "/data/f003"), // two added, one changed
+		historyTestSnapshot(3, 8, ""),            // four deleted
+		historyTestSnapshot(4, 9, "/data/f000"),  // new full
+		historyTestSnapshot(5, 11, "/data/f010"), // delta
+	}
+	for _, s := range want {
+		if err := h.Append(s); err != nil {
+			t.Fatalf("Append failed: %v", err)
+		}
+	}
+
+	files, err := h.files()
+	if err != nil {
+		t.Fatalf("files failed: %v", err)
+	}
+	var kinds []bool
+	for _, f := range files {
+		kinds = append(kinds, f.full)
+	}
+	if fmt.Sprint(kinds) != "[true false false true false]" {
+		t.Errorf("full/delta layout: got %v", kinds)
+	}
+
+	got, err := h.All()
+	if err != nil {
+		t.Fatalf("All failed: %v", err)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d snapshots, want %d", len(got), len(want))
+	}
+	for i := range want {
+		assertSnapshotsEqual(t, fmt.Sprintf("snapshot %d", i), got[i], want[i])
+	}
+
+	latest, err := h.Latest()
+	if err != nil {
+		t.Fatalf("Latest failed: %v", err)
+	}
+	assertSnapshotsEqual(t, "latest", latest, want[len(want)-1])
+}
+
+func TestHistoryDeltaIsSmall(t *testing.T) {
+	h := NewHistory(t.TempDir(), 10)
+	if err := h.Append(historyTestSnapshot(1, 500, "")); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+	if err := h.Append(historyTestSnapshot(2, 500, "/data/f007")); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+
+	full, err := os.Stat(filepath.Join(h.Dir, "00000001.full.json"))
+	if err != nil {
+		t.Fatalf("stat full: %v", err)
+	}
+	delta, err := os.Stat(filepath.Join(h.Dir, "00000002.delta.json"))
+	if err != nil {
+		t.Fatalf("stat delta: %v", err)
+	}
+	if delta.Size()*50 > full.Size() {
+		t.Errorf("delta is %d bytes, full is %d bytes; expected delta to be much smaller", delta.Size(), full.Size())
+	}
+}
+
+func TestHistoryCompact(t *testing.T) {
+	h := NewHistory(filepath.Join(t.TempDir(), "history"), 2)
+
+	var want []*Snapshot
+	for day := 1; day <= 5; day++ {
+		s := historyTestSnapshot(day, 5+day, "")
+		want = append(want, s)
+		if err := h.Append(s); err != nil {
+			t.Fatalf("Append failed: %v", err)
+		}
+	}
+
+	if err := h.Compact(3); err != nil {
+		t.Fatalf("Compact failed: %v", err)
+	}
+
+	got, err := h.All()
+	if err != nil {
+		t.Fatalf("All failed: %v", err)
+	}
+	if len(got) != 3 {
+		t.Fatalf("got %d snapshots after compaction, want 3", len(got))
+	}
+	for i, s := range got {
+		assertSnapshotsEqual(t, fmt.Sprintf("snapshot %d", i), s, want[2+i])
+	}
+
+	files, err := h.files()
+	if err != nil {
+		t.Fatalf("files failed: %v", err)
+	}
+	if files[0].seq != 1 || !files[0].full {
+		t.Errorf("first file after compaction: got %+v, want full snapshot 1", files[0])
+	}
+	if _, err := os.Stat(h.Dir + ".old"); !os.IsNotExist(err) {
+		t.Errorf("old history directory should be removed, stat err: %v", err)
+	}
+}
+
+func TestHistoryEmpty(t *testing.T) {
+	h := NewHistory(filepath.Join(t.TempDir(), "missing"), 0)
+	if h.FullEvery != DefaultHistoryFullEvery {
+		t.Errorf("FullEvery: got %d, want %d", h.FullEvery, DefaultHistoryFullEvery)
+	}
+
+	latest, err := h.Latest()
+	if err != nil || latest != nil {
+		t.Errorf("Latest on empty history: got (%v, %v), want (nil, nil)", latest, err)
+	}
+	if err := h.Compact(1); err != nil {
+		t.Errorf("Compact on missing history: %v", err)
+	}
+}
+
+func assertSnapshotsEqual(t *testing.T, label string, got, want *Snapshot) {
+	t.Helper()
+	if !got.Time.Equal(want.Time) {
+		t.Errorf("%s: time %v, want %v", label, got.Time, want.Time)
+	}
+	if len(got.Entries) != len(want.Entries) {
+		t.Errorf("%s: %d entries, want %d", label, len(got.Entries), len(want.Entries))
+		return
+	}
+	for i := range want.Entries {
+		if !got.Entries[i].equal(want.Entries[i]) {
+			t.Errorf("%s: entry %d = %+v, want %+v", label, i, got.Entries[i], want.Entries[i])
+		}
+	}
+}
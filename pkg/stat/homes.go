@@ -0,0 +1,124 @@
+package stat
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/otuschhoff/cwalk/pkg/sftp"
+)
+
+// HomeStat summarizes a user home: a directory directly below a scanned
+// root, such as /home/alice when /home is scanned.
+type HomeStat struct {
+	Path        string    // Full path of the home
+	UID         uint32    // Owner of the home directory
+	Owner       string    // Name of UID, "uid:<uid>" if it does not resolve or the home is remote
+	Size        int64     // Size of the home and the entries below it
+	Inodes      int64     // Count of the home and the entries below it
+	OldestPath  string    // Full path of the least recently modified file (empty if none)
+	OldestMtime time.Time // Modification time of OldestPath
+	Foreign     int64     // Entries below the home owned by another user
+	ForeignSize int64     // Size of the Foreign entries
+	Warnings    []string  // Owner mismatches, see Homes
+}
+
+// Homes treats each directory directly below a scanned root as a user home
+// and summarizes it, sorted by path. Entries directly below a root that
+// are not directories are left out.
+//
+// Warnings flag owner mismatches: a home owned by a user other than the
+// one it is named after, a home owned by a UID that does not resolve to a
+// user, and each other user owning entries below the home, with the most
+// entries first. Owners of homes under sftp:// roots are not resolved, so
+// only the last kind is reported for them.
+func (r *Results) Homes() []HomeStat {
+	homes := make(map[string]*homeStats)
+	key := func(root, name string) string { return root + "\x00" + name }
+
+	// Homes first, so their owners are known when counting the entries
+	for _, fi := range r.AllFileInfos {
+		if fi.IsDir && fi.Path != "" && !strings.Contains(fi.Path, "/") {
+			homes[key(fi.Root, fi.Path)] = &homeStats{
+				HomeStat: HomeStat{Path: fi.FullPath(), UID: fi.UID},
+				name:     fi.Path,
+				remote:   sftp.IsURL(fi.Root),
+				foreign:  make(map[uint32]int64),
+			}
+		}
+	}
+
+	for _, fi := range r.AllFileInfos {
+		name, _, _ := strings.Cut(fi.Path, "/")
+		h, ok := homes[key(fi.Root, name)]
+		if !ok {
+			continue
+		}
+		h.Size += fi.Size
+		h.Inodes++
+		if fi.UID != h.UID {
+			h.Foreign++
+			h.ForeignSize += fi.Size
+			h.foreign[fi.UID]++
+		}
+		if fi.Mode.IsRegular() && !fi.ModTime.IsZero() && (h.OldestPath == "" || fi.ModTime.Before(h.OldestMtime)) {
+			h.OldestPath, h.OldestMtime = fi.FullPath(), fi.ModTime
+		}
+	}
+
+	result := make([]HomeStat, 0, len(homes))
+	for _, h := range homes {
+		h.Owner = h.owner(h.UID)
+		h.Warnings = h.warnings()
+		result = append(result, h.HomeStat)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Path < result[j].Path
+	})
+	return result
+}
+
+// homeStats accumulates a HomeStat.
+type homeStats struct {
+	HomeStat
+	name    string           // Name of the home directory
+	remote  bool             // Under an sftp:// root
+	foreign map[uint32]int64 // Entries per other owner
+}
+
+// owner returns the name of uid, not resolved for remote homes.
+func (h *homeStats) owner(uid uint32) string {
+	if h.remote {
+		return fmt.Sprintf("uid:%d", uid)
+	}
+	return Username(uid)
+}
+
+// warnings returns the owner mismatches of the home, see Homes.
+func (h *homeStats) warnings() []string {
+	var warnings []string
+	if !h.remote && !DefaultResolver().Numeric() {
+		switch {
+		case strings.HasPrefix(h.Owner, "uid:"):
+			warnings = append(warnings, fmt.Sprintf("owner %s does not resolve to a user", h.Owner))
+		case h.Owner != h.name:
+			warnings = append(warnings, fmt.Sprintf("owned by %s, not %s", h.Owner, h.name))
+		}
+	}
+
+	uids := make([]uint32, 0, len(h.foreign))
+	for uid := range h.foreign {
+		uids = append(uids, uid)
+	}
+	sort.Slice(uids, func(i, j int) bool {
+		if h.foreign[uids[i]] != h.foreign[uids[j]] {
+			return h.foreign[uids[i]] > h.foreign[uids[j]]
+		}
+		return uids[i] < uids[j]
+	})
+	for _, uid := range uids {
+		warnings = append(warnings, fmt.Sprintf("%d entries owned by %s", h.foreign[uid], h.owner(uid)))
+	}
+	return warnings
+}
@@ -0,0 +1,69 @@
+package stat
+
+import (
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestHomes(t *testing.T) {
+	const orphanUID = 3999999 // Not expected to resolve to a user
+	old := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	recent := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	results := &Results{AllFileInfos: []FileInfo{
+		{Root: "/home", Path: "", Mode: os.ModeDir | 0o755, IsDir: true},
+		{Root: "/home", Path: "README", Mode: 0o644, Size: 10},
+		{Root: "/home", Path: "root", Mode: os.ModeDir | 0o700, IsDir: true, Size: 4096},
+		{Root: "/home", Path: "root/new", Mode: 0o644, Size: 100, ModTime: recent},
+		{Root: "/home", Path: "root/old", Mode: 0o644, Size: 200, ModTime: old},
+		{Root: "/home", Path: "root/dir", Mode: os.ModeDir | 0o755, IsDir: true, ModTime: old.Add(-time.Hour)},
+		{Root: "/home", Path: "root/dir/x", Mode: 0o644, Size: 5, UID: orphanUID, ModTime: recent},
+		{Root: "/home", Path: "root/dir/y", Mode: 0o644, Size: 5, UID: orphanUID, ModTime: recent},
+		{Root: "/home", Path: "root/dir/z", Mode: 0o644, Size: 5, UID: 2, ModTime: recent},
+		{Root: "/home", Path: "alice", Mode: os.ModeDir | 0o700, IsDir: true},
+		{Root: "/home", Path: "ghost", Mode: os.ModeDir | 0o700, IsDir: true, UID: orphanUID},
+		{Root: "sftp://host/home", Path: "bob", Mode: os.ModeDir | 0o700, IsDir: true, UID: 1000},
+		{Root: "sftp://host/home", Path: "bob/f", Mode: 0o644, UID: 1001},
+	}}
+
+	homes := results.Homes()
+	var paths []string
+	for _, h := range homes {
+		paths = append(paths, h.Path)
+	}
+	if want := []string{"/home/alice", "/home/ghost", "/home/root", "sftp://host/home/bob"}; !reflect.DeepEqual(paths, want) {
+		t.Fatalf("homes = %v, want %v", paths, want)
+	}
+
+	root := homes[2]
+	if root.Owner != "root" || homes[3].Owner != "uid:1000" {
+		t.Errorf("owners %q and %q, want root and uid:1000", root.Owner, homes[3].Owner)
+	}
+	if root.Size != 4096+100+200+15 || root.Inodes != 7 {
+		t.Errorf("root: size %d, inodes %d", root.Size, root.Inodes)
+	}
+	if root.OldestPath != "/home/root/old" || !root.OldestMtime.Equal(old) {
+		t.Errorf("root: oldest %s at %v, want /home/root/old", root.OldestPath, root.OldestMtime)
+	}
+	if root.Foreign != 3 || root.ForeignSize != 15 {
+		t.Errorf("root: %d foreign entries of %d bytes, want 3 of 15", root.Foreign, root.ForeignSize)
+	}
+	if len(root.Warnings) != 2 || root.Warnings[0] != "2 entries owned by uid:3999999" {
+		t.Errorf("root: warnings %q", root.Warnings)
+	}
+
+	if want := []string{"owned by root, not alice"}; !reflect.DeepEqual(homes[0].Warnings, want) {
+		t.Errorf("alice: warnings %q, want %q", homes[0].Warnings, want)
+	}
+	if homes[0].OldestPath != "" {
+		t.Errorf("alice: oldest %q in an empty home", homes[0].OldestPath)
+	}
+	if want := []string{"owner uid:3999999 does not resolve to a user"}; !reflect.DeepEqual(homes[1].Warnings, want) {
+		t.Errorf("ghost: warnings %q, want %q", homes[1].Warnings, want)
+	}
+	if want := []string{"1 entries owned by uid:1001"}; !reflect.DeepEqual(homes[3].Warnings, want) {
+		t.Errorf("bob: warnings %q, want %q", homes[3].Warnings, want)
+	}
+}
@@ -0,0 +1,170 @@
+package stat
+
+import (
+	"encoding/binary"
+	"sort"
+)
+
+// lustreLOVXattr is the extended attribute holding the layout of a file on
+// Lustre, in the lov_user_md format LL_IOC_LOV_GETSTRIPE returns.
+const lustreLOVXattr = "lustre.lov"
+
+// Magic numbers of Lustre layouts
+const (
+	lovMagicV1     = 0x0BD10BD0 // Plain layout
+	lovMagicV3     = 0x0BD30BD0 // Plain layout with an OST pool name
+	lovMagicCompV1 = 0x0BD60BD0 // Composite (progressive file) layout of plain components
+)
+
+// lcmeFlagInit flags the components of a composite layout whose objects
+// are allocated.
+const lcmeFlagInit = 0x10
+
+// Stripe is the layout of a file striped over Lustre OSTs.
+type Stripe struct {
+	Count      int   // OSTs the file is striped over
+	Size       int64 // Bytes written to one OST before moving on to the next
+	Components int   // Components of a composite layout, 0 for a plain one
+}
+
+// SetLustre makes the walk read the project ID of every file and
+// directory, as used by project quotas, with the FS_IOC_FSGETXATTR ioctl,
+// and the Lustre stripe layout of every regular file, for
+// Results.ByProjectID and Results.Striping. Project IDs are also set on
+// ext4 and XFS; stripes only on Lustre. This costs an open and an ioctl
+// per entry plus a getxattr call per file. Only supported on Linux.
+func (sw *StatsWalker) SetLustre(lustre bool) {
+	sw.lustre = lustre
+}
+
+// parseLOV parses a layout in the lov_user_md format. For a composite
+// layout, the stripe is the one of its last allocated component, which
+// holds the end of the file. Returns false for layouts it does not know.
+func parseLOV(b []byte) (Stripe, bool) {
+	le := binary.LittleEndian
+	if len(b) < 4 {
+		return Stripe{}, false
+	}
+	switch le.Uint32(b) {
+	case lovMagicV1, lovMagicV3:
+		// magic, pattern, object ID, stripe size, stripe count
+		if len(b) < 30 {
+			return Stripe{}, false
+		}
+		return Stripe{Count: int(le.Uint16(b[28:])), Size: int64(le.Uint32(b[24:]))}, true
+
+	case lovMagicCompV1:
+		// Header of 32 bytes with the entry count at 14, then entries of
+		// 48 bytes with the flags at 4 and the component offset at 24
+		if len(b) < 32 {
+			return Stripe{}, false
+		}
+		n := int(le.Uint16(b[14:]))
+		stripe, found := Stripe{Components: n}, false
+		for i := range n {
+			entry := 32 + 48*i
+			if len(b) < entry+48 {
+				return Stripe{}, false
+			}
+			if le.Uint32(b[entry+4:])&lcmeFlagInit == 0 {
+				continue
+			}
+			offset := int(le.Uint32(b[entry+24:]))
+			if offset < 0 || offset > len(b) {
+				return Stripe{}, false
+			}
+			if s, ok := parseLOV(b[offset:]); ok && s.Components == 0 {
+				stripe.Count, stripe.Size, found = s.Count, s.Size, true
+			}
+		}
+		return stripe, found
+	}
+	return Stripe{}, false
+}
+
+// ProjectIDStat holds the statistics of the entries with one project ID.
+type ProjectIDStat struct {
+	ProjectID uint32 // Project ID, 0 for entries without one
+	Size      int64  // Total size of the entries
+	Inodes    int64  // Count of the entries
+	Files     int64  // Count of regular files
+}
+
+// ByProjectID sums up the entries by project ID, the largest first. Project
+// IDs are only read by walks with SetLustre.
+func (r *Results) ByProjectID() []ProjectIDStat {
+	byID := make(map[uint32]*ProjectIDStat)
+	for _, fi := range r.AllFileInfos {
+		s, ok := byID[fi.ProjectID]
+		if !ok {
+			s = &ProjectIDStat{ProjectID: fi.ProjectID}
+			byID[fi.ProjectID] = s
+		}
+		s.Size += fi.Size
+		s.Inodes++
+		if fi.Mode.IsRegular() {
+			s.Files++
+		}
+	}
+
+	result := make([]ProjectIDStat, 0, len(byID))
+	for _, s := range byID {
+		result = append(result, *s)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Size != result[j].Size {
+			return result[i].Size > result[j].Size
+		}
+		return result[i].ProjectID < result[j].ProjectID
+	})
+	return result
+}
+
+// StripeStat holds the statistics of the regular files with one stripe
+// count and size.
+type StripeStat struct {
+	Count   int   // Stripe count, 0 for files without a known layout
+	Size    int64 // Stripe size in bytes
+	Files   int64 // Count of the files
+	Bytes   int64 // Total size of the files
+	Largest int64 // Size of the largest file
+}
+
+// Striping returns the distribution of the regular files over stripe
+// counts and sizes, ordered by stripe count and then size, with the files
+// without a known layout first. Large files on a single stripe are a
+// common cause of unbalanced OSTs and slow parallel IO. Stripes are only
+// read by walks with SetLustre.
+func (r *Results) Striping() []StripeStat {
+	type key struct {
+		count int
+		size  int64
+	}
+	byStripe := make(map[key]*StripeStat)
+	for _, fi := range r.AllFileInfos {
+		if !fi.Mode.IsRegular() {
+			continue
+		}
+		k := key{fi.StripeCount, fi.StripeSize}
+		s, ok := byStripe[k]
+		if !ok {
+			s = &StripeStat{Count: k.count, Size: k.size}
+			byStripe[k] = s
+		}
+		s.Files++
+		s.Bytes += fi.Size
+		s.Largest = max(s.Largest, fi.Size)
+	}
+
+	result := make([]StripeStat, 0, len(byStripe))
+	for _, s := range byStripe {
+		result = append(result, *s)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count < result[j].Count
+		}
+		return result[i].Size < result[j].Size
+	})
+	return result
+}
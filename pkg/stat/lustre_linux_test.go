@@ -0,0 +1,48 @@
+package stat
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// TestWalkProjectIDs verifies that project IDs are read when enabled, on
+// file systems with project quota support.
+func TestWalkProjectIDs(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, "a")
+	if err := os.WriteFile(path, []byte("data"), 0644); err != nil {
+		t.Fatalf("failed to create file: %v", err)
+	}
+
+	// FS_IOC_FSSETXATTR: _IOW('X', 32, struct fsxattr)
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var attr fsxattr
+	_, _, errno := unix.Syscall(unix.SYS_IOCTL, f.Fd(), fsIocFSGetXattr, uintptr(unsafe.Pointer(&attr)))
+	if errno == 0 {
+		attr.projid = 42
+		_, _, errno = unix.Syscall(unix.SYS_IOCTL, f.Fd(), 0x401c5820, uintptr(unsafe.Pointer(&attr)))
+	}
+	f.Close()
+	if errno != 0 {
+		t.Skipf("project IDs not supported: %v", errno)
+	}
+
+	walker := NewStatsWalker([]string{root}, 2, nil)
+	walker.SetLustre(true)
+	res, err := walker.Walk()
+	if err != nil {
+		t.Fatalf("walk failed: %v", err)
+	}
+	for _, fi := range res.AllFileInfos {
+		if fi.Path == "a" && fi.ProjectID != 42 {
+			t.Errorf("project ID of a = %d, want 42", fi.ProjectID)
+		}
+	}
+}
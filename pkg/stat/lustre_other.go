@@ -0,0 +1,6 @@
+//go:build !linux
+
+package stat
+
+// readLustre is not supported on this platform.
+func readLustre(path string, fi *FileInfo) {}
@@ -0,0 +1,81 @@
+package stat
+
+import (
+	"encoding/binary"
+	"os"
+	"reflect"
+	"testing"
+)
+
+// lovV1 returns a plain layout in the lov_user_md_v1 format.
+func lovV1(count uint16, size uint32) []byte {
+	b := make([]byte, 32)
+	binary.LittleEndian.PutUint32(b, lovMagicV1)
+	binary.LittleEndian.PutUint32(b[24:], size)
+	binary.LittleEndian.PutUint16(b[28:], count)
+	return b
+}
+
+func TestParseLOV(t *testing.T) {
+	if got, ok := parseLOV(lovV1(4, 1<<20)); !ok || got != (Stripe{Count: 4, Size: 1 << 20}) {
+		t.Errorf("plain layout: %+v, %v", got, ok)
+	}
+
+	// Composite layout of three components, the last one not allocated
+	header := 32 + 3*48
+	b := make([]byte, header)
+	binary.LittleEndian.PutUint32(b, lovMagicCompV1)
+	binary.LittleEndian.PutUint16(b[14:], 3)
+	for i, c := range []struct {
+		flags uint32
+		lov   []byte
+	}{{lcmeFlagInit, lovV1(1, 1<<20)}, {lcmeFlagInit, lovV1(8, 4<<20)}, {0, lovV1(64, 4<<20)}} {
+		entry := 32 + 48*i
+		binary.LittleEndian.PutUint32(b[entry+4:], c.flags)
+		binary.LittleEndian.PutUint32(b[entry+24:], uint32(len(b)))
+		b = append(b, c.lov...)
+	}
+	if got, ok := parseLOV(b); !ok || got != (Stripe{Count: 8, Size: 4 << 20, Components: 3}) {
+		t.Errorf("composite layout: %+v, %v", got, ok)
+	}
+
+	for name, b := range map[string][]byte{
+		"empty":     nil,
+		"unknown":   {1, 2, 3, 4, 5, 6, 7, 8},
+		"truncated": lovV1(4, 1<<20)[:20],
+		"bad entry": b[:40],
+	} {
+		if got, ok := parseLOV(b); ok {
+			t.Errorf("%s: parsed as %+v", name, got)
+		}
+	}
+}
+
+func TestByProjectIDAndStriping(t *testing.T) {
+	results := &Results{AllFileInfos: []FileInfo{
+		{Path: "d", Mode: os.ModeDir | 0o755, IsDir: true, ProjectID: 7},
+		{Path: "d/a", Mode: 0o644, Size: 100, ProjectID: 7, StripeCount: 1, StripeSize: 1 << 20},
+		{Path: "d/b", Mode: 0o644, Size: 300, ProjectID: 7, StripeCount: 1, StripeSize: 1 << 20},
+		{Path: "c", Mode: 0o644, Size: 1000, ProjectID: 9, StripeCount: 4, StripeSize: 1 << 20},
+		{Path: "l", Mode: os.ModeSymlink | 0o777, IsSymlink: true, Size: 5},
+		{Path: "e", Mode: 0o644, Size: 2},
+	}}
+
+	want := []ProjectIDStat{
+		{ProjectID: 9, Size: 1000, Inodes: 1, Files: 1},
+		{ProjectID: 7, Size: 400, Inodes: 3, Files: 2},
+		{ProjectID: 0, Size: 7, Inodes: 2, Files: 1},
+	}
+	if got := results.ByProjectID(); !reflect.DeepEqual(got, want) {
+		t.Errorf("by project ID = %+v, want %+v", got, want)
+	}
+
+	stripes := []StripeStat{
+		{Count: 0, Size: 0, Files: 1, Bytes: 2, Largest: 2},
+		{Count: 1, Size: 1 << 20, Files: 2, Bytes: 400, Largest: 300},
+		{Count: 4, Size: 1 << 20, Files: 1, Bytes: 1000, Largest: 1000},
+	}
+	if got := results.Striping(); !reflect.DeepEqual(got, stripes) {
+		t.Errorf("striping = %+v, want %+v", got, stripes)
+	}
+}
@@ -0,0 +1,123 @@
+package stat
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// FSStat holds statistics grouped by the file system (device) entries
+// reside on, so trees spanning several volumes can be broken down by
+// mount point.
+type FSStat struct {
+	Dev         uint64 // Device number (volume serial number on Windows, 0 if unknown)
+	Mountpoint  string // Mount point of the file system, or the topmost walked path on it
+	Type        string // File system type, e.g. "ext4" (empty if unknown)
+	Source      string // Mounted device or remote share, e.g. "/dev/sda1" (empty if unknown)
+	TotalSize   int64  // Total size of entries on the file system
+	TotalInodes int64  // Total count of inodes on the file system
+	Files       int64  // Count of regular files
+	Dirs        int64  // Count of directories
+	Symlinks    int64  // Count of symbolic links
+	Others      int64  // Count of other inode types
+
+	top string // Shortest full path seen on the device
+}
+
+// mountInfo describes a mounted file system as listed by the mount table.
+type mountInfo struct {
+	Mountpoint string
+	Type       string
+	Source     string
+}
+
+// add records an entry of the given type ("file", "dir", "symlink" or
+// "other") found at fullPath.
+func (s *FSStat) add(fileType, fullPath string, size int64) {
+	s.TotalInodes++
+	s.TotalSize += size
+	switch fileType {
+	case "file":
+		s.Files++
+	case "dir":
+		s.Dirs++
+	case "symlink":
+		s.Symlinks++
+	default:
+		s.Others++
+	}
+	if s.top == "" || len(fullPath) < len(s.top) {
+		s.top = fullPath
+	}
+}
+
+// merge folds the statistics of other into s.
+func (s *FSStat) merge(other *FSStat) {
+	s.TotalSize += other.TotalSize
+	s.TotalInodes += other.TotalInodes
+	s.Files += other.Files
+	s.Dirs += other.Dirs
+	s.Symlinks += other.Symlinks
+	s.Others += other.Others
+	if s.top == "" || (other.top != "" && len(other.top) < len(s.top)) {
+		s.top = other.top
+	}
+}
+
+// resolveMounts fills in the mount point, type and source of each file
+// system from the mount table. File systems missing from the table, and
+// all of them on platforms without one, fall back to the topmost path
+// walked on them.
+func resolveMounts(byFS map[uint64]*FSStat) {
+	mounts := readMounts()
+	for dev, s := range byFS {
+		s.Mountpoint = s.top
+		if dev == 0 {
+			continue
+		}
+		if m, ok := pickMount(mounts[dev], s.top); ok {
+			s.Mountpoint, s.Type, s.Source = m.Mountpoint, m.Type, m.Source
+		}
+	}
+}
+
+// pickMount returns the mount among candidates, which share a device, that
+// contains path. Bind mounts list the same device several times, so the
+// longest containing mount point wins; the first candidate is used if
+// none contains path.
+func pickMount(candidates []mountInfo, path string) (mountInfo, bool) {
+	if len(candidates) == 0 {
+		return mountInfo{}, false
+	}
+	best, bestLen := candidates[0], -1
+	for _, m := range candidates {
+		if isWithin(path, m.Mountpoint) && len(m.Mountpoint) > bestLen {
+			best, bestLen = m, len(m.Mountpoint)
+		}
+	}
+	return best, true
+}
+
+// isWithin reports whether path is dir or below it.
+func isWithin(path, dir string) bool {
+	path, dir = filepath.Clean(path), filepath.Clean(dir)
+	if path == dir || dir == string(filepath.Separator) {
+		return true
+	}
+	return strings.HasPrefix(path, dir+string(filepath.Separator))
+}
+
+// SortedFS returns the per-file-system statistics ordered by mount point.
+func (r *Results) SortedFS() []*FSStat {
+	out := make([]*FSStat, 0, len(r.ByFS))
+	for _, s := range r.ByFS {
+		out = append(out, s)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Mountpoint != out[j].Mountpoint {
+			return out[i].Mountpoint < out[j].Mountpoint
+		}
+		return out[i].Dev < out[j].Dev
+	})
+	return out
+}
@@ -0,0 +1,84 @@
+package stat
+
+import (
+	"bufio"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// readMounts parses /proc/self/mountinfo into mounts by device number.
+// Returns nil if the mount table cannot be read.
+func readMounts() map[uint64][]mountInfo {
+	f, err := os.Open("/proc/self/mountinfo")
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	mounts := make(map[uint64][]mountInfo)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if dev, m, ok := parseMountInfoLine(scanner.Text()); ok {
+			mounts[dev] = append(mounts[dev], m)
+		}
+	}
+	return mounts
+}
+
+// parseMountInfoLine parses a line of /proc/self/mountinfo, for example
+//
+//	36 35 98:0 /mnt1 /mnt/parent rw,noatime master:1 - ext3 /dev/root rw,errors=continue
+//
+// The optional fields before the "-" separator vary in number.
+func parseMountInfoLine(line string) (uint64, mountInfo, bool) {
+	fields := strings.Fields(line)
+	sep := -1
+	for i, field := range fields {
+		if field == "-" {
+			sep = i
+			break
+		}
+	}
+	if sep < 5 || len(fields) < sep+3 {
+		return 0, mountInfo{}, false
+	}
+
+	majorStr, minorStr, ok := strings.Cut(fields[2], ":")
+	if !ok {
+		return 0, mountInfo{}, false
+	}
+	major, err1 := strconv.ParseUint(majorStr, 10, 32)
+	minor, err2 := strconv.ParseUint(minorStr, 10, 32)
+	if err1 != nil || err2 != nil {
+		return 0, mountInfo{}, false
+	}
+
+	return unix.Mkdev(uint32(major), uint32(minor)), mountInfo{
+		Mountpoint: unescapeMountPath(fields[4]),
+		Type:       fields[sep+1],
+		Source:     unescapeMountPath(fields[sep+2]),
+	}, true
+}
+
+// unescapeMountPath decodes the octal escapes (\040 for a space and so on)
+// the kernel uses for whitespace and backslashes in mount table paths.
+func unescapeMountPath(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+3 < len(s) {
+			if v, err := strconv.ParseUint(s[i+1:i+4], 8, 8); err == nil {
+				b.WriteByte(byte(v))
+				i += 3
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
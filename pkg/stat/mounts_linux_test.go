@@ -0,0 +1,24 @@
+package stat
+
+import (
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+func TestParseMountInfoLine(t *testing.T) {
+	dev, m, ok := parseMountInfoLine(`36 35 98:0 /mnt1 /mnt/my\040disk rw,noatime master:1 shared:2 - ext3 /dev/root rw,errors=continue`)
+	if !ok {
+		t.Fatal("parseMountInfoLine failed")
+	}
+	if dev != unix.Mkdev(98, 0) {
+		t.Errorf("dev = %d, want 98:0", dev)
+	}
+	if m.Mountpoint != "/mnt/my disk" || m.Type != "ext3" || m.Source != "/dev/root" {
+		t.Errorf("got %+v", m)
+	}
+
+	if _, _, ok := parseMountInfoLine("36 35 98:0 /mnt1"); ok {
+		t.Error("parseMountInfoLine should reject truncated lines")
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !linux
+
+package stat
+
+// readMounts returns no mounts on platforms without a supported mount
+// table; file systems are then identified by the topmost walked path.
+func readMounts() map[uint64][]mountInfo {
+	return nil
+}
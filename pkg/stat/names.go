@@ -0,0 +1,241 @@
+package stat
+
+import (
+	"bufio"
+	"container/list"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultNameCacheSize is the number of user and of group names the
+// default resolver keeps.
+const DefaultNameCacheSize = 65536
+
+// defaultResolver backs Username and Groupname.
+var defaultResolver = NewNameResolver(DefaultNameCacheSize)
+
+// Username returns the name of the user with the given UID, or "uid:<uid>"
+// if it cannot be resolved. Results are cached.
+func Username(uid uint32) string {
+	return defaultResolver.Username(uid)
+}
+
+// Groupname returns the name of the group with the given GID, or
+// "gid:<gid>" if it cannot be resolved. Results are cached.
+func Groupname(gid uint32) string {
+	return defaultResolver.Groupname(gid)
+}
+
+// DefaultResolver returns the resolver used by Username and Groupname, for
+// preloading names before a walk.
+func DefaultResolver() *NameResolver {
+	return defaultResolver
+}
+
+// NameResolver resolves UIDs and GIDs to names. Lookups can hit NSS, LDAP
+// or SSSD and take milliseconds each, so resolved names are kept in LRU
+// caches, one for users and one for groups. A NameResolver is safe for
+// concurrent use; lookups run outside its lock, so two goroutines missing
+// the cache for the same ID at the same time may both look it up.
+type NameResolver struct {
+	mu      sync.Mutex
+	users   *nameCache
+	groups  *nameCache
+	numeric atomic.Bool // Skip lookups and return IDs as names
+}
+
+// NewNameResolver returns a resolver caching up to size user names and
+// size group names.
+func NewNameResolver(size int) *NameResolver {
+	return &NameResolver{
+		users:  newNameCache(size),
+		groups: newNameCache(size),
+	}
+}
+
+// SetNumeric makes the resolver skip all lookups and return IDs in decimal
+// as names, like ls -n. Lookups for thousands of UIDs through LDAP or SSSD
+// can otherwise dominate the scan time.
+func (r *NameResolver) SetNumeric(numeric bool) {
+	r.numeric.Store(numeric)
+}
+
+// Numeric reports whether the resolver returns IDs instead of names.
+func (r *NameResolver) Numeric() bool {
+	return r.numeric.Load()
+}
+
+// Username returns the name of the user with the given UID, or "uid:<uid>"
+// if it cannot be resolved. In numeric mode it returns the UID.
+func (r *NameResolver) Username(uid uint32) string {
+	if r.Numeric() {
+		return strconv.FormatUint(uint64(uid), 10)
+	}
+	return r.resolve(r.users, uid, lookupUsername)
+}
+
+// Groupname returns the name of the group with the given GID, or
+// "gid:<gid>" if it cannot be resolved. In numeric mode it returns the GID.
+func (r *NameResolver) Groupname(gid uint32) string {
+	if r.Numeric() {
+		return strconv.FormatUint(uint64(gid), 10)
+	}
+	return r.resolve(r.groups, gid, lookupGroupname)
+}
+
+// resolve returns the cached name of id, looking it up on a miss.
+func (r *NameResolver) resolve(c *nameCache, id uint32, lookup func(uint32) string) string {
+	r.mu.Lock()
+	name, ok := c.get(id)
+	r.mu.Unlock()
+	if ok {
+		return name
+	}
+
+	name = lookup(id)
+	r.mu.Lock()
+	c.put(id, name)
+	r.mu.Unlock()
+	return name
+}
+
+// Preload resolves the given UIDs and GIDs with up to workers concurrent
+// lookups, so that a batch of IDs costs about one round trip to a slow
+// directory service per worker instead of one per ID in sequence. IDs
+// already cached are skipped, and nothing is looked up in numeric mode.
+func (r *NameResolver) Preload(uids, gids []uint32, workers int) {
+	if r.Numeric() {
+		return
+	}
+	if workers < 1 {
+		workers = 1
+	}
+
+	type job struct {
+		id    uint32
+		group bool
+	}
+	jobs := make(chan job)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				if j.group {
+					r.Groupname(j.id)
+				} else {
+					r.Username(j.id)
+				}
+			}
+		}()
+	}
+	for _, uid := range uids {
+		jobs <- job{id: uid}
+	}
+	for _, gid := range gids {
+		jobs <- job{id: gid, group: true}
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// LoadPasswd caches the user names listed in a passwd(5) file such as
+// /etc/passwd. Users from other NSS sources are still looked up on demand.
+func (r *NameResolver) LoadPasswd(path string) error {
+	return r.loadFile(r.users, path)
+}
+
+// LoadGroup caches the group names listed in a group(5) file such as
+// /etc/group. Groups from other NSS sources are still looked up on demand.
+func (r *NameResolver) LoadGroup(path string) error {
+	return r.loadFile(r.groups, path)
+}
+
+// loadFile caches the names of a passwd or group file. Both have the name
+// in the first and the numeric ID in the third colon-separated field. Like
+// NSS, the first entry for an ID wins.
+func (r *NameResolver) loadFile(c *nameCache, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	seen := make(map[uint32]bool)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || line[0] == '#' || line[0] == '+' || line[0] == '-' {
+			continue
+		}
+		fields := strings.SplitN(line, ":", 4)
+		if len(fields) < 3 || fields[0] == "" {
+			continue
+		}
+		id, err := strconv.ParseUint(fields[2], 10, 32)
+		if err != nil || seen[uint32(id)] {
+			continue
+		}
+		seen[uint32(id)] = true
+
+		r.mu.Lock()
+		c.put(uint32(id), fields[0])
+		r.mu.Unlock()
+	}
+	return scanner.Err()
+}
+
+// nameCache is a fixed-size LRU cache of names by ID. It is not safe for
+// concurrent use.
+type nameCache struct {
+	size  int
+	order *list.List // Most recently used first; values are *nameEntry
+	items map[uint32]*list.Element
+}
+
+// nameEntry is a cached name.
+type nameEntry struct {
+	id   uint32
+	name string
+}
+
+func newNameCache(size int) *nameCache {
+	if size < 1 {
+		size = 1
+	}
+	return &nameCache{
+		size:  size,
+		order: list.New(),
+		items: make(map[uint32]*list.Element),
+	}
+}
+
+// get returns the cached name of id and marks it as recently used.
+func (c *nameCache) get(id uint32) (string, bool) {
+	e, ok := c.items[id]
+	if !ok {
+		return "", false
+	}
+	c.order.MoveToFront(e)
+	return e.Value.(*nameEntry).name, true
+}
+
+// put caches the name of id, evicting the least recently used name if the
+// cache is full.
+func (c *nameCache) put(id uint32, name string) {
+	if e, ok := c.items[id]; ok {
+		e.Value.(*nameEntry).name = name
+		c.order.MoveToFront(e)
+		return
+	}
+	if c.order.Len() >= c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*nameEntry).id)
+	}
+	c.items[id] = c.order.PushFront(&nameEntry{id: id, name: name})
+}
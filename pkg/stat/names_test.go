@@ -0,0 +1,106 @@
+package stat
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNameCacheEviction(t *testing.T) {
+	c := newNameCache(2)
+	c.put(1, "one")
+	c.put(2, "two")
+	c.get(1) // Make 2 the least recently used
+	c.put(3, "three")
+
+	if _, ok := c.get(2); ok {
+		t.Error("least recently used entry 2 should have been evicted")
+	}
+	for id, want := range map[uint32]string{1: "one", 3: "three"} {
+		if got, ok := c.get(id); !ok || got != want {
+			t.Errorf("get(%d) = %q, %v, want %q", id, got, ok, want)
+		}
+	}
+
+	c.put(3, "drei")
+	if got, _ := c.get(3); got != "drei" {
+		t.Errorf("updated entry: got %q, want drei", got)
+	}
+	if c.order.Len() != 2 || len(c.items) != 2 {
+		t.Errorf("cache holds %d/%d entries, want 2", c.order.Len(), len(c.items))
+	}
+}
+
+func TestNameResolverLoadFiles(t *testing.T) {
+	dir := t.TempDir()
+	passwd := filepath.Join(dir, "passwd")
+	group := filepath.Join(dir, "group")
+	if err := os.WriteFile(passwd, []byte("# comment\n"+
+		"alice:x:3999998:3999998:Alice:/home/alice:/bin/sh\n"+
+		"alias:x:3999998:3999998::/:/bin/false\n"+
+		"+@netgroup\n"+
+		"broken:x:notanumber:0::/:\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(group, []byte("staff:x:3999997:alice\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewNameResolver(16)
+	if err := r.LoadPasswd(passwd); err != nil {
+		t.Fatalf("LoadPasswd: %v", err)
+	}
+	if err := r.LoadGroup(group); err != nil {
+		t.Fatalf("LoadGroup: %v", err)
+	}
+
+	if got := r.Username(3999998); got != "alice" {
+		t.Errorf("Username(3999998) = %q, want alice (first entry wins)", got)
+	}
+	if got := r.Groupname(3999997); got != "staff" {
+		t.Errorf("Groupname(3999997) = %q, want staff", got)
+	}
+	if got := r.Username(3999999); got != "uid:3999999" {
+		t.Errorf("Username(3999999) = %q, want uid:3999999", got)
+	}
+
+	if err := r.LoadPasswd(filepath.Join(dir, "missing")); err == nil {
+		t.Error("LoadPasswd of a missing file should fail")
+	}
+}
+
+func TestNameResolverPreload(t *testing.T) {
+	r := NewNameResolver(16)
+	r.Preload([]uint32{0, 3999999, 3999999}, []uint32{0}, 4)
+
+	r.mu.Lock()
+	users, groups := len(r.users.items), len(r.groups.items)
+	r.mu.Unlock()
+	if users != 2 || groups != 1 {
+		t.Errorf("cached %d users and %d groups, want 2 and 1", users, groups)
+	}
+	if got, want := r.Username(0), Username(0); got != want {
+		t.Errorf("Username(0) = %q, want %q", got, want)
+	}
+}
+
+func TestNameResolverNumeric(t *testing.T) {
+	r := NewNameResolver(16)
+	r.SetNumeric(true)
+	r.Preload([]uint32{0}, []uint32{0}, 2)
+
+	if got := r.Username(1000); got != "1000" {
+		t.Errorf("Username(1000) = %q, want 1000", got)
+	}
+	if got := r.Groupname(0); got != "0" {
+		t.Errorf("Groupname(0) = %q, want 0", got)
+	}
+	if len(r.users.items) != 0 || len(r.groups.items) != 0 {
+		t.Error("numeric mode should not look up or cache names")
+	}
+
+	r.SetNumeric(false)
+	if got, want := r.Username(0), Username(0); got != want {
+		t.Errorf("Username(0) after numeric mode = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,104 @@
+package stat
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/otuschhoff/cwalk/pkg/sftp"
+)
+
+// OwnershipStat describes who owns a top-level directory: a directory
+// directly below a scanned root, such as /srv/shared when /srv is scanned.
+type OwnershipStat struct {
+	Path      string  // Full path of the directory
+	Size      int64   // Size of the directory and the entries below it
+	Inodes    int64   // Count of the directory and the entries below it
+	Owners    int     // Count of distinct owners
+	UID       uint32  // Dominant owner: the one owning the most bytes
+	Owner     string  // Name of UID, "uid:<uid>" if it does not resolve or the directory is remote
+	OwnerSize int64   // Bytes owned by UID
+	Entropy   float64 // Shannon entropy of the bytes per owner, in bits
+}
+
+// OwnerPct returns the share of the bytes owned by the dominant owner in
+// percent, or 100 for a directory without bytes.
+func (o *OwnershipStat) OwnerPct() float64 {
+	if o.Size == 0 {
+		return 100
+	}
+	return 100 * float64(o.OwnerSize) / float64(o.Size)
+}
+
+// Ownership attributes the entries below each top-level directory to their
+// owners and reports the dominant owner by bytes and the entropy of the
+// ownership, most mixed first. An entropy of 0 means a single owner holds
+// all bytes; n owners holding equal shares give log2(n) bits. Directories
+// of mixed ownership are the ones cleanups cannot simply hand to a single
+// user. Without bytes, the owner of the most entries is dominant. Entries
+// directly below a root that are not directories are left out, and owners
+// below sftp:// roots are not resolved.
+func (r *Results) Ownership() []OwnershipStat {
+	type dirOwners struct {
+		OwnershipStat
+		remote  bool
+		bytes   map[uint32]int64
+		entries map[uint32]int64
+	}
+	dirs := make(map[string]*dirOwners)
+	key := func(root, name string) string { return root + "\x00" + name }
+
+	for _, fi := range r.AllFileInfos {
+		if fi.IsDir && fi.Path != "" && !strings.Contains(fi.Path, "/") {
+			dirs[key(fi.Root, fi.Path)] = &dirOwners{
+				OwnershipStat: OwnershipStat{Path: fi.FullPath()},
+				remote:        sftp.IsURL(fi.Root),
+				bytes:         make(map[uint32]int64),
+				entries:       make(map[uint32]int64),
+			}
+		}
+	}
+
+	for _, fi := range r.AllFileInfos {
+		name, _, _ := strings.Cut(fi.Path, "/")
+		d, ok := dirs[key(fi.Root, name)]
+		if !ok {
+			continue
+		}
+		d.Size += fi.Size
+		d.Inodes++
+		d.bytes[fi.UID] += fi.Size
+		d.entries[fi.UID]++
+	}
+
+	result := make([]OwnershipStat, 0, len(dirs))
+	for _, d := range dirs {
+		d.Owners = len(d.entries)
+		first := true
+		for uid, size := range d.bytes {
+			if first || size > d.OwnerSize ||
+				size == d.OwnerSize && (d.entries[uid] > d.entries[d.UID] || d.entries[uid] == d.entries[d.UID] && uid < d.UID) {
+				d.UID, d.OwnerSize, first = uid, size, false
+			}
+			if size > 0 {
+				p := float64(size) / float64(d.Size)
+				d.Entropy -= p * math.Log2(p)
+			}
+		}
+		// Rounding leaves -0 or tiny values for a single owner
+		d.Entropy = math.Max(0, d.Entropy)
+		d.Owner = Username(d.UID)
+		if d.remote {
+			d.Owner = fmt.Sprintf("uid:%d", d.UID)
+		}
+		result = append(result, d.OwnershipStat)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Entropy != result[j].Entropy {
+			return result[i].Entropy > result[j].Entropy
+		}
+		return result[i].Path < result[j].Path
+	})
+	return result
+}
@@ -0,0 +1,59 @@
+package stat
+
+import (
+	"math"
+	"os"
+	"testing"
+)
+
+func TestOwnership(t *testing.T) {
+	results := &Results{AllFileInfos: []FileInfo{
+		{Root: "/srv", Path: "", Mode: os.ModeDir | 0o755, IsDir: true},
+		{Root: "/srv", Path: "README", Mode: 0o644, Size: 10},
+		{Root: "/srv", Path: "solo", Mode: os.ModeDir | 0o755, IsDir: true},
+		{Root: "/srv", Path: "solo/a", Mode: 0o644, Size: 100},
+		{Root: "/srv", Path: "shared", Mode: os.ModeDir | 0o775, IsDir: true},
+		{Root: "/srv", Path: "shared/a", Mode: 0o644, Size: 300, UID: 1001},
+		{Root: "/srv", Path: "shared/b", Mode: 0o644, Size: 100, UID: 1002},
+		{Root: "/srv", Path: "shared/sub", Mode: os.ModeDir | 0o775, IsDir: true, UID: 1002},
+		{Root: "/srv", Path: "empty", Mode: os.ModeDir | 0o755, IsDir: true, UID: 1003},
+		{Root: "sftp://host/srv", Path: "remote", Mode: os.ModeDir | 0o755, IsDir: true, UID: 1000},
+	}}
+
+	dirs := results.Ownership()
+	var paths []string
+	for _, d := range dirs {
+		paths = append(paths, d.Path)
+	}
+	// Mixed ownership first, then by path
+	if len(dirs) != 4 || dirs[0].Path != "/srv/shared" {
+		t.Fatalf("directories = %q", paths)
+	}
+
+	shared := dirs[0]
+	if shared.UID != 1001 || shared.OwnerSize != 300 || shared.Owners != 3 || shared.Size != 400 || shared.Inodes != 4 {
+		t.Errorf("shared: %+v", shared)
+	}
+	// 300 and 100 bytes: -(0.75 log2 0.75 + 0.25 log2 0.25)
+	if want := 0.8113; math.Abs(shared.Entropy-want) > 0.0001 {
+		t.Errorf("shared: entropy %.4f, want %.4f", shared.Entropy, want)
+	}
+	if pct := shared.OwnerPct(); pct != 75 {
+		t.Errorf("shared: owner share %.1f%%, want 75%%", pct)
+	}
+
+	for _, d := range dirs[1:] {
+		if d.Entropy != 0 || d.Owners != 1 {
+			t.Errorf("%s: entropy %v with %d owners", d.Path, d.Entropy, d.Owners)
+		}
+	}
+	if empty := dirs[1]; empty.Path != "/srv/empty" || empty.UID != 1003 || empty.OwnerPct() != 100 {
+		t.Errorf("empty: %+v", empty)
+	}
+	if solo := dirs[2]; solo.Owner != "root" {
+		t.Errorf("solo: owner %q, want root", solo.Owner)
+	}
+	if remote := dirs[3]; remote.Owner != "uid:1000" {
+		t.Errorf("remote: owner %q, want uid:1000", remote.Owner)
+	}
+}
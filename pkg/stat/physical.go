@@ -0,0 +1,94 @@
+package stat
+
+import (
+	"encoding/binary"
+	"sync"
+	"sync/atomic"
+)
+
+// Btrfs tree items describing the data of a file
+const (
+	btrfsExtentDataKey    = 108 // Key type of the extents of a file, by file offset
+	btrfsFileExtentInline = 0   // Extent stored in the tree item itself
+	btrfsSearchHeaderSize = 32  // struct btrfs_ioctl_search_header
+	btrfsInlineHeaderSize = 21  // struct btrfs_file_extent_item up to the inline data
+	btrfsExtentItemSize   = 53  // struct btrfs_file_extent_item of a regular extent
+)
+
+// physicalReader reads the physical usage of regular files, remembering
+// which devices are Btrfs and whether Btrfs extent searches are permitted.
+type physicalReader struct {
+	btrfs  sync.Map    // Device -> whether it is a Btrfs file system
+	denied atomic.Bool // Btrfs extent searches need CAP_SYS_ADMIN
+}
+
+// SetPhysical makes the walk read the physical usage of every regular file,
+// the bytes its data takes on disk after compression, and sum it up in
+// SummaryStat.PhysicalSize to compare with the logical size. On Btrfs,
+// where stat reports the uncompressed allocation and FIEMAP the
+// uncompressed extent lengths, the compressed extents of each file are
+// looked up in the subvolume tree, which needs CAP_SYS_ADMIN; without it,
+// Btrfs files count with their allocated blocks. Elsewhere the allocated
+// blocks are used, which ZFS reports after compression. Sparse files and
+// block rounding also make physical and logical sizes differ. This costs
+// an open and a tree search per file on Btrfs. Only supported on Linux;
+// other platforms count files as uncompressed.
+func (sw *StatsWalker) SetPhysical(physical bool) {
+	sw.physical = nil
+	if physical {
+		sw.physical = &physicalReader{}
+	}
+}
+
+// CompressionRatio returns the logical size of the regular files divided
+// by their physical size, or 0 if the physical size was not collected.
+func (s *SummaryStat) CompressionRatio() float64 {
+	if s.PhysicalSize == 0 {
+		return 0
+	}
+	return float64(s.FilesSize) / float64(s.PhysicalSize)
+}
+
+// parseBtrfsExtents sums up the on-disk bytes of the n file extent items in
+// a BTRFS_IOC_TREE_SEARCH result. Extents on disk are counted once per
+// file however many of its ranges share them, as seen records; holes take
+// no space. Returns the file offset of the last item, and false for a
+// truncated result.
+func parseBtrfsExtents(buf []byte, n int, seen map[uint64]bool) (disk int64, last uint64, ok bool) {
+	le := binary.LittleEndian
+	pos := 0
+	for range n {
+		// transid, objectid, offset, type, len
+		if len(buf) < pos+btrfsSearchHeaderSize {
+			return 0, 0, false
+		}
+		last = le.Uint64(buf[pos+16:])
+		typ, size := le.Uint32(buf[pos+24:]), int(le.Uint32(buf[pos+28:]))
+		pos += btrfsSearchHeaderSize
+		if len(buf) < pos+size {
+			return 0, 0, false
+		}
+		item := buf[pos : pos+size]
+		pos += size
+		if typ != btrfsExtentDataKey || size < btrfsInlineHeaderSize {
+			continue
+		}
+
+		// generation, ram_bytes, compression, encryption, other_encoding,
+		// type, then the inline data or disk_bytenr and disk_num_bytes
+		if item[20] == btrfsFileExtentInline {
+			disk += int64(size - btrfsInlineHeaderSize)
+			continue
+		}
+		if size < btrfsExtentItemSize {
+			continue
+		}
+		bytenr := le.Uint64(item[21:])
+		if bytenr == 0 || seen[bytenr] {
+			continue
+		}
+		seen[bytenr] = true
+		disk += int64(le.Uint64(item[29:]))
+	}
+	return disk, last, true
+}
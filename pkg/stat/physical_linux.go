@@ -0,0 +1,110 @@
+package stat
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"os"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// btrfsIocTreeSearch is BTRFS_IOC_TREE_SEARCH: _IOWR(0x94, 17, struct
+// btrfs_ioctl_search_args).
+const btrfsIocTreeSearch = 0xd0009411
+
+// btrfsSearchKeySize is the size of struct btrfs_ioctl_search_key, which
+// struct btrfs_ioctl_search_args follows with the result buffer.
+const btrfsSearchKeySize = 104
+
+// read returns the bytes the data of the regular file at path takes on
+// disk, falling back to its allocated blocks, or its size if info carries
+// no *syscall.Stat_t.
+func (p *physicalReader) read(path string, info os.FileInfo) int64 {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return info.Size()
+	}
+	blocks := st.Blocks * 512
+	if p.denied.Load() {
+		return blocks
+	}
+	// statx does not report the device, so only lstat results are looked up
+	if btrfs, ok := p.btrfs.Load(st.Dev); ok && st.Dev != 0 && !btrfs.(bool) {
+		return blocks
+	}
+
+	fd, err := openEntry(path)
+	if err != nil {
+		return blocks
+	}
+	defer unix.Close(fd)
+
+	var fst unix.Stat_t
+	if err := unix.Fstat(fd, &fst); err != nil {
+		return blocks
+	}
+	btrfs, ok := p.btrfs.Load(fst.Dev)
+	if !ok {
+		var fs unix.Statfs_t
+		if err := unix.Fstatfs(fd, &fs); err != nil {
+			return blocks
+		}
+		btrfs, _ = p.btrfs.LoadOrStore(fst.Dev, uint32(fs.Type) == unix.BTRFS_SUPER_MAGIC)
+	}
+	if !btrfs.(bool) {
+		return blocks
+	}
+
+	disk, err := searchBtrfsExtents(fd, fst.Ino)
+	if errors.Is(err, unix.EPERM) {
+		p.denied.Store(true)
+	}
+	if err != nil {
+		return blocks
+	}
+	return disk
+}
+
+// searchBtrfsExtents returns the on-disk bytes of the extents of inode ino
+// in the Btrfs subvolume of the open file fd.
+func searchBtrfsExtents(fd int, ino uint64) (int64, error) {
+	le := binary.LittleEndian
+	var args [4096]byte
+	key, buf := args[:btrfsSearchKeySize], args[btrfsSearchKeySize:]
+
+	seen := make(map[uint64]bool)
+	var disk int64
+	var offset uint64
+	for {
+		// tree_id 0 searches the subvolume of fd
+		clear(key)
+		le.PutUint64(key[8:], ino)             // min_objectid
+		le.PutUint64(key[16:], ino)            // max_objectid
+		le.PutUint64(key[24:], offset)         // min_offset
+		le.PutUint64(key[32:], math.MaxUint64) // max_offset
+		le.PutUint64(key[48:], math.MaxUint64) // max_transid
+		le.PutUint32(key[56:], btrfsExtentDataKey)
+		le.PutUint32(key[60:], btrfsExtentDataKey)
+		le.PutUint32(key[64:], math.MaxUint32) // nr_items, as many as fit
+
+		if _, _, errno := unix.Syscall(unix.SYS_IOCTL, uintptr(fd), btrfsIocTreeSearch, uintptr(unsafe.Pointer(&args))); errno != 0 {
+			return 0, errno
+		}
+		n := int(le.Uint32(key[64:]))
+		if n == 0 {
+			return disk, nil
+		}
+		size, last, ok := parseBtrfsExtents(buf, n, seen)
+		if !ok {
+			return 0, unix.EINVAL
+		}
+		disk += size
+		if last == math.MaxUint64 {
+			return disk, nil
+		}
+		offset = last + 1
+	}
+}
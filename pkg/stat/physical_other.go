@@ -0,0 +1,10 @@
+//go:build !linux
+
+package stat
+
+import "os"
+
+// read is not supported on this platform, files count as uncompressed.
+func (p *physicalReader) read(path string, info os.FileInfo) int64 {
+	return info.Size()
+}
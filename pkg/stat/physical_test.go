@@ -0,0 +1,75 @@
+package stat
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// btrfsItem returns a tree search result item of type typ at offset.
+func btrfsItem(typ uint32, offset uint64, item []byte) []byte {
+	b := make([]byte, btrfsSearchHeaderSize, btrfsSearchHeaderSize+len(item))
+	binary.LittleEndian.PutUint64(b[16:], offset)
+	binary.LittleEndian.PutUint32(b[24:], typ)
+	binary.LittleEndian.PutUint32(b[28:], uint32(len(item)))
+	return append(b, item...)
+}
+
+// btrfsExtent returns a regular file extent item of disk bytes at bytenr.
+func btrfsExtent(bytenr, disk uint64) []byte {
+	b := make([]byte, btrfsExtentItemSize)
+	b[20] = 1
+	binary.LittleEndian.PutUint64(b[21:], bytenr)
+	binary.LittleEndian.PutUint64(b[29:], disk)
+	return b
+}
+
+func TestParseBtrfsExtents(t *testing.T) {
+	var buf []byte
+	buf = append(buf, btrfsItem(btrfsExtentDataKey, 0, make([]byte, btrfsInlineHeaderSize+100))...) // Inline
+	buf = append(buf, btrfsItem(btrfsExtentDataKey, 4096, btrfsExtent(1<<20, 4096))...)             // Compressed
+	buf = append(buf, btrfsItem(btrfsExtentDataKey, 131072, btrfsExtent(1<<20, 4096))...)           // Same extent again
+	buf = append(buf, btrfsItem(btrfsExtentDataKey, 262144, btrfsExtent(0, 0))...)                  // Hole
+	buf = append(buf, btrfsItem(1, 393216, btrfsExtent(2<<20, 8192))...)                            // Other item type
+	buf = append(buf, btrfsItem(btrfsExtentDataKey, 524288, btrfsExtent(3<<20, 8192))...)
+
+	seen := make(map[uint64]bool)
+	disk, last, ok := parseBtrfsExtents(buf, 6, seen)
+	if !ok || disk != 100+4096+8192 || last != 524288 {
+		t.Errorf("extents = %d bytes, last offset %d, %v", disk, last, ok)
+	}
+	// Extents seen in an earlier search are not counted again
+	if disk, _, _ := parseBtrfsExtents(btrfsItem(btrfsExtentDataKey, 1<<30, btrfsExtent(1<<20, 4096)), 1, seen); disk != 0 {
+		t.Errorf("extent counted twice: %d bytes", disk)
+	}
+	if _, _, ok := parseBtrfsExtents(buf[:40], 1, seen); ok {
+		t.Error("truncated result parsed")
+	}
+}
+
+func TestWalkPhysical(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "a"), make([]byte, 64<<10), 0644); err != nil {
+		t.Fatalf("failed to create file: %v", err)
+	}
+
+	walker := NewStatsWalker([]string{root}, 2, nil)
+	walker.SetPhysical(true)
+	res, err := walker.Walk()
+	if err != nil {
+		t.Fatalf("walk failed: %v", err)
+	}
+	if res.Summary.PhysicalSize <= 0 || res.Summary.CompressionRatio() <= 0 {
+		t.Errorf("physical size %d, compression ratio %.2f", res.Summary.PhysicalSize, res.Summary.CompressionRatio())
+	}
+
+	walker = NewStatsWalker([]string{root}, 2, nil)
+	res, err = walker.Walk()
+	if err != nil {
+		t.Fatalf("walk failed: %v", err)
+	}
+	if res.Summary.PhysicalSize != 0 || res.Summary.CompressionRatio() != 0 {
+		t.Errorf("physical size %d collected without SetPhysical", res.Summary.PhysicalSize)
+	}
+}
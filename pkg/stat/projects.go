@@ -0,0 +1,150 @@
+package stat
+
+import (
+	"path"
+	"slices"
+	"sort"
+	"time"
+)
+
+// DefaultProjectMarkers are the names of the entries that mark a project
+// directory unless SetProjectMarkers is given others.
+var DefaultProjectMarkers = []string{".project", "CODEOWNERS", ".git"}
+
+// codeownersDirs are the directories a CODEOWNERS file is also looked up
+// in by GitHub and GitLab; such a file marks their parent directory.
+var codeownersDirs = []string{".github", ".gitlab", "docs"}
+
+// ProjectStat summarizes a project: a directory marked by an entry such as
+// .git, and the entries below it that are not part of a nested project.
+type ProjectStat struct {
+	Path    string    // Full path of the project directory, empty for entries outside all projects
+	Markers []string  // Names of the marker entries found in the directory, sorted
+	Size    int64     // Size of the project directory and its entries
+	Inodes  int64     // Count of the project directory and its entries
+	Files   int64     // Count of regular files
+	Newest  time.Time // Latest modification time of an entry (zero if none)
+}
+
+// SetProjectMarkers makes the walk look for entries with one of the given
+// names, such as DefaultProjectMarkers, and record the directories they
+// are found in as projects for Results.Projects. Markers are detected
+// before filtering, so a .git directory marks its project even if the
+// filters leave it out; excluded and pruned paths are not seen. A
+// CODEOWNERS file in a .github, .gitlab or docs directory marks the parent
+// of that directory. Nil disables detection.
+func (sw *StatsWalker) SetProjectMarkers(markers []string) {
+	sw.markers = markers
+}
+
+// projectMarker returns the full path of the project directory marked by
+// an entry and the name of the marker, or false if the entry is not a
+// marker.
+func (sw *StatsWalker) projectMarker(fi *FileInfo) (string, string, bool) {
+	if len(sw.markers) == 0 || fi.Path == "" {
+		return "", "", false
+	}
+	name := path.Base(fi.Path)
+	if !slices.Contains(sw.markers, name) {
+		return "", "", false
+	}
+	dir := parentDir(fi.Path)
+	if name == "CODEOWNERS" && dir != "" && slices.Contains(codeownersDirs, path.Base(dir)) {
+		dir = parentDir(dir)
+	}
+	return (&FileInfo{Root: fi.Root, Path: dir}).FullPath(), name, true
+}
+
+// parentDir returns the directory of a path relative to a root, "" for
+// entries directly below it.
+func parentDir(p string) string {
+	if dir := path.Dir(p); dir != "." {
+		return dir
+	}
+	return ""
+}
+
+// addProject records a marker found in a project directory.
+func (r *Results) addProject(dir, marker string) {
+	if !slices.Contains(r.ProjectDirs[dir], marker) {
+		r.ProjectDirs[dir] = append(r.ProjectDirs[dir], marker)
+	}
+}
+
+// mergeProjects adds the project directories of other.
+func (r *Results) mergeProjects(other *Results) {
+	for dir, markers := range other.ProjectDirs {
+		for _, marker := range markers {
+			r.addProject(dir, marker)
+		}
+	}
+}
+
+// Projects attributes each entry to the project directory closest above
+// it, or the entry itself if it is one, and summarizes the projects. Entries
+// of nested projects only count for the innermost one. Projects are sorted
+// by size, largest first; entries outside all projects are summarized last
+// under an empty path, if any. Projects are only detected by walks with
+// SetProjectMarkers.
+func (r *Results) Projects() []ProjectStat {
+	projects := make(map[string]*ProjectStat)
+	for dir, markers := range r.ProjectDirs {
+		markers = slices.Clone(markers)
+		slices.Sort(markers)
+		projects[dir] = &ProjectStat{Path: dir, Markers: markers}
+	}
+
+	// Projects of directories already looked up, by full path
+	lookup := make(map[string]*ProjectStat)
+	var projectOf func(root, dir string) *ProjectStat
+	projectOf = func(root, dir string) *ProjectStat {
+		full := (&FileInfo{Root: root, Path: dir}).FullPath()
+		if p, ok := lookup[full]; ok {
+			return p
+		}
+		p, ok := projects[full]
+		if !ok && dir != "" {
+			p = projectOf(root, parentDir(dir))
+		}
+		lookup[full] = p
+		return p
+	}
+
+	var outside *ProjectStat
+	for _, fi := range r.AllFileInfos {
+		dir := fi.Path
+		if !fi.IsDir {
+			dir = parentDir(fi.Path)
+		}
+		p := projectOf(fi.Root, dir)
+		if p == nil {
+			if outside == nil {
+				outside = &ProjectStat{}
+			}
+			p = outside
+		}
+		p.Size += fi.Size
+		p.Inodes++
+		if fi.Mode.IsRegular() {
+			p.Files++
+		}
+		if fi.ModTime.After(p.Newest) {
+			p.Newest = fi.ModTime
+		}
+	}
+
+	result := make([]ProjectStat, 0, len(projects)+1)
+	for _, p := range projects {
+		result = append(result, *p)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Size != result[j].Size {
+			return result[i].Size > result[j].Size
+		}
+		return result[i].Path < result[j].Path
+	})
+	if outside != nil {
+		result = append(result, *outside)
+	}
+	return result
+}
@@ -0,0 +1,99 @@
+package stat
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestProjects(t *testing.T) {
+	mtime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	results := &Results{
+		ProjectDirs: map[string][]string{
+			"/src/app":     {".git", "CODEOWNERS"},
+			"/src/app/lib": {".project"},
+			"/src/tool":    {".git"},
+		},
+		AllFileInfos: []FileInfo{
+			{Root: "/src", Path: "", Mode: os.ModeDir | 0o755, IsDir: true},
+			{Root: "/src", Path: "notes.txt", Mode: 0o644, Size: 1},
+			{Root: "/src", Path: "app", Mode: os.ModeDir | 0o755, IsDir: true, Size: 10},
+			{Root: "/src", Path: "app/main.go", Mode: 0o644, Size: 100, ModTime: mtime},
+			{Root: "/src", Path: "app/lib", Mode: os.ModeDir | 0o755, IsDir: true, Size: 10},
+			{Root: "/src", Path: "app/lib/x.go", Mode: 0o644, Size: 50},
+			{Root: "/src", Path: "app/lib/deep/y.go", Mode: 0o644, Size: 50},
+			{Root: "/src", Path: "tool", Mode: os.ModeDir | 0o755, IsDir: true, Size: 10},
+		},
+	}
+
+	projects := results.Projects()
+	var paths []string
+	for _, p := range projects {
+		paths = append(paths, p.Path)
+	}
+	if want := []string{"/src/app", "/src/app/lib", "/src/tool", ""}; !reflect.DeepEqual(paths, want) {
+		t.Fatalf("projects = %q, want %q", paths, want)
+	}
+
+	app := projects[0]
+	if app.Size != 110 || app.Inodes != 2 || app.Files != 1 || !app.Newest.Equal(mtime) {
+		t.Errorf("app: %+v", app)
+	}
+	if want := []string{".git", "CODEOWNERS"}; !reflect.DeepEqual(app.Markers, want) {
+		t.Errorf("app: markers %q, want %q", app.Markers, want)
+	}
+	if lib := projects[1]; lib.Size != 110 || lib.Inodes != 3 {
+		t.Errorf("lib: %+v", lib)
+	}
+	if outside := projects[3]; outside.Size != 1 || outside.Inodes != 2 || outside.Markers != nil {
+		t.Errorf("outside: %+v", outside)
+	}
+}
+
+func TestProjectMarkers(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{
+		"app/.git/HEAD", "app/main.go",
+		"docs/site/.github/CODEOWNERS", "docs/site/index.html",
+		"other/file",
+	} {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("data"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The markers are found even though the filters leave them out
+	glob, err := CompileGlob("*.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	walker := NewStatsWalker([]string{root}, 2, &Filters{NameGlob: glob})
+	walker.SetProjectMarkers(DefaultProjectMarkers)
+	walker.SetSeparateRoots(true)
+	results, err := walker.Walk()
+	if err != nil {
+		t.Fatalf("walk failed: %v", err)
+	}
+
+	want := map[string][]string{
+		filepath.Join(root, "app"):       {".git"},
+		filepath.Join(root, "docs/site"): {"CODEOWNERS"},
+	}
+	if !reflect.DeepEqual(results.ProjectDirs, want) {
+		t.Errorf("project dirs = %v, want %v", results.ProjectDirs, want)
+	}
+	if got := results.ByRoot[root].ProjectDirs; !reflect.DeepEqual(got, want) {
+		t.Errorf("project dirs of the root = %v, want %v", got, want)
+	}
+
+	projects := results.Projects()
+	if len(projects) != 2 || projects[0].Path != filepath.Join(root, "app") || projects[0].Files != 1 {
+		t.Errorf("projects = %+v", projects)
+	}
+}
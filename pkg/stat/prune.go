@@ -0,0 +1,54 @@
+package stat
+
+import (
+	"os"
+	"strings"
+)
+
+// SetMaxDepth limits the walk to entries at most depth levels below each
+// root (see FileInfo.Depth): directories at that depth are recorded but
+// not read. At depth 0 only the roots themselves are recorded; they are
+// still listed by the walk, but nothing below them is recorded or counted.
+// A negative depth removes the limit, which is the default.
+func (sw *StatsWalker) SetMaxDepth(depth int) {
+	sw.maxDepth, sw.depthLimit = depth, depth >= 0
+}
+
+// SetExcludePaths excludes the entries matching any of the patterns from
+// the walk. Unlike filters, which are applied to entries after they have
+// been stat'ed, matching directories are not read at all, so nothing
+// below them is visited. Patterns match like Filters.NameGlob.
+func (sw *StatsWalker) SetExcludePaths(patterns []*Glob) {
+	sw.excludes = patterns
+}
+
+// excluded reports whether the entry at relPath matches an exclude
+// pattern. Roots are never excluded.
+func (sw *StatsWalker) excluded(relPath string) bool {
+	if relPath == "" {
+		return false
+	}
+	fi := FileInfo{Path: relPath}
+	for _, g := range sw.excludes {
+		if g.Matches(&fi) {
+			return true
+		}
+	}
+	return false
+}
+
+// tooDeep reports whether the entry at relPath lies below the maximum
+// depth. Only entries directly below a root at maximum depth 0 do, since
+// deeper directories are pruned before they are read.
+func (sw *StatsWalker) tooDeep(relPath string) bool {
+	return sw.depthLimit && relPath != "" && strings.Count(relPath, "/")+1 > sw.maxDepth
+}
+
+// shouldDescend prunes directories that are excluded or at the maximum
+// depth before they are read.
+func (sw *StatsWalker) shouldDescend(relPath string, entry os.DirEntry) bool {
+	if sw.depthLimit && strings.Count(relPath, "/")+1 >= sw.maxDepth {
+		return false
+	}
+	return !sw.excluded(relPath)
+}
@@ -0,0 +1,85 @@
+package stat
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"testing"
+)
+
+func TestPruneWalk(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"a/1", "a/b/2", "a/b/c/3", "cache/4", "src/cache/5", "src/6"} {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("create dir: %v", err)
+		}
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatalf("create file: %v", err)
+		}
+	}
+
+	paths := func(sw *StatsWalker) []string {
+		t.Helper()
+		res, err := sw.Walk()
+		if err != nil {
+			t.Fatalf("walk failed: %v", err)
+		}
+		var out []string
+		for _, fi := range res.AllFileInfos {
+			out = append(out, fi.Path)
+		}
+		sort.Strings(out)
+		return out
+	}
+
+	sw := NewStatsWalker([]string{root}, 2, &Filters{})
+	sw.SetMaxDepth(2)
+	got := paths(sw)
+	want := []string{"", "a", "a/1", "a/b", "cache", "cache/4", "src", "src/6", "src/cache"}
+	if !slices.Equal(got, want) {
+		t.Errorf("max depth 2: got %v, want %v", got, want)
+	}
+	if fanOut := sw.results.FanOut; fanOut.Dirs != 4 {
+		t.Errorf("max depth 2: read %d directories, want 4", fanOut.Dirs)
+	}
+
+	sw = NewStatsWalker([]string{root}, 2, &Filters{})
+	sw.SetMaxDepth(1)
+	got = paths(sw)
+	want = []string{"", "a", "cache", "src"}
+	if !slices.Equal(got, want) {
+		t.Errorf("max depth 1: got %v, want %v", got, want)
+	}
+	if fanOut := sw.results.FanOut; fanOut.Dirs != 1 {
+		t.Errorf("max depth 1: read %d directories, want 1", fanOut.Dirs)
+	}
+
+	sw = NewStatsWalker([]string{root}, 2, &Filters{})
+	sw.SetMaxDepth(0)
+	got = paths(sw)
+	want = []string{""}
+	if !slices.Equal(got, want) {
+		t.Errorf("max depth 0: got %v, want %v", got, want)
+	}
+	if sw.results.Scan.Entries != 1 || sw.results.FanOut.Dirs != 0 {
+		t.Errorf("max depth 0: saw %d entries and read %d directories, want 1 and 0", sw.results.Scan.Entries, sw.results.FanOut.Dirs)
+	}
+
+	cache, err := CompileGlob("cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	deep, err := CompileGlob("a/b/c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sw = NewStatsWalker([]string{root}, 2, &Filters{})
+	sw.SetExcludePaths([]*Glob{cache, deep})
+	got = paths(sw)
+	want = []string{"", "a", "a/1", "a/b", "a/b/2", "src", "src/6"}
+	if !slices.Equal(got, want) {
+		t.Errorf("excluded: got %v, want %v", got, want)
+	}
+}
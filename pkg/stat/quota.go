@@ -0,0 +1,205 @@
+package stat
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Quota is a size limit for the entries of a user or below a directory.
+// Exactly one of User and Dir is set.
+type Quota struct {
+	User  string // Name or UID of the owner of the entries counted
+	Dir   string // Full path of the directory whose entries are counted
+	Limit int64  // Size limit in bytes
+}
+
+// Target returns the user or directory the quota applies to.
+func (q *Quota) Target() string {
+	if q.Dir != "" {
+		return q.Dir
+	}
+	return q.User
+}
+
+// QuotaStat is the usage of a quota found by a walk.
+type QuotaStat struct {
+	Quota
+	Used   int64 // Size of the entries counted
+	Inodes int64 // Count of the entries counted
+}
+
+// Exceeded reports whether the usage is over the limit.
+func (q *QuotaStat) Exceeded() bool {
+	return q.Used > q.Limit
+}
+
+// Pct returns the usage as a percentage of the limit, or 0 without one.
+func (q *QuotaStat) Pct() float64 {
+	if q.Limit <= 0 {
+		return 0
+	}
+	return 100 * float64(q.Used) / float64(q.Limit)
+}
+
+// quotaFile is the YAML form of a quota file.
+type quotaFile struct {
+	Users map[string]string `yaml:"users"`
+	Dirs  map[string]string `yaml:"dirs"`
+}
+
+// LoadQuotas reads a quota file. Files ending in .yaml or .yml map user
+// names or UIDs under "users" and directories under "dirs" to limits:
+//
+//	users:
+//	  alice: 50G
+//	dirs:
+//	  /srv/projects/x: 1T
+//
+// Other files are CSV with a target and a limit per line, where targets
+// starting with '/' or containing "://" are directories and anything else
+// is a user. Lines starting with '#' are comments, and a first line with
+// the limit column "limit" is a header. Limits are sizes like 1.5G.
+func LoadQuotas(filename string) ([]Quota, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".yaml", ".yml":
+		return readQuotaYAML(f, filename)
+	default:
+		return readQuotaCSV(f, filename)
+	}
+}
+
+// readQuotaYAML reads the YAML form of a quota file, users first, each
+// sorted by target.
+func readQuotaYAML(r io.Reader, filename string) ([]Quota, error) {
+	var file quotaFile
+	if err := yaml.NewDecoder(r).Decode(&file); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+
+	var quotas []Quota
+	for _, section := range []struct {
+		limits map[string]string
+		dir    bool
+	}{{file.Users, false}, {file.Dirs, true}} {
+		for _, target := range slices.Sorted(maps.Keys(section.limits)) {
+			q, err := newQuota(target, section.limits[target], section.dir)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s: %w", filename, target, err)
+			}
+			quotas = append(quotas, q)
+		}
+	}
+	return quotas, nil
+}
+
+// readQuotaCSV reads the CSV form of a quota file, in file order.
+func readQuotaCSV(r io.Reader, filename string) ([]Quota, error) {
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = 2
+	reader.TrimLeadingSpace = true
+
+	var quotas []Quota
+	for first := true; ; first = false {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return quotas, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filename, err)
+		}
+		target, limit := strings.TrimSpace(record[0]), strings.TrimSpace(record[1])
+		if first && strings.EqualFold(limit, "limit") {
+			continue
+		}
+		dir := strings.HasPrefix(target, "/") || strings.Contains(target, "://")
+		q, err := newQuota(target, limit, dir)
+		if err != nil {
+			line, _ := reader.FieldPos(0)
+			return nil, fmt.Errorf("%s:%d: %w", filename, line, err)
+		}
+		quotas = append(quotas, q)
+	}
+}
+
+// newQuota returns the quota of a user or directory target with a limit
+// like 1.5G.
+func newQuota(target, limit string, dir bool) (Quota, error) {
+	if target == "" {
+		return Quota{}, fmt.Errorf("missing user or directory")
+	}
+	size, err := parseSizeValue(limit)
+	if err != nil {
+		return Quota{}, err
+	}
+	if dir {
+		if strings.Contains(target, "://") {
+			target = strings.TrimSuffix(target, "/")
+		} else {
+			target = filepath.Clean(target)
+		}
+		return Quota{Dir: target, Limit: size}, nil
+	}
+	return Quota{User: target, Limit: size}, nil
+}
+
+// CheckQuotas returns the usage of each quota, in the order given. User
+// quotas count the entries owned by the user, matched by name or UID;
+// directory quotas count the entries below the directory, not the
+// directory itself. Only entries that passed the filters are counted.
+func (r *Results) CheckQuotas(quotas []Quota) []QuotaStat {
+	stats := make([]QuotaStat, len(quotas))
+	byUser := make(map[string][]int)
+	for i, q := range quotas {
+		stats[i].Quota = q
+		if q.User != "" {
+			byUser[q.User] = append(byUser[q.User], i)
+		}
+	}
+
+	// Resolve each owner once
+	owners := make(map[uint32][]int)
+	userQuotas := func(uid uint32) []int {
+		idx, ok := owners[uid]
+		if !ok {
+			name, id := Username(uid), strconv.FormatUint(uint64(uid), 10)
+			idx = slices.Clone(byUser[name])
+			if id != name {
+				idx = append(idx, byUser[id]...)
+			}
+			owners[uid] = idx
+		}
+		return idx
+	}
+
+	for _, fi := range r.AllFileInfos {
+		for _, i := range userQuotas(fi.UID) {
+			stats[i].Used += fi.Size
+			stats[i].Inodes++
+		}
+		full := fi.FullPath()
+		for i := range stats {
+			if dir := stats[i].Dir; dir != "" && strings.HasPrefix(full, strings.TrimSuffix(dir, "/")+"/") {
+				stats[i].Used += fi.Size
+				stats[i].Inodes++
+			}
+		}
+	}
+	return stats
+}
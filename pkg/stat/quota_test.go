@@ -0,0 +1,88 @@
+package stat
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadQuotas(t *testing.T) {
+	dir := t.TempDir()
+	want := []Quota{
+		{User: "alice", Limit: 50 << 30},
+		{User: "1001", Limit: 1536},
+		{Dir: "/srv/projects/x", Limit: 1 << 40},
+	}
+
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"quotas.csv", "target,limit\n# users\nalice,50G\n1001, 1.5K\n/srv/projects/x/,1T\n"},
+		{"quotas.yaml", "users:\n  alice: 50G\n  \"1001\": 1.5K\ndirs:\n  /srv/projects/x/: 1T\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filename := filepath.Join(dir, tt.name)
+			if err := os.WriteFile(filename, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			quotas, err := LoadQuotas(filename)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// YAML sorts users by name
+			if tt.name == "quotas.yaml" {
+				quotas[0], quotas[1] = quotas[1], quotas[0]
+			}
+			if !reflect.DeepEqual(quotas, want) {
+				t.Errorf("got %+v, want %+v", quotas, want)
+			}
+		})
+	}
+
+	bad := filepath.Join(dir, "bad.csv")
+	if err := os.WriteFile(bad, []byte("alice,50G\nbob,lots\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadQuotas(bad); err == nil {
+		t.Error("invalid limit: no error")
+	}
+}
+
+func TestCheckQuotas(t *testing.T) {
+	results := &Results{AllFileInfos: []FileInfo{
+		{Root: "/srv", Path: "", IsDir: true, Size: 4096},
+		{Root: "/srv", Path: "a", IsDir: true, Size: 4096},
+		{Root: "/srv", Path: "a/f", Size: 1000, UID: 1001},
+		{Root: "/srv", Path: "a/g", Size: 500, UID: 0},
+		{Root: "/srv", Path: "ab", Size: 10, UID: 1001},
+	}}
+
+	stats := results.CheckQuotas([]Quota{
+		{User: "1001", Limit: 1000},
+		{User: "root", Limit: 1 << 20},
+		{Dir: "/srv/a", Limit: 2000},
+		{Dir: "/", Limit: 100},
+	})
+	tests := []struct {
+		used, inodes int64
+		exceeded     bool
+	}{
+		{1010, 2, true},
+		{4096 + 4096 + 500, 3, false},
+		{1500, 2, false},
+		{4096 + 4096 + 1510, 5, true},
+	}
+	for i, tt := range tests {
+		s := stats[i]
+		if s.Used != tt.used || s.Inodes != tt.inodes || s.Exceeded() != tt.exceeded {
+			t.Errorf("%s: used %d, inodes %d, exceeded %v; want %d, %d, %v",
+				s.Target(), s.Used, s.Inodes, s.Exceeded(), tt.used, tt.inodes, tt.exceeded)
+		}
+	}
+	if pct := stats[2].Pct(); pct != 75 {
+		t.Errorf("Pct() = %v, want 75", pct)
+	}
+}
@@ -0,0 +1,125 @@
+package stat
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	cwalk "github.com/otuschhoff/cwalk"
+)
+
+// rootTally holds what the walk saw below a single root, for the scan
+// statistics and fan-out of Results.ByRoot.
+type rootTally struct {
+	entries  int64           // Entries seen, including those filtered out
+	errors   int64           // Read errors seen
+	files    int64           // Non-directory entries seen
+	walkTime time.Duration   // Time spent walking the root
+	walk     cwalk.WalkStats // Walker statistics
+	fanOut   FanOutStat      // Entries per directory, unfiltered
+}
+
+// SetSeparateRoots makes Walk and WalkSnapshot also aggregate the entries
+// below each root separately, into Results.ByRoot. The results of a root
+// are complete reports of their own: their scan statistics cover the walk
+// of that root only, and Churn is left for the caller to fill in. A root
+// given twice is reported once, with the entries of both walks.
+func (sw *StatsWalker) SetSeparateRoots(separate bool) {
+	sw.separateRoots = separate
+}
+
+// rootTally returns the tally of a root, adding it if missing.
+func (sw *StatsWalker) rootTally(root string) *rootTally {
+	if sw.roots == nil {
+		sw.roots = make(map[string]*rootTally)
+	}
+	t, ok := sw.roots[root]
+	if !ok {
+		t = &rootTally{}
+		sw.roots[root] = t
+	}
+	return t
+}
+
+// addWalkStats adds the statistics of the walker of a root to those of
+// the scan and, with separate roots, to those of the root.
+func (sw *StatsWalker) addWalkStats(root string, stats cwalk.WalkStats) {
+	sw.walkStats.Add(stats)
+	if sw.separateRoots {
+		sw.rootTally(root).walk.Add(stats)
+	}
+}
+
+// splitRoots fills in Results.ByRoot from the merged results, aggregating
+// the entries of each root again with the same grouping, watchlist and
+// symlink settings. It does nothing unless separate roots are enabled.
+func (sw *StatsWalker) splitRoots(roots []string) {
+	if !sw.separateRoots {
+		return
+	}
+
+	byRoot := make(map[string]*Results, len(roots))
+	for _, root := range roots {
+		byRoot[root] = newResults()
+	}
+	for _, fi := range sw.results.AllFileInfos {
+		r, ok := byRoot[fi.Root]
+		if !ok {
+			// Snapshot entries outside all roots
+			continue
+		}
+		year := sw.year(&fi)
+		r.add(fi, year)
+		if keys := sw.groupKeys(&fi, year); keys != nil {
+			r.addGroup(keys, &fi)
+		}
+		if match := sw.watchMatch(&fi); match != nil {
+			r.WatchlistMatches = append(r.WatchlistMatches, *match)
+		}
+	}
+
+	for root, r := range byRoot {
+		sort.Slice(r.WatchlistMatches, func(i, j int) bool {
+			return r.WatchlistMatches[i].Path < r.WatchlistMatches[j].Path
+		})
+		r.calculateSummary()
+		resolveMounts(r.ByFS)
+		for uid, us := range r.ByUID {
+			us.Username = sw.results.ByUID[uid].Username
+		}
+		r.GroupColumns = sw.results.GroupColumns
+		prefix := strings.TrimSuffix(root, "/") + "/"
+		for dir, markers := range sw.results.ProjectDirs {
+			if dir == root || strings.HasPrefix(dir, prefix) {
+				r.ProjectDirs[dir] = markers
+			}
+		}
+		if sw.linkCheck {
+			r.Symlinks.sort()
+		} else {
+			r.Symlinks = nil
+		}
+		for _, u := range sw.results.Usage {
+			if u.Root == root {
+				r.Usage = append(r.Usage, u)
+			}
+		}
+
+		r.Scan = ScanStat{Roots: []string{root}}
+		if t, ok := sw.roots[root]; ok {
+			fanOut := t.fanOut
+			fanOut.sort()
+			r.FanOut = &fanOut
+			r.Scan.Entries = t.entries
+			r.Scan.Errors = t.errors
+			r.Scan.Duration = t.walkTime
+			r.Scan.WalkTime = t.walkTime
+			r.Scan.Files = t.files
+			r.Scan.Dirs = t.walk.Dirs
+			r.Scan.Syscalls = t.walk.Syscalls
+			r.Scan.PeakQueue = t.walk.PeakQueue
+			r.Scan.Workers = t.walk.Workers
+		}
+	}
+	sw.results.ByRoot = byRoot
+}
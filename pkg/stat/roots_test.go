@@ -0,0 +1,82 @@
+package stat
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSeparateRoots(t *testing.T) {
+	a, b := t.TempDir(), t.TempDir()
+	if err := os.MkdirAll(filepath.Join(a, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []string{filepath.Join(a, "sub", "one"), filepath.Join(a, "two"), filepath.Join(b, "three")} {
+		if err := os.WriteFile(p, []byte("data"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sw := NewStatsWalker([]string{a, b}, 2, nil)
+	sw.SetSeparateRoots(true)
+	res, err := sw.Walk()
+	if err != nil {
+		t.Fatalf("walk failed: %v", err)
+	}
+	if len(res.ByRoot) != 2 {
+		t.Fatalf("got %d roots, want 2", len(res.ByRoot))
+	}
+
+	ra, rb := res.ByRoot[a], res.ByRoot[b]
+	if ra.Summary.Files != 2 || ra.Summary.Dirs != 2 || rb.Summary.Files != 1 || rb.Summary.Dirs != 1 {
+		t.Errorf("got %d files and %d dirs below a, %d and %d below b; want 2, 2, 1 and 1",
+			ra.Summary.Files, ra.Summary.Dirs, rb.Summary.Files, rb.Summary.Dirs)
+	}
+	if res.Summary.TotalInodes != ra.Summary.TotalInodes+rb.Summary.TotalInodes {
+		t.Errorf("total of %d inodes is not the sum of the roots", res.Summary.TotalInodes)
+	}
+	if ra.FanOut.Dirs != 2 || rb.FanOut.Dirs != 1 || res.FanOut.Dirs != 3 {
+		t.Errorf("got fan-out of %d, %d and %d dirs, want 2, 1 and 3", ra.FanOut.Dirs, rb.FanOut.Dirs, res.FanOut.Dirs)
+	}
+	if len(ra.Scan.Roots) != 1 || ra.Scan.Roots[0] != a || ra.Scan.Entries != 4 || ra.Scan.Dirs != 2 || rb.Scan.Entries != 2 {
+		t.Errorf("scan of a = %+v, of b = %+v", ra.Scan, rb.Scan)
+	}
+
+	// Without separate roots, ByRoot stays nil
+	res, err = NewStatsWalker([]string{a, b}, 2, nil).Walk()
+	if err != nil {
+		t.Fatalf("walk failed: %v", err)
+	}
+	if res.ByRoot != nil {
+		t.Errorf("ByRoot = %v, want nil", res.ByRoot)
+	}
+}
+
+func TestSeparateRootsSnapshot(t *testing.T) {
+	s := &Snapshot{
+		Version: SnapshotVersion,
+		Roots:   []string{"/a", "/b"},
+		Entries: []SnapshotEntry{
+			{Path: "/a", Mode: os.ModeDir | 0755, ModTime: time.Now()},
+			{Path: "/a/f", Size: 10, Mode: 0644, ModTime: time.Now()},
+			{Path: "/b", Mode: os.ModeDir | 0755, ModTime: time.Now()},
+			{Path: "/b/g", Size: 20, Mode: 0644, ModTime: time.Now()},
+			{Path: "/b/h", Size: 30, Mode: 0644, ModTime: time.Now()},
+		},
+	}
+
+	sw := NewStatsWalker(nil, 1, nil)
+	sw.SetSeparateRoots(true)
+	res, err := sw.WalkSnapshot(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := res.ByRoot["/a"].Summary.FilesSize; got != 10 {
+		t.Errorf("files size of /a = %d, want 10", got)
+	}
+	if rb := res.ByRoot["/b"]; rb.Summary.FilesSize != 50 || rb.FanOut.Entries != 2 || rb.Scan.Entries != 3 {
+		t.Errorf("/b: files size %d, fan-out of %d entries, %d entries seen; want 50, 2 and 3",
+			rb.Summary.FilesSize, rb.FanOut.Entries, rb.Scan.Entries)
+	}
+}
@@ -0,0 +1,50 @@
+package stat
+
+import (
+	"sort"
+	"strings"
+)
+
+// selinuxXattr is the extended attribute holding the SELinux security
+// context of a file.
+const selinuxXattr = "security.selinux"
+
+// LabelStat holds statistics for one SELinux security context.
+type LabelStat struct {
+	Label   string // Security context, e.g. "system_u:object_r:httpd_sys_content_t:s0"
+	Entries int64  // Count of entries with the context
+	Size    int64  // Total size of entries with the context
+}
+
+// SELinuxType returns the type component of an SELinux security context,
+// for example "httpd_sys_content_t" for
+// "system_u:object_r:httpd_sys_content_t:s0". Returns "" if the context is
+// malformed.
+func SELinuxType(label string) string {
+	parts := strings.SplitN(label, ":", 4)
+	if len(parts) < 3 {
+		return ""
+	}
+	return parts[2]
+}
+
+// trimLabel strips the NUL terminator the kernel stores with the context.
+func trimLabel(value string) string {
+	return strings.TrimRight(value, "\x00")
+}
+
+// SortedLabels returns the per-label statistics ordered by entry count,
+// largest first, then by label. Unlabeled entries are not included.
+func (r *Results) SortedLabels() []*LabelStat {
+	labels := make([]*LabelStat, 0, len(r.ByLabel))
+	for _, ls := range r.ByLabel {
+		labels = append(labels, ls)
+	}
+	sort.Slice(labels, func(i, j int) bool {
+		if labels[i].Entries != labels[j].Entries {
+			return labels[i].Entries > labels[j].Entries
+		}
+		return labels[i].Label < labels[j].Label
+	})
+	return labels
+}
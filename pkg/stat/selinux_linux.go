@@ -0,0 +1,13 @@
+package stat
+
+import "golang.org/x/sys/unix"
+
+// readSELinuxLabel returns the SELinux security context of the entry at
+// path without following symlinks, or "" if it has none.
+func readSELinuxLabel(path string) string {
+	value, err := readXattrBuf(func(dest []byte) (int, error) { return unix.Lgetxattr(path, selinuxXattr, dest) })
+	if err != nil {
+		return ""
+	}
+	return trimLabel(string(value))
+}
@@ -0,0 +1,8 @@
+//go:build !linux
+
+package stat
+
+// readSELinuxLabel is not supported on this platform.
+func readSELinuxLabel(path string) string {
+	return ""
+}
@@ -0,0 +1,49 @@
+package stat
+
+import "testing"
+
+func TestSELinuxType(t *testing.T) {
+	tests := []struct {
+		label, want string
+	}{
+		{"system_u:object_r:httpd_sys_content_t:s0", "httpd_sys_content_t"},
+		{"unconfined_u:object_r:user_home_t:s0:c0.c1023", "user_home_t"},
+		{"user_u:role_r", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := SELinuxType(tt.label); got != tt.want {
+			t.Errorf("SELinuxType(%q) = %q, want %q", tt.label, got, tt.want)
+		}
+	}
+}
+
+func TestSELinuxTypeFilter(t *testing.T) {
+	f := &Filters{SELinuxTypes: []string{"httpd_sys_content_t", "public_content_t"}}
+	if !f.Matches(&FileInfo{Label: "system_u:object_r:public_content_t:s0"}) {
+		t.Error("listed type should match")
+	}
+	if f.Matches(&FileInfo{Label: "system_u:object_r:user_home_t:s0"}) {
+		t.Error("other type should not match")
+	}
+	if f.Matches(&FileInfo{}) {
+		t.Error("unlabeled entry should not match")
+	}
+}
+
+func TestResultsByLabel(t *testing.T) {
+	a, b := newResults(), newResults()
+	a.add(FileInfo{Path: "a", Size: 10, Label: "system_u:object_r:etc_t:s0"}, 2024)
+	a.add(FileInfo{Path: "b", Size: 5}, 2024)
+	b.add(FileInfo{Path: "c", Size: 1, Label: "system_u:object_r:etc_t:s0"}, 2024)
+	b.add(FileInfo{Path: "d", Size: 1, Label: "system_u:object_r:tmp_t:s0"}, 2024)
+	a.merge(b)
+
+	labels := a.SortedLabels()
+	if len(labels) != 2 {
+		t.Fatalf("got %d labels, want 2", len(labels))
+	}
+	if labels[0].Label != "system_u:object_r:etc_t:s0" || labels[0].Entries != 2 || labels[0].Size != 11 {
+		t.Errorf("unexpected first label: %+v", labels[0])
+	}
+}
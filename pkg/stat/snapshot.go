@@ -0,0 +1,106 @@
+package stat
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SnapshotVersion is the current snapshot file format version.
+const SnapshotVersion = 1
+
+// Snapshot is a point-in-time record of every entry seen by a scan.
+// Snapshots from consecutive scans of the same roots can be compared
+// to compute churn.
+type Snapshot struct {
+	Version int             `json:"version"`
+	Time    time.Time       `json:"time"`    // When the scan finished
+	Roots   []string        `json:"roots"`   // Root paths that were scanned
+	Entries []SnapshotEntry `json:"entries"` // Entries sorted by Path
+}
+
+// SnapshotEntry holds the metadata of a single entry in a snapshot.
+type SnapshotEntry struct {
+	Path      string      `json:"path"` // Root path joined with the relative path
+	Size      int64       `json:"size"`
+	ModTime   time.Time   `json:"mtime"`
+	BirthTime time.Time   `json:"btime,omitzero"` // Zero if unknown
+	Mode      os.FileMode `json:"mode"`
+	UID       uint32      `json:"uid"`
+	GID       uint32      `json:"gid"`
+}
+
+// NewSnapshot builds a snapshot of the entries collected in results.
+func NewSnapshot(roots []string, results *Results, t time.Time) *Snapshot {
+	entries := make([]SnapshotEntry, 0, len(results.AllFileInfos))
+	for _, fi := range results.AllFileInfos {
+		entries = append(entries, SnapshotEntry{
+			Path:      filepath.Join(fi.Root, fi.Path),
+			Size:      fi.Size,
+			ModTime:   fi.ModTime,
+			BirthTime: fi.BirthTime,
+			Mode:      fi.Mode,
+			UID:       fi.UID,
+			GID:       fi.GID,
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Path < entries[j].Path })
+
+	return &Snapshot{
+		Version: SnapshotVersion,
+		Time:    t,
+		Roots:   append([]string(nil), roots...),
+		Entries: entries,
+	}
+}
+
+// splitPath splits an entry path into the root it was found under and the
+// path relative to that root. The longest matching root wins; paths outside
+// all roots are returned unchanged with an empty root.
+func (s *Snapshot) splitPath(p string) (root, relPath string) {
+	relPath = p
+	for _, r := range s.Roots {
+		clean := filepath.Clean(r)
+		if root != "" && len(clean) <= len(filepath.Clean(root)) {
+			continue
+		}
+		prefix := strings.TrimSuffix(clean, "/") + "/"
+		switch {
+		case p == clean:
+			root, relPath = r, ""
+		case strings.HasPrefix(p, prefix):
+			root, relPath = r, p[len(prefix):]
+		}
+	}
+	return root, relPath
+}
+
+// Save writes the snapshot to a file as JSON.
+func (s *Snapshot) Save(filename string) error {
+	b, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, b, 0644)
+}
+
+// LoadSnapshot reads a snapshot previously written by Save.
+func LoadSnapshot(filename string) (*Snapshot, error) {
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var s Snapshot
+	if err := json.Unmarshal(b, &s); err != nil {
+		return nil, fmt.Errorf("parse snapshot %s: %w", filename, err)
+	}
+	if s.Version != SnapshotVersion {
+		return nil, fmt.Errorf("snapshot %s: unsupported version %d", filename, s.Version)
+	}
+	return &s, nil
+}
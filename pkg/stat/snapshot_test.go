@@ -0,0 +1,157 @@
+package stat
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSnapshotSaveLoad(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "b.txt"), []byte("data"), 0644); err != nil {
+		t.Fatalf("create file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "a.txt"), []byte("more data"), 0644); err != nil {
+		t.Fatalf("create file: %v", err)
+	}
+
+	res, err := NewStatsWalker([]string{root}, 2, &Filters{}).Walk()
+	if err != nil {
+		t.Fatalf("walk failed: %v", err)
+	}
+
+	scanTime := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	snap := NewSnapshot([]string{root}, res, scanTime)
+
+	// Root directory plus two files, sorted by path
+	if len(snap.Entries) != 3 {
+		t.Fatalf("got %d entries, want 3", len(snap.Entries))
+	}
+	if snap.Entries[1].Path != filepath.Join(root, "a.txt") || snap.Entries[1].Size != 9 {
+		t.Errorf("entry[1] = %+v, want a.txt with size 9", snap.Entries[1])
+	}
+
+	filename := filepath.Join(t.TempDir(), "snap.json")
+	if err := snap.Save(filename); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	loaded, err := LoadSnapshot(filename)
+	if err != nil {
+		t.Fatalf("LoadSnapshot failed: %v", err)
+	}
+
+	if !loaded.Time.Equal(scanTime) || len(loaded.Entries) != len(snap.Entries) {
+		t.Fatalf("loaded snapshot mismatch: %+v", loaded)
+	}
+	for i := range snap.Entries {
+		want, got := snap.Entries[i], loaded.Entries[i]
+		if got.Path != want.Path || got.Size != want.Size || !got.ModTime.Equal(want.ModTime) || got.Mode != want.Mode {
+			t.Errorf("entry[%d] = %+v, want %+v", i, got, want)
+		}
+	}
+}
+
+func TestLoadSnapshotErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	if _, err := LoadSnapshot(filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("expected error for missing file")
+	}
+
+	badVersion := filepath.Join(dir, "v99.json")
+	if err := os.WriteFile(badVersion, []byte(`{"version":99}`), 0644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	if _, err := LoadSnapshot(badVersion); err == nil {
+		t.Error("expected error for unsupported version")
+	}
+
+	garbage := filepath.Join(dir, "garbage.json")
+	if err := os.WriteFile(garbage, []byte("not json"), 0644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	if _, err := LoadSnapshot(garbage); err == nil {
+		t.Error("expected error for invalid JSON")
+	}
+}
+
+func TestWalkSnapshotMatchesWalk(t *testing.T) {
+	root := setupStatsTree(t, 3, 4)
+	if err := os.WriteFile(filepath.Join(root, "notes.locky"), []byte("x"), 0644); err != nil {
+		t.Fatalf("create file: %v", err)
+	}
+
+	live := NewStatsWalker([]string{root}, 2, &Filters{})
+	live.SetWatchlist(DefaultWatchlist())
+	want, err := live.Walk()
+	if err != nil {
+		t.Fatalf("walk failed: %v", err)
+	}
+	snap := NewSnapshot([]string{root}, want, time.Now())
+
+	replay := NewStatsWalker(nil, 2, &Filters{})
+	replay.SetWatchlist(DefaultWatchlist())
+	got, err := replay.WalkSnapshot(snap)
+	if err != nil {
+		t.Fatalf("WalkSnapshot failed: %v", err)
+	}
+
+	if *got.Summary != *want.Summary {
+		t.Errorf("summary = %+v, want %+v", *got.Summary, *want.Summary)
+	}
+	if len(got.ByUID) != len(want.ByUID) || len(got.ByYear) != len(want.ByYear) {
+		t.Errorf("got %d UIDs and %d years, want %d and %d",
+			len(got.ByUID), len(got.ByYear), len(want.ByUID), len(want.ByYear))
+	}
+	if len(got.WatchlistMatches) != 1 || got.WatchlistMatches[0].Path != filepath.Join(root, "notes.locky") {
+		t.Errorf("watchlist matches = %+v, want notes.locky", got.WatchlistMatches)
+	}
+	for _, fi := range got.AllFileInfos {
+		if fi.Root != root {
+			t.Errorf("entry %q has root %q, want %q", fi.Path, fi.Root, root)
+		}
+	}
+}
+
+func TestWalkSnapshotFilters(t *testing.T) {
+	snap := &Snapshot{
+		Version: SnapshotVersion,
+		Roots:   []string{"/data"},
+		Entries: []SnapshotEntry{
+			{Path: "/data", Mode: os.ModeDir | 0755},
+			{Path: "/data/big.bin", Size: 1 << 20, Mode: 0644},
+			{Path: "/data/small.txt", Size: 10, Mode: 0644},
+		},
+	}
+
+	minSize := int64(1024)
+	res, err := NewStatsWalker(nil, 1, &Filters{SizeMin: &minSize}).WalkSnapshot(snap)
+	if err != nil {
+		t.Fatalf("WalkSnapshot failed: %v", err)
+	}
+	if res.Summary.Files != 1 || res.Summary.TotalSize != 1<<20 {
+		t.Errorf("got %d files of %d bytes, want 1 of %d", res.Summary.Files, res.Summary.TotalSize, 1<<20)
+	}
+}
+
+func TestSnapshotSplitPath(t *testing.T) {
+	snap := &Snapshot{Roots: []string{"/data/", "/data/projects", "rel"}}
+
+	tests := []struct {
+		path, root, rel string
+	}{
+		{"/data", "/data/", ""},
+		{"/data/a.txt", "/data/", "a.txt"},
+		{"/data/projects/x/y", "/data/projects", "x/y"},
+		{"/data/projectsX", "/data/", "projectsX"},
+		{"rel/z", "rel", "z"},
+		{"/other/file", "", "/other/file"},
+	}
+	for _, tt := range tests {
+		root, rel := snap.splitPath(tt.path)
+		if root != tt.root || rel != tt.rel {
+			t.Errorf("splitPath(%q) = %q, %q; want %q, %q", tt.path, root, rel, tt.root, tt.rel)
+		}
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !linux && !darwin && !freebsd && !windows
+
+package stat
+
+import "errors"
+
+// statFS is not supported on this platform.
+func statFS(path string) (UsageStat, error) {
+	return UsageStat{}, errors.New("file system usage is not supported on this platform")
+}
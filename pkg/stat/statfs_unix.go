@@ -0,0 +1,21 @@
+//go:build linux || darwin || freebsd
+
+package stat
+
+import "golang.org/x/sys/unix"
+
+// statFS returns the capacity and usage of the file system holding path.
+func statFS(path string) (UsageStat, error) {
+	var st unix.Statfs_t
+	if err := unix.Statfs(path, &st); err != nil {
+		return UsageStat{}, err
+	}
+	bsize := uint64(st.Bsize)
+	return UsageStat{
+		TotalBytes:  uint64(st.Blocks) * bsize,
+		FreeBytes:   uint64(st.Bfree) * bsize,
+		AvailBytes:  uint64(st.Bavail) * bsize,
+		TotalInodes: uint64(st.Files),
+		FreeInodes:  uint64(st.Ffree),
+	}, nil
+}
@@ -0,0 +1,17 @@
+package stat
+
+import "golang.org/x/sys/windows"
+
+// statFS returns the capacity and usage of the volume holding path. NTFS
+// has no fixed inode table, so inode counts are left at zero.
+func statFS(path string) (UsageStat, error) {
+	p, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return UsageStat{}, err
+	}
+	var avail, total, free uint64
+	if err := windows.GetDiskFreeSpaceEx(p, &avail, &total, &free); err != nil {
+		return UsageStat{}, err
+	}
+	return UsageStat{TotalBytes: total, FreeBytes: free, AvailBytes: avail}, nil
+}
@@ -0,0 +1,164 @@
+package stat
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// maxSymlinkChainDepth bounds symlink chain resolution. It matches the Linux
+// MAXSYMLINKS limit, beyond which the kernel itself reports ELOOP.
+const maxSymlinkChainDepth = 40
+
+// SymlinkChainStat holds statistics about symbolic link chains.
+// A chain's depth is the number of links followed before reaching a
+// non-symlink or a missing target, so a link to a regular file has depth 1.
+type SymlinkChainStat struct {
+	Chains     int64 // Count of symlinks whose chains were resolved
+	TotalDepth int64 // Sum of all chain depths (for averaging)
+	MaxDepth   int64 // Depth of the longest chain
+	Loops      int64 // Count of chains that loop or exceed maxSymlinkChainDepth
+}
+
+// AvgDepth returns the average chain depth, or 0 if no chains were resolved.
+func (s *SymlinkChainStat) AvgDepth() float64 {
+	if s.Chains == 0 {
+		return 0
+	}
+	return float64(s.TotalDepth) / float64(s.Chains)
+}
+
+// add records a single resolved chain.
+func (s *SymlinkChainStat) add(depth int, loop bool) {
+	s.Chains++
+	s.TotalDepth += int64(depth)
+	if int64(depth) > s.MaxDepth {
+		s.MaxDepth = int64(depth)
+	}
+	if loop {
+		s.Loops++
+	}
+}
+
+// merge folds the statistics of other into s.
+func (s *SymlinkChainStat) merge(other *SymlinkChainStat) {
+	s.Chains += other.Chains
+	s.TotalDepth += other.TotalDepth
+	s.Loops += other.Loops
+	if other.MaxDepth > s.MaxDepth {
+		s.MaxDepth = other.MaxDepth
+	}
+}
+
+// SymlinkStat holds the results of checking symlink targets. Targets are
+// checked lexically against the root the link was found under, so links
+// reaching outside through a symlinked parent directory are not counted as
+// escaping.
+type SymlinkStat struct {
+	Symlinks      int64    // Count of symlinks checked
+	Broken        int64    // Symlinks whose chain ends at a missing target or loops
+	Absolute      int64    // Symlinks with an absolute target
+	Relative      int64    // Symlinks with a relative target
+	Escaping      int64    // Symlinks whose target lies outside the scanned root
+	BrokenPaths   []string // Paths of broken symlinks, sorted
+	EscapingPaths []string // Paths of escaping symlinks, sorted
+}
+
+// add records the target check of a single symlink. Targets that could not
+// be read count as neither absolute nor relative.
+func (s *SymlinkStat) add(fi *FileInfo) {
+	s.Symlinks++
+	if fi.Dangling || fi.SymlinkLoop {
+		s.Broken++
+		s.BrokenPaths = append(s.BrokenPaths, fi.FullPath())
+	}
+	if fi.Target == "" {
+		return
+	}
+
+	target := fi.Target
+	if filepath.IsAbs(target) {
+		s.Absolute++
+	} else {
+		s.Relative++
+		target = filepath.Join(filepath.Dir(filepath.Join(fi.Root, fi.Path)), target)
+	}
+	if escapesRoot(target, fi.Root) {
+		s.Escaping++
+		s.EscapingPaths = append(s.EscapingPaths, fi.FullPath())
+	}
+}
+
+// escapesRoot reports whether target lies outside root. Relative roots are
+// made absolute when the target is absolute.
+func escapesRoot(target, root string) bool {
+	if filepath.IsAbs(target) && !filepath.IsAbs(root) {
+		if abs, err := filepath.Abs(root); err == nil {
+			root = abs
+		}
+	}
+	rel, err := filepath.Rel(root, target)
+	if err != nil {
+		return true
+	}
+	return rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// merge folds the statistics of other into s.
+func (s *SymlinkStat) merge(other *SymlinkStat) {
+	s.Symlinks += other.Symlinks
+	s.Broken += other.Broken
+	s.Absolute += other.Absolute
+	s.Relative += other.Relative
+	s.Escaping += other.Escaping
+	s.BrokenPaths = append(s.BrokenPaths, other.BrokenPaths...)
+	s.EscapingPaths = append(s.EscapingPaths, other.EscapingPaths...)
+}
+
+// sort orders the collected paths.
+func (s *SymlinkStat) sort() {
+	sort.Strings(s.BrokenPaths)
+	sort.Strings(s.EscapingPaths)
+}
+
+// resolveSymlinkChain follows the symlink at path link by link and returns
+// the chain depth, whether a loop was detected, and whether the chain ends
+// at a missing target. Resolution stops at the first entry that is not a
+// symlink or cannot be read, so dangling links report the depth reached
+// before the missing target. Only the final path component is followed at
+// each step; symlinks in parent directories are resolved by the kernel as
+// usual.
+func resolveSymlinkChain(path string) (depth int, loop, dangling bool) {
+	seen := make(map[string]struct{})
+	cur := path
+
+	for {
+		info, err := os.Lstat(cur)
+		if err != nil {
+			return depth, false, depth > 0 && os.IsNotExist(err)
+		}
+		if info.Mode()&os.ModeSymlink == 0 {
+			return depth, false, false
+		}
+
+		if _, ok := seen[cur]; ok {
+			return depth, true, false
+		}
+		if depth >= maxSymlinkChainDepth {
+			return depth, true, false
+		}
+		seen[cur] = struct{}{}
+
+		target, err := os.Readlink(cur)
+		if err != nil {
+			return depth, false, false
+		}
+		depth++
+
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(filepath.Dir(cur), target)
+		}
+		cur = filepath.Clean(target)
+	}
+}
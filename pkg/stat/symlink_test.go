@@ -0,0 +1,129 @@
+package stat
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestResolveSymlinkChain(t *testing.T) {
+	root := t.TempDir()
+
+	mustSymlink := func(target, name string) {
+		if err := os.Symlink(target, filepath.Join(root, name)); err != nil {
+			t.Fatalf("create symlink %s: %v", name, err)
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(root, "file"), []byte("data"), 0644); err != nil {
+		t.Fatalf("create file: %v", err)
+	}
+	mustSymlink("file", "one")
+	mustSymlink("one", "two")
+	mustSymlink(filepath.Join(root, "two"), "three")
+	mustSymlink("missing", "dangling")
+	mustSymlink("loop-b", "loop-a")
+	mustSymlink("loop-a", "loop-b")
+	mustSymlink("self", "self")
+
+	tests := []struct {
+		name         string
+		wantDepth    int
+		wantLoop     bool
+		wantDangling bool
+	}{
+		{"file", 0, false, false},
+		{"one", 1, false, false},
+		{"two", 2, false, false},
+		{"three", 3, false, false},
+		{"dangling", 1, false, true},
+		{"loop-a", 2, true, false},
+		{"self", 1, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			depth, loop, dangling := resolveSymlinkChain(filepath.Join(root, tt.name))
+			if depth != tt.wantDepth || loop != tt.wantLoop || dangling != tt.wantDangling {
+				t.Errorf("got depth=%d loop=%v dangling=%v, want depth=%d loop=%v dangling=%v",
+					depth, loop, dangling, tt.wantDepth, tt.wantLoop, tt.wantDangling)
+			}
+		})
+	}
+}
+
+func TestSymlinkChainStatWalk(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "file"), []byte("data"), 0644); err != nil {
+		t.Fatalf("create file: %v", err)
+	}
+	for target, name := range map[string]string{"file": "one", "one": "two", "self": "self"} {
+		if err := os.Symlink(target, filepath.Join(root, name)); err != nil {
+			t.Fatalf("create symlink %s: %v", name, err)
+		}
+	}
+
+	res, err := NewStatsWalker([]string{root}, 2, &Filters{}).Walk()
+	if err != nil {
+		t.Fatalf("walk failed: %v", err)
+	}
+
+	chains := res.SymlinkChains
+	if chains.Chains != 3 || chains.MaxDepth != 2 || chains.Loops != 1 {
+		t.Errorf("got %+v, want 3 chains, max depth 2, 1 loop", *chains)
+	}
+	if avg := chains.AvgDepth(); avg != 4.0/3.0 {
+		t.Errorf("avg depth: got %v, want %v", avg, 4.0/3.0)
+	}
+}
+
+func TestSymlinkCheckWalk(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "file"), []byte("data"), 0644); err != nil {
+		t.Fatalf("create file: %v", err)
+	}
+	for target, name := range map[string]string{
+		"file":                     "rel",
+		filepath.Join(root, "rel"): "abs",
+		"..":                       "up",
+		"/":                        "slash",
+		"missing":                  "dangling",
+		"self":                     "self",
+	} {
+		if err := os.Symlink(target, filepath.Join(root, name)); err != nil {
+			t.Fatalf("create symlink %s: %v", name, err)
+		}
+	}
+
+	sw := NewStatsWalker([]string{root}, 2, &Filters{})
+	sw.SetSymlinkCheck(true)
+	res, err := sw.Walk()
+	if err != nil {
+		t.Fatalf("walk failed: %v", err)
+	}
+
+	links := res.Symlinks
+	if links == nil {
+		t.Fatal("Symlinks is nil with the check enabled")
+	}
+	if links.Symlinks != 6 || links.Absolute != 2 || links.Relative != 4 {
+		t.Errorf("got %d symlinks, %d absolute, %d relative, want 6, 2, 4", links.Symlinks, links.Absolute, links.Relative)
+	}
+	wantBroken := []string{filepath.Join(root, "dangling"), filepath.Join(root, "self")}
+	if !slices.Equal(links.BrokenPaths, wantBroken) || links.Broken != 2 {
+		t.Errorf("broken: got %d %v, want %v", links.Broken, links.BrokenPaths, wantBroken)
+	}
+	wantEscaping := []string{filepath.Join(root, "slash"), filepath.Join(root, "up")}
+	if !slices.Equal(links.EscapingPaths, wantEscaping) || links.Escaping != 2 {
+		t.Errorf("escaping: got %d %v, want %v", links.Escaping, links.EscapingPaths, wantEscaping)
+	}
+
+	res, err = NewStatsWalker([]string{root}, 2, &Filters{}).Walk()
+	if err != nil {
+		t.Fatalf("walk failed: %v", err)
+	}
+	if res.Symlinks != nil {
+		t.Errorf("Symlinks should be nil without the check, got %+v", *res.Symlinks)
+	}
+}
@@ -0,0 +1,45 @@
+//go:build !windows
+
+package stat
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"strconv"
+	"syscall"
+)
+
+// fillSys sets the owner, file ID and link count of fi from the
+// *syscall.Stat_t carried by info. The lstat and statx backends both
+// provide one, so path is not needed.
+func fillSys(fi *FileInfo, path string, info os.FileInfo) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return
+	}
+	fi.UID = stat.Uid
+	fi.GID = stat.Gid
+	fi.ID = FileID{Dev: uint64(stat.Dev), Ino: uint64(stat.Ino)}
+	fi.Links = uint64(stat.Nlink)
+}
+
+// lookupUsername resolves a UID to a username.
+// Returns a string like "username" on success, or "uid:1000" on lookup failure.
+func lookupUsername(uid uint32) string {
+	u, err := user.LookupId(strconv.FormatUint(uint64(uid), 10))
+	if err != nil {
+		return fmt.Sprintf("uid:%d", uid)
+	}
+	return u.Username
+}
+
+// lookupGroupname resolves a GID to a group name.
+// Returns a string like "staff" on success, or "gid:100" on lookup failure.
+func lookupGroupname(gid uint32) string {
+	g, err := user.LookupGroupId(strconv.FormatUint(uint64(gid), 10))
+	if err != nil {
+		return fmt.Sprintf("gid:%d", gid)
+	}
+	return g.Name
+}
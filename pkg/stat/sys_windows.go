@@ -0,0 +1,99 @@
+//go:build windows
+
+package stat
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	"golang.org/x/sys/windows"
+)
+
+// accounts and groups map the RIDs seen as owners and groups to
+// "DOMAIN\name" account names.
+var accounts, groups sync.Map
+
+// fillSys sets the owner, file ID and link count of fi by opening the
+// entry at path. Windows has no numeric owner IDs, so the relative ID (the
+// last sub-authority) of the owner and group SIDs stands in for UID and GID;
+// the account name is remembered for lookupUsername unless names are
+// numeric. Symlinks are not
+// followed, and their size is set to the length of the target as on Unix.
+func fillSys(fi *FileInfo, path string, info os.FileInfo) {
+	if fi.IsSymlink {
+		if target, err := os.Readlink(path); err == nil {
+			fi.Size = int64(len(target))
+		}
+	}
+
+	p, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return
+	}
+	h, err := windows.CreateFile(p, windows.READ_CONTROL,
+		windows.FILE_SHARE_READ|windows.FILE_SHARE_WRITE|windows.FILE_SHARE_DELETE, nil,
+		windows.OPEN_EXISTING, windows.FILE_FLAG_BACKUP_SEMANTICS|windows.FILE_FLAG_OPEN_REPARSE_POINT, 0)
+	if err != nil {
+		return
+	}
+	defer windows.CloseHandle(h)
+
+	var d windows.ByHandleFileInformation
+	if err := windows.GetFileInformationByHandle(h, &d); err == nil {
+		fi.ID = FileID{
+			Dev: uint64(d.VolumeSerialNumber),
+			Ino: uint64(d.FileIndexHigh)<<32 | uint64(d.FileIndexLow),
+		}
+		fi.Links = uint64(d.NumberOfLinks)
+	}
+
+	sd, err := windows.GetSecurityInfo(h, windows.SE_FILE_OBJECT,
+		windows.OWNER_SECURITY_INFORMATION|windows.GROUP_SECURITY_INFORMATION)
+	if err != nil {
+		return
+	}
+	if owner, _, err := sd.Owner(); err == nil && owner != nil {
+		fi.UID = rid(owner)
+		if _, ok := accounts.Load(fi.UID); !ok && !defaultResolver.Numeric() {
+			if account, domain, _, err := owner.LookupAccount(""); err == nil {
+				accounts.Store(fi.UID, domain+`\`+account)
+			}
+		}
+	}
+	if group, _, err := sd.Group(); err == nil && group != nil {
+		fi.GID = rid(group)
+		if _, ok := groups.Load(fi.GID); !ok && !defaultResolver.Numeric() {
+			if account, domain, _, err := group.LookupAccount(""); err == nil {
+				groups.Store(fi.GID, domain+`\`+account)
+			}
+		}
+	}
+}
+
+// rid returns the relative ID of a SID, or 0 if it has no sub-authorities.
+func rid(sid *windows.SID) uint32 {
+	n := sid.SubAuthorityCount()
+	if n == 0 {
+		return 0
+	}
+	return sid.SubAuthority(uint32(n) - 1)
+}
+
+// lookupUsername resolves an owner RID to the account name seen during the
+// walk. Returns "uid:<rid>" for owners not seen, such as in loaded snapshots.
+func lookupUsername(uid uint32) string {
+	if name, ok := accounts.Load(uid); ok {
+		return name.(string)
+	}
+	return fmt.Sprintf("uid:%d", uid)
+}
+
+// lookupGroupname resolves a group RID to the account name seen during the
+// walk. Returns "gid:<rid>" for groups not seen.
+func lookupGroupname(gid uint32) string {
+	if name, ok := groups.Load(gid); ok {
+		return name.(string)
+	}
+	return fmt.Sprintf("gid:%d", gid)
+}
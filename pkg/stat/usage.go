@@ -0,0 +1,76 @@
+package stat
+
+import "github.com/otuschhoff/cwalk/pkg/sftp"
+
+// UsageStat compares what a walk found below a root with the capacity
+// and usage the file system holding the root reports, so you can see how
+// close each file system is to running out of space or inodes.
+type UsageStat struct {
+	Root         string // Scanned root path
+	TotalBytes   uint64 // File system size in bytes
+	FreeBytes    uint64 // Free bytes, including those reserved for root
+	AvailBytes   uint64 // Bytes available to unprivileged users
+	TotalInodes  uint64 // Inodes the file system can hold (0 if not limited or unknown)
+	FreeInodes   uint64 // Free inodes
+	WalkedSize   int64  // Size of the entries walked below the root
+	WalkedInodes int64  // Count of the entries walked below the root
+}
+
+// UsedBytes returns the bytes in use on the file system.
+func (u *UsageStat) UsedBytes() uint64 {
+	return u.TotalBytes - u.FreeBytes
+}
+
+// UsedInodes returns the inodes in use on the file system.
+func (u *UsageStat) UsedInodes() uint64 {
+	return u.TotalInodes - u.FreeInodes
+}
+
+// BytesPct returns the percentage of bytes in use as df computes it: used
+// bytes over used plus available bytes, so space reserved for root counts
+// as unavailable.
+func (u *UsageStat) BytesPct() float64 {
+	total := u.UsedBytes() + u.AvailBytes
+	if total == 0 {
+		return 0
+	}
+	return 100 * float64(u.UsedBytes()) / float64(total)
+}
+
+// InodesPct returns the percentage of inodes in use, or 0 if the file
+// system does not report an inode limit.
+func (u *UsageStat) InodesPct() float64 {
+	if u.TotalInodes == 0 {
+		return 0
+	}
+	return 100 * float64(u.UsedInodes()) / float64(u.TotalInodes)
+}
+
+// rootUsage queries the file system of each local root and pairs it with
+// the totals walked below that root. Roots whose file system cannot be
+// queried, including sftp:// roots, are left out.
+func rootUsage(roots []string, infos []FileInfo) []UsageStat {
+	walked := make(map[string]*UsageStat)
+	var usage []UsageStat
+	for _, root := range roots {
+		if sftp.IsURL(root) {
+			continue
+		}
+		u, err := statFS(root)
+		if err != nil {
+			continue
+		}
+		u.Root = root
+		usage = append(usage, u)
+	}
+	for i := range usage {
+		walked[usage[i].Root] = &usage[i]
+	}
+	for _, fi := range infos {
+		if u, ok := walked[fi.Root]; ok {
+			u.WalkedSize += fi.Size
+			u.WalkedInodes++
+		}
+	}
+	return usage
+}
@@ -0,0 +1,48 @@
+package stat
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestUsageStatPct(t *testing.T) {
+	u := UsageStat{TotalBytes: 1000, FreeBytes: 300, AvailBytes: 200, TotalInodes: 400, FreeInodes: 100}
+	if got := u.BytesPct(); got != 700.0/900*100 {
+		t.Errorf("BytesPct() = %v", got)
+	}
+	if got := u.InodesPct(); got != 75 {
+		t.Errorf("InodesPct() = %v, want 75", got)
+	}
+	if got := (&UsageStat{}).InodesPct(); got != 0 {
+		t.Errorf("InodesPct() without inode limit = %v, want 0", got)
+	}
+}
+
+func TestRootUsage(t *testing.T) {
+	if runtime.GOOS != "linux" && runtime.GOOS != "darwin" && runtime.GOOS != "freebsd" && runtime.GOOS != "windows" {
+		t.Skip("file system usage not supported")
+	}
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "a"), []byte("data"), 0644); err != nil {
+		t.Fatalf("failed to create file: %v", err)
+	}
+
+	infos := []FileInfo{
+		{Root: root, Path: "", Size: 10},
+		{Root: root, Path: "a", Size: 4},
+		{Root: "/elsewhere", Path: "b", Size: 100},
+	}
+	usage := rootUsage([]string{root, "sftp://host/data", filepath.Join(root, "missing")}, infos)
+	if len(usage) != 1 {
+		t.Fatalf("got %d roots, want 1", len(usage))
+	}
+	u := usage[0]
+	if u.Root != root || u.WalkedInodes != 2 || u.WalkedSize != 14 {
+		t.Errorf("got %+v", u)
+	}
+	if u.TotalBytes == 0 || u.UsedBytes() > u.TotalBytes {
+		t.Errorf("implausible capacity: %+v", u)
+	}
+}
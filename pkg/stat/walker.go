@@ -7,28 +7,85 @@ package stat
 
 import (
 	"fmt"
+	"io/fs"
+	"log/slog"
 	"os"
-	"os/user"
-	"strconv"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
-	"syscall"
+	"sync/atomic"
 	"time"
 
 	cwalk "github.com/otuschhoff/cwalk"
+	"github.com/otuschhoff/cwalk/pkg/sftp"
 )
 
 // FileInfo holds aggregated file information for a single filesystem entry.
 type FileInfo struct {
-	Path      string      // Absolute path to the file
+	Root      string      // Root path the entry was found under
+	Path      string      // Path relative to Root
 	Size      int64       // Size in bytes
 	Mode      os.FileMode // File mode and permissions
 	ModTime   time.Time   // Last modification time
+	BirthTime time.Time   // Creation time (zero if unknown)
 	IsDir     bool        // True if entry is a directory
 	IsSymlink bool        // True if entry is a symbolic link
-	UID       uint32      // User ID of the owner
-	GID       uint32      // Group ID of the owner
+	UID       uint32      // User ID of the owner (RID of the owner SID on Windows)
+	GID       uint32      // Group ID of the owner (RID of the group SID on Windows)
+	ID        FileID      // Identity of the underlying file (zero if unknown)
+	Links     uint64      // Number of hard links (0 if unknown)
+	Archive   string      // Path of the containing archive relative to Root (empty if not in one)
+
+	Xattrs map[string]string // Extended attribute values by name (nil unless collected)
+	Label  string            // SELinux security context (empty unless collected)
+
+	ProjectID   uint32 // Project ID of project quotas (zero unless collected)
+	StripeCount int    // Lustre stripe count (zero unless collected, regular files only)
+	StripeSize  int64  // Lustre stripe size in bytes (zero unless collected)
+
+	Physical int64 // Bytes the data takes on disk after compression (zero unless collected, regular files only)
+
+	SymlinkDepth int    // Symlink chain depth (symlinks only)
+	SymlinkLoop  bool   // True if the symlink chain loops (symlinks only)
+	Dangling     bool   // True if the symlink chain ends at a missing target (symlinks only)
+	Target       string // Symlink target as read by readlink (empty unless checked)
+
+	EmptyDir bool // True if the directory has no entries (directories only, unless checked)
+}
+
+// FullPath returns the root joined with the relative path. Roots are
+// joined with a slash rather than filepath.Join, which would mangle
+// sftp:// URLs.
+func (fi *FileInfo) FullPath() string {
+	if fi.Path == "" {
+		return fi.Root
+	}
+	if fi.Root == "" {
+		return fi.Path
+	}
+	return strings.TrimSuffix(fi.Root, "/") + "/" + fi.Path
+}
+
+// FileID identifies a file independently of its path. Entries with the
+// same nonzero FileID are hard links to the same file.
+type FileID struct {
+	Dev uint64 // Device (volume serial number on Windows)
+	Ino uint64 // Inode number (file index on Windows)
 }
 
+// TimeField selects which timestamp per-year statistics are grouped by.
+type TimeField int
+
+const (
+	// GroupByMtime groups entries by modification year (the default).
+	GroupByMtime TimeField = iota
+	// GroupByBtime groups entries by creation year. Entries with unknown
+	// birth times are grouped under year 0.
+	GroupByBtime
+)
+
 // Results holds all aggregated statistics from a directory walk.
 // It provides multiple dimensions of analysis: summary totals, per-year breakdown,
 // and per-UID (owner) breakdown.
@@ -36,10 +93,80 @@ type Results struct {
 	Summary      *SummaryStat
 	ByYear       map[int]*YearStat   // Year -> stats
 	ByUID        map[uint32]*UIDStat // UID -> stats
+	ByFS         map[uint64]*FSStat  // Device -> stats
+	ByDepth      map[int]*DepthStat  // Depth below the root -> stats
 	TotalFiles   map[string]int64    // Type -> count
 	TotalSize    map[string]int64    // Type -> size
 	TotalInodes  map[string]int64    // Type -> inode count
 	AllFileInfos []FileInfo          // For detailed analysis
+
+	SymlinkChains *SymlinkChainStat          // Symlink chain depth and loop statistics
+	Symlinks      *SymlinkStat               // Symlink target checks (nil unless checked)
+	NameEntropy   map[string]*DirEntropyStat // Directory -> random-name counts
+	FanOut        *FanOutStat                // Entries per directory, unfiltered
+
+	WatchlistMatches []WatchlistMatch // Entries matching the watchlist, sorted by path
+
+	GroupColumns []string              // Cross tabulation columns: dimension names, then "key" for an expression
+	Groups       map[string]*GroupStat // Cross tabulation groups by joined keys, see SortedGroups
+
+	Churn *ChurnStat // Change since a previous snapshot (nil unless compared)
+
+	Quotas []QuotaStat // Usage of quotas, see CheckQuotas (nil unless checked)
+
+	Xattrs  *XattrStat            // Extended attribute and ACL statistics (empty unless collected)
+	ByLabel map[string]*LabelStat // SELinux security context -> stats of labeled entries (empty unless collected)
+
+	Usage []UsageStat // File system capacity and usage per scanned root (empty for snapshots)
+
+	ProjectDirs map[string][]string // Project directory (full path) -> markers found in it, see Projects (empty unless detected)
+
+	Scan ScanStat // Operational details of the scan that produced the results
+
+	ByRoot map[string]*Results // Root path -> results of the entries below it (nil unless separated)
+}
+
+// ScanStat describes the scan that produced a set of results, so reports
+// can carry their operational context.
+type ScanStat struct {
+	Roots     []string      // Root paths that were scanned
+	Entries   int64         // Entries seen, including those filtered out
+	Errors    int64         // Entries and directories that could not be read
+	Duration  time.Duration // Total time of the scan
+	WalkTime  time.Duration // Time spent walking (or replaying a snapshot)
+	MergeTime time.Duration // Time spent merging shards and summarizing
+
+	Files     int64               // Non-directory entries seen, including those filtered out
+	Dirs      int64               // Directories read (zero for snapshots)
+	Syscalls  int64               // stat and readdir calls issued (zero for snapshots)
+	PeakQueue int64               // Most directories queued at once
+	Workers   []cwalk.WorkerStats // Directories and busy time per worker, by ID
+	Rstats    []string            // Roots whose totals were taken from CephFS recursive statistics, see StatsWalker.SetCephFS
+}
+
+// DirsPerSec returns the directories read per second of walk time.
+func (s *ScanStat) DirsPerSec() float64 {
+	return perSec(s.Dirs, s.WalkTime)
+}
+
+// FilesPerSec returns the non-directory entries seen per second of walk
+// time.
+func (s *ScanStat) FilesPerSec() float64 {
+	return perSec(s.Files, s.WalkTime)
+}
+
+// SyscallsPerSec returns the stat and readdir calls issued per second of
+// walk time.
+func (s *ScanStat) SyscallsPerSec() float64 {
+	return perSec(s.Syscalls, s.WalkTime)
+}
+
+// perSec returns the rate of n per d, or 0 if d is not positive.
+func perSec(n int64, d time.Duration) float64 {
+	if d <= 0 {
+		return 0
+	}
+	return float64(n) / d.Seconds()
 }
 
 // SummaryStat holds aggregate statistics across all files.
@@ -55,6 +182,9 @@ type SummaryStat struct {
 	DirsSize     int64 // Total size of directories (usually 0 or block size)
 	SymlinksSize int64 // Total size of symbolic links
 	OthersSize   int64 // Total size of other inode types
+	PhysicalSize int64 // Bytes regular files take on disk after compression (zero unless collected)
+
+	Extremes // Oldest and newest modification, largest file and deepest path
 }
 
 // YearStat holds statistics grouped by modification year.
@@ -92,15 +222,73 @@ type UIDStat struct {
 
 // StatsWalker performs parallel directory traversal with statistics collection.
 // It applies filters to entries and aggregates statistics across multiple dimensions.
-// Safe for concurrent use via mutex-protected results aggregation.
+// Entries are aggregated into independently locked shards that are merged once the
+// walk completes, so workers rarely contend on the same lock.
 type StatsWalker struct {
-	paths   []string   // Directories to walk
-	workers int        // Number of parallel workers
-	filters *Filters   // Filters to apply during walk
-	results *Results   // Aggregated results (protected by mu)
-	mu      sync.Mutex // Protects concurrent access to results
+	paths      []string              // Directories to walk
+	workers    int                   // Number of parallel workers
+	filters    *Filters              // Filters to apply during walk
+	watchlist  *Watchlist            // Ransomware watchlist (nil disables matching)
+	statxMask  cwalk.StatxMask       // statx field mask (0 uses lstat)
+	backend    cwalk.Backend         // Metadata backend
+	order      cwalk.Order           // Traversal order of queued directories
+	inodeOrder bool                  // Stat directory entries in inode order
+	batchSize  int                   // Directory entries read at a time (0 reads whole directories)
+	batches    batchCounts           // Entries of directories being read in batches
+	openDirs   int                   // Max directories open at once (0 derives it from RLIMIT_NOFILE)
+	local      *cwalk.Walker         // Walker of local roots, reused for each (nil until the first)
+	localRoot  string                // Local root being walked
+	maxDepth   int                   // Deepest level recorded, if depthLimit is set
+	depthLimit bool                  // Whether directories below maxDepth are pruned
+	excludes   []*Glob               // Paths pruned from the walk
+	ioLimit    int                   // Max stat/readdir calls in flight (0 = one per worker)
+	maxAuto    int                   // Max workers when auto-tuning (0 disables tuning)
+	throttle   cwalk.Throttle        // IO pacing (zero is unlimited)
+	groupBy    TimeField             // Timestamp per-year statistics are grouped by
+	archives   bool                  // Walk the entries of tar and zip archives
+	sftpConns  int                   // Connections per sftp:// root
+	xattrs     bool                  // Collect extended attributes and ACLs
+	selinux    bool                  // Collect SELinux security contexts
+	lustre     bool                  // Collect project IDs and Lustre stripes
+	cephfs     bool                  // Take totals from CephFS recursive statistics where possible
+	physical   *physicalReader       // Reader of physical file usage (nil disables collection)
+	rstats     map[string]CephRstats // Roots whose totals were taken from recursive statistics
+	linkCheck  bool                  // Read and check symlink targets
+	emptyCheck bool                  // Record directories once read, to detect empty ones
+	pending    pendingDirs           // Directories found but not read yet (empty check only)
+	markers    []string              // Names of project marker entries (nil disables detection)
+	crossDims  []Dimension           // Cross tabulation dimensions (nil disables it)
+	groupExpr  *GroupExpr            // Cross tabulation key expression (nil disables it)
+	results    *Results              // Merged results, populated by Walk
+	entries    atomic.Int64          // Entries seen by the walk
+	errors     atomic.Int64          // Read errors seen by the walk
+	files      atomic.Int64          // Non-directory entries seen by the walk
+	walkStats  cwalk.WalkStats       // Walker statistics summed over the roots
+	logger     *slog.Logger          // Logger of paths that could not be read (nil: slog.Default())
+	shards     []*statsShard         // Partial aggregations, one lock each
+
+	separateRoots bool                  // Also aggregate each root into Results.ByRoot
+	roots         map[string]*rootTally // Scan statistics of each root (separate roots only)
+
+	checkpointPath  string        // File checkpoints are saved to (empty disables them)
+	checkpointEvery time.Duration // Interval between checkpoints
+	resumeFrom      *Checkpoint   // Checkpoint to resume from (nil starts over)
+	entryLog        *entryLog     // Entry log of the checkpoints (nil until the first)
 }
 
+// statsShard holds a partial aggregation of the entries hashed to it.
+type statsShard struct {
+	mu      sync.Mutex
+	results *Results
+	fanOut  map[string]*FanOutStat // Fan-out by root (separate roots only)
+	saved   int                    // Entries of results saved to the checkpoint entry log
+}
+
+// shardsPerWorker controls how many shards are allocated for each worker.
+// More shards than workers keeps the chance of two workers hitting the
+// same shard at the same time low.
+const shardsPerWorker = 4
+
 // NewStatsWalker creates a new statistics walker for the given paths with filters.
 // The workers parameter controls parallelism; typical values are 1-8.
 // If filters is nil, all entries are included.
@@ -109,15 +297,159 @@ func NewStatsWalker(paths []string, workers int, filters *Filters) *StatsWalker
 		paths:   paths,
 		workers: workers,
 		filters: filters,
-		results: &Results{
-			Summary:      &SummaryStat{},
-			ByYear:       make(map[int]*YearStat),
-			ByUID:        make(map[uint32]*UIDStat),
-			TotalFiles:   make(map[string]int64),
-			TotalSize:    make(map[string]int64),
-			TotalInodes:  make(map[string]int64),
-			AllFileInfos: []FileInfo{},
-		},
+		results: newResults(),
+		shards:  newShards(workers),
+	}
+}
+
+// newShards allocates the shards for the given number of workers.
+func newShards(workers int) []*statsShard {
+	numShards := workers * shardsPerWorker
+	if numShards < 1 {
+		numShards = 1
+	}
+	shards := make([]*statsShard, numShards)
+	for i := range shards {
+		shards[i] = &statsShard{results: newResults(), fanOut: make(map[string]*FanOutStat)}
+	}
+	return shards
+}
+
+// SetWatchlist enables matching of non-directory entries against a
+// ransomware watchlist. Matches are reported in Results.WatchlistMatches.
+// Passing nil disables matching.
+func (sw *StatsWalker) SetWatchlist(w *Watchlist) {
+	sw.watchlist = w
+}
+
+// SetLogger makes the walk log paths that could not be read, archives
+// that could not be listed and backend fallbacks through logger instead
+// of slog.Default(). See cwalk.Walker.SetSlogLogger.
+func (sw *StatsWalker) SetLogger(logger *slog.Logger) {
+	sw.logger = logger
+}
+
+// log returns the logger of the walk.
+func (sw *StatsWalker) log() *slog.Logger {
+	if sw.logger != nil {
+		return sw.logger
+	}
+	return slog.Default()
+}
+
+// SetStatx makes the walk use statx with the given field mask instead of
+// lstat. See cwalk.Walker.SetStatx for details; fields left out of the mask
+// are aggregated as zero.
+func (sw *StatsWalker) SetStatx(mask cwalk.StatxMask) {
+	sw.statxMask = mask
+}
+
+// SetBackend selects the metadata backend. See cwalk.Walker.SetBackend.
+func (sw *StatsWalker) SetBackend(b cwalk.Backend) {
+	sw.backend = b
+}
+
+// SetOrder selects the traversal order. See cwalk.Walker.SetOrder.
+func (sw *StatsWalker) SetOrder(o cwalk.Order) {
+	sw.order = o
+}
+
+// SetInodeOrder makes the walk stat the entries of each directory in inode
+// order, for spinning disks. See cwalk.Walker.SetInodeOrder.
+func (sw *StatsWalker) SetInodeOrder(inodeOrder bool) {
+	sw.inodeOrder = inodeOrder
+}
+
+// SetMaxOpenDirs limits the directories held open at once across all
+// workers. See cwalk.Walker.SetMaxOpenDirs.
+func (sw *StatsWalker) SetMaxOpenDirs(n int) {
+	sw.openDirs = n
+}
+
+// SetAutoWorkers lets the walk tune the worker count between 1 and
+// maxWorkers, starting from the count passed to NewStatsWalker.
+// See cwalk.Walker.SetAutoWorkers.
+func (sw *StatsWalker) SetAutoWorkers(maxWorkers int) {
+	sw.maxAuto = maxWorkers
+	if maxWorkers > sw.workers {
+		sw.shards = newShards(maxWorkers)
+	}
+}
+
+// SetGroupBy selects the timestamp per-year statistics are grouped by.
+func (sw *StatsWalker) SetGroupBy(field TimeField) {
+	sw.groupBy = field
+}
+
+// SetArchives makes the walk read .tar, .tar.gz, .tgz and .zip files and
+// include their entries in the statistics under virtual paths below the
+// archive, without extracting them. The archive files are counted as well.
+func (sw *StatsWalker) SetArchives(archives bool) {
+	sw.archives = archives
+}
+
+// SetXattrs makes the walk read the extended attributes of every entry,
+// including POSIX ACLs, for Results.Xattrs and extended attribute filters.
+// This costs one listxattr call per entry plus one getxattr call per
+// attribute. Only supported on Linux.
+func (sw *StatsWalker) SetXattrs(xattrs bool) {
+	sw.xattrs = xattrs
+}
+
+// SetSymlinkCheck makes the walk read the target of every symlink and
+// classify it for Results.Symlinks: broken, absolute or relative, and
+// escaping the root it was found under. This costs one readlink call per
+// symlink.
+func (sw *StatsWalker) SetSymlinkCheck(check bool) {
+	sw.linkCheck = check
+}
+
+// SetSELinux makes the walk read the SELinux security context of every
+// entry, for Results.ByLabel and SELinux type filters. Only supported on
+// Linux.
+func (sw *StatsWalker) SetSELinux(selinux bool) {
+	sw.selinux = selinux
+}
+
+// SetSFTPConnections sets the number of connections opened to the host of
+// each sftp:// root; requests of all workers are spread over them. The
+// default is one.
+func (sw *StatsWalker) SetSFTPConnections(n int) {
+	sw.sftpConns = n
+}
+
+// SetThrottle paces stat and readdir calls. See cwalk.Walker.SetThrottle.
+func (sw *StatsWalker) SetThrottle(t cwalk.Throttle) {
+	sw.throttle = t
+}
+
+// SetIOConcurrency limits the stat and readdir calls in flight across all
+// workers. See cwalk.Walker.SetIOConcurrency.
+func (sw *StatsWalker) SetIOConcurrency(n int) {
+	sw.ioLimit = n
+}
+
+// newResults returns an empty Results with all maps initialized.
+func newResults() *Results {
+	return &Results{
+		Summary:      &SummaryStat{},
+		ByYear:       make(map[int]*YearStat),
+		ByUID:        make(map[uint32]*UIDStat),
+		ByFS:         make(map[uint64]*FSStat),
+		ByDepth:      make(map[int]*DepthStat),
+		TotalFiles:   make(map[string]int64),
+		TotalSize:    make(map[string]int64),
+		TotalInodes:  make(map[string]int64),
+		AllFileInfos: []FileInfo{},
+
+		SymlinkChains: &SymlinkChainStat{},
+		Symlinks:      &SymlinkStat{},
+		NameEntropy:   make(map[string]*DirEntropyStat),
+		FanOut:        &FanOutStat{},
+		Xattrs:        &XattrStat{ByName: make(map[string]*XattrNameStat)},
+		ByLabel:       make(map[string]*LabelStat),
+		Groups:        make(map[string]*GroupStat),
+		ProjectDirs:   make(map[string][]string),
 	}
 }
 
@@ -125,33 +457,354 @@ func NewStatsWalker(paths []string, workers int, filters *Filters) *StatsWalker
 // It walks all configured paths, applies filters, aggregates statistics,
 // and returns the Results object. Returns an error if directory traversal fails.
 func (sw *StatsWalker) Walk() (*Results, error) {
-	// Walk each path
-	for _, rootPath := range sw.paths {
-		if err := sw.walkPath(rootPath); err != nil {
+	start := time.Now()
+
+	done, resume := 0, []string(nil)
+	if sw.resumeFrom != nil {
+		var err error
+		if done, resume, err = sw.restore(sw.resumeFrom); err != nil {
 			return nil, err
 		}
 	}
 
-	// Calculate summary from all collected data
-	sw.calculateSummary()
+	// Walk each path, skipping those a resumed walk completed
+	for i, rootPath := range sw.paths {
+		if i < done {
+			continue
+		}
+		if i > done {
+			resume = nil
+		}
+		if sw.rstatsUsable() && !sftp.IsURL(rootPath) {
+			if rs, ok := readCephRstats(rootPath); ok {
+				sw.addRstats(rootPath, rs)
+				continue
+			}
+		}
+		rootStart, seen, errs, files := time.Now(), sw.entries.Load(), sw.errors.Load(), sw.files.Load()
+		if err := sw.walkPath(i, rootPath, resume); err != nil {
+			return nil, err
+		}
+		if sw.separateRoots {
+			t := sw.rootTally(rootPath)
+			t.entries += sw.entries.Load() - seen
+			t.errors += sw.errors.Load() - errs
+			t.files += sw.files.Load() - files
+			t.walkTime += time.Since(rootStart)
+		}
+		if sw.checkpointPath != "" {
+			if err := sw.saveCheckpoint(i+1, nil); err != nil {
+				return nil, fmt.Errorf("failed to save checkpoint: %w", err)
+			}
+		}
+	}
+	for _, fi := range sw.pending.drain() {
+		sw.record(fi, false)
+	}
+
+	sw.finish(sw.paths, start)
+	sw.results.Usage = rootUsage(sw.paths, sw.results.AllFileInfos)
+	for i := range sw.results.Usage {
+		if rs, ok := sw.rstats[sw.results.Usage[i].Root]; ok {
+			sw.results.Usage[i].WalkedSize += rs.Bytes
+			sw.results.Usage[i].WalkedInodes += rs.Files + rs.Subdirs
+		}
+	}
+	sw.splitRoots(sw.paths)
+	if sw.checkpointPath != "" {
+		if err := sw.removeCheckpoint(); err != nil {
+			return nil, fmt.Errorf("failed to remove checkpoint: %w", err)
+		}
+	}
+	return sw.results, nil
+}
 
+// WalkSnapshot aggregates the entries of a saved snapshot instead of
+// scanning the file system, applying the same filters and watchlist as
+// Walk. This allows exploring a scan offline, for example one taken on an
+// air-gapped cluster and copied over as a file. The paths passed to
+// NewStatsWalker are ignored.
+//
+// Snapshots do not record symlink targets, so symlink chain statistics are
+// left empty.
+func (sw *StatsWalker) WalkSnapshot(s *Snapshot) (*Results, error) {
+	start := time.Now()
+	var parents map[string]bool
+	if sw.emptyCheck {
+		parents = snapshotParents(s)
+	}
+	if sw.separateRoots {
+		for root, fanOut := range snapshotRootFanOuts(s) {
+			sw.rootTally(root).fanOut = *fanOut
+		}
+	}
+	for _, entry := range s.Entries {
+		sw.entries.Add(1)
+		root, relPath := s.splitPath(entry.Path)
+		if sw.separateRoots {
+			sw.rootTally(root).entries++
+		}
+		sw.record(FileInfo{
+			Root:      root,
+			Path:      relPath,
+			Size:      entry.Size,
+			Mode:      entry.Mode,
+			ModTime:   entry.ModTime,
+			BirthTime: entry.BirthTime,
+			IsDir:     entry.Mode.IsDir(),
+			IsSymlink: entry.Mode&os.ModeSymlink != 0,
+			UID:       entry.UID,
+			GID:       entry.GID,
+			EmptyDir:  parents != nil && entry.Mode.IsDir() && !parents[entry.Path],
+		}, false)
+	}
+
+	sw.finish(s.Roots, start)
+	sw.results.FanOut = snapshotFanOut(s)
+	sw.results.SymlinkChains = &SymlinkChainStat{}
+	sw.results.Symlinks = nil
+	sw.splitRoots(s.Roots)
+	for _, r := range sw.results.ByRoot {
+		r.SymlinkChains = &SymlinkChainStat{}
+		r.Symlinks = nil
+	}
 	return sw.results, nil
 }
 
+// record filters a single entry and aggregates it into its shard. If
+// resolve is set, symlink chains are followed on the live file system.
+func (sw *StatsWalker) record(fi FileInfo, resolve bool) {
+	// Markers count whether or not they match the filters
+	if dir, marker, ok := sw.projectMarker(&fi); ok {
+		shard := sw.shardFor(fi.Path)
+		shard.mu.Lock()
+		shard.results.addProject(dir, marker)
+		shard.mu.Unlock()
+	}
+
+	// Apply filters
+	if !sw.filters.Matches(&fi) {
+		return
+	}
+
+	// Resolve symlink chains outside the shard lock
+	if resolve && fi.IsSymlink {
+		fi.SymlinkDepth, fi.SymlinkLoop, fi.Dangling = resolveSymlinkChain(filepath.Join(fi.Root, fi.Path))
+		if sw.linkCheck {
+			fi.Target, _ = os.Readlink(filepath.Join(fi.Root, fi.Path))
+		}
+	}
+
+	match := sw.watchMatch(&fi)
+	year := sw.year(&fi)
+	keys := sw.groupKeys(&fi, year)
+
+	shard := sw.shardFor(fi.Path)
+	shard.mu.Lock()
+	shard.results.add(fi, year)
+	if keys != nil {
+		shard.results.addGroup(keys, &fi)
+	}
+	if match != nil {
+		shard.results.WatchlistMatches = append(shard.results.WatchlistMatches, *match)
+	}
+	shard.mu.Unlock()
+}
+
+// watchMatch returns the watchlist match of an entry, or nil if it does
+// not match or no watchlist is set.
+func (sw *StatsWalker) watchMatch(fi *FileInfo) *WatchlistMatch {
+	if sw.watchlist == nil || fi.IsDir {
+		return nil
+	}
+	if pattern, ok := sw.watchlist.Match(path.Base(fi.Path)); ok {
+		return &WatchlistMatch{Path: fi.FullPath(), Pattern: pattern}
+	}
+	return nil
+}
+
+// groupKeys returns the cross tabulation keys of an entry counted under
+// year, or nil if cross tabulation is disabled.
+func (sw *StatsWalker) groupKeys(fi *FileInfo, year int) []string {
+	if len(sw.crossDims) == 0 && sw.groupExpr == nil {
+		return nil
+	}
+	keys := groupKeys(fi, year, sw.crossDims)
+	if sw.groupExpr != nil {
+		keys = append(keys, sw.groupExpr.Key(fi, year))
+	}
+	return keys
+}
+
+// finish merges the per-shard aggregations into the final results and
+// calculates the summary. start is when the walk over roots began.
+func (sw *StatsWalker) finish(roots []string, start time.Time) {
+	merge := time.Now()
+
+	// Merge the per-shard aggregations into the final results
+	for _, shard := range sw.shards {
+		sw.results.merge(shard.results)
+		shard.results = newResults()
+		for root, s := range shard.fanOut {
+			sw.rootTally(root).fanOut.merge(s)
+		}
+		shard.fanOut = make(map[string]*FanOutStat)
+	}
+	sort.Slice(sw.results.WatchlistMatches, func(i, j int) bool {
+		return sw.results.WatchlistMatches[i].Path < sw.results.WatchlistMatches[j].Path
+	})
+
+	// Calculate summary from all collected data
+	sw.results.calculateSummary()
+	resolveMounts(sw.results.ByFS)
+	sw.resolveUsernames()
+	sw.results.GroupColumns = sw.groupColumns()
+	sw.results.FanOut.sort()
+	if sw.linkCheck {
+		sw.results.Symlinks.sort()
+	} else {
+		sw.results.Symlinks = nil
+	}
+
+	end := time.Now()
+	sw.results.Scan = ScanStat{
+		Roots:     append([]string(nil), roots...),
+		Entries:   sw.entries.Load(),
+		Errors:    sw.errors.Load(),
+		Duration:  end.Sub(start),
+		WalkTime:  merge.Sub(start),
+		MergeTime: end.Sub(merge),
+		Files:     sw.files.Load(),
+		Dirs:      sw.walkStats.Dirs,
+		Syscalls:  sw.walkStats.Syscalls,
+		PeakQueue: sw.walkStats.PeakQueue,
+		Workers:   sw.walkStats.Workers,
+	}
+	for _, root := range roots {
+		if _, ok := sw.rstats[root]; ok {
+			sw.results.Scan.Rstats = append(sw.results.Scan.Rstats, root)
+		}
+	}
+}
+
+// resolveUsernames fills in the usernames of the per-UID statistics. Names
+// are looked up once per UID after merging rather than while aggregating,
+// with one lookup in flight per worker.
+func (sw *StatsWalker) resolveUsernames() {
+	uids := make([]uint32, 0, len(sw.results.ByUID))
+	for uid := range sw.results.ByUID {
+		uids = append(uids, uid)
+	}
+	DefaultResolver().Preload(uids, nil, sw.workers)
+	for uid, us := range sw.results.ByUID {
+		us.Username = Username(uid)
+	}
+}
+
 // walkPath walks a single directory tree using cwalk with the configured workers.
 // It calls the OnLstat callback for each entry, applying filters and aggregating statistics.
-func (sw *StatsWalker) walkPath(rootPath string) error {
-	callbacks := cwalk.Callbacks{
+// Roots given as sftp://[user@]host[:port]/path are walked over SFTP. index is the
+// position of the root in the walked paths and resume the directories left by an
+// interrupted walk of it (nil walks it from the start).
+func (sw *StatsWalker) walkPath(index int, rootPath string, resume []string) error {
+	if sftp.IsURL(rootPath) {
+		fsys, err := sftp.Dial(rootPath, sw.sftpConns)
+		if err != nil {
+			return err
+		}
+		defer fsys.Close()
+		return sw.walkFS(index, rootPath, fsys, resume)
+	}
+
+	walker := sw.localWalker(rootPath)
+	walker.SetStatx(sw.statxMask)
+	walker.SetBackend(sw.backend)
+	walker.SetOrder(sw.order)
+	walker.SetInodeOrder(sw.inodeOrder)
+	walker.SetReadDirBatch(sw.batchSize)
+	walker.SetMaxOpenDirs(sw.openDirs)
+	walker.SetIOConcurrency(sw.ioLimit)
+	walker.SetAutoWorkers(sw.maxAuto)
+	walker.SetThrottle(sw.throttle)
+	walker.SetSlogLogger(sw.logger)
+	sw.setCheckpoint(walker, index, resume)
+	err := walker.Run()
+	sw.addWalkStats(rootPath, walker.Stats())
+	return err
+}
+
+// localWalker returns the walker of local roots, set up to walk rootPath.
+// It is created for the first root and reset for the others, so all local
+// roots are walked by the same walker.
+func (sw *StatsWalker) localWalker(rootPath string) *cwalk.Walker {
+	sw.localRoot = rootPath
+	if sw.local == nil {
+		sw.local = cwalk.NewWalker(rootPath, sw.workers, sw.callbacks(&sw.localRoot, true))
+		return sw.local
+	}
+	sw.local.Reset()
+	sw.local.SetRoots(rootPath)
+	return sw.local
+}
+
+// walkFS walks a remote tree presented as an fs.FS, recording entries
+// under rootPath. Owners come from the SFTP attributes; symlink chains and
+// archives are not followed since that would need local access.
+func (sw *StatsWalker) walkFS(index int, rootPath string, fsys fs.FS, resume []string) error {
+	walker := cwalk.NewWalkerFS(fsys, ".", sw.workers, sw.callbacks(&rootPath, false))
+	walker.SetOrder(sw.order)
+	walker.SetReadDirBatch(sw.batchSize)
+	walker.SetIOConcurrency(sw.ioLimit)
+	walker.SetAutoWorkers(sw.maxAuto)
+	walker.SetThrottle(sw.throttle)
+	walker.SetSlogLogger(sw.logger)
+	sw.setCheckpoint(walker, index, resume)
+	err := walker.Run()
+	sw.addWalkStats(rootPath, walker.Stats())
+	return err
+}
+
+// setCheckpoint configures the checkpoints and resumption of the walk of
+// the root at index.
+func (sw *StatsWalker) setCheckpoint(walker *cwalk.Walker, index int, resume []string) {
+	if resume != nil {
+		walker.SetResume(resume)
+	}
+	if sw.checkpointPath != "" {
+		walker.SetCheckpoint(sw.checkpointEvery, func(pending []string) error {
+			if len(pending) == 0 {
+				return sw.saveCheckpoint(index+1, nil)
+			}
+			return sw.saveCheckpoint(index, pending)
+		})
+	}
+}
+
+// callbacks returns the walk callbacks recording entries under the root
+// path in root, which may change between walks but not during one. local
+// is false for remote trees, whose entries cannot be opened.
+func (sw *StatsWalker) callbacks(root *string, local bool) cwalk.Callbacks {
+	return cwalk.Callbacks{
 		OnLstat: func(isDir bool, relPath string, info os.FileInfo, err error) {
+			rootPath := *root
+			if sw.excludes != nil && sw.excluded(relPath) || sw.tooDeep(relPath) {
+				return
+			}
+			sw.entries.Add(1)
 			if err != nil {
+				sw.errors.Add(1)
 				return
 			}
 			if info == nil {
 				return
 			}
+			if !info.IsDir() {
+				sw.files.Add(1)
+			}
 
 			// Extract file info
 			fi := FileInfo{
+				Root:    rootPath,
 				Path:    relPath,
 				Size:    info.Size(),
 				Mode:    info.Mode(),
@@ -164,121 +817,346 @@ func (sw *StatsWalker) walkPath(rootPath string) error {
 				fi.IsSymlink = true
 			}
 
-			// Get UID/GID from syscall.Stat_t
-			if stat, ok := info.Sys().(*syscall.Stat_t); ok {
-				fi.UID = stat.Uid
-				fi.GID = stat.Gid
-			}
-
-			// Apply filters
-			if !sw.filters.Matches(&fi) {
+			if !local {
+				if attrs, ok := info.Sys().(*sftp.Attrs); ok {
+					fi.UID, fi.GID = attrs.UID, attrs.GID
+				}
+				if sw.physical != nil && fi.Mode.IsRegular() {
+					// Remote files count as uncompressed
+					fi.Physical = fi.Size
+				}
+				if sw.emptyCheck && fi.IsDir {
+					sw.pending.put(fi)
+					return
+				}
+				sw.record(fi, false)
 				return
 			}
 
-			sw.mu.Lock()
-			defer sw.mu.Unlock()
-
-			// Record the file info
-			sw.results.AllFileInfos = append(sw.results.AllFileInfos, fi)
-
-			// Determine type
-			fileType := "other"
-			if fi.IsDir {
-				fileType = "dir"
-			} else if fi.IsSymlink {
-				fileType = "symlink"
-			} else {
-				fileType = "file"
+			if btime, ok := cwalk.BirthTime(info); ok {
+				fi.BirthTime = btime
 			}
 
-			// Update counts
-			sw.results.TotalFiles[fileType]++
-			sw.results.TotalSize[fileType] += fi.Size
-			sw.results.TotalInodes[fileType]++
+			// Get owner, file ID and link count from the platform
+			fillSys(&fi, filepath.Join(rootPath, relPath), info)
+			if sw.xattrs {
+				fi.Xattrs = readXattrs(filepath.Join(rootPath, relPath))
+			}
+			if sw.selinux {
+				if label, ok := fi.Xattrs[selinuxXattr]; ok {
+					fi.Label = trimLabel(label)
+				} else if !sw.xattrs {
+					fi.Label = readSELinuxLabel(filepath.Join(rootPath, relPath))
+				}
+			}
 
-			// Update year stats
-			year := fi.ModTime.Year()
-			if _, ok := sw.results.ByYear[year]; !ok {
-				sw.results.ByYear[year] = &YearStat{Year: year}
+			if sw.lustre {
+				readLustre(filepath.Join(rootPath, relPath), &fi)
 			}
-			ys := sw.results.ByYear[year]
-			ys.TotalInodes++
-			ys.TotalSize += fi.Size
-			switch fileType {
-			case "file":
-				ys.Files++
-				ys.FilesSize += fi.Size
-			case "dir":
-				ys.Dirs++
-				ys.DirsSize += fi.Size
-			case "symlink":
-				ys.Symlinks++
-				ys.SymlinksSize += fi.Size
-			default:
-				ys.Others++
-				ys.OthersSize += fi.Size
+			if sw.physical != nil && fi.Mode.IsRegular() {
+				fi.Physical = sw.physical.read(filepath.Join(rootPath, relPath), info)
 			}
 
-			// Update UID stats
-			if _, ok := sw.results.ByUID[fi.UID]; !ok {
-				username := lookupUsername(fi.UID)
-				sw.results.ByUID[fi.UID] = &UIDStat{
-					UID:      fi.UID,
-					Username: username,
-				}
+			if sw.emptyCheck && fi.IsDir {
+				sw.pending.put(fi)
+				return
+			}
+			sw.record(fi, true)
+
+			if sw.archives && fi.Mode.IsRegular() && archiveFormatOf(relPath) != notArchive {
+				sw.walkArchive(fi)
 			}
-			us := sw.results.ByUID[fi.UID]
-			us.TotalInodes++
-			us.TotalSize += fi.Size
-			switch fileType {
-			case "file":
-				us.Files++
-				us.FilesSize += fi.Size
-			case "dir":
-				us.Dirs++
-				us.DirsSize += fi.Size
-			case "symlink":
-				us.Symlinks++
-				us.SymlinksSize += fi.Size
-			default:
-				us.Others++
-				us.OthersSize += fi.Size
+		},
+		OnReadDir: func(relPath string, entries []os.DirEntry, err error) {
+			sw.dirRead(*root, relPath, len(entries), err)
+		},
+		ShouldDescend: sw.shouldDescend,
+		OnReadDirBatch: func(relPath string, entries []os.DirEntry, err error) {
+			rootPath := *root
+			dir := FileInfo{Root: rootPath, Path: relPath}
+			if len(entries) > 0 && err == nil {
+				sw.batches.add(dir.FullPath(), len(entries))
+				return
 			}
+			// Last batch: the directory is read completely or failed
+			sw.dirRead(rootPath, relPath, sw.batches.take(dir.FullPath())+len(entries), err)
 		},
 	}
+}
 
-	walker := cwalk.NewWalker(rootPath, sw.workers, callbacks)
-	return walker.Run()
+// dirRead records a directory read with n entries, or failed with err: its
+// fan-out and, with the empty check, the directory held until now.
+func (sw *StatsWalker) dirRead(rootPath, relPath string, n int, err error) {
+	if sw.depthLimit && sw.maxDepth == 0 {
+		// Only the roots are recorded, not their contents
+		return
+	}
+	dir := FileInfo{Root: rootPath, Path: relPath}
+	if err != nil {
+		sw.errors.Add(1)
+	} else {
+		shard := sw.shardFor(relPath)
+		shard.mu.Lock()
+		shard.results.FanOut.add(dir.FullPath(), n)
+		if sw.separateRoots {
+			rootFanOut(shard.fanOut, rootPath).add(dir.FullPath(), n)
+		}
+		shard.mu.Unlock()
+	}
+	if sw.emptyCheck {
+		if fi, ok := sw.pending.take(dir.FullPath()); ok {
+			fi.EmptyDir = err == nil && n == 0
+			sw.record(fi, false)
+		}
+	}
 }
 
-func (sw *StatsWalker) calculateSummary() {
-	sum := sw.results.Summary
+// shardFor picks the shard for a relative path using an FNV-1a hash.
+func (sw *StatsWalker) shardFor(relPath string) *statsShard {
+	const (
+		offset32 = 2166136261
+		prime32  = 16777619
+	)
+	h := uint32(offset32)
+	for i := 0; i < len(relPath); i++ {
+		h ^= uint32(relPath[i])
+		h *= prime32
+	}
+	return sw.shards[h%uint32(len(sw.shards))]
+}
 
-	for _, count := range sw.results.TotalInodes {
-		sum.TotalInodes += count
+// year returns the year an entry is grouped under in per-year statistics,
+// or 0 if its timestamp is unknown.
+func (sw *StatsWalker) year(fi *FileInfo) int {
+	t := fi.ModTime
+	if sw.groupBy == GroupByBtime {
+		t = fi.BirthTime
+	}
+	if t.IsZero() {
+		return 0
 	}
+	return t.Year()
+}
 
-	for _, size := range sw.results.TotalSize {
-		sum.TotalSize += size
+// add records a single filtered entry in the per-type, per-year and per-UID
+// tallies, counting it under the given year.
+func (r *Results) add(fi FileInfo, year int) {
+	// Record the file info
+	r.AllFileInfos = append(r.AllFileInfos, fi)
+	r.addNameEntropy(&fi)
+	r.Xattrs.add(fi.Xattrs, fi.HasACL())
+	if fi.Label != "" {
+		ls, ok := r.ByLabel[fi.Label]
+		if !ok {
+			ls = &LabelStat{Label: fi.Label}
+			r.ByLabel[fi.Label] = ls
+		}
+		ls.Entries++
+		ls.Size += fi.Size
+	}
+
+	r.Summary.Extremes.add(&fi)
+	if fi.Mode.IsRegular() {
+		r.Summary.PhysicalSize += fi.Physical
+	}
+
+	// Determine type
+	fileType := "other"
+	if fi.IsDir {
+		fileType = "dir"
+	} else if fi.IsSymlink {
+		fileType = "symlink"
+		r.SymlinkChains.add(fi.SymlinkDepth, fi.SymlinkLoop)
+		r.Symlinks.add(&fi)
+	} else {
+		fileType = "file"
 	}
 
-	sum.Files = sw.results.TotalFiles["file"]
-	sum.Dirs = sw.results.TotalFiles["dir"]
-	sum.Symlinks = sw.results.TotalFiles["symlink"]
-	sum.Others = sw.results.TotalFiles["other"]
+	// Update counts
+	r.TotalFiles[fileType]++
+	r.TotalSize[fileType] += fi.Size
+	r.TotalInodes[fileType]++
+
+	// Update year stats
+	if _, ok := r.ByYear[year]; !ok {
+		r.ByYear[year] = &YearStat{Year: year}
+	}
+	ys := r.ByYear[year]
+	ys.TotalInodes++
+	ys.TotalSize += fi.Size
+	switch fileType {
+	case "file":
+		ys.Files++
+		ys.FilesSize += fi.Size
+	case "dir":
+		ys.Dirs++
+		ys.DirsSize += fi.Size
+	case "symlink":
+		ys.Symlinks++
+		ys.SymlinksSize += fi.Size
+	default:
+		ys.Others++
+		ys.OthersSize += fi.Size
+	}
 
-	sum.FilesSize = sw.results.TotalSize["file"]
-	sum.DirsSize = sw.results.TotalSize["dir"]
-	sum.SymlinksSize = sw.results.TotalSize["symlink"]
-	sum.OthersSize = sw.results.TotalSize["other"]
+	// Update UID stats
+	if _, ok := r.ByUID[fi.UID]; !ok {
+		r.ByUID[fi.UID] = &UIDStat{UID: fi.UID}
+	}
+	us := r.ByUID[fi.UID]
+	us.TotalInodes++
+	us.TotalSize += fi.Size
+	switch fileType {
+	case "file":
+		us.Files++
+		us.FilesSize += fi.Size
+	case "dir":
+		us.Dirs++
+		us.DirsSize += fi.Size
+	case "symlink":
+		us.Symlinks++
+		us.SymlinksSize += fi.Size
+	default:
+		us.Others++
+		us.OthersSize += fi.Size
+	}
+
+	// Update file system stats
+	fss, ok := r.ByFS[fi.ID.Dev]
+	if !ok {
+		fss = &FSStat{Dev: fi.ID.Dev}
+		r.ByFS[fi.ID.Dev] = fss
+	}
+	fss.add(fileType, fi.FullPath(), fi.Size)
+
+	// Update depth stats
+	d := fi.Depth()
+	ds, ok := r.ByDepth[d]
+	if !ok {
+		ds = &DepthStat{Depth: d}
+		r.ByDepth[d] = ds
+	}
+	ds.add(fileType, fi.Size)
 }
 
-// lookupUsername resolves a UID to a username.
-// Returns a string like "username" on success, or "uid:1000" on lookup failure.
-func lookupUsername(uid uint32) string {
-	u, err := user.LookupId(strconv.FormatUint(uint64(uid), 10))
-	if err != nil {
-		return fmt.Sprintf("uid:%d", uid)
+// merge folds the tallies of other into r. Of the summary only the
+// extremes are merged; the counts are derived from the per-type totals by
+// calculateSummary.
+func (r *Results) merge(other *Results) {
+	r.AllFileInfos = append(r.AllFileInfos, other.AllFileInfos...)
+	r.Summary.Extremes.merge(&other.Summary.Extremes)
+	r.Summary.PhysicalSize += other.Summary.PhysicalSize
+
+	for k, v := range other.TotalFiles {
+		r.TotalFiles[k] += v
+	}
+	for k, v := range other.TotalSize {
+		r.TotalSize[k] += v
+	}
+	for k, v := range other.TotalInodes {
+		r.TotalInodes[k] += v
+	}
+	r.SymlinkChains.merge(other.SymlinkChains)
+	r.Symlinks.merge(other.Symlinks)
+	r.FanOut.merge(other.FanOut)
+	r.mergeGroups(other)
+	r.mergeProjects(other)
+	r.Xattrs.merge(other.Xattrs)
+	for label, s := range other.ByLabel {
+		ls, ok := r.ByLabel[label]
+		if !ok {
+			ls = &LabelStat{Label: label}
+			r.ByLabel[label] = ls
+		}
+		ls.Entries += s.Entries
+		ls.Size += s.Size
+	}
+	r.WatchlistMatches = append(r.WatchlistMatches, other.WatchlistMatches...)
+
+	for dir, s := range other.NameEntropy {
+		ds, ok := r.NameEntropy[dir]
+		if !ok {
+			ds = &DirEntropyStat{Path: dir}
+			r.NameEntropy[dir] = ds
+		}
+		ds.Entries += s.Entries
+		ds.Random += s.Random
+	}
+
+	for year, s := range other.ByYear {
+		ys, ok := r.ByYear[year]
+		if !ok {
+			ys = &YearStat{Year: year}
+			r.ByYear[year] = ys
+		}
+		ys.TotalSize += s.TotalSize
+		ys.TotalInodes += s.TotalInodes
+		ys.Files += s.Files
+		ys.Dirs += s.Dirs
+		ys.Symlinks += s.Symlinks
+		ys.Others += s.Others
+		ys.FilesSize += s.FilesSize
+		ys.DirsSize += s.DirsSize
+		ys.SymlinksSize += s.SymlinksSize
+		ys.OthersSize += s.OthersSize
+	}
+
+	for uid, s := range other.ByUID {
+		us, ok := r.ByUID[uid]
+		if !ok {
+			us = &UIDStat{UID: uid}
+			r.ByUID[uid] = us
+		}
+		us.TotalSize += s.TotalSize
+		us.TotalInodes += s.TotalInodes
+		us.Files += s.Files
+		us.Dirs += s.Dirs
+		us.Symlinks += s.Symlinks
+		us.Others += s.Others
+		us.FilesSize += s.FilesSize
+		us.DirsSize += s.DirsSize
+		us.SymlinksSize += s.SymlinksSize
+		us.OthersSize += s.OthersSize
+	}
+
+	for dev, s := range other.ByFS {
+		fss, ok := r.ByFS[dev]
+		if !ok {
+			fss = &FSStat{Dev: dev}
+			r.ByFS[dev] = fss
+		}
+		fss.merge(s)
+	}
+
+	for d, s := range other.ByDepth {
+		ds, ok := r.ByDepth[d]
+		if !ok {
+			ds = &DepthStat{Depth: d}
+			r.ByDepth[d] = ds
+		}
+		ds.merge(s)
 	}
-	return u.Username
+}
+
+// calculateSummary derives the summary counts from the per-type totals.
+func (r *Results) calculateSummary() {
+	sum := r.Summary
+
+	for _, count := range r.TotalInodes {
+		sum.TotalInodes += count
+	}
+
+	for _, size := range r.TotalSize {
+		sum.TotalSize += size
+	}
+
+	sum.Files = r.TotalFiles["file"]
+	sum.Dirs = r.TotalFiles["dir"]
+	sum.Symlinks = r.TotalFiles["symlink"]
+	sum.Others = r.TotalFiles["other"]
+
+	sum.FilesSize = r.TotalSize["file"]
+	sum.DirsSize = r.TotalSize["dir"]
+	sum.SymlinksSize = r.TotalSize["symlink"]
+	sum.OthersSize = r.TotalSize["other"]
 }
@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"sync"
 	"testing"
+	"testing/fstest"
 	"time"
 )
 
@@ -214,12 +215,13 @@ func TestWalkerConcurrency(t *testing.T) {
 		t.Fatal("results should be initialized")
 	}
 
-	// The mu field should exist and be zero-initialized
-	// We can't directly test mutex functionality without actual concurrent access,
-	// but we can verify the walker was created properly
+	// Each worker gets several independently locked shards
 	if walker.workers != 4 {
 		t.Errorf("workers mismatch: got %d, want %d", walker.workers, 4)
 	}
+	if len(walker.shards) != 4*shardsPerWorker {
+		t.Errorf("shards mismatch: got %d, want %d", len(walker.shards), 4*shardsPerWorker)
+	}
 }
 
 // Test that repeated walks always start and collect entries (guards against race conditions).
@@ -293,6 +295,71 @@ func TestWalkStartsConcurrently(t *testing.T) {
 	}
 }
 
+// Walks with different worker counts must aggregate to identical totals.
+func TestWalkShardedTotalsMatch(t *testing.T) {
+	root := setupStatsTree(t, 20, 10)
+
+	var want *Results
+	for _, workers := range []int{1, 2, 8, 16} {
+		res, err := NewStatsWalker([]string{root}, workers, &Filters{}).Walk()
+		if err != nil {
+			t.Fatalf("walk with %d workers failed: %v", workers, err)
+		}
+		if want == nil {
+			want = res
+			continue
+		}
+		if *res.Summary != *want.Summary {
+			t.Errorf("workers=%d: summary %+v, want %+v", workers, *res.Summary, *want.Summary)
+		}
+		if len(res.AllFileInfos) != len(want.AllFileInfos) {
+			t.Errorf("workers=%d: %d file infos, want %d", workers, len(res.AllFileInfos), len(want.AllFileInfos))
+		}
+		for year, ys := range want.ByYear {
+			if got, ok := res.ByYear[year]; !ok || *got != *ys {
+				t.Errorf("workers=%d: year %d stats mismatch", workers, year)
+			}
+		}
+		for uid, us := range want.ByUID {
+			if got, ok := res.ByUID[uid]; !ok || *got != *us {
+				t.Errorf("workers=%d: uid %d stats mismatch", workers, uid)
+			}
+		}
+	}
+
+	// 20 directories plus the root itself
+	if want.Summary.Files != 200 || want.Summary.Dirs != 21 {
+		t.Errorf("got %d files and %d dirs, want 200 and 21", want.Summary.Files, want.Summary.Dirs)
+	}
+}
+
+func TestResultsMerge(t *testing.T) {
+	a := newResults()
+	b := newResults()
+	mtime := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+	a.add(FileInfo{Path: "a", Size: 10, ModTime: mtime, UID: 1}, mtime.Year())
+	b.add(FileInfo{Path: "b", Size: 20, ModTime: mtime, UID: 1}, mtime.Year())
+	b.add(FileInfo{Path: "c", Size: 5, ModTime: mtime, UID: 2, IsDir: true}, mtime.Year())
+
+	a.merge(b)
+
+	if len(a.AllFileInfos) != 3 {
+		t.Errorf("file infos: got %d, want 3", len(a.AllFileInfos))
+	}
+	if a.TotalSize["file"] != 30 || a.TotalFiles["dir"] != 1 {
+		t.Errorf("type totals mismatch: size=%v files=%v", a.TotalSize, a.TotalFiles)
+	}
+	if ys := a.ByYear[2023]; ys.Files != 2 || ys.Dirs != 1 || ys.TotalSize != 35 {
+		t.Errorf("year stats mismatch: %+v", *ys)
+	}
+	if us := a.ByUID[1]; us.Files != 2 || us.FilesSize != 30 {
+		t.Errorf("uid 1 stats mismatch: %+v", *us)
+	}
+	if us := a.ByUID[2]; us.Dirs != 1 || us.DirsSize != 5 {
+		t.Errorf("uid 2 stats mismatch: %+v", *us)
+	}
+}
+
 func TestLookupUsername(t *testing.T) {
 	// Test that lookupUsername returns a string
 	result := lookupUsername(0)
@@ -313,3 +380,219 @@ func TestLookupUsername(t *testing.T) {
 	// Should be in format "uid:999999" if not found
 	t.Logf("lookupUsername(999999) returned: %s", result)
 }
+
+// TestGroupByBtime verifies that per-year statistics follow the selected
+// timestamp and that unknown birth times are grouped under year 0.
+func TestGroupByBtime(t *testing.T) {
+	mtime := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	snapshot := &Snapshot{
+		Roots: []string{"/data"},
+		Entries: []SnapshotEntry{
+			{Path: "/data/a", Size: 10, ModTime: mtime, BirthTime: time.Date(2019, 5, 1, 0, 0, 0, 0, time.UTC)},
+			{Path: "/data/b", Size: 20, ModTime: mtime},
+		},
+	}
+
+	sw := NewStatsWalker(nil, 1, &Filters{})
+	sw.SetGroupBy(GroupByBtime)
+	res, err := sw.WalkSnapshot(snapshot)
+	if err != nil {
+		t.Fatalf("WalkSnapshot failed: %v", err)
+	}
+	if len(res.ByYear) != 2 || res.ByYear[2019] == nil || res.ByYear[0] == nil {
+		t.Fatalf("years = %v, want 2019 and 0", res.ByYear)
+	}
+	if res.ByYear[2019].TotalSize != 10 || res.ByYear[0].TotalSize != 20 {
+		t.Errorf("sizes = %d and %d, want 10 and 20", res.ByYear[2019].TotalSize, res.ByYear[0].TotalSize)
+	}
+
+	res, err = NewStatsWalker(nil, 1, &Filters{}).WalkSnapshot(snapshot)
+	if err != nil {
+		t.Fatalf("WalkSnapshot failed: %v", err)
+	}
+	if len(res.ByYear) != 1 || res.ByYear[2024] == nil {
+		t.Errorf("years by mtime = %v, want 2024", res.ByYear)
+	}
+}
+
+// TestWalkScanStat verifies that the walk records its roots, timing, and
+// entry and error counts.
+func TestWalkScanStat(t *testing.T) {
+	root := setupStatsTree(t, 2, 3)
+	missing := filepath.Join(t.TempDir(), "missing")
+
+	res, err := NewStatsWalker([]string{root, missing}, 2, &Filters{}).Walk()
+	if err != nil {
+		t.Fatalf("walk failed: %v", err)
+	}
+
+	scan := res.Scan
+	if len(scan.Roots) != 2 || scan.Roots[0] != root || scan.Roots[1] != missing {
+		t.Errorf("roots = %v, want [%s %s]", scan.Roots, root, missing)
+	}
+	// The root, 2 directories, 6 files and the missing root
+	if scan.Entries != 10 || scan.Errors != 1 {
+		t.Errorf("entries = %d, errors = %d, want 10 and 1", scan.Entries, scan.Errors)
+	}
+	if scan.Duration <= 0 || scan.Duration != scan.WalkTime+scan.MergeTime {
+		t.Errorf("duration %v, want walk %v + merge %v", scan.Duration, scan.WalkTime, scan.MergeTime)
+	}
+	// Both roots are read or tried by the same 2 workers
+	if scan.Files != 6 || scan.Dirs != 4 || scan.Syscalls == 0 || len(scan.Workers) != 2 {
+		t.Errorf("files = %d, dirs = %d, syscalls = %d, %d workers, want 6, 4, some and 2",
+			scan.Files, scan.Dirs, scan.Syscalls, len(scan.Workers))
+	}
+	if scan.DirsPerSec() <= 0 || scan.FilesPerSec() <= 0 {
+		t.Errorf("rates %v dirs/s and %v files/s, want positive", scan.DirsPerSec(), scan.FilesPerSec())
+	}
+}
+
+// TestWalkFileIDs verifies that hard links share a file ID and report
+// their link count.
+func TestWalkFileIDs(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "a"), []byte("data"), 0644); err != nil {
+		t.Fatalf("failed to create file: %v", err)
+	}
+	if err := os.Link(filepath.Join(root, "a"), filepath.Join(root, "b")); err != nil {
+		t.Skipf("hard links not supported: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "c"), []byte("data"), 0644); err != nil {
+		t.Fatalf("failed to create file: %v", err)
+	}
+
+	res, err := NewStatsWalker([]string{root}, 2, &Filters{}).Walk()
+	if err != nil {
+		t.Fatalf("walk failed: %v", err)
+	}
+
+	infos := make(map[string]FileInfo)
+	for _, fi := range res.AllFileInfos {
+		infos[fi.Path] = fi
+	}
+	a, b, c := infos["a"], infos["b"], infos["c"]
+	if a.ID == (FileID{}) {
+		t.Fatal("file ID not set")
+	}
+	if a.ID != b.ID {
+		t.Errorf("hard links have IDs %+v and %+v, want equal", a.ID, b.ID)
+	}
+	if a.ID == c.ID {
+		t.Errorf("distinct files share ID %+v", a.ID)
+	}
+	if a.Links != 2 || c.Links != 1 {
+		t.Errorf("links = %d and %d, want 2 and 1", a.Links, c.Links)
+	}
+}
+
+// TestWalkFS verifies that remote trees are aggregated under their URL and
+// that entries are never opened locally.
+func TestWalkFS(t *testing.T) {
+	files := fstest.MapFS{
+		"a.txt":       {Data: []byte("hello")},
+		"dir/b.tar":   {Data: []byte("not opened")},
+		"dir/missing": {Data: []byte("x"), Mode: os.ModeSymlink},
+	}
+
+	sw := NewStatsWalker(nil, 2, &Filters{})
+	sw.SetArchives(true)
+	if err := sw.walkFS(0, "sftp://host/data", files, nil); err != nil {
+		t.Fatalf("walk failed: %v", err)
+	}
+	sw.finish([]string{"sftp://host/data"}, time.Now())
+	res := sw.results
+
+	// The root, a.txt, dir, dir/b.tar and dir/missing
+	if res.Summary.TotalInodes != 5 || res.Summary.Symlinks != 1 || res.Scan.Errors != 0 {
+		t.Errorf("summary = %+v, errors = %d, want 5 inodes with 1 symlink and no errors", res.Summary, res.Scan.Errors)
+	}
+	for _, fi := range res.AllFileInfos {
+		if fi.Root != "sftp://host/data" {
+			t.Errorf("root of %q = %q, want the URL", fi.Path, fi.Root)
+		}
+		if fi.SymlinkDepth != 0 || fi.Archive != "" {
+			t.Errorf("%q was resolved locally: %+v", fi.Path, fi)
+		}
+	}
+}
+
+// setupStatsTree creates numDirs directories each holding filesPerDir files.
+func setupStatsTree(tb testing.TB, numDirs, filesPerDir int) string {
+	root := tb.TempDir()
+	for d := 0; d < numDirs; d++ {
+		dir := filepath.Join(root, fmt.Sprintf("dir%03d", d))
+		if err := os.Mkdir(dir, 0755); err != nil {
+			tb.Fatalf("create dir: %v", err)
+		}
+		for f := 0; f < filesPerDir; f++ {
+			path := filepath.Join(dir, fmt.Sprintf("file%03d.txt", f))
+			if err := os.WriteFile(path, []byte("data"), 0644); err != nil {
+				tb.Fatalf("create file: %v", err)
+			}
+		}
+	}
+	return root
+}
+
+// BenchmarkStatsWalkerScaling measures aggregation throughput as workers are
+// added. With sharded aggregation ns/op should drop close to linearly until
+// the filesystem itself becomes the bottleneck.
+func BenchmarkStatsWalkerScaling(b *testing.B) {
+	root := setupStatsTree(b, 200, 50)
+
+	for _, workers := range []int{1, 2, 4, 8, 16} {
+		b.Run(fmt.Sprintf("workers_%d", workers), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := NewStatsWalker([]string{root}, workers, &Filters{}).Walk(); err != nil {
+					b.Fatalf("walk failed: %v", err)
+				}
+			}
+		})
+	}
+}
+
+// TestWalkByFS verifies that a tree on one file system is counted under a
+// single device whose mount point contains the root.
+func TestWalkByFS(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "a"), []byte("data"), 0644); err != nil {
+		t.Fatalf("failed to create file: %v", err)
+	}
+
+	res, err := NewStatsWalker([]string{root}, 2, &Filters{}).Walk()
+	if err != nil {
+		t.Fatalf("walk failed: %v", err)
+	}
+
+	if len(res.ByFS) != 1 {
+		t.Fatalf("got %d file systems, want 1", len(res.ByFS))
+	}
+	for dev, s := range res.ByFS {
+		if dev == 0 {
+			t.Error("device not set")
+		}
+		if s.Files != 1 || s.Dirs != 1 || s.TotalSize < 4 {
+			t.Errorf("got %d files, %d dirs, %d bytes", s.Files, s.Dirs, s.TotalSize)
+		}
+		if !isWithin(root, s.Mountpoint) {
+			t.Errorf("mount point %q does not contain %q", s.Mountpoint, root)
+		}
+	}
+}
+
+func TestPickMount(t *testing.T) {
+	mounts := []mountInfo{
+		{Mountpoint: "/", Type: "ext4"},
+		{Mountpoint: "/srv/data", Type: "ext4"},
+		{Mountpoint: "/srv/other", Type: "ext4"},
+	}
+	if m, ok := pickMount(mounts, "/srv/data/projects"); !ok || m.Mountpoint != "/srv/data" {
+		t.Errorf("pickMount() = %+v, %v, want /srv/data", m, ok)
+	}
+	if m, ok := pickMount(mounts[1:], "/home"); !ok || m.Mountpoint != "/srv/data" {
+		t.Errorf("pickMount() = %+v, %v, want the first candidate", m, ok)
+	}
+	if _, ok := pickMount(nil, "/home"); ok {
+		t.Error("pickMount(nil) should fail")
+	}
+}
@@ -0,0 +1,108 @@
+package stat
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+)
+
+// defaultWatchlistExtensions lists file extensions appended by known
+// ransomware families to the files they encrypt.
+var defaultWatchlistExtensions = []string{
+	".aes256", ".cerber", ".clop", ".conti", ".crab", ".crypt", ".crypted",
+	".cryptolocker", ".crysis", ".dharma", ".djvu", ".encrypted", ".gandcrab",
+	".hive", ".krab", ".lockbit", ".locked", ".locky", ".makop", ".maze",
+	".onion", ".phobos", ".revil", ".ryk", ".ryuk", ".sodinokibi", ".wallet",
+	".wcry", ".wncry", ".wnry", ".zepto",
+}
+
+// defaultWatchlistMarkers lists file names of ransom notes commonly dropped
+// next to encrypted files.
+var defaultWatchlistMarkers = []string{
+	"!!!readme!!!.txt", "_readme.txt", "decrypt_instructions.html",
+	"decrypt_instructions.txt", "help_decrypt.html", "how_to_decrypt.txt",
+	"how_to_recover_files.txt", "readme-warning.txt", "readme_for_decrypt.txt",
+	"restore_files.txt",
+}
+
+// Watchlist matches file names against known ransomware extensions and
+// ransom note file names. Matching is case-insensitive.
+type Watchlist struct {
+	Extensions []string // Extensions including the leading dot, e.g. ".locky"
+	Markers    []string // Exact base names of ransom notes
+}
+
+// WatchlistMatch records an entry that matched the watchlist.
+type WatchlistMatch struct {
+	Path    string // Full path of the matching entry, including its root
+	Pattern string // Extension or marker name that matched
+}
+
+// DefaultWatchlist returns the built-in ransomware watchlist.
+func DefaultWatchlist() *Watchlist {
+	return &Watchlist{
+		Extensions: append([]string(nil), defaultWatchlistExtensions...),
+		Markers:    append([]string(nil), defaultWatchlistMarkers...),
+	}
+}
+
+// LoadWatchlist reads a watchlist file that replaces the built-in list.
+// Each non-empty line holds one pattern; lines starting with '#' are comments.
+// Patterns starting with '.' are extensions, anything else is a marker file name.
+func LoadWatchlist(filename string) (*Watchlist, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	w := &Watchlist{}
+	scanner := bufio.NewScanner(f)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.ContainsRune(line, '/') {
+			return nil, fmt.Errorf("%s:%d: pattern must not contain '/': %s", filename, lineNo, line)
+		}
+		if strings.HasPrefix(line, ".") {
+			w.Extensions = append(w.Extensions, line)
+		} else {
+			w.Markers = append(w.Markers, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+// Match reports whether name matches the watchlist and returns the
+// matching pattern.
+func (w *Watchlist) Match(name string) (string, bool) {
+	lower := strings.ToLower(name)
+
+	for _, marker := range w.Markers {
+		if lower == strings.ToLower(marker) {
+			return marker, true
+		}
+	}
+
+	ext := path.Ext(lower)
+	if ext == "" {
+		return "", false
+	}
+	for _, e := range w.Extensions {
+		if ext == strings.ToLower(e) {
+			return e, true
+		}
+	}
+
+	return "", false
+}
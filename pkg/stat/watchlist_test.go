@@ -0,0 +1,97 @@
+package stat
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWatchlistMatch(t *testing.T) {
+	w := DefaultWatchlist()
+
+	tests := []struct {
+		name        string
+		wantPattern string
+		wantMatch   bool
+	}{
+		{"budget.xlsx.locky", ".locky", true},
+		{"PHOTO.JPG.WNCRY", ".wncry", true},
+		{"HOW_TO_DECRYPT.txt", "how_to_decrypt.txt", true},
+		{"budget.xlsx", "", false},
+		{"locky", "", false},
+		{"notes.txt", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pattern, ok := w.Match(tt.name)
+			if ok != tt.wantMatch || pattern != tt.wantPattern {
+				t.Errorf("Match(%q) = (%q, %v), want (%q, %v)", tt.name, pattern, ok, tt.wantPattern, tt.wantMatch)
+			}
+		})
+	}
+}
+
+func TestLoadWatchlist(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "watchlist.txt")
+	content := "# custom list\n.evil\n\nPAY_ME.txt\n"
+	if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+		t.Fatalf("write watchlist: %v", err)
+	}
+
+	w, err := LoadWatchlist(filename)
+	if err != nil {
+		t.Fatalf("LoadWatchlist failed: %v", err)
+	}
+	if len(w.Extensions) != 1 || w.Extensions[0] != ".evil" {
+		t.Errorf("extensions: got %v, want [.evil]", w.Extensions)
+	}
+	if len(w.Markers) != 1 || w.Markers[0] != "PAY_ME.txt" {
+		t.Errorf("markers: got %v, want [PAY_ME.txt]", w.Markers)
+	}
+	if _, ok := w.Match("a.locky"); ok {
+		t.Error("custom watchlist should replace the built-in extensions")
+	}
+	if _, ok := w.Match("pay_me.txt"); !ok {
+		t.Error("custom marker should match case-insensitively")
+	}
+
+	if err := os.WriteFile(filename, []byte("bad/pattern\n"), 0644); err != nil {
+		t.Fatalf("write watchlist: %v", err)
+	}
+	if _, err := LoadWatchlist(filename); err == nil {
+		t.Error("expected error for pattern containing '/'")
+	}
+}
+
+func TestWalkWatchlistMatches(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"a.doc.locky", "b.doc", "HOW_TO_DECRYPT.TXT"} {
+		if err := os.WriteFile(filepath.Join(root, name), nil, 0644); err != nil {
+			t.Fatalf("create file: %v", err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(root, "dir.locked"), 0755); err != nil {
+		t.Fatalf("create dir: %v", err)
+	}
+
+	walker := NewStatsWalker([]string{root}, 2, &Filters{})
+	walker.SetWatchlist(DefaultWatchlist())
+	res, err := walker.Walk()
+	if err != nil {
+		t.Fatalf("walk failed: %v", err)
+	}
+
+	want := []WatchlistMatch{
+		{Path: filepath.Join(root, "HOW_TO_DECRYPT.TXT"), Pattern: "how_to_decrypt.txt"},
+		{Path: filepath.Join(root, "a.doc.locky"), Pattern: ".locky"},
+	}
+	if len(res.WatchlistMatches) != len(want) {
+		t.Fatalf("got %v, want %v", res.WatchlistMatches, want)
+	}
+	for i := range want {
+		if res.WatchlistMatches[i] != want[i] {
+			t.Errorf("match[%d] = %+v, want %+v", i, res.WatchlistMatches[i], want[i])
+		}
+	}
+}
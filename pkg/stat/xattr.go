@@ -0,0 +1,118 @@
+package stat
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Names of the extended attributes holding POSIX ACLs on Linux.
+const (
+	aclAccessXattr  = "system.posix_acl_access"
+	aclDefaultXattr = "system.posix_acl_default"
+)
+
+// XattrStat aggregates the extended attributes collected during a walk.
+type XattrStat struct {
+	Entries int64                     // Count of entries with at least one extended attribute
+	Bytes   int64                     // Total size of all extended attribute values
+	ACLs    int64                     // Count of entries with a POSIX ACL
+	ByName  map[string]*XattrNameStat // Attribute name -> stats
+}
+
+// XattrNameStat holds statistics for one extended attribute name.
+type XattrNameStat struct {
+	Name    string // Attribute name, e.g. "user.backup"
+	Entries int64  // Count of entries carrying the attribute
+	Bytes   int64  // Total size of the attribute's values
+}
+
+// XattrMatch selects entries by extended attribute. An entry matches if it
+// has the attribute and, if Value is set, the attribute has that value.
+type XattrMatch struct {
+	Name     string // Attribute name, e.g. "user.backup"
+	Value    string // Required value (ignored unless HasValue is set)
+	HasValue bool   // True if the value must match
+}
+
+// ParseXattrMatch parses an extended attribute filter of the form
+// "name" or "name=value", for example "user.backup=exclude".
+func ParseXattrMatch(s string) (XattrMatch, error) {
+	name, value, hasValue := strings.Cut(s, "=")
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return XattrMatch{}, fmt.Errorf("missing attribute name: %q", s)
+	}
+	return XattrMatch{Name: name, Value: value, HasValue: hasValue}, nil
+}
+
+// Matches reports whether fi carries the attribute, with the required
+// value if one is set.
+func (m XattrMatch) Matches(fi *FileInfo) bool {
+	value, ok := fi.Xattrs[m.Name]
+	if !ok {
+		return false
+	}
+	return !m.HasValue || value == m.Value
+}
+
+// HasACL reports whether the entry carries a POSIX access or default ACL.
+// Only meaningful if extended attributes were collected.
+func (fi *FileInfo) HasACL() bool {
+	_, access := fi.Xattrs[aclAccessXattr]
+	_, def := fi.Xattrs[aclDefaultXattr]
+	return access || def
+}
+
+// add records the extended attributes of an entry.
+func (s *XattrStat) add(xattrs map[string]string, acl bool) {
+	if len(xattrs) == 0 {
+		return
+	}
+	s.Entries++
+	if acl {
+		s.ACLs++
+	}
+	for name, value := range xattrs {
+		ns, ok := s.ByName[name]
+		if !ok {
+			ns = &XattrNameStat{Name: name}
+			s.ByName[name] = ns
+		}
+		ns.Entries++
+		ns.Bytes += int64(len(value))
+		s.Bytes += int64(len(value))
+	}
+}
+
+// merge folds the statistics of other into s.
+func (s *XattrStat) merge(other *XattrStat) {
+	s.Entries += other.Entries
+	s.Bytes += other.Bytes
+	s.ACLs += other.ACLs
+	for name, o := range other.ByName {
+		ns, ok := s.ByName[name]
+		if !ok {
+			ns = &XattrNameStat{Name: name}
+			s.ByName[name] = ns
+		}
+		ns.Entries += o.Entries
+		ns.Bytes += o.Bytes
+	}
+}
+
+// SortedNames returns the per-name statistics ordered by entry count,
+// largest first, then by name.
+func (s *XattrStat) SortedNames() []*XattrNameStat {
+	names := make([]*XattrNameStat, 0, len(s.ByName))
+	for _, ns := range s.ByName {
+		names = append(names, ns)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if names[i].Entries != names[j].Entries {
+			return names[i].Entries > names[j].Entries
+		}
+		return names[i].Name < names[j].Name
+	})
+	return names
+}
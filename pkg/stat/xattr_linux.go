@@ -0,0 +1,51 @@
+package stat
+
+import (
+	"bytes"
+	"errors"
+
+	"golang.org/x/sys/unix"
+)
+
+// readXattrs returns the extended attributes of the entry at path without
+// following symlinks. Returns nil if it has none or they cannot be read.
+func readXattrs(path string) map[string]string {
+	list, err := readXattrBuf(func(dest []byte) (int, error) { return unix.Llistxattr(path, dest) })
+	if err != nil || len(list) == 0 {
+		return nil
+	}
+
+	xattrs := make(map[string]string)
+	for _, name := range bytes.Split(bytes.TrimRight(list, "\x00"), []byte{0}) {
+		value, err := readXattrBuf(func(dest []byte) (int, error) { return unix.Lgetxattr(path, string(name), dest) })
+		if err != nil {
+			continue // removed since listed, or not readable
+		}
+		xattrs[string(name)] = string(value)
+	}
+	return xattrs
+}
+
+// readXattrBuf calls a listxattr or getxattr style function, first to
+// learn the size and then to fill a buffer of that size. Retries if the
+// attributes grow in between.
+func readXattrBuf(call func(dest []byte) (int, error)) ([]byte, error) {
+	for {
+		size, err := call(nil)
+		if err != nil {
+			return nil, err
+		}
+		if size == 0 {
+			return nil, nil
+		}
+		buf := make([]byte, size)
+		n, err := call(buf)
+		if errors.Is(err, unix.ERANGE) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return buf[:n], nil
+	}
+}
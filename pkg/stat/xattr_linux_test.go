@@ -0,0 +1,37 @@
+package stat
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+// TestWalkXattrs verifies that extended attributes are collected and
+// aggregated when enabled.
+func TestWalkXattrs(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, "a")
+	if err := os.WriteFile(path, []byte("data"), 0644); err != nil {
+		t.Fatalf("failed to create file: %v", err)
+	}
+	if err := unix.Setxattr(path, "user.backup", []byte("exclude"), 0); err != nil {
+		t.Skipf("user xattrs not supported: %v", err)
+	}
+
+	m, _ := ParseXattrMatch("user.backup=exclude")
+	walker := NewStatsWalker([]string{root}, 2, &Filters{Xattrs: []XattrMatch{m}})
+	walker.SetXattrs(true)
+	res, err := walker.Walk()
+	if err != nil {
+		t.Fatalf("walk failed: %v", err)
+	}
+
+	if len(res.AllFileInfos) != 1 || res.AllFileInfos[0].Path != "a" {
+		t.Fatalf("filter kept %d entries, want only a", len(res.AllFileInfos))
+	}
+	if ns := res.Xattrs.ByName["user.backup"]; ns == nil || ns.Entries != 1 || ns.Bytes != 7 {
+		t.Errorf("user.backup stats = %+v", ns)
+	}
+}
@@ -0,0 +1,8 @@
+//go:build !linux
+
+package stat
+
+// readXattrs is not supported on this platform.
+func readXattrs(path string) map[string]string {
+	return nil
+}
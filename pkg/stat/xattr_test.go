@@ -0,0 +1,56 @@
+package stat
+
+import "testing"
+
+func TestParseXattrMatch(t *testing.T) {
+	m, err := ParseXattrMatch("user.backup=exclude")
+	if err != nil || m != (XattrMatch{Name: "user.backup", Value: "exclude", HasValue: true}) {
+		t.Errorf("ParseXattrMatch() = %+v, %v", m, err)
+	}
+	m, err = ParseXattrMatch("user.tag")
+	if err != nil || m != (XattrMatch{Name: "user.tag"}) {
+		t.Errorf("ParseXattrMatch() = %+v, %v", m, err)
+	}
+	if _, err := ParseXattrMatch("=x"); err == nil {
+		t.Error("ParseXattrMatch should reject a missing name")
+	}
+}
+
+func TestXattrFilter(t *testing.T) {
+	fi := &FileInfo{Xattrs: map[string]string{"user.backup": "exclude", "user.tag": ""}}
+	tests := []struct {
+		spec string
+		want bool
+	}{
+		{"user.backup=exclude", true},
+		{"user.backup=include", false},
+		{"user.backup", true},
+		{"user.tag=", true},
+		{"user.other", false},
+	}
+	for _, tt := range tests {
+		m, _ := ParseXattrMatch(tt.spec)
+		f := &Filters{Xattrs: []XattrMatch{m}}
+		if got := f.Matches(fi); got != tt.want {
+			t.Errorf("filter %q: got %v, want %v", tt.spec, got, tt.want)
+		}
+	}
+}
+
+func TestXattrStat(t *testing.T) {
+	a := &XattrStat{ByName: map[string]*XattrNameStat{}}
+	a.add(map[string]string{"user.a": "xyz", aclAccessXattr: "acl"}, true)
+	a.add(nil, false)
+
+	b := &XattrStat{ByName: map[string]*XattrNameStat{}}
+	b.add(map[string]string{"user.a": "x"}, false)
+	a.merge(b)
+
+	if a.Entries != 2 || a.ACLs != 1 || a.Bytes != 7 {
+		t.Errorf("got %d entries, %d ACLs, %d bytes", a.Entries, a.ACLs, a.Bytes)
+	}
+	names := a.SortedNames()
+	if len(names) != 2 || names[0].Name != "user.a" || names[0].Entries != 2 || names[0].Bytes != 4 {
+		t.Errorf("unexpected names: %+v", names)
+	}
+}
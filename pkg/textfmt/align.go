@@ -0,0 +1,309 @@
+// Package textfmt formats numbers for display in aligned text columns.
+//
+// It scales a numeric column to the unit of its largest value, aligns the
+// decimal points of all rows and optionally dims rows that are negligible
+// compared to the maximum, so columns of sizes and counts stay easy to scan
+// in terminal tables.
+package textfmt
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Units selects the units sizes are shown in.
+type Units int
+
+const (
+	// UnitsDefault scales sizes by powers of 1024 named KB, MB, ..., as
+	// many traditional tools do.
+	UnitsDefault Units = iota
+	// UnitsBinary scales sizes by powers of 1024 named KiB, MiB, ...
+	UnitsBinary
+	// UnitsSI scales sizes by powers of 1000 named kB, MB, ...
+	UnitsSI
+	// UnitsRaw shows sizes as exact byte counts without a unit.
+	UnitsRaw
+)
+
+// Counts selects how counts are shown.
+type Counts int
+
+const (
+	// CountsPlain shows counts as digits, e.g. 1234567 (the default).
+	CountsPlain Counts = iota
+	// CountsGrouped separates groups of thousands, e.g. 1,234,567.
+	CountsGrouped
+	// CountsCompact scales counts by powers of 1000, e.g. 1.2M.
+	CountsCompact
+)
+
+// Small selects how nonzero values that round to zero at the column's scale
+// are shown.
+type Small int
+
+const (
+	// SmallDigits adds decimals until the first significant digit shows,
+	// so every row remains a parseable number.
+	SmallDigits Small = iota
+	// SmallMarker replaces the value with "<" at the decimal point.
+	SmallMarker
+)
+
+const (
+	// minDecimals is the precision of values below one unit.
+	minDecimals = 2
+
+	// maxDecimals bounds the precision of SmallDigits; one byte in EB
+	// needs 19 decimals.
+	maxDecimals = 20
+
+	dimStart = "\x1b[90m"
+	dimEnd   = "\x1b[0m"
+)
+
+// Options controls how AlignColumn formats a column.
+type Options struct {
+	Bytes          bool    // Scale values to units of bytes (KB, MB, ...) and append the unit
+	Units          Units   // Units of byte values
+	Counts         Counts  // Form of other values
+	Separator      string  // Thousands separator of CountsGrouped; "," if empty
+	SuffixEveryRow bool    // Append the unit to every row instead of only the maximum
+	Small          Small   // How to show values that round to zero
+	DimBelow       float64 // Dim rows below this fraction of the maximum; 0 disables dimming
+	ASCII          bool    // Plain ASCII output: no ANSI escapes and no spaces within numbers
+}
+
+// AlignColumn formats a numeric column with consistent scaling and
+// alignment:
+//   - Uses the scale of the highest value in the column for all rows.
+//   - Aligns decimal points vertically across the column.
+//   - Prints an empty string for zero values.
+//
+// Unless opts.ASCII is set, leading zeros of fractions are replaced by
+// spaces (".06" becomes ". 6") so the significant digits stand out.
+func AlignColumn(values []int64, opts Options) []string {
+	out := make([]string, len(values))
+	if opts.Bytes && opts.Units == UnitsRaw {
+		// Exact byte counts align like plain counts
+		opts.Bytes, opts.Counts = false, CountsPlain
+	}
+
+	maxVal := int64(0)
+	for _, v := range values {
+		if v > maxVal {
+			maxVal = v
+		}
+	}
+	if maxVal == 0 {
+		return out
+	}
+
+	unit, unitSep, factor := "", " ", 1.0
+	if opts.Bytes {
+		unit, factor = byteUnit(maxVal, opts.Units)
+	} else if opts.Counts == CountsCompact {
+		unit, factor = countUnit(maxVal)
+		unitSep = ""
+	}
+	// Only scaled values have decimals; grouped counts may use "." as
+	// thousands separator
+	fractional := opts.Bytes || factor > 1
+	split := func(s string) (string, string) {
+		if !fractional {
+			return s, ""
+		}
+		return splitDecimal(s)
+	}
+
+	// First pass: format scaled numbers to find alignment widths.
+	raw := make([]string, len(values))
+	marked := make([]bool, len(values))
+	maxLeft, maxRight := 0, 0
+	for i, v := range values {
+		if v == 0 {
+			continue
+		}
+		raw[i], marked[i] = formatScaled(float64(v)/factor, fractional, opts)
+		if marked[i] {
+			continue
+		}
+
+		left, right := split(raw[i])
+		maxLeft = max(maxLeft, len(left))
+		maxRight = max(maxRight, len(right))
+	}
+
+	for i, v := range values {
+		if v == 0 {
+			continue
+		}
+
+		var formatted string
+		if marked[i] {
+			// Align "<" where the decimal point would be
+			formatted = strings.Repeat(" ", maxLeft) + "<" + strings.Repeat(" ", maxRight)
+		} else {
+			left, right := split(raw[i])
+			formatted = strings.Repeat(" ", maxLeft-len(left)) + left
+			if maxRight > 0 {
+				formatted += "." + right + strings.Repeat(" ", maxRight-len(right))
+			}
+		}
+
+		if unit != "" && (opts.SuffixEveryRow || v == maxVal) {
+			if marked[i] {
+				formatted += unitSep + strings.Repeat(" ", len(unit))
+			} else {
+				formatted += unitSep + unit
+			}
+		}
+
+		if !opts.ASCII && (marked[i] || float64(v) < float64(maxVal)*opts.DimBelow) {
+			formatted = dimStart + formatted + dimEnd
+		}
+
+		out[i] = formatted
+	}
+
+	return out
+}
+
+// FormatBytes formats a single size in units, with one decimal unless in
+// bytes, e.g. "1.5 GB", "1.5 GiB" or "1.6 GB" for 1610612736 bytes, or the
+// exact byte count with UnitsRaw.
+func FormatBytes(b int64, units Units) string {
+	if units == UnitsRaw {
+		return strconv.FormatInt(b, 10)
+	}
+	unit, factor := byteUnit(b, units)
+	if factor == 1 {
+		return fmt.Sprintf("%d B", b)
+	}
+	return fmt.Sprintf("%.1f %s", float64(b)/factor, unit)
+}
+
+// FormatCount formats a single count as counts selects, e.g. "1234567",
+// "1,234,567" with separator "," or "1.2M".
+func FormatCount(n int64, counts Counts, separator string) string {
+	switch counts {
+	case CountsGrouped:
+		return groupThousands(strconv.FormatInt(n, 10), separator)
+	case CountsCompact:
+		unit, factor := countUnit(n)
+		if factor > 1 {
+			return fmt.Sprintf("%.1f%s", float64(n)/factor, unit)
+		}
+	}
+	return strconv.FormatInt(n, 10)
+}
+
+// groupThousands inserts separator, "," if empty, between the groups of
+// thousands of a formatted integer.
+func groupThousands(digits, separator string) string {
+	if separator == "" {
+		separator = ","
+	}
+	sign := ""
+	if strings.HasPrefix(digits, "-") {
+		sign, digits = "-", digits[1:]
+	}
+	var b strings.Builder
+	for i, d := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteString(separator)
+		}
+		b.WriteRune(d)
+	}
+	return sign + b.String()
+}
+
+// countUnit returns the largest power of 1000 not exceeding maxVal, named
+// k, M, G, ..., and its value; "" and 1 below 1000.
+func countUnit(maxVal int64) (string, float64) {
+	names := []string{"", "k", "M", "G", "T", "P", "E"}
+	idx := 0
+	for maxVal >= 1000 && idx < len(names)-1 {
+		maxVal /= 1000
+		idx++
+	}
+	return names[idx], math.Pow(1000, float64(idx))
+}
+
+// byteUnit returns the largest unit of units not exceeding maxVal and its
+// size in bytes.
+func byteUnit(maxVal int64, units Units) (string, float64) {
+	base, names := int64(1024), []string{"B", "KB", "MB", "GB", "TB", "PB", "EB"}
+	switch units {
+	case UnitsBinary:
+		names = []string{"B", "KiB", "MiB", "GiB", "TiB", "PiB", "EiB"}
+	case UnitsSI:
+		base, names = 1000, []string{"B", "kB", "MB", "GB", "TB", "PB", "EB"}
+	}
+	idx := 0
+	for maxVal >= base && idx < len(names)-1 {
+		maxVal /= base
+		idx++
+	}
+	return names[idx], math.Pow(float64(base), float64(idx))
+}
+
+// formatScaled formats one scaled, nonzero value. It reports whether the
+// value rounds to zero and is to be shown as the SmallMarker.
+func formatScaled(scaled float64, fractional bool, opts Options) (string, bool) {
+	decimals := 0
+	if scaled < 1 {
+		decimals = minDecimals
+	} else if fractional {
+		decimals = 1
+	}
+	if decimals == 0 {
+		return FormatCount(int64(math.Round(scaled)), opts.Counts, opts.Separator), false
+	}
+
+	s := fmt.Sprintf("%.*f", decimals, scaled)
+	for isZero(s) {
+		if opts.Small == SmallMarker || decimals >= maxDecimals {
+			return "<", true
+		}
+		decimals++
+		s = fmt.Sprintf("%.*f", decimals, scaled)
+	}
+
+	if !opts.ASCII && strings.HasPrefix(s, "0.") {
+		s = replaceLeadingFractionZeros(s[1:])
+	}
+	return s, false
+}
+
+// isZero reports whether a formatted decimal is all zeros.
+func isZero(s string) bool {
+	return strings.Trim(s, "0.") == ""
+}
+
+// splitDecimal splits a formatted number at the decimal point.
+func splitDecimal(s string) (string, string) {
+	left, right, _ := strings.Cut(s, ".")
+	return left, right
+}
+
+// replaceLeadingFractionZeros replaces zeros between the decimal point and the
+// first non-zero digit with spaces (e.g., ".06" -> ". 6").
+func replaceLeadingFractionZeros(s string) string {
+	if len(s) < 3 || s[0] != '.' {
+		return s
+	}
+	firstNonZero := -1
+	for i := 1; i < len(s); i++ {
+		if s[i] != '0' {
+			firstNonZero = i
+			break
+		}
+	}
+	if firstNonZero == -1 || firstNonZero == 1 {
+		return s
+	}
+	return "." + strings.Repeat(" ", firstNonZero-1) + s[firstNonZero:]
+}
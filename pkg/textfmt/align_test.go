@@ -0,0 +1,215 @@
+package textfmt
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAlignColumnThreshold(t *testing.T) {
+	tests := []struct {
+		name      string
+		values    []int64
+		shouldHas bool // Whether output should contain "<"
+	}{
+		{
+			name:      "bytes below threshold",
+			values:    []int64{1024 * 1024, 100}, // 1MB, 100B - 100B is 0.00 MB
+			shouldHas: true,
+		},
+		{
+			name:      "all byte values above threshold",
+			values:    []int64{1024 * 1024, 1024 * 1024 / 2}, // 1MB, 0.5MB
+			shouldHas: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := AlignColumn(tt.values, Options{Bytes: true, Small: SmallMarker, DimBelow: 0.001})
+
+			hasLess := false
+			hasDimming := false
+			for _, v := range result {
+				if strings.Contains(v, "<") {
+					hasLess = true
+					if strings.Contains(v, dimStart) {
+						hasDimming = true
+					}
+				}
+			}
+
+			if hasLess != tt.shouldHas {
+				t.Errorf("AlignColumn(%v) has '<'=%v, want %v. Output: %q",
+					tt.values, hasLess, tt.shouldHas, result)
+			}
+			if hasLess && !hasDimming {
+				t.Errorf("AlignColumn(%v) has '<' but not dimmed. Output: %q", tt.values, result)
+			}
+		})
+	}
+}
+
+func TestAlignColumn(t *testing.T) {
+	const mb = 1024 * 1024
+
+	tests := []struct {
+		name   string
+		values []int64
+		opts   Options
+		want   []string
+	}{
+		{
+			name:   "counts",
+			values: []int64{1500, 20, 0},
+			want:   []string{"1500", "  20", ""},
+		},
+		{
+			name:   "suffix on max row only",
+			values: []int64{2 * mb, mb / 2},
+			opts:   Options{Bytes: true},
+			want:   []string{"2.0  MB", " .50"},
+		},
+		{
+			name:   "suffix on every row",
+			values: []int64{2 * mb, mb / 2},
+			opts:   Options{Bytes: true, SuffixEveryRow: true},
+			want:   []string{"2.0  MB", " .50 MB"},
+		},
+		{
+			name:   "small values keep their digits",
+			values: []int64{mb, 100},
+			opts:   Options{Bytes: true, SuffixEveryRow: true, ASCII: true},
+			want:   []string{"1.0    MB", "0.0001 MB"},
+		},
+		{
+			name:   "leading fraction zeros become spaces",
+			values: []int64{2 * mb, 60 * 1024},
+			opts:   Options{Bytes: true, SuffixEveryRow: true},
+			want:   []string{"2.0  MB", " . 6 MB"},
+		},
+		{
+			name:   "ascii keeps leading zeros",
+			values: []int64{2 * mb, 60 * 1024},
+			opts:   Options{Bytes: true, SuffixEveryRow: true, ASCII: true},
+			want:   []string{"2.0  MB", "0.06 MB"},
+		},
+		{
+			name:   "marker keeps suffix width",
+			values: []int64{mb, 100},
+			opts:   Options{Bytes: true, SuffixEveryRow: true, Small: SmallMarker, ASCII: true},
+			want:   []string{"1.0 MB", " <    "},
+		},
+		{
+			name:   "binary units",
+			values: []int64{2 * mb, mb / 2},
+			opts:   Options{Bytes: true, SuffixEveryRow: true, Units: UnitsBinary},
+			want:   []string{"2.0  MiB", " .50 MiB"},
+		},
+		{
+			name:   "si units",
+			values: []int64{2 * mb, 400},
+			opts:   Options{Bytes: true, SuffixEveryRow: true, Units: UnitsSI, ASCII: true},
+			want:   []string{"2.1    MB", "0.0004 MB"},
+		},
+		{
+			name:   "raw units",
+			values: []int64{2 * mb, 500},
+			opts:   Options{Bytes: true, SuffixEveryRow: true, Units: UnitsRaw},
+			want:   []string{"2097152", "    500"},
+		},
+		{
+			name:   "raw units ignore counts",
+			values: []int64{2 * mb},
+			opts:   Options{Bytes: true, Units: UnitsRaw, Counts: CountsCompact},
+			want:   []string{"2097152"},
+		},
+		{
+			name:   "grouped counts",
+			values: []int64{1234567, 20},
+			opts:   Options{Counts: CountsGrouped, Separator: "."},
+			want:   []string{"1.234.567", "       20"},
+		},
+		{
+			name:   "compact counts",
+			values: []int64{1234567, 250000},
+			opts:   Options{Counts: CountsCompact, SuffixEveryRow: true},
+			want:   []string{"1.2 M", " .25M"},
+		},
+		{
+			name:   "dimming",
+			values: []int64{10000, 5},
+			opts:   Options{DimBelow: 0.001},
+			want:   []string{"10000", dimStart + "    5" + dimEnd},
+		},
+		{
+			name:   "ascii disables dimming",
+			values: []int64{10000, 5},
+			opts:   Options{DimBelow: 0.001, ASCII: true},
+			want:   []string{"10000", "    5"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := AlignColumn(tt.values, tt.opts)
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("AlignColumn(%v, %+v) = %q, want %q", tt.values, tt.opts, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := []struct {
+		bytes int64
+		units Units
+		want  string
+	}{
+		{512, UnitsDefault, "512 B"},
+		{1536, UnitsDefault, "1.5 KB"},
+		{1536, UnitsBinary, "1.5 KiB"},
+		{1536, UnitsSI, "1.5 kB"},
+		{1610612736, UnitsBinary, "1.5 GiB"},
+		{1610612736, UnitsSI, "1.6 GB"},
+		{999, UnitsSI, "999 B"},
+		{1610612736, UnitsRaw, "1610612736"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatBytes(tt.bytes, tt.units); got != tt.want {
+			t.Errorf("FormatBytes(%d, %d) = %q, want %q", tt.bytes, tt.units, got, tt.want)
+		}
+	}
+}
+
+func TestFormatCount(t *testing.T) {
+	tests := []struct {
+		n         int64
+		counts    Counts
+		separator string
+		want      string
+	}{
+		{1234567, CountsPlain, "", "1234567"},
+		{1234567, CountsGrouped, "", "1,234,567"},
+		{1234567, CountsGrouped, " ", "1 234 567"},
+		{123456, CountsGrouped, ".", "123.456"},
+		{-1234, CountsGrouped, "", "-1,234"},
+		{999, CountsGrouped, "", "999"},
+		{1234567, CountsCompact, "", "1.2M"},
+		{1500, CountsCompact, "", "1.5k"},
+		{999, CountsCompact, "", "999"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatCount(tt.n, tt.counts, tt.separator); got != tt.want {
+			t.Errorf("FormatCount(%d, %d, %q) = %q, want %q", tt.n, tt.counts, tt.separator, got, tt.want)
+		}
+	}
+}
+
+func TestAlignColumnAllZero(t *testing.T) {
+	got := AlignColumn([]int64{0, 0}, Options{Bytes: true})
+	if len(got) != 2 || got[0] != "" || got[1] != "" {
+		t.Errorf("AlignColumn(zeros) = %q, want two empty strings", got)
+	}
+}
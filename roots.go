@@ -0,0 +1,112 @@
+package cwalk
+
+import (
+	"context"
+	"path"
+	"path/filepath"
+	"sync"
+)
+
+// NewWalkerRoots creates a new Walker for several root paths. Run walks
+// them one after another with the same workers, so the setup of the
+// worker pool is paid once. Callbacks.OnRoot tells which root the
+// relative paths reported next belong to.
+func NewWalkerRoots(roots []string, numWorkers int, callbacks Callbacks) *Walker {
+	w := NewWalker(".", numWorkers, callbacks)
+	w.SetRoots(roots...)
+	return w
+}
+
+// SetRoots replaces the root paths walked by Run, for example to walk
+// other roots after Reset. Roots of a walker created with NewWalkerFS are
+// slash-separated paths inside its filesystem.
+func (c *Walker) SetRoots(roots ...string) {
+	c.roots = make([]string, len(roots))
+	for i, root := range roots {
+		if c.fsys != nil {
+			c.roots[i] = path.Clean(root)
+		} else {
+			c.roots[i] = filepath.Clean(root)
+		}
+	}
+	if len(c.roots) > 0 {
+		c.rootPath = c.roots[0]
+	}
+}
+
+// Reset prepares a finished walker to Run again, keeping its roots,
+// configuration and callbacks. It clears the statistics, the directories
+// set with SetResume and a previous Stop. Reset must not be called while
+// Run is in progress.
+func (c *Walker) Reset() {
+	c.stopMu.Lock()
+	c.cancel()
+	c.monitorCtx, c.cancel = context.WithCancel(context.Background())
+	c.stopMu.Unlock()
+
+	c.workers, c.nextWorkerID, c.workerStats = nil, 0, nil
+	c.active, c.target, c.idle = 0, 0, 0
+	c.pending, c.rootsLeft, c.busy, c.paused = 0, 0, 0, false
+	c.resume = nil
+	c.panics = nil
+	c.ringFallback, c.fdWait = sync.Once{}, sync.Once{}
+
+	c.syscalls.Store(0)
+	c.branchErrors.Store(0)
+	c.queued.Store(0)
+	c.ioOps.Store(0)
+	c.ioNanos.Store(0)
+	c.peakQueue = 0
+}
+
+// startRoot makes the root at index the one being walked and queues it,
+// or the directories to resume for the first root. Later roots are only
+// started once the previous one is done and no checkpoint is being taken.
+func (c *Walker) startRoot(index int) {
+	c.schedMu.Lock()
+	for c.pending > 0 || c.paused {
+		c.schedCond.Wait()
+	}
+	if c.stopped() {
+		// Skip the roots left, so the workers see the walk finished
+		c.rootsLeft = 0
+		c.schedMu.Unlock()
+		c.schedCond.Broadcast()
+		return
+	}
+	c.schedMu.Unlock()
+
+	c.rootPath = c.roots[index]
+	if c.callbacks.OnRoot != nil {
+		c.callbacks.OnRoot(c.rootPath)
+	}
+
+	branches := []*walkBranch{{}}
+	if index == 0 && c.resume != nil {
+		branches = branches[:0]
+		for _, relPath := range c.resume {
+			branches = append(branches, resumeBranch(relPath))
+		}
+	}
+	for _, branch := range branches {
+		branch.subtree = c.newSubtree()
+	}
+
+	// Queue under schedMu, so no worker retires with a branch queued
+	c.schedMu.Lock()
+	workers := c.workerList()
+	for i, branch := range branches {
+		workers[i%len(workers)].queuePush(branch)
+		c.pending++
+		c.peakQueue = max(c.peakQueue, c.queued.Add(1))
+	}
+	c.rootsLeft--
+	c.schedMu.Unlock()
+	c.schedCond.Broadcast()
+}
+
+// finished reports whether the walk of all roots is done. The caller must
+// hold schedMu.
+func (c *Walker) finished() bool {
+	return c.pending == 0 && c.rootsLeft == 0
+}
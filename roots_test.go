@@ -0,0 +1,102 @@
+package cwalk
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+// makeRoots creates n directory trees with a subdirectory and files each
+// and returns their paths.
+func makeRoots(t *testing.T, n int) []string {
+	t.Helper()
+	var roots []string
+	for i := 0; i < n; i++ {
+		root := filepath.Join(t.TempDir(), "root"+strconv.Itoa(i))
+		if err := os.MkdirAll(filepath.Join(root, "sub"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		for j := 0; j <= i; j++ {
+			if err := os.WriteFile(filepath.Join(root, "sub", strconv.Itoa(j)), nil, 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		roots = append(roots, root)
+	}
+	return roots
+}
+
+func TestWalkerRoots(t *testing.T) {
+	roots := makeRoots(t, 3)
+
+	var mu sync.Mutex
+	var order []string
+	current := ""
+	entries := map[string]int{}
+	walker := NewWalkerRoots(roots, 4, Callbacks{
+		OnRoot: func(root string) {
+			order = append(order, root)
+			current = root
+		},
+		OnLstat: func(isDir bool, relPath string, info os.FileInfo, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				t.Errorf("lstat %s: %v", relPath, err)
+			}
+			entries[current]++
+		},
+	})
+	walker.SetAutoWorkers(8)
+	if err := walker.Run(); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	if len(order) != 3 || order[0] != roots[0] || order[1] != roots[1] || order[2] != roots[2] {
+		t.Errorf("OnRoot calls = %v, want %v", order, roots)
+	}
+	for i, root := range roots {
+		// The root, sub and i+1 files
+		if want := i + 3; entries[root] != want {
+			t.Errorf("%s: got %d entries, want %d", root, entries[root], want)
+		}
+	}
+	if stats := walker.Stats(); stats.Dirs != 6 {
+		t.Errorf("Stats().Dirs = %d, want 6", stats.Dirs)
+	}
+}
+
+func TestWalkerReset(t *testing.T) {
+	roots := makeRoots(t, 2)
+
+	var mu sync.Mutex
+	entries := 0
+	walker := NewWalker(roots[0], 2, Callbacks{
+		OnLstat: func(isDir bool, relPath string, info os.FileInfo, err error) {
+			mu.Lock()
+			entries++
+			mu.Unlock()
+		},
+	})
+	if err := walker.Run(); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if entries != 3 {
+		t.Fatalf("first run: got %d entries, want 3", entries)
+	}
+
+	walker.Reset()
+	walker.SetRoots(roots[1])
+	entries = 0
+	if err := walker.Run(); err != nil {
+		t.Fatalf("Run after Reset failed: %v", err)
+	}
+	if entries != 4 {
+		t.Errorf("second run: got %d entries, want 4", entries)
+	}
+	if stats := walker.Stats(); stats.Dirs != 2 || len(stats.Workers) != 2 {
+		t.Errorf("stats after Reset = %d dirs and %d workers, want 2 and 2", stats.Dirs, len(stats.Workers))
+	}
+}
@@ -0,0 +1,86 @@
+package cwalk
+
+import (
+	"sort"
+	"time"
+)
+
+// WalkStats describes how a walk went, for tuning the worker count: a
+// high peak queue depth with busy workers asks for more workers, low
+// utilization for fewer.
+type WalkStats struct {
+	Dirs      int64         // Directories processed, including unreadable ones
+	Syscalls  int64         // stat and readdir calls issued
+	Errors    int64         // Directories that could not be read completely
+	PeakQueue int64         // Most directories queued at once
+	Workers   []WorkerStats // Workers that ran, by ID
+}
+
+// WorkerStats describes the work of a single worker.
+type WorkerStats struct {
+	ID       int           // Worker ID
+	Dirs     int64         // Directories processed
+	Busy     time.Duration // Time spent processing directories
+	Lifetime time.Duration // Time from start to exit; tuned workers may not run the whole walk
+}
+
+// Utilization returns the fraction of its lifetime the worker was busy.
+func (s WorkerStats) Utilization() float64 {
+	if s.Lifetime <= 0 {
+		return 0
+	}
+	return float64(s.Busy) / float64(s.Lifetime)
+}
+
+// Stats returns the statistics of the walk. It is meant to be called once
+// Run has returned.
+func (c *Walker) Stats() WalkStats {
+	c.workerMu.Lock()
+	workers := append([]WorkerStats(nil), c.workerStats...)
+	c.workerMu.Unlock()
+	sort.Slice(workers, func(i, j int) bool { return workers[i].ID < workers[j].ID })
+
+	stats := WalkStats{
+		Syscalls:  c.syscalls.Load(),
+		Errors:    c.branchErrors.Load(),
+		PeakQueue: c.peakQueue,
+		Workers:   workers,
+	}
+	for _, w := range workers {
+		stats.Dirs += w.Dirs
+	}
+	return stats
+}
+
+// Add folds the statistics of another walk, such as that of the next
+// root, into s. Workers with the same ID are summed.
+func (s *WalkStats) Add(other WalkStats) {
+	s.Dirs += other.Dirs
+	s.Syscalls += other.Syscalls
+	s.Errors += other.Errors
+	s.PeakQueue = max(s.PeakQueue, other.PeakQueue)
+	for _, w := range other.Workers {
+		i := sort.Search(len(s.Workers), func(i int) bool { return s.Workers[i].ID >= w.ID })
+		if i < len(s.Workers) && s.Workers[i].ID == w.ID {
+			s.Workers[i].Dirs += w.Dirs
+			s.Workers[i].Busy += w.Busy
+			s.Workers[i].Lifetime += w.Lifetime
+			continue
+		}
+		s.Workers = append(s.Workers, WorkerStats{})
+		copy(s.Workers[i+1:], s.Workers[i:])
+		s.Workers[i] = w
+	}
+}
+
+// recordWorker saves the statistics of a worker when it exits.
+func (c *Walker) recordWorker(worker *walkWorker) {
+	c.workerMu.Lock()
+	c.workerStats = append(c.workerStats, WorkerStats{
+		ID:       worker.id,
+		Dirs:     worker.dirs,
+		Busy:     worker.busy,
+		Lifetime: time.Since(worker.started),
+	})
+	c.workerMu.Unlock()
+}
@@ -0,0 +1,63 @@
+package cwalk
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWalkStats(t *testing.T) {
+	tmpDir := setupTestDir(t)
+
+	walker := NewWalker(tmpDir, 3, Callbacks{})
+	if err := walker.Run(); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	stats := walker.Stats()
+
+	// The root lstat, 4 directory reads and 7 entry stats
+	if stats.Dirs != 4 || stats.Syscalls != 12 || stats.Errors != 0 {
+		t.Errorf("dirs = %d, syscalls = %d, errors = %d, want 4, 12 and 0", stats.Dirs, stats.Syscalls, stats.Errors)
+	}
+	if stats.PeakQueue < 1 || stats.PeakQueue > 4 {
+		t.Errorf("peak queue = %d, want 1 to 4", stats.PeakQueue)
+	}
+	if len(stats.Workers) != 3 {
+		t.Fatalf("got %d workers, want 3", len(stats.Workers))
+	}
+	for i, w := range stats.Workers {
+		if w.ID != i || w.Lifetime <= 0 || w.Busy > w.Lifetime {
+			t.Errorf("worker %d: %+v", i, w)
+		}
+	}
+
+	missing := NewWalker(filepath.Join(tmpDir, "missing"), 1, Callbacks{})
+	missing.SetLogger(&mockLogger{})
+	if err := missing.Run(); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if errs := missing.Stats().Errors; errs != 1 {
+		t.Errorf("errors = %d for a missing root, want 1", errs)
+	}
+}
+
+func TestWalkStatsAdd(t *testing.T) {
+	s := WalkStats{Dirs: 2, PeakQueue: 5, Workers: []WorkerStats{{ID: 0, Dirs: 1}, {ID: 2, Dirs: 1}}}
+	s.Add(WalkStats{Dirs: 3, Syscalls: 7, PeakQueue: 3, Workers: []WorkerStats{{ID: 1, Dirs: 1}, {ID: 2, Dirs: 2, Busy: time.Second}}})
+
+	if s.Dirs != 5 || s.Syscalls != 7 || s.PeakQueue != 5 {
+		t.Errorf("stats = %+v", s)
+	}
+	if len(s.Workers) != 3 || s.Workers[1].ID != 1 || s.Workers[2].Dirs != 3 || s.Workers[2].Busy != time.Second {
+		t.Errorf("workers = %+v, want IDs 0, 1 and 2 with worker 2 summed", s.Workers)
+	}
+}
+
+func TestWorkerUtilization(t *testing.T) {
+	if u := (WorkerStats{Busy: time.Second, Lifetime: 4 * time.Second}).Utilization(); u != 0.25 {
+		t.Errorf("utilization = %v, want 0.25", u)
+	}
+	if u := (WorkerStats{}).Utilization(); u != 0 {
+		t.Errorf("utilization without a lifetime = %v, want 0", u)
+	}
+}
@@ -0,0 +1,62 @@
+package cwalk
+
+import "os"
+
+// StatxMask selects the metadata fields requested from statx(2).
+// The file type is always requested, since the walker needs it to decide
+// whether to descend into an entry. Fields that are not requested are
+// reported as zero.
+type StatxMask uint32
+
+const (
+	// StatxMode requests the permission bits.
+	StatxMode StatxMask = 1 << iota
+	// StatxSize requests the size in bytes and allocated blocks.
+	StatxSize
+	// StatxMtime requests the last modification time.
+	StatxMtime
+	// StatxOwner requests the owning UID and GID.
+	StatxOwner
+	// StatxIno requests the inode number.
+	StatxIno
+	// StatxBtime requests the creation (birth) time, reported by BirthTime.
+	StatxBtime
+
+	// StatxAll requests every field the walker can report.
+	StatxAll = StatxMode | StatxSize | StatxMtime | StatxOwner | StatxIno | StatxBtime
+)
+
+// SetStatx makes the walker use statx(2) with the given field mask instead of
+// lstat(2), and passes AT_STATX_DONT_SYNC so network filesystems (NFS, Lustre)
+// may answer from cached attributes instead of revalidating each entry with
+// the server. This can dramatically speed up scans of cold network trees, at
+// the cost of possibly stale metadata.
+//
+// A zero mask restores the default lstat behavior. On platforms without statx
+// the mask is ignored and lstat is always used. The os.FileInfo passed to
+// callbacks carries a *syscall.Stat_t in Sys() either way.
+func (c *Walker) SetStatx(mask StatxMask) {
+	c.statxMask = mask
+}
+
+// lstat returns file info for path without following symlinks, using statx
+// when enabled.
+func (c *Walker) lstat(path string) (os.FileInfo, error) {
+	if c.fsys != nil {
+		return c.statFS(path)
+	}
+	if c.useStatx() {
+		return statx(path, c.statxFields())
+	}
+	return os.Lstat(path)
+}
+
+// useStatx reports whether metadata is fetched with statx, either because a
+// field mask was set or because a statx-based backend was selected. Walks
+// of an fs.FS never use statx.
+func (c *Walker) useStatx() bool {
+	if c.fsys != nil {
+		return false
+	}
+	return c.statxMask != 0 || c.backend != BackendLstat
+}
@@ -0,0 +1,140 @@
+//go:build linux
+
+package cwalk
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// statxFlags are the flags passed to every statx call: do not follow
+// symlinks, and let network filesystems answer from cached attributes.
+const statxFlags = unix.AT_SYMLINK_NOFOLLOW | unix.AT_STATX_DONT_SYNC
+
+// statx calls statx(2) for path with the requested fields and
+// AT_STATX_DONT_SYNC. It falls back to lstat on kernels without statx.
+func statx(path string, mask StatxMask) (os.FileInfo, error) {
+	var stx unix.Statx_t
+	err := unix.Statx(unix.AT_FDCWD, path, statxFlags, statxRequestMask(mask), &stx)
+	if errors.Is(err, unix.ENOSYS) {
+		return os.Lstat(path)
+	}
+	if err != nil {
+		return nil, &os.PathError{Op: "statx", Path: path, Err: err}
+	}
+
+	return newStatxFileInfo(filepath.Base(path), &stx), nil
+}
+
+// statxRequestMask converts a StatxMask to the STATX_* bits passed to the
+// kernel. The file type is always requested.
+func statxRequestMask(mask StatxMask) int {
+	req := unix.STATX_TYPE
+	if mask&StatxMode != 0 {
+		req |= unix.STATX_MODE
+	}
+	if mask&StatxSize != 0 {
+		req |= unix.STATX_SIZE | unix.STATX_BLOCKS
+	}
+	if mask&StatxMtime != 0 {
+		req |= unix.STATX_MTIME
+	}
+	if mask&StatxOwner != 0 {
+		req |= unix.STATX_UID | unix.STATX_GID
+	}
+	if mask&StatxIno != 0 {
+		req |= unix.STATX_INO
+	}
+	if mask&StatxBtime != 0 {
+		req |= unix.STATX_BTIME
+	}
+	return req
+}
+
+// statxFileInfo implements os.FileInfo on top of a statx result.
+type statxFileInfo struct {
+	name  string
+	mode  os.FileMode
+	sys   syscall.Stat_t
+	btime time.Time // Zero if not reported
+}
+
+// newStatxFileInfo converts a statx result. Only fields reported back in
+// stx.Mask are copied; the rest stay zero.
+func newStatxFileInfo(name string, stx *unix.Statx_t) *statxFileInfo {
+	fi := &statxFileInfo{name: name}
+	st := &fi.sys
+
+	if stx.Mask&unix.STATX_TYPE != 0 {
+		st.Mode |= uint32(stx.Mode) & syscall.S_IFMT
+	}
+	if stx.Mask&unix.STATX_MODE != 0 {
+		st.Mode |= uint32(stx.Mode) &^ syscall.S_IFMT
+	}
+	if stx.Mask&unix.STATX_SIZE != 0 {
+		st.Size = int64(stx.Size)
+	}
+	if stx.Mask&unix.STATX_BLOCKS != 0 {
+		st.Blocks = int64(stx.Blocks)
+	}
+	if stx.Mask&unix.STATX_MTIME != 0 {
+		st.Mtim = syscall.NsecToTimespec(stx.Mtime.Sec*1e9 + int64(stx.Mtime.Nsec))
+	}
+	if stx.Mask&unix.STATX_UID != 0 {
+		st.Uid = stx.Uid
+	}
+	if stx.Mask&unix.STATX_GID != 0 {
+		st.Gid = stx.Gid
+	}
+	if stx.Mask&unix.STATX_INO != 0 {
+		st.Ino = stx.Ino
+	}
+	if stx.Mask&unix.STATX_BTIME != 0 {
+		fi.btime = time.Unix(stx.Btime.Sec, int64(stx.Btime.Nsec))
+	}
+
+	fi.mode = fileModeFromUnix(st.Mode)
+	return fi
+}
+
+func (fi *statxFileInfo) Name() string       { return fi.name }
+func (fi *statxFileInfo) Size() int64        { return fi.sys.Size }
+func (fi *statxFileInfo) Mode() os.FileMode  { return fi.mode }
+func (fi *statxFileInfo) IsDir() bool        { return fi.mode.IsDir() }
+func (fi *statxFileInfo) Sys() interface{}   { return &fi.sys }
+func (fi *statxFileInfo) ModTime() time.Time { return time.Unix(fi.sys.Mtim.Unix()) }
+
+// fileModeFromUnix converts a raw st_mode to an os.FileMode, mirroring the
+// conversion done by os.Lstat.
+func fileModeFromUnix(m uint32) os.FileMode {
+	mode := os.FileMode(m & 0777)
+	switch m & syscall.S_IFMT {
+	case syscall.S_IFBLK:
+		mode |= os.ModeDevice
+	case syscall.S_IFCHR:
+		mode |= os.ModeDevice | os.ModeCharDevice
+	case syscall.S_IFDIR:
+		mode |= os.ModeDir
+	case syscall.S_IFIFO:
+		mode |= os.ModeNamedPipe
+	case syscall.S_IFLNK:
+		mode |= os.ModeSymlink
+	case syscall.S_IFSOCK:
+		mode |= os.ModeSocket
+	}
+	if m&syscall.S_ISGID != 0 {
+		mode |= os.ModeSetgid
+	}
+	if m&syscall.S_ISUID != 0 {
+		mode |= os.ModeSetuid
+	}
+	if m&syscall.S_ISVTX != 0 {
+		mode |= os.ModeSticky
+	}
+	return mode
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package cwalk
+
+import "os"
+
+// statx falls back to lstat on platforms without statx(2).
+func statx(path string, mask StatxMask) (os.FileInfo, error) {
+	return os.Lstat(path)
+}
@@ -0,0 +1,94 @@
+//go:build !windows
+
+package cwalk
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"testing"
+)
+
+// TestStatxMatchesLstat verifies that statx reports the same metadata as
+// lstat for regular files, directories and symlinks when all fields are
+// requested.
+func TestStatxMatchesLstat(t *testing.T) {
+	tmpDir := setupTestDir(t)
+	link := filepath.Join(tmpDir, "link")
+	if err := os.Symlink("file1.txt", link); err != nil {
+		t.Fatalf("failed to create symlink: %v", err)
+	}
+
+	for _, rel := range []string{"file1.txt", "dir1", "link"} {
+		t.Run(rel, func(t *testing.T) {
+			path := filepath.Join(tmpDir, rel)
+			want, err := os.Lstat(path)
+			if err != nil {
+				t.Fatalf("lstat failed: %v", err)
+			}
+			got, err := statx(path, StatxAll)
+			if err != nil {
+				t.Fatalf("statx failed: %v", err)
+			}
+
+			if got.Name() != want.Name() || got.Size() != want.Size() || got.Mode() != want.Mode() ||
+				got.IsDir() != want.IsDir() || !got.ModTime().Equal(want.ModTime()) {
+				t.Errorf("statx = {%s %d %v %v}, lstat = {%s %d %v %v}",
+					got.Name(), got.Size(), got.Mode(), got.ModTime(),
+					want.Name(), want.Size(), want.Mode(), want.ModTime())
+			}
+
+			gotSys, ok1 := got.Sys().(*syscall.Stat_t)
+			wantSys, ok2 := want.Sys().(*syscall.Stat_t)
+			if ok1 && ok2 && (gotSys.Uid != wantSys.Uid || gotSys.Gid != wantSys.Gid || gotSys.Ino != wantSys.Ino) {
+				t.Errorf("Sys() mismatch: uid %d/%d gid %d/%d ino %d/%d",
+					gotSys.Uid, wantSys.Uid, gotSys.Gid, wantSys.Gid, gotSys.Ino, wantSys.Ino)
+			}
+		})
+	}
+}
+
+// TestStatxMissingPath verifies that statx reports errors like lstat does.
+func TestStatxMissingPath(t *testing.T) {
+	_, err := statx(filepath.Join(t.TempDir(), "missing"), StatxAll)
+	if !os.IsNotExist(err) {
+		t.Errorf("expected not-exist error, got %v", err)
+	}
+}
+
+// TestWalkWithStatx verifies that a walk using statx visits the same entries.
+func TestWalkWithStatx(t *testing.T) {
+	tmpDir := setupTestDir(t)
+
+	var mu sync.Mutex
+	var files, dirs int
+	var sizes int64
+	callbacks := Callbacks{
+		OnLstat: func(isDir bool, relPath string, fileInfo os.FileInfo, err error) {
+			if err != nil {
+				t.Errorf("OnLstat got error for %q: %v", relPath, err)
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if fileInfo.IsDir() {
+				dirs++
+			} else {
+				files++
+				sizes += fileInfo.Size()
+			}
+		},
+	}
+
+	walker := NewWalker(tmpDir, 2, callbacks)
+	walker.SetStatx(StatxSize)
+	if err := walker.Run(); err != nil {
+		t.Fatalf("Walk failed: %v", err)
+	}
+
+	// Root plus 3 directories, 4 files of 8 bytes each
+	if dirs != 4 || files != 4 || sizes != 32 {
+		t.Errorf("got %d dirs, %d files, %d bytes; want 4, 4, 32", dirs, files, sizes)
+	}
+}
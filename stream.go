@@ -0,0 +1,59 @@
+package cwalk
+
+import "context"
+
+// defaultStreamBuffer is the number of entries the workers may produce
+// ahead of the consumer of Entries or Stream.
+const defaultStreamBuffer = 256
+
+// SetStreamBuffer sets the number of entries the workers may produce ahead
+// of the consumer of Entries or Stream before they wait. 0 hands each
+// entry over directly; the default is 256.
+func (c *Walker) SetStreamBuffer(n int) {
+	c.streamBuffer = max(n, 0)
+}
+
+// Stream runs the walk in the background and sends its entries on the
+// returned entry channel, for pipelines composed with select and
+// cancellation instead of callbacks. Entries are those passed to OnEntry;
+// an entry whose metadata could not be fetched, or a directory that could
+// not be read, carries the error in Entry.Err. The workers wait while the
+// channel is full (see SetStreamBuffer).
+//
+// The entry channel is closed when the walk ends. The error channel then
+// receives the error of the walk, if any, and is closed as well.
+// Cancelling ctx stops the walk; its error is reported on the error
+// channel. Stop ends the stream as well, with context.Canceled.
+// Callbacks set on the walker are still called. As with Run, the walker
+// walks once; call Reset to stream again.
+func (c *Walker) Stream(ctx context.Context) (<-chan Entry, <-chan error) {
+	entries := make(chan Entry, c.streamBuffer)
+	errc := make(chan error, 1)
+	c.runs.Add(1)
+	go func() {
+		defer c.runs.Done()
+		defer close(errc)
+		stop := context.AfterFunc(ctx, c.Stop)
+		defer stop()
+
+		stopped := c.monitorCtx.Done()
+		err := c.walkEntries(func(e Entry) bool {
+			select {
+			case entries <- e:
+				return true
+			case <-ctx.Done():
+				return false
+			case <-stopped:
+				return false
+			}
+		})
+		close(entries)
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		}
+		if err != nil {
+			errc <- err
+		}
+	}()
+	return entries, errc
+}
@@ -0,0 +1,79 @@
+package cwalk
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestStream(t *testing.T) {
+	tmpDir := t.TempDir()
+	for i := 0; i < 10; i++ {
+		dir := filepath.Join(tmpDir, strconv.Itoa(i))
+		if err := os.Mkdir(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		for j := 0; j < 50; j++ {
+			if err := os.WriteFile(filepath.Join(dir, strconv.Itoa(j)), nil, 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	walker := NewWalker(tmpDir, 4, Callbacks{})
+	walker.SetStreamBuffer(0)
+	entries, errc := walker.Stream(context.Background())
+	seen := map[string]bool{}
+	for e := range entries {
+		if e.Err != nil {
+			t.Fatalf("%s: %v", e.RelPath, e.Err)
+		}
+		if seen[e.RelPath] {
+			t.Errorf("%q sent twice", e.RelPath)
+		}
+		seen[e.RelPath] = true
+	}
+	if err := <-errc; err != nil {
+		t.Fatal(err)
+	}
+	if len(seen) != 511 {
+		t.Errorf("sent %d entries, want 511", len(seen))
+	}
+
+	// Cancelling the context stops the walk
+	walker.Reset()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	entries, errc = walker.Stream(ctx)
+	n := 0
+	for range entries {
+		n++
+		if n == 20 {
+			cancel()
+		}
+	}
+	if err := <-errc; !errors.Is(err, context.Canceled) {
+		t.Errorf("error = %v, want context.Canceled", err)
+	}
+	if stats := walker.Stats(); stats.Dirs == 11 {
+		t.Error("all directories read after cancelling")
+	}
+
+	// A missing root is sent as an entry with the lstat error
+	missing := filepath.Join(tmpDir, "missing")
+	walker = NewWalker(missing, 2, Callbacks{})
+	entries, errc = walker.Stream(context.Background())
+	n = 0
+	for e := range entries {
+		n++
+		if e.Err == nil || e.AbsPath != missing {
+			t.Errorf("got %+v; want the lstat error of the root", e)
+		}
+	}
+	if err := <-errc; err != nil || n != 1 {
+		t.Errorf("sent %d entries and error %v, want 1 entry and no error", n, err)
+	}
+}
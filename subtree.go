@@ -0,0 +1,99 @@
+package cwalk
+
+import (
+	"os"
+	"sync"
+)
+
+// DirTotals summarizes the subtree below a directory, as passed to
+// Callbacks.OnDirectoryDone.
+type DirTotals struct {
+	Entries int64 // Entries below the directory at any depth, not ignored
+	Dirs    int64 // Directories among them
+	Files   int64 // Non-directories among them
+	Size    int64 // Sum of the lstat sizes of the entries
+	Errors  int64 // Directories in the subtree, itself included, not read completely
+}
+
+// add counts an entry with the given lstat info.
+func (t *DirTotals) add(info os.FileInfo) {
+	t.Entries++
+	t.Size += info.Size()
+	if info.IsDir() {
+		t.Dirs++
+	} else {
+		t.Files++
+	}
+}
+
+// merge adds the totals in other to t.
+func (t *DirTotals) merge(other DirTotals) {
+	t.Entries += other.Entries
+	t.Dirs += other.Dirs
+	t.Files += other.Files
+	t.Size += other.Size
+	t.Errors += other.Errors
+}
+
+// subtree tracks the totals of a directory until its subtree is done.
+type subtree struct {
+	mu     sync.Mutex
+	totals DirTotals
+	open   int // The directory itself and its subdirectories not done yet
+}
+
+// newSubtree returns the subtree state of a new branch, or nil if
+// subtrees are not tracked because OnDirectoryDone is not set.
+func (c *Walker) newSubtree() *subtree {
+	if c.callbacks.OnDirectoryDone == nil {
+		return nil
+	}
+	return &subtree{open: 1}
+}
+
+// openSubdir counts a subdirectory of the branch that was queued.
+func (cb *walkBranch) openSubdir() {
+	if s := cb.subtree; s != nil {
+		s.mu.Lock()
+		s.open++
+		s.mu.Unlock()
+	}
+}
+
+// addTotals adds totals of the branch's own entries.
+func (cb *walkBranch) addTotals(totals DirTotals) {
+	if s := cb.subtree; s != nil {
+		s.mu.Lock()
+		s.totals.merge(totals)
+		s.mu.Unlock()
+	}
+}
+
+// finishSubtree marks the branch as processed, with own counting an error
+// reading it. Each directory whose subtree is done is reported through
+// OnDirectoryDone and its totals are added to its parent, so directories
+// are reported after all their subdirectories. Ancestors of resumed
+// directories are not tracked and not reported.
+func (c *Walker) finishSubtree(branch *walkBranch, own DirTotals) {
+	for b := branch; b != nil && b.subtree != nil; b = b.parent {
+		s := b.subtree
+		s.mu.Lock()
+		s.totals.merge(own)
+		s.open--
+		done, totals := s.open == 0, s.totals
+		s.mu.Unlock()
+		if !done {
+			return
+		}
+		err := c.guard(b, func() error {
+			c.callbacks.OnDirectoryDone(b.relPath(), totals)
+			return nil
+		})
+		if err != nil {
+			// Ancestors are still completed
+			c.branchErrors.Add(1)
+			c.log().Error("processing failed", "path", b.absPath(c), "err", err)
+		}
+		own = totals
+	}
+}
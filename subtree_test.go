@@ -0,0 +1,104 @@
+package cwalk
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestDirectoryDone(t *testing.T) {
+	tmpDir := t.TempDir()
+	files := map[string]int{
+		"a/1":      10,
+		"a/b/2":    20,
+		"a/b/c/3":  30,
+		"d/4":      40,
+		"top":      5,
+		"skip/big": 1000,
+	}
+	for name, size := range files {
+		path := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, make([]byte, size), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(tmpDir, "empty"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	done := map[string]DirTotals{}
+	var order []string
+	walker := NewWalker(tmpDir, 4, Callbacks{
+		OnDirectoryDone: func(relPath string, totals DirTotals) {
+			mu.Lock()
+			defer mu.Unlock()
+			if _, ok := done[relPath]; ok {
+				t.Errorf("%q reported twice", relPath)
+			}
+			done[relPath] = totals
+			order = append(order, relPath)
+		},
+	})
+	walker.SetIgnoreNames([]string{"skip"})
+	if err := walker.Run(); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	if len(done) != 6 {
+		t.Fatalf("reported %v, want the root, a, a/b, a/b/c, d and empty", order)
+	}
+	if order[len(order)-1] != "" {
+		t.Errorf("root reported before %q", order[len(order)-1])
+	}
+	position := map[string]int{}
+	for i, relPath := range order {
+		position[relPath] = i
+	}
+	for child, parent := range map[string]string{"a/b/c": "a/b", "a/b": "a", "a": ""} {
+		if position[child] > position[parent] {
+			t.Errorf("%q reported after its parent %q", child, parent)
+		}
+	}
+
+	if got := done["empty"]; got != (DirTotals{}) {
+		t.Errorf("empty: totals = %+v, want zero", got)
+	}
+	if got := done["a/b"]; got.Entries != 3 || got.Dirs != 1 || got.Files != 2 {
+		t.Errorf("a/b: totals = %+v, want 3 entries, 1 dir and 2 files", got)
+	}
+	root := done[""]
+	if root.Files != 5 || root.Dirs != 5 || root.Entries != 10 || root.Errors != 0 {
+		t.Errorf("root: totals = %+v, want 5 files, 5 dirs and no errors", root)
+	}
+	var fileSizes int64
+	for name, size := range files {
+		if name != "skip/big" {
+			fileSizes += int64(size)
+		}
+	}
+	if root.Size < fileSizes {
+		t.Errorf("root: size = %d, want at least the %d bytes of the files", root.Size, fileSizes)
+	}
+	if got := done["a/b/c"].Size; got != 30 {
+		t.Errorf("a/b/c: size = %d, want 30", got)
+	}
+}
+
+func TestDirectoryDoneErrors(t *testing.T) {
+	tmpDir := t.TempDir()
+	var errs int64 = -1
+	walker := NewWalker(filepath.Join(tmpDir, "missing"), 2, Callbacks{
+		OnDirectoryDone: func(relPath string, totals DirTotals) {
+			errs = totals.Errors
+		},
+	})
+	walker.Run()
+	if errs != 1 {
+		t.Errorf("missing root: errors = %d, want 1", errs)
+	}
+}
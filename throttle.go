@@ -0,0 +1,117 @@
+package cwalk
+
+import (
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	// statCost approximates the metadata bytes returned by one stat call
+	// (the size of struct stat on 64-bit Linux).
+	statCost = 144
+
+	// direntCost approximates the fixed size of one directory entry
+	// returned by getdents, excluding the name.
+	direntCost = 24
+
+	// throttleBurst is how many seconds worth of tokens may be spent at
+	// once after an idle period.
+	throttleBurst = 0.1
+)
+
+// Throttle limits how hard a walk hits the filesystem, so scans of
+// production file servers do not starve other clients. Zero fields are
+// unlimited.
+type Throttle struct {
+	OpsPerSec   float64 // Max stat and readdir calls per second
+	BytesPerSec float64 // Max metadata bytes per second (stat results and directory entries)
+}
+
+// SetThrottle paces stat and readdir calls across all workers with token
+// buckets. Metadata bytes are estimated: each stat call counts as the size
+// of a stat result, and each directory read as the size of its entries,
+// charged once the read returns. A zero Throttle disables pacing.
+func (c *Walker) SetThrottle(t Throttle) {
+	c.opsBucket = newTokenBucket(t.OpsPerSec)
+	c.bytesBucket = newTokenBucket(t.BytesPerSec)
+}
+
+// throttle waits until ops stat or readdir calls may be issued.
+func (c *Walker) throttle(ops int) {
+	c.wait(c.opsBucket.take(float64(ops)))
+	c.wait(c.bytesBucket.take(float64(ops * statCost)))
+}
+
+// chargeReadDir accounts for the bytes returned by a directory read and
+// waits if they exceed the byte budget.
+func (c *Walker) chargeReadDir(entries []os.DirEntry) {
+	if c.bytesBucket == nil {
+		return
+	}
+	n := 0
+	for _, entry := range entries {
+		n += direntCost + len(entry.Name())
+	}
+	c.wait(c.bytesBucket.take(float64(n)))
+}
+
+// wait sleeps for d, returning early if the walk is stopped.
+func (c *Walker) wait(d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-c.monitorCtx.Done():
+	}
+}
+
+// tokenBucket is a token bucket rate limiter that allows its balance to go
+// negative, so costs only known after a call can be charged afterwards.
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64 // Tokens added per second
+	burst  float64 // Maximum balance
+	tokens float64
+	last   time.Time
+}
+
+// newTokenBucket returns a bucket refilling at rate tokens per second, or
+// nil if rate is not positive. A nil bucket never delays.
+func newTokenBucket(rate float64) *tokenBucket {
+	if rate <= 0 {
+		return nil
+	}
+	burst := rate * throttleBurst
+	if burst < 1 {
+		burst = 1
+	}
+	return &tokenBucket{rate: rate, burst: burst, tokens: burst, last: time.Now()}
+}
+
+// take removes n tokens and returns how long the caller must wait until
+// the balance is no longer negative.
+func (b *tokenBucket) take(n float64) time.Duration {
+	if b == nil {
+		return 0
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+
+	b.tokens -= n
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
@@ -0,0 +1,59 @@
+package cwalk
+
+import (
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestTokenBucket verifies burst handling and the wait for an overdrawn
+// balance.
+func TestTokenBucket(t *testing.T) {
+	if newTokenBucket(0) != nil {
+		t.Fatal("expected nil bucket for zero rate")
+	}
+	var none *tokenBucket
+	if d := none.take(1e9); d != 0 {
+		t.Errorf("nil bucket wait = %v, want 0", d)
+	}
+
+	b := newTokenBucket(100)
+	if d := b.take(10); d != 0 {
+		t.Errorf("wait within burst = %v, want 0", d)
+	}
+	d := b.take(5)
+	if d < 40*time.Millisecond || d > 60*time.Millisecond {
+		t.Errorf("wait after burst = %v, want about 50ms", d)
+	}
+}
+
+// TestWalkWithThrottle verifies that a throttled walk visits every entry
+// and is paced to the configured rate.
+func TestWalkWithThrottle(t *testing.T) {
+	tmpDir := setupTestDir(t)
+
+	var entries int64
+	callbacks := Callbacks{
+		OnLstat: func(isDir bool, relPath string, fileInfo os.FileInfo, err error) {
+			atomic.AddInt64(&entries, 1)
+		},
+	}
+
+	// The root lstat, 4 directory reads and 7 entry stats are 12 calls.
+	// With a burst of 10, at least 2 calls wait 10ms each.
+	walker := NewWalker(tmpDir, 2, callbacks)
+	walker.SetThrottle(Throttle{OpsPerSec: 100, BytesPerSec: 1 << 30})
+	start := time.Now()
+	if err := walker.Run(); err != nil {
+		t.Fatalf("Walk failed: %v", err)
+	}
+	elapsed := time.Since(start)
+
+	if entries != 8 {
+		t.Errorf("visited %d entries, want 8", entries)
+	}
+	if elapsed < 15*time.Millisecond {
+		t.Errorf("throttled walk took %v, want at least 15ms", elapsed)
+	}
+}